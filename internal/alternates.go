@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/helper/mount"
+	"github.com/go-git/go-billy/v5/helper/polyfill"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/cache"
+	"github.com/go-git/go-git/v5/storage/filesystem"
+	"github.com/go-git/go-git/v5/storage/filesystem/dotgit"
+)
+
+// maxAlternateDepth is the nesting limit git applies to the alternates of alternates
+const maxAlternateDepth = 5
+
+// alternateStorage reads the objects missing from a repository from the object directories listed in
+// objects/info/alternates, as left by 'git clone --shared' or pooled storage on a forge.
+// go-git resolves those paths inside the .git directory and only for some lookups, so absolute paths
+// outside it and relative paths with ".." fail with "object not found".
+type alternateStorage struct {
+	*filesystem.Storage
+	alternates []*filesystem.ObjectStorage
+}
+
+// EncodedObject reads the object from the repository, then from each alternate in order
+func (s *alternateStorage) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	obj, err := s.Storage.EncodedObject(t, h)
+	for _, alternate := range s.alternates {
+		if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			break
+		}
+		obj, err = alternate.EncodedObject(t, h)
+	}
+	return obj, err
+}
+
+// HasEncodedObject returns nil if the repository or one of its alternates holds the object
+func (s *alternateStorage) HasEncodedObject(h plumbing.Hash) error {
+	err := s.Storage.HasEncodedObject(h)
+	for _, alternate := range s.alternates {
+		if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			break
+		}
+		err = alternate.HasEncodedObject(h)
+	}
+	return err
+}
+
+// EncodedObjectSize returns the size of the object from the repository or the first alternate holding it
+func (s *alternateStorage) EncodedObjectSize(h plumbing.Hash) (int64, error) {
+	size, err := s.Storage.EncodedObjectSize(h)
+	for _, alternate := range s.alternates {
+		if !errors.Is(err, plumbing.ErrObjectNotFound) {
+			break
+		}
+		size, err = alternate.EncodedObjectSize(h)
+	}
+	return size, err
+}
+
+// HashesWithPrefix resolves abbreviated hashes against the repository and all of its alternates
+func (s *alternateStorage) HashesWithPrefix(prefix []byte) ([]plumbing.Hash, error) {
+	hashes, err := s.Storage.HashesWithPrefix(prefix)
+	if err != nil {
+		return nil, err
+	}
+	for _, alternate := range s.alternates {
+		found, err := alternate.HashesWithPrefix(prefix)
+		if err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, found...)
+	}
+	return hashes, nil
+}
+
+// withAlternates reopens a repository on an alternateStorage when it lists alternate object directories.
+// Directories that do not exist are skipped, as git does; 'check' reports them.
+func withAlternates(repo *git.Repository) (*git.Repository, error) {
+	storage, ok := repo.Storer.(*filesystem.Storage)
+	if !ok {
+		return repo, nil
+	}
+
+	directories, _, err := alternateObjectDirectories(objectsDirectory(storage))
+	if err != nil {
+		return nil, err
+	}
+	if len(directories) == 0 {
+		return repo, nil
+	}
+
+	alternate := &alternateStorage{Storage: storage}
+	for _, directory := range directories {
+		// DotGit expects the objects below a .git directory; mount the alternate where it looks for them
+		objectsFs := polyfill.New(mount.New(memfs.New(), "objects", osfs.New(directory)))
+		alternate.alternates = append(alternate.alternates, filesystem.NewObjectStorage(dotgit.New(objectsFs), cache.NewObjectLRUDefault()))
+	}
+
+	var worktree billy.Filesystem
+	if wt, err := repo.Worktree(); err == nil {
+		worktree = wt.Filesystem
+	}
+	return git.Open(alternate, worktree)
+}
+
+// repositoryStorage returns the filesystem storage of a repository opened by openRepository
+func repositoryStorage(repo *git.Repository) (*filesystem.Storage, bool) {
+	switch storage := repo.Storer.(type) {
+	case *filesystem.Storage:
+		return storage, true
+	case *alternateStorage:
+		return storage.Storage, true
+	}
+	return nil, false
+}
+
+// objectsDirectory returns the absolute objects directory of a repository.
+// A linked worktree names the common directory holding its objects in its commondir file.
+func objectsDirectory(storage *filesystem.Storage) string {
+	gitDir := storage.Filesystem().Root()
+	if content, err := os.ReadFile(filepath.Join(gitDir, "commondir")); err == nil {
+		commonDir := strings.TrimSpace(string(content))
+		if !filepath.IsAbs(commonDir) {
+			commonDir = filepath.Join(gitDir, commonDir)
+		}
+		gitDir = commonDir
+	}
+	return filepath.Join(gitDir, "objects")
+}
+
+// alternateObjectDirectories returns the object directories listed in objects/info/alternates, each followed
+// by its own alternates up to git's nesting limit. Relative entries are resolved against the objects directory
+// that lists them. Entries whose directory does not exist are returned as missing.
+func alternateObjectDirectories(objectsDir string) (found []string, missing []string, err error) {
+	seen := map[string]bool{filepath.Clean(objectsDir): true}
+
+	var read func(dir string, depth int) error
+	read = func(dir string, depth int) error {
+		content, err := os.ReadFile(filepath.Join(dir, "info", "alternates"))
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read alternates of %s: %w", dir, err)
+		}
+
+		for _, line := range strings.Split(string(content), "\n") {
+			entry := strings.TrimSpace(line)
+			if entry == "" || strings.HasPrefix(entry, "#") {
+				continue
+			}
+			// git quotes paths with unusual characters C-style
+			if strings.HasPrefix(entry, `"`) {
+				if unquoted, err := strconv.Unquote(entry); err == nil {
+					entry = unquoted
+				}
+			}
+			if !filepath.IsAbs(entry) {
+				entry = filepath.Join(dir, entry)
+			}
+			entry = filepath.Clean(entry)
+			if seen[entry] {
+				continue
+			}
+			seen[entry] = true
+
+			if info, err := os.Stat(entry); err != nil || !info.IsDir() {
+				missing = append(missing, entry)
+				continue
+			}
+			found = append(found, entry)
+			if depth < maxAlternateDepth {
+				if err := read(entry, depth+1); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	err = read(objectsDir, 0)
+	return found, missing, err
+}
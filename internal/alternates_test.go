@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// sharedClone clones the fixture with 'git clone --shared', so the clone holds refs but no objects of its own
+func sharedClone(t *testing.T, source string) string {
+	t.Helper()
+	dir := filepath.Join(t.TempDir(), "shared")
+	cmd := exec.Command("git", "clone", "--quiet", "--shared", "--bare", source, dir)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git clone --shared failed: %v\n%s", err, out)
+	}
+	return dir
+}
+
+// TestOpenRepository_Alternates tests that objects are read from absolute and relative alternate directories
+// by both the go-git and the git binary code paths
+func TestOpenRepository_Alternates(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	sourceObjects := filepath.Join(fixture.Path(), ".git", "objects")
+
+	tests := []struct {
+		name      string
+		alternate func(clone string) string
+	}{
+		{name: "Absolute path", alternate: func(clone string) string { return sourceObjects }},
+		{name: "Relative path", alternate: func(clone string) string {
+			relative, err := filepath.Rel(filepath.Join(clone, "objects"), sourceObjects)
+			if err != nil {
+				t.Fatalf("filepath.Rel() error = %v", err)
+			}
+			return relative
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clone := sharedClone(t, fixture.Path())
+			alternates := "# pooled objects\n" + tt.alternate(clone) + "\n"
+			if err := os.WriteFile(filepath.Join(clone, "objects", "info", "alternates"), []byte(alternates), 0o644); err != nil {
+				t.Fatalf("Failed to write alternates: %v", err)
+			}
+
+			repo, err := NewGitRepository(clone)
+			if err != nil {
+				t.Fatalf("NewGitRepository() error = %v", err)
+			}
+			ref := fixture.Reference("v1.1.0")
+			set, err := repo.GetCommitSetForTag(context.Background(), ref)
+			if err != nil {
+				t.Fatalf("GetCommitSetForTag() error = %v", err)
+			}
+			if len(set) != 3 {
+				t.Errorf("GetCommitSetForTag() = %d commits, want 3", len(set))
+			}
+			files, err := repo.GetFileHashes(context.Background(), ref)
+			if err != nil {
+				t.Fatalf("GetFileHashes() error = %v", err)
+			}
+			if len(files) != 3 {
+				t.Errorf("GetFileHashes() = %v, want 3 files", files)
+			}
+
+			diff, err := repo.GetDiffBetweenTags(context.Background(), fixture.Reference("v1.0.0"), ref, nil)
+			if err != nil {
+				t.Fatalf("GetDiffBetweenTags() error = %v", err)
+			}
+			if !strings.Contains(diff, "src/api/b.go") {
+				t.Errorf("GetDiffBetweenTags() = %q, want the added file", diff)
+			}
+		})
+	}
+}
+
+// TestAlternateObjectDirectories tests nested, duplicate, and missing alternate entries
+func TestAlternateObjectDirectories(t *testing.T) {
+	root := t.TempDir()
+	writeAlternates := func(objectsDir string, entries ...string) {
+		t.Helper()
+		if err := os.MkdirAll(filepath.Join(objectsDir, "info"), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		content := strings.Join(entries, "\n") + "\n"
+		if err := os.WriteFile(filepath.Join(objectsDir, "info", "alternates"), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	repoObjects := filepath.Join(root, "repo", "objects")
+	poolObjects := filepath.Join(root, "pool", "objects")
+	baseObjects := filepath.Join(root, "base", "objects")
+	writeAlternates(repoObjects, poolObjects, "", "# comment", "../../missing/objects", poolObjects)
+	writeAlternates(poolObjects, "../../base/objects")
+	if err := os.MkdirAll(baseObjects, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	found, missing, err := alternateObjectDirectories(repoObjects)
+	if err != nil {
+		t.Fatalf("alternateObjectDirectories() error = %v", err)
+	}
+	if want := []string{poolObjects, baseObjects}; !reflect.DeepEqual(found, want) {
+		t.Errorf("found = %v, want %v", found, want)
+	}
+	if want := []string{filepath.Join(root, "missing", "objects")}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+}
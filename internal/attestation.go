@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+var (
+	ErrWriteAttestation = errors.New("failed to write attestation")
+	ErrLoadSigningKey   = errors.New("failed to load attestation signing key")
+)
+
+const (
+	// inTotoStatementType is the _type of an in-toto v1 statement
+	inTotoStatementType = "https://in-toto.io/Statement/v1"
+	// ComparisonPredicateType identifies the predicate of comparison attestations
+	ComparisonPredicateType = "https://github.com/byron1st/git-tag-similarity/attestation/comparison/v1"
+	// inTotoPayloadType is the DSSE payload type of an in-toto statement
+	inTotoPayloadType = "application/vnd.in-toto+json"
+)
+
+// Statement is an in-toto v1 statement asserting the result of a comparison about the tagged commits
+type Statement struct {
+	Type          string               `json:"_type"`
+	Subject       []ResourceDescriptor `json:"subject"`
+	PredicateType string               `json:"predicateType"`
+	Predicate     ComparisonPredicate  `json:"predicate"`
+}
+
+// ResourceDescriptor names an attested artifact and its digests
+type ResourceDescriptor struct {
+	Name   string            `json:"name"`
+	Digest map[string]string `json:"digest"`
+}
+
+// ComparisonPredicate records the inputs and results of a comparison
+type ComparisonPredicate struct {
+	Tool         AttestedTool     `json:"tool"`
+	Repository   *RepoFingerprint `json:"repository,omitempty"`
+	Tag1         AttestedTag      `json:"tag1"`
+	Tag2         AttestedTag      `json:"tag2"`
+	Directory    string           `json:"directory,omitempty"`
+	Profile      string           `json:"profile,omitempty"`
+	FirstParent  bool             `json:"first_parent,omitempty"`
+	Baseline     []string         `json:"exclude_reachable_from,omitempty"`
+	AuthorFilter string           `json:"author_filter,omitempty"`
+	DateRange    string           `json:"date_range,omitempty"`
+	Merges       string           `json:"merges,omitempty"`
+	Similarity   float64          `json:"similarity"`
+	Approximate  bool             `json:"approximate,omitempty"` // Unreadable commits were skipped
+	Shared       int              `json:"shared_commits"`
+	OnlyInTag1   int              `json:"only_in_tag1"`
+	OnlyInTag2   int              `json:"only_in_tag2"`
+	GeneratedAt  time.Time        `json:"generated_at"`
+
+	Policy   *PolicyResult         `json:"policy,omitempty"`
+	Archives []ArchiveVerification `json:"archives,omitempty"`
+	Warnings []string              `json:"warnings,omitempty"`
+}
+
+// AttestedTool identifies the build of the tool that produced the attestation
+type AttestedTool struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// AttestedTag is a compared tag and the commit it pointed to
+type AttestedTag struct {
+	Name   string `json:"name"`
+	Commit string `json:"commit"`
+}
+
+// Envelope is a DSSE envelope carrying a signed statement, as verified by 'cosign verify-blob-attestation'
+type Envelope struct {
+	PayloadType string              `json:"payloadType"`
+	Payload     string              `json:"payload"` // Base64 of the statement
+	Signatures  []EnvelopeSignature `json:"signatures"`
+}
+
+// EnvelopeSignature is a signature over the DSSE pre-authentication encoding of the payload
+type EnvelopeSignature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"` // Base64 of the signature
+}
+
+// NewAttestation builds the in-toto statement of a comparison. The subjects are the commits of both tags.
+func NewAttestation(ctx context.Context, result CompareResult) (Statement, error) {
+	predicate := ComparisonPredicate{
+		Tool:         AttestedTool{Name: "git-tag-similarity", Version: ToolVersion()},
+		Tag1:         AttestedTag{Name: result.Config.Tag1Name, Commit: result.Tag1Audit.Commit},
+		Tag2:         AttestedTag{Name: result.Config.Tag2Name, Commit: result.Tag2Audit.Commit},
+		Directory:    result.Config.directoryFilter().String(),
+		Profile:      string(result.Config.Profile),
+		FirstParent:  result.Config.FirstParent,
+		Baseline:     result.Config.Baseline,
+		AuthorFilter: result.Config.Authors.String(),
+		DateRange:    result.Config.Dates.String(),
+		Merges:       mergeModeField(result.Config.Merges),
+		Similarity:   result.Similarity,
+		Approximate:  len(result.UnreadableCommits) > 0,
+		Shared:       len(result.SharedCommits),
+		OnlyInTag1:   len(result.OnlyInTag1),
+		OnlyInTag2:   len(result.OnlyInTag2),
+		GeneratedAt:  time.Now().UTC(),
+		Policy:       result.Policy,
+		Archives:     result.Archives,
+		Warnings:     result.Warnings,
+	}
+
+	fingerprint, err := FingerprintRepository(ctx, result.Repo)
+	if err != nil {
+		return Statement{}, errors.Join(ErrWriteAttestation, err)
+	}
+	if fingerprint.ID != "" {
+		predicate.Repository = &fingerprint
+	}
+
+	statement := Statement{
+		Type:          inTotoStatementType,
+		PredicateType: ComparisonPredicateType,
+		Predicate:     predicate,
+	}
+	for _, tag := range []AttestedTag{predicate.Tag1, predicate.Tag2} {
+		statement.Subject = append(statement.Subject, ResourceDescriptor{Name: tag.Name, Digest: map[string]string{"gitCommit": tag.Commit}})
+	}
+	return statement, nil
+}
+
+// LoadSigningKey reads an unencrypted ECDSA or Ed25519 private key in PEM format,
+// e.g. created with 'openssl genpkey -algorithm ed25519' or 'openssl ecparam -name prime256v1 -genkey'
+func LoadSigningKey(path string) (crypto.Signer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.Join(ErrLoadSigningKey, err)
+	}
+
+	// Skip the EC PARAMETERS block openssl ecparam writes before the key
+	for block, rest := pem.Decode(data); block != nil; block, rest = pem.Decode(rest) {
+		switch block.Type {
+		case "EC PRIVATE KEY":
+			key, err := x509.ParseECPrivateKey(block.Bytes)
+			if err != nil {
+				return nil, errors.Join(ErrLoadSigningKey, err)
+			}
+			return key, nil
+		case "PRIVATE KEY":
+			key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+			if err != nil {
+				return nil, errors.Join(ErrLoadSigningKey, err)
+			}
+			switch key := key.(type) {
+			case *ecdsa.PrivateKey:
+				return key, nil
+			case ed25519.PrivateKey:
+				return key, nil
+			default:
+				return nil, errors.Join(ErrLoadSigningKey, fmt.Errorf("unsupported key type %T; use an ECDSA or Ed25519 key", key))
+			}
+		case "ENCRYPTED PRIVATE KEY", "ENCRYPTED SIGSTORE PRIVATE KEY", "ENCRYPTED COSIGN PRIVATE KEY":
+			return nil, errors.Join(ErrLoadSigningKey, fmt.Errorf("%s is encrypted; export an unencrypted PEM key", path))
+		}
+	}
+	return nil, errors.Join(ErrLoadSigningKey, fmt.Errorf("no private key found in %s", path))
+}
+
+// SignStatement wraps a statement in a DSSE envelope signed by key
+func SignStatement(statement Statement, key crypto.Signer) (Envelope, error) {
+	payload, err := json.Marshal(statement)
+	if err != nil {
+		return Envelope{}, errors.Join(ErrWriteAttestation, err)
+	}
+
+	message := dssePAE(inTotoPayloadType, payload)
+	var sig []byte
+	if _, ok := key.(ed25519.PrivateKey); ok {
+		// Ed25519 signs the message itself
+		sig, err = key.Sign(rand.Reader, message, crypto.Hash(0))
+	} else {
+		digest := sha256.Sum256(message)
+		sig, err = key.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	if err != nil {
+		return Envelope{}, errors.Join(ErrWriteAttestation, err)
+	}
+
+	return Envelope{
+		PayloadType: inTotoPayloadType,
+		Payload:     base64.StdEncoding.EncodeToString(payload),
+		Signatures:  []EnvelopeSignature{{Sig: base64.StdEncoding.EncodeToString(sig)}},
+	}, nil
+}
+
+// dssePAE returns the DSSE pre-authentication encoding of a payload, which is what gets signed
+func dssePAE(payloadType string, payload []byte) []byte {
+	return fmt.Appendf(nil, "DSSEv1 %d %s %d %s", len(payloadType), payloadType, len(payload), payload)
+}
+
+// WriteAttestation writes the attestation of a comparison to the configured path:
+// a DSSE envelope when a signing key is configured, otherwise the bare in-toto statement
+func WriteAttestation(ctx context.Context, result CompareResult) error {
+	statement, err := NewAttestation(ctx, result)
+	if err != nil {
+		return err
+	}
+
+	var document any = statement
+	if result.Config.AttestationKey != "" {
+		key, err := LoadSigningKey(result.Config.AttestationKey)
+		if err != nil {
+			return err
+		}
+		if document, err = SignStatement(statement, key); err != nil {
+			return err
+		}
+	}
+
+	data, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return errors.Join(ErrWriteAttestation, err)
+	}
+	if err := os.WriteFile(result.Config.AttestationPath, append(data, '\n'), 0644); err != nil {
+		return errors.Join(ErrWriteAttestation, err)
+	}
+	return nil
+}
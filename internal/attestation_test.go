@@ -0,0 +1,154 @@
+package internal
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeKey writes a private key as a PEM file and returns its path
+func writeKey(t *testing.T, blockType string, der []byte) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "key.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0600); err != nil {
+		t.Fatalf("Failed to write key: %v", err)
+	}
+	return path
+}
+
+// TestWriteAttestation tests the statement written for a comparison of the fixture tags
+func TestWriteAttestation(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	path := filepath.Join(t.TempDir(), "comparison.intoto.json")
+
+	result, err := Compare(CompareConfig{
+		TagOptions:      TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		AttestationPath: path,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if err := WriteAttestation(context.Background(), result); err != nil {
+		t.Fatalf("WriteAttestation() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Failed to read attestation: %v", err)
+	}
+	var statement Statement
+	if err := json.Unmarshal(data, &statement); err != nil {
+		t.Fatalf("Failed to parse attestation: %v", err)
+	}
+
+	if statement.Type != inTotoStatementType || statement.PredicateType != ComparisonPredicateType {
+		t.Errorf("statement types = %s, %s", statement.Type, statement.PredicateType)
+	}
+	if len(statement.Subject) != 2 || statement.Subject[1].Digest["gitCommit"] != fixture.Hash("v1.1.0").String() {
+		t.Errorf("subjects = %+v, want the commits of v1.0.0 and v1.1.0", statement.Subject)
+	}
+	predicate := statement.Predicate
+	if predicate.Shared != 1 || predicate.OnlyInTag1 != 0 || predicate.OnlyInTag2 != 2 {
+		t.Errorf("commit counts = %d shared, %d, %d unique, want 1, 0, 2", predicate.Shared, predicate.OnlyInTag1, predicate.OnlyInTag2)
+	}
+	if predicate.Repository == nil || predicate.Repository.RootCommit != fixture.Hash("v1.0.0").String() {
+		t.Errorf("repository = %+v, want the fingerprint of the fixture", predicate.Repository)
+	}
+	if predicate.Tool.Version == "" {
+		t.Errorf("tool version is empty")
+	}
+}
+
+// TestSignStatement tests that envelopes verify against the public key over the DSSE encoding
+func TestSignStatement(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	ecDER, err := x509.MarshalECPrivateKey(ecKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+	edPublic, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("Failed to generate key: %v", err)
+	}
+	edDER, err := x509.MarshalPKCS8PrivateKey(edKey)
+	if err != nil {
+		t.Fatalf("Failed to marshal key: %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		verify func(message []byte, sig []byte) bool
+	}{
+		{
+			name: "ecdsa",
+			path: writeKey(t, "EC PRIVATE KEY", ecDER),
+			verify: func(message []byte, sig []byte) bool {
+				digest := sha256.Sum256(message)
+				return ecdsa.VerifyASN1(&ecKey.PublicKey, digest[:], sig)
+			},
+		},
+		{
+			name:   "ed25519",
+			path:   writeKey(t, "PRIVATE KEY", edDER),
+			verify: func(message []byte, sig []byte) bool { return ed25519.Verify(edPublic, message, sig) },
+		},
+	}
+
+	statement := Statement{Type: inTotoStatementType, PredicateType: ComparisonPredicateType}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			key, err := LoadSigningKey(tt.path)
+			if err != nil {
+				t.Fatalf("LoadSigningKey() error = %v", err)
+			}
+			envelope, err := SignStatement(statement, key)
+			if err != nil {
+				t.Fatalf("SignStatement() error = %v", err)
+			}
+
+			payload, _ := base64.StdEncoding.DecodeString(envelope.Payload)
+			sig, _ := base64.StdEncoding.DecodeString(envelope.Signatures[0].Sig)
+			if !tt.verify(dssePAE(envelope.PayloadType, payload), sig) {
+				t.Errorf("signature does not verify")
+			}
+			if tt.verify(dssePAE(envelope.PayloadType, append(payload, ' ')), sig) {
+				t.Errorf("signature verifies a modified payload")
+			}
+		})
+	}
+}
+
+// TestLoadSigningKey_Unsupported tests rejecting keys that cannot sign attestations
+func TestLoadSigningKey_Unsupported(t *testing.T) {
+	tests := []struct {
+		name      string
+		blockType string
+	}{
+		{name: "encrypted", blockType: "ENCRYPTED SIGSTORE PRIVATE KEY"},
+		{name: "certificate only", blockType: "CERTIFICATE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadSigningKey(writeKey(t, tt.blockType, []byte("data")))
+			if !errors.Is(err, ErrLoadSigningKey) {
+				t.Errorf("LoadSigningKey() error = %v, want ErrLoadSigningKey", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var ErrInvalidAuthorFilter = errors.New("invalid author filter")
+
+// AuthorFilter limits the commit sets to commits of some authors or committers, e.g. -author '@example.com$'.
+// Each pattern is an exact email address, compared case-insensitively, or a regular expression
+// matched against "Name <email>" as git log --author does.
+type AuthorFilter struct {
+	Authors    []string // A commit matches if its author matches any of these; empty matches every author
+	Committers []string // A commit matches if its committer matches any of these; empty matches every committer
+}
+
+// patternList is a flag.Value that collects the values of a repeated flag
+type patternList []string
+
+func (l *patternList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *patternList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// registerFlags adds the repeatable -author and -committer flags to a command
+func (f *AuthorFilter) registerFlags(flags *flag.FlagSet) {
+	flags.Var((*patternList)(&f.Authors), "author", "Only count commits whose author has this `email` or matches this regex against 'Name <email>'; may be repeated")
+	flags.Var((*patternList)(&f.Committers), "committer", "Only count commits whose committer has this `email` or matches this regex against 'Name <email>'; may be repeated")
+}
+
+// IsZero reports whether the filter keeps every commit
+func (f AuthorFilter) IsZero() bool {
+	return len(f.Authors) == 0 && len(f.Committers) == 0
+}
+
+// Validate checks that every pattern is an email address or a valid regular expression
+func (f AuthorFilter) Validate() error {
+	_, err := f.compile()
+	return err
+}
+
+// String describes the filter for output and saved results, e.g. "author a@example.com, committer /bot/"
+func (f AuthorFilter) String() string {
+	var parts []string
+	for _, list := range []struct {
+		role     string
+		patterns []string
+	}{{"author", f.Authors}, {"committer", f.Committers}} {
+		for _, pattern := range list.patterns {
+			if isEmailPattern(pattern) {
+				parts = append(parts, list.role+" "+pattern)
+			} else {
+				parts = append(parts, list.role+" /"+pattern+"/")
+			}
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// personMatcher reports whether a commit signature matches one pattern
+type personMatcher func(signature object.Signature) bool
+
+// compiledAuthorFilter holds the matchers of each role; an empty list matches every commit
+type compiledAuthorFilter struct {
+	authors    []personMatcher
+	committers []personMatcher
+}
+
+func (f AuthorFilter) compile() (compiledAuthorFilter, error) {
+	var compiled compiledAuthorFilter
+	for _, list := range []struct {
+		patterns []string
+		target   *[]personMatcher
+	}{{f.Authors, &compiled.authors}, {f.Committers, &compiled.committers}} {
+		for _, pattern := range list.patterns {
+			matcher, err := compilePersonPattern(pattern)
+			if err != nil {
+				return compiled, err
+			}
+			*list.target = append(*list.target, matcher)
+		}
+	}
+	return compiled, nil
+}
+
+// isEmailPattern reports whether a pattern is a plain email address rather than a regular expression
+func isEmailPattern(pattern string) bool {
+	return strings.Contains(pattern, "@") && !strings.ContainsAny(pattern, `^$*+?()[]{}|\ <>`)
+}
+
+func compilePersonPattern(pattern string) (personMatcher, error) {
+	if pattern == "" {
+		return nil, errors.Join(ErrInvalidAuthorFilter, fmt.Errorf("empty pattern"))
+	}
+	if isEmailPattern(pattern) {
+		return func(signature object.Signature) bool {
+			return strings.EqualFold(signature.Email, pattern)
+		}, nil
+	}
+
+	expression, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, errors.Join(ErrInvalidAuthorFilter, err)
+	}
+	return func(signature object.Signature) bool {
+		return expression.MatchString(signature.Name + " <" + signature.Email + ">")
+	}, nil
+}
+
+// matchAny reports whether the signature matches one of the matchers, or whether there are none
+func matchAny(matchers []personMatcher, signature object.Signature) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	for _, matcher := range matchers {
+		if matcher(signature) {
+			return true
+		}
+	}
+	return false
+}
+
+// Apply returns the commits of the set whose author and committer both pass the filter
+func (f AuthorFilter) Apply(repo Repository, set map[plumbing.Hash]struct{}) (map[plumbing.Hash]struct{}, error) {
+	if f.IsZero() {
+		return set, nil
+	}
+	compiled, err := f.compile()
+	if err != nil {
+		return nil, err
+	}
+
+	hashes := hashesOf(set)
+	commits, err := repo.GetCommitObjects(hashes)
+	if err != nil {
+		return nil, errors.Join(ErrGetCommits, err)
+	}
+
+	filtered := make(map[plumbing.Hash]struct{})
+	for i, commit := range commits {
+		if matchAny(compiled.authors, commit.Author) && matchAny(compiled.committers, commit.Committer) {
+			filtered[hashes[i]] = struct{}{}
+		}
+	}
+	return filtered, nil
+}
@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// TestCompilePersonPattern tests email and regular expression patterns against commit signatures
+func TestCompilePersonPattern(t *testing.T) {
+	alice := object.Signature{Name: "Alice Smith", Email: "Alice@Example.com"}
+
+	tests := []struct {
+		name      string
+		pattern   string
+		want      bool
+		wantError error
+	}{
+		{name: "Exact email ignores case", pattern: "alice@example.com", want: true},
+		{name: "Exact email does not match a prefix", pattern: "ice@example.com", want: false},
+		{name: "Dots in an email are literal", pattern: "alice@example.org", want: false},
+		{name: "Regex against name and email", pattern: "^Alice ", want: true},
+		{name: "Regex on the email domain", pattern: "@Example\\.com>$", want: true},
+		{name: "Regex is case-sensitive", pattern: "^alice", want: false},
+		{name: "Invalid regex", pattern: "(alice", wantError: ErrInvalidAuthorFilter},
+		{name: "Empty pattern", pattern: "", wantError: ErrInvalidAuthorFilter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matcher, err := compilePersonPattern(tt.pattern)
+			if tt.wantError != nil {
+				if !errors.Is(err, tt.wantError) {
+					t.Errorf("compilePersonPattern(%q) error = %v, want %v", tt.pattern, err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("compilePersonPattern(%q) error = %v", tt.pattern, err)
+			}
+			if got := matcher(alice); got != tt.want {
+				t.Errorf("compilePersonPattern(%q) matches %v = %v, want %v", tt.pattern, alice, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestAuthorFilterString tests the description of a filter in output and saved results
+func TestAuthorFilterString(t *testing.T) {
+	filter := AuthorFilter{Authors: []string{"a@example.com", "^Bob"}, Committers: []string{"bot"}}
+	if got, want := filter.String(), "author a@example.com, author /^Bob/, committer /bot/"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
+
+// TestAuthorFilterApply tests that only commits of matching authors and committers are kept
+func TestAuthorFilterApply(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Author("Alice", "alice@team.example.com").
+		Commit("Add a", testutil.File("a.go", "package a\n")).
+		Author("Bob", "bob@other.example.com").
+		Commit("Add b", testutil.File("b.go", "package b\n")).
+		Author("Carol", "carol@team.example.com").
+		Commit("Add c", testutil.File("c.go", "package c\n")).
+		Tag("v1.0.0")
+	repo := openFixture(t, fixture)
+
+	set, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.0.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() error = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter AuthorFilter
+		want   []string
+	}{
+		{name: "No filter", filter: AuthorFilter{}, want: []string{"v1.0.0~2", "v1.0.0~1", "v1.0.0"}},
+		{name: "Author domain", filter: AuthorFilter{Authors: []string{"@team\\.example\\.com>$"}}, want: []string{"v1.0.0~2", "v1.0.0"}},
+		{name: "Repeated authors match any", filter: AuthorFilter{Authors: []string{"bob@other.example.com", "^Carol"}}, want: []string{"v1.0.0~1", "v1.0.0"}},
+		{name: "Author and committer both match", filter: AuthorFilter{Authors: []string{"^Alice"}, Committers: []string{"^Bob"}}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Apply(repo, set)
+			if err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+			want := make(map[plumbing.Hash]struct{})
+			for _, revision := range tt.want {
+				want[fixture.Hash(revision)] = struct{}{}
+			}
+			if !reflect.DeepEqual(got, want) && !(len(got) == 0 && len(want) == 0) {
+				t.Errorf("Apply() = %v, want %v", got, want)
+			}
+		})
+	}
+}
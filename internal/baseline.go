@@ -0,0 +1,64 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrExcludeReachable = errors.New("failed to exclude reachable commits")
+
+// BaselineExclusion removes the commits reachable from some refs, e.g. the previous LTS tag, from both
+// commit sets, as ^<ref> does in git log, so the comparison covers only what is new since that baseline
+// while the compared tags stay the same.
+type BaselineExclusion []string
+
+// registerFlags adds the repeatable -exclude-reachable-from flag to a command
+func (e *BaselineExclusion) registerFlags(flags *flag.FlagSet) {
+	flags.Var((*patternList)(e), "exclude-reachable-from", "Leave out the commits reachable from this `ref` (tag, branch, or commit) from both tags; may be repeated")
+}
+
+// IsZero reports whether no commits are excluded
+func (e BaselineExclusion) IsZero() bool {
+	return len(e) == 0
+}
+
+// String lists the baseline refs for output, e.g. "v1.0.0, release/1.x"
+func (e BaselineExclusion) String() string {
+	return strings.Join(e, ", ")
+}
+
+// Resolve returns the commits reachable from any of the baseline refs.
+// Refs are resolved like the compared tags: tags first, then branches, commits, and revisions.
+func (e BaselineExclusion) Resolve(ctx context.Context, repo Repository) (map[plumbing.Hash]struct{}, error) {
+	excluded := make(map[plumbing.Hash]struct{})
+	for _, name := range e {
+		ref, err := findReference(ctx, repo, name)
+		if err != nil {
+			return nil, errors.Join(ErrExcludeReachable, err)
+		}
+		err = repo.ForEachCommitInTag(ctx, ref, func(hash plumbing.Hash) error {
+			excluded[hash] = struct{}{}
+			return nil
+		})
+		if err != nil {
+			return nil, errors.Join(ErrExcludeReachable, fmt.Errorf("commits of %s", name), err)
+		}
+	}
+	return excluded, nil
+}
+
+// withoutCommits returns the commits of the set that are not excluded
+func withoutCommits(set map[plumbing.Hash]struct{}, excluded map[plumbing.Hash]struct{}) map[plumbing.Hash]struct{} {
+	kept := make(map[plumbing.Hash]struct{}, len(set))
+	for hash := range set {
+		if _, ok := excluded[hash]; !ok {
+			kept[hash] = struct{}{}
+		}
+	}
+	return kept
+}
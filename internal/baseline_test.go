@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestCompare_Baseline tests that commits reachable from the baseline refs are left out of both tags
+func TestCompare_Baseline(t *testing.T) {
+	// lts is an old release both tags build on; x is shared beyond it, y and z are unique
+	fixture := testutil.NewRepo(t).
+		Commit("first", testutil.File("a.go", "package a\n")).
+		Commit("second", testutil.File("b.go", "package b\n")).
+		Tag("lts").
+		Commit("x", testutil.File("x.go", "package x\n")).
+		Branch("release").
+		Checkout("release").
+		Commit("y", testutil.File("y.go", "package y\n")).
+		Tag("v1.1.0").
+		Checkout("main").
+		Commit("z", testutil.File("z.go", "package z\n")).
+		Tag("v2.0.0")
+
+	tests := []struct {
+		name       string
+		baseline   BaselineExclusion
+		similarity float64
+		shared     int
+		warnings   []string
+		wantErr    error
+	}{
+		{name: "No baseline", similarity: 3.0 / 5.0, shared: 3},
+		{name: "Baseline tag", baseline: BaselineExclusion{"lts"}, similarity: 1.0 / 3.0, shared: 1},
+		{name: "Baseline revision", baseline: BaselineExclusion{"lts~1", "lts"}, similarity: 1.0 / 3.0, shared: 1},
+		{
+			name:       "Baseline covering a tag",
+			baseline:   BaselineExclusion{"v2.0.0"},
+			similarity: 0,
+			warnings:   []string{"every commit of v2.0.0 is reachable from v2.0.0"},
+		},
+		{name: "Unknown ref", baseline: BaselineExclusion{"v9.9.9"}, wantErr: ErrExcludeReachable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.1.0", Tag2Name: "v2.0.0"},
+				Baseline:   tt.baseline,
+				SetsOnly:   true,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("Compare() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if result.Similarity != tt.similarity || len(result.SharedCommits) != tt.shared {
+				t.Errorf("Compare() similarity = %v, shared = %d, want %v, %d", result.Similarity, len(result.SharedCommits), tt.similarity, tt.shared)
+			}
+			if !reflect.DeepEqual(result.Warnings, tt.warnings) {
+				t.Errorf("Warnings = %q, want %q", result.Warnings, tt.warnings)
+			}
+		})
+	}
+}
+
+// TestBaselineFlag tests that -exclude-reachable-from may be repeated
+func TestBaselineFlag(t *testing.T) {
+	config, err := NewCompareConfig([]string{"-repo", ".", "-tag1", "a", "-tag2", "b", "-exclude-reachable-from", "v1.0.0", "-exclude-reachable-from", "lts"})
+	if err != nil {
+		t.Fatalf("NewCompareConfig() error = %v", err)
+	}
+	if want := (BaselineExclusion{"v1.0.0", "lts"}); !reflect.DeepEqual(config.Baseline, want) {
+		t.Errorf("Baseline = %v, want %v", config.Baseline, want)
+	}
+	if got := config.Baseline.String(); got != "v1.0.0, lts" {
+		t.Errorf("String() = %q, want %q", got, "v1.0.0, lts")
+	}
+}
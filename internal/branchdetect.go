@@ -0,0 +1,114 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrDetectBranch = errors.New("failed to detect tag branch")
+
+// TagBranch is the branch a tag was most likely cut from
+type TagBranch struct {
+	Branch string // Branch name without the remote prefix; empty when no branch contains the tag
+	Reason string // Why the branch was chosen, e.g. "release branch for 1.2"
+}
+
+// String formats the branch for console output
+func (b TagBranch) String() string {
+	if b.Branch == "" {
+		return "unknown"
+	}
+	return b.Branch
+}
+
+// DetectTagBranch guesses the branch a tag was cut from.
+// Branches that have the tag commit on their first-parent line are preferred over branches that only
+// merged it; among those, a release branch named after the tag's major.minor version wins, then the
+// default branch, then the alphabetically first branch.
+func DetectTagBranch(ctx context.Context, repo Repository, tag *plumbing.Reference) (TagBranch, error) {
+	commit, err := repo.GetTagCommit(tag)
+	if err != nil {
+		return TagBranch{}, errors.Join(ErrDetectBranch, err)
+	}
+
+	refs, err := repo.GetBranchesContaining(ctx, commit.Hash)
+	if err != nil {
+		return TagBranch{}, errors.Join(ErrDetectBranch, err)
+	}
+	if len(refs) == 0 {
+		return TagBranch{}, nil
+	}
+
+	// Local and remote-tracking branches of the same name count once
+	tips := make(map[string]plumbing.Hash)
+	for _, ref := range refs {
+		name := branchName(ref.Name())
+		if _, ok := tips[name]; !ok || ref.Name().IsBranch() {
+			tips[name] = ref.Hash()
+		}
+	}
+
+	var onFirstParent []string
+	for name, tip := range tips {
+		ok, err := repo.IsFirstParentAncestor(ctx, commit.Hash, tip)
+		if err != nil {
+			return TagBranch{}, errors.Join(ErrDetectBranch, err)
+		}
+		if ok {
+			onFirstParent = append(onFirstParent, name)
+		}
+	}
+
+	candidates := onFirstParent
+	scope := "first-parent history"
+	if len(candidates) == 0 {
+		for name := range tips {
+			candidates = append(candidates, name)
+		}
+		scope = "merged"
+	}
+	sort.Strings(candidates)
+
+	if version, ok := parseSemver(tag.Name().Short()); ok {
+		pattern := releaseBranchPattern(version)
+		for _, name := range candidates {
+			if pattern.MatchString(name) {
+				return TagBranch{Branch: name, Reason: fmt.Sprintf("release branch for %d.%d", version.major, version.minor)}, nil
+			}
+		}
+	}
+
+	if defaultRef, err := repo.GetBranchReference(""); err == nil {
+		defaultName := branchName(defaultRef.Name())
+		for _, name := range candidates {
+			if name == defaultName {
+				return TagBranch{Branch: name, Reason: "default branch, " + scope}, nil
+			}
+		}
+	}
+
+	if len(candidates) == 1 {
+		return TagBranch{Branch: candidates[0], Reason: "only branch, " + scope}, nil
+	}
+	return TagBranch{Branch: candidates[0], Reason: fmt.Sprintf("one of %d branches, %s", len(candidates), scope)}, nil
+}
+
+// branchName returns a branch name without refs/heads/ or the refs/remotes/<remote>/ prefix
+func branchName(name plumbing.ReferenceName) string {
+	if name.IsRemote() {
+		_, branch, _ := strings.Cut(name.Short(), "/")
+		return branch
+	}
+	return name.Short()
+}
+
+// releaseBranchPattern matches branch names such as release/1.2, release-v1.2, stable/1.2.x, or 1.2.x
+func releaseBranchPattern(version semver) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`(^|[/_-])v?%d\.%d(\.x)?$`, version.major, version.minor))
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestDetectTagBranch tests how the branch a tag was cut from is chosen
+func TestDetectTagBranch(t *testing.T) {
+	commit := plumbing.NewHash("0000000000000000000000000000000000000001")
+	branch := func(name string, hash string) *plumbing.Reference {
+		return plumbing.NewReferenceFromStrings(name, hash)
+	}
+	mainRef := branch("refs/heads/main", "00000000000000000000000000000000000000aa")
+	originMain := branch("refs/remotes/origin/main", "00000000000000000000000000000000000000ab")
+	release := branch("refs/remotes/origin/release/1.2", "00000000000000000000000000000000000000bb")
+	feature := branch("refs/heads/feature", "00000000000000000000000000000000000000cc")
+
+	tests := []struct {
+		name        string
+		tag         string
+		containing  []*plumbing.Reference
+		firstParent map[plumbing.Hash]bool
+		want        string
+	}{
+		{
+			name:        "Release branch named after the version",
+			tag:         "v1.2.3",
+			containing:  []*plumbing.Reference{mainRef, release},
+			firstParent: map[plumbing.Hash]bool{mainRef.Hash(): true, release.Hash(): true},
+			want:        "release/1.2",
+		},
+		{
+			name:        "Default branch over branches created later",
+			tag:         "v1.3.0",
+			containing:  []*plumbing.Reference{feature, mainRef, originMain, release},
+			firstParent: map[plumbing.Hash]bool{mainRef.Hash(): true, feature.Hash(): true},
+			want:        "main",
+		},
+		{
+			name:        "Branch that has the tag on its first-parent line",
+			tag:         "v1.2.4",
+			containing:  []*plumbing.Reference{mainRef, feature},
+			firstParent: map[plumbing.Hash]bool{feature.Hash(): true},
+			want:        "feature",
+		},
+		{
+			name:       "Only merged into branches",
+			tag:        "nightly",
+			containing: []*plumbing.Reference{mainRef, feature},
+			want:       "main",
+		},
+		{
+			name: "No branch contains the tag",
+			tag:  "v0.1.0",
+			want: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tag := plumbing.NewReferenceFromStrings("refs/tags/"+tt.tag, commit.String())
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetTagCommit(tag).Return(&object.Commit{Hash: commit}, nil)
+			mockRepo.EXPECT().GetBranchesContaining(gomock.Any(), commit).Return(tt.containing, nil)
+			mockRepo.EXPECT().IsFirstParentAncestor(gomock.Any(), commit, gomock.Any()).DoAndReturn(func(_ context.Context, ancestor plumbing.Hash, descendant plumbing.Hash) (bool, error) {
+				return tt.firstParent[descendant], nil
+			}).AnyTimes()
+			mockRepo.EXPECT().GetBranchReference("").Return(mainRef, nil).AnyTimes()
+
+			got, err := DetectTagBranch(context.Background(), mockRepo, tag)
+			if err != nil {
+				t.Fatalf("DetectTagBranch() error = %v, want nil", err)
+			}
+			if got.Branch != tt.want {
+				t.Errorf("DetectTagBranch() = %q (%s), want %q", got.Branch, got.Reason, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrInvalidDepth = errors.New("invalid breakdown depth")
+
+// rootPathLabel is the breakdown label for files at the repository root
+const rootPathLabel = "./"
+
+// BuildPathBreakdown aggregates per-file commits into directories truncated at the given depth.
+// A commit touching several files under the same directory is counted once for that directory.
+// Rows are ordered from least to most similar, then by path.
+func BuildPathBreakdown(result CompareResult, fileCommits map[string][]plumbing.Hash, depth int) []FileSimilarity {
+	dirCommits := make(map[string][]plumbing.Hash)
+	for path, commits := range fileCommits {
+		dir := pathAtDepth(path, depth)
+		dirCommits[dir] = append(dirCommits[dir], commits...)
+	}
+
+	// Per-directory aggregation is the same computation as the per-file matrix
+	return BuildFileMatrix(result, dirCommits)
+}
+
+// pathAtDepth returns the directory of path truncated to at most depth components
+func pathAtDepth(path string, depth int) string {
+	parts := strings.Split(path, "/")
+	dirs := parts[:len(parts)-1]
+	if len(dirs) == 0 {
+		return rootPathLabel
+	}
+	if len(dirs) > depth {
+		dirs = dirs[:depth]
+	}
+	return strings.Join(dirs, "/") + "/"
+}
+
+// printPathBreakdown prints the per-path breakdown table
+func printPathBreakdown(result CompareResult) {
+	if len(result.PathBreakdown) == 0 {
+		return
+	}
+
+	fmt.Printf("\nBreakdown by path (depth %d):\n", result.Config.Depth)
+	printBreakdownTable(result, "PATH", result.PathBreakdown)
+}
+
+// printCategoryBreakdown prints the infrastructure/application breakdown table
+func printCategoryBreakdown(result CompareResult) {
+	if len(result.CategoryBreakdown) == 0 {
+		return
+	}
+
+	fmt.Printf("\nBreakdown by category:\n")
+	printBreakdownTable(result, "CATEGORY", result.CategoryBreakdown)
+}
+
+// printBreakdownTable prints breakdown rows under the given label column
+func printBreakdownTable(result CompareResult, label string, breakdown []FileSimilarity) {
+	rows := [][]string{{"  " + label, "ONLY [" + result.Config.Tag1Name + "]", "ONLY [" + result.Config.Tag2Name + "]", "SHARED", "SIMILARITY"}}
+	for _, row := range breakdown {
+		rows = append(rows, []string{
+			"  " + row.Path,
+			strconv.Itoa(row.OnlyInTag1),
+			strconv.Itoa(row.OnlyInTag2),
+			strconv.Itoa(row.SharedCommits),
+			fmt.Sprintf("%.2f%%", row.Similarity*100.0),
+		})
+	}
+	writeTable(os.Stdout, result.Config.Output, 0, rows)
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestPathAtDepth tests truncating file paths to directory prefixes
+func TestPathAtDepth(t *testing.T) {
+	tests := []struct {
+		path  string
+		depth int
+		want  string
+	}{
+		{path: "README.md", depth: 1, want: "./"},
+		{path: "internal/cli.go", depth: 1, want: "internal/"},
+		{path: "internal/report/template.go", depth: 1, want: "internal/"},
+		{path: "internal/report/template.go", depth: 2, want: "internal/report/"},
+		{path: "internal/cli.go", depth: 3, want: "internal/"},
+	}
+
+	for _, tt := range tests {
+		if got := pathAtDepth(tt.path, tt.depth); got != tt.want {
+			t.Errorf("pathAtDepth(%q, %d) = %q, want %q", tt.path, tt.depth, got, tt.want)
+		}
+	}
+}
+
+// TestBuildPathBreakdown tests that commits touching several files in a directory count once
+func TestBuildPathBreakdown(t *testing.T) {
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+
+	result := CompareResult{
+		SharedCommits: map[plumbing.Hash]struct{}{hash1: {}},
+		OnlyInTag1:    map[plumbing.Hash]struct{}{},
+		OnlyInTag2:    map[plumbing.Hash]struct{}{hash2: {}},
+	}
+
+	fileCommits := map[string][]plumbing.Hash{
+		"internal/a.go":        {hash1, hash2},
+		"internal/report/b.go": {hash2},
+		"main.go":              {hash1},
+	}
+
+	rows := BuildPathBreakdown(result, fileCommits, 1)
+	if len(rows) != 2 {
+		t.Fatalf("BuildPathBreakdown() returned %d rows, want 2", len(rows))
+	}
+
+	want := []FileSimilarity{
+		{Path: "internal/", OnlyInTag2: 1, SharedCommits: 1, Similarity: 0.5},
+		{Path: "./", SharedCommits: 1, Similarity: 1.0},
+	}
+	for i, row := range rows {
+		if row != want[i] {
+			t.Errorf("BuildPathBreakdown()[%d] = %+v, want %+v", i, row, want[i])
+		}
+	}
+}
+
+// TestClassifyPath tests separating infrastructure-as-code files from application code
+func TestClassifyPath(t *testing.T) {
+	tests := []struct {
+		path string
+		want PathCategory
+	}{
+		{path: "main.go", want: CategoryApplication},
+		{path: "internal/deploy.go", want: CategoryApplication},
+		{path: "infra/main.tf", want: CategoryInfrastructure},
+		{path: "envs/prod.tfvars", want: CategoryInfrastructure},
+		{path: "charts/api/templates/deployment.yaml", want: CategoryInfrastructure},
+		{path: "ops/api/Chart.yaml", want: CategoryInfrastructure},
+		{path: "K8s/base/service.yaml", want: CategoryInfrastructure},
+		{path: "overlays/prod/kustomization.yaml", want: CategoryInfrastructure},
+		{path: "config/values.yaml", want: CategoryApplication},
+	}
+
+	for _, tt := range tests {
+		if got := ClassifyPath(tt.path); got != tt.want {
+			t.Errorf("ClassifyPath(%q) = %q, want %q", tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestBuildCategoryBreakdown tests that a commit touching both categories counts for each
+func TestBuildCategoryBreakdown(t *testing.T) {
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+
+	result := CompareResult{
+		SharedCommits: map[plumbing.Hash]struct{}{hash1: {}},
+		OnlyInTag1:    map[plumbing.Hash]struct{}{},
+		OnlyInTag2:    map[plumbing.Hash]struct{}{hash2: {}},
+	}
+
+	fileCommits := map[string][]plumbing.Hash{
+		"cmd/main.go":            {hash1, hash2},
+		"internal/server.go":     {hash1},
+		"terraform/main.tf":      {hash2},
+		"charts/api/values.yaml": {hash2},
+	}
+
+	want := []FileSimilarity{
+		{Path: "infrastructure", OnlyInTag2: 1, Similarity: 0.0},
+		{Path: "application", OnlyInTag2: 1, SharedCommits: 1, Similarity: 0.5},
+	}
+	rows := BuildCategoryBreakdown(result, fileCommits)
+	if len(rows) != len(want) {
+		t.Fatalf("BuildCategoryBreakdown() returned %d rows, want %d", len(rows), len(want))
+	}
+	for i, row := range rows {
+		if row != want[i] {
+			t.Errorf("BuildCategoryBreakdown()[%d] = %+v, want %+v", i, row, want[i])
+		}
+	}
+}
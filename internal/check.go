@@ -0,0 +1,395 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	ErrCheckFailed = errors.New("repository check failed")
+)
+
+// CheckStatus is the outcome of a single repository check
+type CheckStatus string
+
+const (
+	CheckOK   CheckStatus = "ok"
+	CheckWarn CheckStatus = "warn"
+	CheckFail CheckStatus = "fail"
+)
+
+// CheckResult describes the outcome of a single repository check and how to fix it
+type CheckResult struct {
+	Name   string
+	Status CheckStatus
+	Detail string
+	Hint   string // Remediation shown when the check did not pass
+}
+
+// CheckConfig holds the configuration of the check command
+type CheckConfig struct {
+	Command Command
+	TagOptions
+	Output  OutputOptions
+	Timeout time.Duration // Cancel the checks after this long; 0 never times out
+}
+
+// checkUsage is the help of the check command
+var checkUsage = commandUsage{
+	Name:        "check",
+	Summary:     "Check that a repository can be compared",
+	Description: "Check that a repository can be compared: git binary, shallow and partial clones,\ntags pointing at missing objects, and the object store of the tags' history.",
+	Examples: []string{
+		"check -repo /path/to/repo",
+		"check -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+	},
+}
+
+// NewCheckConfig parses the check command flags
+func NewCheckConfig(args []string) (CheckConfig, error) {
+	config := CheckConfig{Command: CheckCommand}
+	checkCmd := newCommandFlagSet(checkUsage)
+	parseTagOptions := config.TagOptions.registerFlags(checkCmd, "whose history should be verified")
+	parseOutputOptions := config.Output.registerFlags(checkCmd, "Maximum line width of the results table")
+	registerTimeoutFlag(checkCmd, &config.Timeout, "the checks")
+
+	if err := checkCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseTagOptions(); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid. Unlike compare, both tags are optional.
+func (c *CheckConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return validateRepoPath(c.RepoPath)
+}
+
+// RunCheck runs all repository checks and returns their results.
+// ErrCheckFailed is returned when at least one check failed.
+func RunCheck(ctx context.Context, config CheckConfig) (_ []CheckResult, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return nil, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	results := []CheckResult{checkGitBinary(ctx)}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, config.CloneDepth)
+	if err != nil {
+		results = append(results, CheckResult{
+			Name:   "repository",
+			Status: CheckFail,
+			Detail: err.Error(),
+			Hint:   "point -repo at the root of a git working tree or a bare repository",
+		})
+		return results, ErrCheckFailed
+	}
+	results = append(results, CheckResult{Name: "repository", Status: CheckOK, Detail: config.RepoPath})
+
+	results = append(results, checkShallow(repo), checkPartialClone(repo), checkAlternates(repo))
+
+	tagNames := make([]string, 0, 2)
+	if config.Tag1Name != "" || config.Tag2Name != "" {
+		if err := config.ResolveTagOffsets(repo); err != nil {
+			results = append(results, CheckResult{
+				Name:   "tag offsets",
+				Status: CheckFail,
+				Detail: err.Error(),
+				Hint:   "list the available tags with 'git tag --list' and pick an offset within range",
+			})
+			return results, ErrCheckFailed
+		}
+		for _, name := range []string{config.Tag1Name, config.Tag2Name} {
+			if name != "" {
+				tagNames = append(tagNames, name)
+			}
+		}
+	}
+
+	results = append(results, checkTags(ctx, repo, tagNames)...)
+
+	for _, result := range results {
+		if result.Status == CheckFail {
+			return results, ErrCheckFailed
+		}
+	}
+	return results, nil
+}
+
+// checkGitBinary verifies that the git executable used for diffs and file history is available
+func checkGitBinary(ctx context.Context) CheckResult {
+	result := CheckResult{Name: "git binary"}
+
+	path, err := exec.LookPath("git")
+	if err != nil {
+		result.Status = CheckWarn
+		result.Detail = "git not found in PATH; diff, -json, -file-matrix and -depth will fail"
+		result.Hint = "install git and make sure it is on PATH"
+		return result
+	}
+
+	output, err := exec.CommandContext(ctx, path, "--version").Output()
+	if err != nil {
+		result.Status = CheckWarn
+		result.Detail = fmt.Sprintf("%s could not be run: %v", path, err)
+		result.Hint = "reinstall git or fix the permissions of the git executable"
+		return result
+	}
+
+	result.Status = CheckOK
+	result.Detail = strings.TrimSpace(string(output))
+	return result
+}
+
+// checkShallow fails for shallow clones, whose truncated history makes similarity meaningless
+func checkShallow(repo *GitRepository) CheckResult {
+	result := CheckResult{Name: "shallow clone"}
+
+	reader, err := repo.borrowReader()
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("failed to open repository: %v", err)
+		return result
+	}
+	shallow, err := reader.Storer.Shallow()
+	repo.returnReader(reader)
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("failed to read shallow file: %v", err)
+		result.Hint = "run 'git fsck' to inspect the repository"
+		return result
+	}
+
+	if len(shallow) > 0 {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("history is truncated at %d commit(s); commit sets would be incomplete", len(shallow))
+		result.Hint = "run 'git fetch --unshallow --tags' to fetch the full history"
+		return result
+	}
+
+	result.Status = CheckOK
+	result.Detail = "full history"
+	return result
+}
+
+// checkPartialClone warns about partial clones, whose missing objects go-git cannot fetch on demand
+func checkPartialClone(repo *GitRepository) CheckResult {
+	result := CheckResult{Name: "partial clone"}
+
+	reader, err := repo.borrowReader()
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("failed to open repository: %v", err)
+		return result
+	}
+	cfg, err := reader.Config()
+	repo.returnReader(reader)
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("failed to read repository config: %v", err)
+		result.Hint = "check .git/config for syntax errors"
+		return result
+	}
+
+	var promisors []string
+	if remote := cfg.Raw.Section("extensions").Option("partialclone"); remote != "" {
+		promisors = append(promisors, remote)
+	}
+	for _, remote := range cfg.Raw.Section("remote").Subsections {
+		if remote.Option("promisor") == "true" || remote.Option("partialclonefilter") != "" {
+			if !slices.Contains(promisors, remote.Name) {
+				promisors = append(promisors, remote.Name)
+			}
+		}
+	}
+
+	if len(promisors) > 0 {
+		result.Status = CheckWarn
+		result.Detail = fmt.Sprintf("objects are fetched lazily from %s; directory filters may hit missing trees", strings.Join(promisors, ", "))
+		result.Hint = fmt.Sprintf("run 'git config --unset remote.%s.partialclonefilter && git fetch --refetch %s' for a full clone", promisors[0], promisors[0])
+		return result
+	}
+
+	result.Status = CheckOK
+	result.Detail = "all objects are local"
+	return result
+}
+
+// checkAlternates reports the alternate object directories a repository borrows objects from, and warns
+// about listed directories that no longer exist
+func checkAlternates(repo *GitRepository) CheckResult {
+	result := CheckResult{Name: "alternates"}
+
+	reader, err := repo.borrowReader()
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("failed to open repository: %v", err)
+		return result
+	}
+	storage, ok := repositoryStorage(reader)
+	repo.returnReader(reader)
+	if !ok {
+		result.Status = CheckOK
+		result.Detail = "no alternate object directories"
+		return result
+	}
+
+	objectsDir := objectsDirectory(storage)
+	found, missing, err := alternateObjectDirectories(objectsDir)
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = err.Error()
+		result.Hint = "check the permissions of objects/info/alternates"
+		return result
+	}
+
+	if len(missing) > 0 {
+		result.Status = CheckWarn
+		result.Detail = fmt.Sprintf("alternate object directory does not exist: %s; objects stored there cannot be read", strings.Join(missing, ", "))
+		result.Hint = fmt.Sprintf("fix the path in %s, or run 'git repack -a -d' while it is reachable to copy the objects", filepath.Join(objectsDir, "info", "alternates"))
+		return result
+	}
+
+	result.Status = CheckOK
+	if len(found) == 0 {
+		result.Detail = "no alternate object directories"
+	} else {
+		result.Detail = fmt.Sprintf("objects are also read from %s", strings.Join(found, ", "))
+	}
+	return result
+}
+
+// checkTags reports tags whose target object is missing and verifies the history of the selected tags.
+// A broken tag only fails the check when it is one of the selected tags.
+func checkTags(ctx context.Context, repo *GitRepository, selected []string) []CheckResult {
+	refs, err := repo.FetchAllTags()
+	if err != nil {
+		return []CheckResult{{
+			Name:   "tags",
+			Status: CheckFail,
+			Detail: err.Error(),
+			Hint:   "run 'git fsck' and 'git pack-refs --all' to repair the references",
+		}}
+	}
+
+	byName := make(map[string]*plumbing.Reference, len(refs))
+	var dangling []string
+	for _, ref := range refs {
+		byName[ref.Name().Short()] = ref
+		if _, err := repo.GetTagCommit(ref); err != nil {
+			dangling = append(dangling, ref.Name().Short())
+		}
+	}
+
+	tagsResult := CheckResult{Name: "tags", Status: CheckOK, Detail: fmt.Sprintf("%d tag(s) resolve to commits", len(refs))}
+	if len(dangling) > 0 {
+		tagsResult.Status = CheckWarn
+		tagsResult.Detail = fmt.Sprintf("%d tag(s) point at missing or non-commit objects: %s", len(dangling), strings.Join(dangling, ", "))
+		tagsResult.Hint = "run 'git fetch --tags --force' to restore the targets, or 'git tag -d <tag>' to remove the tags"
+	}
+	results := []CheckResult{tagsResult}
+
+	for _, name := range selected {
+		ref, ok := byName[name]
+		if !ok {
+			// Branches, commits, and revision expressions can be compared as well
+			if ref, err = repo.ResolveRevision(ctx, name); err != nil {
+				results = append(results, CheckResult{
+					Name:   "tag " + name,
+					Status: CheckFail,
+					Detail: "tag, branch, or commit not found",
+					Hint:   "run 'git fetch --tags' or check the tag name with 'git tag --list'",
+				})
+				continue
+			}
+		}
+		results = append(results, checkTagHistory(repo, name, ref))
+	}
+
+	return results
+}
+
+// checkTagHistory walks every commit reachable from a tag and reads its root tree,
+// surfacing missing objects before a comparison trips over them
+func checkTagHistory(repo *GitRepository, name string, ref *plumbing.Reference) CheckResult {
+	result := CheckResult{Name: "tag " + name}
+
+	// The walk reads trees, so it holds a handle of its own rather than using GetTagCommit
+	reader, err := repo.borrowReader()
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("failed to open repository: %v", err)
+		return result
+	}
+	defer repo.returnReader(reader)
+
+	commit, err := resolveCommit(reader, ref)
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("tag target cannot be read: %v", err)
+		result.Hint = fmt.Sprintf("run 'git fetch origin tag %s --force' or recreate the tag", name)
+		return result
+	}
+
+	iter := object.NewCommitPreorderIter(commit, nil, nil)
+	defer iter.Close()
+
+	count := 0
+	err = iter.ForEach(func(c *object.Commit) error {
+		count++
+		if _, err := c.Tree(); err != nil {
+			return fmt.Errorf("tree %s of commit %s: %w", c.TreeHash, c.Hash, err)
+		}
+		return nil
+	})
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("object store is incomplete after %d commit(s): %v", count, err)
+		result.Hint = "run 'git fsck --full' for details, then 'git fetch --refetch' or restore from a healthy clone"
+		return result
+	}
+
+	result.Status = CheckOK
+	result.Detail = fmt.Sprintf("%d reachable commit(s) readable", count)
+	return result
+}
+
+// PrintCheckResults prints the check results with remediation hints for failed checks
+func PrintCheckResults(w io.Writer, results []CheckResult, output OutputOptions) {
+	rows := make([][]string, 0, len(results))
+	for _, result := range results {
+		rows = append(rows, []string{"[" + strings.ToUpper(string(result.Status)) + "]", result.Name, result.Detail})
+		if result.Status != CheckOK && result.Hint != "" {
+			rows = append(rows, []string{"", "", "  hint: " + result.Hint})
+		}
+	}
+	writeTable(w, output, 2, rows)
+}
@@ -0,0 +1,138 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// findCheck returns the result with the given name
+func findCheck(t *testing.T, results []CheckResult, name string) CheckResult {
+	t.Helper()
+	for _, result := range results {
+		if result.Name == name {
+			return result
+		}
+	}
+	t.Fatalf("check %q not found in %v", name, results)
+	return CheckResult{}
+}
+
+// TestRunCheck tests the repository checks against healthy and broken repositories
+func TestRunCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		tag1       string
+		tag2       string
+		setup      func(t *testing.T, fixture *testutil.RepoBuilder)
+		wantErr    bool
+		wantCheck  string
+		wantStatus CheckStatus
+	}{
+		{
+			name:       "healthy repository",
+			tag1:       "v1.0.0",
+			tag2:       "latest",
+			wantCheck:  "tag v1.1.0",
+			wantStatus: CheckOK,
+		},
+		{
+			name: "shallow clone",
+			setup: func(t *testing.T, fixture *testutil.RepoBuilder) {
+				writeFile(t, filepath.Join(fixture.Path(), ".git", "shallow"), "0123456789abcdef0123456789abcdef01234567\n")
+			},
+			wantErr:    true,
+			wantCheck:  "shallow clone",
+			wantStatus: CheckFail,
+		},
+		{
+			name: "partial clone",
+			setup: func(t *testing.T, fixture *testutil.RepoBuilder) {
+				cfg, err := fixture.Repository().Config()
+				if err != nil {
+					t.Fatalf("failed to read config: %v", err)
+				}
+				cfg.Raw.Section("extensions").SetOption("partialclone", "origin")
+				if err := fixture.Repository().SetConfig(cfg); err != nil {
+					t.Fatalf("failed to write config: %v", err)
+				}
+			},
+			wantCheck:  "partial clone",
+			wantStatus: CheckWarn,
+		},
+		{
+			name: "missing alternate object directory",
+			setup: func(t *testing.T, fixture *testutil.RepoBuilder) {
+				writeFile(t, filepath.Join(fixture.Path(), ".git", "objects", "info", "alternates"), "/nonexistent/pool/objects\n")
+			},
+			wantCheck:  "alternates",
+			wantStatus: CheckWarn,
+		},
+		{
+			name: "dangling tag not selected",
+			setup: func(t *testing.T, fixture *testutil.RepoBuilder) {
+				writeFile(t, filepath.Join(fixture.Path(), ".git", "refs", "tags", "broken"), "0123456789abcdef0123456789abcdef01234567\n")
+			},
+			wantCheck:  "tags",
+			wantStatus: CheckWarn,
+		},
+		{
+			name: "dangling tag selected",
+			tag1: "broken",
+			tag2: "v1.0.0",
+			setup: func(t *testing.T, fixture *testutil.RepoBuilder) {
+				writeFile(t, filepath.Join(fixture.Path(), ".git", "refs", "tags", "broken"), "0123456789abcdef0123456789abcdef01234567\n")
+			},
+			wantErr:    true,
+			wantCheck:  "tag broken",
+			wantStatus: CheckFail,
+		},
+		{
+			name:       "missing tag",
+			tag1:       "v9.9.9",
+			wantErr:    true,
+			wantCheck:  "tag v9.9.9",
+			wantStatus: CheckFail,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := testutil.NewRepo(t).Commit("first").Tag("v1.0.0").Commit("second").AnnotatedTag("v1.1.0", "release")
+			if tt.setup != nil {
+				tt.setup(t, fixture)
+			}
+
+			results, err := RunCheck(context.Background(), CheckConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: tt.tag1, Tag2Name: tt.tag2, SortBy: SortBySemver}})
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("RunCheck() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrCheckFailed) {
+				t.Errorf("RunCheck() error = %v, want ErrCheckFailed", err)
+			}
+
+			check := findCheck(t, results, tt.wantCheck)
+			if check.Status != tt.wantStatus {
+				t.Errorf("check %q status = %s, want %s (%s)", tt.wantCheck, check.Status, tt.wantStatus, check.Detail)
+			}
+			if check.Status != CheckOK && check.Hint == "" {
+				t.Errorf("check %q has no remediation hint", tt.wantCheck)
+			}
+		})
+	}
+}
+
+// writeFile writes content to path, creating parent directories
+func writeFile(t *testing.T, path string, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatalf("failed to create directory: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
@@ -0,0 +1,265 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrLoadChecksums   = errors.New("failed to load checksums")
+	ErrVerifyChecksums = errors.New("failed to verify release archives")
+)
+
+// checksumTagPlaceholder is replaced by the tag name in -checksums and -archive-prefix,
+// e.g. to fetch the checksums file of each GitHub release
+const checksumTagPlaceholder = "{tag}"
+
+// archiveFormats maps the file extensions of source archives to the git archive format creating them
+var archiveFormats = []struct {
+	extension string
+	format    string
+}{
+	{extension: ".tar.gz", format: "tar.gz"},
+	{extension: ".tgz", format: "tgz"},
+	{extension: ".tar", format: "tar"},
+	{extension: ".zip", format: "zip"},
+}
+
+// checksumAlgorithms maps the hex digest length to the hash algorithm producing it
+var checksumAlgorithms = map[int]struct {
+	name string
+	new  func() hash.Hash
+}{
+	sha256.Size * 2: {name: "sha256", new: sha256.New},
+	sha512.Size * 2: {name: "sha512", new: sha512.New},
+}
+
+// ChecksumEntry is a single line of a checksums file
+type ChecksumEntry struct {
+	Name      string // File name the checksum was published for
+	Algorithm string // sha256 or sha512, derived from the digest length
+	Digest    string // Lowercase hex digest
+}
+
+// bsdChecksumLine matches the BSD format written by 'shasum --tag' and 'sha256sum --tag', e.g. "SHA256 (name) = digest"
+var bsdChecksumLine = regexp.MustCompile(`^SHA(256|512) \((.+)\) = ([0-9a-fA-F]+)$`)
+
+// ParseChecksums reads a checksums file in the format of sha256sum and sha512sum ("<digest>  <name>"),
+// or in their BSD format ("SHA256 (<name>) = <digest>"). Blank lines, comments, and PGP armor are skipped.
+func ParseChecksums(r io.Reader) ([]ChecksumEntry, error) {
+	var entries []ChecksumEntry
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-----") {
+			continue
+		}
+
+		var name, digest string
+		if match := bsdChecksumLine.FindStringSubmatch(line); match != nil {
+			name, digest = match[2], match[3]
+		} else if fields := strings.Fields(line); len(fields) >= 2 {
+			// The name may be prefixed with * (binary mode) and contain spaces
+			digest = fields[0]
+			name = strings.TrimPrefix(strings.TrimSpace(line[len(digest):]), "*")
+		} else {
+			continue
+		}
+
+		algorithm, ok := checksumAlgorithms[len(digest)]
+		if _, err := hex.DecodeString(digest); !ok || err != nil {
+			// Lines of signatures and other metadata are not checksums
+			continue
+		}
+		entries = append(entries, ChecksumEntry{Name: name, Algorithm: algorithm.name, Digest: strings.ToLower(digest)})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Join(ErrLoadChecksums, err)
+	}
+
+	return entries, nil
+}
+
+// LoadChecksums reads the checksums published for a tag from a file or an http(s) URL.
+// {tag} in the source is replaced by the tag name. ctx bounds the download.
+func LoadChecksums(ctx context.Context, source string, tag string, client *http.Client) ([]ChecksumEntry, error) {
+	source = strings.ReplaceAll(source, checksumTagPlaceholder, tag)
+
+	var r io.Reader
+	if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+		if err != nil {
+			return nil, errors.Join(ErrLoadChecksums, err)
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, errors.Join(ErrLoadChecksums, err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		if resp.StatusCode != http.StatusOK {
+			return nil, errors.Join(ErrLoadChecksums, fmt.Errorf("%s returned %s", source, resp.Status))
+		}
+		r = resp.Body
+	} else {
+		file, err := os.Open(source)
+		if err != nil {
+			return nil, errors.Join(ErrLoadChecksums, err)
+		}
+		defer func() { _ = file.Close() }()
+		r = file
+	}
+
+	return ParseChecksums(r)
+}
+
+// ArchiveVerification is the outcome of checking a published source archive checksum against
+// the archive git creates for the tag
+type ArchiveVerification struct {
+	Tag       string `json:"tag"`
+	Asset     string `json:"asset"` // File name listed in the checksums file
+	Algorithm string `json:"algorithm"`
+	Expected  string `json:"expected"` // Published digest
+	Actual    string `json:"actual"`   // Digest of the archive created with git archive
+}
+
+// Verified reports whether the published checksum matches the archive of the tag
+func (v ArchiveVerification) Verified() bool {
+	return v.Expected == v.Actual
+}
+
+// archiveFormat returns the git archive format of a source archive name and the name without its extension.
+// The format is "" if git archive cannot create the file, e.g. for a .tar.xz.
+func archiveFormat(name string) (string, string) {
+	lower := strings.ToLower(name)
+	for _, candidate := range archiveFormats {
+		if strings.HasSuffix(lower, candidate.extension) {
+			return candidate.format, name[:len(name)-len(candidate.extension)]
+		}
+	}
+	return "", ""
+}
+
+// tagArchiveEntries returns the entries naming a source archive of the tag, e.g. project-1.0.0.tar.gz or v1.0.0.zip.
+// The version must be the whole last component of the base name, so v1.0.1 does not match v1.0.10.
+func tagArchiveEntries(entries []ChecksumEntry, tag string) []ChecksumEntry {
+	version := strings.TrimPrefix(tag, "v")
+
+	var matches []ChecksumEntry
+	for _, entry := range entries {
+		format, base := archiveFormat(entry.Name)
+		if format == "" {
+			continue
+		}
+		// Ignore directories in the name, e.g. "./dist/project-1.0.0.tar.gz"
+		base = base[strings.LastIndex(base, "/")+1:]
+		for _, candidate := range []string{tag, version} {
+			if before, ok := strings.CutSuffix(base, candidate); ok && (before == "" || strings.HasSuffix(before, "-") || strings.HasSuffix(before, "_")) {
+				matches = append(matches, entry)
+				break
+			}
+		}
+	}
+	return matches
+}
+
+// VerifyTagArchives recreates the source archives listed for a tag with git archive and compares their checksums.
+// The archive paths are prefixed with prefix, or with the archive base name and a / when prefix is empty,
+// which is the layout of 'git archive --prefix=<name>/' and most release tooling.
+func VerifyTagArchives(ctx context.Context, repo Repository, tag string, ref *plumbing.Reference, entries []ChecksumEntry, prefix string) ([]ArchiveVerification, error) {
+	var verifications []ArchiveVerification
+	for _, entry := range tagArchiveEntries(entries, tag) {
+		format, base := archiveFormat(entry.Name)
+		archivePrefix := strings.ReplaceAll(prefix, checksumTagPlaceholder, tag)
+		if prefix == "" {
+			archivePrefix = base[strings.LastIndex(base, "/")+1:] + "/"
+		}
+
+		digest := checksumAlgorithms[len(entry.Digest)].new()
+		if err := repo.WriteArchive(ctx, ref, format, archivePrefix, digest); err != nil {
+			return verifications, errors.Join(ErrVerifyChecksums, err)
+		}
+
+		verifications = append(verifications, ArchiveVerification{
+			Tag:       tag,
+			Asset:     entry.Name,
+			Algorithm: entry.Algorithm,
+			Expected:  entry.Digest,
+			Actual:    hex.EncodeToString(digest.Sum(nil)),
+		})
+	}
+	return verifications, nil
+}
+
+// verifyReleaseArchives checks the published checksums of both tags' source archives.
+// Without {tag} in the source, a single checksums file is read and searched for both tags.
+func verifyReleaseArchives(ctx context.Context, repo Repository, result CompareResult) ([]ArchiveVerification, error) {
+	config := result.Config
+	client := config.HTTP.Client()
+
+	perTag := strings.Contains(config.ChecksumsPath, checksumTagPlaceholder)
+	var shared []ChecksumEntry
+	if !perTag {
+		entries, err := LoadChecksums(ctx, config.ChecksumsPath, "", client)
+		if err != nil {
+			return nil, errors.Join(ErrVerifyChecksums, err)
+		}
+		shared = entries
+	}
+
+	tags := []struct {
+		name string
+		ref  *plumbing.Reference
+	}{{name: config.Tag1Name, ref: result.Tag1Ref}, {name: config.Tag2Name, ref: result.Tag2Ref}}
+
+	var verifications []ArchiveVerification
+	for _, tag := range tags {
+		entries := shared
+		if perTag {
+			var err error
+			if entries, err = LoadChecksums(ctx, config.ChecksumsPath, tag.name, client); err != nil {
+				return verifications, errors.Join(ErrVerifyChecksums, err)
+			}
+		}
+
+		tagVerifications, err := VerifyTagArchives(ctx, repo, tag.name, tag.ref, entries, config.ArchivePrefix)
+		if err != nil {
+			return verifications, err
+		}
+		verifications = append(verifications, tagVerifications...)
+	}
+	return verifications, nil
+}
+
+// printArchiveVerifications prints whether the published source archives match the tags
+func printArchiveVerifications(result CompareResult) {
+	if result.Config.ChecksumsPath == "" {
+		return
+	}
+
+	fmt.Printf("\nRelease archives:\n")
+	if len(result.Archives) == 0 {
+		fmt.Printf("  No source archive of %s or %s is listed in the checksums\n", result.Config.Tag1Name, result.Config.Tag2Name)
+		return
+	}
+	for _, archive := range result.Archives {
+		status := "verified"
+		if !archive.Verified() {
+			status = fmt.Sprintf("MISMATCH (published %s, git archive %s)", shortHash(archive.Expected), shortHash(archive.Actual))
+		}
+		fmt.Printf("  %s  %s (%s): %s\n", archive.Tag, archive.Asset, archive.Algorithm, status)
+	}
+}
@@ -0,0 +1,135 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseChecksums tests reading the GNU and BSD checksum formats
+func TestParseChecksums(t *testing.T) {
+	digest256 := strings.Repeat("ab", 32)
+	digest512 := strings.Repeat("CD", 64)
+	content := strings.Join([]string{
+		"-----BEGIN PGP SIGNED MESSAGE-----",
+		"Hash: SHA256",
+		"",
+		"# checksums of project 1.0.0",
+		digest256 + "  project-1.0.0.tar.gz",
+		digest512 + " *project 1.0.0.zip",
+		"SHA256 (project-1.0.0.tar) = " + digest256,
+		"deadbeef  too-short.tar",
+	}, "\n")
+
+	entries, err := ParseChecksums(strings.NewReader(content))
+	if err != nil {
+		t.Fatalf("ParseChecksums() error = %v", err)
+	}
+
+	want := []ChecksumEntry{
+		{Name: "project-1.0.0.tar.gz", Algorithm: "sha256", Digest: digest256},
+		{Name: "project 1.0.0.zip", Algorithm: "sha512", Digest: strings.ToLower(digest512)},
+		{Name: "project-1.0.0.tar", Algorithm: "sha256", Digest: digest256},
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("ParseChecksums() = %+v, want %+v", entries, want)
+	}
+}
+
+// TestTagArchiveEntries tests matching archive names to a tag
+func TestTagArchiveEntries(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	var entries []ChecksumEntry
+	for _, name := range []string{"project-1.0.1.tar.gz", "v1.0.1.zip", "dist/project_1.0.1.tgz", "project-1.0.10.tar.gz", "project-1.0.1.tar.xz", "project-1.0.1-linux-amd64"} {
+		entries = append(entries, ChecksumEntry{Name: name, Algorithm: "sha256", Digest: digest})
+	}
+
+	var got []string
+	for _, entry := range tagArchiveEntries(entries, "v1.0.1") {
+		got = append(got, entry.Name)
+	}
+
+	want := []string{"project-1.0.1.tar.gz", "v1.0.1.zip", "dist/project_1.0.1.tgz"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tagArchiveEntries() = %v, want %v", got, want)
+	}
+}
+
+// TestVerifyTagArchives tests comparing published checksums with the archives of a fixture tag
+func TestVerifyTagArchives(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	// Publish the checksum of the archive git creates, as a release pipeline would
+	cmd := exec.Command("git", "archive", "--format=tar", "--prefix=project-1.0.0/", "v1.0.0")
+	cmd.Dir = fixture.Path()
+	archive, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("git archive failed: %v", err)
+	}
+	sum := sha256.Sum256(archive)
+	published := hex.EncodeToString(sum[:])
+
+	entries := []ChecksumEntry{
+		{Name: "project-1.0.0.tar", Algorithm: "sha256", Digest: published},
+		{Name: "project-1.0.0.zip", Algorithm: "sha256", Digest: strings.Repeat("0", 64)},
+		{Name: "project-1.1.0.tar", Algorithm: "sha256", Digest: published},
+	}
+
+	verifications, err := VerifyTagArchives(context.Background(), repo, "v1.0.0", fixture.Reference("v1.0.0"), entries, "")
+	if err != nil {
+		t.Fatalf("VerifyTagArchives() error = %v", err)
+	}
+	if len(verifications) != 2 {
+		t.Fatalf("VerifyTagArchives() returned %d verifications, want 2: %+v", len(verifications), verifications)
+	}
+	if !verifications[0].Verified() {
+		t.Errorf("%s: expected %s, got %s", verifications[0].Asset, verifications[0].Expected, verifications[0].Actual)
+	}
+	if verifications[1].Verified() {
+		t.Errorf("%s: verified against a forged checksum", verifications[1].Asset)
+	}
+
+	// A different prefix produces a different archive
+	verifications, err = VerifyTagArchives(context.Background(), repo, "v1.0.0", fixture.Reference("v1.0.0"), entries[:1], "{tag}/")
+	if err != nil {
+		t.Fatalf("VerifyTagArchives() error = %v", err)
+	}
+	if len(verifications) != 1 || verifications[0].Verified() {
+		t.Errorf("VerifyTagArchives() with prefix v1.0.0/ = %+v, want a mismatch", verifications)
+	}
+}
+
+// TestLoadChecksums_URL tests fetching the checksums of a tag from a release URL
+func TestLoadChecksums_URL(t *testing.T) {
+	digest := strings.Repeat("ab", 32)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/releases/download/v1.0.0/SHA256SUMS" {
+			http.NotFound(w, r)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%s  project-1.0.0.tar.gz\n", digest)
+	}))
+	defer server.Close()
+
+	entries, err := LoadChecksums(context.Background(), server.URL+"/releases/download/{tag}/SHA256SUMS", "v1.0.0", server.Client())
+	if err != nil {
+		t.Fatalf("LoadChecksums() error = %v", err)
+	}
+	if len(entries) != 1 || entries[0].Name != "project-1.0.0.tar.gz" {
+		t.Errorf("LoadChecksums() = %+v, want project-1.0.0.tar.gz", entries)
+	}
+
+	_, err = LoadChecksums(context.Background(), server.URL+"/releases/download/{tag}/SHA256SUMS", "v9.9.9", server.Client())
+	if !errors.Is(err, ErrLoadChecksums) {
+		t.Errorf("LoadChecksums() for a missing release error = %v, want ErrLoadChecksums", err)
+	}
+}
@@ -0,0 +1,102 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrInvalidCommitMatch = errors.New("invalid commit match mode")
+	ErrMatchPatchIDs      = errors.New("failed to match commits by patch ID")
+)
+
+// CommitMatch determines when a commit of one tag counts as shared with the other tag
+type CommitMatch string
+
+const (
+	// MatchHash shares only identical commits
+	MatchHash CommitMatch = "hash"
+	// MatchPatchID also shares commits whose changes are identical, like 'git cherry'
+	MatchPatchID CommitMatch = "patch-id"
+)
+
+// ParseCommitMatch converts a -match flag value into a CommitMatch
+func ParseCommitMatch(value string) (CommitMatch, error) {
+	switch CommitMatch(value) {
+	case MatchHash, MatchPatchID:
+		return CommitMatch(value), nil
+	default:
+		return "", errors.Join(ErrInvalidCommitMatch, fmt.Errorf("unknown match mode: %s (expected hash or patch-id)", value))
+	}
+}
+
+// MatchPatchEquivalents pairs the commits unique to each tag that have the same stable patch ID, e.g. a fix
+// cherry-picked from one release branch to another. The result maps each tag2 commit to its tag1 equivalent.
+// Only commits in the given sets are paired, so directory and profile filters apply.
+func MatchPatchEquivalents(ctx context.Context, repo Repository, tag1Ref *plumbing.Reference, tag2Ref *plumbing.Reference,
+	tag1Commits map[plumbing.Hash]struct{}, tag2Commits map[plumbing.Hash]struct{}) (map[plumbing.Hash]plumbing.Hash, error) {
+	// tag2..tag1 and tag1..tag2 hold the unique commits of each side, except merges, which have no diff
+	ids1, err := repo.GetPatchIDs(ctx, tag1Ref, tag2Ref)
+	if err != nil {
+		return nil, errors.Join(ErrMatchPatchIDs, err)
+	}
+	ids2, err := repo.GetPatchIDs(ctx, tag2Ref, tag1Ref)
+	if err != nil {
+		return nil, errors.Join(ErrMatchPatchIDs, err)
+	}
+
+	equivalents := make(map[plumbing.Hash]plumbing.Hash)
+	for patchID, hash2 := range ids2 {
+		hash1, ok := ids1[patchID]
+		if !ok {
+			continue
+		}
+		if _, ok := tag1Commits[hash1]; !ok {
+			continue
+		}
+		if _, ok := tag2Commits[hash2]; !ok {
+			continue
+		}
+		equivalents[hash2] = hash1
+	}
+	return equivalents, nil
+}
+
+// applyPatchEquivalents returns a copy of the tag2 commits in which each commit with a tag1 equivalent
+// is replaced by that equivalent, so set operations count the pair as one shared commit
+func applyPatchEquivalents(tag2Commits map[plumbing.Hash]struct{}, equivalents map[plumbing.Hash]plumbing.Hash) map[plumbing.Hash]struct{} {
+	commits := make(map[plumbing.Hash]struct{}, len(tag2Commits))
+	for hash := range tag2Commits {
+		if equivalent, ok := equivalents[hash]; ok {
+			hash = equivalent
+		}
+		commits[hash] = struct{}{}
+	}
+	return commits
+}
+
+// printPatchEquivalents prints how many commits were shared by patch ID, and the pairs in verbose mode
+func printPatchEquivalents(result CompareResult) {
+	if result.Config.Match != MatchPatchID {
+		return
+	}
+
+	fmt.Printf("Matched by patch ID: %d commits of [%s] are cherry-picks of commits of [%s] and count as shared\n",
+		len(result.PatchEquivalents), result.Config.Tag2Name, result.Config.Tag1Name)
+	if !result.Config.Verbose || len(result.PatchEquivalents) == 0 {
+		return
+	}
+
+	pairs := make([][2]string, 0, len(result.PatchEquivalents))
+	for hash2, hash1 := range result.PatchEquivalents {
+		pairs = append(pairs, [2]string{hash1.String(), hash2.String()})
+	}
+	sort.Slice(pairs, func(i int, j int) bool { return pairs[i][0] < pairs[j][0] })
+	for _, pair := range pairs {
+		fmt.Printf("  %s = %s\n", shortHash(pair[0]), shortHash(pair[1]))
+	}
+}
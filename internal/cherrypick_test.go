@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestCompare_MatchPatchID tests counting cherry-picked commits as shared
+func TestCompare_MatchPatchID(t *testing.T) {
+	// The fix is committed on the release branch and cherry-picked to main, which also adds a feature
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("a.go", "package a\n")).
+		Branch("release/1.0").
+		Checkout("release/1.0").
+		Commit("Fix crash", testutil.File("fix.go", "package a\n\nconst Fixed = true\n")).
+		Tag("v1.0.1").
+		Checkout("main").
+		Commit("Add feature", testutil.File("feature.go", "package a\n")).
+		Commit("Fix crash", testutil.File("fix.go", "package a\n\nconst Fixed = true\n")).
+		Tag("v1.1.0")
+
+	tests := []struct {
+		name        string
+		match       CommitMatch
+		similarity  float64
+		equivalents int
+		onlyInTag1  int
+		onlyInTag2  int
+	}{
+		{name: "hash", match: MatchHash, similarity: 0.25, equivalents: 0, onlyInTag1: 1, onlyInTag2: 2},
+		{name: "patch-id", match: MatchPatchID, similarity: 2.0 / 3.0, equivalents: 1, onlyInTag1: 0, onlyInTag2: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.1", Tag2Name: "v1.1.0"},
+				Match:      tt.match,
+			})
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+
+			if result.Similarity != tt.similarity {
+				t.Errorf("Similarity = %v, want %v", result.Similarity, tt.similarity)
+			}
+			if len(result.PatchEquivalents) != tt.equivalents {
+				t.Errorf("PatchEquivalents = %v, want %d pairs", result.PatchEquivalents, tt.equivalents)
+			}
+			if len(result.OnlyInTag1) != tt.onlyInTag1 || len(result.OnlyInTag2) != tt.onlyInTag2 {
+				t.Errorf("unique commits = %d, %d, want %d, %d", len(result.OnlyInTag1), len(result.OnlyInTag2), tt.onlyInTag1, tt.onlyInTag2)
+			}
+			for hash2, hash1 := range result.PatchEquivalents {
+				if hash1 != fixture.Hash("v1.0.1") || hash2 != fixture.Hash("v1.1.0") {
+					t.Errorf("equivalent %s = %s, want the fix commits of v1.0.1 and v1.1.0", hash1, hash2)
+				}
+				if _, ok := result.SharedCommits[hash1]; !ok {
+					t.Errorf("the cherry-picked commit %s is not shared", hash1)
+				}
+			}
+		})
+	}
+}
+
+// TestParseCommitMatch tests validating -match values
+func TestParseCommitMatch(t *testing.T) {
+	if match, err := ParseCommitMatch("patch-id"); err != nil || match != MatchPatchID {
+		t.Errorf("ParseCommitMatch(patch-id) = %q, %v", match, err)
+	}
+	if _, err := ParseCommitMatch("subject"); !errors.Is(err, ErrInvalidCommitMatch) {
+		t.Errorf("ParseCommitMatch(subject) error = %v, want ErrInvalidCommitMatch", err)
+	}
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// PathCategory separates infrastructure-as-code and deployment files from application code
+type PathCategory string
+
+const (
+	CategoryInfrastructure PathCategory = "infrastructure"
+	CategoryApplication    PathCategory = "application"
+)
+
+// infrastructureDirectories are directory names whose contents are deployment definitions
+var infrastructureDirectories = map[string]struct{}{
+	"charts":      {},
+	"helm":        {},
+	"terraform":   {},
+	"k8s":         {},
+	"kubernetes":  {},
+	"manifests":   {},
+	"kustomize":   {},
+	"deploy":      {},
+	"deployment":  {},
+	"deployments": {},
+}
+
+// infrastructureFiles are file name patterns of Helm, Terraform, and Kubernetes definitions
+var infrastructureFiles = []string{
+	"*.tf",
+	"*.tf.json",
+	"*.tfvars",
+	"*.tfvars.json",
+	".terraform.lock.hcl",
+	"terragrunt.hcl",
+	"Chart.yaml",
+	"Chart.lock",
+	"helmfile.yaml",
+	"helmfile.yml",
+	"kustomization.yaml",
+	"kustomization.yml",
+}
+
+// ClassifyPath returns the category of a repository-relative file path.
+// A file is infrastructure when its name matches a Helm, Terraform, or Kustomize file,
+// or when any of its parent directories is a conventional deployment directory (charts/, k8s/, deploy/, ...).
+func ClassifyPath(filePath string) PathCategory {
+	parts := strings.Split(filePath, "/")
+	for _, dir := range parts[:len(parts)-1] {
+		if _, ok := infrastructureDirectories[strings.ToLower(dir)]; ok {
+			return CategoryInfrastructure
+		}
+	}
+
+	name := parts[len(parts)-1]
+	for _, pattern := range infrastructureFiles {
+		if ok, _ := path.Match(pattern, name); ok {
+			return CategoryInfrastructure
+		}
+	}
+	return CategoryApplication
+}
+
+// BuildCategoryBreakdown aggregates per-file commits into the infrastructure and application categories.
+// A commit touching files of both categories counts for each of them.
+// Rows are ordered from least to most similar; categories without changes are omitted.
+func BuildCategoryBreakdown(result CompareResult, fileCommits map[string][]plumbing.Hash) []FileSimilarity {
+	categoryCommits := make(map[string][]plumbing.Hash)
+	for filePath, commits := range fileCommits {
+		category := string(ClassifyPath(filePath))
+		categoryCommits[category] = append(categoryCommits[category], commits...)
+	}
+
+	return BuildFileMatrix(result, categoryCommits)
+}
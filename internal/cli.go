@@ -20,9 +20,25 @@ var (
 type Command string
 
 const (
-	CompareCommand Command = "compare"
-	HelpCommand    Command = "help"
-	VersionCommand Command = "version"
+	CompareCommand     Command = "compare"
+	DiffCommand        Command = "diff"
+	ReportCommand      Command = "report"
+	CheckCommand       Command = "check"
+	VerifyCommand      Command = "verify"
+	HistoryCommand     Command = "history"
+	ExplainZeroCommand Command = "explain-zero"
+	PatchesCommand     Command = "patches"
+	SnapshotCommand    Command = "snapshot"
+	MatrixCommand      Command = "matrix"
+	GetCommand         Command = "get"
+	SuggestCommand     Command = "suggest"
+	RCAuditCommand     Command = "rc-audit"
+	IndexCommand       Command = "index"
+	CacheCommand       Command = "cache"
+	SelftestCommand    Command = "selftest"
+	TUICommand         Command = "tui"
+	HelpCommand        Command = "help"
+	VersionCommand     Command = "version"
 )
 
 // ParseCommand parses command-line arguments and returns the configuration
@@ -35,6 +51,38 @@ func ParseCommand(args []string) (Command, error) {
 	switch command {
 	case "compare":
 		return CompareCommand, nil
+	case "diff":
+		return DiffCommand, nil
+	case "report":
+		return ReportCommand, nil
+	case "check":
+		return CheckCommand, nil
+	case "verify":
+		return VerifyCommand, nil
+	case "history":
+		return HistoryCommand, nil
+	case "explain-zero":
+		return ExplainZeroCommand, nil
+	case "patches":
+		return PatchesCommand, nil
+	case "snapshot":
+		return SnapshotCommand, nil
+	case "matrix":
+		return MatrixCommand, nil
+	case "get":
+		return GetCommand, nil
+	case "suggest":
+		return SuggestCommand, nil
+	case "rc-audit":
+		return RCAuditCommand, nil
+	case "index":
+		return IndexCommand, nil
+	case "cache":
+		return CacheCommand, nil
+	case "selftest":
+		return SelftestCommand, nil
+	case "tui":
+		return TUICommand, nil
 	case "help":
 		return HelpCommand, nil
 	case "version":
@@ -0,0 +1,73 @@
+package internal
+
+import (
+	"container/list"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// defaultCommitCacheSize is the number of commit objects kept in memory per repository
+const defaultCommitCacheSize = 16384
+
+// commitCache is a least-recently-used cache of commit objects, safe for concurrent use
+type commitCache struct {
+	mu       sync.Mutex
+	capacity int
+	order    *list.List // Front is most recently used
+	entries  map[plumbing.Hash]*list.Element
+}
+
+type commitCacheEntry struct {
+	hash   plumbing.Hash
+	commit *object.Commit
+}
+
+// newCommitCache creates a cache holding at most capacity commits
+func newCommitCache(capacity int) *commitCache {
+	return &commitCache{
+		capacity: capacity,
+		order:    list.New(),
+		entries:  make(map[plumbing.Hash]*list.Element),
+	}
+}
+
+// Get returns the cached commit for hash and marks it as recently used
+func (c *commitCache) Get(hash plumbing.Hash) (*object.Commit, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[hash]
+	if !ok {
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return element.Value.(*commitCacheEntry).commit, true
+}
+
+// Add stores a commit, evicting the least recently used entry when the cache is full
+func (c *commitCache) Add(hash plumbing.Hash, commit *object.Commit) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[hash]; ok {
+		element.Value.(*commitCacheEntry).commit = commit
+		c.order.MoveToFront(element)
+		return
+	}
+
+	c.entries[hash] = c.order.PushFront(&commitCacheEntry{hash: hash, commit: commit})
+	if c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*commitCacheEntry).hash)
+	}
+}
+
+// Len returns the number of cached commits
+func (c *commitCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// TestCommitCacheEviction tests that the least recently used commit is evicted first
+func TestCommitCacheEviction(t *testing.T) {
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+	hash3 := plumbing.NewHash("0000000000000000000000000000000000000003")
+
+	cache := newCommitCache(2)
+	cache.Add(hash1, &object.Commit{Hash: hash1})
+	cache.Add(hash2, &object.Commit{Hash: hash2})
+
+	// Touch hash1 so hash2 becomes the least recently used entry
+	if commit, ok := cache.Get(hash1); !ok || commit.Hash != hash1 {
+		t.Fatalf("Get(hash1) = %v, %v, want cached commit", commit, ok)
+	}
+
+	cache.Add(hash3, &object.Commit{Hash: hash3})
+
+	if _, ok := cache.Get(hash2); ok {
+		t.Errorf("Get(hash2) found an entry that should have been evicted")
+	}
+	if _, ok := cache.Get(hash1); !ok {
+		t.Errorf("Get(hash1) missed a recently used entry")
+	}
+	if _, ok := cache.Get(hash3); !ok {
+		t.Errorf("Get(hash3) missed the newest entry")
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Len() = %d, want 2", cache.Len())
+	}
+}
+
+// TestCommitCacheUpdate tests that re-adding a hash replaces the entry without growing the cache
+func TestCommitCacheUpdate(t *testing.T) {
+	hash := plumbing.NewHash("0000000000000000000000000000000000000001")
+
+	cache := newCommitCache(2)
+	cache.Add(hash, &object.Commit{Message: "old"})
+	cache.Add(hash, &object.Commit{Message: "new"})
+
+	commit, ok := cache.Get(hash)
+	if !ok || commit.Message != "new" {
+		t.Errorf("Get() = %v, %v, want updated commit", commit, ok)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", cache.Len())
+	}
+}
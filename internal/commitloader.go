@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// commitLoadBatchSize is the number of hashes a worker loads per batch
+const commitLoadBatchSize = 64
+
+// commitLoadWorkers bounds the number of concurrent commit lookups
+var commitLoadWorkers = runtime.NumCPU()
+
+// loadCommits fetches the commit objects for hashes with a bounded pool of workers.
+// repo.GetCommitObject must be safe for concurrent use.
+// Results are returned in the order of hashes; a failed lookup leaves a nil commit
+// and its error at the same index.
+func loadCommits(repo Repository, hashes []plumbing.Hash) ([]*object.Commit, []error) {
+	commits := make([]*object.Commit, len(hashes))
+	errs := make([]error, len(hashes))
+
+	batches := make(chan int)
+	var wg sync.WaitGroup
+
+	workers := min(commitLoadWorkers, (len(hashes)+commitLoadBatchSize-1)/commitLoadBatchSize)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for start := range batches {
+				end := min(start+commitLoadBatchSize, len(hashes))
+				for i := start; i < end; i++ {
+					// Each index is written by exactly one worker
+					commits[i], errs[i] = repo.GetCommitObject(hashes[i])
+				}
+			}
+		}()
+	}
+
+	for start := 0; start < len(hashes); start += commitLoadBatchSize {
+		batches <- start
+	}
+	close(batches)
+	wg.Wait()
+
+	return commits, errs
+}
+
+// hashesOf returns the hashes of a commit set as a slice
+func hashesOf(commitSet map[plumbing.Hash]struct{}) []plumbing.Hash {
+	hashes := make([]plumbing.Hash, 0, len(commitSet))
+	for hash := range commitSet {
+		hashes = append(hashes, hash)
+	}
+	return hashes
+}
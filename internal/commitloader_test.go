@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestLoadCommits tests that commits are loaded in input order across several batches
+func TestLoadCommits(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	errMissing := errors.New("object not found")
+	missing := plumbing.NewHash("00000000000000000000000000000000000000ff")
+
+	var hashes []plumbing.Hash
+	for i := range commitLoadBatchSize*3 + 5 {
+		hashes = append(hashes, plumbing.NewHash(fmt.Sprintf("%040x", i+1)))
+	}
+	hashes = append(hashes, missing)
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetCommitObject(gomock.Any()).DoAndReturn(func(hash plumbing.Hash) (*object.Commit, error) {
+		if hash == missing {
+			return nil, errMissing
+		}
+		return &object.Commit{Hash: hash, Message: "commit " + hash.String()}, nil
+	}).Times(len(hashes))
+
+	commits, errs := loadCommits(mockRepo, hashes)
+	for i, hash := range hashes {
+		if hash == missing {
+			if !errors.Is(errs[i], errMissing) || commits[i] != nil {
+				t.Errorf("loadCommits()[%d] = %v, %v, want nil, %v", i, commits[i], errs[i], errMissing)
+			}
+			continue
+		}
+		if errs[i] != nil {
+			t.Errorf("loadCommits()[%d] error = %v, want nil", i, errs[i])
+		} else if commits[i].Hash != hash {
+			t.Errorf("loadCommits()[%d] hash = %s, want %s", i, commits[i].Hash, hash)
+		}
+	}
+}
+
+// TestLoadCommitsEmpty tests that no lookups happen for an empty input
+func TestLoadCommitsEmpty(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	commits, errs := loadCommits(mocks.NewMockRepository(ctrl), nil)
+	if len(commits) != 0 || len(errs) != 0 {
+		t.Errorf("loadCommits(nil) = %v, %v, want empty results", commits, errs)
+	}
+}
@@ -0,0 +1,271 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrMissingCacheSubcommand = errors.New("cache subcommand is required")
+	ErrUnknownCacheSubcommand = errors.New("unknown cache subcommand")
+	ErrClearCache             = errors.New("failed to clear cache")
+)
+
+// CacheDirEnv names the environment variable that overrides the directory of the commit set cache
+const CacheDirEnv = "GIT_TAG_SIMILARITY_CACHE_DIR"
+
+// CacheClearSubcommand deletes cached commit sets
+const CacheClearSubcommand = "clear"
+
+// CacheConfig holds the configuration of the cache command
+type CacheConfig struct {
+	Command    Command
+	Subcommand string
+	RepoPath   string // Only clear the commit sets of this repository; empty clears all
+}
+
+// cacheUsage is the help of the cache command
+var cacheUsage = commandUsage{
+	Name:        "cache",
+	Synopsis:    "<subcommand> [options]",
+	Summary:     "Manage the cache of commit sets kept between runs (cache clear)",
+	Description: "compare, matrix, and get store the commit set of every tag they traverse in the user cache\ndirectory, so later runs on the same repository skip the traversal. Set " + CacheDirEnv + "\nto move the cache, or pass -no-cache to those commands to bypass it.",
+	Examples: []string{
+		"cache clear",
+		"cache clear -repo /path/to/repo",
+	},
+	Subcommands: []commandUsage{cacheClearUsage},
+}
+
+// cacheClearUsage is the help of the cache clear command
+var cacheClearUsage = commandUsage{
+	Name:        "cache clear",
+	Summary:     "Delete cached commit sets",
+	Description: "Delete the cached commit sets of every repository, or of one repository with -repo.",
+	Examples: []string{
+		"cache clear",
+		"cache clear -repo /path/to/repo",
+	},
+}
+
+// NewCacheConfig parses the cache subcommand and its flags
+func NewCacheConfig(args []string) (CacheConfig, error) {
+	config := CacheConfig{Command: CacheCommand}
+
+	if len(args) < 1 {
+		printCommandUsage(os.Stderr, cacheUsage, nil)
+		return config, ErrMissingCacheSubcommand
+	}
+	config.Subcommand = args[0]
+
+	switch config.Subcommand {
+	case CacheClearSubcommand:
+		clearCmd := newCommandFlagSet(cacheClearUsage)
+		clearCmd.StringVar(&config.RepoPath, "repo", "", "Only clear the commit sets of this repository")
+
+		if err := clearCmd.Parse(args[1:]); err != nil {
+			return config, err
+		}
+	default:
+		printCommandUsage(os.Stderr, cacheUsage, nil)
+		return config, errors.Join(ErrUnknownCacheSubcommand, fmt.Errorf("unknown subcommand: %s", args[0]))
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *CacheConfig) Validate() error {
+	if c.RepoPath == "" {
+		return nil
+	}
+	if IsRemoteURL(c.RepoPath) {
+		return nil
+	}
+	return validateRepoPath(c.RepoPath)
+}
+
+// CacheClearStats describes the commit sets deleted by cache clear
+type CacheClearStats struct {
+	Path     string // Directory that was cleared
+	Sets     int
+	Bytes    int64
+	RepoPath string
+}
+
+// ClearCache deletes the cached commit sets of the configured repository, or of every repository
+func ClearCache(ctx context.Context, config CacheConfig) (CacheClearStats, error) {
+	if err := config.Validate(); err != nil {
+		return CacheClearStats{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	root, err := commitSetCacheRoot()
+	if err != nil {
+		return CacheClearStats{}, errors.Join(ErrClearCache, err)
+	}
+	stats := CacheClearStats{Path: root, RepoPath: config.RepoPath}
+	if config.RepoPath != "" {
+		path := config.RepoPath
+		if IsRemoteURL(path) {
+			// Clear the sets of the existing clone instead of fetching the repository; only full
+			// clones are cached, so a URL without one has nothing to clear
+			dir, ok, err := existingClone(path)
+			if err != nil {
+				return CacheClearStats{}, errors.Join(ErrClearCache, err)
+			}
+			if !ok {
+				return stats, nil
+			}
+			path = dir
+		}
+		repo, err := OpenGitRepositoryContext(ctx, path, 0)
+		if err != nil {
+			return CacheClearStats{}, errors.Join(ErrOpenRepository, err)
+		}
+		if stats.Path, err = repo.commitSetCacheDir(root); err != nil {
+			return CacheClearStats{}, errors.Join(ErrClearCache, err)
+		}
+	}
+
+	err = filepath.WalkDir(stats.Path, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		if info, err := entry.Info(); err == nil {
+			stats.Sets++
+			stats.Bytes += info.Size()
+		}
+		return nil
+	})
+	if errors.Is(err, fs.ErrNotExist) {
+		return stats, nil
+	}
+	if err != nil {
+		return CacheClearStats{}, errors.Join(ErrClearCache, err)
+	}
+	if err := os.RemoveAll(stats.Path); err != nil {
+		return CacheClearStats{}, errors.Join(ErrClearCache, err)
+	}
+	return stats, nil
+}
+
+// PrintCacheClearStats prints what cache clear deleted
+func PrintCacheClearStats(w io.Writer, stats CacheClearStats) {
+	scope := "all repositories"
+	if stats.RepoPath != "" {
+		scope = stats.RepoPath
+	}
+	_, _ = fmt.Fprintf(w, "Removed %d cached commit sets (%s) of %s\n", stats.Sets, formatFileSize(stats.Bytes), scope)
+	_, _ = fmt.Fprintf(w, "Cache: %s\n", stats.Path)
+}
+
+// commitSetCacheRoot returns the directory holding the cached commit sets of all repositories
+func commitSetCacheRoot() (string, error) {
+	if dir := os.Getenv(CacheDirEnv); dir != "" {
+		return dir, nil
+	}
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "git-tag-similarity", "commit-sets"), nil
+}
+
+// commitSetCache stores the commit set of a commit, which never changes, in one file per commit
+type commitSetCache struct {
+	dir string
+}
+
+// commitSetCacheDir returns the cache directory of the repository, named after a digest of the path of
+// its git directory, which linked worktrees share with their main repository
+func (gr *GitRepository) commitSetCacheDir(root string) (string, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return "", err
+	}
+	defer gr.returnReader(reader)
+
+	storage, ok := repositoryStorage(reader)
+	if !ok {
+		return "", errors.New("repository has no git directory")
+	}
+	gitDir, err := filepath.Abs(filepath.Dir(objectsDirectory(storage)))
+	if err != nil {
+		return "", err
+	}
+	digest := sha256.Sum256([]byte(gitDir))
+	return filepath.Join(root, hex.EncodeToString(digest[:8])), nil
+}
+
+// enableCommitSetCache makes traversals read and store commit sets in the cache. It must be called
+// right after opening, before the repository is shared. The clone of a remote repository persists
+// under the user cache directory (see CloneRemote), so it is cached like a local repository; the
+// truncated sets of a shallow clone would outlive an unshallowing fetch, so those are not cached.
+// Without a cache directory, nothing is cached.
+func (gr *GitRepository) enableCommitSetCache() {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return
+	}
+	shallow, err := reader.Storer.Shallow()
+	gr.returnReader(reader)
+	if err != nil || len(shallow) > 0 {
+		return
+	}
+
+	root, err := commitSetCacheRoot()
+	if err != nil {
+		return
+	}
+	dir, err := gr.commitSetCacheDir(root)
+	if err != nil {
+		return
+	}
+	gr.commitSets = &commitSetCache{dir: dir}
+}
+
+// load returns the cached commit set of a commit, or false when it is not cached
+func (c *commitSetCache) load(commit plumbing.Hash) ([]plumbing.Hash, bool) {
+	data, err := os.ReadFile(filepath.Join(c.dir, commit.String()))
+	if err != nil || len(data)%len(plumbing.Hash{}) != 0 {
+		return nil, false
+	}
+
+	hashes := make([]plumbing.Hash, len(data)/len(plumbing.Hash{}))
+	for i := range hashes {
+		copy(hashes[i][:], data[i*len(plumbing.Hash{}):])
+	}
+	return hashes, true
+}
+
+// store caches the commit set of a commit. The cache only saves work, so failures are ignored.
+func (c *commitSetCache) store(commit plumbing.Hash, hashes []plumbing.Hash) {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return
+	}
+	file, err := os.CreateTemp(c.dir, commit.String()+".*")
+	if err != nil {
+		return
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	data := make([]byte, 0, len(hashes)*len(plumbing.Hash{}))
+	for _, hash := range hashes {
+		data = append(data, hash[:]...)
+	}
+	_, err = file.Write(data)
+	if closeErr := file.Close(); err != nil || closeErr != nil {
+		return
+	}
+	// Renaming makes a concurrent run see the whole set or none of it
+	_ = os.Rename(file.Name(), filepath.Join(c.dir, commit.String()))
+}
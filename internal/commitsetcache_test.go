@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestCommitSetCache tests that a traversed commit set is cached, read back by later runs unless -no-cache
+// is set, and deleted by cache clear
+func TestCommitSetCache(t *testing.T) {
+	t.Setenv(CacheDirEnv, t.TempDir())
+	fixture := newReleaseFixture(t)
+	config := CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		SetsOnly:   true,
+	}
+
+	first, err := Compare(config)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	repo := openFixture(t, fixture)
+	repo.enableCommitSetCache()
+	if repo.commitSets == nil {
+		t.Fatal("enableCommitSetCache() left the cache disabled")
+	}
+	cached, ok := repo.commitSets.load(fixture.Hash("v1.1.0"))
+	if !ok || len(cached) != 3 {
+		t.Fatalf("cached set of v1.1.0 = %d commits, %v, want 3", len(cached), ok)
+	}
+
+	// Later runs read the cached set instead of the missing commit object
+	if err := os.Remove(fixture.ObjectPath(fixture.Hash("v1.1.0~1"))); err != nil {
+		t.Fatalf("failed to remove commit object: %v", err)
+	}
+	second, err := Compare(config)
+	if err != nil {
+		t.Fatalf("Compare() with a cached set error = %v", err)
+	}
+	if second.Similarity != first.Similarity || len(second.Warnings) != 0 {
+		t.Errorf("Compare() with a cached set = %v, %q, want %v without warnings", second.Similarity, second.Warnings, first.Similarity)
+	}
+
+	config.NoCache = true
+	bypassed, err := Compare(config)
+	if err != nil {
+		t.Fatalf("Compare(-no-cache) error = %v", err)
+	}
+	if len(bypassed.Warnings) != 1 {
+		t.Errorf("Compare(-no-cache) warnings = %q, want the unreadable commit", bypassed.Warnings)
+	}
+
+	stats, err := ClearCache(context.Background(), CacheConfig{Subcommand: CacheClearSubcommand, RepoPath: fixture.Path()})
+	if err != nil {
+		t.Fatalf("ClearCache() error = %v", err)
+	}
+	if stats.Sets != 2 {
+		t.Errorf("ClearCache() removed %d sets, want 2", stats.Sets)
+	}
+	if _, ok := repo.commitSets.load(fixture.Hash("v1.1.0")); ok {
+		t.Error("commit set of v1.1.0 is still cached after cache clear")
+	}
+}
+
+// TestCommitSetCache_Damaged tests that a stored set is read back, and a cache file of the wrong size is ignored
+func TestCommitSetCache_Damaged(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	cache := commitSetCache{dir: t.TempDir()}
+
+	hash := fixture.Hash("v1.1.0")
+	want := []plumbing.Hash{fixture.Hash("v1.1.0"), fixture.Hash("v1.0.0")}
+	cache.store(hash, want)
+	got, ok := cache.load(hash)
+	if !ok || !reflect.DeepEqual(got, want) {
+		t.Fatalf("load() = %v, %v, want %v", got, ok, want)
+	}
+
+	if err := os.WriteFile(filepath.Join(cache.dir, hash.String()), []byte("short"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.load(hash); ok {
+		t.Error("load() read a damaged cache file")
+	}
+}
+
+// TestNewCacheConfig tests parsing the cache subcommands
+func TestNewCacheConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		want    CacheConfig
+		wantErr bool
+	}{
+		{name: "clear all", args: []string{"clear"}, want: CacheConfig{Command: CacheCommand, Subcommand: CacheClearSubcommand}},
+		{name: "clear one repository", args: []string{"clear", "-repo", "/tmp/repo"}, want: CacheConfig{Command: CacheCommand, Subcommand: CacheClearSubcommand, RepoPath: "/tmp/repo"}},
+		{name: "missing subcommand", args: nil, wantErr: true},
+		{name: "unknown subcommand", args: []string{"purge"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NewCacheConfig(tt.args)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewCacheConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("NewCacheConfig() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
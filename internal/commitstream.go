@@ -0,0 +1,89 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"iter"
+	"slices"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// SortedCommitHashes returns the commits reachable from a tag as a sorted slice. The slice holds just
+// one hash per commit, less than a commit set with its hash table, which matters when many tags are
+// held at once, as in a similarity matrix. The traversal behind it still tracks every commit it visits,
+// so the peak memory of a call grows with the history as GetCommitSetForTag's does; only tags served
+// from the index or the commit set cache are read without that.
+func SortedCommitHashes(ctx context.Context, repo Repository, ref *plumbing.Reference) ([]plumbing.Hash, error) {
+	var hashes []plumbing.Hash
+	err := repo.ForEachCommitInTag(ctx, ref, func(hash plumbing.Hash) error {
+		hashes = append(hashes, hash)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	slices.SortFunc(hashes, compareHashes)
+	return slices.Clip(hashes), nil
+}
+
+// sortedHashes returns the hashes of a commit set as a sorted slice
+func sortedHashes(set map[plumbing.Hash]struct{}) []plumbing.Hash {
+	hashes := make([]plumbing.Hash, 0, len(set))
+	for hash := range set {
+		hashes = append(hashes, hash)
+	}
+	slices.SortFunc(hashes, compareHashes)
+	return hashes
+}
+
+func compareHashes(a plumbing.Hash, b plumbing.Hash) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+// MergeSortedHashes merges ascending sequences of distinct hashes (a k-way merge), yielding each hash
+// once in ascending order with the number of sequences that contain it. Only the head of each
+// sequence is held in memory, so the sequences can be streamed from disk or a database.
+func MergeSortedHashes(seqs ...iter.Seq[plumbing.Hash]) iter.Seq2[plumbing.Hash, int] {
+	return func(yield func(plumbing.Hash, int) bool) {
+		type head struct {
+			next func() (plumbing.Hash, bool)
+			hash plumbing.Hash
+			ok   bool
+		}
+
+		heads := make([]head, len(seqs))
+		for i, seq := range seqs {
+			next, stop := iter.Pull(seq)
+			defer stop()
+			heads[i].next = next
+			heads[i].hash, heads[i].ok = next()
+		}
+
+		for {
+			// The smallest head is the next hash; every sequence starting with it advances.
+			// A linear scan beats a heap for the handful of sequences compared at once.
+			var smallest *plumbing.Hash
+			for i := range heads {
+				if heads[i].ok && (smallest == nil || compareHashes(heads[i].hash, *smallest) < 0) {
+					smallest = &heads[i].hash
+				}
+			}
+			if smallest == nil {
+				return
+			}
+
+			hash, count := *smallest, 0
+			for i := range heads {
+				if heads[i].ok && heads[i].hash == hash {
+					count++
+					heads[i].hash, heads[i].ok = heads[i].next()
+				}
+			}
+			if !yield(hash, count) {
+				return
+			}
+		}
+	}
+}
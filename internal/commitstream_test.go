@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"slices"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestMergeSortedHashes tests the k-way merge of sorted hash sequences
+func TestMergeSortedHashes(t *testing.T) {
+	a, b, c, d := hashFromString("a"), hashFromString("b"), hashFromString("c"), hashFromString("d")
+
+	var hashes []plumbing.Hash
+	var counts []int
+	merged := MergeSortedHashes(slices.Values([]plumbing.Hash{a, c}), slices.Values([]plumbing.Hash{a, b, c}), slices.Values([]plumbing.Hash{c, d}))
+	for hash, count := range merged {
+		hashes = append(hashes, hash)
+		counts = append(counts, count)
+	}
+
+	if want := []plumbing.Hash{a, b, c, d}; !reflect.DeepEqual(hashes, want) {
+		t.Errorf("merged hashes = %v, want %v", hashes, want)
+	}
+	if want := []int{2, 1, 3, 1}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("counts = %v, want %v", counts, want)
+	}
+
+	// Stopping early must not read further
+	for hash := range merged {
+		if hash != a {
+			t.Errorf("first hash = %v, want %v", hash, a)
+		}
+		break
+	}
+}
+
+// TestSortedCommitHashes tests streaming the commits of a tag into a sorted slice
+func TestSortedCommitHashes(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	hashes, err := SortedCommitHashes(context.Background(), repo, fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("SortedCommitHashes() error = %v", err)
+	}
+
+	set, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() error = %v", err)
+	}
+	if want := sortedHashes(set); !reflect.DeepEqual(hashes, want) {
+		t.Errorf("SortedCommitHashes() = %v, want %v", hashes, want)
+	}
+	if !slices.IsSortedFunc(hashes, compareHashes) {
+		t.Errorf("SortedCommitHashes() is not sorted: %v", hashes)
+	}
+}
+
+// TestForEachCommitInTag_Stop tests that an error returned by the callback stops the traversal
+func TestForEachCommitInTag_Stop(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	errStop := errors.New("stop")
+	visited := 0
+	err := repo.ForEachCommitInTag(context.Background(), fixture.Reference("v1.1.0"), func(plumbing.Hash) error {
+		visited++
+		return errStop
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("ForEachCommitInTag() error = %v, want the callback's error", err)
+	}
+	if visited != 1 {
+		t.Errorf("visited %d commits, want 1", visited)
+	}
+}
@@ -1,13 +1,17 @@
 package internal
 
 import (
+	"context"
 	"errors"
-	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 var (
@@ -16,87 +20,332 @@ var (
 	ErrGetTagReference      = errors.New("failed to get tag reference")
 	ErrGetCommits           = errors.New("failed to get commits")
 	ErrInvalidDirectory     = errors.New("invalid directory path")
+	ErrFirstParentConflict  = errors.New("first-parent history does not support per-file results")
+	ErrAttestationKeyOnly   = errors.New("signing key given without an attestation path")
+	ErrInvalidThreshold     = errors.New("invalid similarity threshold")
+	ErrBelowMinSimilarity   = errors.New("similarity is below the minimum")
+	ErrCanceled             = errors.New("run canceled")
+	ErrInvalidTimeout       = errors.New("invalid timeout")
 )
 
-func PrintCompareResult(result CompareResult) {
+func PrintCompareResult(ctx context.Context, result CompareResult) {
 	fmt.Printf("Comparing tags: %s vs %s\n", result.Config.Tag1Name, result.Config.Tag2Name)
-	if result.Config.Directory != "" {
-		fmt.Printf("Directory filter: %s\n", result.Config.Directory)
+	if result.Tag1Branch.Branch != "" || result.Tag2Branch.Branch != "" {
+		fmt.Printf("Branches: %s: %s, %s: %s\n", result.Config.Tag1Name, result.Tag1Branch, result.Config.Tag2Name, result.Tag2Branch)
 	}
-	fmt.Printf("Similarity: %.2f%%\n", result.Similarity*100.0)
+	if filter := result.Config.directoryFilter(); !filter.IsZero() {
+		fmt.Printf("Directory filter: %s\n", filter)
+	}
+	if result.Config.Profile != ProfileNone {
+		fmt.Printf("Path profile: %s (%s)\n", result.Config.Profile, result.Config.Profile.Description())
+	}
+	if !result.Config.Baseline.IsZero() {
+		fmt.Printf("Excluding commits reachable from: %s\n", result.Config.Baseline)
+	}
+	if !result.Config.Authors.IsZero() {
+		fmt.Printf("Author filter: %s\n", result.Config.Authors)
+	}
+	if !result.Config.Dates.IsZero() {
+		fmt.Printf("Date range: %s (committer date)\n", result.Config.Dates)
+	}
+	if !result.Config.Merges.IsZero() {
+		fmt.Printf("Merge commits: %s\n", result.Config.Merges.Description())
+	}
+	if result.Config.FirstParent {
+		fmt.Printf("History: first-parent (each merged branch counts as one change)\n")
+	}
+	if len(result.UnreadableCommits) > 0 {
+		fmt.Printf("Similarity: %.2f%% (approximate, %d commits unreadable)\n", result.Similarity*100.0, len(result.UnreadableCommits))
+	} else {
+		fmt.Printf("Similarity: %.2f%%\n", result.Similarity*100.0)
+	}
+	if result.Config.MinSimilarity > 0 {
+		status := "passed"
+		if result.CheckMinSimilarity() != nil {
+			status = "FAILED"
+		}
+		fmt.Printf("Minimum similarity: %.2f%% (%s)\n", result.Config.MinSimilarity*100.0, status)
+	}
+	if result.Interpretation != "" {
+		fmt.Printf("Interpretation: %s\n", result.Interpretation)
+	}
+	if result.MessageSimilarity != nil {
+		fmt.Printf("Message similarity: %.2f%% (commit subjects, matched across rebases)\n", *result.MessageSimilarity*100.0)
+	}
+	if result.TreeSimilarity != nil {
+		fmt.Printf("File similarity: %.2f%% (files with the same path and content, ignoring history)\n", *result.TreeSimilarity*100.0)
+	}
+	if result.LineSimilarity != nil {
+		fmt.Printf("Line similarity: %.2f%% (lines of code unchanged between the tag trees, ignoring history)\n", *result.LineSimilarity*100.0)
+	}
+	printRewriteAnalysis(result)
+	printPatchEquivalents(result)
 	fmt.Printf("\nSummary:\n")
 	fmt.Printf("  Total commits in [%s]: %d\n", result.Config.Tag1Name, len(result.OnlyInTag1)+len(result.SharedCommits))
 	fmt.Printf("  Total commits in [%s]: %d\n", result.Config.Tag2Name, len(result.OnlyInTag2)+len(result.SharedCommits))
 	fmt.Printf("  Shared commits: %d\n", len(result.SharedCommits))
-	fmt.Printf("  Unique to [%s]: %d\n", result.Config.Tag1Name, len(result.OnlyInTag1))
-	fmt.Printf("  Unique to [%s]: %d\n", result.Config.Tag2Name, len(result.OnlyInTag2))
+	fmt.Printf("  Unique to [%s]: %d%s\n", result.Config.Tag1Name, len(result.OnlyInTag1), formatTopologyCounts(len(result.OnlyInTag1), result.Tag1Mainline))
+	fmt.Printf("  Unique to [%s]: %d%s\n", result.Config.Tag2Name, len(result.OnlyInTag2), formatTopologyCounts(len(result.OnlyInTag2), result.Tag2Mainline))
+	if len(result.UnreadableCommits) > 0 {
+		fmt.Printf("  Unreadable commits: %d\n", len(result.UnreadableCommits))
+	}
+	PrintWarnings(os.Stdout, result.Warnings)
+
+	printTagAudits(result)
+	printArchiveVerifications(result)
+	printPathBreakdown(result)
+	printCategoryBreakdown(result)
+	printTestChangeStats(result)
+	printRiskScore(result)
+	if result.Policy != nil {
+		PrintPolicyResult(os.Stdout, *result.Policy)
+	}
 
 	// Print detailed commit lists if verbose flag is set
-	if result.Config.Verbose {
-		printDiffCommits(result.Repo, result.Config.Tag1Name, result.OnlyInTag1)
-		printDiffCommits(result.Repo, result.Config.Tag2Name, result.OnlyInTag2)
+	if result.Config.GroupByPR {
+		printGroupedCommits(ctx, result.Repo, result.Tag1Ref, result.Config.Tag1Name, result.OnlyInTag1, result.Config.Output)
+		printGroupedCommits(ctx, result.Repo, result.Tag2Ref, result.Config.Tag2Name, result.OnlyInTag2, result.Config.Output)
+	} else if result.Config.Verbose {
+		printDiffCommits(result.Repo, result.Config.Tag1Name, result.OnlyInTag1, result.Tag1Mainline, result.Config.Output)
+		printDiffCommits(result.Repo, result.Config.Tag2Name, result.OnlyInTag2, result.Tag2Mainline, result.Config.Output)
 	}
 }
 
 func Compare(config CompareConfig) (CompareResult, error) {
-	result := CompareResult{Config: config}
+	return CompareContext(context.Background(), config)
+}
+
+// CompareContext is Compare, stopping when ctx is done or config.Timeout has passed. A canceled
+// comparison returns ErrCanceled joined with the context's error, e.g. context.DeadlineExceeded.
+func CompareContext(ctx context.Context, config CompareConfig) (CompareResult, error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	result, err := compare(ctx, config)
+	return result, end(err)
+}
+
+func compare(ctx context.Context, config CompareConfig) (CompareResult, error) {
+	result := CompareResult{Config: config, Timings: &Timings{}}
+	phases := phaseRecorder{timings: result.Timings, progress: config.Progress}
 
 	// Validate basic configuration
 	if err := config.Validate(); err != nil {
 		return result, errors.Join(ErrInvalidConfiguration, err)
 	}
 
+	config.Directory, _ = cleanDirectory(config.Directory)
+	config.Paths, _ = config.Paths.clean()
+	result.Config = config
+
+	// Load the project config and policy before the comparison, so a missing or invalid config fails fast.
+	// Without a policy, the config is optional and only scopes namespaced tags to their service.
+	loadProject := LoadOptionalProjectConfig
+	if config.PolicyName != "" {
+		loadProject = LoadProjectConfig
+	}
+	project, err := loadProject(config.ConfigPath, config.RepoPath)
+	if err != nil {
+		return result, errors.Join(ErrInvalidConfiguration, err)
+	}
+	if config.Format == "" {
+		config.Format = project.Defaults.Format
+		result.Config = config
+	}
+	var policy Policy
+	if config.PolicyName != "" {
+		if policy, err = project.Policy(config.PolicyName); err != nil {
+			return result, errors.Join(ErrInvalidConfiguration, err)
+		}
+	}
+
 	// 2. Open repository
-	repo, err := NewGitRepository(config.RepoPath)
+	done := phases.start("open repository")
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, config.CloneDepth)
 	if err != nil {
 		return result, errors.Join(ErrOpenRepository, err)
 	}
+	done()
+
+	repo.strict = config.Strict
+	repo.dates = config.Dates
+	if !config.NoCache {
+		repo.enableCommitSetCache()
+	}
 
 	// Store repo in result for later use (e.g., verbose output)
 	result.Repo = repo
 
-	// 3. Validate that both tags exist in the repository
-	if err := config.ValidateWithRepository(repo); err != nil {
-		return result, errors.Join(ErrValidationFailed, err)
+	// 3. Resolve "latest"/"latest-N" references and get references for both tags
+	done = phases.start("resolve tags")
+	tag1Ref, tag2Ref, err := config.ResolveTags(ctx, repo)
+	result.Config = config
+	if err != nil {
+		return result, err
 	}
 
-	// 4. Get tag references for both tags
-	tag1Ref, err := config.GetTagReference(repo, config.Tag1Name)
-	if err != nil {
-		return result, errors.Join(ErrGetTagReference, err)
+	result.Tag1Ref = tag1Ref
+	result.Tag2Ref = tag2Ref
+
+	// Scope the tags of a monorepo service, e.g. service-a/v1.2.0, to the service's directory,
+	// and fall back to the directory filters the project shares
+	if config.Directory == "" && config.Paths.IsZero() {
+		if directory, ok := project.ServiceDirectory(config.Tag1Name, config.Tag2Name); ok {
+			config.Directory = directory
+		} else {
+			config.Directory, config.Paths = splitDirectoryFilter(project.Defaults.directoryFilter())
+		}
+		result.Config = config
 	}
 
-	tag2Ref, err := config.GetTagReference(repo, config.Tag2Name)
-	if err != nil {
-		return result, errors.Join(ErrGetTagReference, err)
+	// 4. Check that the directory filter exists in at least one of the tags
+	if err := validateDirectoryFilterInTags(repo, config.directoryFilter(), tag1Ref, tag2Ref); err != nil {
+		return result, errors.Join(ErrValidationFailed, err)
+	}
+	done()
+
+	if !config.SetsOnly {
+		// Label the branches the tags were cut from; detection is best-effort and never fails the comparison
+		done = phases.start("tag metadata")
+		for _, tag := range []struct {
+			name   string
+			ref    *plumbing.Reference
+			branch *TagBranch
+		}{{config.Tag1Name, tag1Ref, &result.Tag1Branch}, {config.Tag2Name, tag2Ref, &result.Tag2Branch}} {
+			if *tag.branch, err = DetectTagBranch(ctx, repo, tag.ref); err != nil {
+				result.AddWarning("could not detect the branch of %s: %v", tag.name, err)
+			}
+		}
+
+		// Record who created the tags and from which commit, for release sign-off; like the branch
+		// detection, a failed audit leaves a warning and the record collected so far
+		if result.Tag1Audit, err = AuditTag(repo, tag1Ref); err != nil {
+			result.AddWarning("could not audit %s: %v", config.Tag1Name, err)
+		}
+		if result.Tag2Audit, err = AuditTag(repo, tag2Ref); err != nil {
+			result.AddWarning("could not audit %s: %v", config.Tag2Name, err)
+		}
+		done()
 	}
 
 	// 5. Get commit sets for both tags (with optional directory filtering)
-	var tag1Commits, tag2Commits map[plumbing.Hash]struct{}
-	if config.Directory != "" {
-		tag1Commits, err = repo.GetCommitSetForTagFilteredByDirectory(tag1Ref, config.Directory)
-		if err != nil {
-			return result, errors.Join(ErrGetCommits, err)
+	getCommitSet := func(ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error) {
+		switch {
+		case config.FirstParent:
+			return repo.GetFirstParentCommitSet(ctx, ref, config.Directory)
+		case config.Profile != ProfileNone || !config.Paths.IsZero():
+			return repo.GetCommitSetForTagFilteredByPathspecs(ctx, ref, config.pathspecs())
+		case config.Directory != "":
+			return repo.GetCommitSetForTagFilteredByDirectory(ctx, ref, config.Directory)
+		default:
+			return repo.GetCommitSetForTag(ctx, ref)
 		}
+	}
 
-		tag2Commits, err = repo.GetCommitSetForTagFilteredByDirectory(tag2Ref, config.Directory)
+	var tag1Commits, tag2Commits map[plumbing.Hash]struct{}
+	if !config.FirstParent && config.Profile == ProfileNone && config.directoryFilter().IsZero() {
+		// Full histories are traversed concurrently, in one phase
+		done = phases.start("traverse " + config.Tag1Name + ", " + config.Tag2Name)
+		tag1Commits, tag2Commits, err = getCommitSetsConcurrently(ctx, repo, tag1Ref, tag2Ref)
 		if err != nil {
 			return result, errors.Join(ErrGetCommits, err)
 		}
+		done()
 	} else {
-		tag1Commits, err = repo.GetCommitSetForTag(tag1Ref)
+		done = phases.start("traverse " + config.Tag1Name)
+		tag1Commits, err = getCommitSet(tag1Ref)
 		if err != nil {
 			return result, errors.Join(ErrGetCommits, err)
 		}
+		done()
 
-		tag2Commits, err = repo.GetCommitSetForTag(tag2Ref)
+		done = phases.start("traverse " + config.Tag2Name)
+		tag2Commits, err = getCommitSet(tag2Ref)
 		if err != nil {
 			return result, errors.Join(ErrGetCommits, err)
 		}
+		done()
+	}
+
+	// Leave out the commits both tags inherit from the baseline
+	if !config.Baseline.IsZero() {
+		done = phases.start("exclude reachable")
+		excluded, err := config.Baseline.Resolve(ctx, repo)
+		if err != nil {
+			return result, err
+		}
+		tag1Commits = withoutCommits(tag1Commits, excluded)
+		tag2Commits = withoutCommits(tag2Commits, excluded)
+		for _, tag := range []struct {
+			name    string
+			commits map[plumbing.Hash]struct{}
+		}{{config.Tag1Name, tag1Commits}, {config.Tag2Name, tag2Commits}} {
+			if len(tag.commits) == 0 {
+				result.AddWarning("every commit of %s is reachable from %s", tag.name, config.Baseline)
+			}
+		}
+		done()
+	}
+
+	// Keep only the commits of the selected authors and committers
+	if !config.Authors.IsZero() {
+		done = phases.start("author filter")
+		if tag1Commits, err = config.Authors.Apply(repo, tag1Commits); err != nil {
+			return result, err
+		}
+		if tag2Commits, err = config.Authors.Apply(repo, tag2Commits); err != nil {
+			return result, err
+		}
+		done()
+	}
+
+	// Leave out merge commits, or keep only them
+	if !config.Merges.IsZero() {
+		done = phases.start("merge filter")
+		if tag1Commits, err = config.Merges.Apply(repo, tag1Commits); err != nil {
+			return result, err
+		}
+		if tag2Commits, err = config.Merges.Apply(repo, tag2Commits); err != nil {
+			return result, err
+		}
+		done()
+	}
+	phases.commits(config.Tag1Name, len(tag1Commits))
+	phases.commits(config.Tag2Name, len(tag2Commits))
+
+	// A filter that leaves a tag without commits makes the similarity meaningless
+	if !config.Authors.IsZero() || !config.Dates.IsZero() || !config.Merges.IsZero() {
+		for _, tag := range []struct {
+			name    string
+			commits map[plumbing.Hash]struct{}
+		}{{config.Tag1Name, tag1Commits}, {config.Tag2Name, tag2Commits}} {
+			if len(tag.commits) == 0 {
+				result.AddWarning("no commits of %s pass the author, date, and merge filters", tag.name)
+			}
+		}
+	}
+
+	result.UnreadableCommits = repo.UnreadableCommits()
+	if len(result.UnreadableCommits) > 0 {
+		result.AddWarning("%d commits could not be read; the history behind them was skipped and the similarity is approximate (run 'check' for remediation hints)", len(result.UnreadableCommits))
+	}
+	for _, directory := range repo.ApproximatedDirectories() {
+		result.AddWarning("git log could not read the history of %s; its commits were found by comparing directory trees, which may count merges differently", directory)
+	}
+
+	// Treat cherry-picked commits as shared by replacing them with their equivalent in the first tag
+	if config.Match == MatchPatchID {
+		done = phases.start("patch ids")
+		result.PatchEquivalents, err = MatchPatchEquivalents(ctx, repo, tag1Ref, tag2Ref, tag1Commits, tag2Commits)
+		if err != nil {
+			return result, err
+		}
+		tag2Commits = applyPatchEquivalents(tag2Commits, result.PatchEquivalents)
+		done()
 	}
 
 	// 6. Calculate similarity
+	done = phases.start("set math")
 	result.Similarity = CalculateJaccardSimilarity(tag1Commits, tag2Commits)
+	result.Interpretation = InterpretSimilarity(project.scoreBands(), result.Similarity)
 
 	// 7. Calculate shared and unique commits
 	result.SharedCommits = make(map[plumbing.Hash]struct{})
@@ -116,158 +365,596 @@ func Compare(config CompareConfig) (CompareResult, error) {
 			result.OnlyInTag2[hash] = struct{}{}
 		}
 	}
+	done()
+
+	// Compare the commit subjects, which match even when a rebase changed every hash
+	if config.Metric == MetricMessage {
+		done = phases.start("message similarity")
+		similarity, err := MessageSimilarity(repo, tag1Commits, tag2Commits)
+		if err != nil {
+			return result, err
+		}
+		result.MessageSimilarity = &similarity
+		done()
+	}
+
+	// Compare the content of the tags, which is the same after a rewrite that kept every file
+	if config.Metric == MetricFiles {
+		done = phases.start("tree similarity")
+		similarity, err := TreeSimilarity(ctx, repo, tag1Ref, tag2Ref, config.directoryFilter())
+		if err != nil {
+			return result, err
+		}
+		result.TreeSimilarity = &similarity
+		done()
+	}
+
+	// Compare how much code differs between the tags, however many commits it took
+	if config.Metric == MetricLines {
+		done = phases.start("line similarity")
+		similarity, err := LineSimilarity(ctx, repo, tag1Ref, tag2Ref, config.pathspecs())
+		if err != nil {
+			return result, err
+		}
+		result.LineSimilarity = &similarity
+		done()
+	}
+
+	// Check diverged tags for rewritten history, which makes the similarity misleadingly low.
+	// Matching by patch ID already shares the rewritten commits.
+	if !config.SetsOnly && !config.FirstParent && config.Match != MatchPatchID && rewriteCheckApplies(result) {
+		done = phases.start("rewrite check")
+		if result.Rewrite, err = AnalyzeRewrite(ctx, repo, result); err != nil {
+			return result, err
+		}
+		done()
+	}
+
+	// Tell commits made on the release lines from commits of merged side branches
+	if !config.SetsOnly && !config.FirstParent {
+		done = phases.start("topology")
+		if result.Tag1Mainline, err = ClassifyTopology(ctx, repo, tag1Ref, result.OnlyInTag1); err != nil {
+			return result, err
+		}
+		if result.Tag2Mainline, err = ClassifyTopology(ctx, repo, tag2Ref, result.OnlyInTag2); err != nil {
+			return result, err
+		}
+		done()
+	}
+
+	// 8. Build the per-file similarity matrix and per-path breakdown if requested
+	if config.FileMatrixPath != "" || config.Depth > 0 {
+		done = phases.start("per-file commits")
+		fileCommits, err := repo.GetFileCommits(ctx, tag1Ref, tag2Ref, config.directoryFilter().Pathspecs())
+		if err != nil {
+			return result, errors.Join(ErrGetFileCommits, err)
+		}
+		if config.FileMatrixPath != "" {
+			result.FileMatrix = BuildFileMatrix(result, fileCommits)
+		}
+		if config.Depth > 0 {
+			result.PathBreakdown = BuildPathBreakdown(result, fileCommits, config.Depth)
+			result.CategoryBreakdown = BuildCategoryBreakdown(result, fileCommits)
+		}
+		done()
+	}
+
+	// 9. Classify the files changed by the unique commits into test and non-test files if requested
+	if config.TestRatio {
+		patterns := config.TestFiles
+		if patterns == nil {
+			patterns = TestPatterns(defaultTestPatterns)
+		}
+		done = phases.start("test ratio")
+
+		tag1Tests, err := ComputeTestChangeStats(ctx, repo, result.OnlyInTag1, config.directoryFilter(), patterns)
+		if err != nil {
+			return result, err
+		}
+
+		tag2Tests, err := ComputeTestChangeStats(ctx, repo, result.OnlyInTag2, config.directoryFilter(), patterns)
+		if err != nil {
+			return result, err
+		}
+		result.Tag1Tests = &tag1Tests
+		result.Tag2Tests = &tag2Tests
+		done()
+	}
+
+	// 10. Score the risk of the change if requested
+	if config.Risk {
+		done = phases.start("risk score")
+		risk, err := ScoreRisk(ctx, repo, result, config.RiskWeights)
+		if err != nil {
+			return result, err
+		}
+		result.Risk = &risk
+		done()
+	}
+
+	// 11. Evaluate the comparison against the policy if requested
+	if config.PolicyName != "" {
+		done = phases.start("policy")
+		evaluation, err := EvaluatePolicy(ctx, repo, result, config.PolicyName, policy)
+		if err != nil {
+			return result, err
+		}
+		result.Policy = &evaluation
+		done()
+	}
+
+	// 12. Verify the published source archives of the tags if requested
+	if config.ChecksumsPath != "" {
+		done = phases.start("checksums")
+		if result.Archives, err = verifyReleaseArchives(ctx, repo, result); err != nil {
+			return result, err
+		}
+		done()
+	}
 
 	return result, nil
 }
 
-// printDiffCommits prints the commit messages for commits unique to a tag
-func printDiffCommits(repo Repository, tagName string, diffSet map[plumbing.Hash]struct{}) {
+// printDiffCommits prints the commit messages for commits unique to a tag.
+// With the mainline commits of the tag, commits of merged side branches are marked.
+func printDiffCommits(repo Repository, tagName string, diffSet map[plumbing.Hash]struct{}, mainline map[plumbing.Hash]struct{}, output OutputOptions) {
 	if len(diffSet) == 0 {
 		return
 	}
 
 	fmt.Printf("\nCommits only in [%s] (%d):\n", tagName, len(diffSet))
-	for hash := range diffSet {
-		commit, err := repo.GetCommitObject(hash)
-		if err != nil {
-			fmt.Printf("  - %s (failed to get message: %v)\n", hash.String(), err)
+	hashes := hashesOf(diffSet)
+	commits, err := repo.GetCommitObjects(hashes)
+	errs := make([]error, len(hashes))
+	if err != nil {
+		// Read the commits one by one, so only the unreadable ones lose their message
+		commits = make([]*object.Commit, len(hashes))
+		for i, hash := range hashes {
+			commits[i], errs[i] = repo.GetCommitObject(hash)
+		}
+	}
+
+	for i, hash := range hashes {
+		if errs[i] != nil {
+			fmt.Printf("  - %s (failed to get message: %v)\n", hash.String(), errs[i])
 			continue
 		}
 		// Get only the first line of the message
-		message := strings.Split(commit.Message, "\n")[0]
-		fmt.Printf("  - %s : %s\n", hash.String()[:7], message)
+		message := strings.Split(commits[i].Message, "\n")[0]
+		prefix := fmt.Sprintf("  - %s : ", hash.String()[:7])
+		if commitTopology(mainline, hash) == TopologySideBranch {
+			prefix = fmt.Sprintf("  - %s (side branch) : ", hash.String()[:7])
+		}
+		fmt.Printf("%s%s\n", prefix, output.FitLine(message, len(prefix)))
+	}
+}
+
+// printGroupedCommits prints the commits unique to a tag grouped by the merge or pull request that
+// introduced them, falling back to the flat list when the history cannot be grouped
+func printGroupedCommits(ctx context.Context, repo Repository, tag *plumbing.Reference, tagName string, diffSet map[plumbing.Hash]struct{}, output OutputOptions) {
+	if len(diffSet) == 0 {
+		return
+	}
+
+	groups, err := GroupCommitsByMerge(ctx, repo, tag, diffSet)
+	if err != nil {
+		printDiffCommits(repo, tagName, diffSet, nil, output)
+		fmt.Printf("  (failed to group by merge: %v)\n", err)
+		return
+	}
+
+	fmt.Printf("\nCommits only in [%s] (%d in %d changes):\n", tagName, len(diffSet), len(groups))
+	for _, group := range groups {
+		var prefix string
+		switch count := group.MergedCommits(); {
+		case group.IsMerge() && count == 1:
+			prefix = fmt.Sprintf("  - %s (1 commit): ", group.Label())
+		case group.IsMerge() && count > 1:
+			prefix = fmt.Sprintf("  - %s (%d commits): ", group.Label(), count)
+		case group.IsMerge():
+			prefix = fmt.Sprintf("  - %s: ", group.Label())
+		default:
+			prefix = fmt.Sprintf("  - %s : ", group.Head.Hash.String()[:7])
+		}
+		fmt.Printf("%s%s\n", prefix, output.FitLine(group.Title(), len(prefix)))
 	}
 }
 
 // CompareConfig holds the application configuration from command-line arguments
 type CompareConfig struct {
-	Command   Command
-	RepoPath  string
-	Tag1Name  string
-	Tag2Name  string
-	Directory string
-	Verbose   bool
+	Command Command
+	TagOptions
+	GitHub        string          // owner/repo compared through the GitHub REST API instead of a local repository
+	Directory     string          // Single directory to filter commits by
+	Paths         DirectoryFilter // Several directories, globs, or exclusions, in addition to Directory
+	Verbose       bool
+	Depth         int
+	FirstParent   bool              // Count each merged side branch as a single change by following first parents only
+	Baseline      BaselineExclusion // Leave out the commits reachable from these refs; empty leaves out none
+	Authors       AuthorFilter      // Only count commits of these authors or committers; zero counts every commit
+	Dates         DateRange         // Only count commits committed within this range; zero counts every commit
+	Merges        MergeMode         // Whether merge commits are counted; empty counts them
+	Match         CommitMatch       // When a commit counts as shared; empty matches by hash
+	Metric        SimilarityMetric  // Similarity computed in addition to the commit similarity; empty computes none
+	GroupByPR     bool              // List unique commits under the merge or pull request that introduced them
+	Profile       PathProfile
+	TestRatio     bool             // Report the test-to-code change ratio of the unique commits
+	TestFiles     TestPatterns     // Patterns identifying test files for TestRatio
+	LargeFileSize int64            // Files at least this large are excluded from the saved diff stat and listed separately; 0 only separates binary files
+	Risk          bool             // Score the risk of the change between the tags
+	RiskWeights   RiskWeights      // Weights of the risk signals for Risk; nil selects the defaults
+	Timings       bool             // Print how long each phase took and include the breakdown in saved results
+	Timeout       time.Duration    // Cancel the comparison after this long; 0 never times out. The command also applies it to saving the results.
+	Progress      ProgressReporter // Receives progress events of the comparison; nil reports none
+	MinSimilarity float64          // Fail when the similarity is below this threshold between 0 and 1; 0 disables the check
+	PolicyName    string           // Evaluate the comparison against this policy of the project config
+	Metadata      Metadata         // Key/value annotations such as CI build IDs, saved with the result
+	ConfigPath    string           // Project config file defining the policies and services; empty selects ProjectConfigFile in the repository root
+	Strict        bool
+	NoCache       bool // Traverse every tag instead of reading and storing commit sets in the cache
+	SetsOnly      bool // Only compute the commit sets and similarity, skipping tag metadata and the rewrite check
+	Output        OutputOptions
+	Format        CompareFormat // Console output format; empty selects the project config's default or text
+
+	FileMatrixPath   string
+	FileMatrixFormat FileMatrixFormat
+	JSONPath         string
+	ReportPath       string // Markdown report rendered with the built-in engineering template
+
+	// ChecksumsPath is a checksums file or http(s) URL listing the published source archives of the tags;
+	// {tag} is replaced by each tag name. ArchivePrefix overrides the path prefix inside the archives.
+	ChecksumsPath string
+	ArchivePrefix string
+
+	// AttestationPath receives an in-toto statement of the comparison, signed into a DSSE envelope with AttestationKey if set
+	AttestationPath string
+	AttestationKey  string
+
+	PushgatewayURL string
+	PushJob        string
+	HTTP           HTTPOptions
+}
+
+// compareUsage is the help of the compare command
+var compareUsage = commandUsage{
+	Name:        "compare",
+	Summary:     "Compare two Git tags",
+	Description: "Compare two Git tags and calculate their similarity.",
+	Examples: []string{
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -v",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -d src/api",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -first-parent",
+		"compare -repo /path/to/repo -tag1 v2.0.0 -tag2 v3.0.0 -author '@my-team.example.com>$'",
+		"compare -repo /path/to/repo -tag1 v2.0.0 -tag2 v3.0.0 -since 2024-01-01 -until 2024-03-31",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -merges exclude",
+		"compare -repo /path/to/repo -tag1 v2.3.0 -tag2 v3.0.0 -exclude-reachable-from v2.0.0",
+		"compare -repo /path/to/repo -tag1 v1.2.0 -tag2 v1.3.0 -match patch-id",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v1.0.0-rebased -metric message",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v1.0.0-imported -metric files",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -metric lines",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -profile docker -v",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -group-by-pr",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -format ndjson-commits | jq .subject",
+		"compare -repo /path/to/repo -tag1 latest-1 -tag2 latest",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -file-matrix files.csv",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -depth 2",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -test-ratio",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -risk-weights unsigned=0",
+		"compare -repo /path/to/repo -tag1 v1.2.0 -tag2 v1.2.1 -min-similarity 0.85",
+		"compare -repo /path/to/repo -tag1 v1.2.0 -tag2 v1.2.1 -policy hotfix",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -checksums SHA256SUMS",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -attestation comparison.intoto.json -attestation-key key.pem",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -json result.json",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -report report.md",
+		"compare -repo /path/to/repo -tag1 latest -tag2 main -json result.json -meta build=$BUILD_ID -meta env=staging",
+		"compare -repo /path/to/repo -tag1 latest-1 -tag2 latest -pushgateway http://localhost:9091",
+		"compare -github owner/repo -tag1 v1.0.0 -tag2 v2.0.0",
+	},
 }
 
 // NewCompareConfig parses the compare command flags
 func NewCompareConfig(args []string) (CompareConfig, error) {
 	config := CompareConfig{Command: CompareCommand}
-
-	compareCmd := flag.NewFlagSet("compare", flag.ExitOnError)
-	compareCmd.StringVar(&config.RepoPath, "repo", "", "Path to the Git repository")
-	compareCmd.StringVar(&config.Tag1Name, "tag1", "", "First tag name to compare")
-	compareCmd.StringVar(&config.Tag2Name, "tag2", "", "Second tag name to compare")
-	compareCmd.StringVar(&config.Directory, "d", "", "Directory path to filter commits (only commits touching this directory)")
+	var fileMatrixFormat, format, match, merges, metric, profile, testPatterns, riskWeights string
+	var progress bool
+	largeFileSize := "1M"
+
+	compareCmd := newCommandFlagSet(compareUsage)
+	parseTagOptions := config.TagOptions.registerFlags(compareCmd, "name to compare")
+	compareCmd.StringVar(&config.GitHub, "github", "", "Compare the tags of this GitHub owner/repo through the REST API instead of a local -repo ($"+GitHubTokenEnv+" raises the rate limit)")
+	config.Paths.registerFlags(compareCmd, "Directory path to filter commits (only commits touching this directory)")
+	compareCmd.StringVar(&profile, "profile", "", "Only compare commits touching the paths of a predefined profile (docker)")
 	compareCmd.BoolVar(&config.Verbose, "v", false, "Verbose output (show list of different commits)")
+	compareCmd.BoolVar(&config.GroupByPR, "group-by-pr", false, "List unique commits grouped by the merge or pull request that introduced them (implies -v)")
+	compareCmd.BoolVar(&config.FirstParent, "first-parent", false, "Compare first-parent history, counting each merged branch as one change")
+	config.Baseline.registerFlags(compareCmd)
+	config.Authors.registerFlags(compareCmd)
+	config.Dates.registerFlags(compareCmd)
+	compareCmd.StringVar(&merges, "merges", string(MergesInclude), "Merge commits: include, exclude them like git log --no-merges, or only count them like git log --merges")
+	compareCmd.StringVar(&match, "match", string(MatchHash), "When a commit counts as shared: hash, or patch-id to also share cherry-picked commits with identical changes")
+	compareCmd.StringVar(&metric, "metric", string(MetricCommits), "Similarity metric: commits, message to also compare commit subjects, which survive rebases, or files to also compare the files of the tag trees, ignoring history, or lines to also compare their lines of code")
+	compareCmd.StringVar(&format, "format", "", "Console output format (text, ndjson-commits) (default text, or the project config's)")
+	compareCmd.StringVar(&config.JSONPath, "json", "", "Save the full result as JSON to this path (for use with the report command)")
+	compareCmd.StringVar(&config.ReportPath, "report", "", "Write a markdown report with the built-in engineering template to this path ($SOURCE_DATE_EPOCH fixes its generation time)")
+	compareCmd.Var(&config.Metadata, "meta", "Annotate the saved result with a `key=value` pair, e.g. build=1234; may be repeated")
+	compareCmd.StringVar(&config.PushgatewayURL, "pushgateway", "", "Push run metrics to this Prometheus Pushgateway URL")
+	compareCmd.StringVar(&config.PushJob, "push-job", defaultPushJob, "Job name used when pushing metrics to the Pushgateway")
+	parseHTTPOptions := config.HTTP.registerFlags(compareCmd)
+	compareCmd.BoolVar(&config.Strict, "strict", false, "Abort on missing or corrupt objects instead of reporting an approximate result")
+	compareCmd.BoolVar(&config.NoCache, "no-cache", false, "Traverse the history of both tags instead of reading their commit sets from the cache")
+	parseOutputOptions := config.Output.registerFlags(compareCmd, "Maximum line width of console output and the saved diff stat")
+	compareCmd.StringVar(&largeFileSize, "large-file-size", largeFileSize, "Files at least this large (e.g. 512K, 10M) are listed separately from the saved diff stat (0 only separates binary files)")
+	compareCmd.IntVar(&config.Depth, "depth", 0, "Print a per-path breakdown aggregated at this directory depth (0 disables)")
+	compareCmd.StringVar(&config.FileMatrixPath, "file-matrix", "", "Export per-file similarity for files changed between the tags to this path")
+	compareCmd.BoolVar(&config.TestRatio, "test-ratio", false, "Report the test-to-code change ratio among the commits unique to each tag")
+	compareCmd.StringVar(&testPatterns, "test-patterns", "", "Comma-separated test file patterns for -test-ratio; a trailing / matches a directory (implies -test-ratio)")
+	compareCmd.BoolVar(&config.Risk, "risk", false, "Score the risk of the change from churn, hotspots, breaking changes, unsigned commits, and dependency bumps")
+	compareCmd.StringVar(&riskWeights, "risk-weights", "", "Comma-separated signal=weight pairs overriding the default risk weights, e.g. unsigned=0,churn=5 (implies -risk)")
+	registerTimeoutFlag(compareCmd, &config.Timeout, "the comparison and its outputs")
+	compareCmd.BoolVar(&config.Timings, "timings", false, "Print how long each phase took to stderr and include the breakdown in the saved result")
+	compareCmd.BoolVar(&progress, "progress", false, "Print progress of each phase to stderr while comparing")
+	compareCmd.Float64Var(&config.MinSimilarity, "min-similarity", 0, "Exit with a non-zero status when the similarity is below this threshold between 0 and 1 (e.g. 0.85)")
+	compareCmd.StringVar(&config.PolicyName, "policy", "", "Evaluate the comparison against a policy of the project config and fail if it is not met")
+	compareCmd.StringVar(&config.ConfigPath, "config", "", "Project config file (default: "+ProjectConfigFile+" in the repository root)")
+	compareCmd.StringVar(&config.ChecksumsPath, "checksums", "", "Verify the published source archives listed in this checksums file or URL against git archive ({tag} is replaced by each tag)")
+	compareCmd.StringVar(&config.ArchivePrefix, "archive-prefix", "", "Path prefix inside the verified archives, e.g. 'project-{tag}/' (default: the archive name without extension and a /)")
+	compareCmd.StringVar(&config.AttestationPath, "attestation", "", "Write an in-toto attestation of the comparison to this path")
+	compareCmd.StringVar(&config.AttestationKey, "attestation-key", "", "Sign the attestation into a DSSE envelope with this PEM ECDSA or Ed25519 private key")
+	compareCmd.StringVar(&fileMatrixFormat, "file-matrix-format", string(FileMatrixCSV), "Format of the per-file similarity export (csv, json)")
 
-	compareCmd.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: git-tag-similarity compare [options]\n\n")
-		fmt.Fprintf(os.Stderr, "Compare two Git tags and calculate their similarity.\n\n")
-		fmt.Fprintf(os.Stderr, "Options:\n")
-		compareCmd.PrintDefaults()
-		fmt.Fprintf(os.Stderr, "\nExamples:\n")
-		fmt.Fprintf(os.Stderr, "  git-tag-similarity compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0\n")
-		fmt.Fprintf(os.Stderr, "  git-tag-similarity compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -v\n")
-		fmt.Fprintf(os.Stderr, "  git-tag-similarity compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -d src/api\n")
+	if err := compareCmd.Parse(args); err != nil {
+		return config, err
 	}
+	config.Directory, config.Paths = splitDirectoryFilter(config.Paths)
 
-	if err := compareCmd.Parse(args); err != nil {
+	if err := parseTagOptions(); err != nil {
 		return config, err
 	}
 
-	return config, nil
-}
+	pathProfile, err := ParsePathProfile(profile)
+	if err != nil {
+		return config, err
+	}
+	config.Profile = pathProfile
 
-// Validate checks if the configuration is valid
-func (c *CompareConfig) Validate() error {
-	// Check required flags
-	if c.RepoPath == "" {
-		return ErrMissingRepo
+	config.LargeFileSize, err = ParseFileSize(largeFileSize)
+	if err != nil {
+		return config, err
 	}
 
-	if c.Tag1Name == "" {
-		return ErrMissingTag1
+	config.TestFiles, err = ParseTestPatterns(testPatterns)
+	if err != nil {
+		return config, err
+	}
+	if testPatterns != "" {
+		config.TestRatio = true
 	}
 
-	if c.Tag2Name == "" {
-		return ErrMissingTag2
+	config.RiskWeights, err = ParseRiskWeights(riskWeights)
+	if err != nil {
+		return config, err
+	}
+	if riskWeights != "" {
+		config.Risk = true
 	}
 
-	// Check if repository path exists and is accessible
-	if _, err := os.Stat(c.RepoPath); os.IsNotExist(err) {
-		return errors.Join(ErrInvalidRepo, fmt.Errorf("path does not exist: %s", c.RepoPath))
+	matrixFormat, err := ParseFileMatrixFormat(fileMatrixFormat)
+	if err != nil {
+		return config, err
 	}
+	config.FileMatrixFormat = matrixFormat
 
-	// Check if directory path exists (if specified)
-	if c.Directory != "" {
-		dirPath := fmt.Sprintf("%s/%s", c.RepoPath, c.Directory)
-		if stat, err := os.Stat(dirPath); os.IsNotExist(err) {
-			return errors.Join(ErrInvalidDirectory, fmt.Errorf("directory does not exist: %s", c.Directory))
-		} else if err != nil {
-			return errors.Join(ErrInvalidDirectory, fmt.Errorf("cannot access directory: %s", c.Directory))
-		} else if !stat.IsDir() {
-			return errors.Join(ErrInvalidDirectory, fmt.Errorf("path is not a directory: %s", c.Directory))
+	// An empty format leaves the choice to the project config
+	if format != "" {
+		if config.Format, err = ParseCompareFormat(format); err != nil {
+			return config, err
 		}
 	}
 
-	return nil
-}
+	commitMatch, err := ParseCommitMatch(match)
+	if err != nil {
+		return config, err
+	}
+	config.Match = commitMatch
 
-// ValidateWithRepository checks if both tags exist in the repository
-func (c *CompareConfig) ValidateWithRepository(repo Repository) error {
-	// First validate basic configuration
-	if err := c.Validate(); err != nil {
-		return err
+	if config.Merges, err = ParseMergeMode(merges); err != nil {
+		return config, err
 	}
 
-	// Fetch all tags to check if the specified tags exist
-	tagRefs, err := repo.FetchAllTags()
+	config.Metric, err = ParseSimilarityMetric(metric)
 	if err != nil {
-		return err
+		return config, err
 	}
 
-	// Build a map of tag names for quick lookup
-	tagMap := make(map[string]bool)
-	for _, ref := range tagRefs {
-		tagMap[ref.Name().Short()] = true
+	if err := parseOutputOptions(); err != nil {
+		return config, err
 	}
 
-	// Check if both tags exist
-	tag1Found := tagMap[c.Tag1Name]
-	tag2Found := tagMap[c.Tag2Name]
+	if err := parseHTTPOptions(); err != nil {
+		return config, err
+	}
 
-	if !tag1Found {
-		return errors.Join(ErrTag1NotFound, fmt.Errorf("tag '%s' not found in repository", c.Tag1Name))
+	if err := validateTimeout(config.Timeout); err != nil {
+		return config, err
 	}
 
-	if !tag2Found {
-		return errors.Join(ErrTag2NotFound, fmt.Errorf("tag '%s' not found in repository", c.Tag2Name))
+	if progress {
+		config.Progress = NewConsoleProgress(os.Stderr)
 	}
 
-	return nil
+	return config, nil
 }
 
-// GetTagReference finds and returns the reference for a specific tag name
-func (c *CompareConfig) GetTagReference(repo Repository, tagName string) (*plumbing.Reference, error) {
-	tagRefs, err := repo.FetchAllTags()
-	if err != nil {
-		return nil, err
+// Validate checks if the configuration is valid
+func (c *CompareConfig) Validate() error {
+	// Check required flags and the repository path
+	if err := c.TagOptions.Validate(); err != nil {
+		return err
 	}
 
-	for _, ref := range tagRefs {
-		if ref.Name().Short() == tagName {
-			return ref, nil
-		}
+	if c.Profile != ProfileNone && (c.FirstParent || c.FileMatrixPath != "" || c.Depth > 0) {
+		return errors.Join(ErrProfileConflict, fmt.Errorf("-profile cannot be combined with -first-parent, -file-matrix, or -depth"))
+	}
+
+	if c.FirstParent && (c.FileMatrixPath != "" || c.Depth > 0 || c.TestRatio) {
+		return errors.Join(ErrFirstParentConflict, fmt.Errorf("-first-parent cannot be combined with -file-matrix, -depth, or -test-ratio"))
+	}
+
+	if c.AttestationKey != "" && c.AttestationPath == "" {
+		return errors.Join(ErrAttestationKeyOnly, fmt.Errorf("-attestation-key requires -attestation"))
 	}
 
-	return nil, fmt.Errorf("tag '%s' not found", tagName)
+	if c.MinSimilarity < 0 || c.MinSimilarity > 1 {
+		return errors.Join(ErrInvalidThreshold, fmt.Errorf("-min-similarity must be between 0 and 1: %v", c.MinSimilarity))
+	}
+
+	if c.Depth < 0 {
+		return errors.Join(ErrInvalidDepth, fmt.Errorf("depth must not be negative: %d", c.Depth))
+	}
+
+	// Check that the directory filter stays inside the repository; whether it exists is checked against the tags
+	if _, err := cleanDirectory(c.Directory); err != nil {
+		return err
+	}
+	if err := c.Paths.Validate(); err != nil {
+		return err
+	}
+	if c.FirstParent && !c.Paths.IsZero() {
+		return errors.Join(ErrInvalidDirectory, errors.New("-first-parent supports a single -dir without globs or -exclude-dir"))
+	}
+
+	if err := c.Authors.Validate(); err != nil {
+		return err
+	}
+	if err := c.Dates.Validate(); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// directoryFilter returns the single directory and the other directory filters as one filter
+func (c CompareConfig) directoryFilter() DirectoryFilter {
+	return mergeDirectoryFilter(c.Directory, c.Paths)
+}
+
+// pathspecs returns the git pathspecs of the directory filters and the path profile
+func (c CompareConfig) pathspecs() []string {
+	return filterPathspecs(c.directoryFilter(), c.Profile)
 }
 
 type CompareResult struct {
 	Repo          Repository
 	Config        CompareConfig
+	Tag1Ref       *plumbing.Reference
+	Tag2Ref       *plumbing.Reference
 	Similarity    float64
 	SharedCommits map[plumbing.Hash]struct{}
 	OnlyInTag1    map[plumbing.Hash]struct{}
 	OnlyInTag2    map[plumbing.Hash]struct{}
+	FileMatrix    []FileSimilarity
+	PathBreakdown []FileSimilarity
+
+	// CategoryBreakdown splits the changes into infrastructure and application code, computed with PathBreakdown
+	CategoryBreakdown []FileSimilarity
+
+	// Tag1Tests and Tag2Tests summarize test changes among the unique commits; nil unless TestRatio is set
+	Tag1Tests *TestChangeStats
+	Tag2Tests *TestChangeStats
+
+	// Risk scores the change between the tags; nil unless Risk is set
+	Risk *RiskScore
+
+	// Interpretation is the label of the score band of the project config the similarity falls in
+	Interpretation string
+
+	// Policy is the outcome of evaluating the comparison against PolicyName; nil unless PolicyName is set
+	Policy *PolicyResult
+
+	// Rewrite records indicators of rewritten history between diverged tags; nil when they were not checked
+	Rewrite *RewriteAnalysis
+
+	// MessageSimilarity is the shingled Jaccard similarity of the commit subjects; nil unless Metric is MetricMessage
+	MessageSimilarity *float64
+	// TreeSimilarity is the Jaccard similarity of the files (path and content) in the trees of the tags; nil unless Metric is MetricFiles
+	TreeSimilarity *float64
+	// LineSimilarity is the Jaccard similarity of the lines of code in the trees of the tags; nil unless Metric is MetricLines
+	LineSimilarity *float64
+
+	// PatchEquivalents maps tag2 commits to the tag1 commits with the same patch ID; only set with MatchPatchID.
+	// The tag1 commit stands for the pair in SharedCommits.
+	PatchEquivalents map[plumbing.Hash]plumbing.Hash
+
+	// Tag1Mainline and Tag2Mainline hold the unique commits on the first-parent line of each tag; the other
+	// unique commits came from merged side branches. nil with FirstParent, which only counts first parents.
+	Tag1Mainline map[plumbing.Hash]struct{}
+	Tag2Mainline map[plumbing.Hash]struct{}
+
+	// Archives records whether the published source archives match the tags; nil unless ChecksumsPath is set
+	Archives []ArchiveVerification
+
+	// Timings records how long each phase of the comparison took; saving the result adds the diff phase
+	Timings *Timings
+
+	// UnreadableCommits lists commits skipped because they were missing or corrupt;
+	// when non-empty, the similarity is approximate
+	UnreadableCommits []plumbing.Hash
+
+	// Tag1Branch and Tag2Branch are the branches the tags were most likely cut from
+	Tag1Branch TagBranch
+	Tag2Branch TagBranch
+
+	// Tag1Audit and Tag2Audit record who created each tag, when, and from which commit
+	Tag1Audit TagAudit
+	Tag2Audit TagAudit
+
+	// Warnings lists the caveats of the run, such as skipped commits or failed best-effort steps.
+	// They are printed with the result and saved with it, so every output format carries them.
+	Warnings []string
+}
+
+// AddWarning records a caveat of the run; callers add those found after the comparison, e.g. a failed metrics push
+func (r *CompareResult) AddWarning(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// PrintWarnings prints the caveats of a run, if any
+func PrintWarnings(w io.Writer, warnings []string) {
+	if len(warnings) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\nWarnings:\n")
+	for _, warning := range warnings {
+		_, _ = fmt.Fprintf(w, "  - %s\n", warning)
+	}
+}
+
+// getCommitSetsConcurrently traverses the full histories of both tags at the same time.
+// The errors of both traversals are returned together.
+func getCommitSetsConcurrently(ctx context.Context, repo Repository, tag1Ref *plumbing.Reference, tag2Ref *plumbing.Reference) (map[plumbing.Hash]struct{}, map[plumbing.Hash]struct{}, error) {
+	var tag2Commits map[plumbing.Hash]struct{}
+	var tag2Err error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		tag2Commits, tag2Err = repo.GetCommitSetForTag(ctx, tag2Ref)
+	}()
+
+	tag1Commits, tag1Err := repo.GetCommitSetForTag(ctx, tag1Ref)
+	wg.Wait()
+
+	if err := errors.Join(tag1Err, tag2Err); err != nil {
+		return nil, nil, err
+	}
+	return tag1Commits, tag2Commits, nil
+}
+
+// CheckMinSimilarity returns ErrBelowMinSimilarity when the similarity is below the configured MinSimilarity
+func (r CompareResult) CheckMinSimilarity() error {
+	if r.Config.MinSimilarity > 0 && r.Similarity < r.Config.MinSimilarity {
+		return errors.Join(ErrBelowMinSimilarity, fmt.Errorf("similarity %.2f%% is below %.2f%%", r.Similarity*100.0, r.Config.MinSimilarity*100.0))
+	}
+	return nil
 }
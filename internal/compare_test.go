@@ -1,11 +1,19 @@
 package internal
 
 import (
+	"bytes"
+	"context"
 	"errors"
 	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/byron1st/git-tag-similarity/testutil"
 	"github.com/go-git/go-git/v5/plumbing"
 	"go.uber.org/mock/gomock"
 )
@@ -115,6 +123,22 @@ func TestNewCompareConfig(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "Valid compare command with timeout",
+			args: []string{"-repo", tempDir, "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-timeout", "5m"},
+			validate: func(c CompareConfig) error {
+				if c.Timeout != 5*time.Minute {
+					return fmt.Errorf("expected timeout 5m, got %s", c.Timeout)
+				}
+				return nil
+			},
+			wantError: false,
+		},
+		{
+			name:      "Negative timeout",
+			args:      []string{"-repo", tempDir, "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-timeout", "-1s"},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -151,60 +175,126 @@ func TestConfigValidate(t *testing.T) {
 		{
 			name: "Valid configuration",
 			config: CompareConfig{
-				Command:  CompareCommand,
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: nil,
 		},
 		{
 			name: "Missing repository path",
 			config: CompareConfig{
-				Command:  CompareCommand,
-				RepoPath: "",
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: "",
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: ErrMissingRepo,
 		},
 		{
 			name: "Missing tag1 name",
 			config: CompareConfig{
-				Command:  CompareCommand,
-				RepoPath: tempDir,
-				Tag1Name: "",
-				Tag2Name: "v2.0.0",
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: ErrMissingTag1,
 		},
 		{
 			name: "Missing tag2 name",
 			config: CompareConfig{
-				Command:  CompareCommand,
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "",
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "",
+				},
 			},
 			wantError: ErrMissingTag2,
 		},
 		{
 			name: "Non-existent repository path",
 			config: CompareConfig{
-				Command:  CompareCommand,
-				RepoPath: "/non/existent/path",
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: "/non/existent/path",
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: ErrInvalidRepo,
 		},
+		{
+			name: "Negative breakdown depth",
+			config: CompareConfig{
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
+				Depth: -1,
+			},
+			wantError: ErrInvalidDepth,
+		},
+		{
+			name: "Minimum similarity above 1",
+			config: CompareConfig{
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
+				MinSimilarity: 85,
+			},
+			wantError: ErrInvalidThreshold,
+		},
+		{
+			name: "First-parent history with per-file results",
+			config: CompareConfig{
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
+				FirstParent: true,
+				Depth:       2,
+			},
+			wantError: ErrFirstParentConflict,
+		},
+		{
+			name: "Path profile with first-parent history",
+			config: CompareConfig{
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
+				Profile:     ProfileDocker,
+				FirstParent: true,
+			},
+			wantError: ErrProfileConflict,
+		},
 		{
 			name: "All required fields missing",
 			config: CompareConfig{
-				Command:  CompareCommand,
-				RepoPath: "",
-				Tag1Name: "",
-				Tag2Name: "",
+				Command: CompareCommand,
+				TagOptions: TagOptions{
+					RepoPath: "",
+					Tag1Name: "",
+					Tag2Name: "",
+				},
 			},
 			wantError: ErrMissingRepo, // Should fail on first check
 		},
@@ -245,45 +335,55 @@ func TestConfigValidateWithRepository(t *testing.T) {
 		{
 			name: "Both tags exist",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: nil,
 		},
 		{
 			name: "Tag1 does not exist",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v3.0.0",
-				Tag2Name: "v2.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v3.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: ErrTag1NotFound,
 		},
 		{
 			name: "Tag2 does not exist",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v3.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v3.0.0",
+				},
 			},
 			wantError: ErrTag2NotFound,
 		},
 		{
 			name: "Both tags do not exist",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v3.0.0",
-				Tag2Name: "v4.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v3.0.0",
+					Tag2Name: "v4.0.0",
+				},
 			},
 			wantError: ErrTag1NotFound, // Should fail on first check
 		},
 		{
 			name: "Invalid repository path",
 			config: CompareConfig{
-				RepoPath: "/non/existent/path",
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				TagOptions: TagOptions{
+					RepoPath: "/non/existent/path",
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			wantError: ErrInvalidRepo,
 		},
@@ -296,8 +396,9 @@ func TestConfigValidateWithRepository(t *testing.T) {
 
 			mockRepo := mocks.NewMockRepository(ctrl)
 			mockRepo.EXPECT().FetchAllTags().Return(tags, nil).AnyTimes()
+			mockRepo.EXPECT().ResolveRevision(gomock.Any(), gomock.Any()).Return(nil, ErrResolveRevision).AnyTimes()
 
-			err := tt.config.ValidateWithRepository(mockRepo)
+			err := tt.config.ValidateWithRepository(context.Background(), mockRepo)
 			if tt.wantError == nil {
 				if err != nil {
 					t.Errorf("ValidateWithRepository() error = %v, want nil", err)
@@ -331,9 +432,11 @@ func TestConfigGetTagReference(t *testing.T) {
 		{
 			name: "Find existing tag v1.0.0",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			tagName:   "v1.0.0",
 			wantTag:   "v1.0.0",
@@ -342,9 +445,11 @@ func TestConfigGetTagReference(t *testing.T) {
 		{
 			name: "Find existing tag v2.0.0",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			tagName:   "v2.0.0",
 			wantTag:   "v2.0.0",
@@ -353,14 +458,29 @@ func TestConfigGetTagReference(t *testing.T) {
 		{
 			name: "Tag not found",
 			config: CompareConfig{
-				RepoPath: tempDir,
-				Tag1Name: "v1.0.0",
-				Tag2Name: "v2.0.0",
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "v2.0.0",
+				},
 			},
 			tagName:   "v3.0.0",
 			wantTag:   "",
 			wantError: true,
 		},
+		{
+			name: "Branch instead of a tag",
+			config: CompareConfig{
+				TagOptions: TagOptions{
+					RepoPath: tempDir,
+					Tag1Name: "v1.0.0",
+					Tag2Name: "main",
+				},
+			},
+			tagName:   "main",
+			wantTag:   "main",
+			wantError: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -370,8 +490,14 @@ func TestConfigGetTagReference(t *testing.T) {
 
 			mockRepo := mocks.NewMockRepository(ctrl)
 			mockRepo.EXPECT().FetchAllTags().Return(tags, nil).AnyTimes()
+			mockRepo.EXPECT().ResolveRevision(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, revision string) (*plumbing.Reference, error) {
+				if revision == "main" {
+					return plumbing.NewHashReference(plumbing.ReferenceName(revision), tag2.Hash()), nil
+				}
+				return nil, ErrResolveRevision
+			}).AnyTimes()
 
-			ref, err := tt.config.GetTagReference(mockRepo, tt.tagName)
+			ref, err := tt.config.GetTagReference(context.Background(), mockRepo, tt.tagName)
 			if tt.wantError {
 				if err == nil {
 					t.Errorf("GetTagReference() error = nil, want error")
@@ -389,3 +515,224 @@ func TestConfigGetTagReference(t *testing.T) {
 		})
 	}
 }
+
+// TestCheckMinSimilarity tests the similarity threshold of the -min-similarity flag
+func TestCheckMinSimilarity(t *testing.T) {
+	tests := []struct {
+		name          string
+		minSimilarity float64
+		similarity    float64
+		wantError     error
+	}{
+		{name: "No threshold", minSimilarity: 0, similarity: 0},
+		{name: "Above threshold", minSimilarity: 0.85, similarity: 0.9},
+		{name: "At threshold", minSimilarity: 0.85, similarity: 0.85},
+		{name: "Below threshold", minSimilarity: 0.85, similarity: 0.8, wantError: ErrBelowMinSimilarity},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := CompareResult{Config: CompareConfig{MinSimilarity: tt.minSimilarity}, Similarity: tt.similarity}
+			if err := result.CheckMinSimilarity(); !errors.Is(err, tt.wantError) {
+				t.Errorf("CheckMinSimilarity() error = %v, want %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+// TestGetCommitSetsConcurrently tests that the errors of both traversals are returned
+func TestGetCommitSetsConcurrently(t *testing.T) {
+	tag1 := plumbing.NewHashReference("refs/tags/v1.0.0", plumbing.NewHash("1111111111111111111111111111111111111111"))
+	tag2 := plumbing.NewHashReference("refs/tags/v2.0.0", plumbing.NewHash("2222222222222222222222222222222222222222"))
+	errTag1 := errors.New("tag1 unreadable")
+	errTag2 := errors.New("tag2 unreadable")
+
+	ctrl := gomock.NewController(t)
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetCommitSetForTag(gomock.Any(), tag1).Return(map[plumbing.Hash]struct{}{tag1.Hash(): {}}, nil)
+	mockRepo.EXPECT().GetCommitSetForTag(gomock.Any(), tag2).Return(map[plumbing.Hash]struct{}{tag1.Hash(): {}, tag2.Hash(): {}}, nil)
+
+	tag1Commits, tag2Commits, err := getCommitSetsConcurrently(context.Background(), mockRepo, tag1, tag2)
+	if err != nil {
+		t.Fatalf("getCommitSetsConcurrently() error = %v", err)
+	}
+	if len(tag1Commits) != 1 || len(tag2Commits) != 2 {
+		t.Errorf("getCommitSetsConcurrently() = %d, %d commits, want 1, 2", len(tag1Commits), len(tag2Commits))
+	}
+
+	mockRepo.EXPECT().GetCommitSetForTag(gomock.Any(), tag1).Return(nil, errTag1)
+	mockRepo.EXPECT().GetCommitSetForTag(gomock.Any(), tag2).Return(nil, errTag2)
+	if _, _, err := getCommitSetsConcurrently(context.Background(), mockRepo, tag1, tag2); !errors.Is(err, errTag1) || !errors.Is(err, errTag2) {
+		t.Errorf("getCommitSetsConcurrently() error = %v, want both traversal errors", err)
+	}
+}
+
+// TestCompare_ServiceNamespace tests that namespaced tags of a monorepo are scoped to their service's directory
+func TestCompare_ServiceNamespace(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Add service a", testutil.File("services/a/main.go", "package a\n")).
+		Commit("Add service b", testutil.File("services/b/main.go", "package b\n")).
+		Tag("service-a/v1.0.0").
+		Commit("Change service b", testutil.File("services/b/main.go", "package b\n\n// changed\n")).
+		Commit("Change service a", testutil.File("services/a/main.go", "package a\n\n// changed\n")).
+		Tag("service-a/v1.1.0")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(configPath, []byte("services:\n  service-a:\n    path: services/a\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "service-a/latest-1", Tag2Name: "service-a/latest"},
+		ConfigPath: configPath,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Config.Tag1Name != "service-a/v1.0.0" || result.Config.Tag2Name != "service-a/v1.1.0" {
+		t.Errorf("resolved tags = %s, %s, want service-a/v1.0.0, service-a/v1.1.0", result.Config.Tag1Name, result.Config.Tag2Name)
+	}
+	if result.Config.Directory != "services/a" {
+		t.Errorf("Directory = %q, want services/a", result.Config.Directory)
+	}
+	if _, ok := result.OnlyInTag2[fixture.Hash("service-a/v1.1.0")]; len(result.OnlyInTag2) != 1 || !ok {
+		t.Errorf("OnlyInTag2 = %v, want only the service a change", result.OnlyInTag2)
+	}
+
+	// An explicit directory takes precedence over the service's
+	result, err = Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "service-a/v1.0.0", Tag2Name: "service-a/v1.1.0"},
+		Directory:  "services/b",
+		ConfigPath: configPath,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Config.Directory != "services/b" {
+		t.Errorf("Directory = %q, want services/b", result.Config.Directory)
+	}
+}
+
+// TestCompare_AuthorFilter tests that the similarity only counts commits of the selected authors
+func TestCompare_AuthorFilter(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Author("Alice", "alice@team.example.com").
+		Commit("Add a", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Author("Bob", "bob@other.example.com").
+		Commit("Add b", testutil.File("b.go", "package b\n")).
+		Commit("Add c", testutil.File("c.go", "package c\n")).
+		Tag("v2.0.0")
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+		Authors:    AuthorFilter{Authors: []string{"alice@team.example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Similarity != 1 || len(result.SharedCommits) != 1 || len(result.OnlyInTag2) != 0 {
+		t.Errorf("Compare() similarity = %v, shared = %d, only in tag2 = %d, want 1, 1, 0", result.Similarity, len(result.SharedCommits), len(result.OnlyInTag2))
+	}
+
+	if _, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+		Authors:    AuthorFilter{Committers: []string{"(bob"}},
+	}); !errors.Is(err, ErrInvalidAuthorFilter) {
+		t.Errorf("Compare() with an invalid pattern error = %v, want ErrInvalidAuthorFilter", err)
+	}
+}
+
+// TestCompare_Warnings tests that the caveats of a run are collected in the result
+func TestCompare_Warnings(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("first", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Commit("second", testutil.File("b.go", "package b\n")).
+		Commit("third", testutil.File("c.go", "package c\n")).
+		Tag("v2.0.0")
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+		Authors:    AuthorFilter{Authors: []string{"nobody@example.com"}},
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	want := []string{
+		"no commits of v1.0.0 pass the author, date, and merge filters",
+		"no commits of v2.0.0 pass the author, date, and merge filters",
+	}
+	if !reflect.DeepEqual(result.Warnings, want) {
+		t.Errorf("Warnings = %q, want %q", result.Warnings, want)
+	}
+
+	if err := os.Remove(fixture.ObjectPath(fixture.Hash("v2.0.0~1"))); err != nil {
+		t.Fatalf("failed to remove commit object: %v", err)
+	}
+	result, err = Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+		SetsOnly:   true,
+		NoCache:    true, // The first run cached the intact set of v2.0.0
+	})
+	if err != nil {
+		t.Fatalf("Compare() with a missing commit error = %v", err)
+	}
+	if len(result.Warnings) != 1 || !strings.HasPrefix(result.Warnings[0], "1 commits could not be read") {
+		t.Errorf("Warnings = %q, want the unreadable commit", result.Warnings)
+	}
+}
+
+// TestCompareContext_Canceled tests that a done context stops the comparison, traversals, and git commands
+func TestCompareContext_Canceled(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CompareContext(ctx, CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		NoCache:    true,
+	})
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("CompareContext() error = %v, want %v and %v", err, ErrCanceled, context.Canceled)
+	}
+
+	repo := openFixture(t, fixture)
+	ref := plumbing.NewHashReference(plumbing.NewTagReferenceName("v1.1.0"), fixture.Hash("v1.1.0"))
+	if err := repo.ForEachCommitInTag(ctx, ref, func(plumbing.Hash) error { return nil }); !errors.Is(err, context.Canceled) {
+		t.Errorf("ForEachCommitInTag() error = %v, want %v", err, context.Canceled)
+	}
+	if _, err := repo.GetDiffBetweenTags(ctx, ref, ref, nil); err == nil {
+		t.Error("GetDiffBetweenTags() ran git with a canceled context")
+	}
+
+	// The timeout of the config applies without a deadline on the caller's context
+	_, err = CompareContext(context.Background(), CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		NoCache:    true,
+		Timeout:    time.Nanosecond,
+	})
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("CompareContext() with Timeout error = %v, want %v and %v", err, ErrCanceled, context.DeadlineExceeded)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if _, err := CompareContext(ctx, CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}}); err != nil {
+		t.Errorf("CompareContext() with a live context error = %v", err)
+	}
+}
+
+// TestPrintWarnings tests that warnings are listed under a heading, and nothing is printed without them
+func TestPrintWarnings(t *testing.T) {
+	var buf bytes.Buffer
+	PrintWarnings(&buf, nil)
+	if buf.Len() != 0 {
+		t.Errorf("PrintWarnings(nil) = %q, want no output", buf.String())
+	}
+
+	PrintWarnings(&buf, []string{"failed to push metrics: timeout"})
+	if got, want := buf.String(), "\nWarnings:\n  - failed to push metrics: timeout\n"; got != want {
+		t.Errorf("PrintWarnings() = %q, want %q", got, want)
+	}
+}
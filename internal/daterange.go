@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidDateRange = errors.New("invalid date range")
+
+// dateLayout is the date-only form accepted by -since and -until
+const dateLayout = "2006-01-02"
+
+// DateRange limits the commit sets to commits made within a period, e.g. -since 2024-01-01 -until 2024-03-31.
+// Commits are placed by their committer date, as git log --since does, so a rebased or cherry-picked
+// commit counts on the day it was applied. Traversals stop at commits committed before the range, as
+// git log --since does, so the history beyond it is never read.
+type DateRange struct {
+	Since time.Time // Earliest committer date counted; zero leaves the range open at the start
+	Until time.Time // Latest committer date counted; zero leaves the range open at the end
+}
+
+// dateValue is a flag.Value that parses a date or an RFC 3339 timestamp.
+// A date alone selects the start of that day in local time, or its last second for endOfDay.
+type dateValue struct {
+	target   *time.Time
+	endOfDay bool
+}
+
+func (v dateValue) String() string {
+	if v.target == nil || v.target.IsZero() {
+		return ""
+	}
+	return formatDateBound(*v.target)
+}
+
+func (v dateValue) Set(value string) error {
+	if date, err := time.ParseInLocation(dateLayout, value, time.Local); err == nil {
+		if v.endOfDay {
+			date = date.AddDate(0, 0, 1).Add(-time.Second)
+		}
+		*v.target = date
+		return nil
+	}
+	timestamp, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return errors.Join(ErrInvalidDateRange, fmt.Errorf("expected YYYY-MM-DD or an RFC 3339 timestamp: %s", value))
+	}
+	*v.target = timestamp
+	return nil
+}
+
+// registerFlags adds the -since and -until flags to a command
+func (r *DateRange) registerFlags(flags *flag.FlagSet) {
+	flags.Var(dateValue{target: &r.Since}, "since", "Only count commits committed on or after this `date` (YYYY-MM-DD or RFC 3339)")
+	flags.Var(dateValue{target: &r.Until, endOfDay: true}, "until", "Only count commits committed on or before this `date`; a date alone includes the whole day")
+}
+
+// IsZero reports whether the range keeps every commit
+func (r DateRange) IsZero() bool {
+	return r.Since.IsZero() && r.Until.IsZero()
+}
+
+// Validate checks that the range does not end before it starts
+func (r DateRange) Validate() error {
+	if !r.Since.IsZero() && !r.Until.IsZero() && r.Until.Before(r.Since) {
+		return errors.Join(ErrInvalidDateRange, fmt.Errorf("-until %s is before -since %s", formatDateBound(r.Until), formatDateBound(r.Since)))
+	}
+	return nil
+}
+
+// String describes the range for output and saved results, e.g. "2024-01-01 to 2024-03-31" or "since 2024-01-01"
+func (r DateRange) String() string {
+	switch {
+	case r.IsZero():
+		return ""
+	case r.Until.IsZero():
+		return "since " + formatDateBound(r.Since)
+	case r.Since.IsZero():
+		return "until " + formatDateBound(r.Until)
+	}
+	return formatDateBound(r.Since) + " to " + formatDateBound(r.Until)
+}
+
+// formatDateBound prints a bound given as a date alone as that date, and any other bound as a timestamp
+func formatDateBound(t time.Time) string {
+	local := t.In(time.Local)
+	if hour, minute, second := local.Clock(); (hour == 0 && minute == 0 && second == 0) || (hour == 23 && minute == 59 && second == 59) {
+		return local.Format(dateLayout)
+	}
+	return t.Format(time.RFC3339)
+}
+
+// Contains reports whether a committer date falls within the range
+func (r DateRange) Contains(when time.Time) bool {
+	if !r.Since.IsZero() && when.Before(r.Since) {
+		return false
+	}
+	if !r.Until.IsZero() && when.After(r.Until) {
+		return false
+	}
+	return true
+}
@@ -0,0 +1,175 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestDateRangeFlags tests parsing -since and -until as dates or timestamps
+func TestDateRangeFlags(t *testing.T) {
+	tests := []struct {
+		name     string
+		args     []string
+		want     DateRange
+		wantText string
+		wantErr  bool
+	}{
+		{
+			name:     "Dates cover whole days",
+			args:     []string{"-since", "2024-01-01", "-until", "2024-03-31"},
+			want:     DateRange{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local), Until: time.Date(2024, 3, 31, 23, 59, 59, 0, time.Local)},
+			wantText: "2024-01-01 to 2024-03-31",
+		},
+		{
+			name:     "Timestamp",
+			args:     []string{"-since", "2024-01-01T12:30:00Z"},
+			want:     DateRange{Since: time.Date(2024, 1, 1, 12, 30, 0, 0, time.UTC)},
+			wantText: "since 2024-01-01T12:30:00Z",
+		},
+		{
+			name:     "Open start",
+			args:     []string{"-until", "2024-03-31"},
+			want:     DateRange{Until: time.Date(2024, 3, 31, 23, 59, 59, 0, time.Local)},
+			wantText: "until 2024-03-31",
+		},
+		{name: "Invalid date", args: []string{"-since", "last quarter"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var dates DateRange
+			flags := flag.NewFlagSet("test", flag.ContinueOnError)
+			flags.SetOutput(io.Discard)
+			dates.registerFlags(flags)
+
+			err := flags.Parse(tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("Parse(%v) error = nil, want an error", tt.args)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%v) error = %v", tt.args, err)
+			}
+			if !dates.Since.Equal(tt.want.Since) || !dates.Until.Equal(tt.want.Until) {
+				t.Errorf("DateRange = %+v, want %+v", dates, tt.want)
+			}
+			if got := dates.String(); got != tt.wantText {
+				t.Errorf("String() = %q, want %q", got, tt.wantText)
+			}
+		})
+	}
+}
+
+// TestDateRangeValidate tests that a range ending before it starts is rejected
+func TestDateRangeValidate(t *testing.T) {
+	dates := DateRange{Since: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 3, 31, 0, 0, 0, 0, time.UTC)}
+	if err := dates.Validate(); !errors.Is(err, ErrInvalidDateRange) {
+		t.Errorf("Validate() error = %v, want ErrInvalidDateRange", err)
+	}
+	if err := (DateRange{Since: dates.Until, Until: dates.Since}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil", err)
+	}
+}
+
+// TestCompare_DateRange tests that the similarity only counts commits made within the range,
+// whichever traversal finds them
+func TestCompare_DateRange(t *testing.T) {
+	tests := []struct {
+		name        string
+		directory   string
+		firstParent bool
+		warmCache   bool
+	}{
+		{name: "full history"},
+		{name: "directory filter", directory: "src"},
+		{name: "first parent", firstParent: true},
+		{name: "cached full history", warmCache: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(CacheDirEnv, t.TempDir())
+			fixture := testutil.NewRepo(t).
+				At(time.Date(2023, 12, 15, 10, 0, 0, 0, time.UTC)).
+				Commit("Old work", testutil.File("src/old.go", "package old\n")).
+				At(time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)).
+				Commit("Add a", testutil.File("src/a.go", "package a\n")).
+				Tag("v1.0.0").
+				At(time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC)).
+				Commit("Add b", testutil.File("src/b.go", "package b\n")).
+				At(time.Date(2024, 5, 10, 10, 0, 0, 0, time.UTC)).
+				Commit("Add c", testutil.File("src/c.go", "package c\n")).
+				Tag("v2.0.0")
+
+			config := CompareConfig{
+				TagOptions:  TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+				Directory:   tt.directory,
+				FirstParent: tt.firstParent,
+				SetsOnly:    true,
+			}
+			if tt.warmCache {
+				if _, err := Compare(config); err != nil {
+					t.Fatalf("Compare() without a date range error = %v", err)
+				}
+			}
+
+			config.Dates = DateRange{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Until: time.Date(2024, 3, 31, 23, 59, 59, 0, time.UTC)}
+			result, err := Compare(config)
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if len(result.SharedCommits) != 1 || len(result.OnlyInTag2) != 1 || result.Similarity != 0.5 {
+				t.Errorf("Compare() shared = %d, only in tag2 = %d, similarity = %v, want 1, 1, 0.5", len(result.SharedCommits), len(result.OnlyInTag2), result.Similarity)
+			}
+			if _, ok := result.OnlyInTag2[fixture.Hash("v2.0.0~1")]; !ok {
+				t.Errorf("OnlyInTag2 = %v, want the commit of February", result.OnlyInTag2)
+			}
+		})
+	}
+}
+
+// TestCompare_DateRangeSkipsHistory tests that the traversal stops at the range, so a missing commit
+// before it is never read, and that an unreadable commit within it makes the result approximate
+func TestCompare_DateRangeSkipsHistory(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		At(time.Date(2023, 11, 15, 10, 0, 0, 0, time.UTC)).
+		Commit("Ancient work").
+		At(time.Date(2023, 12, 15, 10, 0, 0, 0, time.UTC)).
+		Commit("Old work").
+		At(time.Date(2024, 1, 10, 10, 0, 0, 0, time.UTC)).
+		Commit("Add a").
+		Tag("v1.0.0").
+		At(time.Date(2024, 2, 10, 10, 0, 0, 0, time.UTC)).
+		Commit("Add b").
+		At(time.Date(2024, 2, 20, 10, 0, 0, 0, time.UTC)).
+		Commit("Add c").
+		Tag("v2.0.0")
+	ancient, february := fixture.Hash("v1.0.0~2"), fixture.Hash("v2.0.0~1")
+	for _, hash := range []plumbing.Hash{ancient, february} {
+		if err := os.Remove(fixture.ObjectPath(hash)); err != nil {
+			t.Fatalf("failed to remove commit object: %v", err)
+		}
+	}
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+		Dates:      DateRange{Since: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		NoCache:    true,
+		SetsOnly:   true,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(result.UnreadableCommits) != 1 || result.UnreadableCommits[0] != february {
+		t.Errorf("UnreadableCommits = %v, want only the commit of February within the range", result.UnreadableCommits)
+	}
+}
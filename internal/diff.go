@@ -0,0 +1,349 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrConflictingDiffModes = errors.New("conflicting diff output modes")
+	ErrGetDiffOutput        = errors.New("failed to get diff output")
+	ErrInvalidDiffOverflow  = errors.New("invalid diff overflow mode")
+	ErrDiffTooLarge         = errors.New("diff exceeds the maximum size")
+)
+
+// DiffMode selects the output format of a diff between two tags
+type DiffMode string
+
+const (
+	DiffModeStat     DiffMode = "stat"
+	DiffModePatch    DiffMode = "patch"
+	DiffModeNameOnly DiffMode = "name-only"
+)
+
+// DiffOverflow selects what happens to a diff larger than the maximum size
+type DiffOverflow string
+
+const (
+	DiffOverflowTruncate  DiffOverflow = "truncate"  // Print the diff up to the maximum size and note the truncation
+	DiffOverflowSummarize DiffOverflow = "summarize" // Print the changed files and lines per directory instead
+	DiffOverflowFail      DiffOverflow = "fail"      // Fail with ErrDiffTooLarge
+)
+
+// ParseDiffOverflow converts a flag value into a DiffOverflow
+func ParseDiffOverflow(value string) (DiffOverflow, error) {
+	switch DiffOverflow(value) {
+	case DiffOverflowTruncate, DiffOverflowSummarize, DiffOverflowFail:
+		return DiffOverflow(value), nil
+	default:
+		return "", errors.Join(ErrInvalidDiffOverflow, fmt.Errorf("unknown diff overflow mode: %s (expected truncate, summarize, or fail)", value))
+	}
+}
+
+// DiffOptions controls how the diff between two tags is produced
+type DiffOptions struct {
+	Mode          DiffMode // Output format (defaults to stat)
+	Pathspecs     []string // Limit the diff to these paths
+	DetectRenames bool     // Report renamed files as renames instead of delete/add pairs
+	StatWidth     int      // Column width of stat output (defaults to defaultOutputWidth)
+
+	// LargeFileSize is the size from which files are excluded from stat output and listed separately.
+	// Binary files are always listed separately; 0 disables the size check.
+	LargeFileSize int64
+
+	// MaxBytes is the largest diff output read from git; 0 reads any size. Overflow selects what
+	// happens to a larger diff (defaults to truncate).
+	MaxBytes int64
+	Overflow DiffOverflow
+}
+
+// gitArgs returns the git diff arguments selecting the output format
+func (o DiffOptions) gitArgs() []string {
+	var args []string
+	switch o.Mode {
+	case DiffModePatch:
+		args = append(args, "--patch")
+	case DiffModeNameOnly:
+		args = append(args, "--name-only")
+	default:
+		width := o.StatWidth
+		if width <= 0 {
+			width = defaultOutputWidth
+		}
+		args = append(args, "--stat", "--stat-width="+strconv.Itoa(width))
+	}
+
+	// git diff detects renames by default (diff.renames), so they are turned off explicitly
+	if o.DetectRenames {
+		args = append(args, "--find-renames")
+	} else {
+		args = append(args, "--no-renames")
+	}
+
+	return args
+}
+
+// DiffConfig holds the configuration of the diff command
+type DiffConfig struct {
+	Command Command
+	TagOptions
+	Directory string          // Directory filter, validated against the tags and prepended to the pathspecs
+	Paths     DirectoryFilter // Several directories, globs, or exclusions, in addition to Directory
+	Profile   PathProfile
+	Options   DiffOptions
+	Timeout   time.Duration // Cancel the diff after this long; 0 never times out
+}
+
+// diffUsage is the help of the diff command
+var diffUsage = commandUsage{
+	Name:        "diff",
+	Synopsis:    "[options] [--] [<pathspec>...]",
+	Summary:     "Show the diff between two Git tags",
+	Description: "Show the diff between two Git tags.",
+	Examples: []string{
+		"diff -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+		"diff -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -patch -- src/api",
+		"diff -repo /path/to/repo -tag1 latest-1 -tag2 latest -name-only -find-renames",
+		"diff -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -large-file-size 200K",
+	},
+}
+
+// NewDiffConfig parses the diff command flags.
+// Arguments after the flags (optionally separated by "--") are used as pathspecs.
+func NewDiffConfig(args []string) (DiffConfig, error) {
+	config := DiffConfig{Command: DiffCommand}
+	var patch, nameOnly bool
+	var profile string
+	largeFileSize := "1M"
+	maxDiffBytes := "0"
+	overflow := string(DiffOverflowTruncate)
+
+	diffCmd := newCommandFlagSet(diffUsage)
+	parseTagOptions := config.TagOptions.registerFlags(diffCmd, "name to compare")
+	config.Paths.registerFlags(diffCmd, "Directory path to limit the diff to")
+	diffCmd.StringVar(&profile, "profile", "", "Limit the diff to the paths of a predefined profile (docker)")
+	diffCmd.BoolVar(&patch, "patch", false, "Show the full patch instead of a diff stat")
+	diffCmd.BoolVar(&nameOnly, "name-only", false, "Show only the names of changed files")
+	diffCmd.BoolVar(&config.Options.DetectRenames, "find-renames", false, "Detect renamed files instead of listing them as a deletion and an addition")
+	diffCmd.StringVar(&largeFileSize, "large-file-size", largeFileSize, "Files at least this large (e.g. 512K, 10M) are listed separately from the diff stat (0 only separates binary files)")
+	diffCmd.StringVar(&maxDiffBytes, "max-diff-bytes", maxDiffBytes, "Largest diff output (e.g. 512K, 10M) to read; 0 reads any size")
+	diffCmd.StringVar(&overflow, "on-diff-overflow", overflow, "What to do with a larger diff: truncate, summarize (changes per directory), or fail")
+	diffCmd.IntVar(&config.Options.StatWidth, "width", defaultOutputWidth, "Column width of the diff stat")
+	registerTimeoutFlag(diffCmd, &config.Timeout, "the diff")
+
+	if err := diffCmd.Parse(args); err != nil {
+		return config, err
+	}
+	config.Directory, config.Paths = splitDirectoryFilter(config.Paths)
+
+	switch {
+	case patch && nameOnly:
+		return config, errors.Join(ErrConflictingDiffModes, fmt.Errorf("-patch and -name-only cannot be combined"))
+	case patch:
+		config.Options.Mode = DiffModePatch
+	case nameOnly:
+		config.Options.Mode = DiffModeNameOnly
+	default:
+		config.Options.Mode = DiffModeStat
+	}
+
+	if err := parseTagOptions(); err != nil {
+		return config, err
+	}
+
+	pathProfile, err := ParsePathProfile(profile)
+	if err != nil {
+		return config, err
+	}
+	config.Profile = pathProfile
+
+	config.Options.LargeFileSize, err = ParseFileSize(largeFileSize)
+	if err != nil {
+		return config, err
+	}
+
+	config.Options.MaxBytes, err = ParseFileSize(maxDiffBytes)
+	if err != nil {
+		return config, err
+	}
+
+	config.Options.Overflow, err = ParseDiffOverflow(overflow)
+	if err != nil {
+		return config, err
+	}
+
+	if config.Options.StatWidth < minOutputWidth {
+		return config, errors.Join(ErrInvalidWidth, fmt.Errorf("width must be at least %d: %d", minOutputWidth, config.Options.StatWidth))
+	}
+
+	config.Options.Pathspecs = diffCmd.Args()
+
+	if err := validateTimeout(config.Timeout); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Diff returns the diff between the two configured tags
+func Diff(ctx context.Context, config DiffConfig) (_ string, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return "", errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	directory, err := cleanDirectory(config.Directory)
+	if err != nil {
+		return "", errors.Join(ErrInvalidConfiguration, err)
+	}
+	paths, err := config.Paths.clean()
+	if err != nil {
+		return "", errors.Join(ErrInvalidConfiguration, err)
+	}
+	filter := mergeDirectoryFilter(directory, paths)
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, config.CloneDepth)
+	if err != nil {
+		return "", errors.Join(ErrOpenRepository, err)
+	}
+
+	tag1Ref, tag2Ref, err := config.ResolveTags(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+
+	if err := validateDirectoryFilterInTags(repo, filter, tag1Ref, tag2Ref); err != nil {
+		return "", errors.Join(ErrValidationFailed, err)
+	}
+
+	pathspecs := append(filterPathspecs(filter, config.Profile), config.Options.Pathspecs...)
+	if config.Options.Mode != DiffModeStat && config.Options.Mode != "" {
+		output, err := limitedDiff(ctx, repo, tag1Ref, tag2Ref, pathspecs, config.Options)
+		if err != nil {
+			return "", errors.Join(ErrGetDiffOutput, err)
+		}
+		return output, nil
+	}
+
+	// Binary and large files would dwarf the line counts of the stat, so they are listed separately
+	largeFiles, err := FindLargeFileChanges(ctx, repo, tag1Ref, tag2Ref, pathspecs, config.Options.LargeFileSize)
+	if err != nil {
+		return "", errors.Join(ErrGetDiffOutput, err)
+	}
+
+	stat, err := limitedDiff(ctx, repo, tag1Ref, tag2Ref, excludeLargeFiles(pathspecs, largeFiles), config.Options)
+	if err != nil {
+		return "", errors.Join(ErrGetDiffOutput, err)
+	}
+
+	var output strings.Builder
+	output.WriteString(stat)
+	writeLargeFileChanges(&output, largeFiles, OutputOptions{Width: config.Options.StatWidth})
+	return output.String(), nil
+}
+
+// limitedDiff returns the diff of the options between two tags, reading at most MaxBytes of it.
+// A larger diff is truncated with a note, replaced by a summary per directory, or an error,
+// as the Overflow of the options selects.
+func limitedDiff(ctx context.Context, repo Repository, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, options DiffOptions) (string, error) {
+	output, truncated, err := repo.GetLimitedDiffBetweenTags(ctx, tag1, tag2, pathspecs, options.MaxBytes, options.gitArgs()...)
+	if err != nil || !truncated {
+		return output, err
+	}
+
+	limit := formatFileSize(options.MaxBytes)
+	switch options.Overflow {
+	case DiffOverflowFail:
+		return "", errors.Join(ErrDiffTooLarge, fmt.Errorf("the diff is larger than %s; raise -max-diff-bytes or use -on-diff-overflow truncate or summarize", limit))
+	case DiffOverflowSummarize:
+		summary, err := summarizeDiffByDirectory(ctx, repo, tag1, tag2, pathspecs, OutputOptions{Width: options.StatWidth})
+		if err != nil {
+			return "", err
+		}
+		return fmt.Sprintf("The diff is larger than %s; changes per directory:\n%s", limit, summary), nil
+	default:
+		return output + fmt.Sprintf("[diff truncated at %s; raise -max-diff-bytes or use -on-diff-overflow summarize]\n", limit), nil
+	}
+}
+
+// numstatEntry is a file of 'git diff --numstat' output
+type numstatEntry struct {
+	Path    string
+	Added   int
+	Deleted int
+	Binary  bool // git counts no lines of binary files
+}
+
+// parseNumstat parses the output of 'git diff --numstat -z --no-renames'
+func parseNumstat(numstat string) []numstatEntry {
+	var entries []numstatEntry
+	for _, record := range strings.Split(numstat, "\x00") {
+		// Record format: <added> TAB <deleted> TAB <path>, with "-" counts for binary files
+		fields := strings.SplitN(record, "\t", 3)
+		if len(fields) != 3 {
+			continue
+		}
+		entry := numstatEntry{Path: fields[2], Binary: fields[0] == "-" && fields[1] == "-"}
+		entry.Added, _ = strconv.Atoi(fields[0])
+		entry.Deleted, _ = strconv.Atoi(fields[1])
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+// summaryDirectoryDepth is the number of leading path segments a diff summary groups files by
+const summaryDirectoryDepth = 2
+
+// directoryChanges counts the changes of the files below a directory
+type directoryChanges struct {
+	files      int
+	insertions int
+	deletions  int
+}
+
+// summarizeDiffByDirectory tabulates the changed files and lines between two tags per directory,
+// grouping files by their first summaryDirectoryDepth directories. Binary files count as files only.
+func summarizeDiffByDirectory(ctx context.Context, repo Repository, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, output OutputOptions) (string, error) {
+	numstat, err := repo.GetDiffBetweenTags(ctx, tag1, tag2, pathspecs, "--numstat", "-z", "--no-renames")
+	if err != nil {
+		return "", err
+	}
+
+	changes := make(map[string]*directoryChanges)
+	for _, entry := range parseNumstat(numstat) {
+		directory := "."
+		if segments := strings.Split(entry.Path, "/"); len(segments) > 1 {
+			directory = strings.Join(segments[:min(len(segments)-1, summaryDirectoryDepth)], "/")
+		}
+		if changes[directory] == nil {
+			changes[directory] = &directoryChanges{}
+		}
+		changes[directory].files++
+		changes[directory].insertions += entry.Added
+		changes[directory].deletions += entry.Deleted
+	}
+
+	directories := make([]string, 0, len(changes))
+	for directory := range changes {
+		directories = append(directories, directory)
+	}
+	sort.Strings(directories)
+
+	rows := [][]string{{"DIRECTORY", "FILES", "INSERTIONS", "DELETIONS"}}
+	for _, directory := range directories {
+		c := changes[directory]
+		rows = append(rows, []string{directory, strconv.Itoa(c.files), "+" + strconv.Itoa(c.insertions), "-" + strconv.Itoa(c.deletions)})
+	}
+
+	var summary strings.Builder
+	writeTable(&summary, output, 0, rows)
+	return summary.String(), nil
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestNewDiffConfig tests the diff config creation
+func TestNewDiffConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		args      []string
+		wantMode  DiffMode
+		wantPaths []string
+		wantDir   string
+		wantMax   int64
+		wantError error
+	}{
+		{
+			name:     "Default stat output",
+			args:     []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v2.0.0"},
+			wantMode: DiffModeStat,
+		},
+		{
+			name:      "Full patch with pathspecs",
+			args:      []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-patch", "--", "src/api", "docs"},
+			wantMode:  DiffModePatch,
+			wantPaths: []string{"src/api", "docs"},
+		},
+		{
+			name:      "Name-only with directory flag and pathspec",
+			args:      []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-name-only", "-d", "internal", "cmd"},
+			wantMode:  DiffModeNameOnly,
+			wantPaths: []string{"cmd"},
+			wantDir:   "internal",
+		},
+		{
+			name:     "Maximum diff size",
+			args:     []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-patch", "-max-diff-bytes", "10M", "-on-diff-overflow", "summarize"},
+			wantMode: DiffModePatch,
+			wantMax:  10 << 20,
+		},
+		{
+			name:      "Unknown overflow mode",
+			args:      []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-on-diff-overflow", "drop"},
+			wantError: ErrInvalidDiffOverflow,
+		},
+		{
+			name:      "Conflicting output modes",
+			args:      []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v2.0.0", "-patch", "-name-only"},
+			wantError: ErrConflictingDiffModes,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config, err := NewDiffConfig(tt.args)
+			if tt.wantError != nil {
+				if !errors.Is(err, tt.wantError) {
+					t.Errorf("NewDiffConfig() error = %v, want %v", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewDiffConfig() error = %v, want nil", err)
+			}
+			if config.Options.Mode != tt.wantMode {
+				t.Errorf("NewDiffConfig() mode = %s, want %s", config.Options.Mode, tt.wantMode)
+			}
+			if len(config.Options.Pathspecs) > 0 || len(tt.wantPaths) > 0 {
+				if !reflect.DeepEqual(config.Options.Pathspecs, tt.wantPaths) {
+					t.Errorf("NewDiffConfig() pathspecs = %v, want %v", config.Options.Pathspecs, tt.wantPaths)
+				}
+			}
+			if config.Options.MaxBytes != tt.wantMax {
+				t.Errorf("NewDiffConfig() max bytes = %d, want %d", config.Options.MaxBytes, tt.wantMax)
+			}
+			if config.Directory != tt.wantDir {
+				t.Errorf("NewDiffConfig() directory = %q, want %q", config.Directory, tt.wantDir)
+			}
+		})
+	}
+}
+
+// TestDiffOptionsGitArgs tests the git arguments generated for each diff mode
+func TestDiffOptionsGitArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		options DiffOptions
+		want    []string
+	}{
+		{name: "Default is stat", options: DiffOptions{}, want: []string{"--stat", "--stat-width=120", "--no-renames"}},
+		{name: "Stat with custom width", options: DiffOptions{StatWidth: 80}, want: []string{"--stat", "--stat-width=80", "--no-renames"}},
+		{name: "Patch", options: DiffOptions{Mode: DiffModePatch}, want: []string{"--patch", "--no-renames"}},
+		{name: "Name-only with renames", options: DiffOptions{Mode: DiffModeNameOnly, DetectRenames: true}, want: []string{"--name-only", "--find-renames"}},
+	}
+
+	for _, tt := range tests {
+		if got := tt.options.gitArgs(); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: gitArgs() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// TestDiffRenames tests that -find-renames controls rename detection, which git turns on by default
+func TestDiffRenames(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Add f", testutil.File("f.txt", strings.Repeat("content\n", 20))).
+		Tag("v1.0.0").
+		Commit("Rename f", testutil.Remove("f.txt"), testutil.File("g.txt", strings.Repeat("content\n", 20))).
+		Tag("v1.1.0")
+	repo := openFixture(t, fixture)
+
+	for _, detect := range []bool{false, true} {
+		output, err := limitedDiff(context.Background(), repo, fixture.Reference("v1.0.0"), fixture.Reference("v1.1.0"), nil, DiffOptions{Mode: DiffModeNameOnly, DetectRenames: detect})
+		if err != nil {
+			t.Fatalf("limitedDiff() error = %v", err)
+		}
+		want := "f.txt\ng.txt\n"
+		if detect {
+			want = "g.txt\n"
+		}
+		if output != want {
+			t.Errorf("limitedDiff() with DetectRenames %v = %q, want %q", detect, output, want)
+		}
+	}
+}
+
+// TestLimitedDiff tests the handling of a diff larger than the maximum size in each overflow mode
+func TestLimitedDiff(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Commit("Add docs", testutil.File("docs/guide/intro.md", strings.Repeat("Introduction\n", 200))).
+		Tag("v1.2.0")
+	repo := openFixture(t, fixture)
+	tag1, tag2 := fixture.Reference("v1.0.0"), fixture.Reference("v1.2.0")
+
+	tests := []struct {
+		name      string
+		options   DiffOptions
+		want      []string
+		wantError error
+	}{
+		{
+			name:    "Within the limit",
+			options: DiffOptions{Mode: DiffModeNameOnly, MaxBytes: 1 << 10},
+			want:    []string{"docs/guide/intro.md\ninternal/x.go\nsrc/api/b.go\n"},
+		},
+		{
+			name:    "Truncate",
+			options: DiffOptions{Mode: DiffModePatch, MaxBytes: 512, Overflow: DiffOverflowTruncate},
+			want:    []string{"+Introduction\n", "[diff truncated at 512 B; raise -max-diff-bytes or use -on-diff-overflow summarize]\n"},
+		},
+		{
+			name:    "Summarize",
+			options: DiffOptions{Mode: DiffModePatch, MaxBytes: 512, Overflow: DiffOverflowSummarize},
+			want: []string{
+				"The diff is larger than 512 B; changes per directory:\n",
+				"DIRECTORY   FILES  INSERTIONS  DELETIONS\n",
+				"docs/guide  1      +200        -0\n",
+				"internal    1      +2          -0\n",
+				"src/api     1      +1          -0\n",
+			},
+		},
+		{
+			name:      "Fail",
+			options:   DiffOptions{Mode: DiffModePatch, MaxBytes: 512, Overflow: DiffOverflowFail},
+			wantError: ErrDiffTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := limitedDiff(context.Background(), repo, tag1, tag2, nil, tt.options)
+			if tt.wantError != nil {
+				if !errors.Is(err, tt.wantError) {
+					t.Errorf("limitedDiff() error = %v, want %v", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("limitedDiff() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(output, want) {
+					t.Errorf("limitedDiff() output missing %q:\n%s", want, output)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package internal
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// DirectoryFilter limits a comparison to several directories or glob patterns, minus excluded ones,
+// e.g. -dir 'services/**' -exclude-dir '**/testdata'. A pattern that names a directory covers all
+// of its files. Commit sets, diffs, and per-file statistics apply the same filter, through git
+// pathspecs or Match.
+type DirectoryFilter struct {
+	Include []string // Directories or glob patterns relative to the repository root; empty includes the whole tree
+	Exclude []string // Directories or glob patterns left out of the included ones
+}
+
+// directoryList is a flag.Value that collects the values of a repeated flag
+type directoryList []string
+
+func (l *directoryList) String() string {
+	if l == nil {
+		return ""
+	}
+	return strings.Join(*l, ",")
+}
+
+func (l *directoryList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// registerFlags adds the repeatable -d/-dir and -exclude-dir flags to a command
+func (f *DirectoryFilter) registerFlags(flags *flag.FlagSet, dirUsage string) {
+	flags.Var((*directoryList)(&f.Include), "d", dirUsage+"; repeat for several `path`s, which may be globs such as 'services/**'")
+	flags.Var((*directoryList)(&f.Include), "dir", "Same as -d (`path`)")
+	flags.Var((*directoryList)(&f.Exclude), "exclude-dir", "Leave out this `path` or glob, e.g. '**/testdata'; may be repeated")
+}
+
+// splitDirectoryFilter separates a filter of exactly one plain directory, which commands handle with
+// their directory-based traversal, from filters that need pathspecs
+func splitDirectoryFilter(filter DirectoryFilter) (string, DirectoryFilter) {
+	if directory, ok := filter.single(); ok {
+		return directory, DirectoryFilter{}
+	}
+	return "", filter
+}
+
+// mergeDirectoryFilter adds a single directory filter to the included directories of a filter
+func mergeDirectoryFilter(directory string, filter DirectoryFilter) DirectoryFilter {
+	if directory == "" {
+		return filter
+	}
+	return DirectoryFilter{
+		Include: append([]string{directory}, filter.Include...),
+		Exclude: filter.Exclude,
+	}
+}
+
+// IsZero reports whether the filter includes the whole tree
+func (f DirectoryFilter) IsZero() bool {
+	return len(f.Include) == 0 && len(f.Exclude) == 0
+}
+
+// single returns the directory of a filter that is exactly one directory without globs or exclusions
+func (f DirectoryFilter) single() (string, bool) {
+	if len(f.Include) != 1 || len(f.Exclude) != 0 || isGlobPattern(f.Include[0]) {
+		return "", false
+	}
+	return f.Include[0], true
+}
+
+// Validate checks that every pattern stays inside the repository and is a valid glob
+func (f DirectoryFilter) Validate() error {
+	_, err := f.clean()
+	return err
+}
+
+// clean normalizes the patterns as cleanDirectory does, dropping those that select the whole tree
+func (f DirectoryFilter) clean() (DirectoryFilter, error) {
+	var cleaned DirectoryFilter
+	for _, list := range []struct {
+		patterns []string
+		target   *[]string
+	}{{f.Include, &cleaned.Include}, {f.Exclude, &cleaned.Exclude}} {
+		for _, pattern := range list.patterns {
+			directory, err := cleanDirectory(pattern)
+			if err != nil {
+				return f, err
+			}
+			for _, segment := range strings.Split(directory, "/") {
+				if _, err := path.Match(segment, ""); err != nil {
+					return f, errors.Join(ErrInvalidDirectory, fmt.Errorf("invalid glob pattern: %s", pattern))
+				}
+			}
+			if directory != "" {
+				*list.target = append(*list.target, directory)
+			}
+		}
+	}
+	return cleaned, nil
+}
+
+// String describes the filter for output and saved results, e.g. "services/** excluding **/testdata"
+func (f DirectoryFilter) String() string {
+	description := strings.Join(f.Include, ", ")
+	if len(f.Exclude) == 0 {
+		return description
+	}
+	if description == "" {
+		description = "all"
+	}
+	return description + " excluding " + strings.Join(f.Exclude, ", ")
+}
+
+// Pathspecs returns the git pathspecs of the filter. A glob also matches the files below the
+// directories it names, as a plain directory does.
+func (f DirectoryFilter) Pathspecs() []string {
+	var pathspecs []string
+	for _, pattern := range f.Include {
+		pathspecs = append(pathspecs, patternPathspecs(pattern, "")...)
+	}
+	for _, pattern := range f.Exclude {
+		pathspecs = append(pathspecs, patternPathspecs(pattern, "exclude")...)
+	}
+	return pathspecs
+}
+
+// patternPathspecs converts one pattern into pathspecs with the given magic, e.g. "exclude"
+func patternPathspecs(pattern string, magic string) []string {
+	if !isGlobPattern(pattern) {
+		if magic == "" {
+			return []string{pattern}
+		}
+		return []string{":(" + magic + ")" + pattern}
+	}
+
+	magic = strings.TrimPrefix(magic+",glob", ",")
+	return []string{":(" + magic + ")" + pattern, ":(" + magic + ")" + pattern + "/**"}
+}
+
+// Match reports whether a file, given by its slash-separated path, passes the filter
+func (f DirectoryFilter) Match(file string) bool {
+	included := len(f.Include) == 0
+	for _, pattern := range f.Include {
+		if matchDirectoryPattern(pattern, file) {
+			included = true
+			break
+		}
+	}
+	if !included {
+		return false
+	}
+
+	for _, pattern := range f.Exclude {
+		if matchDirectoryPattern(pattern, file) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchDirectoryPattern reports whether a file is the pattern's path or below it, matching globs
+// as git's glob pathspecs do: "*" stays within a path segment and "**" spans any number of them
+func matchDirectoryPattern(pattern string, file string) bool {
+	if !isGlobPattern(pattern) {
+		return file == pattern || strings.HasPrefix(file, pattern+"/")
+	}
+
+	patternSegments := strings.Split(pattern, "/")
+	fileSegments := strings.Split(file, "/")
+	return matchSegments(patternSegments, fileSegments) || matchSegments(append(patternSegments, "**"), fileSegments)
+}
+
+func matchSegments(pattern []string, file []string) bool {
+	if len(pattern) == 0 {
+		return len(file) == 0
+	}
+
+	if pattern[0] == "**" {
+		for skip := 0; skip <= len(file); skip++ {
+			if matchSegments(pattern[1:], file[skip:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(file) == 0 {
+		return false
+	}
+	if matched, _ := path.Match(pattern[0], file[0]); !matched {
+		return false
+	}
+	return matchSegments(pattern[1:], file[1:])
+}
+
+// isGlobPattern reports whether a directory filter contains glob characters
+func isGlobPattern(pattern string) bool {
+	return strings.ContainsAny(pattern, "*?[")
+}
+
+// validateDirectoryFilterInTags checks that each plain directory of a filter exists in at least one of
+// the tags, as validateDirectoryInTags does for a single directory. Globs and exclusions are not checked.
+func validateDirectoryFilterInTags(repo Repository, filter DirectoryFilter, refs ...*plumbing.Reference) error {
+	for _, pattern := range filter.Include {
+		if isGlobPattern(pattern) {
+			continue
+		}
+		if err := validateDirectoryInTags(repo, pattern, refs...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
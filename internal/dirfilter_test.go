@@ -0,0 +1,152 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestDirectoryFilterMatch tests matching files against included and excluded directories and globs
+func TestDirectoryFilterMatch(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter DirectoryFilter
+		file   string
+		want   bool
+	}{
+		{name: "No filter", file: "main.go", want: true},
+		{name: "Plain directory", filter: DirectoryFilter{Include: []string{"src"}}, file: "src/api/a.go", want: true},
+		{name: "Plain directory is not a prefix of a sibling", filter: DirectoryFilter{Include: []string{"src"}}, file: "srcgen/a.go", want: false},
+		{name: "Double star covers the directory", filter: DirectoryFilter{Include: []string{"services/**"}}, file: "services/a/main.go", want: true},
+		{name: "Star stays in a segment", filter: DirectoryFilter{Include: []string{"services/*/cmd"}}, file: "services/a/cmd/main.go", want: true},
+		{name: "Star does not span segments", filter: DirectoryFilter{Include: []string{"services/*/cmd"}}, file: "services/a/b/cmd/main.go", want: false},
+		{name: "Second include", filter: DirectoryFilter{Include: []string{"src", "lib"}}, file: "lib/l.go", want: true},
+		{name: "Excluded glob", filter: DirectoryFilter{Include: []string{"services/**"}, Exclude: []string{"**/testdata"}}, file: "services/a/testdata/x.txt", want: false},
+		{name: "Exclusion only", filter: DirectoryFilter{Exclude: []string{"vendor"}}, file: "vendor/x/x.go", want: false},
+		{name: "Outside exclusion", filter: DirectoryFilter{Exclude: []string{"vendor"}}, file: "main.go", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.filter.Match(tt.file); got != tt.want {
+				t.Errorf("Match(%s) = %v, want %v", tt.file, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDirectoryFilterPathspecs tests converting directories and globs into git pathspecs
+func TestDirectoryFilterPathspecs(t *testing.T) {
+	filter := DirectoryFilter{Include: []string{"src", "services/**"}, Exclude: []string{"**/testdata", "vendor"}}
+	want := []string{
+		"src",
+		":(glob)services/**", ":(glob)services/**/**",
+		":(exclude,glob)**/testdata", ":(exclude,glob)**/testdata/**",
+		":(exclude)vendor",
+	}
+	if got := filter.Pathspecs(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Pathspecs() = %v, want %v", got, want)
+	}
+	if got := filter.String(); got != "src, services/** excluding **/testdata, vendor" {
+		t.Errorf("String() = %q", got)
+	}
+}
+
+// TestDirectoryFilterValidate tests rejecting patterns outside the repository and malformed globs
+func TestDirectoryFilterValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  DirectoryFilter
+		wantErr bool
+	}{
+		{name: "Directories and globs", filter: DirectoryFilter{Include: []string{"src/", "services/**"}, Exclude: []string{"**/testdata"}}},
+		{name: "Outside the repository", filter: DirectoryFilter{Exclude: []string{"../other"}}, wantErr: true},
+		{name: "Absolute path", filter: DirectoryFilter{Include: []string{"/src"}}, wantErr: true},
+		{name: "Malformed glob", filter: DirectoryFilter{Include: []string{"src/[a"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.filter.Validate()
+			if tt.wantErr != (err != nil) {
+				t.Fatalf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrInvalidDirectory) {
+				t.Errorf("Validate() error = %v, want ErrInvalidDirectory", err)
+			}
+		})
+	}
+}
+
+// TestNewCompareConfig_DirectoryFilters tests the repeated -dir and -exclude-dir flags
+func TestNewCompareConfig_DirectoryFilters(t *testing.T) {
+	base := []string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v1.1.0"}
+
+	config, err := NewCompareConfig(append(base, "-dir", "src"))
+	if err != nil {
+		t.Fatalf("NewCompareConfig() error = %v", err)
+	}
+	if config.Directory != "src" || !config.Paths.IsZero() {
+		t.Errorf("single -dir: Directory = %q, Paths = %+v, want src and no other filters", config.Directory, config.Paths)
+	}
+
+	config, err = NewCompareConfig(append(base, "-dir", "services/**", "-d", "lib", "-exclude-dir", "**/testdata"))
+	if err != nil {
+		t.Fatalf("NewCompareConfig() error = %v", err)
+	}
+	want := DirectoryFilter{Include: []string{"services/**", "lib"}, Exclude: []string{"**/testdata"}}
+	if config.Directory != "" || !reflect.DeepEqual(config.Paths, want) {
+		t.Errorf("Directory = %q, Paths = %+v, want %+v", config.Directory, config.Paths, want)
+	}
+
+	config.FirstParent = true
+	if err := config.Validate(); !errors.Is(err, ErrInvalidDirectory) {
+		t.Errorf("Validate() with -first-parent error = %v, want ErrInvalidDirectory", err)
+	}
+}
+
+// TestCompare_DirectoryFilters tests that commit sets and diffs apply the same directory filters
+func TestCompare_DirectoryFilters(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n")).
+		Tag("v1.0.0").
+		Commit("Change service a", testutil.File("services/a/main.go", "package a\n")).
+		Commit("Change fixtures", testutil.File("services/a/testdata/input.txt", "input\n")).
+		Commit("Change lib", testutil.File("lib/lib.go", "package lib\n")).
+		Commit("Change docs", testutil.File("docs/guide.md", "guide\n")).
+		Tag("v1.1.0")
+
+	paths := DirectoryFilter{Include: []string{"services/**", "lib"}, Exclude: []string{"**/testdata"}}
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		Paths:      paths,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	if len(result.OnlyInTag2) != 2 {
+		t.Errorf("OnlyInTag2 has %d commits, want 2 (service a and lib)", len(result.OnlyInTag2))
+	}
+	for _, revision := range []string{"v1.1.0~3", "v1.1.0~1"} {
+		if _, ok := result.OnlyInTag2[fixture.Hash(revision)]; !ok {
+			t.Errorf("OnlyInTag2 is missing %s", revision)
+		}
+	}
+
+	diff, err := Diff(context.Background(), DiffConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		Paths:      paths,
+		Options:    DiffOptions{Mode: DiffModeNameOnly},
+	})
+	if err != nil {
+		t.Fatalf("Diff() error = %v", err)
+	}
+	if got := strings.Fields(diff); !reflect.DeepEqual(got, []string{"lib/lib.go", "services/a/main.go"}) {
+		t.Errorf("Diff() files = %v, want lib/lib.go and services/a/main.go", got)
+	}
+}
@@ -0,0 +1,380 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var ErrExplain = errors.New("failed to explain similarity")
+
+// rewriteMatchRatio is the share of the smaller unique side that must match commits of the other side
+// by author, date, and subject before the histories are reported as rewritten
+const rewriteMatchRatio = 0.5
+
+// ExplainConfig holds the configuration of the explain-zero command
+type ExplainConfig struct {
+	Command Command
+	TagOptions
+	Directory string
+	Output    OutputOptions
+	Timeout   time.Duration // Cancel the explanation after this long; 0 never times out
+}
+
+// explainUsage is the help of the explain-zero command
+var explainUsage = commandUsage{
+	Name:        "explain-zero",
+	Summary:     "Explain a 0% or 100% similarity",
+	Description: "Explain a similarity of 0% or 100% by checking for the common causes: shallow clones,\ngrafted or replaced history, unrelated root commits, rewritten history, and tags on the same commit.",
+	Examples: []string{
+		"explain-zero -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+		"explain-zero -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -d src/api",
+	},
+}
+
+// NewExplainConfig parses the explain-zero command flags
+func NewExplainConfig(args []string) (ExplainConfig, error) {
+	config := ExplainConfig{Command: ExplainZeroCommand}
+	explainCmd := newCommandFlagSet(explainUsage)
+	parseTagOptions := config.TagOptions.registerFlags(explainCmd, "name to compare")
+	explainCmd.StringVar(&config.Directory, "d", "", "Directory path used to filter commits, as passed to compare")
+	explainCmd.StringVar(&config.Directory, "dir", "", "Same as -d")
+	parseOutputOptions := config.Output.registerFlags(explainCmd, "Maximum line width of the findings table")
+	registerTimeoutFlag(explainCmd, &config.Timeout, "the explanation")
+
+	if err := explainCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseTagOptions(); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	if err := validateTimeout(config.Timeout); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Explanation is the outcome of the explain-zero diagnostics.
+// Findings use CheckWarn for causes that apply and CheckOK for causes that were ruled out.
+type Explanation struct {
+	Tag1       string
+	Tag2       string
+	Similarity float64
+	Findings   []CheckResult
+}
+
+// Unexpected reports whether the similarity is one of the extremes the diagnostics explain
+func (e Explanation) Unexpected() bool {
+	return e.Similarity == 0 || e.Similarity == 1
+}
+
+// Causes returns the findings that apply
+func (e Explanation) Causes() []CheckResult {
+	var causes []CheckResult
+	for _, finding := range e.Findings {
+		if finding.Status != CheckOK {
+			causes = append(causes, finding)
+		}
+	}
+	return causes
+}
+
+// Explain compares the tags and checks for the common causes of a 0% or 100% similarity
+func Explain(ctx context.Context, config ExplainConfig) (_ Explanation, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	result, err := CompareContext(ctx, CompareConfig{Command: CompareCommand, TagOptions: config.TagOptions, Directory: config.Directory})
+	if err != nil {
+		return Explanation{}, err
+	}
+
+	explanation := Explanation{
+		Tag1:       result.Config.Tag1Name,
+		Tag2:       result.Config.Tag2Name,
+		Similarity: result.Similarity,
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, config.CloneDepth)
+	if err != nil {
+		return explanation, errors.Join(ErrOpenRepository, err)
+	}
+
+	commit1, err := repo.GetTagCommit(result.Tag1Ref)
+	if err != nil {
+		return explanation, errors.Join(ErrExplain, err)
+	}
+	commit2, err := repo.GetTagCommit(result.Tag2Ref)
+	if err != nil {
+		return explanation, errors.Join(ErrExplain, err)
+	}
+
+	shallow := checkShallow(repo)
+	if shallow.Status == CheckFail {
+		shallow.Status = CheckWarn
+	}
+
+	unrelated, err := explainUnrelatedRoots(ctx, repo, commit1, commit2)
+	if err != nil {
+		return explanation, errors.Join(ErrExplain, err)
+	}
+
+	rewritten, err := explainRewrittenHistory(result)
+	if err != nil {
+		return explanation, errors.Join(ErrExplain, err)
+	}
+
+	explanation.Findings = []CheckResult{
+		explainSameCommit(result, commit1, commit2),
+		shallow,
+		explainGrafts(ctx, repo),
+		unrelated,
+		rewritten,
+		explainUnreadable(result),
+		explainDirectory(result, commit1, commit2),
+	}
+	return explanation, nil
+}
+
+// explainSameCommit reports tags that point to the same commit, which always share all history
+func explainSameCommit(result CompareResult, commit1 *object.Commit, commit2 *object.Commit) CheckResult {
+	finding := CheckResult{Name: "same commit", Status: CheckOK, Detail: "the tags point to different commits"}
+	if commit1.Hash == commit2.Hash {
+		finding.Status = CheckWarn
+		finding.Detail = fmt.Sprintf("%s and %s both point to commit %s", result.Config.Tag1Name, result.Config.Tag2Name, shortHash(commit1.Hash.String()))
+		finding.Hint = "check whether a tag was moved, or whether the intended tag was pushed"
+	}
+	return finding
+}
+
+// countReplaceRefs returns the number of refs under refs/replace/, or 0 when the refs cannot be read
+func (gr *GitRepository) countReplaceRefs() int {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return 0
+	}
+	defer gr.returnReader(reader)
+
+	refs, err := reader.References()
+	if err != nil {
+		return 0
+	}
+	replaced := 0
+	_ = refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), "refs/replace/") {
+			replaced++
+		}
+		return nil
+	})
+	return replaced
+}
+
+// explainGrafts reports grafts and replace refs, which native git honors but go-git does not,
+// so commit sets and diffs may disagree about the history
+func explainGrafts(ctx context.Context, repo *GitRepository) CheckResult {
+	finding := CheckResult{Name: "grafted history", Status: CheckOK, Detail: "no grafts or replace refs"}
+
+	var sources []string
+	if output, err := exec.CommandContext(ctx, "git", "-C", repo.path, "rev-parse", "--git-path", "info/grafts").Output(); err == nil {
+		grafts := strings.TrimSpace(string(output))
+		if !filepath.IsAbs(grafts) {
+			grafts = filepath.Join(repo.path, grafts)
+		}
+		if _, err := os.Stat(grafts); err == nil {
+			sources = append(sources, "info/grafts")
+		}
+	}
+
+	if replaced := repo.countReplaceRefs(); replaced > 0 {
+		sources = append(sources, fmt.Sprintf("%d replace ref(s)", replaced))
+	}
+
+	if len(sources) > 0 {
+		finding.Status = CheckWarn
+		finding.Detail = "history is altered by " + strings.Join(sources, " and ") + ", which commit sets ignore"
+		finding.Hint = "run 'git replace --list' and check info/grafts; compare the original tags or make the replacement permanent with 'git filter-repo'"
+	}
+	return finding
+}
+
+// explainUnrelatedRoots reports tags without a common ancestor, e.g. a history imported
+// from another repository or tags pointing to orphan branches
+func explainUnrelatedRoots(ctx context.Context, repo *GitRepository, commit1 *object.Commit, commit2 *object.Commit) (CheckResult, error) {
+	finding := CheckResult{Name: "unrelated roots", Status: CheckOK}
+
+	base, err := repo.mergeBase(ctx, commit1.Hash, commit2.Hash)
+	if err != nil {
+		return finding, err
+	}
+	if base != plumbing.ZeroHash {
+		finding.Detail = "common ancestor " + shortHash(base.String())
+		return finding, nil
+	}
+
+	roots1, err := repo.rootCommits(ctx, commit1.Hash)
+	if err != nil {
+		return finding, err
+	}
+	roots2, err := repo.rootCommits(ctx, commit2.Hash)
+	if err != nil {
+		return finding, err
+	}
+
+	finding.Status = CheckWarn
+	finding.Detail = fmt.Sprintf("no common ancestor; root commits %s vs %s", strings.Join(roots1, ", "), strings.Join(roots2, ", "))
+	finding.Hint = "check that both tags belong to the same project and were not created on an orphan branch or an imported history"
+	return finding, nil
+}
+
+// explainRewrittenHistory reports unique commits that match commits of the other tag by author,
+// author date, and subject: the signature of a rebase, filter-branch, or cherry-picked release line
+func explainRewrittenHistory(result CompareResult) (CheckResult, error) {
+	finding := CheckResult{Name: "rewritten history", Status: CheckOK, Detail: "unique commits do not duplicate each other"}
+
+	smaller := min(len(result.OnlyInTag1), len(result.OnlyInTag2))
+	if smaller == 0 {
+		return finding, nil
+	}
+
+	commits1, err := result.Repo.GetCommitObjects(hashesOf(result.OnlyInTag1))
+	if err != nil {
+		return finding, err
+	}
+	commits2, err := result.Repo.GetCommitObjects(hashesOf(result.OnlyInTag2))
+	if err != nil {
+		return finding, err
+	}
+
+	keys := make(map[string]struct{}, len(commits1))
+	for _, commit := range commits1 {
+		keys[commitIdentity(commit)] = struct{}{}
+	}
+	matches := 0
+	for _, commit := range commits2 {
+		if _, ok := keys[commitIdentity(commit)]; ok {
+			matches++
+		}
+	}
+
+	finding.Detail = fmt.Sprintf("%d of %d unique commits match by author, date, and subject", matches, smaller)
+	if float64(matches) >= float64(smaller)*rewriteMatchRatio {
+		finding.Status = CheckWarn
+		finding.Hint = "the history was rewritten or cherry-picked, so the same changes have different hashes; compare against a tag cut before the rewrite"
+	}
+	return finding, nil
+}
+
+// commitIdentity identifies a commit independently of its hash, which changes when history is rewritten
+func commitIdentity(commit *object.Commit) string {
+	subject := strings.SplitN(strings.TrimSpace(commit.Message), "\n", 2)[0]
+	return fmt.Sprintf("%s\x00%d\x00%s", commit.Author.Email, commit.Author.When.Unix(), subject)
+}
+
+// explainUnreadable reports commits skipped because of missing objects, which truncate the commit sets
+func explainUnreadable(result CompareResult) CheckResult {
+	finding := CheckResult{Name: "missing objects", Status: CheckOK, Detail: "all commits were readable"}
+	if len(result.UnreadableCommits) > 0 {
+		finding.Status = CheckWarn
+		finding.Detail = fmt.Sprintf("%d commit(s) could not be read; the history behind them was skipped", len(result.UnreadableCommits))
+		finding.Hint = "run 'check' for details, then 'git fetch --refetch' or restore from a healthy clone"
+	}
+	return finding
+}
+
+// explainDirectory reports a directory filter that hides all differences between tags on different commits
+func explainDirectory(result CompareResult, commit1 *object.Commit, commit2 *object.Commit) CheckResult {
+	finding := CheckResult{Name: "directory filter", Status: CheckOK, Detail: "no directory filter"}
+	if result.Config.Directory == "" {
+		return finding
+	}
+
+	finding.Detail = "filtering on " + result.Config.Directory
+	if commit1.Hash != commit2.Hash && len(result.OnlyInTag1) == 0 && len(result.OnlyInTag2) == 0 {
+		finding.Status = CheckWarn
+		finding.Detail = fmt.Sprintf("no commit between the tags touches %s", result.Config.Directory)
+		finding.Hint = "run compare without -d to see the commits outside the directory"
+	}
+	return finding
+}
+
+// mergeBase returns the best common ancestor of two commits, or the zero hash when they share no history
+func (gr *GitRepository) mergeBase(ctx context.Context, a plumbing.Hash, b plumbing.Hash) (plumbing.Hash, error) {
+	if index := gr.tagIndex(); index != nil {
+		if base, found := index.mergeBase(a, b); found {
+			return base, nil
+		}
+	}
+
+	// Command: git merge-base <a> <b>
+	cmd := exec.CommandContext(ctx, "git", "merge-base", a.String(), b.String())
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		// Exit status 1 without output means there is no common ancestor
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+			return plumbing.ZeroHash, nil
+		}
+		return plumbing.ZeroHash, errors.Join(ErrCheckAncestry, err)
+	}
+	return plumbing.NewHash(strings.TrimSpace(string(output))), nil
+}
+
+// rootCommits returns the abbreviated hashes of the parentless commits reachable from a commit
+func (gr *GitRepository) rootCommits(ctx context.Context, hash plumbing.Hash) ([]string, error) {
+	// Command: git rev-list --max-parents=0 <hash>
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--max-parents=0", hash.String())
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	var roots []string
+	for _, line := range strings.Fields(string(output)) {
+		roots = append(roots, shortHash(line))
+	}
+	return roots, nil
+}
+
+// PrintExplanation prints the similarity, the causes that apply, and the causes that were ruled out
+func PrintExplanation(w io.Writer, explanation Explanation, output OutputOptions) {
+	_, _ = fmt.Fprintf(w, "Comparing tags: %s vs %s\n", explanation.Tag1, explanation.Tag2)
+	_, _ = fmt.Fprintf(w, "Similarity: %.2f%%\n", explanation.Similarity*100.0)
+
+	causes := explanation.Causes()
+	switch {
+	case !explanation.Unexpected():
+		_, _ = fmt.Fprintf(w, "The similarity is neither 0%% nor 100%%; the checks below are for reference.\n")
+	case len(causes) == 0 && explanation.Similarity == 0:
+		_, _ = fmt.Fprintf(w, "None of the common causes apply; the tags most likely share no history.\n")
+	case len(causes) == 0:
+		_, _ = fmt.Fprintf(w, "None of the common causes apply; the tags most likely share all history.\n")
+	default:
+		names := make([]string, 0, len(causes))
+		for _, cause := range causes {
+			names = append(names, cause.Name)
+		}
+		_, _ = fmt.Fprintf(w, "Likely cause: %s\n", strings.Join(names, ", "))
+	}
+
+	_, _ = fmt.Fprintf(w, "\n")
+	PrintCheckResults(w, explanation.Findings, output)
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestExplain tests which causes are reported for unexpected similarities
+func TestExplain(t *testing.T) {
+	start := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		fixture    func(t *testing.T) *testutil.RepoBuilder
+		directory  string
+		similarity float64
+		causes     []string
+	}{
+		{
+			name: "Rewritten history",
+			fixture: func(t *testing.T) *testutil.RepoBuilder {
+				// The orphan branch replays the same commits, as filter-branch would
+				return testutil.NewRepo(t).
+					Commit("Add API", testutil.File("api.go", "package api\n")).
+					Commit("Add docs", testutil.File("README.md", "readme\n")).
+					Tag("v1.0.0").
+					Orphan("rewritten").
+					At(start).
+					Commit("Add API", testutil.File("api.go", "package api\n")).
+					Commit("Add docs", testutil.File("README.md", "readme\n")).
+					Tag("v1.0.0-rewritten")
+			},
+			similarity: 0,
+			causes:     []string{"unrelated roots", "rewritten history"},
+		},
+		{
+			name: "Same commit",
+			fixture: func(t *testing.T) *testutil.RepoBuilder {
+				return testutil.NewRepo(t).
+					Commit("Add API", testutil.File("api.go", "package api\n")).
+					Tag("v1.0.0").
+					Tag("v1.0.0-rewritten")
+			},
+			similarity: 1,
+			causes:     []string{"same commit"},
+		},
+		{
+			name: "Directory without changes",
+			fixture: func(t *testing.T) *testutil.RepoBuilder {
+				return testutil.NewRepo(t).
+					Commit("Add API", testutil.File("api/a.go", "package api\n")).
+					Tag("v1.0.0").
+					Commit("Add docs", testutil.File("README.md", "readme\n")).
+					Tag("v1.0.0-rewritten")
+			},
+			directory:  "api",
+			similarity: 1,
+			causes:     []string{"directory filter"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := tt.fixture(t)
+			config := ExplainConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.0.0-rewritten"},
+				Directory:  tt.directory,
+			}
+
+			explanation, err := Explain(context.Background(), config)
+			if err != nil {
+				t.Fatalf("Explain() error = %v", err)
+			}
+			if explanation.Similarity != tt.similarity || !explanation.Unexpected() {
+				t.Errorf("Explain() similarity = %v, want %v", explanation.Similarity, tt.similarity)
+			}
+
+			var causes []string
+			for _, cause := range explanation.Causes() {
+				causes = append(causes, cause.Name)
+			}
+			if len(causes) != len(tt.causes) {
+				t.Fatalf("Causes() = %v, want %v", causes, tt.causes)
+			}
+			for i := range causes {
+				if causes[i] != tt.causes[i] {
+					t.Errorf("Causes()[%d] = %s, want %s", i, causes[i], tt.causes[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrInvalidFileMatrixFormat = errors.New("invalid file matrix format")
+	ErrGetFileCommits          = errors.New("failed to get file commits")
+	ErrWriteFileMatrix         = errors.New("failed to write file matrix")
+)
+
+// FileMatrixFormat is the export format of the per-file similarity matrix
+type FileMatrixFormat string
+
+const (
+	FileMatrixCSV  FileMatrixFormat = "csv"
+	FileMatrixJSON FileMatrixFormat = "json"
+)
+
+// ParseFileMatrixFormat converts a flag value into a FileMatrixFormat
+func ParseFileMatrixFormat(value string) (FileMatrixFormat, error) {
+	switch FileMatrixFormat(value) {
+	case FileMatrixCSV, FileMatrixJSON:
+		return FileMatrixFormat(value), nil
+	default:
+		return "", errors.Join(ErrInvalidFileMatrixFormat, fmt.Errorf("unknown format: %s (expected csv or json)", value))
+	}
+}
+
+// FileSimilarity describes how the history of a single file differs between two tags
+type FileSimilarity struct {
+	Path          string  `json:"path"`
+	OnlyInTag1    int     `json:"only_in_tag1"`
+	OnlyInTag2    int     `json:"only_in_tag2"`
+	SharedCommits int     `json:"shared_commits"`
+	Similarity    float64 `json:"similarity"`
+}
+
+// BuildFileMatrix computes the per-file similarity for every file changed between the tags.
+// fileCommits maps each changed file to the commits that touched it; the commits are split
+// into shared and unique sides using the commit sets already computed in result.
+// Rows are ordered from least to most similar, then by path.
+func BuildFileMatrix(result CompareResult, fileCommits map[string][]plumbing.Hash) []FileSimilarity {
+	rows := make([]FileSimilarity, 0, len(fileCommits))
+	for path, commits := range fileCommits {
+		row := FileSimilarity{Path: path}
+		seen := make(map[plumbing.Hash]struct{}, len(commits))
+		for _, hash := range commits {
+			if _, ok := seen[hash]; ok {
+				continue
+			}
+			seen[hash] = struct{}{}
+
+			if _, ok := result.SharedCommits[hash]; ok {
+				row.SharedCommits++
+			} else if _, ok := result.OnlyInTag1[hash]; ok {
+				row.OnlyInTag1++
+			} else if _, ok := result.OnlyInTag2[hash]; ok {
+				row.OnlyInTag2++
+			}
+		}
+
+		union := row.SharedCommits + row.OnlyInTag1 + row.OnlyInTag2
+		if union == 0 {
+			row.Similarity = 1.0
+		} else {
+			row.Similarity = float64(row.SharedCommits) / float64(union)
+		}
+		rows = append(rows, row)
+	}
+
+	sort.Slice(rows, func(i int, j int) bool {
+		if rows[i].Similarity != rows[j].Similarity {
+			return rows[i].Similarity < rows[j].Similarity
+		}
+		return rows[i].Path < rows[j].Path
+	})
+
+	return rows
+}
+
+// WriteFileMatrix writes the per-file similarity matrix of a result to the configured path
+func WriteFileMatrix(result CompareResult) error {
+	file, err := os.Create(result.Config.FileMatrixPath)
+	if err != nil {
+		return errors.Join(ErrWriteFileMatrix, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	switch result.Config.FileMatrixFormat {
+	case FileMatrixJSON:
+		err = writeFileMatrixJSON(file, result.FileMatrix)
+	default:
+		err = writeFileMatrixCSV(file, result.FileMatrix)
+	}
+	if err != nil {
+		return errors.Join(ErrWriteFileMatrix, err)
+	}
+
+	return nil
+}
+
+func writeFileMatrixCSV(w io.Writer, rows []FileSimilarity) error {
+	writer := csv.NewWriter(w)
+	if err := writer.Write([]string{"path", "only_in_tag1", "only_in_tag2", "shared_commits", "similarity"}); err != nil {
+		return err
+	}
+
+	for _, row := range rows {
+		record := []string{
+			row.Path,
+			strconv.Itoa(row.OnlyInTag1),
+			strconv.Itoa(row.OnlyInTag2),
+			strconv.Itoa(row.SharedCommits),
+			strconv.FormatFloat(row.Similarity, 'f', 4, 64),
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeFileMatrixJSON(w io.Writer, rows []FileSimilarity) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(rows)
+}
@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestBuildFileMatrix tests splitting per-file commits into shared and unique sides
+func TestBuildFileMatrix(t *testing.T) {
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+	hash3 := plumbing.NewHash("0000000000000000000000000000000000000003")
+	hash4 := plumbing.NewHash("0000000000000000000000000000000000000004")
+
+	result := CompareResult{
+		SharedCommits: map[plumbing.Hash]struct{}{hash1: {}},
+		OnlyInTag1:    map[plumbing.Hash]struct{}{hash2: {}},
+		OnlyInTag2:    map[plumbing.Hash]struct{}{hash3: {}, hash4: {}},
+	}
+
+	fileCommits := map[string][]plumbing.Hash{
+		"src/main.go":   {hash1, hash2, hash3},
+		"src/new.go":    {hash4},
+		"docs/guide.md": {hash1, hash1},
+	}
+
+	rows := BuildFileMatrix(result, fileCommits)
+	if len(rows) != 3 {
+		t.Fatalf("BuildFileMatrix() returned %d rows, want 3", len(rows))
+	}
+
+	want := []FileSimilarity{
+		{Path: "src/new.go", OnlyInTag2: 1, Similarity: 0.0},
+		{Path: "src/main.go", OnlyInTag1: 1, OnlyInTag2: 1, SharedCommits: 1, Similarity: 1.0 / 3.0},
+		{Path: "docs/guide.md", SharedCommits: 1, Similarity: 1.0},
+	}
+	for i, row := range rows {
+		if row != want[i] {
+			t.Errorf("BuildFileMatrix()[%d] = %+v, want %+v", i, row, want[i])
+		}
+	}
+}
+
+// TestWriteFileMatrixCSV tests the CSV export layout
+func TestWriteFileMatrixCSV(t *testing.T) {
+	rows := []FileSimilarity{
+		{Path: "src/main.go", OnlyInTag1: 1, OnlyInTag2: 2, SharedCommits: 1, Similarity: 0.25},
+	}
+
+	var buf bytes.Buffer
+	if err := writeFileMatrixCSV(&buf, rows); err != nil {
+		t.Fatalf("writeFileMatrixCSV() error = %v, want nil", err)
+	}
+
+	want := "path,only_in_tag1,only_in_tag2,shared_commits,similarity\nsrc/main.go,1,2,1,0.2500\n"
+	if buf.String() != want {
+		t.Errorf("writeFileMatrixCSV() = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestParseFileMatrixFormat tests parsing of the -file-matrix-format flag value
+func TestParseFileMatrixFormat(t *testing.T) {
+	if _, err := ParseFileMatrixFormat("csv"); err != nil {
+		t.Errorf("ParseFileMatrixFormat(csv) error = %v, want nil", err)
+	}
+	if _, err := ParseFileMatrixFormat("json"); err != nil {
+		t.Errorf("ParseFileMatrixFormat(json) error = %v, want nil", err)
+	}
+	if _, err := ParseFileMatrixFormat("xml"); !errors.Is(err, ErrInvalidFileMatrixFormat) {
+		t.Errorf("ParseFileMatrixFormat(xml) error = %v, want %v", err, ErrInvalidFileMatrixFormat)
+	}
+}
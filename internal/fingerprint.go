@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+var ErrFingerprintRepository = errors.New("failed to fingerprint repository")
+
+// fingerprintRemote is the remote preferred for the fingerprint when a repository has several
+const fingerprintRemote = "origin"
+
+// RepoFingerprint identifies a repository independently of where it is checked out,
+// so results of different clones of the same repository can be matched up.
+type RepoFingerprint struct {
+	ID         string `json:"id"`               // Hash of the remote and root commit, used as the key
+	Remote     string `json:"remote,omitempty"` // Normalized remote URL, e.g. github.com/owner/repo
+	RootCommit string `json:"root_commit"`      // Initial commit reachable from HEAD
+}
+
+// FingerprintRepository computes the identity of a repository from its normalized remote URL and initial commit.
+// Returns a zero fingerprint for a repository with neither a remote nor a commit.
+func FingerprintRepository(ctx context.Context, repo Repository) (RepoFingerprint, error) {
+	var fingerprint RepoFingerprint
+
+	urls, err := repo.GetRemoteURLs()
+	if err != nil {
+		return fingerprint, errors.Join(ErrFingerprintRepository, err)
+	}
+	fingerprint.Remote = normalizeRemoteURL(preferredRemoteURL(urls))
+
+	roots, err := repo.GetRootCommits(ctx)
+	if err != nil {
+		return fingerprint, errors.Join(ErrFingerprintRepository, err)
+	}
+	// Histories that merged unrelated projects have several roots; the smallest hash is stable across clones
+	for _, root := range roots {
+		if hash := root.String(); fingerprint.RootCommit == "" || hash < fingerprint.RootCommit {
+			fingerprint.RootCommit = hash
+		}
+	}
+
+	if fingerprint.Remote == "" && fingerprint.RootCommit == "" {
+		return RepoFingerprint{}, nil
+	}
+
+	sum := sha256.Sum256([]byte(fingerprint.Remote + "\n" + fingerprint.RootCommit))
+	fingerprint.ID = hex.EncodeToString(sum[:8])
+	return fingerprint, nil
+}
+
+// preferredRemoteURL returns the URL of origin, or of the first remote by name if there is no origin
+func preferredRemoteURL(urls map[string]string) string {
+	if remoteURL, ok := urls[fingerprintRemote]; ok {
+		return remoteURL
+	}
+
+	names := make([]string, 0, len(urls))
+	for name := range urls {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	if len(names) == 0 {
+		return ""
+	}
+	return urls[names[0]]
+}
+
+// normalizeRemoteURL reduces the URL forms of one hosted repository to host/path, so
+// https://github.com/owner/repo.git and git@github.com:owner/repo map to github.com/owner/repo.
+// Credentials, ports, and the scheme are dropped. Local paths return "", since they differ per machine.
+func normalizeRemoteURL(remoteURL string) string {
+	remoteURL = strings.TrimSpace(remoteURL)
+	if remoteURL == "" {
+		return ""
+	}
+
+	var host, path string
+	if strings.Contains(remoteURL, "://") {
+		parsed, err := url.Parse(remoteURL)
+		if err != nil || parsed.Host == "" {
+			// file:// URLs have no host
+			return ""
+		}
+		host, path = parsed.Hostname(), parsed.Path
+	} else if before, after, ok := strings.Cut(remoteURL, ":"); ok && !strings.Contains(before, "/") && len(before) > 1 {
+		// scp-like syntax: [user@]host:path; a single letter before the colon is a Windows drive
+		host, path = before, after
+		if _, hostname, ok := strings.Cut(host, "@"); ok {
+			host = hostname
+		}
+	} else {
+		return ""
+	}
+
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	return strings.ToLower(host) + "/" + path
+}
@@ -0,0 +1,266 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrMissingGetQuery = errors.New("get query is required")
+	ErrUnknownGetQuery = errors.New("unknown get query")
+	ErrInvalidSide     = errors.New("invalid side")
+	ErrNoMergeBase     = errors.New("tags share no history")
+)
+
+// Queries of the get command
+const (
+	GetSimilarity  = "similarity"
+	GetSharedCount = "shared-count"
+	GetUniqueCount = "unique-count"
+	GetMergeBase   = "merge-base"
+)
+
+// getUsage is the help of the get command
+var getUsage = commandUsage{
+	Name:     "get",
+	Synopsis: "<query> [options]",
+	Summary:  "Print a single value, e.g. the similarity, for shell scripts",
+	Description: "Print exactly one value about a tag pair for use in shell conditionals. Only the work\n" +
+		"needed for the value is done: no tag metadata, diff stat, or report data.",
+	Examples: []string{
+		"get similarity -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+	},
+	Subcommands: []commandUsage{getSimilarityUsage, getSharedCountUsage, getUniqueCountUsage, getMergeBaseUsage},
+}
+
+// getSimilarityUsage is the help of the get similarity query
+var getSimilarityUsage = commandUsage{
+	Name:        "get similarity",
+	Summary:     "Similarity as a fraction between 0 and 1, e.g. 0.4286",
+	Description: "Print the similarity of two tags as a fraction between 0 and 1 with four decimals.",
+	Examples: []string{
+		"get similarity -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+		"get similarity -repo /path/to/repo -tag1 latest-1 -tag2 latest -d src/api -match patch-id",
+	},
+}
+
+// getSharedCountUsage is the help of the get shared-count query
+var getSharedCountUsage = commandUsage{
+	Name:        "get shared-count",
+	Summary:     "Number of commits shared by both tags",
+	Description: "Print the number of commits reachable from both tags.",
+	Examples: []string{
+		"get shared-count -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+	},
+}
+
+// getUniqueCountUsage is the help of the get unique-count query
+var getUniqueCountUsage = commandUsage{
+	Name:        "get unique-count",
+	Summary:     "Number of commits only in one tag (-side tag1 or tag2)",
+	Description: "Print the number of commits reachable from one tag but not the other.",
+	Examples: []string{
+		"get unique-count -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -side tag2",
+		"get unique-count -repo /path/to/repo -tag1 latest -tag2 main",
+	},
+}
+
+// getMergeBaseUsage is the help of the get merge-base query
+var getMergeBaseUsage = commandUsage{
+	Name:        "get merge-base",
+	Summary:     "Best common ancestor of the tags",
+	Description: "Print the full hash of the best common ancestor of two tags; fails when they share no history.",
+	Examples: []string{
+		"get merge-base -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0",
+	},
+}
+
+// GetConfig holds the configuration of the get command
+type GetConfig struct {
+	Command Command
+	Query   string
+	TagOptions
+	Directory   string
+	Paths       DirectoryFilter // Several directories, globs, or exclusions, in addition to Directory
+	FirstParent bool
+	Baseline    BaselineExclusion
+	Authors     AuthorFilter
+	Dates       DateRange
+	Merges      MergeMode
+	Match       CommitMatch
+	Side        string // Tag whose unique commits unique-count counts: "tag1" or "tag2"
+	NoCache     bool
+	Strict      bool          // Fail on missing or corrupt objects instead of printing an approximate value
+	Timeout     time.Duration // Cancel the query after this long; 0 never times out
+}
+
+// NewGetConfig parses the get query and its flags
+func NewGetConfig(args []string) (GetConfig, error) {
+	config := GetConfig{Command: GetCommand}
+
+	if len(args) < 1 {
+		printCommandUsage(os.Stderr, getUsage, nil)
+		return config, ErrMissingGetQuery
+	}
+	usage, ok := lookupCommandUsage([]string{string(GetCommand), args[0]})
+	if !ok {
+		printCommandUsage(os.Stderr, getUsage, nil)
+		return config, errors.Join(ErrUnknownGetQuery, fmt.Errorf("unknown query: %s", args[0]))
+	}
+	config.Query = args[0]
+
+	var match, merges string
+	getCmd := newCommandFlagSet(usage)
+	parseTagOptions := config.TagOptions.registerFlags(getCmd, "name")
+	if config.Query != GetMergeBase {
+		config.Paths.registerFlags(getCmd, "Directory path to filter commits (only commits touching this directory)")
+		getCmd.BoolVar(&config.FirstParent, "first-parent", false, "Count first-parent history only, as compare -first-parent")
+		config.Baseline.registerFlags(getCmd)
+		config.Authors.registerFlags(getCmd)
+		config.Dates.registerFlags(getCmd)
+		getCmd.StringVar(&merges, "merges", string(MergesInclude), "Merge commits (include, exclude, only), as compare -merges")
+		getCmd.StringVar(&match, "match", string(MatchHash), "When a commit counts as shared (hash, patch-id), as compare -match")
+		getCmd.BoolVar(&config.NoCache, "no-cache", false, "Bypass the commit set cache, as compare -no-cache")
+		getCmd.BoolVar(&config.Strict, "strict", false, "Fail on missing or corrupt objects instead of printing an approximate value")
+	}
+	if config.Query == GetUniqueCount {
+		getCmd.StringVar(&config.Side, "side", "tag2", "Tag whose unique commits are counted (tag1, tag2)")
+	}
+	registerTimeoutFlag(getCmd, &config.Timeout, "the query")
+
+	if err := getCmd.Parse(args[1:]); err != nil {
+		return config, err
+	}
+	config.Directory, config.Paths = splitDirectoryFilter(config.Paths)
+
+	if err := parseTagOptions(); err != nil {
+		return config, err
+	}
+
+	if config.Query != GetMergeBase {
+		commitMatch, err := ParseCommitMatch(match)
+		if err != nil {
+			return config, err
+		}
+		config.Match = commitMatch
+
+		if config.Merges, err = ParseMergeMode(merges); err != nil {
+			return config, err
+		}
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *GetConfig) Validate() error {
+	if err := c.TagOptions.Validate(); err != nil {
+		return err
+	}
+
+	if c.Query == GetUniqueCount && c.Side != "tag1" && c.Side != "tag2" {
+		return errors.Join(ErrInvalidSide, fmt.Errorf("unknown side: %s (expected tag1 or tag2)", c.Side))
+	}
+
+	if _, err := cleanDirectory(c.Directory); err != nil {
+		return err
+	}
+	if err := c.Paths.Validate(); err != nil {
+		return err
+	}
+	if err := c.Authors.Validate(); err != nil {
+		return err
+	}
+	if err := c.Dates.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Get computes the value of the configured query, formatted for printing, and the caveats of the run,
+// such as skipped unreadable commits that make the value approximate
+func Get(ctx context.Context, config GetConfig) (_ string, _ []string, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return "", nil, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	if config.Query == GetMergeBase {
+		value, err := getMergeBase(ctx, config)
+		return value, nil, err
+	}
+
+	// The counts and the similarity come from the commit sets of compare, without its extras
+	result, err := CompareContext(ctx, CompareConfig{
+		TagOptions:  config.TagOptions,
+		Directory:   config.Directory,
+		Paths:       config.Paths,
+		FirstParent: config.FirstParent,
+		Baseline:    config.Baseline,
+		Authors:     config.Authors,
+		Dates:       config.Dates,
+		Merges:      config.Merges,
+		Match:       config.Match,
+		NoCache:     config.NoCache,
+		Strict:      config.Strict,
+		SetsOnly:    true,
+	})
+	if err != nil {
+		return "", result.Warnings, err
+	}
+
+	switch config.Query {
+	case GetSimilarity:
+		return strconv.FormatFloat(result.Similarity, 'f', 4, 64), result.Warnings, nil
+	case GetSharedCount:
+		return strconv.Itoa(len(result.SharedCommits)), result.Warnings, nil
+	case GetUniqueCount:
+		if config.Side == "tag1" {
+			return strconv.Itoa(len(result.OnlyInTag1)), result.Warnings, nil
+		}
+		return strconv.Itoa(len(result.OnlyInTag2)), result.Warnings, nil
+	default:
+		return "", nil, errors.Join(ErrUnknownGetQuery, fmt.Errorf("unknown query: %s", config.Query))
+	}
+}
+
+// getMergeBase resolves both tags and returns the hash of their best common ancestor
+func getMergeBase(ctx context.Context, config GetConfig) (string, error) {
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, config.CloneDepth)
+	if err != nil {
+		return "", errors.Join(ErrOpenRepository, err)
+	}
+
+	tag1Ref, tag2Ref, err := config.ResolveTags(ctx, repo)
+	if err != nil {
+		return "", err
+	}
+
+	commit1, err := repo.GetTagCommit(tag1Ref)
+	if err != nil {
+		return "", errors.Join(ErrGetCommits, err)
+	}
+	commit2, err := repo.GetTagCommit(tag2Ref)
+	if err != nil {
+		return "", errors.Join(ErrGetCommits, err)
+	}
+
+	base, err := repo.mergeBase(ctx, commit1.Hash, commit2.Hash)
+	if err != nil {
+		return "", err
+	}
+	if base.IsZero() {
+		return "", errors.Join(ErrNoMergeBase, fmt.Errorf("%s and %s have no common ancestor", config.Tag1Name, config.Tag2Name))
+	}
+	return base.String(), nil
+}
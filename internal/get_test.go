@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestGet tests the single values printed by the get queries
+func TestGet(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Orphan("imported").
+		Commit("Import", testutil.File("README.md", "imported\n")).
+		Tag("imported-1.0")
+
+	tests := []struct {
+		name   string
+		config GetConfig
+		want   string
+	}{
+		{name: "similarity", config: GetConfig{Query: GetSimilarity}, want: "0.3333"},
+		{name: "similarity in directory", config: GetConfig{Query: GetSimilarity, Directory: "internal"}, want: "0.5000"},
+		{name: "shared count", config: GetConfig{Query: GetSharedCount}, want: "1"},
+		{name: "unique count of tag2", config: GetConfig{Query: GetUniqueCount, Side: "tag2"}, want: "2"},
+		{name: "unique count of tag1", config: GetConfig{Query: GetUniqueCount, Side: "tag1"}, want: "0"},
+		{name: "merge base", config: GetConfig{Query: GetMergeBase}, want: fixture.Hash("v1.0.0").String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.TagOptions = TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}
+			got, warnings, err := Get(context.Background(), tt.config)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if got != tt.want || len(warnings) != 0 {
+				t.Errorf("Get() = %q, %v, want %q without warnings", got, warnings, tt.want)
+			}
+		})
+	}
+
+	_, _, err := Get(context.Background(), GetConfig{Query: GetMergeBase, TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "imported-1.0"}})
+	if !errors.Is(err, ErrNoMergeBase) {
+		t.Errorf("Get(merge-base) of unrelated tags error = %v, want ErrNoMergeBase", err)
+	}
+
+	_, _, err = Get(context.Background(), GetConfig{Query: GetUniqueCount, Side: "both", TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}})
+	if !errors.Is(err, ErrInvalidSide) {
+		t.Errorf("Get(unique-count -side both) error = %v, want ErrInvalidSide", err)
+	}
+}
+
+// TestGet_UnreadableCommit tests that an approximate value comes with a warning, and fails in strict mode
+func TestGet_UnreadableCommit(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("first").
+		Tag("v1.0.0").
+		Commit("second")
+	missing := fixture.Hash("HEAD")
+	fixture.Commit("third").Tag("v1.1.0")
+	if err := os.Remove(fixture.ObjectPath(missing)); err != nil {
+		t.Fatalf("failed to remove commit object: %v", err)
+	}
+	config := GetConfig{Query: GetSharedCount, NoCache: true, TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}}
+
+	value, warnings, err := Get(context.Background(), config)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	if value != "0" || len(warnings) == 0 || !strings.Contains(warnings[0], "approximate") {
+		t.Errorf("Get() = %q, %v, want 0 with a warning that it is approximate", value, warnings)
+	}
+
+	config.Strict = true
+	if _, _, err := Get(context.Background(), config); err == nil {
+		t.Errorf("Get() with -strict expected an error for the unreadable commit")
+	}
+}
+
+// TestNewGetConfig tests parsing get queries and their flags
+func TestNewGetConfig(t *testing.T) {
+	config, err := NewGetConfig([]string{"unique-count", "-repo", ".", "-tag1", "v1.0.0", "-tag2", "main", "-side", "tag1"})
+	if err != nil {
+		t.Fatalf("NewGetConfig() error = %v", err)
+	}
+	if config.Query != GetUniqueCount || config.Side != "tag1" || config.Match != MatchHash {
+		t.Errorf("NewGetConfig() = %+v", config)
+	}
+
+	if _, err := NewGetConfig([]string{"commits"}); !errors.Is(err, ErrUnknownGetQuery) {
+		t.Errorf("NewGetConfig(commits) error = %v, want ErrUnknownGetQuery", err)
+	}
+	if _, err := NewGetConfig(nil); !errors.Is(err, ErrMissingGetQuery) {
+		t.Errorf("NewGetConfig() error = %v, want ErrMissingGetQuery", err)
+	}
+}
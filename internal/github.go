@@ -0,0 +1,444 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrInvalidGitHubRepo = errors.New("invalid GitHub repository")
+	ErrGitHubConflict    = errors.New("option not supported with -github")
+	ErrGitHubAPI         = errors.New("GitHub API request failed")
+)
+
+const (
+	// GitHubTokenEnv names the environment variable holding the token sent to the GitHub API
+	GitHubTokenEnv = "GITHUB_TOKEN"
+	// GitHubAPIURLEnv names the environment variable overriding the API URL, e.g. for GitHub Enterprise Server
+	GitHubAPIURLEnv = "GITHUB_API_URL"
+
+	defaultGitHubAPIURL = "https://api.github.com"
+
+	// githubPageSize is the largest page the list endpoints return
+	githubPageSize = 100
+)
+
+// githubRepoPattern matches an owner/repo pair
+var githubRepoPattern = regexp.MustCompile(`^[A-Za-z0-9-]+/[A-Za-z0-9._-]+$`)
+
+// githubLastPagePattern extracts the page number of the rel="last" link of a Link header
+var githubLastPagePattern = regexp.MustCompile(`[?&]page=(\d+)[^>]*>;\s*rel="last"`)
+
+// GitHubComparison is the result of comparing two tags through the GitHub API. Only commit counts are
+// fetched, plus the unique commits in verbose mode, so the shared commits are not listed.
+type GitHubComparison struct {
+	Config         CompareConfig
+	Tag1Commit     string
+	Tag2Commit     string
+	MergeBase      string
+	SharedCount    int
+	OnlyInTag1     int
+	OnlyInTag2     int
+	Similarity     float64
+	Interpretation string
+
+	// Commits unique to each tag, newest first; loaded only in verbose mode
+	OnlyInTag1Commits []CommitInfo
+	OnlyInTag2Commits []CommitInfo
+}
+
+// githubClient calls the REST API for one repository
+type githubClient struct {
+	http    *http.Client
+	baseURL string
+	token   string
+	repo    string // owner/repo
+}
+
+// newGitHubClient creates a client for a repository, authenticated with GitHubTokenEnv if it is set
+func newGitHubClient(repo string, options HTTPOptions) *githubClient {
+	baseURL := os.Getenv(GitHubAPIURLEnv)
+	if baseURL == "" {
+		baseURL = defaultGitHubAPIURL
+	}
+	return &githubClient{
+		http:    options.Client(),
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		token:   os.Getenv(GitHubTokenEnv),
+		repo:    repo,
+	}
+}
+
+// get decodes the JSON response of a GET request on a repository path into v and returns the response headers
+func (c *githubClient) get(ctx context.Context, path string, query url.Values, v any) (http.Header, error) {
+	endpoint := c.baseURL + "/repos/" + c.repo + path
+	if len(query) > 0 {
+		endpoint += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, errors.Join(ErrGitHubAPI, err)
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	if c.token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, errors.Join(ErrGitHubAPI, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, errors.Join(ErrGitHubAPI, githubError(resp))
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, errors.Join(ErrGitHubAPI, fmt.Errorf("GET %s: %w", path, err))
+	}
+	return resp.Header, nil
+}
+
+// githubError describes a failed response, pointing at GitHubTokenEnv when the rate limit was hit
+func githubError(resp *http.Response) error {
+	var body struct {
+		Message string `json:"message"`
+	}
+	data, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	_ = json.Unmarshal(data, &body)
+	message := fmt.Sprintf("%s %s: %s", resp.Request.Method, resp.Request.URL.Path, resp.Status)
+	if body.Message != "" {
+		message += ": " + body.Message
+	}
+
+	if (resp.StatusCode == http.StatusForbidden || resp.StatusCode == http.StatusTooManyRequests) && resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			message += fmt.Sprintf(" (rate limit resets at %s)", time.Unix(reset, 0).Format(time.Kitchen))
+		}
+		if os.Getenv(GitHubTokenEnv) == "" {
+			message += "; set " + GitHubTokenEnv + " for a higher limit"
+		}
+	}
+	return errors.New(message)
+}
+
+// githubTag is an entry of the tags endpoint
+type githubTag struct {
+	Name   string `json:"name"`
+	Commit struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+// tags lists the tags of the repository with the commit each one points to
+func (c *githubClient) tags(ctx context.Context) ([]githubTag, error) {
+	var all []githubTag
+	for page := 1; ; page++ {
+		var tags []githubTag
+		query := url.Values{"per_page": {strconv.Itoa(githubPageSize)}, "page": {strconv.Itoa(page)}}
+		if _, err := c.get(ctx, "/tags", query, &tags); err != nil {
+			return nil, err
+		}
+		all = append(all, tags...)
+		if len(tags) < githubPageSize {
+			return all, nil
+		}
+	}
+}
+
+// githubCommit is an entry of the commit and compare endpoints
+type githubCommit struct {
+	SHA    string `json:"sha"`
+	Commit struct {
+		Author struct {
+			Name  string    `json:"name"`
+			Email string    `json:"email"`
+			Date  time.Time `json:"date"`
+		} `json:"author"`
+		Message string `json:"message"`
+	} `json:"commit"`
+}
+
+// commitInfo converts the commit for the commit lists
+func (c githubCommit) commitInfo() CommitInfo {
+	return CommitInfo{
+		Hash:    c.SHA,
+		Author:  c.Commit.Author.Name,
+		Email:   c.Commit.Author.Email,
+		Date:    c.Commit.Author.Date,
+		Subject: strings.Split(c.Commit.Message, "\n")[0],
+	}
+}
+
+// resolveCommit returns the commit a branch, commit hash, or revision selects
+func (c *githubClient) resolveCommit(ctx context.Context, ref string) (string, error) {
+	var commit githubCommit
+	// Slashes of branch names stay unescaped, as the endpoint expects
+	if _, err := c.get(ctx, "/commits/"+strings.ReplaceAll(url.PathEscape(ref), "%2F", "/"), nil, &commit); err != nil {
+		return "", err
+	}
+	return commit.SHA, nil
+}
+
+// commitCount returns the number of commits reachable from a commit. The commit list is requested one
+// commit per page, so the number of the last page in the Link header is the count.
+func (c *githubClient) commitCount(ctx context.Context, sha string) (int, error) {
+	var commits []githubCommit
+	header, err := c.get(ctx, "/commits", url.Values{"sha": {sha}, "per_page": {"1"}}, &commits)
+	if err != nil {
+		return 0, err
+	}
+	if match := githubLastPagePattern.FindStringSubmatch(header.Get("Link")); match != nil {
+		return strconv.Atoi(match[1])
+	}
+	return len(commits), nil
+}
+
+// githubCompare is the response of the compare endpoint
+type githubCompare struct {
+	AheadBy         int            `json:"ahead_by"`
+	BehindBy        int            `json:"behind_by"`
+	TotalCommits    int            `json:"total_commits"`
+	MergeBaseCommit githubCommit   `json:"merge_base_commit"`
+	Commits         []githubCommit `json:"commits"`
+}
+
+// compare compares base with head. The commits only in head are listed on every page, so only the
+// first page is requested unless listCommits is set.
+func (c *githubClient) compare(ctx context.Context, base string, head string, listCommits bool) (githubCompare, error) {
+	var result githubCompare
+	for page := 1; ; page++ {
+		var response githubCompare
+		query := url.Values{"per_page": {strconv.Itoa(githubPageSize)}, "page": {strconv.Itoa(page)}}
+		if _, err := c.get(ctx, "/compare/"+base+"..."+head, query, &response); err != nil {
+			return result, err
+		}
+		if page == 1 {
+			result = response
+			result.Commits = nil
+		}
+		result.Commits = append(result.Commits, response.Commits...)
+		if !listCommits || len(response.Commits) < githubPageSize || len(result.Commits) >= result.TotalCommits {
+			return result, nil
+		}
+	}
+}
+
+// resolveTags returns the commits of the two tags. Tags win over branches of the same name, as in git;
+// latest and latest-N select tags by semantic version.
+func (c *githubClient) resolveTags(ctx context.Context, config CompareConfig) (string, string, error) {
+	tags, err := c.tags(ctx)
+	if err != nil {
+		return "", "", errors.Join(ErrFetchTags, err)
+	}
+	commits := make(map[string]string, len(tags))
+	refs := make([]*plumbing.Reference, 0, len(tags))
+	for _, tag := range tags {
+		commits[tag.Name] = tag.Commit.SHA
+		refs = append(refs, plumbing.NewHashReference(plumbing.NewTagReferenceName(tag.Name), plumbing.ZeroHash))
+	}
+
+	resolve := func(name string) (string, error) {
+		namespace, version := splitTagNamespace(name)
+		offset, ok, err := parseTagOffset(version)
+		if err != nil {
+			return "", err
+		}
+		if candidates := namespaceTags(refs, namespace); ok && (namespace == "" || len(candidates) > 0) {
+			sorted := sortTagsBySemver(candidates, namespace)
+			if offset >= len(sorted) {
+				return "", errors.Join(ErrInvalidTagOffset, fmt.Errorf("'%s' is out of range: only %d tags available", name, len(sorted)))
+			}
+			name = sorted[offset].Name().Short()
+		}
+		if sha, ok := commits[name]; ok {
+			return sha, nil
+		}
+		return c.resolveCommit(ctx, name)
+	}
+
+	tag1, err := resolve(config.Tag1Name)
+	if err != nil {
+		return "", "", errors.Join(ErrTag1NotFound, err)
+	}
+	tag2, err := resolve(config.Tag2Name)
+	if err != nil {
+		return "", "", errors.Join(ErrTag2NotFound, err)
+	}
+	return tag1, tag2, nil
+}
+
+// validateGitHub checks a compare configuration for -github, which has no local repository to open,
+// filter, or diff
+func (c *CompareConfig) validateGitHub() error {
+	if !githubRepoPattern.MatchString(c.GitHub) {
+		return errors.Join(ErrInvalidGitHubRepo, fmt.Errorf("expected owner/repo: %s", c.GitHub))
+	}
+	if c.RepoPath != "" {
+		return errors.Join(ErrGitHubConflict, fmt.Errorf("-github and -repo cannot be combined"))
+	}
+	if c.Tag1Name == "" {
+		return ErrMissingTag1
+	}
+	if c.Tag2Name == "" {
+		return ErrMissingTag2
+	}
+	if c.SortBy == SortByDate {
+		return errors.Join(ErrGitHubConflict, fmt.Errorf("-sort date: latest-N selects tags by semantic version with -github"))
+	}
+
+	unsupported := []struct {
+		flag string
+		set  bool
+	}{
+		{"-clone-depth", c.CloneDepth > 0},
+		{"-d", c.Directory != "" || !c.Paths.IsZero()},
+		{"-profile", c.Profile != ProfileNone},
+		{"-first-parent", c.FirstParent},
+		{"-exclude-reachable-from", !c.Baseline.IsZero()},
+		{"-author", !c.Authors.IsZero()},
+		{"-since", !c.Dates.IsZero()},
+		{"-merges", !c.Merges.IsZero()},
+		{"-match", c.Match != "" && c.Match != MatchHash},
+		{"-metric", c.Metric != "" && c.Metric != MetricCommits},
+		{"-group-by-pr", c.GroupByPR},
+		{"-test-ratio", c.TestRatio},
+		{"-risk", c.Risk},
+		{"-depth", c.Depth > 0},
+		{"-file-matrix", c.FileMatrixPath != ""},
+		{"-json", c.JSONPath != ""},
+		{"-report", c.ReportPath != ""},
+		{"-policy", c.PolicyName != ""},
+		{"-checksums", c.ChecksumsPath != ""},
+		{"-attestation", c.AttestationPath != ""},
+		{"-pushgateway", c.PushgatewayURL != ""},
+		{"-format", c.Format == CompareFormatNDJSONCommits},
+	}
+	for _, option := range unsupported {
+		if option.set {
+			return errors.Join(ErrGitHubConflict, fmt.Errorf("%s needs a local repository; clone it and use -repo", option.flag))
+		}
+	}
+	return nil
+}
+
+// CompareGitHub compares two tags of a GitHub repository through the REST API, without a local clone.
+// The compare endpoint counts the commits only in either tag, and the commit count of the second tag
+// gives the shared commits, so the similarity equals that of compare with hash matching.
+// When ctx is done, the pending request is aborted and ErrCanceled is returned as by CompareContext.
+func CompareGitHub(ctx context.Context, config CompareConfig) (GitHubComparison, error) {
+	result, err := compareGitHub(ctx, config)
+	if err != nil && ctx.Err() != nil {
+		return result, errors.Join(ErrCanceled, ctx.Err())
+	}
+	return result, err
+}
+
+func compareGitHub(ctx context.Context, config CompareConfig) (GitHubComparison, error) {
+	result := GitHubComparison{Config: config}
+	if err := config.validateGitHub(); err != nil {
+		return result, errors.Join(ErrInvalidConfiguration, err)
+	}
+	if err := config.HTTP.Validate(); err != nil {
+		return result, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	// Score bands come from -config; there is no repository root to find the project config in
+	project := ProjectConfig{}
+	if config.ConfigPath != "" {
+		var err error
+		if project, err = LoadProjectConfig(config.ConfigPath, ""); err != nil {
+			return result, errors.Join(ErrInvalidConfiguration, err)
+		}
+	}
+
+	client := newGitHubClient(config.GitHub, config.HTTP)
+	var err error
+	if result.Tag1Commit, result.Tag2Commit, err = client.resolveTags(ctx, config); err != nil {
+		return result, err
+	}
+
+	compared, err := client.compare(ctx, result.Tag1Commit, result.Tag2Commit, config.Verbose)
+	if err != nil {
+		return result, err
+	}
+	total2, err := client.commitCount(ctx, result.Tag2Commit)
+	if err != nil {
+		return result, err
+	}
+
+	result.MergeBase = compared.MergeBaseCommit.SHA
+	result.OnlyInTag1, result.OnlyInTag2 = compared.BehindBy, compared.AheadBy
+	result.SharedCount = total2 - compared.AheadBy
+	if union := result.SharedCount + result.OnlyInTag1 + result.OnlyInTag2; union > 0 {
+		result.Similarity = float64(result.SharedCount) / float64(union)
+	}
+	result.Interpretation = InterpretSimilarity(project.scoreBands(), result.Similarity)
+
+	if config.Verbose {
+		for _, commit := range compared.Commits {
+			result.OnlyInTag2Commits = append(result.OnlyInTag2Commits, commit.commitInfo())
+		}
+		if result.OnlyInTag1 > 0 {
+			reverse, err := client.compare(ctx, result.Tag2Commit, result.Tag1Commit, true)
+			if err != nil {
+				return result, err
+			}
+			for _, commit := range reverse.Commits {
+				result.OnlyInTag1Commits = append(result.OnlyInTag1Commits, commit.commitInfo())
+			}
+		}
+		sortCommitInfos(result.OnlyInTag1Commits)
+		sortCommitInfos(result.OnlyInTag2Commits)
+	}
+	return result, nil
+}
+
+// CheckMinSimilarity returns ErrBelowMinSimilarity when the similarity is below the configured MinSimilarity
+func (r GitHubComparison) CheckMinSimilarity() error {
+	return CompareResult{Config: r.Config, Similarity: r.Similarity}.CheckMinSimilarity()
+}
+
+// PrintGitHubComparison prints the comparison like compare prints a local one
+func PrintGitHubComparison(w io.Writer, result GitHubComparison) {
+	config := result.Config
+	_, _ = fmt.Fprintf(w, "Comparing tags: %s vs %s\n", config.Tag1Name, config.Tag2Name)
+	_, _ = fmt.Fprintf(w, "Repository: %s (GitHub API)\n", config.GitHub)
+	_, _ = fmt.Fprintf(w, "Similarity: %.2f%%\n", result.Similarity*100.0)
+	if config.MinSimilarity > 0 {
+		status := "passed"
+		if result.CheckMinSimilarity() != nil {
+			status = "FAILED"
+		}
+		_, _ = fmt.Fprintf(w, "Minimum similarity: %.2f%% (%s)\n", config.MinSimilarity*100.0, status)
+	}
+	if result.Interpretation != "" {
+		_, _ = fmt.Fprintf(w, "Interpretation: %s\n", result.Interpretation)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nSummary:\n")
+	_, _ = fmt.Fprintf(w, "  Total commits in [%s]: %d\n", config.Tag1Name, result.SharedCount+result.OnlyInTag1)
+	_, _ = fmt.Fprintf(w, "  Total commits in [%s]: %d\n", config.Tag2Name, result.SharedCount+result.OnlyInTag2)
+	_, _ = fmt.Fprintf(w, "  Shared commits: %d\n", result.SharedCount)
+	_, _ = fmt.Fprintf(w, "  Unique to [%s]: %d\n", config.Tag1Name, result.OnlyInTag1)
+	_, _ = fmt.Fprintf(w, "  Unique to [%s]: %d\n", config.Tag2Name, result.OnlyInTag2)
+	if result.MergeBase != "" {
+		_, _ = fmt.Fprintf(w, "  Merge base: %s\n", shortHash(result.MergeBase))
+	}
+
+	printCommitInfos(w, fmt.Sprintf("Commits only in [%s]", config.Tag1Name), result.OnlyInTag1Commits, config.Output)
+	printCommitInfos(w, fmt.Sprintf("Commits only in [%s]", config.Tag2Name), result.OnlyInTag2Commits, config.Output)
+}
@@ -0,0 +1,198 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// newGitHubServer serves the tags, commits, and compare endpoints of octo/app, whose v1.0.0 has 10
+// commits and v1.1.0 12, 8 of them shared. It records the Authorization header of the last request.
+func newGitHubServer(t *testing.T) *string {
+	t.Helper()
+	var authorization string
+	commit := func(sha string) map[string]any {
+		return map[string]any{"sha": sha, "commit": map[string]any{
+			"message": "Change " + sha + "\n\nBody",
+			"author":  map[string]any{"name": "Alice", "email": "alice@example.com", "date": "2025-01-1" + sha[len(sha)-1:] + "T00:00:00Z"},
+		}}
+	}
+	counts := map[string]int{"aaa1": 10, "bbb2": 12}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/repos/octo/app/tags", func(w http.ResponseWriter, r *http.Request) {
+		tags := []map[string]any{
+			{"name": "v1.0.0", "commit": map[string]any{"sha": "aaa1"}},
+			{"name": "v1.1.0", "commit": map[string]any{"sha": "bbb2"}},
+			{"name": "nightly", "commit": map[string]any{"sha": "ccc3"}},
+		}
+		if r.URL.Query().Get("page") != "1" {
+			tags = nil
+		}
+		_ = json.NewEncoder(w).Encode(tags)
+	})
+	mux.HandleFunc("/repos/octo/app/commits/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/repos/octo/app/commits/release/1.0" {
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = fmt.Fprint(w, `{"message": "No commit found for SHA"}`)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(commit("aaa1"))
+	})
+	mux.HandleFunc("/repos/octo/app/commits", func(w http.ResponseWriter, r *http.Request) {
+		count := counts[r.URL.Query().Get("sha")]
+		w.Header().Set("Link", fmt.Sprintf(`<https://api.github.com/repositories/1/commits?sha=x&per_page=1&page=2>; rel="next", <https://api.github.com/repositories/1/commits?sha=x&per_page=1&page=%d>; rel="last"`, count))
+		_ = json.NewEncoder(w).Encode([]any{commit("aaa1")})
+	})
+	mux.HandleFunc("/repos/octo/app/compare/", func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		response := map[string]any{"merge_base_commit": commit("mmm0")}
+		switch strings.TrimPrefix(r.URL.Path, "/repos/octo/app/compare/") {
+		case "aaa1...bbb2":
+			response["ahead_by"], response["behind_by"], response["total_commits"] = 4, 2, 4
+			response["commits"] = []any{commit("n1"), commit("n2"), commit("n3"), commit("n4")}
+		case "bbb2...aaa1":
+			response["ahead_by"], response["behind_by"], response["total_commits"] = 2, 4, 2
+			response["commits"] = []any{commit("o5"), commit("o6")}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(response)
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	t.Setenv(GitHubAPIURLEnv, server.URL)
+	return &authorization
+}
+
+// TestCompareGitHub tests computing the similarity from the compare endpoint and the commit count of the second tag
+func TestCompareGitHub(t *testing.T) {
+	authorization := newGitHubServer(t)
+	t.Setenv(GitHubTokenEnv, "secret")
+
+	tests := []struct {
+		name    string
+		tag1    string
+		tag2    string
+		verbose bool
+		wantErr error
+	}{
+		{name: "tags", tag1: "v1.0.0", tag2: "v1.1.0"},
+		{name: "latest-N by semantic version", tag1: "latest-1", tag2: "latest"},
+		{name: "branch resolved through the commits endpoint", tag1: "release/1.0", tag2: "v1.1.0", verbose: true},
+		{name: "unknown ref", tag1: "v0.9.0", tag2: "v1.1.0", wantErr: ErrTag1NotFound},
+		{name: "offset out of range", tag1: "v1.0.0", tag2: "latest-2", wantErr: ErrInvalidTagOffset},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := CompareGitHub(context.Background(), CompareConfig{
+				TagOptions: TagOptions{Tag1Name: tt.tag1, Tag2Name: tt.tag2, SortBy: SortBySemver},
+				GitHub:     "octo/app",
+				Verbose:    tt.verbose,
+			})
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("CompareGitHub() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("CompareGitHub() error = %v", err)
+			}
+
+			if result.SharedCount != 8 || result.OnlyInTag1 != 2 || result.OnlyInTag2 != 4 || result.Similarity != 8.0/14 {
+				t.Errorf("CompareGitHub() = %d shared, %d and %d unique, %v, want 8, 2, 4, 8/14", result.SharedCount, result.OnlyInTag1, result.OnlyInTag2, result.Similarity)
+			}
+			if result.MergeBase != "mmm0" || result.Interpretation == "" {
+				t.Errorf("CompareGitHub() merge base %q, interpretation %q", result.MergeBase, result.Interpretation)
+			}
+			if *authorization != "Bearer secret" {
+				t.Errorf("Authorization = %q, want the token from %s", *authorization, GitHubTokenEnv)
+			}
+
+			wantLists := 0
+			if tt.verbose {
+				wantLists = 2
+			}
+			if len(result.OnlyInTag1Commits) != wantLists || len(result.OnlyInTag2Commits) != 2*wantLists {
+				t.Errorf("CompareGitHub() listed %d and %d unique commits", len(result.OnlyInTag1Commits), len(result.OnlyInTag2Commits))
+			}
+			if tt.verbose && (result.OnlyInTag2Commits[0].Hash != "n4" || result.OnlyInTag2Commits[0].Subject != "Change n4") {
+				t.Errorf("first unique commit = %+v, want the newest, n4", result.OnlyInTag2Commits[0])
+			}
+		})
+	}
+}
+
+// TestCompareGitHub_RateLimit tests that an exhausted rate limit points at the token
+func TestCompareGitHub_RateLimit(t *testing.T) {
+	t.Setenv(GitHubTokenEnv, "")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(1700000000))
+		w.WriteHeader(http.StatusForbidden)
+		_, _ = fmt.Fprint(w, `{"message": "API rate limit exceeded"}`)
+	}))
+	defer server.Close()
+	t.Setenv(GitHubAPIURLEnv, server.URL)
+
+	_, err := CompareGitHub(context.Background(), CompareConfig{TagOptions: TagOptions{Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}, GitHub: "octo/app"})
+	if !errors.Is(err, ErrGitHubAPI) || !strings.Contains(err.Error(), "API rate limit exceeded") || !strings.Contains(err.Error(), GitHubTokenEnv) {
+		t.Errorf("CompareGitHub() error = %v, want the rate limit and a hint to set %s", err, GitHubTokenEnv)
+	}
+}
+
+// TestCompareGitHub_Canceled tests that a done context aborts the requests
+func TestCompareGitHub_Canceled(t *testing.T) {
+	newGitHubServer(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := CompareGitHub(ctx, CompareConfig{TagOptions: TagOptions{Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}, GitHub: "octo/app"})
+	if !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+		t.Errorf("CompareGitHub() error = %v, want %v and %v", err, ErrCanceled, context.Canceled)
+	}
+}
+
+// TestCompareConfig_ValidateGitHub tests rejecting options that need a local repository
+func TestCompareConfig_ValidateGitHub(t *testing.T) {
+	valid := func() CompareConfig {
+		return CompareConfig{TagOptions: TagOptions{Tag1Name: "v1.0.0", Tag2Name: "v1.1.0", SortBy: SortBySemver}, GitHub: "octo/app.js"}
+	}
+	tests := []struct {
+		name    string
+		modify  func(*CompareConfig)
+		wantErr error
+	}{
+		{name: "valid", modify: func(*CompareConfig) {}},
+		{name: "matching by hash", modify: func(c *CompareConfig) { c.Match = MatchHash; c.Metric = MetricCommits }},
+		{name: "missing owner", modify: func(c *CompareConfig) { c.GitHub = "app" }, wantErr: ErrInvalidGitHubRepo},
+		{name: "URL instead of owner/repo", modify: func(c *CompareConfig) { c.GitHub = "https://github.com/octo/app" }, wantErr: ErrInvalidGitHubRepo},
+		{name: "with -repo", modify: func(c *CompareConfig) { c.RepoPath = "." }, wantErr: ErrGitHubConflict},
+		{name: "missing tag", modify: func(c *CompareConfig) { c.Tag2Name = "" }, wantErr: ErrMissingTag2},
+		{name: "date sort", modify: func(c *CompareConfig) { c.SortBy = SortByDate }, wantErr: ErrGitHubConflict},
+		{name: "directory filter", modify: func(c *CompareConfig) { c.Directory = "src" }, wantErr: ErrGitHubConflict},
+		{name: "patch ID matching", modify: func(c *CompareConfig) { c.Match = MatchPatchID }, wantErr: ErrGitHubConflict},
+		{name: "saved result", modify: func(c *CompareConfig) { c.JSONPath = "result.json" }, wantErr: ErrGitHubConflict},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := valid()
+			tt.modify(&config)
+			err := config.validateGitHub()
+			if (tt.wantErr == nil && err != nil) || (tt.wantErr != nil && !errors.Is(err, tt.wantErr)) {
+				t.Errorf("validateGitHub() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
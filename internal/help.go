@@ -1,23 +1,217 @@
 package internal
 
 import (
+	"errors"
+	"flag"
 	"fmt"
+	"io"
 	"os"
+	"strings"
 )
 
+// commandUsage is the help text of a command; each command declares its own next to its flags
+type commandUsage struct {
+	Name        string         // Command name, including the parent for subcommands (e.g. "report diff")
+	Synopsis    string         // Arguments after the name (default: "[options]" for commands with options)
+	Summary     string         // One line shown in the command list
+	Description string         // Paragraph shown above the options
+	Examples    []string       // Invocations without the program name
+	Subcommands []commandUsage // Subcommands listed below the description
+}
+
+// commands lists the usage of every command in the order of the main usage message
+var commands = []commandUsage{
+	compareUsage,
+	diffUsage,
+	reportUsage,
+	checkUsage,
+	verifyUsage,
+	historyUsage,
+	explainUsage,
+	patchesUsage,
+	snapshotUsage,
+	matrixUsage,
+	getUsage,
+	suggestUsage,
+	rcAuditUsage,
+	indexUsage,
+	cacheUsage,
+	selftestUsage,
+	tuiUsage,
+	helpUsage,
+	versionUsage,
+}
+
+var helpUsage = commandUsage{
+	Name:        "help",
+	Synopsis:    "[<command> [<subcommand>]]",
+	Summary:     "Show this help message, or the options and examples of a command",
+	Description: "Show the list of commands, or the usage, options, and examples of a single command.",
+	Examples:    []string{"help", "help compare", "help report diff"},
+}
+
+// lookupCommandUsage finds the usage of a command or subcommand by its words, e.g. ["report", "diff"]
+func lookupCommandUsage(words []string) (commandUsage, bool) {
+	usages := commands
+	name := strings.Join(words, " ")
+	for len(usages) > 0 {
+		var next []commandUsage
+		for _, usage := range usages {
+			if usage.Name == name {
+				return usage, true
+			}
+			if strings.HasPrefix(name, usage.Name+" ") {
+				next = usage.Subcommands
+			}
+		}
+		usages = next
+	}
+	return commandUsage{}, false
+}
+
+// newCommandFlagSet creates the flag set of a command whose -h output is the command's usage
+func newCommandFlagSet(usage commandUsage) *flag.FlagSet {
+	flags := flag.NewFlagSet(usage.Name, flag.ExitOnError)
+	flags.Usage = func() {
+		printCommandUsage(flags.Output(), usage, flags)
+	}
+	return flags
+}
+
+// printCommandUsage prints the synopsis, description, subcommands, options, and examples of a command.
+// flags is nil for commands without options.
+func printCommandUsage(w io.Writer, usage commandUsage, flags *flag.FlagSet) {
+	synopsis := usage.Synopsis
+	if synopsis == "" && flags != nil {
+		synopsis = "[options]"
+	}
+	_, _ = fmt.Fprintf(w, "Usage: %s\n\n", strings.TrimSpace("git-tag-similarity "+usage.Name+" "+synopsis))
+	_, _ = fmt.Fprintf(w, "%s\n", usage.Description)
+
+	if len(usage.Subcommands) > 0 {
+		_, _ = fmt.Fprintf(w, "\nSubcommands:\n")
+		// Align the summaries two spaces after the longest subcommand name, and at least at column 10
+		width := 6
+		for _, subcommand := range usage.Subcommands {
+			width = max(width, len(strings.TrimPrefix(subcommand.Name, usage.Name+" ")))
+		}
+		for _, subcommand := range usage.Subcommands {
+			_, _ = fmt.Fprintf(w, "  %-*s%s\n", width+2, strings.TrimPrefix(subcommand.Name, usage.Name+" "), subcommand.Summary)
+		}
+	}
+
+	if flags != nil {
+		_, _ = fmt.Fprintf(w, "\nOptions:\n")
+		flags.SetOutput(w)
+		flags.PrintDefaults()
+	}
+
+	_, _ = fmt.Fprintf(w, "\nExamples:\n")
+	for _, example := range usage.Examples {
+		_, _ = fmt.Fprintf(w, "  git-tag-similarity %s\n", example)
+	}
+
+	switch len(usage.Subcommands) {
+	case 0:
+	case 1:
+		subcommand := usage.Subcommands[0]
+		_, _ = fmt.Fprintf(w, "\nUse 'git-tag-similarity help %s' for the options of the %s subcommand.\n", subcommand.Name, strings.TrimPrefix(subcommand.Name, usage.Name+" "))
+	default:
+		_, _ = fmt.Fprintf(w, "\nUse 'git-tag-similarity help %s <subcommand>' for the options of a subcommand.\n", usage.Name)
+	}
+}
+
 // PrintUsage prints the main usage information
 func PrintUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: git-tag-similarity <command> [options]\n\n")
-	fmt.Fprintf(os.Stderr, "A tool to compare two Git tags and calculate their similarity based on commit history.\n\n")
-	fmt.Fprintf(os.Stderr, "Commands:\n")
-	fmt.Fprintf(os.Stderr, "  compare    Compare two Git tags\n")
-	fmt.Fprintf(os.Stderr, "  help       Show this help message\n")
-	fmt.Fprintf(os.Stderr, "  version    Show version information\n")
-	fmt.Fprintf(os.Stderr, "\nExamples:\n")
-	fmt.Fprintf(os.Stderr, "  git-tag-similarity compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0\n")
-	fmt.Fprintf(os.Stderr, "  git-tag-similarity compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -v\n")
-	fmt.Fprintf(os.Stderr, "  git-tag-similarity help\n")
-	fmt.Fprintf(os.Stderr, "  git-tag-similarity version\n")
-	fmt.Fprintf(os.Stderr, "\nFor more information on a command, use:\n")
-	fmt.Fprintf(os.Stderr, "  git-tag-similarity <command> -h\n")
+	writeUsage(os.Stderr)
+}
+
+// writeUsage prints the command list and the first example of every command and subcommand
+func writeUsage(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Usage: git-tag-similarity <command> [options]\n\n")
+	_, _ = fmt.Fprintf(w, "A tool to compare two Git tags and calculate their similarity based on commit history.\n\n")
+	_, _ = fmt.Fprintf(w, "Commands:\n")
+	for _, usage := range commands {
+		_, _ = fmt.Fprintf(w, "  %-14s%s\n", usage.Name, usage.Summary)
+	}
+
+	_, _ = fmt.Fprintf(w, "\nExamples:\n")
+	printed := make(map[string]struct{})
+	for _, usage := range commands {
+		for _, usage := range append([]commandUsage{usage}, usage.Subcommands...) {
+			// Skip the preparatory steps of examples, such as the compare run a report is generated from
+			for _, example := range usage.Examples {
+				if example == usage.Name || strings.HasPrefix(example, usage.Name+" ") {
+					if _, ok := printed[example]; !ok {
+						printed[example] = struct{}{}
+						_, _ = fmt.Fprintf(w, "  git-tag-similarity %s\n", example)
+					}
+					break
+				}
+			}
+		}
+	}
+
+	_, _ = fmt.Fprintf(w, "\nFor the options and examples of a command, use:\n")
+	_, _ = fmt.Fprintf(w, "  git-tag-similarity help <command>\n")
+	_, _ = fmt.Fprintf(w, "  git-tag-similarity <command> -h\n")
+}
+
+// PrintCommandHelp prints the usage of the command named by args, e.g. ["report", "diff"].
+// Commands with options print them through their own flag parser, which exits after printing
+// the same help as '<command> -h'.
+func PrintCommandHelp(args []string) error {
+	usage, ok := lookupCommandUsage(args)
+	if !ok {
+		return errors.Join(ErrInvalidCommand, fmt.Errorf("unknown command: %s", strings.Join(args, " ")))
+	}
+
+	help := []string{"-h"}
+	if query, ok := strings.CutPrefix(usage.Name, string(GetCommand)+" "); ok {
+		_, err := NewGetConfig(append([]string{query}, help...))
+		return err
+	}
+
+	var err error
+	switch usage.Name {
+	case string(CompareCommand):
+		_, err = NewCompareConfig(help)
+	case string(DiffCommand):
+		_, err = NewDiffConfig(help)
+	case string(ReportCommand):
+		_, err = NewReportConfig(help)
+	case string(ReportCommand) + " " + ReportDiffSubcommand:
+		_, err = NewReportDiffConfig(help)
+	case string(CheckCommand):
+		_, err = NewCheckConfig(help)
+	case string(VerifyCommand):
+		_, err = NewVerifyConfig(help)
+	case string(HistoryCommand) + " " + HistoryDiffSubcommand:
+		_, err = NewHistoryConfig(append([]string{HistoryDiffSubcommand}, help...))
+	case string(HistoryCommand) + " " + HistoryExportSubcommand:
+		_, err = NewHistoryConfig(append([]string{HistoryExportSubcommand}, help...))
+	case string(ExplainZeroCommand):
+		_, err = NewExplainConfig(help)
+	case string(PatchesCommand):
+		_, err = NewPatchesConfig(help)
+	case string(SnapshotCommand):
+		_, err = NewSnapshotConfig(help)
+	case string(MatrixCommand):
+		_, err = NewMatrixConfig(help)
+	case string(SuggestCommand):
+		_, err = NewSuggestConfig(help)
+	case string(RCAuditCommand):
+		_, err = NewRCAuditConfig(help)
+	case string(IndexCommand):
+		_, err = NewIndexConfig(help)
+	case string(CacheCommand) + " " + CacheClearSubcommand:
+		_, err = NewCacheConfig(append([]string{CacheClearSubcommand}, help...))
+	case string(SelftestCommand):
+		_, err = NewSelftestConfig(help)
+	case string(TUICommand):
+		_, err = NewTUIConfig(help)
+	default:
+		printCommandUsage(os.Stderr, usage, nil)
+	}
+	return err
 }
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// TestCommandUsages tests that every command the parser accepts has help with examples
+func TestCommandUsages(t *testing.T) {
+	for _, usage := range commands {
+		t.Run(usage.Name, func(t *testing.T) {
+			if _, err := ParseCommand([]string{usage.Name}); err != nil {
+				t.Errorf("ParseCommand(%q) error = %v", usage.Name, err)
+			}
+			for _, usage := range append([]commandUsage{usage}, usage.Subcommands...) {
+				if usage.Summary == "" || usage.Description == "" || len(usage.Examples) == 0 {
+					t.Errorf("usage of %q is incomplete: %+v", usage.Name, usage)
+				}
+				if found, ok := lookupCommandUsage(strings.Fields(usage.Name)); !ok || found.Name != usage.Name {
+					t.Errorf("lookupCommandUsage(%q) = %q, %v", usage.Name, found.Name, ok)
+				}
+			}
+		})
+	}
+
+	var buf bytes.Buffer
+	writeUsage(&buf)
+	for _, command := range []Command{CompareCommand, DiffCommand, ReportCommand, CheckCommand, VerifyCommand, HistoryCommand,
+		ExplainZeroCommand, PatchesCommand, SnapshotCommand, MatrixCommand, HelpCommand, VersionCommand} {
+		if !strings.Contains(buf.String(), "\n  "+string(command)+" ") {
+			t.Errorf("usage does not list the %s command", command)
+		}
+	}
+}
+
+// TestPrintCommandUsage tests the sections of a command's help
+func TestPrintCommandUsage(t *testing.T) {
+	flags := newCommandFlagSet(reportUsage)
+	flags.String("input", "", "Path to a result file saved with 'compare -json'")
+
+	var buf bytes.Buffer
+	flags.SetOutput(&buf)
+	flags.Usage()
+
+	for _, want := range []string{
+		"Usage: git-tag-similarity report [options]\n",
+		"\nSubcommands:\n  diff    ",
+		"\nOptions:\n  -input string\n",
+		"\n  git-tag-similarity report -input result.json -output report.md\n",
+		"'git-tag-similarity help report diff'",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("usage does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+// TestPrintCommandUsage_LongSubcommands tests aligning the summaries of subcommands with long names
+func TestPrintCommandUsage_LongSubcommands(t *testing.T) {
+	var buf bytes.Buffer
+	printCommandUsage(&buf, getUsage, nil)
+
+	for _, want := range []string{
+		"\n  similarity    Similarity as a fraction",
+		"\n  shared-count  Number of commits shared",
+		"\n  merge-base    Best common ancestor",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("usage does not contain %q:\n%s", want, buf.String())
+		}
+	}
+}
+
+// TestPrintCommandHelp_Unknown tests rejecting help for commands that do not exist
+func TestPrintCommandHelp_Unknown(t *testing.T) {
+	for _, args := range [][]string{{"nope"}, {"report", "nope"}, {"compare", "diff"}} {
+		if err := PrintCommandHelp(args); !errors.Is(err, ErrInvalidCommand) {
+			t.Errorf("PrintCommandHelp(%v) error = %v, want ErrInvalidCommand", args, err)
+		}
+	}
+}
@@ -0,0 +1,261 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var (
+	ErrMissingHistorySubcommand = errors.New("history subcommand is required")
+	ErrUnknownHistorySubcommand = errors.New("unknown history subcommand")
+	ErrMissingHistoryInput      = errors.New("both saved result paths are required")
+)
+
+// HistoryDiffSubcommand compares two saved comparison results
+const HistoryDiffSubcommand = "diff"
+
+// HistoryConfig holds the configuration of the history command
+type HistoryConfig struct {
+	Command    Command
+	Subcommand string
+	OldPath    string // Earlier result saved with 'compare -json'
+	NewPath    string // Later result saved with 'compare -json'
+	Output     OutputOptions
+
+	// Export selects the saved results of the history export subcommand
+	Export HistoryExportOptions
+}
+
+// historyUsage is the help of the history command
+var historyUsage = commandUsage{
+	Name:        "history",
+	Synopsis:    "<subcommand> [options]",
+	Summary:     "Compare or export saved results of earlier runs (history diff, export)",
+	Description: "Compare results saved with 'compare -json' by earlier runs, or export them as a time series.",
+	Examples: []string{
+		"history diff -old rc1.json -new rc2.json",
+		"history export -format csv -tag1 v1.0.0 -tag2 main results/",
+	},
+	Subcommands: []commandUsage{historyDiffUsage, historyExportUsage},
+}
+
+// historyDiffUsage is the help of the history diff command
+var historyDiffUsage = commandUsage{
+	Name:        "history diff",
+	Summary:     "Compare two saved comparison results",
+	Description: "Compare two saved results of the same (or a re-cut) tag pair and report what changed:\nthe similarity delta, commits that became shared, and new divergence.",
+	Examples: []string{
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0-rc1 -json rc1.json",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0-rc2 -json rc2.json",
+		"history diff -old rc1.json -new rc2.json",
+	},
+}
+
+// NewHistoryConfig parses the history subcommand and its flags
+func NewHistoryConfig(args []string) (HistoryConfig, error) {
+	config := HistoryConfig{Command: HistoryCommand}
+
+	if len(args) < 1 {
+		printCommandUsage(os.Stderr, historyUsage, nil)
+		return config, ErrMissingHistorySubcommand
+	}
+	config.Subcommand = args[0]
+
+	switch config.Subcommand {
+	case HistoryDiffSubcommand:
+		diffCmd := newCommandFlagSet(historyDiffUsage)
+		diffCmd.StringVar(&config.OldPath, "old", "", "Path to the earlier result saved with 'compare -json'")
+		diffCmd.StringVar(&config.NewPath, "new", "", "Path to the later result saved with 'compare -json'")
+		parseOutputOptions := config.Output.registerFlags(diffCmd, "Maximum line width of the listed commits")
+
+		if err := diffCmd.Parse(args[1:]); err != nil {
+			return config, err
+		}
+
+		if err := parseOutputOptions(); err != nil {
+			return config, err
+		}
+	case HistoryExportSubcommand:
+		if err := config.Export.parseFlags(args[1:]); err != nil {
+			return config, err
+		}
+	default:
+		printCommandUsage(os.Stderr, historyUsage, nil)
+		return config, errors.Join(ErrUnknownHistorySubcommand, fmt.Errorf("unknown subcommand: %s", args[0]))
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *HistoryConfig) Validate() error {
+	if c.Subcommand == HistoryExportSubcommand {
+		return c.Export.validate()
+	}
+
+	if c.OldPath == "" || c.NewPath == "" {
+		return ErrMissingHistoryInput
+	}
+
+	for _, path := range []string{c.OldPath, c.NewPath} {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return errors.Join(ErrLoadResult, fmt.Errorf("file does not exist: %s", path))
+		}
+	}
+
+	return nil
+}
+
+// ResultDiff describes how a comparison changed between two saved runs
+type ResultDiff struct {
+	Old SavedResult
+	New SavedResult
+
+	SimilarityDelta float64 // New minus old similarity
+
+	NowShared      []CommitInfo // Unique to either tag before, shared now
+	NoLongerShared []string     // Shared before, not shared now
+	NewOnlyInTag1  []CommitInfo // Unique to the first tag now, but not before
+	NewOnlyInTag2  []CommitInfo // Unique to the second tag now, but not before
+	Dropped        []CommitInfo // Unique to either tag before, in neither tag now
+}
+
+// TagsChanged reports whether the runs compared different tag pairs, e.g. after a tag was re-cut under a new name
+func (d ResultDiff) TagsChanged() bool {
+	return d.Old.Tag1 != d.New.Tag1 || d.Old.Tag2 != d.New.Tag2
+}
+
+// RepositoryChanged reports whether the runs are known to come from different repositories.
+// Results saved before fingerprints were recorded are assumed to match.
+func (d ResultDiff) RepositoryChanged() bool {
+	if d.Old.Repository == nil || d.New.Repository == nil {
+		return false
+	}
+	return d.Old.Repository.ID != d.New.Repository.ID
+}
+
+// DiffHistory loads two saved results and computes their difference
+func DiffHistory(config HistoryConfig) (ResultDiff, error) {
+	if err := config.Validate(); err != nil {
+		return ResultDiff{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	oldResult, err := LoadSavedResult(config.OldPath)
+	if err != nil {
+		return ResultDiff{}, err
+	}
+
+	newResult, err := LoadSavedResult(config.NewPath)
+	if err != nil {
+		return ResultDiff{}, err
+	}
+
+	return DiffSavedResults(oldResult, newResult), nil
+}
+
+// DiffSavedResults computes what changed from an earlier to a later saved result
+func DiffSavedResults(oldResult SavedResult, newResult SavedResult) ResultDiff {
+	diff := ResultDiff{
+		Old:             oldResult,
+		New:             newResult,
+		SimilarityDelta: newResult.Similarity - oldResult.Similarity,
+	}
+
+	newShared := stringSet(newResult.SharedCommits)
+	newUnique := make(map[string]struct{}, len(newResult.OnlyInTag1)+len(newResult.OnlyInTag2))
+	for _, info := range append(append([]CommitInfo(nil), newResult.OnlyInTag1...), newResult.OnlyInTag2...) {
+		newUnique[info.Hash] = struct{}{}
+	}
+
+	for _, info := range append(append([]CommitInfo(nil), oldResult.OnlyInTag1...), oldResult.OnlyInTag2...) {
+		if _, ok := newShared[info.Hash]; ok {
+			diff.NowShared = append(diff.NowShared, info)
+		} else if _, ok := newUnique[info.Hash]; !ok {
+			diff.Dropped = append(diff.Dropped, info)
+		}
+	}
+	sortCommitInfos(diff.NowShared)
+	sortCommitInfos(diff.Dropped)
+
+	for _, hash := range oldResult.SharedCommits {
+		if _, ok := newShared[hash]; !ok {
+			diff.NoLongerShared = append(diff.NoLongerShared, hash)
+		}
+	}
+
+	diff.NewOnlyInTag1 = newlyUnique(newResult.OnlyInTag1, oldResult.OnlyInTag1)
+	diff.NewOnlyInTag2 = newlyUnique(newResult.OnlyInTag2, oldResult.OnlyInTag2)
+
+	return diff
+}
+
+// newlyUnique returns the commits of current that were not unique to the same side before.
+// Commits that were shared before count as new divergence as well.
+func newlyUnique(current []CommitInfo, previous []CommitInfo) []CommitInfo {
+	before := make(map[string]struct{}, len(previous))
+	for _, info := range previous {
+		before[info.Hash] = struct{}{}
+	}
+
+	var infos []CommitInfo
+	for _, info := range current {
+		if _, ok := before[info.Hash]; ok {
+			continue
+		}
+		infos = append(infos, info)
+	}
+	return infos
+}
+
+// stringSet builds a set from a slice of strings
+func stringSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		set[value] = struct{}{}
+	}
+	return set
+}
+
+// PrintResultDiff prints the changes between two saved results
+func PrintResultDiff(w io.Writer, diff ResultDiff, output OutputOptions) {
+	_, _ = fmt.Fprintf(w, "Old: %s vs %s (%s)\n", diff.Old.Tag1, diff.Old.Tag2, diff.Old.GeneratedAt.Format("2006-01-02 15:04:05"))
+	_, _ = fmt.Fprintf(w, "New: %s vs %s (%s)\n", diff.New.Tag1, diff.New.Tag2, diff.New.GeneratedAt.Format("2006-01-02 15:04:05"))
+	if diff.TagsChanged() {
+		_, _ = fmt.Fprintf(w, "Note: the runs compare different tag pairs\n")
+	}
+	if diff.RepositoryChanged() {
+		_, _ = fmt.Fprintf(w, "Note: the runs are from different repositories (%s vs %s)\n", diff.Old.RepoPath, diff.New.RepoPath)
+	}
+	if diff.Old.Directory != diff.New.Directory {
+		_, _ = fmt.Fprintf(w, "Note: the runs use different directory filters (%q vs %q)\n", diff.Old.Directory, diff.New.Directory)
+	}
+
+	_, _ = fmt.Fprintf(w, "Similarity: %.2f%% -> %.2f%% (%+.2f points)\n", diff.Old.Similarity*100.0, diff.New.Similarity*100.0, diff.SimilarityDelta*100.0)
+
+	_, _ = fmt.Fprintf(w, "\nChanges:\n")
+	_, _ = fmt.Fprintf(w, "  Now shared: %d\n", len(diff.NowShared))
+	_, _ = fmt.Fprintf(w, "  No longer shared: %d\n", len(diff.NoLongerShared))
+	_, _ = fmt.Fprintf(w, "  New divergence in [%s]: %d\n", diff.New.Tag1, len(diff.NewOnlyInTag1))
+	_, _ = fmt.Fprintf(w, "  New divergence in [%s]: %d\n", diff.New.Tag2, len(diff.NewOnlyInTag2))
+	_, _ = fmt.Fprintf(w, "  Dropped: %d\n", len(diff.Dropped))
+
+	printCommitInfos(w, "Commits now shared", diff.NowShared, output)
+	printCommitInfos(w, fmt.Sprintf("New commits only in [%s]", diff.New.Tag1), diff.NewOnlyInTag1, output)
+	printCommitInfos(w, fmt.Sprintf("New commits only in [%s]", diff.New.Tag2), diff.NewOnlyInTag2, output)
+	printCommitInfos(w, "Commits dropped", diff.Dropped, output)
+}
+
+// printCommitInfos prints a titled list of commits, skipping empty lists
+func printCommitInfos(w io.Writer, title string, infos []CommitInfo, output OutputOptions) {
+	if len(infos) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%s (%d):\n", title, len(infos))
+	for _, info := range infos {
+		prefix := fmt.Sprintf("  %s ", shortHash(info.Hash))
+		_, _ = fmt.Fprintf(w, "%s%s\n", prefix, output.FitLine(info.Subject, len(prefix)))
+	}
+}
@@ -0,0 +1,121 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewHistoryConfig tests parsing the history subcommand
+func TestNewHistoryConfig(t *testing.T) {
+	config, err := NewHistoryConfig([]string{"diff", "-old", "rc1.json", "-new", "rc2.json"})
+	if err != nil {
+		t.Fatalf("NewHistoryConfig() error = %v, want nil", err)
+	}
+	if config.Subcommand != HistoryDiffSubcommand || config.OldPath != "rc1.json" || config.NewPath != "rc2.json" {
+		t.Errorf("NewHistoryConfig() = %+v, want diff of rc1.json and rc2.json", config)
+	}
+
+	if _, err := NewHistoryConfig([]string{"prune"}); !errors.Is(err, ErrUnknownHistorySubcommand) {
+		t.Errorf("NewHistoryConfig(prune) error = %v, want %v", err, ErrUnknownHistorySubcommand)
+	}
+	if _, err := NewHistoryConfig(nil); !errors.Is(err, ErrMissingHistorySubcommand) {
+		t.Errorf("NewHistoryConfig() error = %v, want %v", err, ErrMissingHistorySubcommand)
+	}
+}
+
+// TestDiffSavedResults tests the changes reported between two runs of a re-cut tag pair
+func TestDiffSavedResults(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	fix := CommitInfo{Hash: "0000000000000000000000000000000000000002", Subject: "Fix crash", Date: day(2)}
+	feature := CommitInfo{Hash: "0000000000000000000000000000000000000003", Subject: "Add feature", Date: day(3)}
+	hotfix := CommitInfo{Hash: "0000000000000000000000000000000000000004", Subject: "Hotfix", Date: day(4)}
+	revert := CommitInfo{Hash: "0000000000000000000000000000000000000001", Subject: "Initial", Date: day(1)}
+	experiment := CommitInfo{Hash: "0000000000000000000000000000000000000005", Subject: "Experiment", Date: day(5)}
+
+	oldResult := SavedResult{
+		Tag1:          "v1.0.0",
+		Tag2:          "v2.0.0-rc1",
+		Similarity:    0.25,
+		SharedCommits: []string{revert.Hash},
+		OnlyInTag1:    []CommitInfo{fix},
+		OnlyInTag2:    []CommitInfo{experiment, feature},
+	}
+	// rc2 picked up the fix, dropped the initial commit through a rewrite, added a hotfix, and left out the experiment
+	newResult := SavedResult{
+		Tag1:          "v1.0.0",
+		Tag2:          "v2.0.0-rc2",
+		Similarity:    0.5,
+		SharedCommits: []string{fix.Hash},
+		OnlyInTag1:    []CommitInfo{revert},
+		OnlyInTag2:    []CommitInfo{hotfix, feature},
+	}
+
+	diff := DiffSavedResults(oldResult, newResult)
+
+	if diff.SimilarityDelta != 0.25 {
+		t.Errorf("SimilarityDelta = %v, want 0.25", diff.SimilarityDelta)
+	}
+	if !reflect.DeepEqual(diff.NowShared, []CommitInfo{fix}) {
+		t.Errorf("NowShared = %+v, want [%s]", diff.NowShared, fix.Subject)
+	}
+	if !reflect.DeepEqual(diff.NoLongerShared, []string{revert.Hash}) {
+		t.Errorf("NoLongerShared = %v, want [%s]", diff.NoLongerShared, revert.Hash)
+	}
+	if !reflect.DeepEqual(diff.NewOnlyInTag1, []CommitInfo{revert}) {
+		t.Errorf("NewOnlyInTag1 = %+v, want [%s]", diff.NewOnlyInTag1, revert.Subject)
+	}
+	if !reflect.DeepEqual(diff.NewOnlyInTag2, []CommitInfo{hotfix}) {
+		t.Errorf("NewOnlyInTag2 = %+v, want [%s]", diff.NewOnlyInTag2, hotfix.Subject)
+	}
+	if !reflect.DeepEqual(diff.Dropped, []CommitInfo{experiment}) {
+		t.Errorf("Dropped = %+v, want [%s]", diff.Dropped, experiment.Subject)
+	}
+	if !diff.TagsChanged() {
+		t.Errorf("TagsChanged() = false, want true")
+	}
+
+	var buf bytes.Buffer
+	PrintResultDiff(&buf, diff, OutputOptions{})
+	for _, want := range []string{
+		"Similarity: 25.00% -> 50.00% (+25.00 points)",
+		"Note: the runs compare different tag pairs",
+		"New divergence in [v2.0.0-rc2]: 1",
+		"  0000000 Fix crash",
+		"Commits dropped (1):",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("PrintResultDiff() output missing %q\n%s", want, buf.String())
+		}
+	}
+}
+
+// TestResultDiff_RepositoryChanged tests that results from other clones of a repository still match
+func TestResultDiff_RepositoryChanged(t *testing.T) {
+	clone := &RepoFingerprint{ID: "a1"}
+	tests := []struct {
+		name    string
+		oldRepo *RepoFingerprint
+		newRepo *RepoFingerprint
+		want    bool
+	}{
+		{name: "Same repository at another path", oldRepo: clone, newRepo: &RepoFingerprint{ID: "a1"}, want: false},
+		{name: "Different repositories", oldRepo: clone, newRepo: &RepoFingerprint{ID: "b2"}, want: true},
+		{name: "Result without fingerprint", oldRepo: nil, newRepo: clone, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			diff := DiffSavedResults(
+				SavedResult{RepoPath: "/home/alice/repo", Repository: tt.oldRepo},
+				SavedResult{RepoPath: "/tmp/ci/repo", Repository: tt.newRepo},
+			)
+			if got := diff.RepositoryChanged(); got != tt.want {
+				t.Errorf("RepositoryChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
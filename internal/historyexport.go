@@ -0,0 +1,275 @@
+package internal
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrInvalidTrendFormat = errors.New("invalid trend format")
+	ErrMissingTrendInput  = errors.New("at least one saved result or directory of saved results is required")
+	ErrNoTrendPoints      = errors.New("no saved results match the selected tags")
+	ErrWriteTrend         = errors.New("failed to write trend")
+)
+
+// HistoryExportSubcommand exports saved comparison results as a time series
+const HistoryExportSubcommand = "export"
+
+// TrendFormat is the file format of an exported time series
+type TrendFormat string
+
+const (
+	TrendFormatCSV  TrendFormat = "csv"
+	TrendFormatJSON TrendFormat = "json"
+)
+
+// ParseTrendFormat converts a flag value into a TrendFormat
+func ParseTrendFormat(value string) (TrendFormat, error) {
+	switch TrendFormat(value) {
+	case TrendFormatCSV, TrendFormatJSON:
+		return TrendFormat(value), nil
+	default:
+		return "", errors.Join(ErrInvalidTrendFormat, fmt.Errorf("unknown format: %s (expected csv or json)", value))
+	}
+}
+
+// HistoryExportOptions holds the flags of the history export subcommand
+type HistoryExportOptions struct {
+	Inputs     []string // Saved results, or directories whose *.json files are saved results
+	Tag1       string   // Only export runs with this first tag; empty matches any
+	Tag2       string   // Only export runs with this second tag; empty matches any
+	Pattern    string   // Glob matched against the second tag, e.g. "v1.*" for a release line
+	Format     TrendFormat
+	OutputPath string // Empty writes to stdout
+}
+
+// historyExportUsage is the help of the history export command
+var historyExportUsage = commandUsage{
+	Name:        "history export",
+	Synopsis:    "[options] <result.json|directory>...",
+	Summary:     "Export saved results as a similarity time series",
+	Description: "Export results saved with 'compare -json' as a time series of the similarity and the\nshared and unique commit counts, ordered by the time of each run, for plotting in\nGrafana, a spreadsheet, or a notebook. Select a tag pair with -tag1 and -tag2, or a\nrelease line with -pattern.",
+	Examples: []string{
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 main -json results/$(date +%F).json",
+		"history export -format csv -tag1 v1.0.0 -tag2 main results/ > trend.csv",
+		"history export -format json -pattern 'v2.*' -output trend.json results/",
+	},
+}
+
+// parseFlags parses the flags and inputs of the history export subcommand
+func (o *HistoryExportOptions) parseFlags(args []string) error {
+	var format string
+
+	exportCmd := newCommandFlagSet(historyExportUsage)
+	exportCmd.StringVar(&o.Tag1, "tag1", "", "Only export runs whose first tag is this tag")
+	exportCmd.StringVar(&o.Tag2, "tag2", "", "Only export runs whose second tag is this tag")
+	exportCmd.StringVar(&o.Pattern, "pattern", "", "Only export runs whose second tag matches this glob (e.g. 'v1.*' for a release line)")
+	exportCmd.StringVar(&format, "format", string(TrendFormatCSV), "Output format (csv, json)")
+	exportCmd.StringVar(&o.OutputPath, "output", "", "Path to write the time series to (default: stdout)")
+
+	if err := exportCmd.Parse(args); err != nil {
+		return err
+	}
+	o.Inputs = exportCmd.Args()
+
+	trendFormat, err := ParseTrendFormat(format)
+	if err != nil {
+		return err
+	}
+	o.Format = trendFormat
+
+	return nil
+}
+
+// validate checks the inputs and the pattern of the export
+func (o *HistoryExportOptions) validate() error {
+	if len(o.Inputs) == 0 {
+		return ErrMissingTrendInput
+	}
+
+	for _, input := range o.Inputs {
+		if _, err := os.Stat(input); os.IsNotExist(err) {
+			return errors.Join(ErrLoadResult, fmt.Errorf("file does not exist: %s", input))
+		}
+	}
+
+	if o.Pattern != "" {
+		if _, err := path.Match(o.Pattern, ""); err != nil {
+			return errors.Join(ErrInvalidTagPattern, err)
+		}
+	}
+
+	return nil
+}
+
+// matches reports whether a saved result compares the selected tags
+func (o *HistoryExportOptions) matches(saved SavedResult) bool {
+	if o.Tag1 != "" && saved.Tag1 != o.Tag1 {
+		return false
+	}
+	if o.Tag2 != "" && saved.Tag2 != o.Tag2 {
+		return false
+	}
+	if o.Pattern != "" {
+		if matched, _ := path.Match(o.Pattern, saved.Tag2); !matched {
+			return false
+		}
+	}
+	return true
+}
+
+// TrendPoint is one saved run in an exported time series
+type TrendPoint struct {
+	GeneratedAt   time.Time `json:"generated_at"`
+	Tag1          string    `json:"tag1"`
+	Tag2          string    `json:"tag2"`
+	Similarity    float64   `json:"similarity"`
+	SharedCommits int       `json:"shared_commits"`
+	OnlyInTag1    int       `json:"only_in_tag1"`
+	OnlyInTag2    int       `json:"only_in_tag2"`
+	Metadata      Metadata  `json:"metadata,omitempty"` // -meta annotations of the run
+}
+
+// ExportHistory loads the saved results of the configured inputs and writes the matching runs as a time series
+func ExportHistory(config HistoryConfig) error {
+	if err := config.Validate(); err != nil {
+		return errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	points, err := LoadTrend(config.Export)
+	if err != nil {
+		return err
+	}
+
+	if config.Export.OutputPath == "" {
+		return WriteTrend(os.Stdout, points, config.Export.Format)
+	}
+
+	file, err := os.Create(config.Export.OutputPath)
+	if err != nil {
+		return errors.Join(ErrWriteTrend, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return WriteTrend(file, points, config.Export.Format)
+}
+
+// LoadTrend loads the saved results of the inputs that compare the selected tags, ordered by the time of each run.
+// Directories contribute their *.json files; subdirectories are not searched.
+func LoadTrend(options HistoryExportOptions) ([]TrendPoint, error) {
+	var paths []string
+	for _, input := range options.Inputs {
+		info, err := os.Stat(input)
+		if err != nil {
+			return nil, errors.Join(ErrLoadResult, err)
+		}
+		if !info.IsDir() {
+			paths = append(paths, input)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(input, "*.json"))
+		if err != nil {
+			return nil, errors.Join(ErrLoadResult, err)
+		}
+		paths = append(paths, matches...)
+	}
+
+	var points []TrendPoint
+	for _, resultPath := range paths {
+		saved, err := LoadSavedResult(resultPath)
+		if err != nil {
+			return nil, err
+		}
+		if !options.matches(saved) {
+			continue
+		}
+		points = append(points, TrendPoint{
+			GeneratedAt:   saved.GeneratedAt,
+			Tag1:          saved.Tag1,
+			Tag2:          saved.Tag2,
+			Similarity:    saved.Similarity,
+			SharedCommits: len(saved.SharedCommits),
+			OnlyInTag1:    len(saved.OnlyInTag1),
+			OnlyInTag2:    len(saved.OnlyInTag2),
+			Metadata:      saved.Metadata,
+		})
+	}
+	if len(points) == 0 {
+		return nil, errors.Join(ErrNoTrendPoints, fmt.Errorf("%d saved results read", len(paths)))
+	}
+
+	sort.SliceStable(points, func(i int, j int) bool {
+		if !points[i].GeneratedAt.Equal(points[j].GeneratedAt) {
+			return points[i].GeneratedAt.Before(points[j].GeneratedAt)
+		}
+		return points[i].Tag2 < points[j].Tag2
+	})
+	return points, nil
+}
+
+// WriteTrend writes a time series as CSV with a header row or as a JSON array.
+// In CSV, each metadata key of any run becomes a "meta.<key>" column.
+func WriteTrend(w io.Writer, points []TrendPoint, format TrendFormat) error {
+	var err error
+	switch format {
+	case TrendFormatJSON:
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(points)
+	default:
+		err = writeTrendCSV(w, points)
+	}
+	if err != nil {
+		return errors.Join(ErrWriteTrend, err)
+	}
+	return nil
+}
+
+func writeTrendCSV(w io.Writer, points []TrendPoint) error {
+	allMetadata := Metadata{}
+	for _, point := range points {
+		for key := range point.Metadata {
+			allMetadata[key] = ""
+		}
+	}
+	metadataKeys := allMetadata.Keys()
+
+	writer := csv.NewWriter(w)
+	header := []string{"generated_at", "tag1", "tag2", "similarity", "shared_commits", "only_in_tag1", "only_in_tag2"}
+	for _, key := range metadataKeys {
+		header = append(header, "meta."+key)
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for _, point := range points {
+		record := []string{
+			point.GeneratedAt.UTC().Format(time.RFC3339),
+			point.Tag1,
+			point.Tag2,
+			strconv.FormatFloat(point.Similarity, 'f', 4, 64),
+			strconv.Itoa(point.SharedCommits),
+			strconv.Itoa(point.OnlyInTag1),
+			strconv.Itoa(point.OnlyInTag2),
+		}
+		for _, key := range metadataKeys {
+			record = append(record, point.Metadata[key])
+		}
+		if err := writer.Write(record); err != nil {
+			return err
+		}
+	}
+
+	writer.Flush()
+	return writer.Error()
+}
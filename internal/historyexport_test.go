@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// writeTrendFixture saves results of nightly runs into a directory
+func writeTrendFixture(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	day := func(d int) time.Time { return time.Date(2025, 3, d, 2, 0, 0, 0, time.UTC) }
+	results := map[string]SavedResult{
+		"day2.json": {GeneratedAt: day(2), Tag1: "v1.0.0", Tag2: "main", Similarity: 0.5, SharedCommits: []string{"a"}, OnlyInTag2: []CommitInfo{{Hash: "b"}}},
+		"day1.json": {GeneratedAt: day(1), Tag1: "v1.0.0", Tag2: "main", Similarity: 1, SharedCommits: []string{"a"}},
+		"rc.json":   {GeneratedAt: day(3), Tag1: "v1.0.0", Tag2: "v1.1.0-rc1", Similarity: 0.25, SharedCommits: []string{"a"}, OnlyInTag1: []CommitInfo{{Hash: "c"}}, OnlyInTag2: []CommitInfo{{Hash: "d"}, {Hash: "e"}}},
+	}
+	for name, saved := range results {
+		saved.FormatVersion = savedResultFormatLatest
+		data, err := json.Marshal(saved)
+		if err != nil {
+			t.Fatalf("Failed to marshal %s: %v", name, err)
+		}
+		if err := os.WriteFile(filepath.Join(dir, name), data, 0o644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	if err := os.WriteFile(filepath.Join(dir, "notes.txt"), []byte("not a result"), 0o644); err != nil {
+		t.Fatalf("Failed to write notes: %v", err)
+	}
+	return dir
+}
+
+// TestLoadTrend tests selecting and ordering the runs of a time series
+func TestLoadTrend(t *testing.T) {
+	dir := writeTrendFixture(t)
+
+	tests := []struct {
+		name    string
+		options HistoryExportOptions
+		tags    []string // Second tag of each point in order
+		wantErr error
+	}{
+		{name: "directory ordered by run time", options: HistoryExportOptions{Inputs: []string{dir}}, tags: []string{"main", "main", "v1.1.0-rc1"}},
+		{name: "tag pair", options: HistoryExportOptions{Inputs: []string{dir}, Tag1: "v1.0.0", Tag2: "main"}, tags: []string{"main", "main"}},
+		{name: "release line", options: HistoryExportOptions{Inputs: []string{dir}, Pattern: "v1.1.*"}, tags: []string{"v1.1.0-rc1"}},
+		{name: "single file", options: HistoryExportOptions{Inputs: []string{filepath.Join(dir, "rc.json")}}, tags: []string{"v1.1.0-rc1"}},
+		{name: "no matching run", options: HistoryExportOptions{Inputs: []string{dir}, Tag2: "v2.0.0"}, wantErr: ErrNoTrendPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points, err := LoadTrend(tt.options)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("LoadTrend() error = %v, want %v", err, tt.wantErr)
+			}
+			var tags []string
+			for _, point := range points {
+				tags = append(tags, point.Tag2)
+			}
+			if !reflect.DeepEqual(tags, tt.tags) {
+				t.Errorf("LoadTrend() tags = %v, want %v", tags, tt.tags)
+			}
+		})
+	}
+}
+
+// TestWriteTrend tests the CSV and JSON forms of a time series
+func TestWriteTrend(t *testing.T) {
+	points, err := LoadTrend(HistoryExportOptions{Inputs: []string{writeTrendFixture(t)}, Pattern: "v1.1.*"})
+	if err != nil {
+		t.Fatalf("LoadTrend() error = %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTrend(&buf, points, TrendFormatCSV); err != nil {
+		t.Fatalf("WriteTrend(csv) error = %v", err)
+	}
+	want := "generated_at,tag1,tag2,similarity,shared_commits,only_in_tag1,only_in_tag2\n" +
+		"2025-03-03T02:00:00Z,v1.0.0,v1.1.0-rc1,0.2500,1,1,2\n"
+	if buf.String() != want {
+		t.Errorf("WriteTrend(csv) = %q, want %q", buf.String(), want)
+	}
+
+	buf.Reset()
+	if err := WriteTrend(&buf, points, TrendFormatJSON); err != nil {
+		t.Fatalf("WriteTrend(json) error = %v", err)
+	}
+	var decoded []TrendPoint
+	if err := json.Unmarshal(buf.Bytes(), &decoded); err != nil {
+		t.Fatalf("WriteTrend(json) is not valid JSON: %v", err)
+	}
+	if !reflect.DeepEqual(decoded, points) {
+		t.Errorf("WriteTrend(json) decoded = %+v, want %+v", decoded, points)
+	}
+}
+
+// TestWriteTrend_Metadata tests that metadata keys of any run become CSV columns
+func TestWriteTrend_Metadata(t *testing.T) {
+	generatedAt := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	points := []TrendPoint{
+		{GeneratedAt: generatedAt, Tag1: "v1.0.0", Tag2: "main", Similarity: 0.5, Metadata: Metadata{"build": "41"}},
+		{GeneratedAt: generatedAt.Add(time.Hour), Tag1: "v1.0.0", Tag2: "main", Similarity: 0.4, Metadata: Metadata{"build": "42", "env": "staging"}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteTrend(&buf, points, TrendFormatCSV); err != nil {
+		t.Fatalf("WriteTrend(csv) error = %v", err)
+	}
+	want := "generated_at,tag1,tag2,similarity,shared_commits,only_in_tag1,only_in_tag2,meta.build,meta.env\n" +
+		"2025-03-01T00:00:00Z,v1.0.0,main,0.5000,0,0,0,41,\n" +
+		"2025-03-01T01:00:00Z,v1.0.0,main,0.4000,0,0,0,42,staging\n"
+	if buf.String() != want {
+		t.Errorf("WriteTrend(csv) = %q, want %q", buf.String(), want)
+	}
+}
+
+// TestNewHistoryConfig_Export tests parsing the history export flags and inputs
+func TestNewHistoryConfig_Export(t *testing.T) {
+	config, err := NewHistoryConfig([]string{"export", "-format", "json", "-tag2", "main", "a.json", "results"})
+	if err != nil {
+		t.Fatalf("NewHistoryConfig() error = %v", err)
+	}
+	if config.Subcommand != HistoryExportSubcommand || config.Export.Format != TrendFormatJSON || config.Export.Tag2 != "main" ||
+		!reflect.DeepEqual(config.Export.Inputs, []string{"a.json", "results"}) {
+		t.Errorf("NewHistoryConfig() = %+v, want JSON export of a.json and results", config)
+	}
+
+	if _, err := NewHistoryConfig([]string{"export", "-format", "xml"}); !errors.Is(err, ErrInvalidTrendFormat) {
+		t.Errorf("NewHistoryConfig() with -format xml error = %v, want ErrInvalidTrendFormat", err)
+	}
+
+	config, _ = NewHistoryConfig([]string{"export"})
+	if err := config.Validate(); !errors.Is(err, ErrMissingTrendInput) {
+		t.Errorf("Validate() without inputs error = %v, want ErrMissingTrendInput", err)
+	}
+}
@@ -0,0 +1,251 @@
+package internal
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+var ErrInvalidHTTPOptions = errors.New("invalid HTTP client options")
+
+// Defaults of the HTTP client used by network integrations such as the Pushgateway
+const (
+	defaultHTTPConnectTimeout  = 10 * time.Second
+	defaultHTTPReadTimeout     = 30 * time.Second
+	defaultHTTPTimeout         = 60 * time.Second
+	defaultHTTPMaxIdleConns    = 10
+	defaultHTTPIdleConnTimeout = 90 * time.Second
+	defaultHTTPRetries         = 3
+	defaultHTTPRetryBackoff    = time.Second
+	httpKeepAlive              = 30 * time.Second
+
+	// maxHTTPRetryWait caps the backoff between attempts. A Retry-After beyond it, such as the reset of
+	// an hourly rate limit, is not waited for: the response is returned to fail the request.
+	maxHTTPRetryWait = time.Minute
+)
+
+// HTTPOptions configures the HTTP client shared by all network integrations.
+// Zero values select the defaults, so a stalled connection never blocks a run indefinitely.
+type HTTPOptions struct {
+	ConnectTimeout  time.Duration // Bounds the TCP connect and the TLS handshake
+	ReadTimeout     time.Duration // Bounds the wait for response headers once the request is sent
+	Timeout         time.Duration // Bounds the whole request, including reading the response body
+	MaxIdleConns    int           // Keep-alive connections kept open per host for reuse
+	IdleConnTimeout time.Duration // How long an unused keep-alive connection stays open
+	DisableHTTP2    bool          // Use HTTP/1.1 even when the server supports HTTP/2
+	Retries         int           // Attempts after a network error, 429, or 5xx response; 0 disables retrying
+	RetryBackoff    time.Duration // Wait before the first retry, doubled for each further one
+}
+
+// registerFlags adds the -http-* flags to a command.
+// The returned function must be called after parsing to validate the flag values.
+func (o *HTTPOptions) registerFlags(flags *flag.FlagSet) func() error {
+	var http2 bool
+
+	flags.DurationVar(&o.ConnectTimeout, "http-connect-timeout", defaultHTTPConnectTimeout, "Timeout for connecting to HTTP endpoints, including the TLS handshake")
+	flags.DurationVar(&o.ReadTimeout, "http-read-timeout", defaultHTTPReadTimeout, "Timeout for waiting on an HTTP response once the request is sent")
+	flags.DurationVar(&o.Timeout, "http-timeout", defaultHTTPTimeout, "Overall timeout of a single HTTP request")
+	flags.BoolVar(&http2, "http2", true, "Negotiate HTTP/2 with servers that support it")
+	flags.IntVar(&o.Retries, "http-retries", defaultHTTPRetries, "Retry HTTP requests failing with a network error, 429, or 5xx this many times (0 disables retrying)")
+	flags.DurationVar(&o.RetryBackoff, "http-retry-backoff", defaultHTTPRetryBackoff, "Wait before the first HTTP retry, doubled for each further one; a Retry-After header takes precedence")
+
+	return func() error {
+		o.DisableHTTP2 = !http2
+		return o.Validate()
+	}
+}
+
+// Validate checks that no timeout, pool size, or retry setting is negative
+func (o HTTPOptions) Validate() error {
+	for name, value := range map[string]time.Duration{
+		"http-connect-timeout":    o.ConnectTimeout,
+		"http-read-timeout":       o.ReadTimeout,
+		"http-timeout":            o.Timeout,
+		"idle connection timeout": o.IdleConnTimeout,
+		"http-retry-backoff":      o.RetryBackoff,
+	} {
+		if value < 0 {
+			return errors.Join(ErrInvalidHTTPOptions, fmt.Errorf("%s must not be negative: %s", name, value))
+		}
+	}
+	if o.MaxIdleConns < 0 {
+		return errors.Join(ErrInvalidHTTPOptions, fmt.Errorf("max idle connections must not be negative: %d", o.MaxIdleConns))
+	}
+	if o.Retries < 0 {
+		return errors.Join(ErrInvalidHTTPOptions, fmt.Errorf("http-retries must not be negative: %d", o.Retries))
+	}
+	return nil
+}
+
+// Client builds an HTTP client with explicit timeouts, keep-alive connection pooling, and retries.
+// The overall timeout applies to each attempt, so a retry is not cut short by the time the earlier ones took.
+func (o HTTPOptions) Client() *http.Client {
+	connectTimeout := durationOrDefault(o.ConnectTimeout, defaultHTTPConnectTimeout)
+	maxIdleConns := o.MaxIdleConns
+	if maxIdleConns == 0 {
+		maxIdleConns = defaultHTTPMaxIdleConns
+	}
+
+	dialer := &net.Dialer{Timeout: connectTimeout, KeepAlive: httpKeepAlive}
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: durationOrDefault(o.ReadTimeout, defaultHTTPReadTimeout),
+		ExpectContinueTimeout: time.Second,
+		MaxIdleConns:          maxIdleConns,
+		MaxIdleConnsPerHost:   maxIdleConns,
+		IdleConnTimeout:       durationOrDefault(o.IdleConnTimeout, defaultHTTPIdleConnTimeout),
+		ForceAttemptHTTP2:     !o.DisableHTTP2,
+	}
+	if o.DisableHTTP2 {
+		// A non-nil, empty map disables the transport's automatic HTTP/2 upgrade
+		transport.TLSNextProto = map[string]func(string, *tls.Conn) http.RoundTripper{}
+	}
+
+	return &http.Client{
+		Transport: &retryTransport{
+			base:    transport,
+			timeout: durationOrDefault(o.Timeout, defaultHTTPTimeout),
+			retries: o.Retries,
+			backoff: durationOrDefault(o.RetryBackoff, defaultHTTPRetryBackoff),
+			sleep:   sleepContext,
+		},
+	}
+}
+
+// retryTransport retries requests that failed with a network error or a transient status, waiting with
+// exponential backoff or as long as the server's Retry-After asks. Requests whose body cannot be
+// replayed are sent once.
+type retryTransport struct {
+	base    http.RoundTripper
+	timeout time.Duration // Bounds each attempt, including reading the response body
+	retries int
+	backoff time.Duration
+	sleep   func(ctx context.Context, d time.Duration) error
+}
+
+// RoundTrip sends the request, retrying transient failures
+func (t *retryTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	replayable := req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 && req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			attemptReq = req.Clone(req.Context())
+			attemptReq.Body = body
+		}
+
+		resp, err := t.send(attemptReq)
+		if attempt == t.retries || !replayable || req.Context().Err() != nil {
+			return resp, err
+		}
+
+		wait := t.backoff << attempt
+		if wait > maxHTTPRetryWait || wait <= 0 {
+			wait = maxHTTPRetryWait
+		}
+		if err == nil {
+			if !retryableStatus(resp) {
+				return resp, nil
+			}
+			if after, ok := retryAfter(resp.Header.Get("Retry-After")); ok {
+				if after > maxHTTPRetryWait {
+					return resp, nil
+				}
+				wait = after
+			}
+			// Drain the body so the connection can be reused
+			_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+			_ = resp.Body.Close()
+		}
+
+		if err := t.sleep(req.Context(), wait); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// send performs one attempt, bounded by the timeout until the response body is closed
+func (t *retryTransport) send(req *http.Request) (*http.Response, error) {
+	ctx, cancel := context.WithTimeout(req.Context(), t.timeout)
+	resp, err := t.base.RoundTrip(req.WithContext(ctx))
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases the context of an attempt once its response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body and cancels the attempt's context
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// retryableStatus reports whether a response is a transient failure: too many requests, a server error
+// other than 501 Not Implemented, or a 403 with Retry-After, as GitHub sends for secondary rate limits
+func retryableStatus(resp *http.Response) bool {
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests:
+		return true
+	case resp.StatusCode == http.StatusForbidden:
+		return resp.Header.Get("Retry-After") != ""
+	case resp.StatusCode >= 500:
+		return resp.StatusCode != http.StatusNotImplemented
+	default:
+		return false
+	}
+}
+
+// retryAfter parses a Retry-After header given in seconds or as an HTTP date
+func retryAfter(value string) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second, true
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		return max(time.Until(date), 0), true
+	}
+	return 0, false
+}
+
+// sleepContext waits for d, returning early with the context's error when it is canceled
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// durationOrDefault returns value, or fallback when value is zero
+func durationOrDefault(value time.Duration, fallback time.Duration) time.Duration {
+	if value == 0 {
+		return fallback
+	}
+	return value
+}
@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/gitignore"
+)
+
+var ErrLoadIgnoreRules = errors.New("failed to load ignore rules")
+
+// IgnoreFile lists paths to leave out of content comparisons in .gitignore syntax, read from the root of a tag's tree
+const IgnoreFile = ".git-tag-similarity-ignore"
+
+// ignoreRules matches paths against the .gitignore-style rules that apply to the tree of a tag.
+// Unlike git, tracked files are matched too, so build outputs committed by mistake are left out.
+type ignoreRules struct {
+	matcher gitignore.Matcher
+}
+
+// loadIgnoreRules collects the rules of the .gitignore files in a tag's tree, its IgnoreFile, and the
+// repository's exclude files (info/exclude and core.excludesFile). Later sources override earlier ones,
+// and deeper .gitignore files override the rules of their parents, as in git.
+func loadIgnoreRules(ctx context.Context, repo Repository, tagFiles map[string]plumbing.Hash) (ignoreRules, error) {
+	excludes, err := repo.GetExcludePatterns(ctx)
+	if err != nil {
+		return ignoreRules{}, errors.Join(ErrLoadIgnoreRules, err)
+	}
+	patterns := parseIgnorePatterns(excludes, nil)
+
+	// Parents first, so that the rules of nested .gitignore files take precedence
+	var gitignores []string
+	for filePath := range tagFiles {
+		if path.Base(filePath) == ".gitignore" {
+			gitignores = append(gitignores, filePath)
+		}
+	}
+	sort.Slice(gitignores, func(i int, j int) bool {
+		depthI, depthJ := strings.Count(gitignores[i], "/"), strings.Count(gitignores[j], "/")
+		if depthI != depthJ {
+			return depthI < depthJ
+		}
+		return gitignores[i] < gitignores[j]
+	})
+	for _, filePath := range gitignores {
+		content, err := repo.GetBlob(tagFiles[filePath])
+		if err != nil {
+			return ignoreRules{}, errors.Join(ErrLoadIgnoreRules, err)
+		}
+		var domain []string
+		if dir := path.Dir(filePath); dir != "." {
+			domain = strings.Split(dir, "/")
+		}
+		patterns = append(patterns, parseIgnorePatterns(strings.Split(string(content), "\n"), domain)...)
+	}
+
+	if hash, ok := tagFiles[IgnoreFile]; ok {
+		content, err := repo.GetBlob(hash)
+		if err != nil {
+			return ignoreRules{}, errors.Join(ErrLoadIgnoreRules, err)
+		}
+		patterns = append(patterns, parseIgnorePatterns(strings.Split(string(content), "\n"), nil)...)
+	}
+
+	return ignoreRules{matcher: gitignore.NewMatcher(patterns)}, nil
+}
+
+// parseIgnorePatterns parses the lines of an ignore file, skipping blank lines and comments
+func parseIgnorePatterns(lines []string, domain []string) []gitignore.Pattern {
+	var patterns []gitignore.Pattern
+	for _, line := range lines {
+		line = strings.TrimRight(line, "\r")
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		patterns = append(patterns, gitignore.ParsePattern(line, domain))
+	}
+	return patterns
+}
+
+// Ignored reports whether a file, given by its slash-separated path, matches the rules.
+// The ignore files themselves are never ignored.
+func (r ignoreRules) Ignored(filePath string) bool {
+	if r.matcher == nil {
+		return false
+	}
+	if base := path.Base(filePath); base == ".gitignore" || filePath == IgnoreFile {
+		return false
+	}
+	return r.matcher.Match(strings.Split(filePath, "/"), false)
+}
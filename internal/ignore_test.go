@@ -0,0 +1,92 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// isolateGitConfig hides the global and system git config of the developer, whose exclude files would change results
+func isolateGitConfig(t *testing.T) string {
+	t.Helper()
+	configHome := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configHome)
+	t.Setenv("GIT_CONFIG_GLOBAL", filepath.Join(configHome, "gitconfig"))
+	t.Setenv("GIT_CONFIG_NOSYSTEM", "1")
+	return configHome
+}
+
+// TestCompareSnapshot_IgnoreRules tests leaving out files matched by ignore files on both sides
+func TestCompareSnapshot_IgnoreRules(t *testing.T) {
+	configHome := isolateGitConfig(t)
+	if err := os.MkdirAll(filepath.Join(configHome, "git"), 0o755); err != nil {
+		t.Fatalf("Failed to create config directory: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(configHome, "git", "ignore"), []byte("*.swp\n"), 0o644); err != nil {
+		t.Fatalf("Failed to write global ignore file: %v", err)
+	}
+
+	fixture := testutil.NewRepo(t).
+		Commit("Add app",
+			testutil.File("src/app.go", "package app\n"),
+			testutil.File(".gitignore", "# Build outputs\ndist/\n*.log\n"),
+			testutil.File("web/.gitignore", "!keep.log\n"),
+			testutil.File(IgnoreFile, "docs/generated/\n"),
+		).
+		Commit("Commit build output by mistake",
+			testutil.File("dist/app.js", "built"),
+			testutil.File("web/keep.log", "kept"),
+		).
+		Tag("v1.0.0")
+	repo := openFixture(t, fixture)
+
+	snapshot := writeSnapshot(t, map[string]string{
+		"src/app.go":               "package app\n",
+		".gitignore":               "# Build outputs\ndist/\n*.log\n",
+		"web/.gitignore":           "!keep.log\n",
+		IgnoreFile:                 "docs/generated/\n",
+		"web/keep.log":             "kept",
+		"build.log":                "noise",
+		"docs/generated/index.htm": "generated",
+		"src/.app.go.swp":          "editor",
+	})
+
+	tests := []struct {
+		name      string
+		noIgnore  bool
+		identical int
+		ignored   int
+		onlyTag   []string
+		onlySnap  []string
+	}{
+		{name: "ignore rules", identical: 5, ignored: 4},
+		{
+			name:      "no ignore",
+			noIgnore:  true,
+			identical: 5,
+			onlyTag:   []string{"dist/app.js"},
+			onlySnap:  []string{"build.log", "docs/generated/index.htm", "src/.app.go.swp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := SnapshotConfig{RepoPath: fixture.Path(), TagName: "v1.0.0", SnapshotPath: snapshot, NoIgnore: tt.noIgnore}
+			comparison, _ := compareSnapshot(context.Background(), repo, config)
+
+			if comparison.Identical != tt.identical || comparison.Ignored != tt.ignored {
+				t.Errorf("Identical, Ignored = %d, %d, want %d, %d", comparison.Identical, comparison.Ignored, tt.identical, tt.ignored)
+			}
+			if !reflect.DeepEqual(comparison.OnlyInTag, tt.onlyTag) {
+				t.Errorf("OnlyInTag = %v, want %v", comparison.OnlyInTag, tt.onlyTag)
+			}
+			if !reflect.DeepEqual(comparison.OnlyInSnapshot, tt.onlySnap) {
+				t.Errorf("OnlyInSnapshot = %v, want %v", comparison.OnlyInSnapshot, tt.onlySnap)
+			}
+		})
+	}
+}
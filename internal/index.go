@@ -0,0 +1,627 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrBuildIndex  = errors.New("failed to build tag index")
+	ErrRemoveIndex = errors.New("failed to remove tag index")
+)
+
+// indexFormatVersion is stored with every index; an index of another version is ignored until rebuilt
+const indexFormatVersion = 1
+
+// indexDirectory is the directory of the tag index inside the git directory, next to git's own caches
+const indexDirectory = "git-tag-similarity"
+
+// IndexConfig holds the configuration of the index command
+type IndexConfig struct {
+	Command  Command
+	RepoPath string
+	Remove   bool          // Delete the index instead of building it
+	Timeout  time.Duration // Cancel indexing after this long; 0 never times out
+}
+
+// indexUsage is the help of the index command
+var indexUsage = commandUsage{
+	Name:        "index",
+	Summary:     "Precompute the commit sets, patch IDs, and merge bases of every tag",
+	Description: "Walk the history of every tag once, in parallel, and store each tag's commit set, the patch ID\nof every commit, and the generation numbers that answer merge base queries in the git directory.\nLater compare, matrix, patches, explain-zero, and get invocations on the repository read the\nindex instead of traversing history. Tags created after the index was built are traversed as\nbefore, so the index never gives wrong answers; rebuild it to make new tags fast too.",
+	Examples: []string{
+		"index -repo /path/to/repo",
+		"index -repo /path/to/repo -remove",
+	},
+}
+
+// NewIndexConfig parses the index command flags
+func NewIndexConfig(args []string) (IndexConfig, error) {
+	config := IndexConfig{Command: IndexCommand}
+
+	indexCmd := newCommandFlagSet(indexUsage)
+	indexCmd.StringVar(&config.RepoPath, "repo", "", "Path to the Git repository")
+	indexCmd.BoolVar(&config.Remove, "remove", false, "Delete the index of the repository instead of building it")
+	registerTimeoutFlag(indexCmd, &config.Timeout, "indexing")
+
+	if err := indexCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *IndexConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	// A remote repository is indexed in its clone under the user cache directory, which later runs reuse
+	if IsRemoteURL(c.RepoPath) {
+		return nil
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return validateRepoPath(c.RepoPath)
+}
+
+// IndexStats describes a built or removed index
+type IndexStats struct {
+	Path     string
+	Removed  bool // Set by -remove when an index existed
+	Tags     int
+	Commits  int
+	PatchIDs int
+	Duration time.Duration
+}
+
+// IndexRepository builds the tag index of the configured repository, or removes it
+func IndexRepository(ctx context.Context, config IndexConfig) (_ IndexStats, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return IndexStats{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	path := config.RepoPath
+	if config.Remove && IsRemoteURL(path) {
+		// Remove the index of the existing clone instead of fetching the repository
+		dir, ok, err := existingClone(path)
+		if err != nil {
+			return IndexStats{}, errors.Join(ErrRemoveIndex, err)
+		}
+		if !ok {
+			return IndexStats{}, nil
+		}
+		path = dir
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, path, 0)
+	if err != nil {
+		return IndexStats{}, errors.Join(ErrOpenRepository, err)
+	}
+
+	path, err = repo.indexPath()
+	if err != nil {
+		return IndexStats{}, err
+	}
+
+	if config.Remove {
+		err := os.Remove(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return IndexStats{Path: path}, nil
+		}
+		if err != nil {
+			return IndexStats{}, errors.Join(ErrRemoveIndex, err)
+		}
+		return IndexStats{Path: path, Removed: true}, nil
+	}
+
+	start := time.Now()
+	index, err := repo.buildTagIndex(ctx)
+	if err != nil {
+		return IndexStats{}, errors.Join(ErrBuildIndex, err)
+	}
+	if err := index.write(path); err != nil {
+		return IndexStats{}, errors.Join(ErrBuildIndex, err)
+	}
+
+	stats := IndexStats{Path: path, Tags: len(index.Sets), Commits: len(index.Commits), Duration: time.Since(start)}
+	for _, commit := range index.Commits {
+		if commit.PatchID != "" {
+			stats.PatchIDs++
+		}
+	}
+	return stats, nil
+}
+
+// PrintIndexStats prints the outcome of the index command
+func PrintIndexStats(w io.Writer, stats IndexStats, config IndexConfig) {
+	if config.Remove {
+		if stats.Removed {
+			_, _ = fmt.Fprintf(w, "Removed index: %s\n", stats.Path)
+		} else {
+			_, _ = fmt.Fprintf(w, "No index to remove: %s\n", stats.Path)
+		}
+		return
+	}
+	_, _ = fmt.Fprintf(w, "Indexed %d tag commits (%d commits, %d patch IDs) in %s\n", stats.Tags, stats.Commits, stats.PatchIDs, stats.Duration.Round(time.Millisecond))
+	_, _ = fmt.Fprintf(w, "Index: %s\n", stats.Path)
+}
+
+// tagIndex holds the commit set of every tagged commit with per-commit data derived from the history.
+// Everything is keyed by commit hash, so moving or adding tags never makes it wrong: a commit that was
+// not tagged when the index was built is simply not found and traversed as usual.
+type tagIndex struct {
+	FormatVersion int
+	BuiltAt       time.Time
+	Commits       []indexedCommit              // Every commit reachable from a tag, parents before children
+	Sets          map[plumbing.Hash]indexedSet // Commit sets by tagged commit
+
+	// positions maps the hashes of Commits to their position, rebuilt after loading
+	positions map[plumbing.Hash]uint32
+}
+
+// indexedCommit is a commit of the index
+type indexedCommit struct {
+	Hash       plumbing.Hash
+	Generation uint32 // 1 for root commits, otherwise one more than the highest generation of the parents
+	When       int64  // Committer date as a Unix timestamp
+	PatchID    string // Stable patch ID; empty for merges and commits without a diff
+}
+
+// indexedSet stores a commit set as the commits it adds to the set of an ancestor tag, which keeps
+// the index of a long release history close to the size of the history itself
+type indexedSet struct {
+	Base  plumbing.Hash // Tagged ancestor whose set this one extends; zero when the set is stored whole
+	Added []uint32      // Positions of the commits not reachable from Base
+}
+
+// indexPath returns where the tag index of the repository is stored; linked worktrees share the
+// index of their main repository
+func (gr *GitRepository) indexPath() (string, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return "", err
+	}
+	defer gr.returnReader(reader)
+
+	storage, ok := repositoryStorage(reader)
+	if !ok {
+		return "", errors.Join(ErrOpenRepository, errors.New("repository has no git directory"))
+	}
+	return filepath.Join(filepath.Dir(objectsDirectory(storage)), indexDirectory, "index"), nil
+}
+
+// tagIndex returns the index built by the index command, or nil when there is none.
+// It is loaded on first use; an index that cannot be read is ignored, so a damaged or outdated
+// file only costs the speedup.
+func (gr *GitRepository) tagIndex() *tagIndex {
+	gr.indexOnce.Do(func() {
+		path, err := gr.indexPath()
+		if err != nil {
+			return
+		}
+		index, err := readTagIndex(path)
+		if err != nil {
+			return
+		}
+		gr.index = index
+	})
+	return gr.index
+}
+
+// readTagIndex loads an index written by write
+func readTagIndex(path string) (*tagIndex, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { _ = file.Close() }()
+
+	var index tagIndex
+	if err := gob.NewDecoder(bufio.NewReader(file)).Decode(&index); err != nil {
+		return nil, err
+	}
+	if index.FormatVersion != indexFormatVersion {
+		return nil, fmt.Errorf("index format %d, want %d", index.FormatVersion, indexFormatVersion)
+	}
+	index.locate()
+	return &index, nil
+}
+
+// write stores the index at path, replacing any previous index atomically
+func (idx *tagIndex) write(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	file, err := os.CreateTemp(filepath.Dir(path), "index-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(file.Name()) }()
+
+	buffered := bufio.NewWriter(file)
+	if err := gob.NewEncoder(buffered).Encode(idx); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := buffered.Flush(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	// CreateTemp makes the file private; the index holds nothing the repository does not
+	if err := os.Chmod(file.Name(), 0o644); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// locate builds the position lookup of the commits
+func (idx *tagIndex) locate() {
+	idx.positions = make(map[plumbing.Hash]uint32, len(idx.Commits))
+	for i, commit := range idx.Commits {
+		idx.positions[commit.Hash] = uint32(i)
+	}
+}
+
+// forEachPosition calls fn with the position of every commit in the set of a tagged commit, once each.
+// It reports false when the commit is not indexed.
+func (idx *tagIndex) forEachPosition(commit plumbing.Hash, fn func(position uint32) error) (bool, error) {
+	set, ok := idx.Sets[commit]
+	if !ok {
+		return false, nil
+	}
+	for {
+		for _, position := range set.Added {
+			if err := fn(position); err != nil {
+				return true, err
+			}
+		}
+		if set.Base.IsZero() {
+			return true, nil
+		}
+		set = idx.Sets[set.Base]
+	}
+}
+
+// positionSet returns the positions in the set of a tagged commit as a bitmap, or nil when it is not indexed
+func (idx *tagIndex) positionSet(commit plumbing.Hash) []bool {
+	members := make([]bool, len(idx.Commits))
+	found, _ := idx.forEachPosition(commit, func(position uint32) error {
+		members[position] = true
+		return nil
+	})
+	if !found {
+		return nil
+	}
+	return members
+}
+
+// mergeBase returns the common ancestor of two tagged commits with the highest generation, which no other
+// common ancestor descends from. When several qualify (criss-cross merges), the lowest hash is chosen, so the
+// answer is stable but may differ from the one 'git merge-base' picks. It reports false when either commit is
+// not indexed.
+func (idx *tagIndex) mergeBase(a plumbing.Hash, b plumbing.Hash) (plumbing.Hash, bool) {
+	inA := idx.positionSet(a)
+	if inA == nil {
+		return plumbing.ZeroHash, false
+	}
+
+	var best *indexedCommit
+	found, _ := idx.forEachPosition(b, func(position uint32) error {
+		if !inA[position] {
+			return nil
+		}
+		candidate := &idx.Commits[position]
+		if best == nil || candidate.Generation > best.Generation ||
+			(candidate.Generation == best.Generation && compareHashes(candidate.Hash, best.Hash) < 0) {
+			best = candidate
+		}
+		return nil
+	})
+	if !found {
+		return plumbing.ZeroHash, false
+	}
+	if best == nil {
+		return plumbing.ZeroHash, true
+	}
+	return best.Hash, true
+}
+
+// patchIDs returns the patch IDs of the commits reachable from a tagged commit but not from base, which may be
+// zero, keeping the newest commit of each patch ID like GetPatchIDs. It reports false when a commit is not indexed.
+func (idx *tagIndex) patchIDs(commit plumbing.Hash, base plumbing.Hash) (map[string]plumbing.Hash, bool) {
+	var excluded []bool
+	if !base.IsZero() {
+		if excluded = idx.positionSet(base); excluded == nil {
+			return nil, false
+		}
+	}
+
+	newest := make(map[string]*indexedCommit)
+	found, _ := idx.forEachPosition(commit, func(position uint32) error {
+		c := &idx.Commits[position]
+		if c.PatchID == "" || (excluded != nil && excluded[position]) {
+			return nil
+		}
+		if previous, ok := newest[c.PatchID]; !ok || c.When > previous.When ||
+			(c.When == previous.When && c.Generation > previous.Generation) {
+			newest[c.PatchID] = c
+		}
+		return nil
+	})
+	if !found {
+		return nil, false
+	}
+
+	ids := make(map[string]plumbing.Hash, len(newest))
+	for patchID, c := range newest {
+		ids[patchID] = c.Hash
+	}
+	return ids, true
+}
+
+// buildTagIndex indexes every tag of the repository. The commit graph is read in one 'git rev-list' pass and
+// the patch IDs in one 'git log | git patch-id' pass running alongside; the per-tag sets are then computed
+// from the graph in memory by commitLoadWorkers workers.
+func (gr *GitRepository) buildTagIndex(ctx context.Context) (*tagIndex, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, err
+	}
+	shallow, err := reader.Storer.Shallow()
+	gr.returnReader(reader)
+	if err != nil {
+		return nil, err
+	}
+	if len(shallow) > 0 {
+		return nil, errors.New("a shallow clone cannot be indexed; fetch the full history with 'git fetch --unshallow' first")
+	}
+
+	refs, err := gr.FetchAllTags()
+	if err != nil {
+		return nil, err
+	}
+	var tagged []plumbing.Hash
+	seen := make(map[plumbing.Hash]bool)
+	for _, ref := range refs {
+		commit, err := gr.resolveTagToCommit(ref)
+		if err != nil {
+			// Tags of trees and blobs have no history to index
+			continue
+		}
+		if !seen[commit.Hash] {
+			seen[commit.Hash] = true
+			tagged = append(tagged, commit.Hash)
+		}
+	}
+
+	index := &tagIndex{FormatVersion: indexFormatVersion, BuiltAt: time.Now().UTC(), Sets: make(map[plumbing.Hash]indexedSet)}
+	if len(tagged) == 0 {
+		index.locate()
+		return index, nil
+	}
+
+	var patchIDs map[plumbing.Hash]string
+	var patchErr error
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		patchIDs, patchErr = gr.allPatchIDs(ctx, tagged)
+	}()
+
+	parents, err := gr.readCommitGraph(ctx, index, tagged)
+	wg.Wait()
+	if err != nil {
+		return nil, err
+	}
+	if patchErr != nil {
+		return nil, patchErr
+	}
+	for i := range index.Commits {
+		index.Commits[i].PatchID = patchIDs[index.Commits[i].Hash]
+	}
+
+	index.indexSets(parents, tagged)
+	return index, nil
+}
+
+// readCommitGraph fills the commits of the index with everything reachable from the tagged commits and
+// returns the parent positions of each commit
+func (gr *GitRepository) readCommitGraph(ctx context.Context, index *tagIndex, tagged []plumbing.Hash) ([][]uint32, error) {
+	// Command: git rev-list --reverse --topo-order --timestamp --parents --stdin < commits
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--reverse", "--topo-order", "--timestamp", "--parents", "--stdin")
+	cmd.Dir = gr.path
+	cmd.Stdin = strings.NewReader(joinHashes(tagged))
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	// Each line is "<timestamp> <commit> <parents...>", parents listed before their children
+	index.positions = make(map[plumbing.Hash]uint32)
+	var parents [][]uint32
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		when, err := strconv.ParseInt(fields[0], 10, 64)
+		if err != nil {
+			return nil, errors.Join(ErrTraverseCommits, fmt.Errorf("unexpected rev-list output: %s", line))
+		}
+
+		commit := indexedCommit{Hash: plumbing.NewHash(fields[1]), Generation: 1, When: when}
+		var commitParents []uint32
+		for _, parent := range fields[2:] {
+			position, ok := index.positions[plumbing.NewHash(parent)]
+			if !ok {
+				return nil, errors.Join(ErrTraverseCommits, fmt.Errorf("parent %s of commit %s listed after it", parent, fields[1]))
+			}
+			commitParents = append(commitParents, position)
+			commit.Generation = max(commit.Generation, index.Commits[position].Generation+1)
+		}
+
+		index.positions[commit.Hash] = uint32(len(index.Commits))
+		index.Commits = append(index.Commits, commit)
+		parents = append(parents, commitParents)
+	}
+	return parents, nil
+}
+
+// allPatchIDs returns the stable patch ID of every non-merge commit reachable from the given commits
+func (gr *GitRepository) allPatchIDs(ctx context.Context, commits []plumbing.Hash) (map[plumbing.Hash]string, error) {
+	// Command: git log -p --no-merges --stdin < commits | git patch-id --stable
+	logCmd := exec.CommandContext(ctx, "git", "log", "-p", "--no-merges", "--no-color", "--no-ext-diff", "--no-textconv", "--stdin")
+	logCmd.Dir = gr.path
+	logCmd.Stdin = strings.NewReader(joinHashes(commits))
+	patchIDCmd := exec.CommandContext(ctx, "git", "patch-id", "--stable")
+	patchIDCmd.Dir = gr.path
+
+	patches, err := logCmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+	patchIDCmd.Stdin = patches
+	if err := logCmd.Start(); err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	output, err := patchIDCmd.Output()
+	if waitErr := logCmd.Wait(); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	// Each line is "<patch-id> <commit>"
+	ids := make(map[plumbing.Hash]string)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		if patchID, hash, ok := strings.Cut(line, " "); ok {
+			ids[plumbing.NewHash(hash)] = patchID
+		}
+	}
+	return ids, nil
+}
+
+// joinHashes returns the hashes one per line, as read by --stdin
+func joinHashes(hashes []plumbing.Hash) string {
+	var b strings.Builder
+	for _, hash := range hashes {
+		b.WriteString(hash.String())
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// indexSets computes the set of every tagged commit. Each set is stored relative to the tagged ancestor
+// with the highest generation, the latest release it builds on in a typical history.
+func (idx *tagIndex) indexSets(parents [][]uint32, tagged []plumbing.Hash) {
+	isTagged := make([]bool, len(idx.Commits))
+	for _, hash := range tagged {
+		isTagged[idx.positions[hash]] = true
+	}
+
+	jobs := make(chan plumbing.Hash)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for range min(commitLoadWorkers, len(tagged)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// Reused between tags; reachable clears what it marked through the returned list
+			visited := make([]bool, len(idx.Commits))
+			var set, baseSet []uint32
+
+			for hash := range jobs {
+				start := idx.positions[hash]
+				set = reachable(parents, start, visited, set)
+
+				base, hasBase := uint32(0), false
+				for _, position := range set[1:] {
+					if !isTagged[position] {
+						continue
+					}
+					candidate, current := idx.Commits[position], idx.Commits[base]
+					if !hasBase || candidate.Generation > current.Generation ||
+						(candidate.Generation == current.Generation && compareHashes(candidate.Hash, current.Hash) < 0) {
+						base, hasBase = position, true
+					}
+				}
+				clearVisited(visited, set)
+
+				entry := indexedSet{}
+				if hasBase {
+					entry.Base = idx.Commits[base].Hash
+					baseSet = reachable(parents, base, visited, baseSet)
+					for _, position := range set {
+						if !visited[position] {
+							entry.Added = append(entry.Added, position)
+						}
+					}
+					clearVisited(visited, baseSet)
+				} else {
+					entry.Added = slices.Clone(set)
+				}
+				slices.Sort(entry.Added)
+
+				mu.Lock()
+				idx.Sets[hash] = entry
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for _, hash := range tagged {
+		jobs <- hash
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// reachable returns the positions reachable from start, start first, marking them as visited
+func reachable(parents [][]uint32, start uint32, visited []bool, set []uint32) []uint32 {
+	set = append(set[:0], start)
+	visited[start] = true
+	for i := 0; i < len(set); i++ {
+		for _, parent := range parents[set[i]] {
+			if !visited[parent] {
+				visited[parent] = true
+				set = append(set, parent)
+			}
+		}
+	}
+	return set
+}
+
+// clearVisited unmarks the positions of a set returned by reachable
+func clearVisited(visited []bool, set []uint32) {
+	for _, position := range set {
+		visited[position] = false
+	}
+}
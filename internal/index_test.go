@@ -0,0 +1,187 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// newIndexFixture creates a history with a release branch, a cherry-picked fix, a merge, and an unrelated root
+func newIndexFixture(t *testing.T) *testutil.RepoBuilder {
+	t.Helper()
+	return testutil.NewRepo(t).
+		Commit("Initial", testutil.File("a.go", "package a\n")).
+		AnnotatedTag("v1.0.0", "Release 1.0.0").
+		Branch("release/1.0").
+		Checkout("release/1.0").
+		Commit("Fix crash", testutil.File("fix.go", "package a\n\nconst Fixed = true\n")).
+		Tag("v1.0.1").
+		Checkout("main").
+		Commit("Add feature", testutil.File("feature.go", "package a\n")).
+		Commit("Fix crash", testutil.File("fix.go", "package a\n\nconst Fixed = true\n")).
+		Tag("v1.1.0").
+		Branch("topic").
+		Checkout("topic").
+		Commit("Add topic", testutil.File("topic.go", "package a\n")).
+		Checkout("main").
+		Commit("Update feature", testutil.File("feature.go", "package a\n\nconst Feature = 2\n")).
+		Merge("topic", "Merge topic").
+		AnnotatedTag("v2.0.0", "Release 2.0.0").
+		Orphan("docs").
+		Commit("Docs", testutil.File("README.md", "# Docs\n")).
+		Tag("docs-1")
+}
+
+// buildIndex runs the index command on the fixture
+func buildIndex(t *testing.T, fixture *testutil.RepoBuilder) IndexStats {
+	t.Helper()
+	stats, err := IndexRepository(context.Background(), IndexConfig{RepoPath: fixture.Path()})
+	if err != nil {
+		t.Fatalf("IndexRepository() error = %v", err)
+	}
+	return stats
+}
+
+// TestIndex_MatchesTraversal tests that commit sets, patch IDs, and merge bases read from the index
+// equal those computed from the history
+func TestIndex_MatchesTraversal(t *testing.T) {
+	fixture := newIndexFixture(t)
+	tags := []string{"v1.0.0", "v1.0.1", "v1.1.0", "v2.0.0", "docs-1"}
+
+	type answers struct {
+		sets       map[string]any
+		patchIDs   map[string]any
+		mergeBases map[string]any
+	}
+	collect := func(repo *GitRepository) answers {
+		t.Helper()
+		got := answers{sets: map[string]any{}, patchIDs: map[string]any{}, mergeBases: map[string]any{}}
+		for _, tag := range tags {
+			set, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference(tag))
+			if err != nil {
+				t.Fatalf("GetCommitSetForTag(%s) error = %v", tag, err)
+			}
+			got.sets[tag] = set
+
+			ids, err := repo.GetPatchIDs(context.Background(), fixture.Reference(tag), nil)
+			if err != nil {
+				t.Fatalf("GetPatchIDs(%s) error = %v", tag, err)
+			}
+			got.patchIDs[tag] = ids
+
+			for _, other := range tags {
+				pair := tag + ".." + other
+				ids, err := repo.GetPatchIDs(context.Background(), fixture.Reference(other), fixture.Reference(tag))
+				if err != nil {
+					t.Fatalf("GetPatchIDs(%s) error = %v", pair, err)
+				}
+				got.patchIDs[pair] = ids
+
+				base, err := repo.mergeBase(context.Background(), fixture.Hash(tag), fixture.Hash(other))
+				if err != nil {
+					t.Fatalf("mergeBase(%s) error = %v", pair, err)
+				}
+				got.mergeBases[pair] = base
+			}
+		}
+		return got
+	}
+
+	want := collect(openFixture(t, fixture))
+
+	stats := buildIndex(t, fixture)
+	if stats.Tags != len(tags) || stats.Commits != 8 || stats.PatchIDs != 7 {
+		t.Errorf("IndexRepository() = %d tags, %d commits, %d patch IDs, want 5, 8, 7", stats.Tags, stats.Commits, stats.PatchIDs)
+	}
+
+	repo := openFixture(t, fixture)
+	if repo.tagIndex() == nil {
+		t.Fatal("tagIndex() = nil after building the index")
+	}
+	got := collect(repo)
+
+	if !reflect.DeepEqual(got.sets, want.sets) {
+		t.Errorf("indexed commit sets = %v, want %v", got.sets, want.sets)
+	}
+	if !reflect.DeepEqual(got.patchIDs, want.patchIDs) {
+		t.Errorf("indexed patch IDs = %v, want %v", got.patchIDs, want.patchIDs)
+	}
+	if !reflect.DeepEqual(got.mergeBases, want.mergeBases) {
+		t.Errorf("indexed merge bases = %v, want %v", got.mergeBases, want.mergeBases)
+	}
+}
+
+// TestIndex_NewTag tests that a tag created after the index was built is traversed
+func TestIndex_NewTag(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	buildIndex(t, fixture)
+
+	fixture.Commit("Add c endpoint", testutil.File("src/api/c.go", "package api\n")).Tag("v1.2.0")
+
+	repo := openFixture(t, fixture)
+	set, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.2.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() error = %v", err)
+	}
+	if len(set) != 4 {
+		t.Errorf("GetCommitSetForTag() = %d commits, want 4", len(set))
+	}
+	if _, err := repo.GetPatchIDs(context.Background(), fixture.Reference("v1.2.0"), fixture.Reference("v1.1.0")); err != nil {
+		t.Errorf("GetPatchIDs() error = %v", err)
+	}
+}
+
+// TestIndexRepository_Remove tests deleting the index, and that a damaged index is ignored
+func TestIndexRepository_Remove(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	stats := buildIndex(t, fixture)
+
+	if err := os.WriteFile(stats.Path, []byte("not an index"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	repo := openFixture(t, fixture)
+	if repo.tagIndex() != nil {
+		t.Error("tagIndex() loaded a damaged index")
+	}
+	if set, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.1.0")); err != nil || len(set) != 3 {
+		t.Errorf("GetCommitSetForTag() = %d commits, %v, want 3", len(set), err)
+	}
+
+	removed, err := IndexRepository(context.Background(), IndexConfig{RepoPath: fixture.Path(), Remove: true})
+	if err != nil || !removed.Removed {
+		t.Fatalf("IndexRepository(-remove) = %+v, %v, want the index removed", removed, err)
+	}
+	if _, err := os.Stat(stats.Path); !os.IsNotExist(err) {
+		t.Errorf("index still exists after -remove: %v", err)
+	}
+
+	again, err := IndexRepository(context.Background(), IndexConfig{RepoPath: fixture.Path(), Remove: true})
+	if err != nil || again.Removed {
+		t.Errorf("IndexRepository(-remove) without an index = %+v, %v, want nothing removed", again, err)
+	}
+}
+
+// TestIndexRepository_Remote tests indexing the persistent clone of a remote repository
+func TestIndexRepository_Remote(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+	url := "file://" + newReleaseFixture(t).Path()
+
+	stats, err := IndexRepository(context.Background(), IndexConfig{RepoPath: url})
+	if err != nil {
+		t.Fatalf("IndexRepository(%s) error = %v", url, err)
+	}
+	dir, ok, err := existingClone(url)
+	if err != nil || !ok || !strings.HasPrefix(stats.Path, dir) || stats.Tags == 0 {
+		t.Fatalf("IndexRepository(%s) = %+v, want an index in the clone %s", url, stats, dir)
+	}
+
+	removed, err := IndexRepository(context.Background(), IndexConfig{RepoPath: url, Remove: true})
+	if err != nil || !removed.Removed {
+		t.Errorf("IndexRepository(-remove) = %+v, %v, want the index of the clone removed", removed, err)
+	}
+}
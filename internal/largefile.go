@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrInvalidFileSize = errors.New("invalid file size")
+	ErrGetLargeFiles   = errors.New("failed to find binary and large files")
+)
+
+// defaultLargeFileSize is the size from which text files are reported as large files
+const defaultLargeFileSize int64 = 1 << 20
+
+// fileSizeUnits are the binary size suffixes accepted by ParseFileSize, largest first
+var fileSizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{suffix: "G", bytes: 1 << 30},
+	{suffix: "M", bytes: 1 << 20},
+	{suffix: "K", bytes: 1 << 10},
+}
+
+// LargeFileChange is a binary or large file changed between two tags.
+// Such files are listed separately and excluded from the diff stat, so a single checked-in
+// artifact does not dwarf the line counts.
+type LargeFileChange struct {
+	Path    string `json:"path"`
+	Binary  bool   `json:"binary"`
+	OldSize int64  `json:"old_size"` // Size in the first tag; 0 when the file was added
+	NewSize int64  `json:"new_size"` // Size in the second tag; 0 when the file was deleted
+}
+
+// SizeDelta returns the change in size from the first to the second tag
+func (c LargeFileChange) SizeDelta() int64 {
+	return c.NewSize - c.OldSize
+}
+
+// ParseFileSize converts a size such as "512K", "10M", "1G", or a plain number of bytes into bytes
+func ParseFileSize(value string) (int64, error) {
+	text := strings.ToUpper(strings.TrimSpace(value))
+	text = strings.TrimSuffix(strings.TrimSuffix(text, "B"), "I")
+
+	multiplier := int64(1)
+	for _, unit := range fileSizeUnits {
+		if number, ok := strings.CutSuffix(text, unit.suffix); ok {
+			text, multiplier = number, unit.bytes
+			break
+		}
+	}
+
+	size, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64)
+	if err != nil || size < 0 {
+		return 0, errors.Join(ErrInvalidFileSize, fmt.Errorf("expected a non-negative size such as 512K, 10M, or 1G: %s", value))
+	}
+	return size * multiplier, nil
+}
+
+// formatFileSize formats a number of bytes with a binary unit, e.g. "1.5 MiB"
+func formatFileSize(size int64) string {
+	for _, unit := range fileSizeUnits {
+		if size >= unit.bytes {
+			return fmt.Sprintf("%.1f %siB", float64(size)/float64(unit.bytes), unit.suffix)
+		}
+	}
+	return fmt.Sprintf("%d B", size)
+}
+
+// formatSizeDelta formats a size change with an explicit sign
+func formatSizeDelta(delta int64) string {
+	if delta >= 0 {
+		return "+" + formatFileSize(delta)
+	}
+	return "-" + formatFileSize(-delta)
+}
+
+// FindLargeFileChanges returns the binary files and the files of at least threshold bytes in either tag
+// among the files changed between two tags, ordered by path. A threshold of 0 only reports binary files.
+func FindLargeFileChanges(ctx context.Context, repo Repository, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, threshold int64) ([]LargeFileChange, error) {
+	// Renames are reported as delete/add pairs so every path exists in at most one side
+	numstat, err := repo.GetDiffBetweenTags(ctx, tag1, tag2, pathspecs, "--numstat", "-z", "--no-renames")
+	if err != nil {
+		return nil, errors.Join(ErrGetLargeFiles, err)
+	}
+
+	oldSizes, err := repo.GetFileSizes(ctx, tag1)
+	if err != nil {
+		return nil, errors.Join(ErrGetLargeFiles, err)
+	}
+	newSizes, err := repo.GetFileSizes(ctx, tag2)
+	if err != nil {
+		return nil, errors.Join(ErrGetLargeFiles, err)
+	}
+
+	var changes []LargeFileChange
+	for _, entry := range parseNumstat(numstat) {
+		change := LargeFileChange{
+			Path:    entry.Path,
+			Binary:  entry.Binary,
+			OldSize: oldSizes[entry.Path],
+			NewSize: newSizes[entry.Path],
+		}
+		if change.Binary || (threshold > 0 && max(change.OldSize, change.NewSize) >= threshold) {
+			changes = append(changes, change)
+		}
+	}
+
+	sort.Slice(changes, func(i int, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+// excludeLargeFiles adds exclude pathspecs for the given files, so line-based diff output skips them
+func excludeLargeFiles(pathspecs []string, changes []LargeFileChange) []string {
+	if len(changes) == 0 {
+		return pathspecs
+	}
+
+	excluded := append([]string(nil), pathspecs...)
+	for _, change := range changes {
+		excluded = append(excluded, ":(exclude,literal)"+change.Path)
+	}
+	return excluded
+}
+
+// writeLargeFileChanges writes the binary and large files as a table with their size deltas
+func writeLargeFileChanges(w io.Writer, changes []LargeFileChange, output OutputOptions) {
+	if len(changes) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "\nBinary and large files (%d, excluded from the stat above):\n", len(changes))
+	rows := [][]string{{"  PATH", "TYPE", "OLD SIZE", "NEW SIZE", "DELTA"}}
+	for _, change := range changes {
+		kind := "large"
+		if change.Binary {
+			kind = "binary"
+		}
+		rows = append(rows, []string{
+			"  " + change.Path,
+			kind,
+			formatFileSize(change.OldSize),
+			formatFileSize(change.NewSize),
+			formatSizeDelta(change.SizeDelta()),
+		})
+	}
+	writeTable(w, output, 0, rows)
+}
@@ -0,0 +1,119 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestParseFileSize tests parsing of the -large-file-size flag
+func TestParseFileSize(t *testing.T) {
+	tests := []struct {
+		value     string
+		want      int64
+		wantError error
+	}{
+		{value: "0", want: 0},
+		{value: "4096", want: 4096},
+		{value: "512K", want: 512 << 10},
+		{value: "10m", want: 10 << 20},
+		{value: "1GiB", want: 1 << 30},
+		{value: "1.5M", wantError: ErrInvalidFileSize},
+		{value: "-1", wantError: ErrInvalidFileSize},
+		{value: "big", wantError: ErrInvalidFileSize},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseFileSize(tt.value)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ParseFileSize() error = %v, want %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("ParseFileSize() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFormatSizeDelta tests formatting of file sizes and size changes
+func TestFormatSizeDelta(t *testing.T) {
+	tests := []struct {
+		delta int64
+		want  string
+	}{
+		{delta: 0, want: "+0 B"},
+		{delta: 1536, want: "+1.5 KiB"},
+		{delta: -3 << 20, want: "-3.0 MiB"},
+	}
+
+	for _, tt := range tests {
+		if got := formatSizeDelta(tt.delta); got != tt.want {
+			t.Errorf("formatSizeDelta(%d) = %q, want %q", tt.delta, got, tt.want)
+		}
+	}
+}
+
+// TestFindLargeFileChanges tests that binary and large files are found and excluded from the stat
+func TestFindLargeFileChanges(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n"), testutil.File("assets/logo.png", "\x89PNG\x00\x01")).
+		Tag("v1.0.0").
+		Commit("Update assets", testutil.File("assets/logo.png", "\x89PNG\x00\x02\x03"), testutil.File("data/fixture.json", strings.Repeat("{}\n", 100))).
+		Commit("Update docs", testutil.File("README.md", "readme v2\n")).
+		Tag("v2.0.0")
+	repo := openFixture(t, fixture)
+	tag1, tag2 := fixture.Reference("v1.0.0"), fixture.Reference("v2.0.0")
+
+	tests := []struct {
+		name      string
+		threshold int64
+		want      []LargeFileChange
+	}{
+		{
+			name: "Binary only",
+			want: []LargeFileChange{{Path: "assets/logo.png", Binary: true, OldSize: 6, NewSize: 7}},
+		},
+		{
+			name:      "Binary and large",
+			threshold: 200,
+			want: []LargeFileChange{
+				{Path: "assets/logo.png", Binary: true, OldSize: 6, NewSize: 7},
+				{Path: "data/fixture.json", NewSize: 300},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindLargeFileChanges(context.Background(), repo, tag1, tag2, nil, tt.threshold)
+			if err != nil {
+				t.Fatalf("FindLargeFileChanges() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("FindLargeFileChanges() = %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("FindLargeFileChanges()[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+
+			stat, err := repo.GetDiffBetweenTags(context.Background(), tag1, tag2, excludeLargeFiles(nil, got), "--name-only")
+			if err != nil {
+				t.Fatalf("GetDiffBetweenTags() error = %v", err)
+			}
+			for _, change := range got {
+				if strings.Contains(stat, change.Path) {
+					t.Errorf("GetDiffBetweenTags() = %q, want %s excluded", stat, change.Path)
+				}
+			}
+			if !strings.Contains(stat, "README.md") {
+				t.Errorf("GetDiffBetweenTags() = %q, want README.md", stat)
+			}
+		})
+	}
+}
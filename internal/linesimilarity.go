@@ -0,0 +1,42 @@
+package internal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrLineSimilarity = errors.New("failed to compute line similarity")
+
+// LineSimilarity compares the code of two tags by the diff between their trees, ignoring history.
+// The lines of the first tag that the diff does not delete are shared by both tags, so the similarity is
+// the Jaccard index of the lines: unchanged / (unchanged + added + deleted). It is symmetric, 1 for
+// identical trees, and 0 when every line changed. Binary files have no lines and are ignored.
+func LineSimilarity(ctx context.Context, repo Repository, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string) (float64, error) {
+	numstat, err := repo.GetDiffBetweenTags(ctx, tag1, tag2, pathspecs, "--numstat", "-z", "--no-renames")
+	if err != nil {
+		return 0, errors.Join(ErrLineSimilarity, err)
+	}
+	tag1Lines, err := repo.GetLineCount(ctx, tag1, pathspecs)
+	if err != nil {
+		return 0, errors.Join(ErrLineSimilarity, err)
+	}
+
+	added, deleted := 0, 0
+	for _, entry := range parseNumstat(numstat) {
+		added += entry.Added
+		deleted += entry.Deleted
+	}
+	return lineJaccard(tag1Lines, added, deleted), nil
+}
+
+// lineJaccard returns the Jaccard index of the lines of two trees from the line count of the first
+// and the lines the diff to the second adds and deletes
+func lineJaccard(tag1Lines int, added int, deleted int) float64 {
+	unchanged := tag1Lines - deleted
+	if unchanged+added+deleted == 0 {
+		return 1.0 // Both trees have no lines
+	}
+	return float64(unchanged) / float64(unchanged+added+deleted)
+}
@@ -0,0 +1,76 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestLineJaccard tests the line similarity computed from line counts
+func TestLineJaccard(t *testing.T) {
+	tests := []struct {
+		name                      string
+		tag1Lines, added, deleted int
+		want                      float64
+	}{
+		{name: "Identical trees", tag1Lines: 10, want: 1},
+		{name: "Both empty", want: 1},
+		{name: "Every line replaced", tag1Lines: 4, added: 4, deleted: 4, want: 0},
+		{name: "Lines added", tag1Lines: 6, added: 2, want: 0.75},
+		{name: "Lines modified", tag1Lines: 4, added: 1, deleted: 1, want: 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lineJaccard(tt.tag1Lines, tt.added, tt.deleted); got != tt.want {
+				t.Errorf("lineJaccard(%d, %d, %d) = %v, want %v", tt.tag1Lines, tt.added, tt.deleted, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompare_LinesMetric tests the line similarity of tags whose trees differ in a few lines
+func TestCompare_LinesMetric(t *testing.T) {
+	// v1.1.0 modifies one of four lines, adds a two-line file in src and another outside it, and adds a binary file
+	fixture := testutil.NewRepo(t).
+		Commit("Add a", testutil.File("src/a.go", "package a\n\nconst A = 1\nconst B = 2\n")).
+		Tag("v1.0.0").
+		Commit("Change B", testutil.File("src/a.go", "package a\n\nconst A = 1\nconst B = 3\n")).
+		Commit("Add c", testutil.File("src/c.go", "package a\n\n"), testutil.File("docs/c.md", "# C\n\n"), testutil.File("logo.png", "\x89PNG\x00\x01")).
+		Tag("v1.1.0")
+
+	tests := []struct {
+		name      string
+		directory string
+		want      float64
+	}{
+		{name: "Whole tree", want: 3.0 / 9.0},
+		{name: "Directory filter", directory: "src", want: 3.0 / 7.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+				Directory:  tt.directory,
+				Metric:     MetricLines,
+			})
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if result.LineSimilarity == nil || *result.LineSimilarity != tt.want {
+				t.Fatalf("LineSimilarity = %v, want %v", result.LineSimilarity, tt.want)
+			}
+		})
+	}
+
+	repo := openFixture(t, fixture)
+	lines, err := repo.GetLineCount(context.Background(), fixture.Reference("v1.1.0"), nil)
+	if err != nil {
+		t.Fatalf("GetLineCount() error = %v", err)
+	}
+	if lines != 8 {
+		t.Errorf("GetLineCount() = %d, want 8 lines without the binary file", lines)
+	}
+}
@@ -0,0 +1,19 @@
+package internal
+
+import (
+	"os"
+	"testing"
+)
+
+// TestMain keeps the commit set cache of the tests out of the user cache directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "git-tag-similarity-cache-")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv(CacheDirEnv, dir)
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,320 @@
+package internal
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrTooFewTags          = errors.New("at least two tags are required")
+	ErrInvalidMatrixFormat = errors.New("invalid matrix format")
+)
+
+// MatrixFormat is the output format of the matrix command
+type MatrixFormat string
+
+const (
+	MatrixFormatText MatrixFormat = "text"
+	MatrixFormatCSV  MatrixFormat = "csv"
+)
+
+// ParseMatrixFormat validates a -format value of the matrix command
+func ParseMatrixFormat(value string) (MatrixFormat, error) {
+	switch MatrixFormat(value) {
+	case MatrixFormatText, MatrixFormatCSV:
+		return MatrixFormat(value), nil
+	default:
+		return "", errors.Join(ErrInvalidMatrixFormat, fmt.Errorf("unknown format: %s (expected text or csv)", value))
+	}
+}
+
+// MatrixConfig holds the configuration of the matrix command
+type MatrixConfig struct {
+	Command   Command
+	RepoPath  string
+	Tags      []string // Tags to compare, in matrix order; empty means all tags matching Pattern
+	Pattern   string   // Glob matched against tag names (e.g. "v*"), ordered oldest release first
+	Directory string
+	Paths     DirectoryFilter // Several directories, globs, or exclusions, in addition to Directory
+	SortBy    SortStrategy
+	Format    MatrixFormat
+	Output    OutputOptions
+	NoCache   bool          // Traverse every tag instead of reading and storing commit sets in the cache
+	Timeout   time.Duration // Cancel the matrix after this long; 0 never times out
+}
+
+// matrixUsage is the help of the matrix command
+var matrixUsage = commandUsage{
+	Name:        "matrix",
+	Summary:     "Compare every pair of several tags in one run",
+	Description: "Compute the similarity of every pair of tags in a single run and print an N×N matrix,\nto see how a family of release tags relate.",
+	Examples: []string{
+		"matrix -repo /path/to/repo -tags v1.0.0,v1.1.0,v2.0.0",
+		"matrix -repo /path/to/repo -pattern 'v2.*' -d src/api",
+		"matrix -repo /path/to/repo -tags latest-3,latest-2,latest-1,latest -format csv",
+	},
+}
+
+// NewMatrixConfig parses the matrix command flags
+func NewMatrixConfig(args []string) (MatrixConfig, error) {
+	config := MatrixConfig{Command: MatrixCommand}
+	var tags, sortBy, format string
+
+	matrixCmd := newCommandFlagSet(matrixUsage)
+	matrixCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	matrixCmd.StringVar(&tags, "tags", "", "Comma-separated tags to compare pairwise (or latest, latest-N)")
+	matrixCmd.StringVar(&config.Pattern, "pattern", "", "Compare all tags matching this glob (e.g. 'v1.*'); ignored when -tags is set")
+	config.Paths.registerFlags(matrixCmd, "Directory path to filter commits (only commits touching this directory)")
+	matrixCmd.StringVar(&sortBy, "sort", string(SortBySemver), "Order of tags selected with -pattern and of latest-N references (semver, date)")
+	matrixCmd.StringVar(&format, "format", string(MatrixFormatText), "Output format (text, csv)")
+	parseOutputOptions := config.Output.registerFlags(matrixCmd, "Maximum line width of the matrix")
+	matrixCmd.BoolVar(&config.NoCache, "no-cache", false, "Bypass the commit set cache, as compare -no-cache")
+	registerTimeoutFlag(matrixCmd, &config.Timeout, "the matrix")
+
+	if err := matrixCmd.Parse(args); err != nil {
+		return config, err
+	}
+	config.Directory, config.Paths = splitDirectoryFilter(config.Paths)
+
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			config.Tags = append(config.Tags, tag)
+		}
+	}
+
+	strategy, err := ParseSortStrategy(sortBy)
+	if err != nil {
+		return config, err
+	}
+	config.SortBy = strategy
+
+	matrixFormat, err := ParseMatrixFormat(format)
+	if err != nil {
+		return config, err
+	}
+	config.Format = matrixFormat
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *MatrixConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+
+	if len(c.Tags) == 1 {
+		return errors.Join(ErrTooFewTags, fmt.Errorf("-tags lists only %s", c.Tags[0]))
+	}
+
+	if c.Pattern != "" {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return errors.Join(ErrInvalidTagPattern, err)
+		}
+	}
+
+	if _, err := cleanDirectory(c.Directory); err != nil {
+		return err
+	}
+	if err := c.Paths.Validate(); err != nil {
+		return err
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SimilarityMatrix holds the pairwise similarity of a list of tags
+type SimilarityMatrix struct {
+	Tags         []string
+	Commits      []int       // Number of commits reachable from each tag
+	Similarities [][]float64 // Similarities[i][j] is the Jaccard similarity of Tags[i] and Tags[j]
+}
+
+// ComputeMatrix computes the pairwise similarity of the configured tags
+func ComputeMatrix(ctx context.Context, config MatrixConfig) (_ SimilarityMatrix, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return SimilarityMatrix{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+	config.Directory, _ = cleanDirectory(config.Directory)
+	config.Paths, _ = config.Paths.clean()
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return SimilarityMatrix{}, errors.Join(ErrOpenRepository, err)
+	}
+	if !config.NoCache {
+		repo.enableCommitSetCache()
+	}
+
+	return computeMatrix(ctx, repo, config)
+}
+
+// computeMatrix traverses each tag once and compares the commit sets of every pair
+func computeMatrix(ctx context.Context, repo Repository, config MatrixConfig) (SimilarityMatrix, error) {
+	selected, err := selectTags(repo, config.Tags, config.Pattern, config.SortBy)
+	if err != nil {
+		return SimilarityMatrix{}, err
+	}
+
+	refs := selected
+	if len(config.Tags) == 0 {
+		// Order tags selected by pattern oldest release first; tags the strategy cannot order go last
+		if refs, err = releaseOrder(repo, selected, config.SortBy); err != nil {
+			return SimilarityMatrix{}, err
+		}
+	}
+	if len(refs) < 2 {
+		return SimilarityMatrix{}, errors.Join(ErrTooFewTags, fmt.Errorf("only %d tag matches pattern: %q", len(refs), config.Pattern))
+	}
+
+	if err := validateDirectoryFilterInTags(repo, mergeDirectoryFilter(config.Directory, config.Paths), refs...); err != nil {
+		return SimilarityMatrix{}, errors.Join(ErrValidationFailed, err)
+	}
+
+	matrix := SimilarityMatrix{
+		Tags:         make([]string, len(refs)),
+		Commits:      make([]int, len(refs)),
+		Similarities: make([][]float64, len(refs)),
+	}
+	commits, err := matrixCommits(ctx, repo, refs, config)
+	if err != nil {
+		return matrix, errors.Join(ErrGetCommits, err)
+	}
+	for i, ref := range refs {
+		matrix.Tags[i] = ref.Name().Short()
+		matrix.Commits[i] = len(commits[i])
+	}
+
+	for i := range refs {
+		matrix.Similarities[i] = make([]float64, len(refs))
+	}
+	for i := range refs {
+		matrix.Similarities[i][i] = 1
+		for j := i + 1; j < len(refs); j++ {
+			similarity := CalculateSortedJaccardSimilarity(slices.Values(commits[i]), slices.Values(commits[j]))
+			matrix.Similarities[i][j] = similarity
+			matrix.Similarities[j][i] = similarity
+		}
+	}
+
+	return matrix, nil
+}
+
+// matrixCommits traverses the tags with a bounded pool of workers sharing the repository and returns
+// the commits of each tag in the order of refs. Every tag's commits are held at once, so they are kept
+// as sorted slices rather than sets.
+func matrixCommits(ctx context.Context, repo Repository, refs []*plumbing.Reference, config MatrixConfig) ([][]plumbing.Hash, error) {
+	commits := make([][]plumbing.Hash, len(refs))
+	errs := make([]error, len(refs))
+
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for range min(commitLoadWorkers, len(refs)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				// Each index is written by exactly one worker
+				var set map[plumbing.Hash]struct{}
+				switch {
+				case !config.Paths.IsZero():
+					set, errs[i] = repo.GetCommitSetForTagFilteredByPathspecs(ctx, refs[i], mergeDirectoryFilter(config.Directory, config.Paths).Pathspecs())
+					commits[i] = sortedHashes(set)
+				case config.Directory != "":
+					set, errs[i] = repo.GetCommitSetForTagFilteredByDirectory(ctx, refs[i], config.Directory)
+					commits[i] = sortedHashes(set)
+				default:
+					commits[i], errs[i] = SortedCommitHashes(ctx, repo, refs[i])
+				}
+			}
+		}()
+	}
+
+	for i := range refs {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return commits, nil
+}
+
+// releaseOrder sorts tags oldest release first, appending the tags the strategy cannot order
+func releaseOrder(repo Repository, refs []*plumbing.Reference, sortBy SortStrategy) ([]*plumbing.Reference, error) {
+	sorted, err := SortTags(repo, refs, sortBy)
+	if err != nil {
+		return nil, err
+	}
+
+	ordered := make(map[plumbing.ReferenceName]struct{}, len(sorted))
+	result := make([]*plumbing.Reference, 0, len(refs))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		ordered[sorted[i].Name()] = struct{}{}
+		result = append(result, sorted[i])
+	}
+	for _, ref := range refs {
+		if _, ok := ordered[ref.Name()]; !ok {
+			result = append(result, ref)
+		}
+	}
+	return result, nil
+}
+
+// PrintMatrix prints the similarity matrix as a table of percentages or as CSV with fractions
+func PrintMatrix(w io.Writer, matrix SimilarityMatrix, format MatrixFormat, output OutputOptions) error {
+	if format == MatrixFormatCSV {
+		writer := csv.NewWriter(w)
+		if err := writer.Write(append([]string{"tag"}, matrix.Tags...)); err != nil {
+			return err
+		}
+		for i, tag := range matrix.Tags {
+			row := []string{tag}
+			for _, similarity := range matrix.Similarities[i] {
+				row = append(row, fmt.Sprintf("%.4f", similarity))
+			}
+			if err := writer.Write(row); err != nil {
+				return err
+			}
+		}
+		writer.Flush()
+		return writer.Error()
+	}
+
+	rows := [][]string{append([]string{"TAG", "COMMITS"}, matrix.Tags...)}
+	for i, tag := range matrix.Tags {
+		row := []string{tag, fmt.Sprintf("%d", matrix.Commits[i])}
+		for _, similarity := range matrix.Similarities[i] {
+			row = append(row, fmt.Sprintf("%.2f%%", similarity*100.0))
+		}
+		rows = append(rows, row)
+	}
+	writeTable(w, output, 0, rows)
+	return nil
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestComputeMatrix tests pairwise similarities of the fixture tags
+func TestComputeMatrix(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Commit("Add c endpoint", testutil.File("src/api/c.go", "package api\n")).
+		Tag("v1.2.0")
+	repo := openFixture(t, fixture)
+
+	tests := []struct {
+		name    string
+		config  MatrixConfig
+		tags    []string
+		commits []int
+		row     []float64 // Similarities of the first tag
+	}{
+		{
+			name:    "pattern in release order",
+			config:  MatrixConfig{Pattern: "v1.*", SortBy: SortBySemver},
+			tags:    []string{"v1.0.0", "v1.1.0", "v1.2.0"},
+			commits: []int{1, 3, 4},
+			row:     []float64{1, 1.0 / 3.0, 0.25},
+		},
+		{
+			name:    "tags in given order",
+			config:  MatrixConfig{Tags: []string{"latest", "v1.0.0"}, SortBy: SortBySemver},
+			tags:    []string{"v1.2.0", "v1.0.0"},
+			commits: []int{4, 1},
+			row:     []float64{1, 0.25},
+		},
+		{
+			name:    "directory filter",
+			config:  MatrixConfig{Tags: []string{"v1.0.0", "v1.1.0"}, Directory: "internal", SortBy: SortBySemver},
+			tags:    []string{"v1.0.0", "v1.1.0"},
+			commits: []int{1, 2},
+			row:     []float64{1, 0.5},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matrix, err := computeMatrix(context.Background(), repo, tt.config)
+			if err != nil {
+				t.Fatalf("computeMatrix() error = %v", err)
+			}
+			if !reflect.DeepEqual(matrix.Tags, tt.tags) {
+				t.Errorf("Tags = %v, want %v", matrix.Tags, tt.tags)
+			}
+			if !reflect.DeepEqual(matrix.Commits, tt.commits) {
+				t.Errorf("Commits = %v, want %v", matrix.Commits, tt.commits)
+			}
+			if !reflect.DeepEqual(matrix.Similarities[0], tt.row) {
+				t.Errorf("Similarities[0] = %v, want %v", matrix.Similarities[0], tt.row)
+			}
+			for i := range matrix.Tags {
+				for j := range matrix.Tags {
+					if matrix.Similarities[i][j] != matrix.Similarities[j][i] {
+						t.Errorf("matrix is not symmetric at %d, %d", i, j)
+					}
+				}
+			}
+		})
+	}
+
+	if _, err := computeMatrix(context.Background(), repo, MatrixConfig{Pattern: "v1.0.*", SortBy: SortBySemver}); !errors.Is(err, ErrTooFewTags) {
+		t.Errorf("computeMatrix() with a single matching tag error = %v, want ErrTooFewTags", err)
+	}
+}
+
+// TestPrintMatrix_CSV tests the CSV form of the matrix
+func TestPrintMatrix_CSV(t *testing.T) {
+	matrix := SimilarityMatrix{
+		Tags:         []string{"v1.0.0", "v2.0.0"},
+		Commits:      []int{2, 4},
+		Similarities: [][]float64{{1, 0.5}, {0.5, 1}},
+	}
+
+	var buf bytes.Buffer
+	if err := PrintMatrix(&buf, matrix, MatrixFormatCSV, OutputOptions{}); err != nil {
+		t.Fatalf("PrintMatrix() error = %v", err)
+	}
+
+	want := "tag,v1.0.0,v2.0.0\nv1.0.0,1.0000,0.5000\nv2.0.0,0.5000,1.0000\n"
+	if buf.String() != want {
+		t.Errorf("PrintMatrix() = %q, want %q", buf.String(), want)
+	}
+}
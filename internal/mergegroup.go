@@ -0,0 +1,143 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var ErrGroupCommits = errors.New("failed to group commits by merge")
+
+// pullRequestPatterns extract pull/merge request references from commit messages
+var pullRequestPatterns = []struct {
+	pattern     *regexp.Regexp
+	label       string
+	fullMessage bool // Match against the whole message instead of the subject
+}{
+	{pattern: regexp.MustCompile(`^Merge pull request #(\d+)`), label: "PR #"},                          // GitHub merge commit
+	{pattern: regexp.MustCompile(`(?m)^See merge request \S*!(\d+)`), label: "MR !", fullMessage: true}, // GitLab merge commit
+	{pattern: regexp.MustCompile(`\(#(\d+)\)\s*$`), label: "PR #"},                                      // Squash merge subject, e.g. "Add API (#123)"
+}
+
+// CommitGroup is a set of unique commits brought in by the same first-parent commit
+type CommitGroup struct {
+	Head    *object.Commit   // The merge that introduced the commits, or the single commit itself
+	Commits []*object.Commit // Commits of the set in the group, newest first; includes Head only when it is in the set
+}
+
+// IsMerge reports whether the group stands for a merged branch rather than a single commit
+func (g CommitGroup) IsMerge() bool {
+	return len(g.Head.ParentHashes) > 1
+}
+
+// MergedCommits returns the number of commits the group's merge brought in, not counting the merge itself
+func (g CommitGroup) MergedCommits() int {
+	count := 0
+	for _, commit := range g.Commits {
+		if commit.Hash != g.Head.Hash {
+			count++
+		}
+	}
+	return count
+}
+
+// Label names the group after its pull request, if the messages reference one, or its merge commit
+func (g CommitGroup) Label() string {
+	if number, label := pullRequestReference(g.Head.Message); number != "" {
+		return label + number
+	}
+	return "Merge " + g.Head.Hash.String()[:7]
+}
+
+// Title returns the line describing the group: the pull request title for merges, or the commit subject
+func (g CommitGroup) Title() string {
+	lines := strings.Split(strings.TrimSpace(g.Head.Message), "\n")
+	if g.IsMerge() && strings.HasPrefix(lines[0], "Merge ") {
+		// GitHub and GitLab put the pull request title after the generated subject
+		for _, line := range lines[1:] {
+			if line = strings.TrimSpace(line); line != "" {
+				return line
+			}
+		}
+	}
+	return lines[0]
+}
+
+// pullRequestReference returns the pull request number found in a message and its label prefix
+func pullRequestReference(message string) (string, string) {
+	subject := strings.Split(message, "\n")[0]
+	for _, p := range pullRequestPatterns {
+		text := subject
+		if p.fullMessage {
+			text = message
+		}
+		if match := p.pattern.FindStringSubmatch(text); match != nil {
+			return match[1], p.label
+		}
+	}
+	return "", ""
+}
+
+// GroupCommitsByMerge groups the commits of a set by the first-parent commit of tag that introduced them.
+// Groups are ordered newest first by the date of their head commit.
+func GroupCommitsByMerge(ctx context.Context, repo Repository, tag *plumbing.Reference, commitSet map[plumbing.Hash]struct{}) ([]CommitGroup, error) {
+	introducedBy, err := repo.GetIntroducingCommits(ctx, tag)
+	if err != nil {
+		return nil, errors.Join(ErrGroupCommits, err)
+	}
+
+	// Commits outside the tag's readable history form their own groups
+	members := make(map[plumbing.Hash][]plumbing.Hash)
+	for hash := range commitSet {
+		head, ok := introducedBy[hash]
+		if !ok {
+			head = hash
+		}
+		members[head] = append(members[head], hash)
+	}
+
+	heads := make([]plumbing.Hash, 0, len(members))
+	for head := range members {
+		heads = append(heads, head)
+	}
+	headCommits, err := repo.GetCommitObjects(heads)
+	if err != nil {
+		return nil, errors.Join(ErrGroupCommits, err)
+	}
+
+	groups := make([]CommitGroup, 0, len(heads))
+	for i, head := range heads {
+		commits, err := repo.GetCommitObjects(members[head])
+		if err != nil {
+			return nil, errors.Join(ErrGroupCommits, fmt.Errorf("commits merged by %s", head), err)
+		}
+		sortCommitsNewestFirst(commits)
+		groups = append(groups, CommitGroup{Head: headCommits[i], Commits: commits})
+	}
+
+	sort.Slice(groups, func(i int, j int) bool {
+		return newerCommit(groups[i].Head, groups[j].Head)
+	})
+	return groups, nil
+}
+
+// sortCommitsNewestFirst orders commits by author date, breaking ties by hash
+func sortCommitsNewestFirst(commits []*object.Commit) {
+	sort.Slice(commits, func(i int, j int) bool {
+		return newerCommit(commits[i], commits[j])
+	})
+}
+
+// newerCommit reports whether a was authored after b, breaking ties by hash
+func newerCommit(a *object.Commit, b *object.Commit) bool {
+	if !a.Author.When.Equal(b.Author.When) {
+		return a.Author.When.After(b.Author.When)
+	}
+	return a.Hash.String() < b.Hash.String()
+}
@@ -0,0 +1,85 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestPullRequestReference tests extracting pull request numbers from commit messages
+func TestPullRequestReference(t *testing.T) {
+	tests := []struct {
+		message    string
+		wantNumber string
+		wantLabel  string
+	}{
+		{message: "Merge pull request #34 from user/feature\n\nAdd b endpoint", wantNumber: "34", wantLabel: "PR #"},
+		{message: "Merge branch 'feature' into 'main'\n\nAdd b endpoint\n\nSee merge request group/project!12", wantNumber: "12", wantLabel: "MR !"},
+		{message: "Add b endpoint (#56)", wantNumber: "56", wantLabel: "PR #"},
+		{message: "Fix #78 in the parser", wantNumber: "", wantLabel: ""},
+	}
+
+	for _, tt := range tests {
+		number, label := pullRequestReference(tt.message)
+		if number != tt.wantNumber || label != tt.wantLabel {
+			t.Errorf("pullRequestReference(%q) = %q, %q, want %q, %q", tt.message, number, label, tt.wantNumber, tt.wantLabel)
+		}
+	}
+}
+
+// TestGroupCommitsByMerge tests grouping unique commits under the merge that introduced them
+func TestGroupCommitsByMerge(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n")).
+		Tag("v1.0.0").
+		Branch("feature").
+		Checkout("feature").
+		Commit("Add handler", testutil.File("api.go", "package api\n")).
+		Commit("Add test", testutil.File("api_test.go", "package api\n")).
+		Checkout("main").
+		Commit("Update docs", testutil.File("README.md", "readme v2\n")).
+		Merge("feature", "Merge pull request #34 from user/feature\n\nAdd API endpoint").
+		Commit("Bump version (#35)", testutil.File("VERSION", "2\n")).
+		Tag("v2.0.0")
+	repo := openFixture(t, fixture)
+
+	v1, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.0.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() failed: %v", err)
+	}
+	v2, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v2.0.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() failed: %v", err)
+	}
+	for hash := range v1 {
+		delete(v2, hash)
+	}
+
+	groups, err := GroupCommitsByMerge(context.Background(), repo, fixture.Reference("v2.0.0"), v2)
+	if err != nil {
+		t.Fatalf("GroupCommitsByMerge() failed: %v", err)
+	}
+
+	want := []struct {
+		label  string
+		title  string
+		merged int
+	}{
+		{title: "Bump version (#35)"},
+		{label: "PR #34", title: "Add API endpoint", merged: 2},
+		{title: "Update docs"},
+	}
+	if len(groups) != len(want) {
+		t.Fatalf("GroupCommitsByMerge() returned %d groups, want %d", len(groups), len(want))
+	}
+	for i, w := range want {
+		group := groups[i]
+		if group.Title() != w.title || group.MergedCommits() != w.merged {
+			t.Errorf("group %d = %q (%d merged), want %q (%d merged)", i, group.Title(), group.MergedCommits(), w.title, w.merged)
+		}
+		if group.IsMerge() && group.Label() != w.label {
+			t.Errorf("group %d label = %q, want %q", i, group.Label(), w.label)
+		}
+	}
+}
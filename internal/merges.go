@@ -0,0 +1,78 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrInvalidMergeMode = errors.New("invalid merge mode")
+
+// MergeMode determines whether merge commits are counted in the commit sets
+type MergeMode string
+
+const (
+	// MergesInclude counts every commit
+	MergesInclude MergeMode = "include"
+	// MergesExclude leaves out commits with more than one parent, like 'git log --no-merges'
+	MergesExclude MergeMode = "exclude"
+	// MergesOnly counts only commits with more than one parent, like 'git log --merges'
+	MergesOnly MergeMode = "only"
+)
+
+// ParseMergeMode converts a -merges flag value into a MergeMode
+func ParseMergeMode(value string) (MergeMode, error) {
+	switch MergeMode(value) {
+	case MergesInclude, MergesExclude, MergesOnly:
+		return MergeMode(value), nil
+	default:
+		return "", errors.Join(ErrInvalidMergeMode, fmt.Errorf("unknown merge mode: %s (expected include, exclude, or only)", value))
+	}
+}
+
+// IsZero reports whether the mode counts every commit
+func (m MergeMode) IsZero() bool {
+	return m == "" || m == MergesInclude
+}
+
+// Description explains the mode in the console output
+func (m MergeMode) Description() string {
+	switch m {
+	case MergesExclude:
+		return "excluded (like git log --no-merges)"
+	case MergesOnly:
+		return "only (like git log --merges)"
+	default:
+		return "included"
+	}
+}
+
+// mergeModeField returns the mode saved with results, which is empty when every commit is counted
+func mergeModeField(m MergeMode) string {
+	if m.IsZero() {
+		return ""
+	}
+	return string(m)
+}
+
+// Apply returns the commits of the set that the mode counts
+func (m MergeMode) Apply(repo Repository, set map[plumbing.Hash]struct{}) (map[plumbing.Hash]struct{}, error) {
+	if m.IsZero() {
+		return set, nil
+	}
+
+	hashes := hashesOf(set)
+	commits, err := repo.GetCommitObjects(hashes)
+	if err != nil {
+		return nil, errors.Join(ErrGetCommits, err)
+	}
+
+	filtered := make(map[plumbing.Hash]struct{})
+	for i, commit := range commits {
+		if isMerge := len(commit.ParentHashes) > 1; isMerge == (m == MergesOnly) {
+			filtered[hashes[i]] = struct{}{}
+		}
+	}
+	return filtered, nil
+}
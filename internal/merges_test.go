@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestParseMergeMode tests the accepted -merges values
+func TestParseMergeMode(t *testing.T) {
+	if mode, err := ParseMergeMode("exclude"); err != nil || mode != MergesExclude {
+		t.Errorf("ParseMergeMode(exclude) = %q, %v", mode, err)
+	}
+	if _, err := ParseMergeMode("no-merges"); !errors.Is(err, ErrInvalidMergeMode) {
+		t.Errorf("ParseMergeMode(no-merges) error = %v, want ErrInvalidMergeMode", err)
+	}
+}
+
+// TestCompare_Merges tests that merge commits are counted, left out, or counted alone
+func TestCompare_Merges(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial commit", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Branch("feature").
+		Checkout("feature").
+		Commit("Add b", testutil.File("b.go", "package b\n")).
+		Checkout("main").
+		Merge("feature", "Merge branch 'feature'").
+		Tag("v2.0.0")
+
+	tests := []struct {
+		name       string
+		merges     MergeMode
+		wantShared int
+		wantOnly2  int
+	}{
+		{name: "Include", merges: MergesInclude, wantShared: 1, wantOnly2: 2},
+		{name: "Exclude", merges: MergesExclude, wantShared: 1, wantOnly2: 1},
+		{name: "Only", merges: MergesOnly, wantShared: 0, wantOnly2: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+				Merges:     tt.merges,
+			})
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if len(result.SharedCommits) != tt.wantShared || len(result.OnlyInTag2) != tt.wantOnly2 {
+				t.Errorf("Compare() shared = %d, only in tag2 = %d, want %d, %d", len(result.SharedCommits), len(result.OnlyInTag2), tt.wantShared, tt.wantOnly2)
+			}
+			if tt.merges == MergesOnly {
+				if _, ok := result.OnlyInTag2[fixture.Hash("v2.0.0")]; !ok {
+					t.Errorf("OnlyInTag2 = %v, want the merge commit", result.OnlyInTag2)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,100 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrInvalidMetric     = errors.New("invalid similarity metric")
+	ErrMessageSimilarity = errors.New("failed to compute message similarity")
+)
+
+// SimilarityMetric selects the similarity computed in addition to the commit-based similarity
+type SimilarityMetric string
+
+const (
+	// MetricCommits is the Jaccard similarity of the commit sets, which is always computed
+	MetricCommits SimilarityMetric = "commits"
+	// MetricMessage also compares the commit subjects, which survive rebases that change every hash
+	MetricMessage SimilarityMetric = "message"
+	// MetricFiles also compares the files in the trees of the tags, which ignores history altogether
+	MetricFiles SimilarityMetric = "files"
+	// MetricLines also compares the lines of code in the trees of the tags, by the diff between them
+	MetricLines SimilarityMetric = "lines"
+)
+
+// messageShingleSize is the number of consecutive words of a subject forming one shingle
+const messageShingleSize = 3
+
+// subjectPullRequestSuffix matches trailing pull request references like "(#123)", which differ between
+// the copies of a change merged into different branches
+var subjectPullRequestSuffix = regexp.MustCompile(`\s*\(#\d+\)$`)
+
+// ParseSimilarityMetric converts a -metric flag value into a SimilarityMetric
+func ParseSimilarityMetric(value string) (SimilarityMetric, error) {
+	switch SimilarityMetric(value) {
+	case MetricCommits, MetricMessage, MetricFiles, MetricLines:
+		return SimilarityMetric(value), nil
+	default:
+		return "", errors.Join(ErrInvalidMetric, fmt.Errorf("unknown metric: %s (expected commits, message, files, or lines)", value))
+	}
+}
+
+// MessageSimilarity computes the shingled Jaccard similarity of the commit subjects of two commit sets.
+// Each subject is lowercased and split into words, and every run of messageShingleSize consecutive words
+// becomes a shingle; shorter subjects are one shingle. The similarity is the Jaccard index of the shingles
+// of both tags, so rebased or cherry-picked commits with the same subject count as shared.
+func MessageSimilarity(repo Repository, tag1Commits map[plumbing.Hash]struct{}, tag2Commits map[plumbing.Hash]struct{}) (float64, error) {
+	shingles1, err := commitShingles(repo, tag1Commits)
+	if err != nil {
+		return 0, err
+	}
+	shingles2, err := commitShingles(repo, tag2Commits)
+	if err != nil {
+		return 0, err
+	}
+	return CalculateJaccardSimilarity(shingles1, shingles2), nil
+}
+
+// commitShingles collects the subject shingles of a set of commits
+func commitShingles(repo Repository, commitSet map[plumbing.Hash]struct{}) (map[string]struct{}, error) {
+	commits, err := repo.GetCommitObjects(hashesOf(commitSet))
+	if err != nil {
+		return nil, errors.Join(ErrMessageSimilarity, err)
+	}
+
+	shingles := make(map[string]struct{})
+	for _, commit := range commits {
+		subject := strings.Split(commit.Message, "\n")[0]
+		for _, shingle := range subjectShingles(subject) {
+			shingles[shingle] = struct{}{}
+		}
+	}
+	return shingles, nil
+}
+
+// subjectShingles splits a commit subject into shingles of consecutive lowercase words
+func subjectShingles(subject string) []string {
+	subject = subjectPullRequestSuffix.ReplaceAllString(strings.TrimSpace(subject), "")
+	words := strings.FieldsFunc(strings.ToLower(subject), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return nil
+	}
+	if len(words) <= messageShingleSize {
+		return []string{strings.Join(words, " ")}
+	}
+
+	shingles := make([]string, 0, len(words)-messageShingleSize+1)
+	for i := 0; i+messageShingleSize <= len(words); i++ {
+		shingles = append(shingles, strings.Join(words[i:i+messageShingleSize], " "))
+	}
+	return shingles
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestSubjectShingles tests splitting commit subjects into word shingles
+func TestSubjectShingles(t *testing.T) {
+	tests := []struct {
+		subject string
+		want    []string
+	}{
+		{subject: "Fix login redirect loop (#42)", want: []string{"fix login redirect", "login redirect loop"}},
+		{subject: "fix: Login redirect-loop", want: []string{"fix login redirect", "login redirect loop"}},
+		{subject: "Update docs", want: []string{"update docs"}},
+		{subject: "  ", want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.subject, func(t *testing.T) {
+			if got := subjectShingles(tt.subject); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("subjectShingles(%q) = %q, want %q", tt.subject, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompare_MessageMetric tests that the message similarity sees through rebased history
+func TestCompare_MessageMetric(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Base", testutil.File("a.go", "package a\n")).
+		Branch("rebase").
+		Commit("Add export to CSV", testutil.File("x.go", "package x\n")).
+		Commit("Fix login redirect loop (#42)", testutil.File("y.go", "package y\n")).
+		Tag("v1.0.0").
+		Checkout("rebase").
+		Commit("Add export to CSV", testutil.File("x.go", "package x\n")).
+		Commit("Fix login redirect loop (#57)", testutil.File("y.go", "package y\n")).
+		Tag("v1.0.0-rebased")
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.0.0-rebased"},
+		Metric:     MetricMessage,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Similarity != 0.2 {
+		t.Errorf("Similarity = %v, want 0.2 for rebased history", result.Similarity)
+	}
+	if result.MessageSimilarity == nil || *result.MessageSimilarity != 1 {
+		t.Fatalf("MessageSimilarity = %v, want 1", result.MessageSimilarity)
+	}
+
+	saved, err := NewSavedResult(context.Background(), result)
+	if err != nil {
+		t.Fatalf("NewSavedResult() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMarkdownReport(&buf, saved, ReportTemplateOptions{}); err != nil {
+		t.Fatalf("WriteMarkdownReport() error = %v", err)
+	}
+	if want := "| Message similarity (commit subjects) | 100.00% |"; !strings.Contains(buf.String(), want) {
+		t.Errorf("WriteMarkdownReport() output missing %q\n%s", want, buf.String())
+	}
+
+	if _, err := ParseSimilarityMetric("tfidf"); !errors.Is(err, ErrInvalidMetric) {
+		t.Errorf("ParseSimilarityMetric(tfidf) error = %v, want ErrInvalidMetric", err)
+	}
+}
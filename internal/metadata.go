@@ -0,0 +1,55 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+var ErrInvalidMetadata = errors.New("invalid metadata")
+
+// Metadata annotates a result with key/value pairs such as a CI build ID or pipeline URL, so saved
+// results can be traced back to the run that produced them. As a flag.Value, it collects repeated
+// key=value flags.
+type Metadata map[string]string
+
+// String lists the pairs as comma-separated key=value, sorted by key
+func (m *Metadata) String() string {
+	if m == nil {
+		return ""
+	}
+	pairs := make([]string, 0, len(*m))
+	for _, key := range m.Keys() {
+		pairs = append(pairs, key+"="+(*m)[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+// Set adds a key=value pair; the value may be empty or contain further "=" signs
+func (m *Metadata) Set(pair string) error {
+	key, value, ok := strings.Cut(pair, "=")
+	key = strings.TrimSpace(key)
+	if !ok || key == "" {
+		return errors.Join(ErrInvalidMetadata, fmt.Errorf("expected key=value: %s", pair))
+	}
+	if _, exists := (*m)[key]; exists {
+		return errors.Join(ErrInvalidMetadata, fmt.Errorf("duplicate key: %s", key))
+	}
+
+	if *m == nil {
+		*m = Metadata{}
+	}
+	(*m)[key] = value
+	return nil
+}
+
+// Keys returns the keys in sorted order
+func (m Metadata) Keys() []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
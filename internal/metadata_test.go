@@ -0,0 +1,75 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// TestMetadataSet tests parsing repeated -meta key=value flags
+func TestMetadataSet(t *testing.T) {
+	tests := []struct {
+		name    string
+		pairs   []string
+		want    Metadata
+		wantErr bool
+	}{
+		{name: "Pairs", pairs: []string{"build=1234", "url=https://ci.example.com/run?id=7"}, want: Metadata{"build": "1234", "url": "https://ci.example.com/run?id=7"}},
+		{name: "Empty value", pairs: []string{"env="}, want: Metadata{"env": ""}},
+		{name: "Missing equals sign", pairs: []string{"build"}, wantErr: true},
+		{name: "Empty key", pairs: []string{"=1234"}, wantErr: true},
+		{name: "Duplicate key", pairs: []string{"build=1", "build=2"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var metadata Metadata
+			var err error
+			for _, pair := range tt.pairs {
+				if err = metadata.Set(pair); err != nil {
+					break
+				}
+			}
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidMetadata) {
+					t.Errorf("Set() error = %v, want ErrInvalidMetadata", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+			if !reflect.DeepEqual(metadata, tt.want) {
+				t.Errorf("metadata = %v, want %v", metadata, tt.want)
+			}
+		})
+	}
+}
+
+// TestNewCompareConfig_Metadata tests that -meta annotations are parsed and saved with the result
+func TestNewCompareConfig_Metadata(t *testing.T) {
+	config, err := NewCompareConfig([]string{"-repo", ".", "-tag1", "v1.0.0", "-tag2", "v1.1.0", "-meta", "build=1234", "-meta", "env=staging"})
+	if err != nil {
+		t.Fatalf("NewCompareConfig() error = %v", err)
+	}
+	if want := (Metadata{"build": "1234", "env": "staging"}); !reflect.DeepEqual(config.Metadata, want) {
+		t.Errorf("Metadata = %v, want %v", config.Metadata, want)
+	}
+	if got := config.Metadata.String(); got != "build=1234,env=staging" {
+		t.Errorf("String() = %q", got)
+	}
+
+	fixture := newReleaseFixture(t)
+	result, err := Compare(CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}, Metadata: config.Metadata})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	saved, err := NewSavedResult(context.Background(), result)
+	if err != nil {
+		t.Fatalf("NewSavedResult() error = %v", err)
+	}
+	if !reflect.DeepEqual(saved.Metadata, config.Metadata) {
+		t.Errorf("saved metadata = %v, want %v", saved.Metadata, config.Metadata)
+	}
+}
@@ -0,0 +1,96 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	ErrInvalidCompareFormat = errors.New("invalid compare output format")
+	ErrWriteCommits         = errors.New("failed to write commits")
+)
+
+// CompareFormat is the console output format of the compare command
+type CompareFormat string
+
+const (
+	CompareFormatText          CompareFormat = "text"
+	CompareFormatNDJSONCommits CompareFormat = "ndjson-commits"
+)
+
+// ParseCompareFormat converts a flag value into a CompareFormat
+func ParseCompareFormat(value string) (CompareFormat, error) {
+	switch CompareFormat(value) {
+	case CompareFormatText, CompareFormatNDJSONCommits:
+		return CompareFormat(value), nil
+	default:
+		return "", errors.Join(ErrInvalidCompareFormat, fmt.Errorf("unknown format: %s (expected text or ndjson-commits)", value))
+	}
+}
+
+// CommitRecord is a single line of the ndjson-commits output
+type CommitRecord struct {
+	Hash    string    `json:"hash"`
+	Side    string    `json:"side"` // "tag1" or "tag2"
+	Tag     string    `json:"tag"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Files   []string  `json:"files"`
+
+	// Topology is "mainline" or "side-branch"; omitted with -first-parent
+	Topology CommitTopology `json:"topology,omitempty"`
+}
+
+// WriteCommitsNDJSON writes one JSON object per commit unique to either tag, as each commit is resolved.
+// Commits only in the first tag are written first.
+func WriteCommitsNDJSON(ctx context.Context, w io.Writer, result CompareResult) error {
+	encoder := json.NewEncoder(w)
+
+	sides := []struct {
+		side     string
+		tag      string
+		commits  map[plumbing.Hash]struct{}
+		mainline map[plumbing.Hash]struct{}
+	}{
+		{side: "tag1", tag: result.Config.Tag1Name, commits: result.OnlyInTag1, mainline: result.Tag1Mainline},
+		{side: "tag2", tag: result.Config.Tag2Name, commits: result.OnlyInTag2, mainline: result.Tag2Mainline},
+	}
+
+	for _, side := range sides {
+		err := result.Repo.StreamCommitFiles(ctx, hashesOf(side.commits), func(commit *object.Commit, files []string) error {
+			if files == nil {
+				files = []string{}
+			}
+			record := CommitRecord{
+				Hash:    commit.Hash.String(),
+				Side:    side.side,
+				Tag:     side.tag,
+				Author:  commit.Author.Name,
+				Email:   commit.Author.Email,
+				Date:    commit.Author.When,
+				Subject: commit.Message,
+				Files:   files,
+
+				Topology: commitTopology(side.mainline, commit.Hash),
+			}
+			if err := encoder.Encode(record); err != nil {
+				return errors.Join(ErrWriteCommits, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestParseCompareFormat tests parsing of the compare output format flag
+func TestParseCompareFormat(t *testing.T) {
+	if format, err := ParseCompareFormat("ndjson-commits"); err != nil || format != CompareFormatNDJSONCommits {
+		t.Errorf("ParseCompareFormat(ndjson-commits) = %q, %v", format, err)
+	}
+	if _, err := ParseCompareFormat("yaml"); !errors.Is(err, ErrInvalidCompareFormat) {
+		t.Errorf("ParseCompareFormat(yaml) error = %v, want ErrInvalidCompareFormat", err)
+	}
+}
+
+// TestWriteCommitsNDJSON tests that one record is written per unique commit
+func TestWriteCommitsNDJSON(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+	date := time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC)
+	subjects := map[plumbing.Hash]string{hash1: "Subject 1", hash2: "Subject 2"}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().StreamCommitFiles(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, hashes []plumbing.Hash, visit func(commit *object.Commit, files []string) error) error {
+			for _, hash := range hashes {
+				commit := &object.Commit{Hash: hash, Author: object.Signature{Name: "Alice", Email: "alice@example.com", When: date}, Message: subjects[hash]}
+				var files []string
+				if hash == hash1 {
+					files = []string{"a.go", "b.go"}
+				}
+				if err := visit(commit, files); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Times(2)
+
+	result := CompareResult{
+		Repo:       mockRepo,
+		Config:     CompareConfig{TagOptions: TagOptions{Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"}},
+		OnlyInTag1: map[plumbing.Hash]struct{}{hash1: {}},
+		OnlyInTag2: map[plumbing.Hash]struct{}{hash2: {}},
+	}
+
+	var buf bytes.Buffer
+	if err := WriteCommitsNDJSON(context.Background(), &buf, result); err != nil {
+		t.Fatalf("WriteCommitsNDJSON() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("got %d lines, want 2:\n%s", len(lines), buf.String())
+	}
+
+	var first, second CommitRecord
+	if err := json.Unmarshal([]byte(lines[0]), &first); err != nil {
+		t.Fatalf("line 1 is not valid JSON: %v", err)
+	}
+	if err := json.Unmarshal([]byte(lines[1]), &second); err != nil {
+		t.Fatalf("line 2 is not valid JSON: %v", err)
+	}
+
+	if first.Hash != hash1.String() || first.Side != "tag1" || first.Tag != "v1.0.0" || len(first.Files) != 2 || !first.Date.Equal(date) {
+		t.Errorf("first record = %+v", first)
+	}
+	if second.Hash != hash2.String() || second.Side != "tag2" || second.Subject != "Subject 2" {
+		t.Errorf("second record = %+v", second)
+	}
+	if !strings.Contains(lines[1], `"files":[]`) {
+		t.Errorf("commits without files should have an empty files array: %s", lines[1])
+	}
+}
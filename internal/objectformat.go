@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/hash"
+)
+
+var ErrUnsupportedObjectFormat = errors.New("unsupported repository object format")
+
+// BuildObjectFormat returns the object format this binary reads.
+// go-git fixes the hash size at build time: SHA-1 by default, SHA-256 with the sha256 build tag.
+func BuildObjectFormat() config.ObjectFormat {
+	if hash.Size == 32 {
+		return config.SHA256
+	}
+	return config.SHA1
+}
+
+// repositoryObjectFormat returns the object format the repository was initialized with
+func repositoryObjectFormat(repo *git.Repository) (config.ObjectFormat, error) {
+	cfg, err := repo.Config()
+	if err != nil {
+		return "", err
+	}
+
+	if format := cfg.Raw.Section("extensions").Option("objectformat"); format != "" {
+		return config.ObjectFormat(format), nil
+	}
+	return config.DefaultObjectFormat, nil
+}
+
+// checkObjectFormat fails for repositories whose hashes this binary cannot read.
+// A mismatch would otherwise surface as "object not found" on the first tag.
+func checkObjectFormat(repo *git.Repository) error {
+	format, err := repositoryObjectFormat(repo)
+	if err != nil {
+		return errors.Join(ErrUnsupportedObjectFormat, err)
+	}
+
+	if format != BuildObjectFormat() {
+		hint := "build with 'go build -tags sha256'"
+		if BuildObjectFormat() == config.SHA256 {
+			hint = "use a build without the sha256 tag"
+		}
+		return errors.Join(ErrUnsupportedObjectFormat, fmt.Errorf("repository uses %s objects, but this binary reads %s objects; %s", format, BuildObjectFormat(), hint))
+	}
+	return nil
+}
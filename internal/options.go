@@ -0,0 +1,282 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TagOptions holds the repository and tag pair shared by the compare, diff, and check commands.
+// Either side may also name a branch, a commit, or a revision expression instead of a tag.
+// Command configurations embed it, so its flags are parsed, validated, and resolved identically
+// whether they come from the command line or are set by library callers.
+type TagOptions struct {
+	RepoPath   string
+	Tag1Name   string
+	Tag2Name   string
+	SortBy     SortStrategy
+	CloneDepth int // Commits fetched per branch and tag when RepoPath is a remote URL; 0 fetches the full history
+}
+
+// registerFlags adds the -repo, -clone-depth, -tag1/-ref1, -tag2/-ref2, and -sort flags to a command.
+// tagUsage describes what the tags are used for (e.g. "name to compare").
+// The returned function must be called after parsing to convert the flag values.
+func (o *TagOptions) registerFlags(flags *flag.FlagSet, tagUsage string) func() error {
+	var sortBy string
+
+	flags.StringVar(&o.RepoPath, "repo", "", "Path or URL of the Git repository (URLs are cloned into the user cache directory)")
+	flags.IntVar(&o.CloneDepth, "clone-depth", 0, "Shallow-clone a remote -repo with this many commits per branch and tag (0 = full history; similarity needs the full history)")
+	flags.StringVar(&o.Tag1Name, "tag1", "", fmt.Sprintf("First tag %s (or latest, latest-N, a branch, a commit, or a revision like HEAD~5)", tagUsage))
+	flags.StringVar(&o.Tag2Name, "tag2", "", fmt.Sprintf("Second tag %s (or latest, latest-N, a branch, a commit, or a revision like HEAD~5)", tagUsage))
+	flags.StringVar(&o.Tag1Name, "ref1", "", "Same as -tag1")
+	flags.StringVar(&o.Tag2Name, "ref2", "", "Same as -tag2")
+	flags.StringVar(&sortBy, "sort", string(SortBySemver), "Tag order used to resolve latest-N references (semver, date)")
+
+	return func() error {
+		strategy, err := ParseSortStrategy(sortBy)
+		if err != nil {
+			return err
+		}
+		o.SortBy = strategy
+		return nil
+	}
+}
+
+// Validate checks that the repository and both tags are set and the repository path exists
+func (o *TagOptions) Validate() error {
+	if o.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if o.Tag1Name == "" {
+		return ErrMissingTag1
+	}
+
+	if o.Tag2Name == "" {
+		return ErrMissingTag2
+	}
+
+	if o.CloneDepth < 0 {
+		return errors.Join(ErrInvalidRepo, fmt.Errorf("clone depth must not be negative: %d", o.CloneDepth))
+	}
+
+	return validateRepoPath(o.RepoPath)
+}
+
+// ValidateWithRepository checks that both names are tags or resolve to commits in the repository
+func (o *TagOptions) ValidateWithRepository(ctx context.Context, repo Repository) error {
+	// First validate basic configuration
+	if err := o.Validate(); err != nil {
+		return err
+	}
+
+	// Fetch all tags to check if the specified tags exist
+	tagRefs, err := repo.FetchAllTags()
+	if err != nil {
+		return err
+	}
+
+	// Build a map of tag names for quick lookup
+	tagMap := make(map[string]bool)
+	for _, ref := range tagRefs {
+		tagMap[ref.Name().Short()] = true
+	}
+
+	// Names that are not tags may be branches, commit hashes, or revision expressions
+	if !tagMap[o.Tag1Name] {
+		if _, err := repo.ResolveRevision(ctx, o.Tag1Name); err != nil {
+			return errors.Join(ErrTag1NotFound, fmt.Errorf("tag, branch, or commit '%s' not found in repository", o.Tag1Name))
+		}
+	}
+
+	if !tagMap[o.Tag2Name] {
+		if _, err := repo.ResolveRevision(ctx, o.Tag2Name); err != nil {
+			return errors.Join(ErrTag2NotFound, fmt.Errorf("tag, branch, or commit '%s' not found in repository", o.Tag2Name))
+		}
+	}
+
+	return nil
+}
+
+// ResolveTagOffsets replaces "latest"/"latest-N" tag references with concrete tag names.
+// Empty tag names are left empty.
+func (o *TagOptions) ResolveTagOffsets(repo Repository) error {
+	strategy := o.SortBy
+	if strategy == "" {
+		strategy = SortBySemver
+	}
+
+	tag1Name, err := ResolveTagOffset(repo, o.Tag1Name, strategy)
+	if err != nil {
+		return err
+	}
+
+	tag2Name, err := ResolveTagOffset(repo, o.Tag2Name, strategy)
+	if err != nil {
+		return err
+	}
+
+	o.Tag1Name = tag1Name
+	o.Tag2Name = tag2Name
+	return nil
+}
+
+// GetTagReference finds and returns the reference for a specific tag name.
+// Tags take precedence, as in git; other names are resolved as a branch, commit hash, or revision.
+func (o *TagOptions) GetTagReference(ctx context.Context, repo Repository, tagName string) (*plumbing.Reference, error) {
+	return findReference(ctx, repo, tagName)
+}
+
+// findReference resolves a tag name, or else a branch, commit hash, or revision, to a reference
+func findReference(ctx context.Context, repo Repository, tagName string) (*plumbing.Reference, error) {
+	tagRefs, err := repo.FetchAllTags()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ref := range tagRefs {
+		if ref.Name().Short() == tagName {
+			return ref, nil
+		}
+	}
+
+	ref, err := repo.ResolveRevision(ctx, tagName)
+	if err != nil {
+		return nil, errors.Join(fmt.Errorf("tag, branch, or commit '%s' not found", tagName), err)
+	}
+	return ref, nil
+}
+
+// ResolveTags resolves tag offsets, checks that both tags exist, and returns their references
+func (o *TagOptions) ResolveTags(ctx context.Context, repo Repository) (*plumbing.Reference, *plumbing.Reference, error) {
+	if err := o.ResolveTagOffsets(repo); err != nil {
+		return nil, nil, errors.Join(ErrValidationFailed, err)
+	}
+
+	if err := o.ValidateWithRepository(ctx, repo); err != nil {
+		return nil, nil, errors.Join(ErrValidationFailed, err)
+	}
+
+	tag1Ref, err := o.GetTagReference(ctx, repo, o.Tag1Name)
+	if err != nil {
+		return nil, nil, errors.Join(ErrGetTagReference, err)
+	}
+
+	tag2Ref, err := o.GetTagReference(ctx, repo, o.Tag2Name)
+	if err != nil {
+		return nil, nil, errors.Join(ErrGetTagReference, err)
+	}
+
+	return tag1Ref, tag2Ref, nil
+}
+
+// registerFlags adds the -width and -truncate flags to a command.
+// The returned function must be called after parsing to validate the flag values.
+func (o *OutputOptions) registerFlags(flags *flag.FlagSet, widthUsage string) func() error {
+	var truncate string
+
+	flags.IntVar(&o.Width, "width", defaultOutputWidth, widthUsage)
+	flags.StringVar(&truncate, "truncate", string(TruncateEnd), "How to shorten text that does not fit the width (end, middle, none)")
+
+	return func() error {
+		output, err := NewOutputOptions(o.Width, truncate)
+		if err != nil {
+			return err
+		}
+		*o = output
+		return nil
+	}
+}
+
+// validateRepoPath checks that a repository path exists. Remote URLs are checked when cloned.
+func validateRepoPath(path string) error {
+	if IsRemoteURL(path) {
+		return nil
+	}
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return errors.Join(ErrInvalidRepo, fmt.Errorf("path does not exist: %s", path))
+	}
+	return nil
+}
+
+// cleanDirectory normalizes a directory filter relative to the repository root.
+// "." and "" select the whole repository and are returned as "".
+func cleanDirectory(directory string) (string, error) {
+	if directory == "" {
+		return "", nil
+	}
+
+	slashed := strings.ReplaceAll(directory, "\\", "/")
+	if path.IsAbs(slashed) {
+		return "", errors.Join(ErrInvalidDirectory, fmt.Errorf("directory must be relative to the repository root: %s", directory))
+	}
+
+	cleaned := path.Clean(slashed)
+	if cleaned == ".." || strings.HasPrefix(cleaned, "../") {
+		return "", errors.Join(ErrInvalidDirectory, fmt.Errorf("directory is outside the repository: %s", directory))
+	}
+	if cleaned == "." {
+		return "", nil
+	}
+	return cleaned, nil
+}
+
+// validateDirectoryInTags checks that a directory filter exists in at least one of the tags,
+// so a typo is reported instead of silently producing an empty comparison
+func validateDirectoryInTags(repo Repository, directory string, refs ...*plumbing.Reference) error {
+	if directory == "" {
+		return nil
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		names = append(names, ref.Name().Short())
+		exists, err := repo.HasDirectory(ref, directory)
+		if err != nil {
+			return errors.Join(ErrInvalidDirectory, err)
+		}
+		if exists {
+			return nil
+		}
+	}
+
+	return errors.Join(ErrInvalidDirectory, fmt.Errorf("directory does not exist in %s: %s", strings.Join(names, " or "), directory))
+}
+
+// registerTimeoutFlag adds the -timeout flag to a command; what names the work it cancels, e.g. "the matrix"
+func registerTimeoutFlag(flags *flag.FlagSet, timeout *time.Duration, what string) {
+	flags.DurationVar(timeout, "timeout", 0, fmt.Sprintf("Cancel %s after this long, e.g. 5m (0 = no limit); Ctrl-C cancels as well", what))
+}
+
+// validateTimeout checks that a -timeout is not negative
+func validateTimeout(timeout time.Duration) error {
+	if timeout < 0 {
+		return errors.Join(ErrInvalidTimeout, fmt.Errorf("-timeout must not be negative: %s", timeout))
+	}
+	return nil
+}
+
+// startRun bounds ctx by timeout, leaving it unbounded for 0, and returns the function that ends the run.
+// It releases the context and turns the error of a canceled run into ErrCanceled joined with the context's
+// error, e.g. context.DeadlineExceeded; whatever failed first, such as a killed git command, is a
+// consequence of the cancellation.
+func startRun(ctx context.Context, timeout time.Duration) (context.Context, func(err error) error) {
+	cancel := context.CancelFunc(func() {})
+	if timeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+	}
+	return ctx, func(err error) error {
+		defer cancel()
+		if err != nil && ctx.Err() != nil {
+			return errors.Join(ErrCanceled, ctx.Err())
+		}
+		return err
+	}
+}
@@ -0,0 +1,189 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"go.uber.org/mock/gomock"
+)
+
+// TestTagOptionsParsedIdentically tests that every command embedding TagOptions parses the shared flags the same way
+func TestTagOptionsParsedIdentically(t *testing.T) {
+	args := []string{"-repo", ".", "-tag1", "latest-1", "-tag2", "latest", "-sort", "date"}
+	want := TagOptions{RepoPath: ".", Tag1Name: "latest-1", Tag2Name: "latest", SortBy: SortByDate}
+
+	compareConfig, err := NewCompareConfig(args)
+	if err != nil {
+		t.Fatalf("NewCompareConfig() error = %v, want nil", err)
+	}
+	diffConfig, err := NewDiffConfig(args)
+	if err != nil {
+		t.Fatalf("NewDiffConfig() error = %v, want nil", err)
+	}
+	checkConfig, err := NewCheckConfig(args)
+	if err != nil {
+		t.Fatalf("NewCheckConfig() error = %v, want nil", err)
+	}
+
+	for name, got := range map[string]TagOptions{
+		"compare": compareConfig.TagOptions,
+		"diff":    diffConfig.TagOptions,
+		"check":   checkConfig.TagOptions,
+	} {
+		if got != want {
+			t.Errorf("%s options = %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+// TestTagOptionsResolveTags tests resolving tag offsets into references
+func TestTagOptionsResolveTags(t *testing.T) {
+	tempDir := t.TempDir()
+
+	v1 := plumbing.NewReferenceFromStrings("refs/tags/v1.0.0", "0000000000000000000000000000000000000001")
+	v2 := plumbing.NewReferenceFromStrings("refs/tags/v2.0.0", "0000000000000000000000000000000000000002")
+	tags := []*plumbing.Reference{v2, v1}
+
+	tests := []struct {
+		name      string
+		options   TagOptions
+		wantTag1  string
+		wantTag2  string
+		wantError error
+	}{
+		{
+			name:     "Offsets",
+			options:  TagOptions{RepoPath: tempDir, Tag1Name: "latest-1", Tag2Name: "latest", SortBy: SortBySemver},
+			wantTag1: "v1.0.0",
+			wantTag2: "v2.0.0",
+		},
+		{
+			name:      "Missing tag",
+			options:   TagOptions{RepoPath: tempDir, Tag1Name: "v1.0.0", Tag2Name: "v3.0.0", SortBy: SortBySemver},
+			wantError: ErrTag2NotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().FetchAllTags().Return(tags, nil).AnyTimes()
+			mockRepo.EXPECT().ResolveRevision(gomock.Any(), gomock.Any()).Return(nil, ErrResolveRevision).AnyTimes()
+
+			tag1Ref, tag2Ref, err := tt.options.ResolveTags(context.Background(), mockRepo)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ResolveTags() error = %v, want %v", err, tt.wantError)
+			}
+			if tt.wantError != nil {
+				return
+			}
+			if tag1Ref.Name().Short() != tt.wantTag1 || tag2Ref.Name().Short() != tt.wantTag2 {
+				t.Errorf("ResolveTags() = %s, %s, want %s, %s", tag1Ref.Name().Short(), tag2Ref.Name().Short(), tt.wantTag1, tt.wantTag2)
+			}
+		})
+	}
+}
+
+// TestCommands_Canceled tests that the repository commands stop on a done context and after their -timeout
+func TestCommands_Canceled(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	tags := TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}
+
+	tests := []struct {
+		name string
+		run  func(ctx context.Context, timeout time.Duration) error
+	}{
+		{
+			name: "diff",
+			run: func(ctx context.Context, timeout time.Duration) error {
+				_, err := Diff(ctx, DiffConfig{TagOptions: tags, Timeout: timeout})
+				return err
+			},
+		},
+		{
+			name: "get",
+			run: func(ctx context.Context, timeout time.Duration) error {
+				_, _, err := Get(ctx, GetConfig{Query: GetSimilarity, TagOptions: tags, NoCache: true, Timeout: timeout})
+				return err
+			},
+		},
+		{
+			name: "matrix",
+			run: func(ctx context.Context, timeout time.Duration) error {
+				_, err := ComputeMatrix(ctx, MatrixConfig{RepoPath: fixture.Path(), Pattern: "v1.*", SortBy: SortBySemver, NoCache: true, Timeout: timeout})
+				return err
+			},
+		},
+		{
+			name: "suggest",
+			run: func(ctx context.Context, timeout time.Duration) error {
+				_, err := Suggest(ctx, SuggestConfig{RepoPath: fixture.Path(), Recent: 20, Timeout: timeout})
+				return err
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.run(context.Background(), 0); err != nil {
+				t.Fatalf("error with a live context = %v", err)
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			if err := tt.run(ctx, 0); !errors.Is(err, ErrCanceled) || !errors.Is(err, context.Canceled) {
+				t.Errorf("error = %v, want %v and %v", err, ErrCanceled, context.Canceled)
+			}
+
+			if err := tt.run(context.Background(), time.Nanosecond); !errors.Is(err, ErrCanceled) || !errors.Is(err, context.DeadlineExceeded) {
+				t.Errorf("error with Timeout = %v, want %v and %v", err, ErrCanceled, context.DeadlineExceeded)
+			}
+		})
+	}
+}
+
+// TestTimeoutFlag tests that the repository commands parse -timeout and reject a negative one
+func TestTimeoutFlag(t *testing.T) {
+	parsers := map[string]func(args []string) (time.Duration, error){
+		"get": func(args []string) (time.Duration, error) {
+			config, err := NewGetConfig(append([]string{"similarity", "-repo", ".", "-tag1", "v1.0.0", "-tag2", "v1.1.0"}, args...))
+			if err != nil {
+				return 0, err
+			}
+			return config.Timeout, config.Validate()
+		},
+		"matrix": func(args []string) (time.Duration, error) {
+			config, err := NewMatrixConfig(append([]string{"-repo", ".", "-pattern", "v*"}, args...))
+			if err != nil {
+				return 0, err
+			}
+			return config.Timeout, config.Validate()
+		},
+		"suggest": func(args []string) (time.Duration, error) {
+			config, err := NewSuggestConfig(append([]string{"-repo", "."}, args...))
+			if err != nil {
+				return 0, err
+			}
+			return config.Timeout, config.Validate()
+		},
+	}
+
+	for name, parse := range parsers {
+		t.Run(name, func(t *testing.T) {
+			timeout, err := parse([]string{"-timeout", "5m"})
+			if err != nil || timeout != 5*time.Minute {
+				t.Errorf("-timeout 5m = %s, %v, want 5m0s", timeout, err)
+			}
+			if _, err := parse([]string{"-timeout", "-1s"}); !errors.Is(err, ErrInvalidTimeout) {
+				t.Errorf("-timeout -1s error = %v, want %v", err, ErrInvalidTimeout)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+)
+
+var (
+	ErrInvalidTruncateMode = errors.New("invalid truncate mode")
+	ErrInvalidWidth        = errors.New("invalid output width")
+)
+
+// TruncateMode selects how text longer than the available width is shortened
+type TruncateMode string
+
+const (
+	TruncateEnd    TruncateMode = "end"    // Keep the beginning ("Fix race in wat…")
+	TruncateMiddle TruncateMode = "middle" // Keep both ends, useful for paths ("internal/…/watcher.go")
+	TruncateNone   TruncateMode = "none"   // Never shorten; lines may exceed the width
+)
+
+// defaultOutputWidth is the line width used when none is configured
+const defaultOutputWidth = 120
+
+// minOutputWidth is the smallest accepted line width
+const minOutputWidth = 40
+
+// tablePadding is the number of spaces between table columns
+const tablePadding = 2
+
+// minFlexColumnWidth keeps a shortened table column readable even when the other columns fill the line
+const minFlexColumnWidth = 16
+
+// ellipsis marks truncated text
+const ellipsis = "…"
+
+// OutputOptions controls the width of console output, tables, diff stats, and report lines
+type OutputOptions struct {
+	Width    int
+	Truncate TruncateMode
+}
+
+// NewOutputOptions validates the -width and -truncate flag values
+func NewOutputOptions(width int, truncate string) (OutputOptions, error) {
+	mode, err := ParseTruncateMode(truncate)
+	if err != nil {
+		return OutputOptions{}, err
+	}
+
+	if width < minOutputWidth {
+		return OutputOptions{}, errors.Join(ErrInvalidWidth, fmt.Errorf("width must be at least %d: %d", minOutputWidth, width))
+	}
+
+	return OutputOptions{Width: width, Truncate: mode}, nil
+}
+
+// ParseTruncateMode converts a flag value into a TruncateMode
+func ParseTruncateMode(value string) (TruncateMode, error) {
+	switch TruncateMode(value) {
+	case TruncateEnd, TruncateMiddle, TruncateNone:
+		return TruncateMode(value), nil
+	default:
+		return "", errors.Join(ErrInvalidTruncateMode, fmt.Errorf("unknown truncate mode: %s (expected end, middle, or none)", value))
+	}
+}
+
+// width returns the configured width, falling back to the default for zero-valued options
+func (o OutputOptions) width() int {
+	if o.Width <= 0 {
+		return defaultOutputWidth
+	}
+	return o.Width
+}
+
+// Fit shortens text to at most width characters according to the truncate mode
+func (o OutputOptions) Fit(text string, width int) string {
+	if o.Truncate == TruncateNone || utf8.RuneCountInString(text) <= width {
+		return text
+	}
+	if width <= 1 {
+		return ellipsis
+	}
+
+	runes := []rune(text)
+	if o.Truncate == TruncateMiddle {
+		head := (width - 1) / 2
+		tail := width - 1 - head
+		return string(runes[:head]) + ellipsis + string(runes[len(runes)-tail:])
+	}
+	return string(runes[:width-1]) + ellipsis
+}
+
+// FitLine shortens text so that it fits on a line after a prefix of prefixWidth characters
+func (o OutputOptions) FitLine(text string, prefixWidth int) string {
+	return o.Fit(text, max(o.width()-prefixWidth, 1))
+}
+
+// writeTable writes rows as aligned columns, shortening the flex column so rows fit the width.
+// Other columns are never shortened, so very narrow widths may still be exceeded.
+func writeTable(w io.Writer, options OutputOptions, flexColumn int, rows [][]string) {
+	widths := make(map[int]int)
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+
+	fixed := 0
+	for i, width := range widths {
+		if i != flexColumn {
+			fixed += width + tablePadding
+		}
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, tablePadding, ' ', 0)
+	for _, row := range rows {
+		cells := append([]string(nil), row...)
+		if flexColumn < len(cells) {
+			cells[flexColumn] = options.Fit(cells[flexColumn], max(options.width()-fixed, minFlexColumnWidth))
+		}
+		_, _ = fmt.Fprintln(tw, strings.Join(cells, "\t"))
+	}
+	_ = tw.Flush()
+}
@@ -0,0 +1,77 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// TestNewOutputOptions tests validation of the width and truncate flags
+func TestNewOutputOptions(t *testing.T) {
+	tests := []struct {
+		name      string
+		width     int
+		truncate  string
+		wantError error
+	}{
+		{name: "Defaults", width: defaultOutputWidth, truncate: "end"},
+		{name: "Narrow middle", width: minOutputWidth, truncate: "middle"},
+		{name: "Too narrow", width: minOutputWidth - 1, truncate: "end", wantError: ErrInvalidWidth},
+		{name: "Unknown mode", width: 80, truncate: "start", wantError: ErrInvalidTruncateMode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewOutputOptions(tt.width, tt.truncate)
+			if !errors.Is(err, tt.wantError) {
+				t.Errorf("NewOutputOptions() error = %v, want %v", err, tt.wantError)
+			}
+		})
+	}
+}
+
+// TestOutputOptionsFit tests truncation modes
+func TestOutputOptionsFit(t *testing.T) {
+	tests := []struct {
+		name  string
+		mode  TruncateMode
+		text  string
+		width int
+		want  string
+	}{
+		{name: "Short text is unchanged", mode: TruncateEnd, text: "Fix bug", width: 10, want: "Fix bug"},
+		{name: "End", mode: TruncateEnd, text: "Fix race in watcher", width: 10, want: "Fix race …"},
+		{name: "Middle", mode: TruncateMiddle, text: "internal/report/watcher.go", width: 11, want: "inter…er.go"},
+		{name: "None", mode: TruncateNone, text: "Fix race in watcher", width: 10, want: "Fix race in watcher"},
+		{name: "Multibyte", mode: TruncateEnd, text: "리포트 템플릿 추가", width: 5, want: "리포트 …"},
+		{name: "Zero-value mode truncates at the end", text: "abcdefgh", width: 4, want: "abc…"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := OutputOptions{Truncate: tt.mode}.Fit(tt.text, tt.width)
+			if got != tt.want {
+				t.Errorf("Fit() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestWriteTable tests that the flex column is shortened to fit the width
+func TestWriteTable(t *testing.T) {
+	rows := [][]string{
+		{"PATH", "SHARED"},
+		{strings.Repeat("a", 100), "12"},
+	}
+
+	var buf bytes.Buffer
+	writeTable(&buf, OutputOptions{Width: minOutputWidth, Truncate: TruncateEnd}, 0, rows)
+
+	for _, line := range strings.Split(strings.TrimRight(buf.String(), "\n"), "\n") {
+		if width := utf8.RuneCountInString(line); width > minOutputWidth {
+			t.Errorf("line %q is %d characters wide, want at most %d", line, width, minOutputWidth)
+		}
+	}
+}
@@ -0,0 +1,342 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrMissingSeries   = errors.New("patch series path is required")
+	ErrLoadPatchSeries = errors.New("failed to load patch series")
+	ErrEmptySeries     = errors.New("patch series contains no patches")
+)
+
+// patchFileExtensions are the files read from a patch series directory
+var patchFileExtensions = []string{".patch", ".diff", ".mbox", ".eml"}
+
+// PatchesConfig holds the configuration of the patches command
+type PatchesConfig struct {
+	Command    Command
+	RepoPath   string
+	TagName    string // Tag searched for the patches (or latest, latest-N)
+	SinceTag   string // Only search commits not reachable from this tag; empty searches the whole history
+	SeriesPath string // git format-patch output: a directory of patch files or a single mailbox
+	Output     OutputOptions
+	Timeout    time.Duration // Cancel the patch search after this long; 0 never times out
+}
+
+// patchesUsage is the help of the patches command
+var patchesUsage = commandUsage{
+	Name:        "patches",
+	Summary:     "Check how much of a patch series a tag contains",
+	Description: "Report how much of a patch series is already contained in a tag, e.g. whether a vendor\npatch set has been upstreamed into a release. Patches are matched by 'git patch-id',\nso they are found even when applied at different line numbers.",
+	Examples: []string{
+		"patches -repo /path/to/repo -tag v2.0.0 -series ./vendor-patches",
+		"patches -repo /path/to/repo -tag latest -since v1.0.0 -series series.mbox",
+	},
+}
+
+// NewPatchesConfig parses the patches command flags
+func NewPatchesConfig(args []string) (PatchesConfig, error) {
+	config := PatchesConfig{Command: PatchesCommand}
+
+	patchesCmd := newCommandFlagSet(patchesUsage)
+	patchesCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	patchesCmd.StringVar(&config.TagName, "tag", "", "Tag to search for the patches (or latest, latest-N)")
+	patchesCmd.StringVar(&config.SeriesPath, "series", "", "Directory of patch files or a mailbox, e.g. 'git format-patch' output")
+	patchesCmd.StringVar(&config.SinceTag, "since", "", "Only search commits added after this tag (default: the whole history of -tag)")
+	parseOutputOptions := config.Output.registerFlags(patchesCmd, "Maximum line width of the results table")
+	registerTimeoutFlag(patchesCmd, &config.Timeout, "the patch search")
+
+	if err := patchesCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *PatchesConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+	if c.TagName == "" {
+		return ErrMissingTag1
+	}
+	if c.SeriesPath == "" {
+		return ErrMissingSeries
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+	if _, err := os.Stat(c.SeriesPath); os.IsNotExist(err) {
+		return errors.Join(ErrLoadPatchSeries, fmt.Errorf("path does not exist: %s", c.SeriesPath))
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SeriesPatch is a single patch of a series and the commit of the tag containing it
+type SeriesPatch struct {
+	File     string        // File the patch was read from
+	Subject  string        // Subject without the [PATCH n/m] prefix
+	PatchID  string        // Stable patch ID; empty for patches without a diff, such as a cover letter
+	Upstream plumbing.Hash // Commit of the tag with the same patch ID; zero if the tag does not contain the patch
+	content  string
+}
+
+// Contained reports whether the tag contains the patch
+func (p SeriesPatch) Contained() bool {
+	return !p.Upstream.IsZero()
+}
+
+// PatchSeriesResult is the outcome of checking a patch series against a tag
+type PatchSeriesResult struct {
+	Tag     string
+	Since   string
+	Patches []SeriesPatch // Patches with a diff, in series order
+	Skipped []string      // Subjects of patches without a diff
+}
+
+// Contained returns the number of patches the tag contains
+func (r PatchSeriesResult) Contained() int {
+	count := 0
+	for _, patch := range r.Patches {
+		if patch.Contained() {
+			count++
+		}
+	}
+	return count
+}
+
+// Coverage returns the share of the patches the tag contains, between 0 and 1
+func (r PatchSeriesResult) Coverage() float64 {
+	if len(r.Patches) == 0 {
+		return 0
+	}
+	return float64(r.Contained()) / float64(len(r.Patches))
+}
+
+// CheckPatchSeries reports which patches of a series the configured tag contains
+func CheckPatchSeries(ctx context.Context, config PatchesConfig) (_ PatchSeriesResult, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return PatchSeriesResult{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	patches, err := LoadPatchSeries(config.SeriesPath)
+	if err != nil {
+		return PatchSeriesResult{}, err
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return PatchSeriesResult{}, errors.Join(ErrOpenRepository, err)
+	}
+
+	return checkPatchSeries(ctx, repo, config, patches)
+}
+
+// checkPatchSeries matches the patch IDs of a loaded series against the commits of the tag
+func checkPatchSeries(ctx context.Context, repo Repository, config PatchesConfig, patches []SeriesPatch) (PatchSeriesResult, error) {
+	// resolve turns a tag name or a latest/latest-N offset into the tag and its reference
+	options := TagOptions{RepoPath: config.RepoPath}
+	resolve := func(name string) (string, *plumbing.Reference, error) {
+		tag, err := ResolveTagOffset(repo, name, SortBySemver)
+		if err != nil {
+			return "", nil, errors.Join(ErrValidationFailed, err)
+		}
+		ref, err := options.GetTagReference(ctx, repo, tag)
+		if err != nil {
+			return "", nil, errors.Join(ErrGetTagReference, err)
+		}
+		return tag, ref, nil
+	}
+
+	var result PatchSeriesResult
+	var ref, base *plumbing.Reference
+	var err error
+	if result.Tag, ref, err = resolve(config.TagName); err != nil {
+		return result, err
+	}
+	if config.SinceTag != "" {
+		if result.Since, base, err = resolve(config.SinceTag); err != nil {
+			return result, err
+		}
+	}
+
+	upstream, err := repo.GetPatchIDs(ctx, ref, base)
+	if err != nil {
+		return result, err
+	}
+
+	for _, patch := range patches {
+		// Patch IDs are computed by the repository, since their hash depends on its object format
+		if patch.PatchID, err = repo.GetPatchID(ctx, patch.content); err != nil {
+			return result, errors.Join(ErrLoadPatchSeries, fmt.Errorf("%s: %w", patch.File, err))
+		}
+		if patch.PatchID == "" {
+			result.Skipped = append(result.Skipped, patch.Subject)
+			continue
+		}
+		patch.Upstream = upstream[patch.PatchID]
+		result.Patches = append(result.Patches, patch)
+	}
+	if len(result.Patches) == 0 {
+		return result, errors.Join(ErrEmptySeries, fmt.Errorf("no patch in %s contains a diff", config.SeriesPath))
+	}
+
+	return result, nil
+}
+
+// LoadPatchSeries reads the patches of a series.
+// The path may be a directory of patch files, read in name order, or a single file holding one or more patches.
+func LoadPatchSeries(path string) ([]SeriesPatch, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, errors.Join(ErrLoadPatchSeries, err)
+	}
+
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, errors.Join(ErrLoadPatchSeries, err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			ext := strings.ToLower(filepath.Ext(entry.Name()))
+			if !entry.IsDir() && slices.Contains(patchFileExtensions, ext) {
+				files = append(files, filepath.Join(path, entry.Name()))
+			}
+		}
+		sort.Strings(files)
+	}
+
+	var patches []SeriesPatch
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return nil, errors.Join(ErrLoadPatchSeries, err)
+		}
+		for _, content := range splitMailbox(string(data)) {
+			patch := SeriesPatch{File: filepath.Base(file), Subject: patchSubject(content), content: content}
+			if patch.Subject == "" {
+				patch.Subject = patch.File
+			}
+			patches = append(patches, patch)
+		}
+	}
+
+	if len(patches) == 0 {
+		return nil, errors.Join(ErrEmptySeries, fmt.Errorf("no patch files (%s) in %s", strings.Join(patchFileExtensions, ", "), path))
+	}
+	return patches, nil
+}
+
+// mboxSeparator matches the line starting each message of an mbox, e.g. "From <commit> Mon Sep 17 00:00:00 2001"
+var mboxSeparator = regexp.MustCompile(`^From \S+ +(Mon|Tue|Wed|Thu|Fri|Sat|Sun) `)
+
+// splitMailbox splits mbox content into messages at separator lines that start the file or follow a blank line.
+// Content without such lines, e.g. a plain diff, is returned as a single patch.
+func splitMailbox(content string) []string {
+	var messages []string
+	var current strings.Builder
+	previousBlank := true
+
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if previousBlank && current.Len() > 0 && mboxSeparator.MatchString(line) {
+			messages = append(messages, current.String())
+			current.Reset()
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		previousBlank = strings.TrimSpace(line) == ""
+	}
+	if strings.TrimSpace(current.String()) != "" {
+		messages = append(messages, current.String())
+	}
+	return messages
+}
+
+// patchSubject returns the Subject header of a mail, unfolded and without [PATCH ...] prefixes
+func patchSubject(message string) string {
+	var subject string
+	inSubject := false
+	for _, line := range strings.Split(message, "\n") {
+		if line == "" {
+			// End of the headers
+			break
+		}
+		switch {
+		case inSubject && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")):
+			subject += " " + strings.TrimSpace(line)
+		case strings.HasPrefix(strings.ToLower(line), "subject:"):
+			subject = strings.TrimSpace(line[len("subject:"):])
+			inSubject = true
+		default:
+			inSubject = false
+		}
+	}
+
+	for strings.HasPrefix(subject, "[") {
+		end := strings.Index(subject, "]")
+		if end < 0 {
+			break
+		}
+		subject = strings.TrimSpace(subject[end+1:])
+	}
+	return subject
+}
+
+// PrintPatchSeriesResult prints which patches of a series the tag contains
+func PrintPatchSeriesResult(w io.Writer, result PatchSeriesResult, output OutputOptions) {
+	scope := result.Tag
+	if result.Since != "" {
+		scope = fmt.Sprintf("%s (commits since %s)", result.Tag, result.Since)
+	}
+	_, _ = fmt.Fprintf(w, "Tag: %s\n", scope)
+	_, _ = fmt.Fprintf(w, "Contained: %d/%d patches (%.2f%%)\n", result.Contained(), len(result.Patches), result.Coverage()*100.0)
+	if len(result.Skipped) > 0 {
+		_, _ = fmt.Fprintf(w, "Skipped %d patches without a diff: %s\n", len(result.Skipped), strings.Join(result.Skipped, ", "))
+	}
+	if len(result.Patches) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "\n")
+	rows := [][]string{{"STATUS", "COMMIT", "FILE", "SUBJECT"}}
+	for _, patch := range result.Patches {
+		status, commit := "missing", "-"
+		if patch.Contained() {
+			status, commit = "contained", shortHash(patch.Upstream.String())
+		}
+		rows = append(rows, []string{status, commit, patch.File, patch.Subject})
+	}
+	writeTable(w, output, 3, rows)
+}
@@ -0,0 +1,128 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// vendorPatch is a patch that is not part of any fixture history
+const vendorPatch = `From 0000000000000000000000000000000000000000 Mon Sep 17 00:00:00 2001
+From: Vendor <vendor@example.com>
+Subject: [PATCH 2/2] Add vendor
+ hook
+
+---
+ hook.go | 1 +
+ 1 file changed, 1 insertion(+)
+
+diff --git a/hook.go b/hook.go
+new file mode 100644
+--- /dev/null
++++ b/hook.go
+@@ -0,0 +1 @@
++package hook
+`
+
+// TestSplitMailbox tests splitting mbox content into patches
+func TestSplitMailbox(t *testing.T) {
+	mbox := "From 1111 Mon Sep 17 00:00:00 2001\nSubject: One\n\nFrom a quoted line\n\nbody\n\nFrom 2222 Mon Sep 17 00:00:00 2001\nSubject: Two\n\nbody\n"
+	messages := splitMailbox(mbox)
+	if len(messages) != 2 {
+		t.Fatalf("splitMailbox() returned %d messages, want 2: %q", len(messages), messages)
+	}
+	if patchSubject(messages[0]) != "One" || patchSubject(messages[1]) != "Two" {
+		t.Errorf("splitMailbox() subjects = %q, %q, want One, Two", patchSubject(messages[0]), patchSubject(messages[1]))
+	}
+
+	if plain := splitMailbox("diff --git a/x b/x\n"); len(plain) != 1 {
+		t.Errorf("splitMailbox() of a plain diff returned %d patches, want 1", len(plain))
+	}
+}
+
+// TestPatchSubject tests extracting subjects without [PATCH] prefixes
+func TestPatchSubject(t *testing.T) {
+	tests := []struct {
+		message string
+		want    string
+	}{
+		{message: "Subject: [PATCH] Fix crash\n\nbody", want: "Fix crash"},
+		{message: "Subject: [PATCH v2 3/7] [net] Fix\n  folded subject\n\nbody", want: "Fix folded subject"},
+		{message: "From: a\n\nSubject: in the body", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := patchSubject(tt.message); got != tt.want {
+			t.Errorf("patchSubject(%q) = %q, want %q", tt.message, got, tt.want)
+		}
+	}
+}
+
+// TestCheckPatchSeries tests finding the patches of a series in the history of a tag
+func TestCheckPatchSeries(t *testing.T) {
+	fixture := newReleaseFixture(t)
+
+	// Export the commits after v1.0.0 with git format-patch, as a vendor would
+	seriesDir := t.TempDir()
+	cmd := exec.Command("git", "format-patch", "--cover-letter", "-q", "-o", seriesDir, fixture.Hash("v1.0.0").String()+".."+fixture.Hash("v1.1.0").String())
+	cmd.Dir = fixture.Path()
+	if output, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("git format-patch failed: %v\n%s", err, output)
+	}
+	if err := os.WriteFile(filepath.Join(seriesDir, "0099-vendor.patch"), []byte(vendorPatch), 0644); err != nil {
+		t.Fatalf("Failed to write patch: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(seriesDir, "README"), []byte("not a patch"), 0644); err != nil {
+		t.Fatalf("Failed to write README: %v", err)
+	}
+
+	patches, err := LoadPatchSeries(seriesDir)
+	if err != nil {
+		t.Fatalf("LoadPatchSeries() error = %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		tag           string
+		since         string
+		wantContained []bool
+	}{
+		{name: "Release containing the series", tag: "v1.1.0", wantContained: []bool{true, true, false}},
+		{name: "Release before the series", tag: "v1.0.0", wantContained: []bool{false, false, false}},
+		{name: "Latest release since v1.0.0", tag: "latest", since: "v1.0.0", wantContained: []bool{true, true, false}},
+	}
+
+	repo := openFixture(t, fixture)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := PatchesConfig{RepoPath: fixture.Path(), TagName: tt.tag, SinceTag: tt.since, SeriesPath: seriesDir}
+			result, err := checkPatchSeries(context.Background(), repo, config, patches)
+			if err != nil {
+				t.Fatalf("checkPatchSeries() error = %v", err)
+			}
+
+			var contained []bool
+			for _, patch := range result.Patches {
+				contained = append(contained, patch.Contained())
+			}
+			if !reflect.DeepEqual(contained, tt.wantContained) {
+				t.Errorf("contained = %v, want %v", contained, tt.wantContained)
+			}
+			if len(result.Skipped) != 1 {
+				t.Errorf("Skipped = %v, want the cover letter", result.Skipped)
+			}
+		})
+	}
+
+	if subjects := []string{patches[1].Subject, patches[3].Subject}; !reflect.DeepEqual(subjects, []string{"Refactor internal", "Add vendor hook"}) {
+		t.Errorf("subjects = %v, want [Refactor internal, Add vendor hook]", subjects)
+	}
+
+	if _, err := LoadPatchSeries(t.TempDir()); !errors.Is(err, ErrEmptySeries) {
+		t.Errorf("LoadPatchSeries() of an empty directory error = %v, want %v", err, ErrEmptySeries)
+	}
+}
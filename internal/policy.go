@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	ErrInvalidPolicy  = errors.New("invalid policy")
+	ErrEvaluatePolicy = errors.New("failed to evaluate policy")
+	ErrPolicyFailed   = errors.New("policy failed")
+)
+
+// Policy is a set of thresholds a comparison must meet, e.g. for a hotfix release.
+// Unset rules are not checked.
+type Policy struct {
+	MinSimilarity     *float64 `yaml:"min-similarity"`      // Lowest acceptable similarity, 0-1
+	MaxNewCommits     *int     `yaml:"max-new-commits"`     // Most commits allowed only in the second tag
+	NoNewDependencies bool     `yaml:"no-new-dependencies"` // Fail if dependency manifests or lock files changed
+	NoBreakingChanges bool     `yaml:"no-breaking-changes"` // Fail if a commit only in the second tag is marked as breaking
+	MaxRisk           *float64 `yaml:"max-risk"`            // Highest acceptable risk score, 0-100
+}
+
+// Validate checks that the thresholds are in range
+func (p Policy) Validate() error {
+	if p.MinSimilarity != nil && (*p.MinSimilarity < 0 || *p.MinSimilarity > 1) {
+		return errors.Join(ErrInvalidPolicy, fmt.Errorf("min-similarity must be between 0 and 1: %v", *p.MinSimilarity))
+	}
+	if p.MaxNewCommits != nil && *p.MaxNewCommits < 0 {
+		return errors.Join(ErrInvalidPolicy, fmt.Errorf("max-new-commits must not be negative: %d", *p.MaxNewCommits))
+	}
+	if p.MaxRisk != nil && (*p.MaxRisk < 0 || *p.MaxRisk > 100) {
+		return errors.Join(ErrInvalidPolicy, fmt.Errorf("max-risk must be between 0 and 100: %v", *p.MaxRisk))
+	}
+	return nil
+}
+
+// PolicyCheck is the outcome of one rule of a policy
+type PolicyCheck struct {
+	Rule   string `json:"rule"` // The rule with its threshold, e.g. "min-similarity 0.95"
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason"` // The measured value the rule was checked against
+}
+
+// PolicyResult is the outcome of evaluating a comparison against a policy
+type PolicyResult struct {
+	Policy string        `json:"policy"`
+	Checks []PolicyCheck `json:"checks"`
+}
+
+// Passed reports whether every rule of the policy passed
+func (r PolicyResult) Passed() bool {
+	for _, check := range r.Checks {
+		if !check.Passed {
+			return false
+		}
+	}
+	return true
+}
+
+// Err returns ErrPolicyFailed with the failed rules, or nil if the policy passed
+func (r PolicyResult) Err() error {
+	var failed []string
+	for _, check := range r.Checks {
+		if !check.Passed {
+			failed = append(failed, fmt.Sprintf("%s (%s)", check.Rule, check.Reason))
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return errors.Join(ErrPolicyFailed, fmt.Errorf("policy %s: %s", r.Policy, strings.Join(failed, "; ")))
+}
+
+// EvaluatePolicy checks a comparison against the rules of a policy.
+// The second tag is treated as the release being checked, so new commits, dependency changes,
+// and breaking changes are those it adds over the first tag.
+func EvaluatePolicy(ctx context.Context, repo Repository, result CompareResult, name string, policy Policy) (PolicyResult, error) {
+	evaluation := PolicyResult{Policy: name}
+
+	if policy.MinSimilarity != nil {
+		evaluation.Checks = append(evaluation.Checks, PolicyCheck{
+			Rule:   fmt.Sprintf("min-similarity %.2f", *policy.MinSimilarity),
+			Passed: result.Similarity >= *policy.MinSimilarity,
+			Reason: fmt.Sprintf("similarity is %.2f%%", result.Similarity*100.0),
+		})
+	}
+
+	if policy.MaxNewCommits != nil {
+		evaluation.Checks = append(evaluation.Checks, PolicyCheck{
+			Rule:   fmt.Sprintf("max-new-commits %d", *policy.MaxNewCommits),
+			Passed: len(result.OnlyInTag2) <= *policy.MaxNewCommits,
+			Reason: fmt.Sprintf("%d commits only in %s", len(result.OnlyInTag2), result.Config.Tag2Name),
+		})
+	}
+
+	if policy.NoNewDependencies {
+		pathspecs := result.Config.pathspecs()
+		changed, err := FindDependencyChanges(ctx, repo, result.Tag1Ref, result.Tag2Ref, pathspecs)
+		if err != nil {
+			return evaluation, errors.Join(ErrEvaluatePolicy, err)
+		}
+		check := PolicyCheck{Rule: "no-new-dependencies", Passed: len(changed) == 0, Reason: "no dependency files changed"}
+		if len(changed) > 0 {
+			check.Reason = fmt.Sprintf("%d dependency files changed (%s)", len(changed), strings.Join(changed, ", "))
+		}
+		evaluation.Checks = append(evaluation.Checks, check)
+	}
+
+	if policy.NoBreakingChanges {
+		commits, err := repo.GetCommitObjects(hashesOf(result.OnlyInTag2))
+		if err != nil {
+			return evaluation, errors.Join(ErrEvaluatePolicy, err)
+		}
+		var breaking []string
+		for _, commit := range commits {
+			if isBreakingChange(commit.Message) {
+				breaking = append(breaking, shortHash(commit.Hash.String()))
+			}
+		}
+		check := PolicyCheck{Rule: "no-breaking-changes", Passed: len(breaking) == 0, Reason: "no commits marked as breaking"}
+		if len(breaking) > 0 {
+			check.Reason = fmt.Sprintf("%d commits marked as breaking (%s)", len(breaking), strings.Join(breaking, ", "))
+		}
+		evaluation.Checks = append(evaluation.Checks, check)
+	}
+
+	if policy.MaxRisk != nil {
+		risk := result.Risk
+		if risk == nil {
+			score, err := ScoreRisk(ctx, repo, result, result.Config.RiskWeights)
+			if err != nil {
+				return evaluation, errors.Join(ErrEvaluatePolicy, err)
+			}
+			risk = &score
+		}
+		evaluation.Checks = append(evaluation.Checks, PolicyCheck{
+			Rule:   fmt.Sprintf("max-risk %.0f", *policy.MaxRisk),
+			Passed: risk.Score <= *policy.MaxRisk,
+			Reason: fmt.Sprintf("risk score is %.0f (%s)", risk.Score, risk.Level),
+		})
+	}
+
+	return evaluation, nil
+}
+
+// PrintPolicyResult prints the outcome of each rule of a policy
+func PrintPolicyResult(w io.Writer, evaluation PolicyResult) {
+	status := "PASS"
+	if !evaluation.Passed() {
+		status = "FAIL"
+	}
+
+	_, _ = fmt.Fprintf(w, "\nPolicy %s: %s\n", evaluation.Policy, status)
+	if len(evaluation.Checks) == 0 {
+		_, _ = fmt.Fprintf(w, "  (the policy defines no rules)\n")
+	}
+	for _, check := range evaluation.Checks {
+		label := "[PASS]"
+		if !check.Passed {
+			label = "[FAIL]"
+		}
+		_, _ = fmt.Fprintf(w, "  %s %s: %s\n", label, check.Rule, check.Reason)
+	}
+}
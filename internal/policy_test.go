@@ -0,0 +1,93 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestEvaluatePolicy tests the outcome and reason of each policy rule
+func TestEvaluatePolicy(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+	minSimilarity := 0.9
+	maxNewCommits := 1
+	maxRisk := 50.0
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetDiffBetweenTags(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "--name-only", "-z", "--no-renames").
+		Return("main.go\x00go.mod\x00go.sum\x00", nil)
+	mockRepo.EXPECT().GetCommitObjects(gomock.Any()).Return([]*object.Commit{
+		{Hash: hash1, Message: "fix: handle nil\n"},
+		{Hash: hash2, Message: "feat!: new config format\n"},
+	}, nil)
+
+	result := CompareResult{
+		Config:     CompareConfig{TagOptions: TagOptions{Tag1Name: "v1.2.0", Tag2Name: "v1.2.1"}},
+		Similarity: 0.95,
+		OnlyInTag2: map[plumbing.Hash]struct{}{hash1: {}, hash2: {}},
+		Risk:       &RiskScore{Score: 20, Level: "low"},
+	}
+	policy := Policy{
+		MinSimilarity:     &minSimilarity,
+		MaxNewCommits:     &maxNewCommits,
+		NoNewDependencies: true,
+		NoBreakingChanges: true,
+		MaxRisk:           &maxRisk,
+	}
+
+	evaluation, err := EvaluatePolicy(context.Background(), mockRepo, result, "hotfix", policy)
+	if err != nil {
+		t.Fatalf("EvaluatePolicy() error = %v", err)
+	}
+
+	want := []PolicyCheck{
+		{Rule: "min-similarity 0.90", Passed: true, Reason: "similarity is 95.00%"},
+		{Rule: "max-new-commits 1", Passed: false, Reason: "2 commits only in v1.2.1"},
+		{Rule: "no-new-dependencies", Passed: false, Reason: "2 dependency files changed (go.mod, go.sum)"},
+		{Rule: "no-breaking-changes", Passed: false, Reason: "1 commits marked as breaking (0000000)"},
+		{Rule: "max-risk 50", Passed: true, Reason: "risk score is 20 (low)"},
+	}
+	if len(evaluation.Checks) != len(want) {
+		t.Fatalf("EvaluatePolicy() returned %d checks, want %d: %+v", len(evaluation.Checks), len(want), evaluation.Checks)
+	}
+	for i, check := range evaluation.Checks {
+		if check != want[i] {
+			t.Errorf("check %d = %+v, want %+v", i, check, want[i])
+		}
+	}
+
+	if evaluation.Passed() {
+		t.Error("Passed() = true, want false")
+	}
+	if err := evaluation.Err(); !errors.Is(err, ErrPolicyFailed) || !strings.Contains(err.Error(), "max-new-commits 1") {
+		t.Errorf("Err() = %v, want ErrPolicyFailed naming the failed rules", err)
+	}
+
+	var buf bytes.Buffer
+	PrintPolicyResult(&buf, evaluation)
+	if !strings.Contains(buf.String(), "Policy hotfix: FAIL") || !strings.Contains(buf.String(), "[PASS] min-similarity 0.90") {
+		t.Errorf("PrintPolicyResult() output:\n%s", buf.String())
+	}
+}
+
+// TestEvaluatePolicy_NoRules tests that a policy without rules passes
+func TestEvaluatePolicy_NoRules(t *testing.T) {
+	evaluation, err := EvaluatePolicy(context.Background(), nil, CompareResult{}, "empty", Policy{})
+	if err != nil {
+		t.Fatalf("EvaluatePolicy() error = %v", err)
+	}
+	if !evaluation.Passed() || evaluation.Err() != nil {
+		t.Errorf("EvaluatePolicy() = %+v, want a passing result", evaluation)
+	}
+}
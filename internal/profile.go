@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInvalidProfile  = errors.New("invalid path profile")
+	ErrProfileConflict = errors.New("path profiles do not support per-file or first-parent results")
+)
+
+// PathProfile selects a predefined set of paths to focus a comparison on
+type PathProfile string
+
+const (
+	ProfileNone   PathProfile = ""
+	ProfileDocker PathProfile = "docker" // Paths that affect built container images
+)
+
+// profilePatterns are the glob patterns of each profile, relative to the directory filter
+var profilePatterns = map[PathProfile][]string{
+	ProfileDocker: {
+		"**/Dockerfile",
+		"**/Dockerfile.*",
+		"**/*.Dockerfile",
+		"**/*.dockerfile",
+		"**/Containerfile",
+		"**/Containerfile.*",
+		"**/.dockerignore",
+		"**/docker-compose*.yml",
+		"**/docker-compose*.yaml",
+		"**/compose.yml",
+		"**/compose.yaml",
+		"**/docker-bake.hcl",
+		"**/docker-bake.json",
+		"**/docker/**",
+		"**/.docker/**",
+		"**/*entrypoint*.sh",
+		"**/build.sh",
+	},
+}
+
+// profileDescriptions summarize each profile for console output
+var profileDescriptions = map[PathProfile]string{
+	ProfileDocker: "Dockerfiles, .dockerignore, compose and bake files, docker/ directories, entrypoint and build scripts",
+}
+
+// ParsePathProfile converts a flag value into a PathProfile
+func ParsePathProfile(value string) (PathProfile, error) {
+	profile := PathProfile(value)
+	if profile == ProfileNone {
+		return profile, nil
+	}
+	if _, ok := profilePatterns[profile]; !ok {
+		return "", errors.Join(ErrInvalidProfile, fmt.Errorf("unknown profile: %s (expected docker)", value))
+	}
+	return profile, nil
+}
+
+// Description summarizes the paths the profile covers
+func (p PathProfile) Description() string {
+	return profileDescriptions[p]
+}
+
+// Pathspecs returns the git pathspecs of the profile, limited to directory when it is set
+func (p PathProfile) Pathspecs(directory string) []string {
+	patterns := profilePatterns[p]
+	pathspecs := make([]string, 0, len(patterns))
+	for _, pattern := range patterns {
+		if directory != "" {
+			pattern = directory + "/" + pattern
+		}
+		pathspecs = append(pathspecs, ":(glob)"+pattern)
+	}
+	return pathspecs
+}
+
+// filterPathspecs converts the directory filter and path profile into the pathspecs passed to git.
+// With a profile, its patterns are looked up in each included directory.
+func filterPathspecs(filter DirectoryFilter, profile PathProfile) []string {
+	if profile == ProfileNone {
+		return filter.Pathspecs()
+	}
+
+	directories := filter.Include
+	if len(directories) == 0 {
+		directories = []string{""}
+	}
+	var pathspecs []string
+	for _, directory := range directories {
+		pathspecs = append(pathspecs, profile.Pathspecs(directory)...)
+	}
+	return append(pathspecs, DirectoryFilter{Exclude: filter.Exclude}.Pathspecs()...)
+}
@@ -0,0 +1,62 @@
+package internal
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// TestParsePathProfile tests parsing of the -profile flag
+func TestParsePathProfile(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		want      PathProfile
+		wantError error
+	}{
+		{name: "Empty", value: "", want: ProfileNone},
+		{name: "Docker", value: "docker", want: ProfileDocker},
+		{name: "Unknown", value: "helm", wantError: ErrInvalidProfile},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePathProfile(tt.value)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ParsePathProfile() error = %v, want %v", err, tt.wantError)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePathProfile() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestFilterPathspecs tests how the directory filter and path profile combine into pathspecs
+func TestFilterPathspecs(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   DirectoryFilter
+		profile  PathProfile
+		contains string
+		wantLen  int
+	}{
+		{name: "No filter", wantLen: 0},
+		{name: "Directory only", filter: DirectoryFilter{Include: []string{"src"}}, contains: "src", wantLen: 1},
+		{name: "Docker profile", profile: ProfileDocker, contains: ":(glob)**/Dockerfile", wantLen: len(profilePatterns[ProfileDocker])},
+		{name: "Docker profile in directory", filter: DirectoryFilter{Include: []string{"services/api"}}, profile: ProfileDocker, contains: ":(glob)services/api/**/Dockerfile", wantLen: len(profilePatterns[ProfileDocker])},
+		{name: "Docker profile without excluded directory", filter: DirectoryFilter{Exclude: []string{"examples"}}, profile: ProfileDocker, contains: ":(exclude)examples", wantLen: len(profilePatterns[ProfileDocker]) + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := filterPathspecs(tt.filter, tt.profile)
+			if len(got) != tt.wantLen {
+				t.Fatalf("filterPathspecs() = %v, want %d pathspecs", got, tt.wantLen)
+			}
+			if tt.contains != "" && !slices.Contains(got, tt.contains) {
+				t.Errorf("filterPathspecs() = %v, want it to contain %q", got, tt.contains)
+			}
+		})
+	}
+}
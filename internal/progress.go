@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// ProgressReporter receives progress events of a comparison, e.g. to drive a progress display.
+// Set CompareConfig.Progress to receive them; events are delivered from the goroutine running Compare.
+type ProgressReporter interface {
+	// PhaseStarted is called when a phase, e.g. "traverse v1.0.0", begins
+	PhaseStarted(phase string)
+	// PhaseFinished is called when a phase completes successfully
+	PhaseFinished(phase string, elapsed time.Duration)
+	// CommitsTraversed is called with the number of commits found in the history of a tag
+	CommitsTraversed(tag string, commits int)
+	// BytesDiffed is called with the size of diff output read from git
+	BytesDiffed(bytes int)
+}
+
+// phaseRecorder times the phases of a run and reports them to an optional ProgressReporter
+type phaseRecorder struct {
+	timings  *Timings
+	progress ProgressReporter
+}
+
+// start begins a phase and returns the function that finishes it
+func (p phaseRecorder) start(phase string) func() {
+	if p.progress != nil {
+		p.progress.PhaseStarted(phase)
+	}
+	start := time.Now()
+	return func() {
+		p.timings.record(phase, start)
+		if p.progress != nil {
+			p.progress.PhaseFinished(phase, time.Since(start))
+		}
+	}
+}
+
+// commits reports the number of commits found for a tag
+func (p phaseRecorder) commits(tag string, commits int) {
+	if p.progress != nil {
+		p.progress.CommitsTraversed(tag, commits)
+	}
+}
+
+// diffed reports the size of diff output read from git
+func (p phaseRecorder) diffed(bytes int) {
+	if p.progress != nil {
+		p.progress.BytesDiffed(bytes)
+	}
+}
+
+// consoleProgress prints progress events as lines prefixed with the time since the first event
+type consoleProgress struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+}
+
+// NewConsoleProgress returns a ProgressReporter printing one line per event to w, e.g. stderr
+func NewConsoleProgress(w io.Writer) ProgressReporter {
+	return &consoleProgress{w: w}
+}
+
+// printf writes an event line prefixed with the elapsed time
+func (c *consoleProgress) printf(format string, args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.start.IsZero() {
+		c.start = time.Now()
+	}
+	_, _ = fmt.Fprintf(c.w, "[%7.2fs] %s\n", time.Since(c.start).Seconds(), fmt.Sprintf(format, args...))
+}
+
+func (c *consoleProgress) PhaseStarted(phase string) {
+	c.printf("%s...", phase)
+}
+
+func (c *consoleProgress) PhaseFinished(phase string, elapsed time.Duration) {
+	c.printf("%s done (%.3fs)", phase, elapsed.Seconds())
+}
+
+func (c *consoleProgress) CommitsTraversed(tag string, commits int) {
+	c.printf("%s: %d commits", tag, commits)
+}
+
+func (c *consoleProgress) BytesDiffed(bytes int) {
+	c.printf("diff: %s read", formatFileSize(int64(bytes)))
+}
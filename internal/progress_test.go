@@ -0,0 +1,88 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingProgress records progress events as strings
+type recordingProgress struct {
+	events []string
+}
+
+func (r *recordingProgress) PhaseStarted(phase string) {
+	r.events = append(r.events, "start "+phase)
+}
+
+func (r *recordingProgress) PhaseFinished(phase string, elapsed time.Duration) {
+	r.events = append(r.events, "finish "+phase)
+}
+
+func (r *recordingProgress) CommitsTraversed(tag string, commits int) {
+	r.events = append(r.events, fmt.Sprintf("commits %s %d", tag, commits))
+}
+
+func (r *recordingProgress) BytesDiffed(bytes int) {
+	r.events = append(r.events, "diffed")
+}
+
+// TestCompare_Progress tests that Compare reports each phase and the commits found for each tag
+func TestCompare_Progress(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	progress := &recordingProgress{}
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		Progress:   progress,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if _, err := NewSavedResult(context.Background(), result); err != nil {
+		t.Fatalf("NewSavedResult() error = %v", err)
+	}
+
+	want := []string{
+		"start open repository", "finish open repository",
+		"start resolve tags", "finish resolve tags",
+		"start tag metadata", "finish tag metadata",
+		"start traverse v1.0.0, v1.1.0", "finish traverse v1.0.0, v1.1.0", "commits v1.0.0 1", "commits v1.1.0 3",
+		"start set math", "finish set math",
+		"start topology", "finish topology",
+		"start diff stat", "finish diff stat", "diffed",
+	}
+	if !reflect.DeepEqual(progress.events, want) {
+		t.Errorf("progress events = %v, want %v", progress.events, want)
+	}
+
+	// The phases reported as progress are the ones recorded as timings
+	if len(result.Timings.Phases) != 7 {
+		t.Errorf("recorded %d timed phases, want 7", len(result.Timings.Phases))
+	}
+}
+
+// TestConsoleProgress tests the lines printed for each progress event
+func TestConsoleProgress(t *testing.T) {
+	var buf bytes.Buffer
+	progress := NewConsoleProgress(&buf)
+	progress.PhaseStarted("traverse v1.0.0")
+	progress.CommitsTraversed("v1.0.0", 42)
+	progress.PhaseFinished("traverse v1.0.0", 1500*time.Millisecond)
+	progress.BytesDiffed(2048)
+
+	lines := strings.Split(strings.TrimRight(buf.String(), "\n"), "\n")
+	want := []string{"traverse v1.0.0...", "v1.0.0: 42 commits", "traverse v1.0.0 done (1.500s)", "diff: 2.0 KiB read"}
+	if len(lines) != len(want) {
+		t.Fatalf("ConsoleProgress printed %d lines, want %d:\n%s", len(lines), len(want), buf.String())
+	}
+	for i, line := range lines {
+		if !strings.HasPrefix(line, "[") || !strings.HasSuffix(line, "s] "+want[i]) {
+			t.Errorf("line %d = %q, want elapsed time and %q", i, line, want[i])
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+var (
+	ErrLoadProjectConfig = errors.New("failed to load project config")
+	ErrUnknownPolicy     = errors.New("unknown policy")
+)
+
+// ProjectConfigFile is the name of the project config file looked up in the repository root
+const ProjectConfigFile = ".git-tag-similarity.yaml"
+
+// ProjectConfig holds the settings a repository shares through its project config file
+type ProjectConfig struct {
+	// Policies are named threshold sets a comparison can be evaluated against, e.g. "hotfix"
+	Policies map[string]Policy `yaml:"policies"`
+	// Services map a tag namespace of a monorepo, e.g. "service-a" in "service-a/v1.2.0", to the service's code
+	Services map[string]Service `yaml:"services"`
+	// Defaults apply to compare when the corresponding flags are not given, so a team shares one setup
+	Defaults CompareDefaults `yaml:"defaults"`
+	// Bands interpret similarity scores in the console summary and reports; empty selects defaultScoreBands
+	Bands []ScoreBand `yaml:"bands"`
+}
+
+// scoreBands returns the configured score bands, or the default ones
+func (c ProjectConfig) scoreBands() []ScoreBand {
+	if len(c.Bands) == 0 {
+		return defaultScoreBands
+	}
+	return c.Bands
+}
+
+// CompareDefaults are the compare settings a project config provides in place of missing flags
+type CompareDefaults struct {
+	Directories        []string      `yaml:"dir"`         // Directories or globs, as repeated -dir flags
+	ExcludeDirectories []string      `yaml:"exclude-dir"` // Directories or globs, as repeated -exclude-dir flags
+	Format             CompareFormat `yaml:"format"`      // Console output format, as -format
+}
+
+// directoryFilter returns the default directory filters, cleaned when the config was loaded
+func (d CompareDefaults) directoryFilter() DirectoryFilter {
+	return DirectoryFilter{Include: d.Directories, Exclude: d.ExcludeDirectories}
+}
+
+// Service is a component of a monorepo released with its own namespaced tags
+type Service struct {
+	Path string `yaml:"path"` // Directory of the service relative to the repository root
+}
+
+// LoadProjectConfig reads a project config file.
+// An empty path selects ProjectConfigFile in the repository root.
+func LoadProjectConfig(path string, repoPath string) (ProjectConfig, error) {
+	var config ProjectConfig
+	if path == "" {
+		path = filepath.Join(repoPath, ProjectConfigFile)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return config, errors.Join(ErrLoadProjectConfig, err)
+	}
+
+	// Reject unknown keys, so a misspelled rule fails loudly instead of silently never applying
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&config); err != nil && !errors.Is(err, io.EOF) {
+		return config, errors.Join(ErrLoadProjectConfig, fmt.Errorf("%s: %w", path, err))
+	}
+
+	for name, policy := range config.Policies {
+		if err := policy.Validate(); err != nil {
+			return config, errors.Join(ErrLoadProjectConfig, fmt.Errorf("%s: policy %s", path, name), err)
+		}
+	}
+
+	for name, service := range config.Services {
+		cleaned, err := cleanDirectory(service.Path)
+		if err == nil && cleaned == "" {
+			err = errors.Join(ErrInvalidDirectory, errors.New("path is required"))
+		}
+		if err != nil {
+			return config, errors.Join(ErrLoadProjectConfig, fmt.Errorf("%s: service %s", path, name), err)
+		}
+		service.Path = cleaned
+		config.Services[name] = service
+	}
+
+	filter, err := config.Defaults.directoryFilter().clean()
+	if err != nil {
+		return config, errors.Join(ErrLoadProjectConfig, fmt.Errorf("%s: defaults", path), err)
+	}
+	config.Defaults.Directories, config.Defaults.ExcludeDirectories = filter.Include, filter.Exclude
+	if config.Defaults.Format != "" {
+		if _, err := ParseCompareFormat(string(config.Defaults.Format)); err != nil {
+			return config, errors.Join(ErrLoadProjectConfig, fmt.Errorf("%s: defaults", path), err)
+		}
+	}
+
+	if err := validateScoreBands(config.Bands); err != nil {
+		return config, errors.Join(ErrLoadProjectConfig, fmt.Errorf("%s: bands", path), err)
+	}
+
+	return config, nil
+}
+
+// LoadOptionalProjectConfig is LoadProjectConfig for commands that work without a project config:
+// an empty path and no ProjectConfigFile in the repository root yield an empty config
+func LoadOptionalProjectConfig(path string, repoPath string) (ProjectConfig, error) {
+	if path == "" {
+		if _, err := os.Stat(filepath.Join(repoPath, ProjectConfigFile)); os.IsNotExist(err) {
+			return ProjectConfig{}, nil
+		}
+	}
+	return LoadProjectConfig(path, repoPath)
+}
+
+// ServiceDirectory returns the path of the service whose namespace the tags are in, e.g. "services/a" for
+// "service-a/v1.2.0". Tags outside a configured namespace, such as "main", don't count; tags of two
+// different services have no common directory.
+func (c ProjectConfig) ServiceDirectory(tagNames ...string) (string, bool) {
+	directory := ""
+	for _, name := range tagNames {
+		namespace, _ := splitTagNamespace(name)
+		service, ok := c.Services[namespace]
+		if !ok {
+			continue
+		}
+		if directory != "" && directory != service.Path {
+			return "", false
+		}
+		directory = service.Path
+	}
+	return directory, directory != ""
+}
+
+// Policy returns the named policy
+func (c ProjectConfig) Policy(name string) (Policy, error) {
+	policy, ok := c.Policies[name]
+	if !ok {
+		names := make([]string, 0, len(c.Policies))
+		for known := range c.Policies {
+			names = append(names, known)
+		}
+		sort.Strings(names)
+		return Policy{}, errors.Join(ErrUnknownPolicy, fmt.Errorf("policy %q is not defined (defined: %v)", name, names))
+	}
+	return policy, nil
+}
@@ -0,0 +1,263 @@
+package internal
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestLoadProjectConfig tests reading policies from the project config file
+func TestLoadProjectConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr error
+		check   func(t *testing.T, config ProjectConfig)
+	}{
+		{
+			name: "Policies with thresholds",
+			content: `policies:
+  hotfix:
+    min-similarity: 0.95
+    max-new-commits: 0
+    no-new-dependencies: true
+  minor:
+    min-similarity: 0.7
+`,
+			check: func(t *testing.T, config ProjectConfig) {
+				hotfix, err := config.Policy("hotfix")
+				if err != nil {
+					t.Fatalf("Policy(hotfix) error = %v", err)
+				}
+				if hotfix.MinSimilarity == nil || *hotfix.MinSimilarity != 0.95 {
+					t.Errorf("hotfix min-similarity = %v, want 0.95", hotfix.MinSimilarity)
+				}
+				if hotfix.MaxNewCommits == nil || *hotfix.MaxNewCommits != 0 {
+					t.Errorf("hotfix max-new-commits = %v, want 0", hotfix.MaxNewCommits)
+				}
+				if !hotfix.NoNewDependencies {
+					t.Error("hotfix no-new-dependencies = false, want true")
+				}
+
+				minor, err := config.Policy("minor")
+				if err != nil {
+					t.Fatalf("Policy(minor) error = %v", err)
+				}
+				if minor.MaxNewCommits != nil || minor.MaxRisk != nil {
+					t.Errorf("minor should leave unset rules nil: %+v", minor)
+				}
+
+				if _, err := config.Policy("major"); !errors.Is(err, ErrUnknownPolicy) {
+					t.Errorf("Policy(major) error = %v, want ErrUnknownPolicy", err)
+				}
+			},
+		},
+		{
+			name:    "Empty file",
+			content: "",
+			check: func(t *testing.T, config ProjectConfig) {
+				if len(config.Policies) != 0 {
+					t.Errorf("Policies = %v, want none", config.Policies)
+				}
+			},
+		},
+		{
+			name:    "Misspelled rule",
+			content: "policies:\n  hotfix:\n    min-similarty: 0.95\n",
+			wantErr: ErrLoadProjectConfig,
+		},
+		{
+			name:    "Services",
+			content: "services:\n  service-a:\n    path: services/a/\n",
+			check: func(t *testing.T, config ProjectConfig) {
+				if got := config.Services["service-a"].Path; got != "services/a" {
+					t.Errorf("service-a path = %q, want services/a", got)
+				}
+			},
+		},
+		{
+			name:    "Service outside the repository",
+			content: "services:\n  service-a:\n    path: ../a\n",
+			wantErr: ErrInvalidDirectory,
+		},
+		{
+			name:    "Service without a path",
+			content: "services:\n  service-a: {}\n",
+			wantErr: ErrInvalidDirectory,
+		},
+		{
+			name:    "Defaults",
+			content: "defaults:\n  dir: [services/**, lib/]\n  exclude-dir: ['**/testdata']\n  format: ndjson-commits\n",
+			check: func(t *testing.T, config ProjectConfig) {
+				want := DirectoryFilter{Include: []string{"services/**", "lib"}, Exclude: []string{"**/testdata"}}
+				if got := config.Defaults.directoryFilter(); !reflect.DeepEqual(got, want) {
+					t.Errorf("default directory filter = %+v, want %+v", got, want)
+				}
+				if config.Defaults.Format != CompareFormatNDJSONCommits {
+					t.Errorf("default format = %q, want ndjson-commits", config.Defaults.Format)
+				}
+			},
+		},
+		{
+			name:    "Unknown default format",
+			content: "defaults:\n  format: xml\n",
+			wantErr: ErrInvalidCompareFormat,
+		},
+		{
+			name:    "Default directory outside the repository",
+			content: "defaults:\n  dir: [../other]\n",
+			wantErr: ErrInvalidDirectory,
+		},
+		{
+			name:    "Score bands",
+			content: "bands:\n  - min: 0.9\n    label: same release\n  - min: 0\n    label: different release\n",
+			check: func(t *testing.T, config ProjectConfig) {
+				if got := InterpretSimilarity(config.scoreBands(), 0.93); got != "same release" {
+					t.Errorf("InterpretSimilarity(0.93) = %q, want same release", got)
+				}
+			},
+		},
+		{
+			name:    "Score band out of range",
+			content: "bands:\n  - min: 90\n    label: same release\n",
+			wantErr: ErrInvalidScoreBand,
+		},
+		{
+			name:    "Score band without a label",
+			content: "bands:\n  - min: 0.9\n",
+			wantErr: ErrInvalidScoreBand,
+		},
+		{
+			name:    "Threshold out of range",
+			content: "policies:\n  hotfix:\n    min-similarity: 95\n",
+			wantErr: ErrInvalidPolicy,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repoPath := t.TempDir()
+			if err := os.WriteFile(filepath.Join(repoPath, ProjectConfigFile), []byte(tt.content), 0o644); err != nil {
+				t.Fatalf("failed to write config: %v", err)
+			}
+
+			config, err := LoadProjectConfig("", repoPath)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("LoadProjectConfig() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("LoadProjectConfig() error = %v", err)
+			}
+			tt.check(t, config)
+		})
+	}
+}
+
+// TestLoadProjectConfig_ExplicitPath tests that an explicit path takes precedence over the repository root
+func TestLoadProjectConfig_ExplicitPath(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.yaml")
+	if err := os.WriteFile(path, []byte("policies:\n  release: {}\n"), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	config, err := LoadProjectConfig(path, t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadProjectConfig() error = %v", err)
+	}
+	if _, err := config.Policy("release"); err != nil {
+		t.Errorf("Policy(release) error = %v", err)
+	}
+
+	if _, err := LoadProjectConfig("", t.TempDir()); !errors.Is(err, ErrLoadProjectConfig) {
+		t.Errorf("LoadProjectConfig() without a config file error = %v, want ErrLoadProjectConfig", err)
+	}
+}
+
+// TestLoadOptionalProjectConfig tests that a missing default config file yields an empty config
+func TestLoadOptionalProjectConfig(t *testing.T) {
+	config, err := LoadOptionalProjectConfig("", t.TempDir())
+	if err != nil {
+		t.Fatalf("LoadOptionalProjectConfig() error = %v", err)
+	}
+	if len(config.Policies) != 0 || len(config.Services) != 0 {
+		t.Errorf("LoadOptionalProjectConfig() = %+v, want an empty config", config)
+	}
+
+	missing := filepath.Join(t.TempDir(), "missing.yaml")
+	if _, err := LoadOptionalProjectConfig(missing, t.TempDir()); !errors.Is(err, ErrLoadProjectConfig) {
+		t.Errorf("LoadOptionalProjectConfig() with a missing explicit path error = %v, want ErrLoadProjectConfig", err)
+	}
+}
+
+// TestServiceDirectory tests scoping namespaced tags to the directory of their service
+func TestServiceDirectory(t *testing.T) {
+	config := ProjectConfig{Services: map[string]Service{
+		"service-a": {Path: "services/a"},
+		"service-b": {Path: "services/b"},
+	}}
+
+	tests := []struct {
+		name   string
+		tags   []string
+		want   string
+		wantOK bool
+	}{
+		{name: "Both tags in one service", tags: []string{"service-a/v1.0.0", "service-a/v1.1.0"}, want: "services/a", wantOK: true},
+		{name: "Service tag against a branch", tags: []string{"service-a/v1.0.0", "main"}, want: "services/a", wantOK: true},
+		{name: "Tags of two services", tags: []string{"service-a/v1.0.0", "service-b/v1.0.0"}},
+		{name: "Unknown namespace", tags: []string{"release/1.0", "main"}},
+		{name: "Plain tags", tags: []string{"v1.0.0", "v1.1.0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := config.ServiceDirectory(tt.tags...)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("ServiceDirectory(%v) = %q, %v, want %q, %v", tt.tags, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+// TestCompare_ProjectDefaults tests that the project config's defaults apply only in place of missing flags
+func TestCompare_ProjectDefaults(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n")).
+		Tag("v1.0.0").
+		Commit("Change service", testutil.File("services/a/main.go", "package a\n")).
+		Commit("Change docs", testutil.File("docs/guide.md", "guide\n")).
+		Tag("v1.1.0")
+
+	content := "defaults:\n  dir: [services]\n  format: ndjson-commits\n"
+	if err := os.WriteFile(filepath.Join(fixture.Path(), ProjectConfigFile), []byte(content), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	tags := TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}
+
+	result, err := Compare(CompareConfig{TagOptions: tags})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Config.Directory != "services" || result.Config.Format != CompareFormatNDJSONCommits {
+		t.Errorf("Directory = %q, Format = %q, want the defaults", result.Config.Directory, result.Config.Format)
+	}
+	if len(result.OnlyInTag2) != 1 {
+		t.Errorf("OnlyInTag2 has %d commits, want 1 within services", len(result.OnlyInTag2))
+	}
+
+	// Flags win over the defaults
+	result, err = Compare(CompareConfig{TagOptions: tags, Directory: "docs", Format: CompareFormatText})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Config.Directory != "docs" || result.Config.Format != CompareFormatText {
+		t.Errorf("Directory = %q, Format = %q, want the flags", result.Config.Directory, result.Config.Format)
+	}
+}
@@ -0,0 +1,149 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+var (
+	ErrPushMetrics           = errors.New("failed to push metrics")
+	ErrInvalidPushgatewayURL = errors.New("invalid pushgateway URL")
+)
+
+// defaultPushJob is the Pushgateway job name used when none is configured
+const defaultPushJob = "git_tag_similarity"
+
+// RunMetrics is the summary of a single comparison run pushed to a Prometheus Pushgateway
+type RunMetrics struct {
+	RepoPath   string
+	Tag1Name   string
+	Tag2Name   string
+	Similarity float64
+	Shared     int
+	OnlyInTag1 int
+	OnlyInTag2 int
+	Duration   time.Duration
+	Failed     bool
+	FinishedAt time.Time
+}
+
+// NewRunMetrics summarizes a compare run; runErr is the error returned by Compare, if any
+func NewRunMetrics(config CompareConfig, result CompareResult, duration time.Duration, runErr error) RunMetrics {
+	metrics := RunMetrics{
+		RepoPath:   config.RepoPath,
+		Tag1Name:   result.Config.Tag1Name,
+		Tag2Name:   result.Config.Tag2Name,
+		Duration:   duration,
+		Failed:     runErr != nil,
+		FinishedAt: time.Now(),
+	}
+
+	if runErr == nil {
+		metrics.Similarity = result.Similarity
+		metrics.Shared = len(result.SharedCommits)
+		metrics.OnlyInTag1 = len(result.OnlyInTag1)
+		metrics.OnlyInTag2 = len(result.OnlyInTag2)
+	}
+
+	return metrics
+}
+
+// PushMetrics replaces the metrics of the run's group on the Pushgateway.
+// Metrics are grouped by job and repository so each repository keeps its own series.
+// The client's timeouts bound the push so a stalled gateway cannot hang the run, and ctx cancels it.
+func PushMetrics(ctx context.Context, client *http.Client, gatewayURL string, job string, metrics RunMetrics) error {
+	endpoint, err := pushEndpoint(gatewayURL, job, metrics.RepoPath)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, endpoint, strings.NewReader(formatRunMetrics(metrics)))
+	if err != nil {
+		return errors.Join(ErrPushMetrics, err)
+	}
+	req.Header.Set("Content-Type", "text/plain; version=0.0.4")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return errors.Join(ErrPushMetrics, err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode/100 != 2 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return errors.Join(ErrPushMetrics, fmt.Errorf("pushgateway returned %s: %s", resp.Status, strings.TrimSpace(string(body))))
+	}
+
+	return nil
+}
+
+// pushEndpoint builds the grouping-key URL for a job and repository.
+// The repository label is base64-encoded because paths contain slashes.
+func pushEndpoint(gatewayURL string, job string, repoPath string) (string, error) {
+	base, err := url.Parse(gatewayURL)
+	if err != nil || base.Scheme == "" || base.Host == "" {
+		return "", errors.Join(ErrInvalidPushgatewayURL, fmt.Errorf("expected http(s)://host[:port], got %q", gatewayURL))
+	}
+
+	if job == "" {
+		job = defaultPushJob
+	}
+
+	repo := repoPath
+	if abs, err := filepath.Abs(repoPath); err == nil {
+		repo = abs
+	}
+
+	encodedRepo := base64.RawURLEncoding.EncodeToString([]byte(repo))
+	return strings.TrimRight(base.String(), "/") + "/metrics/job/" + url.PathEscape(job) + "/repo@base64/" + encodedRepo, nil
+}
+
+// formatRunMetrics renders the metrics in the Prometheus text exposition format
+func formatRunMetrics(metrics RunMetrics) string {
+	var b bytes.Buffer
+	labels := fmt.Sprintf(`tag1="%s",tag2="%s"`, escapeLabelValue(metrics.Tag1Name), escapeLabelValue(metrics.Tag2Name))
+
+	failed := 0
+	if metrics.Failed {
+		failed = 1
+	}
+
+	writeMetric := func(name string, help string, labels string, value float64) {
+		fmt.Fprintf(&b, "# HELP %s %s\n", name, help)
+		fmt.Fprintf(&b, "# TYPE %s gauge\n", name)
+		if labels != "" {
+			fmt.Fprintf(&b, "%s{%s} %g\n", name, labels, value)
+		} else {
+			fmt.Fprintf(&b, "%s %g\n", name, value)
+		}
+	}
+
+	writeMetric("git_tag_similarity_run_duration_seconds", "Duration of the last comparison run.", "", metrics.Duration.Seconds())
+	writeMetric("git_tag_similarity_run_failed", "Whether the last comparison run failed (1) or succeeded (0).", "", float64(failed))
+	writeMetric("git_tag_similarity_last_run_timestamp_seconds", "Unix time the last comparison run finished.", "", float64(metrics.FinishedAt.Unix()))
+
+	if !metrics.Failed {
+		writeMetric("git_tag_similarity_similarity_ratio", "Jaccard similarity between the two tags.", labels, metrics.Similarity)
+		writeMetric("git_tag_similarity_shared_commits", "Number of commits shared by both tags.", labels, float64(metrics.Shared))
+		writeMetric("git_tag_similarity_unique_commits_tag1", "Number of commits only in the first tag.", labels, float64(metrics.OnlyInTag1))
+		writeMetric("git_tag_similarity_unique_commits_tag2", "Number of commits only in the second tag.", labels, float64(metrics.OnlyInTag2))
+	}
+
+	return b.String()
+}
+
+// escapeLabelValue escapes a Prometheus label value
+func escapeLabelValue(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, `"`, `\"`)
+	return strings.ReplaceAll(value, "\n", `\n`)
+}
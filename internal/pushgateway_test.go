@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// TestPushMetrics tests the request sent to the Pushgateway
+func TestPushMetrics(t *testing.T) {
+	var gotMethod, gotPath, gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotMethod = r.Method
+		gotPath = r.URL.Path
+		body, _ := io.ReadAll(r.Body)
+		gotBody = string(body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	metrics := RunMetrics{
+		RepoPath:   "/srv/repo",
+		Tag1Name:   "v1.0.0",
+		Tag2Name:   "v2.0.0",
+		Similarity: 0.75,
+		Shared:     3,
+		OnlyInTag2: 1,
+		Duration:   1500 * time.Millisecond,
+	}
+
+	if err := PushMetrics(context.Background(), server.Client(), server.URL, "release", metrics); err != nil {
+		t.Fatalf("PushMetrics() error = %v, want nil", err)
+	}
+
+	if gotMethod != http.MethodPut {
+		t.Errorf("PushMetrics() method = %s, want PUT", gotMethod)
+	}
+	if want := "/metrics/job/release/repo@base64/L3Nydi9yZXBv"; gotPath != want {
+		t.Errorf("PushMetrics() path = %s, want %s", gotPath, want)
+	}
+	for _, want := range []string{
+		`git_tag_similarity_similarity_ratio{tag1="v1.0.0",tag2="v2.0.0"} 0.75`,
+		`git_tag_similarity_unique_commits_tag2{tag1="v1.0.0",tag2="v2.0.0"} 1`,
+		"git_tag_similarity_run_duration_seconds 1.5",
+		"git_tag_similarity_run_failed 0",
+	} {
+		if !strings.Contains(gotBody, want) {
+			t.Errorf("PushMetrics() body missing %q\n%s", want, gotBody)
+		}
+	}
+}
+
+// TestPushMetricsFailure tests that failed runs omit similarity and gateway errors are reported
+func TestPushMetricsFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if strings.Contains(string(body), "similarity_ratio") {
+			t.Errorf("failed run should not report similarity:\n%s", body)
+		}
+		http.Error(w, "bad request", http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	err := PushMetrics(context.Background(), server.Client(), server.URL, "", RunMetrics{RepoPath: "/srv/repo", Failed: true})
+	if !errors.Is(err, ErrPushMetrics) {
+		t.Errorf("PushMetrics() error = %v, want %v", err, ErrPushMetrics)
+	}
+
+	if err := PushMetrics(context.Background(), http.DefaultClient, "localhost:9091", "", RunMetrics{}); !errors.Is(err, ErrInvalidPushgatewayURL) {
+		t.Errorf("PushMetrics() error = %v, want %v", err, ErrInvalidPushgatewayURL)
+	}
+}
+
+// TestPushMetricsTimeout tests that a stalled gateway fails the push instead of hanging the run
+func TestPushMetricsTimeout(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := HTTPOptions{ReadTimeout: 50 * time.Millisecond}.Client()
+	err := PushMetrics(context.Background(), client, server.URL, "", RunMetrics{RepoPath: "/srv/repo"})
+	if !errors.Is(err, ErrPushMetrics) {
+		t.Errorf("PushMetrics() error = %v, want %v", err, ErrPushMetrics)
+	}
+}
+
+// TestHTTPOptionsValidate tests that negative timeouts are rejected
+func TestHTTPOptionsValidate(t *testing.T) {
+	if err := (HTTPOptions{}).Validate(); err != nil {
+		t.Errorf("Validate() error = %v, want nil for defaults", err)
+	}
+	if err := (HTTPOptions{Timeout: -time.Second}).Validate(); !errors.Is(err, ErrInvalidHTTPOptions) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidHTTPOptions)
+	}
+	if err := (HTTPOptions{Retries: -1}).Validate(); !errors.Is(err, ErrInvalidHTTPOptions) {
+		t.Errorf("Validate() error = %v, want %v", err, ErrInvalidHTTPOptions)
+	}
+}
+
+// TestHTTPClientRetries tests which responses are retried, that bodies are replayed, and how long the client waits
+func TestHTTPClientRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		statuses     []int
+		retryAfter   string
+		retries      int
+		wantStatus   int
+		wantAttempts int
+		wantWaits    []time.Duration
+	}{
+		{name: "success", statuses: []int{200}, retries: 3, wantStatus: 200, wantAttempts: 1},
+		{name: "server errors with backoff", statuses: []int{503, 502, 200}, retries: 3, wantStatus: 200, wantAttempts: 3, wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "retries exhausted", statuses: []int{500, 500, 500}, retries: 2, wantStatus: 500, wantAttempts: 3, wantWaits: []time.Duration{time.Second, 2 * time.Second}},
+		{name: "Retry-After in seconds", statuses: []int{429, 200}, retryAfter: "7", retries: 3, wantStatus: 200, wantAttempts: 2, wantWaits: []time.Duration{7 * time.Second}},
+		{name: "Retry-After beyond the cap", statuses: []int{429}, retryAfter: "3600", retries: 3, wantStatus: 429, wantAttempts: 1},
+		{name: "secondary rate limit", statuses: []int{403, 200}, retryAfter: "1", retries: 3, wantStatus: 200, wantAttempts: 2, wantWaits: []time.Duration{time.Second}},
+		{name: "client error", statuses: []int{404}, retries: 3, wantStatus: 404, wantAttempts: 1},
+		{name: "not implemented", statuses: []int{501}, retries: 3, wantStatus: 501, wantAttempts: 1},
+		{name: "retrying disabled", statuses: []int{503}, retries: 0, wantStatus: 503, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if body, _ := io.ReadAll(r.Body); string(body) != "payload" {
+					t.Errorf("attempt %d body = %q, want the replayed payload", attempts+1, body)
+				}
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+				w.WriteHeader(tt.statuses[attempts])
+				attempts++
+			}))
+			defer server.Close()
+
+			client := HTTPOptions{Retries: tt.retries}.Client()
+			var waits []time.Duration
+			client.Transport.(*retryTransport).sleep = func(_ context.Context, d time.Duration) error {
+				waits = append(waits, d)
+				return nil
+			}
+
+			req, err := http.NewRequest(http.MethodPut, server.URL, strings.NewReader("payload"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp, err := client.Do(req)
+			if err != nil {
+				t.Fatalf("Do() error = %v", err)
+			}
+			_ = resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus || attempts != tt.wantAttempts {
+				t.Errorf("Do() = %d after %d attempts, want %d after %d", resp.StatusCode, attempts, tt.wantStatus, tt.wantAttempts)
+			}
+			if !reflect.DeepEqual(waits, tt.wantWaits) {
+				t.Errorf("waits = %v, want %v", waits, tt.wantWaits)
+			}
+		})
+	}
+}
+
+// TestHTTPClientRetries_Timeout tests that an attempt that times out is retried with a fresh timeout
+func TestHTTPClientRetries_Timeout(t *testing.T) {
+	var attempts atomic.Int32
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			<-release
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := HTTPOptions{ReadTimeout: 50 * time.Millisecond, Retries: 1, RetryBackoff: time.Millisecond}.Client()
+	resp, err := client.Get(server.URL)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || attempts.Load() != 2 {
+		t.Errorf("Get() = %d after %d attempts, want 200 after 2", resp.StatusCode, attempts.Load())
+	}
+}
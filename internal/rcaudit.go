@@ -0,0 +1,338 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrNoReleaseCandidates = errors.New("no release candidate tags found")
+
+// RCAuditConfig holds the configuration of the rc-audit command
+type RCAuditConfig struct {
+	Command  Command
+	RepoPath string
+	Pattern  string // Glob matched against tag names (e.g. "v2.*")
+	Output   OutputOptions
+	Timeout  time.Duration // Cancel the audit after this long; 0 never times out
+}
+
+// rcAuditUsage is the help of the rc-audit command
+var rcAuditUsage = commandUsage{
+	Name:        "rc-audit",
+	Summary:     "Check what changed between the last release candidate and each final release",
+	Description: "Pair each final release tag like v1.2.3 with its release candidates like v1.2.3-rc.1\nand report what changed between the last candidate and the final release across the\nwhole repository: the commits added and dropped, and the files whose content differs.\nA final release with no changes shipped exactly what was tested as a candidate.\nNamespaced tags such as service-a/v1.2.3-rc.1 pair within their namespace.",
+	Examples: []string{
+		"rc-audit -repo /path/to/repo",
+		"rc-audit -repo /path/to/repo -pattern 'v2.*'",
+	},
+}
+
+// NewRCAuditConfig parses the rc-audit command flags
+func NewRCAuditConfig(args []string) (RCAuditConfig, error) {
+	config := RCAuditConfig{Command: RCAuditCommand}
+
+	rcAuditCmd := newCommandFlagSet(rcAuditUsage)
+	rcAuditCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	rcAuditCmd.StringVar(&config.Pattern, "pattern", "", "Only audit tags matching this glob (e.g. 'v2.*')")
+	parseOutputOptions := config.Output.registerFlags(rcAuditCmd, "Maximum line width of the audit table and commit lists")
+	registerTimeoutFlag(rcAuditCmd, &config.Timeout, "the audit")
+
+	if err := rcAuditCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *RCAuditConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+
+	if c.Pattern != "" {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return errors.Join(ErrInvalidTagPattern, err)
+		}
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// RCAudit compares the last release candidate of a version with its final release
+type RCAudit struct {
+	Final        string
+	LastRC       string
+	Candidates   int // Number of release candidates of the version
+	Similarity   float64
+	Added        []CommitInfo // Commits in the final release but not in the last candidate, newest first
+	Dropped      []CommitInfo // Commits in the last candidate but not in the final release, newest first
+	ChangedFiles []string     // Files added, removed, or modified by the final release, sorted
+}
+
+// Identical reports whether the final release ships exactly the content of its last candidate
+func (a RCAudit) Identical() bool {
+	return len(a.Added) == 0 && len(a.Dropped) == 0 && len(a.ChangedFiles) == 0
+}
+
+// RCAuditReport holds the audits of every final release with candidates, newest first
+type RCAuditReport struct {
+	RepoPath string
+	Audits   []RCAudit
+	Pending  []string // Last candidates of versions without a final release yet, newest first
+}
+
+// RCAuditTags pairs the release candidates of the configured repository with their final releases
+func RCAuditTags(ctx context.Context, config RCAuditConfig) (_ RCAuditReport, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return RCAuditReport{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return RCAuditReport{}, errors.Join(ErrOpenRepository, err)
+	}
+
+	return auditReleaseCandidates(ctx, repo, config)
+}
+
+// releaseVersion groups a final release with its release candidates
+type releaseVersion struct {
+	final      *releaseTag
+	candidates []releaseTag
+}
+
+// releaseCandidateNumber returns N of a "rc.N" pre-release
+func releaseCandidateNumber(version semver) (int, bool) {
+	number, ok := strings.CutPrefix(version.prerelease, "rc.")
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(number)
+	return n, err == nil && n >= 0
+}
+
+// auditReleaseCandidates groups the tags by namespace and version, then compares the last candidate of
+// each version with its final release
+func auditReleaseCandidates(ctx context.Context, repo Repository, config RCAuditConfig) (RCAuditReport, error) {
+	report := RCAuditReport{RepoPath: config.RepoPath}
+
+	allTags, err := repo.FetchAllTags()
+	if err != nil {
+		return report, err
+	}
+
+	versions := make(map[string]*releaseVersion)
+	for _, ref := range allTags {
+		name := ref.Name().Short()
+		if config.Pattern != "" {
+			if matched, _ := path.Match(config.Pattern, name); !matched {
+				continue
+			}
+		}
+		namespace, tagVersion := splitTagNamespace(name)
+		version, ok := parseSemver(tagVersion)
+		if !ok {
+			continue
+		}
+
+		key := fmt.Sprintf("%s/%d.%d.%d", namespace, version.major, version.minor, version.patch)
+		if versions[key] == nil {
+			versions[key] = &releaseVersion{}
+		}
+		tag := releaseTag{ref: ref, version: version}
+		switch _, isCandidate := releaseCandidateNumber(version); {
+		case version.prerelease == "":
+			// "1.2.3" and "v1.2.3" are the same version; keep one of them deterministically
+			if versions[key].final == nil || name < versions[key].final.ref.Name().Short() {
+				versions[key].final = &tag
+			}
+		case isCandidate:
+			versions[key].candidates = append(versions[key].candidates, tag)
+		}
+	}
+
+	var audited []releaseTag
+	var pending []releaseTag
+	finals := make(map[plumbing.ReferenceName]*releaseVersion)
+	for _, version := range versions {
+		if len(version.candidates) == 0 {
+			continue
+		}
+		sort.SliceStable(version.candidates, func(i int, j int) bool {
+			return version.candidates[i].version.less(version.candidates[j].version)
+		})
+		last := version.candidates[len(version.candidates)-1]
+		if version.final == nil {
+			pending = append(pending, last)
+			continue
+		}
+		audited = append(audited, *version.final)
+		finals[version.final.ref.Name()] = version
+	}
+	if len(audited) == 0 && len(pending) == 0 {
+		return report, errors.Join(ErrNoReleaseCandidates, fmt.Errorf("no tags like v1.2.3-rc.1 match pattern: %q", config.Pattern))
+	}
+
+	newestFirst := func(tags []releaseTag) {
+		sort.SliceStable(tags, func(i int, j int) bool {
+			if tags[i].version != tags[j].version {
+				return tags[j].version.less(tags[i].version)
+			}
+			return tags[i].ref.Name().Short() < tags[j].ref.Name().Short()
+		})
+	}
+	newestFirst(audited)
+	newestFirst(pending)
+	for _, tag := range pending {
+		report.Pending = append(report.Pending, tag.ref.Name().Short())
+	}
+
+	for _, final := range audited {
+		candidates := finals[final.ref.Name()].candidates
+		audit, err := auditReleaseCandidate(ctx, repo, candidates[len(candidates)-1].ref, final.ref)
+		if err != nil {
+			return report, err
+		}
+		audit.Candidates = len(candidates)
+		report.Audits = append(report.Audits, audit)
+	}
+
+	return report, nil
+}
+
+// auditReleaseCandidate compares the commits and the files of the last candidate with the final release
+func auditReleaseCandidate(ctx context.Context, repo Repository, candidate *plumbing.Reference, final *plumbing.Reference) (RCAudit, error) {
+	audit := RCAudit{Final: final.Name().Short(), LastRC: candidate.Name().Short()}
+
+	candidateSet, err := repo.GetCommitSetForTag(ctx, candidate)
+	if err != nil {
+		return audit, errors.Join(ErrGetCommits, err)
+	}
+	finalSet, err := repo.GetCommitSetForTag(ctx, final)
+	if err != nil {
+		return audit, errors.Join(ErrGetCommits, err)
+	}
+	audit.Similarity = CalculateJaccardSimilarity(candidateSet, finalSet)
+
+	if audit.Added, err = loadCommitInfos(repo, commitsOnlyIn(finalSet, candidateSet), nil); err != nil {
+		return audit, errors.Join(ErrGetCommits, err)
+	}
+	if audit.Dropped, err = loadCommitInfos(repo, commitsOnlyIn(candidateSet, finalSet), nil); err != nil {
+		return audit, errors.Join(ErrGetCommits, err)
+	}
+
+	candidateFiles, err := repo.GetFileHashes(ctx, candidate)
+	if err != nil {
+		return audit, err
+	}
+	finalFiles, err := repo.GetFileHashes(ctx, final)
+	if err != nil {
+		return audit, err
+	}
+	for file, hash := range finalFiles {
+		if candidateHash, ok := candidateFiles[file]; !ok || candidateHash != hash {
+			audit.ChangedFiles = append(audit.ChangedFiles, file)
+		}
+	}
+	for file := range candidateFiles {
+		if _, ok := finalFiles[file]; !ok {
+			audit.ChangedFiles = append(audit.ChangedFiles, file)
+		}
+	}
+	sort.Strings(audit.ChangedFiles)
+
+	return audit, nil
+}
+
+// commitsOnlyIn returns the commits of set that are not in other
+func commitsOnlyIn(set map[plumbing.Hash]struct{}, other map[plumbing.Hash]struct{}) map[plumbing.Hash]struct{} {
+	only := make(map[plumbing.Hash]struct{})
+	for hash := range set {
+		if _, ok := other[hash]; !ok {
+			only[hash] = struct{}{}
+		}
+	}
+	return only
+}
+
+// PrintRCAudit prints one row per final release, then the commits and files of releases that differ
+// from their last candidate
+func PrintRCAudit(w io.Writer, report RCAuditReport, output OutputOptions) {
+	if len(report.Audits) > 0 {
+		rows := [][]string{{"FINAL", "LAST RC", "RCS", "SIMILARITY", "ADDED", "DROPPED", "FILES", "STATUS"}}
+		for _, audit := range report.Audits {
+			status := "identical"
+			if !audit.Identical() {
+				status = "changed"
+			}
+			rows = append(rows, []string{
+				audit.Final,
+				audit.LastRC,
+				fmt.Sprintf("%d", audit.Candidates),
+				fmt.Sprintf("%.2f%%", audit.Similarity*100.0),
+				fmt.Sprintf("%d", len(audit.Added)),
+				fmt.Sprintf("%d", len(audit.Dropped)),
+				fmt.Sprintf("%d", len(audit.ChangedFiles)),
+				status,
+			})
+		}
+		writeTable(w, output, 1, rows)
+	}
+
+	for _, audit := range report.Audits {
+		if audit.Identical() {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\n%s since %s:\n", audit.Final, audit.LastRC)
+		printRCCommits(w, "Added commits", audit.Added, output)
+		printRCCommits(w, "Dropped commits", audit.Dropped, output)
+		if len(audit.ChangedFiles) > 0 {
+			_, _ = fmt.Fprintf(w, "  Changed files (%d):\n", len(audit.ChangedFiles))
+			for _, file := range audit.ChangedFiles {
+				_, _ = fmt.Fprintf(w, "    - %s\n", output.FitLine(file, 6))
+			}
+		}
+	}
+
+	if len(report.Pending) > 0 {
+		if len(report.Audits) > 0 {
+			_, _ = fmt.Fprintln(w)
+		}
+		_, _ = fmt.Fprintf(w, "Release candidates without a final release: %s\n", strings.Join(report.Pending, ", "))
+	}
+}
+
+func printRCCommits(w io.Writer, title string, commits []CommitInfo, output OutputOptions) {
+	if len(commits) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "  %s (%d):\n", title, len(commits))
+	for _, commit := range commits {
+		prefix := fmt.Sprintf("    - %s : ", shortHash(commit.Hash))
+		_, _ = fmt.Fprintf(w, "%s%s\n", prefix, output.FitLine(commit.Subject, len(prefix)))
+	}
+}
@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestAuditReleaseCandidates tests the pairing of release candidates with final releases and their changes
+func TestAuditReleaseCandidates(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Tag("v1.2.0-rc.1").
+		Commit("Fix b endpoint", testutil.File("src/api/b.go", "package api\n\nconst B = 1\n")).
+		Tag("v1.2.0-rc.2").
+		Tag("v1.2.0-beta.1").
+		Commit("Bump version", testutil.File("VERSION", "1.2.0\n")).
+		Tag("v1.2.0").
+		Tag("v1.3.0-rc.1").
+		Tag("v1.3.0").
+		Commit("Start 1.4", testutil.File("src/api/c.go", "package api\n")).
+		Tag("v1.4.0-rc.1").
+		Tag("v1.4.0-rc.10").
+		Tag("v1.4.0-rc.2")
+	repo := openFixture(t, fixture)
+
+	report, err := auditReleaseCandidates(context.Background(), repo, RCAuditConfig{})
+	if err != nil {
+		t.Fatalf("auditReleaseCandidates() error = %v", err)
+	}
+
+	if len(report.Audits) != 2 {
+		t.Fatalf("Audits = %+v, want v1.3.0 and v1.2.0", report.Audits)
+	}
+	identical := report.Audits[0]
+	if identical.Final != "v1.3.0" || identical.LastRC != "v1.3.0-rc.1" || !identical.Identical() || identical.Similarity != 1 {
+		t.Errorf("Audits[0] = %+v, want v1.3.0 identical to v1.3.0-rc.1", identical)
+	}
+
+	changed := report.Audits[1]
+	if changed.Final != "v1.2.0" || changed.LastRC != "v1.2.0-rc.2" || changed.Candidates != 2 {
+		t.Errorf("Audits[1] = %+v, want v1.2.0 after 2 candidates ending with v1.2.0-rc.2", changed)
+	}
+	if len(changed.Added) != 1 || changed.Added[0].Subject != "Bump version" || len(changed.Dropped) != 0 {
+		t.Errorf("Added = %+v, Dropped = %+v, want only the version bump added", changed.Added, changed.Dropped)
+	}
+	if !reflect.DeepEqual(changed.ChangedFiles, []string{"VERSION"}) {
+		t.Errorf("ChangedFiles = %v, want [VERSION]", changed.ChangedFiles)
+	}
+
+	if !reflect.DeepEqual(report.Pending, []string{"v1.4.0-rc.10"}) {
+		t.Errorf("Pending = %v, want [v1.4.0-rc.10]", report.Pending)
+	}
+
+	filtered, err := auditReleaseCandidates(context.Background(), repo, RCAuditConfig{Pattern: "v1.2.*"})
+	if err != nil {
+		t.Fatalf("auditReleaseCandidates() with -pattern error = %v", err)
+	}
+	if len(filtered.Audits) != 1 || filtered.Audits[0].Final != "v1.2.0" || len(filtered.Pending) != 0 {
+		t.Errorf("auditReleaseCandidates() with -pattern = %+v, want v1.2.0 only", filtered)
+	}
+
+	if _, err := auditReleaseCandidates(context.Background(), repo, RCAuditConfig{Pattern: "v1.0.*"}); !errors.Is(err, ErrNoReleaseCandidates) {
+		t.Errorf("auditReleaseCandidates() without candidates error = %v, want ErrNoReleaseCandidates", err)
+	}
+}
+
+// TestAuditReleaseCandidates_Namespace tests that namespaced candidates pair only within their namespace
+func TestAuditReleaseCandidates_Namespace(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Tag("api/v2.0.0-rc.1").
+		Tag("web/v2.0.0").
+		Commit("Fix api", testutil.File("src/api/a.go", "package api\n\nconst A = 1\n")).
+		Tag("api/v2.0.0")
+	repo := openFixture(t, fixture)
+
+	report, err := auditReleaseCandidates(context.Background(), repo, RCAuditConfig{})
+	if err != nil {
+		t.Fatalf("auditReleaseCandidates() error = %v", err)
+	}
+	if len(report.Audits) != 1 || report.Audits[0].Final != "api/v2.0.0" || report.Audits[0].LastRC != "api/v2.0.0-rc.1" {
+		t.Errorf("Audits = %+v, want api/v2.0.0 against api/v2.0.0-rc.1", report.Audits)
+	}
+}
+
+// TestPrintRCAudit tests the audit table, the changes of changed releases, and pending candidates
+func TestPrintRCAudit(t *testing.T) {
+	report := RCAuditReport{
+		Audits: []RCAudit{
+			{Final: "v1.3.0", LastRC: "v1.3.0-rc.1", Candidates: 1, Similarity: 1},
+			{
+				Final: "v1.2.0", LastRC: "v1.2.0-rc.2", Candidates: 2, Similarity: 0.8,
+				Added:        []CommitInfo{{Hash: "0123456789abcdef", Subject: "Bump version"}},
+				ChangedFiles: []string{"VERSION"},
+			},
+		},
+		Pending: []string{"v1.4.0-rc.2"},
+	}
+
+	var buf bytes.Buffer
+	PrintRCAudit(&buf, report, OutputOptions{})
+	output := buf.String()
+	for _, want := range []string{
+		"v1.3.0  v1.3.0-rc.1  1    100.00%     0      0        0      identical",
+		"v1.2.0  v1.2.0-rc.2  2    80.00%      1      0        1      changed",
+		"v1.2.0 since v1.2.0-rc.2:\n  Added commits (1):\n    - 0123456 : Bump version\n  Changed files (1):\n    - VERSION\n",
+		"Release candidates without a final release: v1.4.0-rc.2",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintRCAudit() output missing %q:\n%s", want, output)
+		}
+	}
+	if strings.Contains(output, "v1.3.0 since") {
+		t.Errorf("PrintRCAudit() listed the changes of an identical release:\n%s", output)
+	}
+}
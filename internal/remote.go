@@ -0,0 +1,142 @@
+package internal
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+var ErrCloneRepository = errors.New("failed to clone repository")
+
+// remoteURLSchemes are the URL schemes accepted by -repo in place of a local path
+var remoteURLSchemes = []string{"https://", "http://", "ssh://", "git://", "file://"}
+
+// IsRemoteURL reports whether a -repo value names a remote repository instead of a local path.
+// Besides URLs, the scp-like syntax of git is accepted, e.g. git@github.com:owner/repo.git.
+func IsRemoteURL(path string) bool {
+	for _, scheme := range remoteURLSchemes {
+		if strings.HasPrefix(path, scheme) {
+			return true
+		}
+	}
+
+	// Like git, a colon before the first slash separates a host from its path, except after a drive letter
+	host, _, ok := strings.Cut(path, ":")
+	return ok && len(host) > 1 && !strings.ContainsAny(host, `/\`)
+}
+
+// cloneDirectory returns the directory a remote repository is cloned into: one per URL and depth
+// under the user cache directory, so later runs only fetch what changed
+func cloneDirectory(url string, depth int) (string, error) {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(url))
+	name := hex.EncodeToString(sum[:8])
+	if depth > 0 {
+		name += "-depth" + strconv.Itoa(depth)
+	}
+	return filepath.Join(cacheDir, "git-tag-similarity", "repos", name), nil
+}
+
+// existingClone returns the full clone of a remote repository made by an earlier run, without fetching,
+// or false when there is none
+func existingClone(url string) (string, bool, error) {
+	dir, err := cloneDirectory(url, 0)
+	if err != nil {
+		return "", false, err
+	}
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+	return dir, true, nil
+}
+
+// CloneRemote clones a remote repository as a bare repository into the cache directory, or fetches
+// the branches and tags again when it was cloned before, and returns the directory.
+// A positive depth fetches at most that many commits of each branch and tag.
+func CloneRemote(url string, depth int) (string, error) {
+	return CloneRemoteContext(context.Background(), url, depth)
+}
+
+// CloneRemoteContext is CloneRemote, killing git when ctx is done; an interrupted clone is removed
+func CloneRemoteContext(ctx context.Context, url string, depth int) (string, error) {
+	dir, err := cloneDirectory(url, depth)
+	if err != nil {
+		return "", errors.Join(ErrCloneRepository, err)
+	}
+
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		// Initialize next to the final directory and move it in place once fetched,
+		// so an interrupted clone is not mistaken for a cached one
+		if err := os.MkdirAll(filepath.Dir(dir), 0o755); err != nil {
+			return "", errors.Join(ErrCloneRepository, err)
+		}
+		tmp, err := os.MkdirTemp(filepath.Dir(dir), filepath.Base(dir)+".tmp-")
+		if err != nil {
+			return "", errors.Join(ErrCloneRepository, err)
+		}
+		defer func() { _ = os.RemoveAll(tmp) }()
+
+		// Command: git clone --bare --quiet [--depth <n> --no-single-branch] -- <url> <dir>
+		// The clone picks up the object format of the remote; the fetch adds tags outside a shallow history
+		args := []string{"clone", "--bare", "--quiet"}
+		if depth > 0 {
+			args = append(args, "--depth", strconv.Itoa(depth), "--no-single-branch")
+		}
+		args = append(args, "--", url, tmp)
+		if err := runGitContext(ctx, filepath.Dir(dir), args...); err != nil {
+			return "", errors.Join(ErrCloneRepository, fmt.Errorf("failed to clone %s", url), err)
+		}
+		if err := fetchRemote(ctx, tmp, depth); err != nil {
+			return "", errors.Join(ErrCloneRepository, fmt.Errorf("failed to fetch %s", url), err)
+		}
+		if err := os.Rename(tmp, dir); err != nil {
+			return "", errors.Join(ErrCloneRepository, err)
+		}
+		return dir, nil
+	}
+
+	if err := fetchRemote(ctx, dir, depth); err != nil {
+		return "", errors.Join(ErrCloneRepository, fmt.Errorf("failed to fetch %s", url), err)
+	}
+	return dir, nil
+}
+
+// fetchRemote mirrors the branches and tags of origin, removing those deleted upstream
+func fetchRemote(ctx context.Context, dir string, depth int) error {
+	// Command: git fetch --quiet --prune --force [--depth <n>] origin +refs/heads/*:refs/heads/* +refs/tags/*:refs/tags/*
+	args := []string{"fetch", "--quiet", "--prune", "--force"}
+	if depth > 0 {
+		args = append(args, "--depth", strconv.Itoa(depth))
+	}
+	args = append(args, "origin", "+refs/heads/*:refs/heads/*", "+refs/tags/*:refs/tags/*")
+	return runGitContext(ctx, dir, args...)
+}
+
+// runGitContext runs a git command in dir, returning its error output on failure and killing git when ctx is done
+func runGitContext(ctx context.Context, dir string, args ...string) error {
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = dir
+
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		if message := strings.TrimSpace(string(output)); message != "" {
+			return errors.Join(err, errors.New(message))
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestIsRemoteURL tests telling remote repositories from local paths
+func TestIsRemoteURL(t *testing.T) {
+	tests := []struct {
+		path string
+		want bool
+	}{
+		{path: "https://github.com/byron1st/git-tag-similarity.git", want: true},
+		{path: "ssh://git@github.com/byron1st/git-tag-similarity.git", want: true},
+		{path: "git@github.com:byron1st/git-tag-similarity.git", want: true},
+		{path: "file:///srv/git/repo.git", want: true},
+		{path: "/path/to/repo", want: false},
+		{path: "./repo:old", want: false},
+		{path: "repo", want: false},
+		{path: `C:\repos\project`, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			if got := IsRemoteURL(tt.path); got != tt.want {
+				t.Errorf("IsRemoteURL(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCloneRemote tests cloning a remote repository once, fetching it on later runs, and caching its commit sets
+func TestCloneRemote(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("HOME", t.TempDir())
+
+	fixture := newReleaseFixture(t)
+	url := "file://" + fixture.Path()
+
+	result, err := Compare(CompareConfig{TagOptions: TagOptions{RepoPath: url, Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(result.SharedCommits) != 1 || len(result.OnlyInTag2) != 2 {
+		t.Errorf("Compare() shared = %d, only in tag2 = %d, want 1 and 2", len(result.SharedCommits), len(result.OnlyInTag2))
+	}
+
+	// A tag pushed after the first clone is fetched into the cached clone
+	fixture.Commit("Fix b endpoint", testutil.File("src/api/b.go", "package api\n\nfunc B() {}\n")).Tag("v1.1.1")
+	dir, err := CloneRemote(url, 0)
+	if err != nil {
+		t.Fatalf("CloneRemote() error = %v", err)
+	}
+	repo, err := NewGitRepository(dir)
+	if err != nil {
+		t.Fatalf("NewGitRepository() error = %v", err)
+	}
+	if _, err := (&TagOptions{}).GetTagReference(context.Background(), repo, "v1.1.1"); err != nil {
+		t.Errorf("GetTagReference(v1.1.1) error = %v, want the tag fetched into the clone", err)
+	}
+	if ref, err := repo.GetBranchReference("main"); err != nil || ref.Hash() != fixture.Hash("main") {
+		t.Errorf("GetBranchReference(main) = %v, %v, want %s", ref, err, fixture.Hash("main"))
+	}
+
+	// The clone persists, so the first run cached the commit sets of its tags
+	repo.enableCommitSetCache()
+	if repo.commitSets == nil {
+		t.Fatal("enableCommitSetCache() left the cache of the clone disabled")
+	}
+	if _, ok := repo.commitSets.load(fixture.Hash("v1.1.0")); !ok {
+		t.Error("commit set of v1.1.0 is not cached for the clone")
+	}
+	stats, err := ClearCache(context.Background(), CacheConfig{Subcommand: CacheClearSubcommand, RepoPath: url})
+	if err != nil || stats.Sets != 2 {
+		t.Errorf("ClearCache(%s) = %d sets, %v, want 2", url, stats.Sets, err)
+	}
+}
@@ -0,0 +1,173 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrMissingReportInput = errors.New("result file path is required")
+	ErrWriteReport        = errors.New("failed to write report")
+)
+
+// ReportConfig holds the configuration of the report command
+type ReportConfig struct {
+	Command    Command
+	InputPath  string
+	OutputPath string
+	Format     ReportFormat
+	Template   ReportTemplateOptions
+}
+
+// reportUsage is the help of the report command
+var reportUsage = commandUsage{
+	Name:        "report",
+	Summary:     "Generate a markdown or HTML report from a saved result (or diff two)",
+	Description: "Generate a markdown or standalone HTML report from a previously saved comparison result.",
+	Examples: []string{
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0 -json result.json",
+		"report -input result.json -output report.md",
+		"report -input result.json -report-style executive -lang ko",
+		"report -input result.json -report-format html -output report.html",
+	},
+	Subcommands: []commandUsage{reportDiffUsage},
+}
+
+// NewReportConfig parses the report command flags
+func NewReportConfig(args []string) (ReportConfig, error) {
+	config := ReportConfig{Command: ReportCommand}
+	var format, style string
+
+	reportCmd := newCommandFlagSet(reportUsage)
+	reportCmd.StringVar(&config.InputPath, "input", "", "Path to a result file saved with 'compare -json'")
+	reportCmd.StringVar(&config.OutputPath, "output", "", "Path to write the report to (default: stdout)")
+	reportCmd.StringVar(&format, "report-format", string(ReportFormatMarkdown), "Report format (markdown, html); HTML reports have one layout in English")
+	reportCmd.StringVar(&style, "report-style", string(ReportStyleEngineering), "Report style (engineering, executive, security)")
+	reportCmd.StringVar(&config.Template.Language, "lang", defaultReportLanguage, "Report language (en, ja, ko)")
+	reportCmd.StringVar(&config.Template.TemplateDir, "template-dir", "", "Directory with <lang>/<style>.md.tmpl templates overriding the built-in ones")
+	parseOutputOptions := config.Template.Output.registerFlags(reportCmd, "Maximum length of commit subjects in the report")
+
+	if err := reportCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	reportStyle, err := ParseReportStyle(style)
+	if err != nil {
+		return config, err
+	}
+	config.Template.Style = reportStyle
+
+	config.Format, err = ParseReportFormat(format)
+	if err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *ReportConfig) Validate() error {
+	if c.InputPath == "" {
+		return ErrMissingReportInput
+	}
+
+	if _, err := os.Stat(c.InputPath); os.IsNotExist(err) {
+		return errors.Join(ErrLoadResult, fmt.Errorf("file does not exist: %s", c.InputPath))
+	}
+
+	if err := validateReportLanguage(c.Template); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GenerateReport loads a saved result and writes its markdown or HTML report
+func GenerateReport(config ReportConfig) error {
+	if err := config.Validate(); err != nil {
+		return errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	saved, err := LoadSavedResult(config.InputPath)
+	if err != nil {
+		return err
+	}
+
+	writeReport := WriteMarkdownReport
+	if config.Format == ReportFormatHTML {
+		writeReport = WriteHTMLReport
+	}
+
+	if config.OutputPath == "" {
+		return writeReport(os.Stdout, saved, config.Template)
+	}
+
+	file, err := os.Create(config.OutputPath)
+	if err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return writeReport(file, saved, config.Template)
+}
+
+// SourceDateEpochEnv names the environment variable that, as in reproducible builds, fixes the generation
+// time written by compare -report to these Unix seconds
+const SourceDateEpochEnv = "SOURCE_DATE_EPOCH"
+
+// WriteCompareReport writes the markdown report of a comparison to the configured -report path with the
+// built-in engineering template, so CI gets a report without a separate compare -json and report run.
+// Commit lists are sorted and the diff stat comes from git, so only the generation time varies between
+// runs; SourceDateEpochEnv pins it.
+func WriteCompareReport(ctx context.Context, result CompareResult) error {
+	saved, err := NewSavedResult(ctx, result)
+	if err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	if epoch := os.Getenv(SourceDateEpochEnv); epoch != "" {
+		seconds, err := strconv.ParseInt(epoch, 10, 64)
+		if err != nil {
+			return errors.Join(ErrWriteReport, fmt.Errorf("invalid %s: %s", SourceDateEpochEnv, epoch))
+		}
+		saved.GeneratedAt = time.Unix(seconds, 0).UTC()
+	}
+
+	file, err := os.Create(result.Config.ReportPath)
+	if err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	options := ReportTemplateOptions{Style: ReportStyleEngineering, Language: defaultReportLanguage, Output: result.Config.Output}
+	return WriteMarkdownReport(file, saved, options)
+}
+
+// WriteMarkdownReport renders a saved result as a markdown report using the selected template
+func WriteMarkdownReport(w io.Writer, saved SavedResult, options ReportTemplateOptions) error {
+	tmpl, err := loadReportTemplate(options)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(w, newReportData(saved)); err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	return nil
+}
+
+// shortHash abbreviates a hex commit hash to seven characters
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
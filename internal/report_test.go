@@ -0,0 +1,131 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteMarkdownReport tests the deterministic markdown report layout
+func TestWriteMarkdownReport(t *testing.T) {
+	saved := SavedResult{
+		FormatVersion: savedResultFormatLatest,
+		GeneratedAt:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		RepoPath:      "/path/to/repo",
+		Tag1:          "v1.0.0",
+		Tag2:          "v2.0.0",
+		Similarity:    0.5,
+		SharedCommits: []string{"0000000000000000000000000000000000000001"},
+		OnlyInTag2: []CommitInfo{
+			{Hash: "0000000000000000000000000000000000000002", Author: "Alice", Subject: "Add feature", Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		DiffStat: " main.go | 2 +-\n 1 file changed\n",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdownReport(&buf, saved, ReportTemplateOptions{}); err != nil {
+		t.Fatalf("WriteMarkdownReport() error = %v, want nil", err)
+	}
+
+	report := buf.String()
+	for _, want := range []string{
+		"# Tag Comparison: v1.0.0 vs v2.0.0",
+		"| Similarity | 50.00% |",
+		"| Total commits in `v2.0.0` | 2 |",
+		"## Commits Only in `v1.0.0` (0)\n\n_None_",
+		"- `0000000` Add feature (Alice, 2025-01-01)",
+		"```\n main.go | 2 +-\n 1 file changed\n```",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("WriteMarkdownReport() output missing %q\n%s", want, report)
+		}
+	}
+}
+
+// TestLoadSavedResult tests loading saved results and rejecting unknown format versions
+func TestLoadSavedResult(t *testing.T) {
+	tempDir := t.TempDir()
+
+	write := func(name string, saved SavedResult) string {
+		path := filepath.Join(tempDir, name)
+		data, err := json.Marshal(saved)
+		if err != nil {
+			t.Fatalf("Failed to marshal result: %v", err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatalf("Failed to write result: %v", err)
+		}
+		return path
+	}
+
+	valid := write("valid.json", SavedResult{FormatVersion: savedResultFormatLatest, Tag1: "v1.0.0", Tag2: "v2.0.0"})
+	saved, err := LoadSavedResult(valid)
+	if err != nil {
+		t.Fatalf("LoadSavedResult() error = %v, want nil", err)
+	}
+	if saved.Tag1 != "v1.0.0" || saved.Tag2 != "v2.0.0" {
+		t.Errorf("LoadSavedResult() tags = %s, %s, want v1.0.0, v2.0.0", saved.Tag1, saved.Tag2)
+	}
+
+	future := write("future.json", SavedResult{FormatVersion: savedResultFormatLatest + 1})
+	if _, err := LoadSavedResult(future); !errors.Is(err, ErrUnsupportedResult) {
+		t.Errorf("LoadSavedResult() error = %v, want %v", err, ErrUnsupportedResult)
+	}
+
+	if _, err := LoadSavedResult(filepath.Join(tempDir, "missing.json")); !errors.Is(err, ErrLoadResult) {
+		t.Errorf("LoadSavedResult() error = %v, want %v", err, ErrLoadResult)
+	}
+}
+
+// TestWriteCompareReport tests that compare -report renders the same report on every run when the generation time is pinned
+func TestWriteCompareReport(t *testing.T) {
+	t.Setenv(SourceDateEpochEnv, "1700000000")
+	fixture := newReleaseFixture(t)
+	dir := t.TempDir()
+
+	var reports []string
+	for _, name := range []string{"first.md", "second.md"} {
+		result, err := Compare(CompareConfig{
+			TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+			ReportPath: filepath.Join(dir, name),
+		})
+		if err != nil {
+			t.Fatalf("Compare() error = %v", err)
+		}
+		if err := WriteCompareReport(context.Background(), result); err != nil {
+			t.Fatalf("WriteCompareReport() error = %v", err)
+		}
+		data, err := os.ReadFile(result.Config.ReportPath)
+		if err != nil {
+			t.Fatalf("Failed to read report: %v", err)
+		}
+		reports = append(reports, string(data))
+	}
+
+	if reports[0] != reports[1] {
+		t.Errorf("WriteCompareReport() differs between runs:\n%s\n---\n%s", reports[0], reports[1])
+	}
+	for _, want := range []string{"- Generated at: 2023-11-14 22:13:20 UTC", "## Commits Only in `v1.1.0` (2)", "## Diff Stat"} {
+		if !strings.Contains(reports[0], want) {
+			t.Errorf("WriteCompareReport() output missing %q\n%s", want, reports[0])
+		}
+	}
+
+	t.Setenv(SourceDateEpochEnv, "yesterday")
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		ReportPath: filepath.Join(dir, "invalid.md"),
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if err := WriteCompareReport(context.Background(), result); !errors.Is(err, ErrWriteReport) {
+		t.Errorf("WriteCompareReport() error = %v, want %v", err, ErrWriteReport)
+	}
+}
@@ -0,0 +1,156 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+var ErrMarkdownReportInput = errors.New("markdown reports cannot be diffed")
+
+// ReportDiffSubcommand renders the changes between two saved results as a markdown document
+const ReportDiffSubcommand = "diff"
+
+// reportStyleDelta selects the template of the 'report diff' document; it is not a -report-style value
+const reportStyleDelta ReportStyle = "delta"
+
+// ReportDiffConfig holds the configuration of the report diff subcommand
+type ReportDiffConfig struct {
+	Command    Command
+	Subcommand string
+	OldPath    string // Result the previous review was generated from
+	NewPath    string // Result of the current review
+	OutputPath string
+	Template   ReportTemplateOptions
+}
+
+// reportDiffUsage is the help of the report diff command
+var reportDiffUsage = commandUsage{
+	Name:        "report diff",
+	Summary:     "Render the changes between two saved results as markdown",
+	Description: "Generate a \"what changed since the last review\" markdown document from the results\ntwo reports were generated from, e.g. after a release candidate tag was re-cut.",
+	Examples: []string{
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0-rc1 -json rc1.json",
+		"compare -repo /path/to/repo -tag1 v1.0.0 -tag2 v2.0.0-rc2 -json rc2.json",
+		"report diff -old rc1.json -new rc2.json -output rc2-delta.md",
+	},
+}
+
+// NewReportDiffConfig parses the report diff subcommand flags
+func NewReportDiffConfig(args []string) (ReportDiffConfig, error) {
+	config := ReportDiffConfig{
+		Command:    ReportCommand,
+		Subcommand: ReportDiffSubcommand,
+		Template:   ReportTemplateOptions{Style: reportStyleDelta},
+	}
+
+	diffCmd := newCommandFlagSet(reportDiffUsage)
+	diffCmd.StringVar(&config.OldPath, "old", "", "Path to the result saved with 'compare -json' for the previous review")
+	diffCmd.StringVar(&config.NewPath, "new", "", "Path to the result saved with 'compare -json' for the current review")
+	diffCmd.StringVar(&config.OutputPath, "output", "", "Path to write the markdown document to (default: stdout)")
+	diffCmd.StringVar(&config.Template.Language, "lang", defaultReportLanguage, "Document language (en, ja, ko)")
+	diffCmd.StringVar(&config.Template.TemplateDir, "template-dir", "", "Directory with a <lang>/delta.md.tmpl template overriding the built-in one")
+	parseOutputOptions := config.Template.Output.registerFlags(diffCmd, "Maximum length of commit subjects in the document")
+
+	if err := diffCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *ReportDiffConfig) Validate() error {
+	if c.OldPath == "" || c.NewPath == "" {
+		return ErrMissingHistoryInput
+	}
+
+	for _, path := range []string{c.OldPath, c.NewPath} {
+		// A rendered report no longer carries the full commit lists, so it cannot be compared
+		if ext := strings.ToLower(filepath.Ext(path)); ext == ".md" || ext == ".markdown" {
+			return errors.Join(ErrMarkdownReportInput, fmt.Errorf("%s is a markdown report; pass the result saved with 'compare -json' it was generated from", path))
+		}
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			return errors.Join(ErrLoadResult, fmt.Errorf("file does not exist: %s", path))
+		}
+	}
+
+	if err := validateReportLanguage(c.Template); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// GenerateReportDiff loads two saved results and writes the markdown document of their changes
+func GenerateReportDiff(config ReportDiffConfig) error {
+	if err := config.Validate(); err != nil {
+		return errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	diff, err := DiffHistory(HistoryConfig{OldPath: config.OldPath, NewPath: config.NewPath})
+	if err != nil {
+		return err
+	}
+
+	if config.OutputPath == "" {
+		return WriteMarkdownReportDiff(os.Stdout, diff, config.Template)
+	}
+
+	file, err := os.Create(config.OutputPath)
+	if err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	defer func() { _ = file.Close() }()
+
+	return WriteMarkdownReportDiff(file, diff, config.Template)
+}
+
+// WriteMarkdownReportDiff renders the changes between two saved results as a markdown document
+func WriteMarkdownReportDiff(w io.Writer, diff ResultDiff, options ReportTemplateOptions) error {
+	options.Style = reportStyleDelta
+	tmpl, err := loadReportTemplate(options)
+	if err != nil {
+		return err
+	}
+
+	if err := tmpl.Execute(w, newReportDiffData(diff)); err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	return nil
+}
+
+// tagMove records a tag that points to a different commit than in the previous review
+type tagMove struct {
+	Tag       string
+	OldCommit string
+	NewCommit string
+}
+
+// reportDiffData is the value passed to the delta template
+type reportDiffData struct {
+	ResultDiff
+	Moved []tagMove // Tags compared in both reviews that were re-cut in place
+}
+
+func newReportDiffData(diff ResultDiff) reportDiffData {
+	data := reportDiffData{ResultDiff: diff}
+
+	audits := [][2]*TagAudit{{diff.Old.Tag1Audit, diff.New.Tag1Audit}, {diff.Old.Tag2Audit, diff.New.Tag2Audit}}
+	for _, pair := range audits {
+		before, after := pair[0], pair[1]
+		if before == nil || after == nil || before.Tag != after.Tag || before.Commit == after.Commit {
+			continue
+		}
+		data.Moved = append(data.Moved, tagMove{Tag: after.Tag, OldCommit: before.Commit, NewCommit: after.Commit})
+	}
+
+	return data
+}
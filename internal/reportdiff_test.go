@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestNewReportDiffConfig tests parsing the report diff flags
+func TestNewReportDiffConfig(t *testing.T) {
+	config, err := NewReportDiffConfig([]string{"-old", "rc1.json", "-new", "rc2.json", "-lang", "ja"})
+	if err != nil {
+		t.Fatalf("NewReportDiffConfig() error = %v, want nil", err)
+	}
+	if config.OldPath != "rc1.json" || config.NewPath != "rc2.json" || config.Template.Language != "ja" || config.Template.Style != reportStyleDelta {
+		t.Errorf("NewReportDiffConfig() = %+v, want delta of rc1.json and rc2.json in ja", config)
+	}
+}
+
+// TestReportDiffConfig_Validate tests that both saved results are required and markdown reports are rejected
+func TestReportDiffConfig_Validate(t *testing.T) {
+	dir := t.TempDir()
+	saved := filepath.Join(dir, "rc1.json")
+	report := filepath.Join(dir, "rc1.md")
+	for _, path := range []string{saved, report} {
+		if err := os.WriteFile(path, []byte("{}"), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", path, err)
+		}
+	}
+
+	tests := []struct {
+		name    string
+		config  ReportDiffConfig
+		wantErr error
+	}{
+		{name: "Valid", config: ReportDiffConfig{OldPath: saved, NewPath: saved}},
+		{name: "Missing new", config: ReportDiffConfig{OldPath: saved}, wantErr: ErrMissingHistoryInput},
+		{name: "Markdown report", config: ReportDiffConfig{OldPath: report, NewPath: saved}, wantErr: ErrMarkdownReportInput},
+		{name: "Missing file", config: ReportDiffConfig{OldPath: saved, NewPath: filepath.Join(dir, "rc2.json")}, wantErr: ErrLoadResult},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.config.Template = ReportTemplateOptions{Style: reportStyleDelta, Language: defaultReportLanguage}
+			err := tt.config.Validate()
+			if tt.wantErr == nil {
+				if err != nil {
+					t.Errorf("Validate() error = %v, want nil", err)
+				}
+				return
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("Validate() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestWriteMarkdownReportDiff tests the delta document of a release candidate re-cut in place
+func TestWriteMarkdownReportDiff(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(2025, 1, d, 0, 0, 0, 0, time.UTC) }
+	fix := CommitInfo{Hash: "0000000000000000000000000000000000000002", Author: "Alice", Subject: "Fix crash", Date: day(2)}
+	hotfix := CommitInfo{Hash: "0000000000000000000000000000000000000004", Author: "Bob", Subject: "Hotfix login", Date: day(4)}
+	experiment := CommitInfo{Hash: "0000000000000000000000000000000000000005", Author: "Carol", Subject: "Experiment", Date: day(5)}
+
+	oldResult := SavedResult{
+		Tag1:       "v1.0.0",
+		Tag2:       "v2.0.0-rc",
+		Tag2Audit:  &TagAudit{Tag: "v2.0.0-rc", Commit: "aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		Similarity: 0.25,
+		OnlyInTag1: []CommitInfo{fix},
+		OnlyInTag2: []CommitInfo{experiment},
+		Risk:       &RiskScore{Score: 40, Level: "medium"},
+	}
+	newResult := SavedResult{
+		Tag1:          "v1.0.0",
+		Tag2:          "v2.0.0-rc",
+		Tag2Audit:     &TagAudit{Tag: "v2.0.0-rc", Commit: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"},
+		Similarity:    0.5,
+		SharedCommits: []string{fix.Hash},
+		OnlyInTag2:    []CommitInfo{hotfix},
+		Risk:          &RiskScore{Score: 20, Level: "low"},
+	}
+	diff := DiffSavedResults(oldResult, newResult)
+
+	for _, lang := range reportLanguages {
+		t.Run(lang, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := WriteMarkdownReportDiff(&buf, diff, ReportTemplateOptions{Language: lang}); err != nil {
+				t.Fatalf("WriteMarkdownReportDiff() error = %v, want nil", err)
+			}
+			for _, want := range []string{
+				"`aaaaaaa` -> `bbbbbbb`",
+				"| 25.00% | 50.00% | +25.00",
+				"`0000000` Hotfix login (Bob, 2025-01-04)",
+				"`0000000` Fix crash (Alice, 2025-01-02)",
+				"`0000000` Experiment (Carol, 2025-01-05)",
+				"| 40 (medium) | 20 (low) |",
+			} {
+				if !strings.Contains(buf.String(), want) {
+					t.Errorf("WriteMarkdownReportDiff() output missing %q\n%s", want, buf.String())
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"io"
+)
+
+var ErrInvalidReportFormat = errors.New("invalid report format")
+
+// ReportFormat is the file format of a report
+type ReportFormat string
+
+const (
+	ReportFormatMarkdown ReportFormat = "markdown"
+	// ReportFormatHTML is a standalone page with a similarity gauge, commit lists, and a collapsible diff stat
+	ReportFormatHTML ReportFormat = "html"
+)
+
+// htmlReportTemplatePath is the embedded template of HTML reports, which has one layout for all styles
+const htmlReportTemplatePath = "templates/report.html.tmpl"
+
+// ParseReportFormat converts a flag value into a ReportFormat
+func ParseReportFormat(value string) (ReportFormat, error) {
+	switch ReportFormat(value) {
+	case ReportFormatMarkdown, ReportFormatHTML:
+		return ReportFormat(value), nil
+	default:
+		return "", errors.Join(ErrInvalidReportFormat, fmt.Errorf("unknown report format: %s (expected markdown or html)", value))
+	}
+}
+
+// htmlReportData adds the size of the commit union, the base of the commit bar, to the report data
+type htmlReportData struct {
+	reportData
+	Union int
+}
+
+// WriteHTMLReport renders a saved result as a standalone HTML page. The stylesheet and charts are inline,
+// so the file can be attached to a review or served as is.
+func WriteHTMLReport(w io.Writer, saved SavedResult, options ReportTemplateOptions) error {
+	source, err := builtinTemplates.ReadFile(htmlReportTemplatePath)
+	if err != nil {
+		return errors.Join(ErrLoadReportTemplate, err)
+	}
+
+	fit := func(text string) string { return options.Output.FitLine(text, 0) }
+	tmpl, err := htmltemplate.New("html").Funcs(reportTemplateFuncs).Funcs(htmltemplate.FuncMap{
+		"fit": fit,
+		// points100 scales a fraction to the 0-100 length of the gauge arc
+		"points100": func(value float64) string { return fmt.Sprintf("%.2f", value*100.0) },
+		// share is the percentage of total that part makes up, for the widths of the commit bar
+		"share": func(part int, total int) string {
+			if total == 0 {
+				return "0"
+			}
+			return fmt.Sprintf("%.2f", float64(part)*100.0/float64(total))
+		},
+		"gaugeColor": gaugeColor,
+	}).Parse(string(source))
+	if err != nil {
+		return errors.Join(ErrLoadReportTemplate, err)
+	}
+
+	data := newReportData(saved)
+	union := len(saved.SharedCommits) + len(saved.OnlyInTag1) + len(saved.OnlyInTag2)
+	if err := tmpl.Execute(w, htmlReportData{reportData: data, Union: union}); err != nil {
+		return errors.Join(ErrWriteReport, err)
+	}
+	return nil
+}
+
+// gaugeColor colors the similarity gauge red below 50%, amber below 80%, and green above
+func gaugeColor(similarity float64) string {
+	switch {
+	case similarity < 0.5:
+		return "#cf222e"
+	case similarity < 0.8:
+		return "#bf8700"
+	default:
+		return "#1a7f37"
+	}
+}
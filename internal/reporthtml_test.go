@@ -0,0 +1,50 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestWriteHTMLReport tests the charts, commit lists, and escaping of the HTML report
+func TestWriteHTMLReport(t *testing.T) {
+	saved := SavedResult{
+		FormatVersion: savedResultFormatLatest,
+		GeneratedAt:   time.Date(2025, 1, 2, 3, 4, 5, 0, time.UTC),
+		RepoPath:      "/path/to/repo",
+		Tag1:          "v1.0.0",
+		Tag2:          "v2.0.0",
+		Similarity:    0.5,
+		SharedCommits: []string{"0000000000000000000000000000000000000001"},
+		OnlyInTag2: []CommitInfo{
+			{Hash: "0000000000000000000000000000000000000002", Author: "Alice", Subject: "Escape <script> in titles", Date: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Topology: TopologySideBranch},
+		},
+		DiffStat: " main.go | 2 +-\n 1 file changed\n",
+	}
+
+	var buf bytes.Buffer
+	if err := WriteHTMLReport(&buf, saved, ReportTemplateOptions{}); err != nil {
+		t.Fatalf("WriteHTMLReport() error = %v", err)
+	}
+
+	report := buf.String()
+	for _, want := range []string{
+		"<title>Tag Comparison: v1.0.0 vs v2.0.0</title>",
+		`stroke-dasharray="50.00 100"`,
+		`<div class="shared" style="width: 50.00%"></div>`,
+		`<div class="tag1" style="width: 0.00%"></div>`,
+		"<summary>Commits only in <code>v1.0.0</code> (0)</summary>\n<p><em>None</em></p>",
+		"<td>Escape &lt;script&gt; in titles <span class=\"badge\">merged side branch</span></td>",
+		"<pre> main.go | 2 &#43;-\n 1 file changed</pre>",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("WriteHTMLReport() output missing %q\n%s", want, report)
+		}
+	}
+
+	if _, err := ParseReportFormat("pdf"); !errors.Is(err, ErrInvalidReportFormat) {
+		t.Errorf("ParseReportFormat(pdf) error = %v, want ErrInvalidReportFormat", err)
+	}
+}
@@ -0,0 +1,174 @@
+package internal
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	ErrInvalidReportStyle    = errors.New("invalid report style")
+	ErrInvalidReportLanguage = errors.New("invalid report language")
+	ErrLoadReportTemplate    = errors.New("failed to load report template")
+)
+
+//go:embed templates
+var builtinTemplates embed.FS
+
+// ReportStyle selects the audience a report is written for
+type ReportStyle string
+
+const (
+	ReportStyleEngineering ReportStyle = "engineering"
+	ReportStyleExecutive   ReportStyle = "executive"
+	ReportStyleSecurity    ReportStyle = "security"
+)
+
+// reportLanguages lists the languages with built-in templates
+var reportLanguages = []string{"en", "ja", "ko"}
+
+// defaultReportLanguage is used when no language is configured
+const defaultReportLanguage = "en"
+
+// ReportTemplateOptions selects the template used to render a report
+type ReportTemplateOptions struct {
+	Style       ReportStyle
+	Language    string
+	TemplateDir string // Directory checked for <lang>/<style>.md.tmpl before the built-in templates
+	Output      OutputOptions
+}
+
+// ParseReportStyle converts a flag value into a ReportStyle
+func ParseReportStyle(value string) (ReportStyle, error) {
+	switch ReportStyle(value) {
+	case ReportStyleEngineering, ReportStyleExecutive, ReportStyleSecurity:
+		return ReportStyle(value), nil
+	default:
+		return "", errors.Join(ErrInvalidReportStyle, fmt.Errorf("unknown report style: %s (expected engineering, executive, or security)", value))
+	}
+}
+
+// validateReportLanguage checks that a built-in template or an on-disk override exists for the language
+func validateReportLanguage(options ReportTemplateOptions) error {
+	for _, lang := range reportLanguages {
+		if options.Language == lang {
+			return nil
+		}
+	}
+
+	if options.TemplateDir != "" {
+		if _, err := os.Stat(templatePath(options.TemplateDir, options)); err == nil {
+			return nil
+		}
+	}
+
+	return errors.Join(ErrInvalidReportLanguage, fmt.Errorf("no template for language: %s (built-in: %s)", options.Language, strings.Join(reportLanguages, ", ")))
+}
+
+// templatePath returns the location of a template relative to root
+func templatePath(root string, options ReportTemplateOptions) string {
+	return filepath.Join(root, options.Language, string(options.Style)+".md.tmpl")
+}
+
+// loadReportTemplate returns the template for the configured style and language,
+// preferring an override in TemplateDir over the built-in template
+func loadReportTemplate(options ReportTemplateOptions) (*template.Template, error) {
+	if options.Style == "" {
+		options.Style = ReportStyleEngineering
+	}
+	if options.Language == "" {
+		options.Language = defaultReportLanguage
+	}
+
+	var source []byte
+	var err error
+	if options.TemplateDir != "" {
+		source, err = os.ReadFile(templatePath(options.TemplateDir, options))
+		if err != nil && !os.IsNotExist(err) {
+			return nil, errors.Join(ErrLoadReportTemplate, err)
+		}
+	}
+	if source == nil {
+		// Embedded paths always use forward slashes
+		source, err = builtinTemplates.ReadFile("templates/" + options.Language + "/" + string(options.Style) + ".md.tmpl")
+		if err != nil {
+			return nil, errors.Join(ErrLoadReportTemplate, err)
+		}
+	}
+
+	// fit shortens commit subjects to the configured width
+	fit := func(text string) string { return options.Output.FitLine(text, 0) }
+
+	tmpl, err := template.New(string(options.Style)).Funcs(reportTemplateFuncs).Funcs(template.FuncMap{"fit": fit}).Parse(string(source))
+	if err != nil {
+		return nil, errors.Join(ErrLoadReportTemplate, err)
+	}
+	return tmpl, nil
+}
+
+// reportData is the value passed to report templates
+type reportData struct {
+	SavedResult
+	Tag1Total int
+	Tag2Total int
+}
+
+func newReportData(saved SavedResult) reportData {
+	saved.DiffStat = strings.TrimRight(saved.DiffStat, "\n")
+	if strings.TrimSpace(saved.DiffStat) == "" {
+		saved.DiffStat = ""
+	}
+
+	return reportData{
+		SavedResult: saved,
+		Tag1Total:   len(saved.OnlyInTag1) + len(saved.SharedCommits),
+		Tag2Total:   len(saved.OnlyInTag2) + len(saved.SharedCommits),
+	}
+}
+
+// reportTemplateFuncs are the helper functions available to report templates
+var reportTemplateFuncs = template.FuncMap{
+	"percent":   func(value float64) string { return fmt.Sprintf("%.2f%%", value*100.0) },
+	"short":     shortHash,
+	"date":      func(t time.Time) string { return t.Format("2006-01-02") },
+	"datetime":  func(t time.Time) string { return t.Format("2006-01-02 15:04:05 MST") },
+	"top":       topItems,
+	"authors":   uniqueAuthors,
+	"size":      formatFileSize,
+	"sizedelta": formatSizeDelta,
+	"points":    func(delta float64) string { return fmt.Sprintf("%+.2f", delta*100.0) },
+	"change":    func(before int, after int) string { return fmt.Sprintf("%+d", after-before) },
+}
+
+// topItems returns at most the first n items of a slice
+func topItems(n int, items any) any {
+	value := reflect.ValueOf(items)
+	if value.Kind() != reflect.Slice || value.Len() <= n {
+		return items
+	}
+	return value.Slice(0, n).Interface()
+}
+
+// uniqueAuthors returns the sorted, de-duplicated "Name <email>" authors of the given commits
+func uniqueAuthors(commitLists ...[]CommitInfo) []string {
+	seen := make(map[string]struct{})
+	for _, commits := range commitLists {
+		for _, commit := range commits {
+			seen[fmt.Sprintf("%s <%s>", commit.Author, commit.Email)] = struct{}{}
+		}
+	}
+
+	authors := make([]string, 0, len(seen))
+	for author := range seen {
+		authors = append(authors, author)
+	}
+	sort.Strings(authors)
+	return authors
+}
@@ -0,0 +1,87 @@
+package internal
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBuiltinReportTemplates tests that every built-in style renders in every language
+func TestBuiltinReportTemplates(t *testing.T) {
+	saved := SavedResult{
+		Tag1:       "v1.0.0",
+		Tag2:       "v2.0.0",
+		OnlyInTag1: []CommitInfo{{Hash: "0000000000000000000000000000000000000001", Author: "Alice", Email: "alice@example.com", Subject: "Fix bug"}},
+		PathBreakdown: []FileSimilarity{
+			{Path: "internal/", OnlyInTag1: 1, Similarity: 0.0},
+		},
+		CategoryBreakdown: []FileSimilarity{
+			{Path: "application", OnlyInTag1: 1, Similarity: 0.0},
+		},
+		Tag1Tests: &TestChangeStats{Commits: 1, CodeFiles: 1},
+		Tag2Tests: &TestChangeStats{},
+		Risk:      &RiskScore{Score: 42, Level: "medium", Factors: []RiskFactor{{Signal: RiskChurn, Weight: 3, Value: 0.5, Detail: "2500 lines changed in 10 files"}}},
+		DiffStat:  " main.go | 1 +\n",
+	}
+
+	for _, style := range []ReportStyle{ReportStyleEngineering, ReportStyleExecutive, ReportStyleSecurity} {
+		for _, lang := range reportLanguages {
+			t.Run(string(style)+"/"+lang, func(t *testing.T) {
+				var buf bytes.Buffer
+				err := WriteMarkdownReport(&buf, saved, ReportTemplateOptions{Style: style, Language: lang})
+				if err != nil {
+					t.Fatalf("WriteMarkdownReport() error = %v, want nil", err)
+				}
+				if !bytes.Contains(buf.Bytes(), []byte("v2.0.0")) {
+					t.Errorf("WriteMarkdownReport() output does not mention the compared tag:\n%s", buf.String())
+				}
+				if style == ReportStyleExecutive && !bytes.Contains(buf.Bytes(), []byte("42/100 (medium)")) {
+					t.Errorf("WriteMarkdownReport() executive output does not show the risk score:\n%s", buf.String())
+				}
+			})
+		}
+	}
+}
+
+// TestReportTemplateOverride tests that templates on disk take precedence over built-in ones
+func TestReportTemplateOverride(t *testing.T) {
+	templateDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(templateDir, "fr"), 0755); err != nil {
+		t.Fatalf("Failed to create template directory: %v", err)
+	}
+	override := "Comparaison {{.Tag1}} / {{.Tag2}} : {{percent .Similarity}}\n"
+	if err := os.WriteFile(filepath.Join(templateDir, "fr", "executive.md.tmpl"), []byte(override), 0644); err != nil {
+		t.Fatalf("Failed to write template: %v", err)
+	}
+
+	options := ReportTemplateOptions{Style: ReportStyleExecutive, Language: "fr", TemplateDir: templateDir}
+	if err := validateReportLanguage(options); err != nil {
+		t.Fatalf("validateReportLanguage() error = %v, want nil", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMarkdownReport(&buf, SavedResult{Tag1: "a", Tag2: "b", Similarity: 0.5}, options); err != nil {
+		t.Fatalf("WriteMarkdownReport() error = %v, want nil", err)
+	}
+	if want := "Comparaison a / b : 50.00%\n"; buf.String() != want {
+		t.Errorf("WriteMarkdownReport() = %q, want %q", buf.String(), want)
+	}
+
+	// Styles without an override fall back to the built-in template, which does not exist for fr
+	options.Style = ReportStyleSecurity
+	if err := validateReportLanguage(options); !errors.Is(err, ErrInvalidReportLanguage) {
+		t.Errorf("validateReportLanguage() error = %v, want %v", err, ErrInvalidReportLanguage)
+	}
+}
+
+// TestParseReportStyle tests parsing of the -report-style flag value
+func TestParseReportStyle(t *testing.T) {
+	if _, err := ParseReportStyle("executive"); err != nil {
+		t.Errorf("ParseReportStyle(executive) error = %v, want nil", err)
+	}
+	if _, err := ParseReportStyle("marketing"); !errors.Is(err, ErrInvalidReportStyle) {
+		t.Errorf("ParseReportStyle(marketing) error = %v, want %v", err, ErrInvalidReportStyle)
+	}
+}
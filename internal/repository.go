@@ -3,9 +3,20 @@ package internal
 
 import (
 	"bufio"
+	"bytes"
+	"context"
 	"errors"
+	"fmt"
+	"io"
+	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
@@ -18,40 +29,209 @@ var (
 	ErrGetCommit       = errors.New("failed to get commit")
 	ErrDereferenceTag  = errors.New("failed to dereference tag")
 	ErrTraverseCommits = errors.New("failed to traverse commits")
+	ErrResolveBranch   = errors.New("failed to resolve branch")
+	ErrResolveRevision = errors.New("failed to resolve revision")
+	ErrCheckAncestry   = errors.New("failed to check commit ancestry")
+	ErrArchiveTag      = errors.New("failed to archive tag")
+	ErrReadBlob        = errors.New("failed to read blob")
+	ErrReadExcludes    = errors.New("failed to read exclude files")
 )
 
-// Repository is an interface that abstracts Git operations for testability
+// Repository is an interface that abstracts Git operations for testability.
+// Implementations must be safe for concurrent use, so one opened repository can be shared by the
+// workers of matrix and similar commands. Commits and tags returned by the methods are safe to use
+// for their fields (hash, author, message, parents); methods that read more objects, such as
+// Commit.Tree, are not part of the guarantee.
+// Methods that traverse history or run native git commands take a context and stop with its error
+// when it is done; reads of single objects and references do not.
 type Repository interface {
 	FetchAllTags() ([]*plumbing.Reference, error)
-	GetCommitSetForTag(ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error)
-	GetCommitSetForTagFilteredByDirectory(ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error)
+	GetCommitSetForTag(ctx context.Context, ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error)
+	ForEachCommitInTag(ctx context.Context, ref *plumbing.Reference, fn func(hash plumbing.Hash) error) error
+	GetCommitSetForTagFilteredByDirectory(ctx context.Context, ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error)
+	GetCommitSetForTagFilteredByPathspecs(ctx context.Context, ref *plumbing.Reference, pathspecs []string) (map[plumbing.Hash]struct{}, error)
+	GetFirstParentCommitSet(ctx context.Context, ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error)
+	GetIntroducingCommits(ctx context.Context, ref *plumbing.Reference) (map[plumbing.Hash]plumbing.Hash, error)
 	GetCommitObject(hash plumbing.Hash) (*object.Commit, error)
-	GetDiffBetweenTags(tag1 *plumbing.Reference, tag2 *plumbing.Reference, directory string) (string, error)
+	GetCommitObjects(hashes []plumbing.Hash) ([]*object.Commit, error)
+	GetTagCommit(ref *plumbing.Reference) (*object.Commit, error)
+	GetTagObject(ref *plumbing.Reference) (*object.Tag, error)
+	GetDiffBetweenTags(ctx context.Context, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, diffArgs ...string) (string, error)
+	GetLimitedDiffBetweenTags(ctx context.Context, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, limit int64, diffArgs ...string) (string, bool, error)
+	GetFileCommits(ctx context.Context, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string) (map[string][]plumbing.Hash, error)
+	GetFileSizes(ctx context.Context, ref *plumbing.Reference) (map[string]int64, error)
+	GetFileHashes(ctx context.Context, ref *plumbing.Reference) (map[string]plumbing.Hash, error)
+	GetLineCount(ctx context.Context, ref *plumbing.Reference, pathspecs []string) (int, error)
+	UnreadableCommits() []plumbing.Hash
+	ApproximatedDirectories() []string
+	GetBranchReference(name string) (*plumbing.Reference, error)
+	ResolveRevision(ctx context.Context, revision string) (*plumbing.Reference, error)
+	IsAncestor(ctx context.Context, ancestor plumbing.Hash, descendant plumbing.Hash) (bool, error)
+	IsFirstParentAncestor(ctx context.Context, ancestor plumbing.Hash, descendant plumbing.Hash) (bool, error)
+	GetBranchesContaining(ctx context.Context, hash plumbing.Hash) ([]*plumbing.Reference, error)
+	GetBranchesPointingAt(hash plumbing.Hash) ([]*plumbing.Reference, error)
+	GetRootCommits(ctx context.Context) ([]plumbing.Hash, error)
+	GetRemoteURLs() (map[string]string, error)
+	GetPatchIDs(ctx context.Context, ref *plumbing.Reference, base *plumbing.Reference) (map[string]plumbing.Hash, error)
+	GetPatchID(ctx context.Context, patch string) (string, error)
+	WriteArchive(ctx context.Context, ref *plumbing.Reference, format string, prefix string, w io.Writer) error
+	StreamCommitFiles(ctx context.Context, hashes []plumbing.Hash, visit func(commit *object.Commit, files []string) error) error
+	HasDirectory(ref *plumbing.Reference, directory string) (bool, error)
+	GetBlob(hash plumbing.Hash) ([]byte, error)
+	GetExcludePatterns(ctx context.Context) ([]string, error)
 }
 
 // GitRepository is a concrete implementation of Repository using go-git
+//
+// A GitRepository is safe for concurrent use. A go-git storer must not be shared between goroutines,
+// so every method reads objects through its own repository handle, borrowed from a pool for the
+// duration of the call; native git commands run as separate processes.
 type GitRepository struct {
 	path string
-	repo *git.Repository
+
+	// readers holds the idle repository handles; a call that finds none opens another one
+	readers chan *git.Repository
+
+	// commits caches commit objects, which are re-read by console output, saved results, and exports
+	commits *commitCache
+
+	// strict aborts traversals on the first missing or corrupt object instead of skipping it.
+	// It is set right after opening, before the repository is shared.
+	strict bool
+
+	// dates limits traversals to the commits committed within the range, as git log --since and --until do.
+	// It is set right after opening, before the repository is shared.
+	dates DateRange
+
+	// unreadable records the commits skipped by traversals because they could not be read, and
+	// approximated the directory filters whose commits were found by commitSetTouchingDirectory
+	unreadable   map[plumbing.Hash]struct{}
+	approximated map[string]struct{}
+	unreadableMu sync.Mutex
+
+	// commitSets caches the commit sets of traversed commits between runs; nil disables it.
+	// It is set right after opening, before the repository is shared.
+	commitSets *commitSetCache
+
+	// index is the tag index built by the index command, loaded by tagIndex on first use
+	index     *tagIndex
+	indexOnce sync.Once
 }
 
-// NewGitRepository creates a new GitRepository instance
+// NewGitRepository creates a new GitRepository instance.
+// The path may be the main checkout, a linked worktree, or the URL of a remote repository,
+// which is cloned with its full history first (see CloneRemote).
 func NewGitRepository(path string) (*GitRepository, error) {
-	repo, err := git.PlainOpen(path)
+	return OpenGitRepository(path, 0)
+}
+
+// OpenGitRepository is NewGitRepository with a clone depth for remote repositories; 0 clones the full history
+func OpenGitRepository(path string, cloneDepth int) (*GitRepository, error) {
+	return OpenGitRepositoryContext(context.Background(), path, cloneDepth)
+}
+
+// OpenGitRepositoryContext is OpenGitRepository, killing the clone of a remote repository when ctx is done.
+// The methods of the repository take their own context.
+func OpenGitRepositoryContext(ctx context.Context, path string, cloneDepth int) (*GitRepository, error) {
+	if IsRemoteURL(path) {
+		dir, err := CloneRemoteContext(ctx, path, cloneDepth)
+		if err != nil {
+			return nil, errors.Join(ErrOpenRepository, err)
+		}
+		path = dir
+	}
+
+	repo, err := openRepository(path)
 	if err != nil {
 		return nil, errors.Join(ErrOpenRepository, err)
 	}
-	return &GitRepository{
-		path: path,
-		repo: repo,
-	}, nil
+	if err := checkObjectFormat(repo); err != nil {
+		return nil, errors.Join(ErrOpenRepository, err)
+	}
+	gr := &GitRepository{
+		path:       path,
+		readers:    make(chan *git.Repository, commitLoadWorkers),
+		commits:    newCommitCache(defaultCommitCacheSize),
+		unreadable: make(map[plumbing.Hash]struct{}),
+	}
+	gr.returnReader(repo)
+	return gr, nil
+}
+
+// openRepository opens the repository at path.
+// Linked worktrees have a .git file pointing to a directory that holds only their HEAD and index;
+// refs, objects, and config are read from the common directory it names.
+// Objects missing locally are read from the alternate object directories the repository lists.
+func openRepository(path string) (*git.Repository, error) {
+	repo, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		return nil, err
+	}
+	return withAlternates(repo)
+}
+
+// borrowReader returns an idle repository handle, opening a new one if none is available
+func (gr *GitRepository) borrowReader() (*git.Repository, error) {
+	select {
+	case reader := <-gr.readers:
+		return reader, nil
+	default:
+		reader, err := openRepository(gr.path)
+		if err != nil {
+			return nil, errors.Join(ErrOpenRepository, err)
+		}
+		return reader, nil
+	}
+}
+
+// returnReader puts a handle back into the pool, dropping it if the pool is full
+func (gr *GitRepository) returnReader(reader *git.Repository) {
+	select {
+	case gr.readers <- reader:
+	default:
+	}
+}
+
+// canceled returns an error when ctx is done, to stop a traversal between commits
+func canceled(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return errors.Join(ErrTraverseCommits, err)
+	}
+	return nil
+}
+
+// markUnreadable records a commit skipped because it could not be read
+func (gr *GitRepository) markUnreadable(hash plumbing.Hash) {
+	gr.unreadableMu.Lock()
+	defer gr.unreadableMu.Unlock()
+	gr.unreadable[hash] = struct{}{}
+}
+
+// unreadableCount returns the number of commits recorded as unreadable so far
+func (gr *GitRepository) unreadableCount() int {
+	gr.unreadableMu.Lock()
+	defer gr.unreadableMu.Unlock()
+	return len(gr.unreadable)
 }
 
 // resolveTagToCommit resolves a tag reference to its commit object.
 // Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+// The handle it reads through goes back to the pool, so only the fields of the commit may be used;
+// callers that read its tree use resolveCommit with a handle they hold.
 func (gr *GitRepository) resolveTagToCommit(ref *plumbing.Reference) (*object.Commit, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrDereferenceTag, err)
+	}
+	defer gr.returnReader(reader)
+
+	return resolveCommit(reader, ref)
+}
+
+// resolveCommit resolves a tag reference to its commit object through the given repository handle
+func resolveCommit(repo *git.Repository, ref *plumbing.Reference) (*object.Commit, error) {
 	// Try to get tag object first (annotated tag)
-	tagObj, err := gr.repo.TagObject(ref.Hash())
+	tagObj, err := repo.TagObject(ref.Hash())
 	if err == nil {
 		// Annotated tag - dereference to commit
 		commit, err := tagObj.Commit()
@@ -62,7 +242,7 @@ func (gr *GitRepository) resolveTagToCommit(ref *plumbing.Reference) (*object.Co
 	}
 
 	// Not a tag object - try commit directly (lightweight tag)
-	commit, err := gr.repo.CommitObject(ref.Hash())
+	commit, err := repo.CommitObject(ref.Hash())
 	if err != nil {
 		return nil, errors.Join(ErrDereferenceTag, err)
 	}
@@ -71,7 +251,13 @@ func (gr *GitRepository) resolveTagToCommit(ref *plumbing.Reference) (*object.Co
 
 // FetchAllTags retrieves all tag references from the repository
 func (gr *GitRepository) FetchAllTags() ([]*plumbing.Reference, error) {
-	tagRefs, err := gr.repo.Tags()
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrFetchTags, err)
+	}
+	defer gr.returnReader(reader)
+
+	tagRefs, err := reader.Tags()
 	if err != nil {
 		return nil, errors.Join(ErrFetchTags, err)
 	}
@@ -90,50 +276,365 @@ func (gr *GitRepository) FetchAllTags() ([]*plumbing.Reference, error) {
 
 // GetCommitSetForTag traverses the history of a tag and returns all parent commit hashes.
 // Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
-func (gr *GitRepository) GetCommitSetForTag(ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error) {
+func (gr *GitRepository) GetCommitSetForTag(ctx context.Context, ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error) {
 	commitSet := make(map[plumbing.Hash]struct{})
 
-	// Resolve tag to commit (handles both annotated and lightweight tags)
-	commit, err := gr.resolveTagToCommit(ref)
+	err := gr.ForEachCommitInTag(ctx, ref, func(hash plumbing.Hash) error {
+		commitSet[hash] = struct{}{}
+		return nil
+	})
 	if err != nil {
 		return nil, err // Error already wrapped by helper
 	}
 
-	// Traverse all parent commits (similar to git log)
-	cIter, err := gr.repo.Log(&git.LogOptions{From: commit.Hash})
+	return commitSet, nil
+}
+
+// ForEachCommitInTag calls fn with the hash of every commit reachable from a tag, once each and in no
+// particular order, without building a commit set. An error returned by fn stops the traversal and is
+// returned as is.
+func (gr *GitRepository) ForEachCommitInTag(ctx context.Context, ref *plumbing.Reference, fn func(hash plumbing.Hash) error) error {
+	reader, err := gr.borrowReader()
 	if err != nil {
-		return nil, errors.Join(ErrTraverseCommits, err)
+		return errors.Join(ErrTraverseCommits, err)
 	}
-	defer func() { cIter.Close() }()
+	defer gr.returnReader(reader)
 
-	// Add all parent commits to the set
-	err = cIter.ForEach(func(c *object.Commit) error {
-		commitSet[c.Hash] = struct{}{}
-		return nil
+	// Resolve tag to commit (handles both annotated and lightweight tags)
+	commit, err := resolveCommit(reader, ref)
+	if err != nil {
+		return err // Error already wrapped by helper
+	}
+
+	// An indexed or cached tag needs no traversal; both hold whole histories, so a date range is walked
+	if index := gr.tagIndex(); index != nil && gr.dates.IsZero() {
+		found, err := index.forEachPosition(commit.Hash, func(position uint32) error {
+			return fn(index.Commits[position].Hash)
+		})
+		if found {
+			return err
+		}
+	}
+	cache := gr.commitSets
+	if !gr.dates.IsZero() {
+		cache = nil
+	}
+	if cache != nil {
+		if hashes, ok := cache.load(commit.Hash); ok {
+			for _, hash := range hashes {
+				if err := fn(hash); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	// Traverse all parent commits (similar to git log)
+	var traversed []plumbing.Hash
+	unreadable := gr.unreadableCount()
+	err = gr.walkCommits(ctx, reader, commit, func(c *object.Commit) error {
+		if cache != nil {
+			traversed = append(traversed, c.Hash)
+		}
+		return fn(c.Hash)
 	})
+	// A set missing the history behind an unreadable commit is not cached
+	if err == nil && cache != nil && gr.unreadableCount() == unreadable {
+		cache.store(commit.Hash, traversed)
+	}
+	return err
+}
+
+// walkCommits visits every commit reachable from start, reading parents through reader.
+// A commit that cannot be read is recorded as unreadable and its ancestry skipped,
+// unless the repository is strict, in which case the traversal stops with an error.
+// With a date range, commits committed after it are passed over and the walk stops at commits
+// committed before it, as git log --since does, so the history beyond the range is never read.
+// An error returned by visit stops the traversal and is returned as is.
+func (gr *GitRepository) walkCommits(ctx context.Context, reader *git.Repository, start *object.Commit, visit func(c *object.Commit) error) error {
+	seen := map[plumbing.Hash]struct{}{start.Hash: {}}
+	pending := []*object.Commit{start}
+
+	for len(pending) > 0 {
+		if err := canceled(ctx); err != nil {
+			return err
+		}
+		c := pending[len(pending)-1]
+		pending = pending[:len(pending)-1]
+		when := c.Committer.When
+		if !gr.dates.Since.IsZero() && when.Before(gr.dates.Since) {
+			continue
+		}
+		if gr.dates.Contains(when) {
+			if err := visit(c); err != nil {
+				return err
+			}
+		}
+
+		for _, parentHash := range c.ParentHashes {
+			if _, ok := seen[parentHash]; ok {
+				continue
+			}
+			seen[parentHash] = struct{}{}
+
+			parent, err := reader.CommitObject(parentHash)
+			if err != nil {
+				if gr.strict {
+					return errors.Join(ErrTraverseCommits, fmt.Errorf("parent %s of commit %s", parentHash, c.Hash), err)
+				}
+				gr.markUnreadable(parentHash)
+				continue
+			}
+			pending = append(pending, parent)
+		}
+	}
+
+	return nil
+}
+
+// GetFirstParentCommitSet follows only the first parent of each commit from a tag, so every merge
+// stands for its whole side branch as a single logical change. With a directory, only commits whose
+// directory content differs from their first parent are included.
+func (gr *GitRepository) GetFirstParentCommitSet(ctx context.Context, ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error) {
+	commitSet := make(map[plumbing.Hash]struct{})
+	directory = strings.Trim(filepath.ToSlash(directory), "/")
+
+	reader, err := gr.borrowReader()
 	if err != nil {
 		return nil, errors.Join(ErrTraverseCommits, err)
 	}
+	defer gr.returnReader(reader)
+
+	commit, err := resolveCommit(reader, ref)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	// The first-parent line stops at the first commit committed before the date range, as git log --since does
+	for commit != nil && (gr.dates.Since.IsZero() || !commit.Committer.When.Before(gr.dates.Since)) {
+		if err := canceled(ctx); err != nil {
+			return nil, err
+		}
+		var parent *object.Commit
+		if len(commit.ParentHashes) > 0 {
+			parent, err = reader.CommitObject(commit.ParentHashes[0])
+			if err != nil {
+				if gr.strict {
+					return nil, errors.Join(ErrTraverseCommits, fmt.Errorf("parent %s of commit %s", commit.ParentHashes[0], commit.Hash), err)
+				}
+				gr.markUnreadable(commit.ParentHashes[0])
+				parent = nil
+			}
+		}
+
+		touched := true
+		if directory != "" {
+			touched, err = gr.touchesDirectory(commit, parent, directory)
+			if err != nil {
+				return nil, err
+			}
+		}
+		if touched && gr.dates.Contains(commit.Committer.When) {
+			commitSet[commit.Hash] = struct{}{}
+		}
+
+		commit = parent
+	}
 
 	return commitSet, nil
 }
 
+// GetIntroducingCommits maps every commit reachable from a tag to the first-parent commit that
+// brought it into the tag's history: a merge for commits of a merged side branch, or the commit
+// itself for commits made directly on the first-parent line.
+func (gr *GitRepository) GetIntroducingCommits(ctx context.Context, ref *plumbing.Reference) (map[plumbing.Hash]plumbing.Hash, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+	defer gr.returnReader(reader)
+
+	tip, err := resolveCommit(reader, ref)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	// Collect the first-parent line, newest first
+	var mainline []*object.Commit
+	for commit := tip; commit != nil; {
+		if err := canceled(ctx); err != nil {
+			return nil, err
+		}
+		mainline = append(mainline, commit)
+		if len(commit.ParentHashes) == 0 {
+			break
+		}
+		parent, err := reader.CommitObject(commit.ParentHashes[0])
+		if err != nil {
+			if gr.strict {
+				return nil, errors.Join(ErrTraverseCommits, fmt.Errorf("parent %s of commit %s", commit.ParentHashes[0], commit.Hash), err)
+			}
+			gr.markUnreadable(commit.ParentHashes[0])
+			break
+		}
+		commit = parent
+	}
+
+	// Oldest first, so everything reachable from a first parent is already assigned
+	// and a merge only claims the commits its side branches add
+	introducedBy := make(map[plumbing.Hash]plumbing.Hash)
+	for i := len(mainline) - 1; i >= 0; i-- {
+		if err := canceled(ctx); err != nil {
+			return nil, err
+		}
+		merge := mainline[i]
+		introducedBy[merge.Hash] = merge.Hash
+		if len(merge.ParentHashes) < 2 {
+			continue
+		}
+
+		pending := append([]plumbing.Hash(nil), merge.ParentHashes[1:]...)
+		for len(pending) > 0 {
+			hash := pending[len(pending)-1]
+			pending = pending[:len(pending)-1]
+			if _, ok := introducedBy[hash]; ok {
+				continue
+			}
+			introducedBy[hash] = merge.Hash
+
+			commit, err := reader.CommitObject(hash)
+			if err != nil {
+				if gr.strict {
+					return nil, errors.Join(ErrTraverseCommits, fmt.Errorf("commit %s merged by %s", hash, merge.Hash), err)
+				}
+				gr.markUnreadable(hash)
+				continue
+			}
+			pending = append(pending, commit.ParentHashes...)
+		}
+	}
+
+	return introducedBy, nil
+}
+
+// touchesDirectory reports whether a commit changed directory compared to parent (nil for a root commit).
+// Commits whose trees cannot be read are recorded as unreadable and treated as untouched.
+func (gr *GitRepository) touchesDirectory(commit *object.Commit, parent *object.Commit, directory string) (bool, error) {
+	dirHash, err := directoryHash(commit, directory)
+	if err != nil {
+		if gr.strict {
+			return false, errors.Join(ErrTraverseCommits, err)
+		}
+		gr.markUnreadable(commit.Hash)
+		return false, nil
+	}
+
+	if parent == nil {
+		return !dirHash.IsZero(), nil
+	}
+
+	parentDirHash, err := directoryHash(parent, directory)
+	if err != nil {
+		return true, nil // The parent is recorded as unreadable when it is visited
+	}
+	return parentDirHash != dirHash, nil
+}
+
+// UnreadableCommits returns the commits skipped by traversals so far because they
+// were missing or corrupt. It is always empty for a strict repository.
+func (gr *GitRepository) UnreadableCommits() []plumbing.Hash {
+	gr.unreadableMu.Lock()
+	defer gr.unreadableMu.Unlock()
+	return hashesOf(gr.unreadable)
+}
+
+// markApproximated records a directory filter whose commits were found without git log
+func (gr *GitRepository) markApproximated(directory string) {
+	gr.unreadableMu.Lock()
+	defer gr.unreadableMu.Unlock()
+	if gr.approximated == nil {
+		gr.approximated = make(map[string]struct{})
+	}
+	gr.approximated[directory] = struct{}{}
+}
+
+// ApproximatedDirectories returns the directory filters whose commits were found by walking the
+// history with go-git because git log could not read it. Like UnreadableCommits, it is always empty
+// for a strict repository.
+func (gr *GitRepository) ApproximatedDirectories() []string {
+	gr.unreadableMu.Lock()
+	defer gr.unreadableMu.Unlock()
+	directories := make([]string, 0, len(gr.approximated))
+	for directory := range gr.approximated {
+		directories = append(directories, directory)
+	}
+	sort.Strings(directories)
+	return directories
+}
+
+// objectReadErrorPattern matches the messages git prints for missing or corrupt objects
+var objectReadErrorPattern = regexp.MustCompile(`(?i)could not read|unable to read|unable to unpack|is corrupt|bad object|bad tree|missing (blob|tree|commit)|inflate:`)
+
+// isObjectReadError reports whether a native git command failed because an object was missing or corrupt,
+// as opposed to e.g. a missing git binary or an invalid pathspec
+func isObjectReadError(err error) bool {
+	var exitErr *exec.ExitError
+	return errors.As(err, &exitErr) && objectReadErrorPattern.Match(exitErr.Stderr)
+}
+
 // GetCommitSetForTagFilteredByDirectory traverses the history of a tag and returns commits
 // that touch files in the specified directory.
 // Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
 // Uses native git log command for performance (go-git's PathFilter is extremely slow).
-func (gr *GitRepository) GetCommitSetForTagFilteredByDirectory(ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error) {
-	commitSet := make(map[plumbing.Hash]struct{})
-
+func (gr *GitRepository) GetCommitSetForTagFilteredByDirectory(ctx context.Context, ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error) {
 	// Resolve tag to commit (handles both annotated and lightweight tags)
 	commit, err := gr.resolveTagToCommit(ref)
 	if err != nil {
 		return nil, err // Error already wrapped by helper
 	}
 
-	// Use native git log with path filtering (orders of magnitude faster than go-git's PathFilter)
-	// Command: git log <commit> --format=%H -- <directory>
-	cmd := exec.Command("git", "log", commit.Hash.String(), "--format=%H", "--", directory)
+	commitSet, err := gr.logCommitSet(ctx, commit, []string{directory})
+	if err != nil {
+		if gr.strict || !isObjectReadError(err) {
+			return nil, err
+		}
+		// git log gives up on the first missing or corrupt object; fall back to a slower walk that
+		// skips gaps, and record that its history simplification only approximates git log's
+		gr.markApproximated(directory)
+		return gr.commitSetTouchingDirectory(ctx, commit.Hash, directory)
+	}
+
+	return commitSet, nil
+}
+
+// GetCommitSetForTagFilteredByPathspecs traverses the history of a tag and returns commits
+// that touch files matching any of the git pathspecs (e.g. ":(glob)**/Dockerfile").
+// Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+func (gr *GitRepository) GetCommitSetForTagFilteredByPathspecs(ctx context.Context, ref *plumbing.Reference, pathspecs []string) (map[plumbing.Hash]struct{}, error) {
+	commit, err := gr.resolveTagToCommit(ref)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	return gr.logCommitSet(ctx, commit, pathspecs)
+}
+
+// logCommitSet returns the commits reachable from start that touch the pathspecs.
+// Uses native git log with path filtering (orders of magnitude faster than go-git's PathFilter).
+func (gr *GitRepository) logCommitSet(ctx context.Context, start *object.Commit, pathspecs []string) (map[plumbing.Hash]struct{}, error) {
+	commitSet := make(map[plumbing.Hash]struct{})
+
+	// Command: git log <commit> --format=%H [--since=<time>] [--until=<time>] -- <pathspec>...
+	args := []string{"log", start.Hash.String(), "--format=%H"}
+	if !gr.dates.Since.IsZero() {
+		args = append(args, "--since="+gr.dates.Since.Format(time.RFC3339))
+	}
+	if !gr.dates.Until.IsZero() {
+		args = append(args, "--until="+gr.dates.Until.Format(time.RFC3339))
+	}
+	args = append(append(args, "--"), pathspecs...)
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = gr.path
 
 	output, err := cmd.Output()
@@ -159,44 +660,943 @@ func (gr *GitRepository) GetCommitSetForTagFilteredByDirectory(ref *plumbing.Ref
 	return commitSet, nil
 }
 
-// GetCommitObject retrieves a commit object by its hash
+// commitSetTouchingDirectory returns the commits reachable from start whose directory
+// content differs from all of their readable parents, approximating git log -- <directory>.
+// Commits whose trees cannot be read are recorded as unreadable.
+func (gr *GitRepository) commitSetTouchingDirectory(ctx context.Context, startHash plumbing.Hash, directory string) (map[plumbing.Hash]struct{}, error) {
+	commitSet := make(map[plumbing.Hash]struct{})
+	directory = strings.Trim(filepath.ToSlash(directory), "/")
+
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+	defer gr.returnReader(reader)
+
+	start, err := reader.CommitObject(startHash)
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	err = gr.walkCommits(ctx, reader, start, func(c *object.Commit) error {
+		dirHash, err := directoryHash(c, directory)
+		if err != nil {
+			gr.markUnreadable(c.Hash)
+			return nil
+		}
+
+		touched := true
+		for _, parentHash := range c.ParentHashes {
+			parent, err := reader.CommitObject(parentHash)
+			if err != nil {
+				continue // Recorded by walkCommits
+			}
+			parentDirHash, err := directoryHash(parent, directory)
+			if err == nil && parentDirHash == dirHash {
+				touched = false
+				break
+			}
+		}
+		if len(c.ParentHashes) == 0 {
+			touched = !dirHash.IsZero()
+		}
+
+		if touched {
+			commitSet[c.Hash] = struct{}{}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	return commitSet, nil
+}
+
+// directoryHash returns the hash of the tree entry at directory in a commit,
+// or the zero hash if the directory does not exist in that commit
+func directoryHash(c *object.Commit, directory string) (plumbing.Hash, error) {
+	tree, err := c.Tree()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if directory == "" || directory == "." {
+		return tree.Hash, nil
+	}
+
+	entry, err := tree.FindEntry(directory)
+	if errors.Is(err, object.ErrEntryNotFound) || errors.Is(err, object.ErrDirectoryNotFound) {
+		return plumbing.ZeroHash, nil
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return entry.Hash, nil
+}
+
+// HasDirectory reports whether directory exists in the tree of the commit a tag points to
+func (gr *GitRepository) HasDirectory(ref *plumbing.Reference, directory string) (bool, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return false, errors.Join(ErrGetCommit, err)
+	}
+	defer gr.returnReader(reader)
+
+	commit, err := resolveCommit(reader, ref)
+	if err != nil {
+		return false, err
+	}
+
+	hash, err := directoryHash(commit, directory)
+	if err != nil {
+		return false, errors.Join(ErrGetCommit, err)
+	}
+	return !hash.IsZero(), nil
+}
+
+// GetCommitObject retrieves a commit object by its hash.
+// The returned commit should only be used for its metadata (hash, author, message).
+// Commits are served from an in-memory LRU cache when possible.
 func (gr *GitRepository) GetCommitObject(hash plumbing.Hash) (*object.Commit, error) {
-	commit, err := gr.repo.CommitObject(hash)
+	if commit, ok := gr.commits.Get(hash); ok {
+		return commit, nil
+	}
+
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrGetCommit, err)
+	}
+	defer gr.returnReader(reader)
+
+	commit, err := reader.CommitObject(hash)
 	if err != nil {
 		return nil, errors.Join(ErrGetCommit, err)
 	}
+
+	gr.commits.Add(hash, commit)
 	return commit, nil
 }
 
+// GetCommitObjects retrieves the commit objects for several hashes at once, in the order given.
+// Cached commits are returned directly and the rest are read concurrently through pooled handles.
+// If any commit cannot be read, the first error is returned along with the number of failures.
+func (gr *GitRepository) GetCommitObjects(hashes []plumbing.Hash) ([]*object.Commit, error) {
+	commits, errs := loadCommits(gr, hashes)
+
+	var firstErr error
+	failed := 0
+	for _, err := range errs {
+		if err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			failed++
+		}
+	}
+	if firstErr != nil {
+		return nil, errors.Join(ErrGetCommit, fmt.Errorf("%d of %d commits could not be read", failed, len(hashes)), firstErr)
+	}
+
+	return commits, nil
+}
+
+// GetTagCommit returns the commit a tag points to.
+// Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+func (gr *GitRepository) GetTagCommit(ref *plumbing.Reference) (*object.Commit, error) {
+	return gr.resolveTagToCommit(ref)
+}
+
+// GetTagObject returns the tag object of an annotated tag, or nil for a lightweight tag
+func (gr *GitRepository) GetTagObject(ref *plumbing.Reference) (*object.Tag, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrDereferenceTag, err)
+	}
+	defer gr.returnReader(reader)
+
+	tagObj, err := reader.TagObject(ref.Hash())
+	if errors.Is(err, plumbing.ErrObjectNotFound) {
+		// The reference points to a commit or a missing object; resolveTagToCommit reports the latter
+		return nil, nil
+	}
+	if err != nil {
+		return nil, errors.Join(ErrDereferenceTag, err)
+	}
+	return tagObj, nil
+}
+
 // GetDiffBetweenTags returns the diff between two tags.
 // Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
-// If directory is specified, only shows diff for files in that directory.
-func (gr *GitRepository) GetDiffBetweenTags(tag1 *plumbing.Reference, tag2 *plumbing.Reference, directory string) (string, error) {
+// If pathspecs are specified, only shows diff for matching files.
+// diffArgs select the output format (e.g. --stat, --patch, --name-only); see DiffOptions.
+func (gr *GitRepository) GetDiffBetweenTags(ctx context.Context, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, diffArgs ...string) (string, error) {
+	diff, _, err := gr.GetLimitedDiffBetweenTags(ctx, tag1, tag2, pathspecs, 0, diffArgs...)
+	return diff, err
+}
+
+// GetLimitedDiffBetweenTags returns the diff between two tags like GetDiffBetweenTags, reading at most
+// limit bytes of it. A larger diff is cut after its last complete line within the limit, git is
+// stopped, and truncated is true, so an enormous diff never has to be held in memory.
+// A limit of 0 reads the whole diff.
+func (gr *GitRepository) GetLimitedDiffBetweenTags(ctx context.Context, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string, limit int64, diffArgs ...string) (string, bool, error) {
 	// Resolve tags to commits (handles both annotated and lightweight tags)
 	commit1, err := gr.resolveTagToCommit(tag1)
 	if err != nil {
-		return "", err // Error already wrapped by helper
+		return "", false, err // Error already wrapped by helper
 	}
 
 	commit2, err := gr.resolveTagToCommit(tag2)
 	if err != nil {
-		return "", err // Error already wrapped by helper
+		return "", false, err // Error already wrapped by helper
 	}
 
-	// Use git diff command with stat for summary
-	// Command: git diff <commit1> <commit2> [-- <directory>]
-	args := []string{"diff", "--stat", "--stat-width=120", commit1.Hash.String(), commit2.Hash.String()}
-	if directory != "" {
-		args = append(args, "--", directory)
+	// Command: git diff <diffArgs...> <commit1> <commit2> [-- <pathspec>...]
+	args := append([]string{"diff"}, diffArgs...)
+	args = append(args, commit1.Hash.String(), commit2.Hash.String())
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
 	}
 
-	cmd := exec.Command("git", args...)
+	cmd := exec.CommandContext(ctx, "git", args...)
 	cmd.Dir = gr.path
 
-	output, err := cmd.Output()
+	if limit <= 0 {
+		output, err := cmd.Output()
+		if err != nil {
+			return "", false, errors.Join(ErrTraverseCommits, err)
+		}
+		return string(output), false, nil
+	}
+
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
-		return "", errors.Join(ErrTraverseCommits, err)
+		return "", false, errors.Join(ErrTraverseCommits, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return "", false, errors.Join(ErrTraverseCommits, err)
+	}
+
+	// One byte beyond the limit tells a diff of exactly limit bytes from a longer one
+	output, err := io.ReadAll(io.LimitReader(stdout, limit+1))
+	if err != nil {
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return "", false, errors.Join(ErrTraverseCommits, err)
+	}
+	if int64(len(output)) <= limit {
+		if err := cmd.Wait(); err != nil {
+			return "", false, errors.Join(ErrTraverseCommits, err)
+		}
+		return string(output), false, nil
 	}
 
-	return string(output), nil
+	// git exits with an error once it is killed or its output pipe is closed
+	_ = cmd.Process.Kill()
+	_ = cmd.Wait()
+
+	output = output[:limit]
+	if end := bytes.LastIndexByte(output, '\n'); end >= 0 {
+		output = output[:end+1]
+	}
+	return string(output), true, nil
+}
+
+// GetFileSizes returns the size in bytes of every file in the tree of a tag, keyed by path.
+// Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+// Submodules have no size in the tree and are omitted.
+func (gr *GitRepository) GetFileSizes(ctx context.Context, ref *plumbing.Reference) (map[string]int64, error) {
+	commit, err := gr.resolveTagToCommit(ref)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	// Command: git ls-tree -r -l -z <commit>
+	cmd := exec.CommandContext(ctx, "git", "ls-tree", "-r", "-l", "-z", commit.Hash.String())
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	sizes := make(map[string]int64)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		// Entry format: <mode> SP <type> SP <object> SP+ <size> TAB <path>
+		meta, filePath, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 4 {
+			continue
+		}
+		size, err := strconv.ParseInt(fields[3], 10, 64)
+		if err != nil {
+			continue
+		}
+		sizes[filePath] = size
+	}
+	return sizes, nil
+}
+
+// GetLineCount returns the number of lines in the text files of the tree of a tag, counted as
+// 'git diff --numstat' counts them, so it adds up with the line changes of GetDiffBetweenTags.
+// Binary files have no lines. If pathspecs are specified, only matching files are counted.
+func (gr *GitRepository) GetLineCount(ctx context.Context, ref *plumbing.Reference, pathspecs []string) (int, error) {
+	commit, err := gr.resolveTagToCommit(ref)
+	if err != nil {
+		return 0, err // Error already wrapped by helper
+	}
+
+	// Every line of the tree is added relative to the empty tree, which git knows without storing it
+	emptyTree := plumbing.ComputeHash(plumbing.TreeObject, nil)
+
+	// Command: git diff --numstat -z --no-renames <empty tree> <commit> [-- <pathspec>...]
+	args := []string{"diff", "--numstat", "-z", "--no-renames", emptyTree.String(), commit.Hash.String()}
+	if len(pathspecs) > 0 {
+		args = append(args, "--")
+		args = append(args, pathspecs...)
+	}
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return 0, errors.Join(ErrTraverseCommits, err)
+	}
+
+	lines := 0
+	for _, entry := range parseNumstat(string(output)) {
+		lines += entry.Added
+	}
+	return lines, nil
+}
+
+// GetFileHashes returns the blob hash of every file in the tree of a tag, keyed by path.
+// Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+// Symbolic links are included with the hash of their target path; submodules are omitted.
+func (gr *GitRepository) GetFileHashes(ctx context.Context, ref *plumbing.Reference) (map[string]plumbing.Hash, error) {
+	commit, err := gr.resolveTagToCommit(ref)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	// Command: git ls-tree -r -z <commit>
+	cmd := exec.CommandContext(ctx, "git", "ls-tree", "-r", "-z", commit.Hash.String())
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	hashes := make(map[string]plumbing.Hash)
+	for _, entry := range strings.Split(string(output), "\x00") {
+		// Entry format: <mode> SP <type> SP <object> TAB <path>
+		meta, filePath, ok := strings.Cut(entry, "\t")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(meta)
+		if len(fields) != 3 || fields[1] != "blob" {
+			continue
+		}
+		hashes[filePath] = plumbing.NewHash(fields[2])
+	}
+	return hashes, nil
+}
+
+// GetFileCommits returns, for every file changed between two tags, the commits reachable
+// from either tag that touched that file.
+// Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+// If pathspecs are specified, only files matching them are considered.
+func (gr *GitRepository) GetFileCommits(ctx context.Context, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string) (map[string][]plumbing.Hash, error) {
+	commit1, err := gr.resolveTagToCommit(tag1)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	commit2, err := gr.resolveTagToCommit(tag2)
+	if err != nil {
+		return nil, err // Error already wrapped by helper
+	}
+
+	// List the files that differ between the two tags, with non-ASCII paths unquoted as in StreamCommitFiles.
+	// Renames count as a deletion and an addition, so the history of the old path is kept.
+	// Command: git -c core.quotepath=off diff --name-only --no-renames <commit1> <commit2> [-- <pathspec>...]
+	args := []string{"-c", "core.quotepath=off", "diff", "--name-only", "--no-renames", commit1.Hash.String(), commit2.Hash.String()}
+	if len(pathspecs) > 0 {
+		args = append(append(args, "--"), pathspecs...)
+	}
+
+	cmd := exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	fileCommits := make(map[string][]plumbing.Hash)
+	for _, line := range strings.Split(string(output), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			fileCommits[line] = nil
+		}
+	}
+	if len(fileCommits) == 0 {
+		return fileCommits, nil
+	}
+
+	// Walk the history of both tags once, recording which commits touched the changed files
+	// Command: git -c core.quotepath=off log --format=commit:%H --name-only --no-renames <commit1> <commit2> [-- <pathspec>...]
+	args = []string{"-c", "core.quotepath=off", "log", "--format=commit:%H", "--name-only", "--no-renames", commit1.Hash.String(), commit2.Hash.String()}
+	if len(pathspecs) > 0 {
+		args = append(append(args, "--"), pathspecs...)
+	}
+
+	cmd = exec.CommandContext(ctx, "git", args...)
+	cmd.Dir = gr.path
+
+	output, err = cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	var current plumbing.Hash
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if hash, ok := strings.CutPrefix(line, "commit:"); ok {
+			current = plumbing.NewHash(hash)
+			continue
+		}
+		if commits, ok := fileCommits[line]; ok {
+			fileCommits[line] = append(commits, current)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	return fileCommits, nil
+}
+
+// defaultBranchCandidates are tried in order when a repository has no remote HEAD
+var defaultBranchCandidates = []plumbing.ReferenceName{
+	plumbing.NewBranchReferenceName("main"),
+	plumbing.NewBranchReferenceName("master"),
+}
+
+// GetBranchReference resolves a local or remote-tracking branch to a reference holding its head commit.
+// An empty name selects the default branch: the target of origin/HEAD, then main, then master.
+func (gr *GitRepository) GetBranchReference(name string) (*plumbing.Reference, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrResolveBranch, err)
+	}
+	defer gr.returnReader(reader)
+
+	var candidates []plumbing.ReferenceName
+	if name == "" {
+		if head, err := reader.Reference(plumbing.NewRemoteHEADReferenceName("origin"), false); err == nil && head.Type() == plumbing.SymbolicReference {
+			candidates = append(candidates, head.Target())
+		}
+		candidates = append(candidates, defaultBranchCandidates...)
+	} else {
+		candidates = []plumbing.ReferenceName{
+			plumbing.NewBranchReferenceName(name),
+			plumbing.ReferenceName("refs/remotes/" + name),
+			plumbing.ReferenceName(name),
+		}
+	}
+
+	for _, candidate := range candidates {
+		ref, err := reader.Reference(candidate, true)
+		if err == nil {
+			return plumbing.NewHashReference(candidate, ref.Hash()), nil
+		}
+	}
+
+	if name == "" {
+		return nil, errors.Join(ErrResolveBranch, fmt.Errorf("no default branch found (tried origin/HEAD, main, master)"))
+	}
+	return nil, errors.Join(ErrResolveBranch, fmt.Errorf("branch not found: %s", name))
+}
+
+// ResolveRevision resolves a branch name, commit hash, or revision expression (e.g. HEAD~5) to a reference
+// named after the revision and holding the commit it selects.
+// Uses native git rev-parse, which supports the full revision syntax and abbreviated hashes.
+func (gr *GitRepository) ResolveRevision(ctx context.Context, revision string) (*plumbing.Reference, error) {
+	if revision == "" || strings.HasPrefix(revision, "-") {
+		return nil, errors.Join(ErrResolveRevision, fmt.Errorf("invalid revision: %q", revision))
+	}
+
+	// Command: git rev-parse --verify --quiet <revision>^{commit}
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--verify", "--quiet", revision+"^{commit}")
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrResolveRevision, fmt.Errorf("unknown revision: %s", revision), err)
+	}
+
+	hash := plumbing.NewHash(strings.TrimSpace(string(output)))
+	return plumbing.NewHashReference(plumbing.ReferenceName(revision), hash), nil
+}
+
+// IsAncestor reports whether ancestor is reachable from descendant.
+// Uses native git merge-base, which is much faster than walking history with go-git.
+func (gr *GitRepository) IsAncestor(ctx context.Context, ancestor plumbing.Hash, descendant plumbing.Hash) (bool, error) {
+	// Command: git merge-base --is-ancestor <ancestor> <descendant>
+	cmd := exec.CommandContext(ctx, "git", "merge-base", "--is-ancestor", ancestor.String(), descendant.String())
+	cmd.Dir = gr.path
+
+	err := cmd.Run()
+	if err == nil {
+		return true, nil
+	}
+
+	// Exit status 1 means "not an ancestor"; anything else is a real failure
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
+		return false, nil
+	}
+	return false, errors.Join(ErrCheckAncestry, err)
+}
+
+// commitRecordSeparator starts every commit header in the StreamCommitFiles git log output
+const commitRecordSeparator = "\x1e"
+
+// commitFieldSeparator separates the fields of a commit header
+const commitFieldSeparator = "\x1f"
+
+// firstParentClockSkew is how much older than the ancestor a first-parent walk continues,
+// tolerating commits whose dates are not monotonic
+const firstParentClockSkew = 24 * time.Hour
+
+// IsFirstParentAncestor reports whether ancestor lies on the first-parent line of descendant,
+// i.e. it was committed on that line rather than merged into it from a side branch.
+// The walk stops once commits are clearly older than ancestor.
+func (gr *GitRepository) IsFirstParentAncestor(ctx context.Context, ancestor plumbing.Hash, descendant plumbing.Hash) (bool, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return false, errors.Join(ErrCheckAncestry, err)
+	}
+	defer gr.returnReader(reader)
+
+	target, err := reader.CommitObject(ancestor)
+	if err != nil {
+		return false, errors.Join(ErrCheckAncestry, err)
+	}
+	cutoff := target.Committer.When.Add(-firstParentClockSkew)
+
+	commit, err := reader.CommitObject(descendant)
+	if err != nil {
+		return false, errors.Join(ErrCheckAncestry, err)
+	}
+
+	for {
+		if commit.Hash == ancestor {
+			return true, nil
+		}
+		if len(commit.ParentHashes) == 0 || commit.Committer.When.Before(cutoff) {
+			return false, nil
+		}
+		if commit, err = reader.CommitObject(commit.ParentHashes[0]); err != nil {
+			if gr.strict {
+				return false, errors.Join(ErrCheckAncestry, err)
+			}
+			return false, nil
+		}
+	}
+}
+
+// GetBranchesContaining returns the local and remote-tracking branches whose history contains hash.
+// Uses native git for-each-ref --contains, which checks all branches in a single pass.
+func (gr *GitRepository) GetBranchesContaining(ctx context.Context, hash plumbing.Hash) ([]*plumbing.Reference, error) {
+	// Command: git for-each-ref --contains <hash> --format=%(objectname) %(refname) refs/heads refs/remotes
+	cmd := exec.CommandContext(ctx, "git", "for-each-ref", "--contains", hash.String(), "--format=%(objectname) %(refname)", "refs/heads", "refs/remotes")
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrResolveBranch, err)
+	}
+
+	var refs []*plumbing.Reference
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		target, name, ok := strings.Cut(line, " ")
+		// Skip symbolic remote HEADs such as origin/HEAD, which duplicate the branch they point to
+		if !ok || strings.HasSuffix(name, "/HEAD") {
+			continue
+		}
+		refs = append(refs, plumbing.NewReferenceFromStrings(name, target))
+	}
+	return refs, nil
+}
+
+// GetBranchesPointingAt returns the local and remote-tracking branches whose tip is the commit.
+// Only the references are read, so unlike GetBranchesContaining it walks no history and needs no git binary.
+func (gr *GitRepository) GetBranchesPointingAt(hash plumbing.Hash) ([]*plumbing.Reference, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrResolveBranch, err)
+	}
+	defer gr.returnReader(reader)
+
+	iter, err := reader.References()
+	if err != nil {
+		return nil, errors.Join(ErrResolveBranch, err)
+	}
+
+	var refs []*plumbing.Reference
+	err = iter.ForEach(func(ref *plumbing.Reference) error {
+		// Skip symbolic remote HEADs such as origin/HEAD, which duplicate the branch they point to
+		if ref.Type() != plumbing.HashReference || !(ref.Name().IsBranch() || ref.Name().IsRemote()) {
+			return nil
+		}
+		if ref.Hash() == hash && !strings.HasSuffix(ref.Name().String(), "/HEAD") {
+			refs = append(refs, ref)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(ErrResolveBranch, err)
+	}
+	return refs, nil
+}
+
+// GetRootCommits returns the parentless commits reachable from HEAD, or none when HEAD is unborn
+func (gr *GitRepository) GetRootCommits(ctx context.Context) ([]plumbing.Hash, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+	_, err = reader.Head()
+	gr.returnReader(reader)
+	if errors.Is(err, plumbing.ErrReferenceNotFound) {
+		return nil, nil
+	}
+
+	// Command: git rev-list --max-parents=0 HEAD
+	cmd := exec.CommandContext(ctx, "git", "rev-list", "--max-parents=0", "HEAD")
+	cmd.Dir = gr.path
+
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	var roots []plumbing.Hash
+	for _, line := range strings.Fields(string(output)) {
+		roots = append(roots, plumbing.NewHash(line))
+	}
+	return roots, nil
+}
+
+// GetBlob returns the content of a blob, e.g. a file listed by GetFileHashes
+func (gr *GitRepository) GetBlob(hash plumbing.Hash) ([]byte, error) {
+	repo, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrReadBlob, err)
+	}
+	defer gr.returnReader(repo)
+
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, errors.Join(ErrReadBlob, err)
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, errors.Join(ErrReadBlob, err)
+	}
+	defer func() { _ = reader.Close() }()
+
+	content, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, errors.Join(ErrReadBlob, err)
+	}
+	return content, nil
+}
+
+// GetExcludePatterns returns the lines of the ignore files that are not part of any tree: the repository's
+// info/exclude file and the file named by core.excludesFile, which defaults to $XDG_CONFIG_HOME/git/ignore.
+// Missing files contribute no lines.
+func (gr *GitRepository) GetExcludePatterns(ctx context.Context) ([]string, error) {
+	// Command: git rev-parse --git-path info/exclude
+	cmd := exec.CommandContext(ctx, "git", "rev-parse", "--git-path", "info/exclude")
+	cmd.Dir = gr.path
+	output, err := cmd.Output()
+	if err != nil {
+		return nil, errors.Join(ErrReadExcludes, err)
+	}
+	infoExclude := strings.TrimSpace(string(output))
+	if !filepath.IsAbs(infoExclude) {
+		infoExclude = filepath.Join(gr.path, infoExclude)
+	}
+	files := []string{infoExclude}
+
+	// Command: git config --path --get core.excludesFile
+	cmd = exec.CommandContext(ctx, "git", "config", "--path", "--get", "core.excludesFile")
+	cmd.Dir = gr.path
+	output, err = cmd.Output()
+	var exitErr *exec.ExitError
+	switch {
+	case err == nil:
+		files = append(files, strings.TrimSpace(string(output)))
+	case errors.As(err, &exitErr) && exitErr.ExitCode() == 1:
+		// Unset; git falls back to the ignore file of the XDG config directory
+		if configHome := os.Getenv("XDG_CONFIG_HOME"); configHome != "" {
+			files = append(files, filepath.Join(configHome, "git", "ignore"))
+		} else if home, err := os.UserHomeDir(); err == nil {
+			files = append(files, filepath.Join(home, ".config", "git", "ignore"))
+		}
+	default:
+		return nil, errors.Join(ErrReadExcludes, err)
+	}
+
+	var patterns []string
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, errors.Join(ErrReadExcludes, err)
+		}
+		patterns = append(patterns, strings.Split(string(content), "\n")...)
+	}
+	return patterns, nil
+}
+
+// GetRemoteURLs returns the first URL of each configured remote by remote name
+func (gr *GitRepository) GetRemoteURLs() (map[string]string, error) {
+	reader, err := gr.borrowReader()
+	if err != nil {
+		return nil, errors.Join(ErrOpenRepository, err)
+	}
+	defer gr.returnReader(reader)
+
+	remotes, err := reader.Remotes()
+	if err != nil {
+		return nil, errors.Join(ErrOpenRepository, err)
+	}
+
+	urls := make(map[string]string, len(remotes))
+	for _, remote := range remotes {
+		if config := remote.Config(); len(config.URLs) > 0 {
+			urls[config.Name] = config.URLs[0]
+		}
+	}
+	return urls, nil
+}
+
+// GetPatchIDs returns the stable patch ID of each non-merge commit reachable from ref, mapped to the commit.
+// A non-nil base limits the commits to those not reachable from base.
+// Uses native git log -p piped into git patch-id, which hashes each diff independently of line numbers and whitespace.
+func (gr *GitRepository) GetPatchIDs(ctx context.Context, ref *plumbing.Reference, base *plumbing.Reference) (map[string]plumbing.Hash, error) {
+	commit, err := gr.resolveTagToCommit(ref)
+	if err != nil {
+		return nil, err
+	}
+	revision := commit.Hash.String()
+	baseHash := plumbing.ZeroHash
+	if base != nil {
+		baseCommit, err := gr.resolveTagToCommit(base)
+		if err != nil {
+			return nil, err
+		}
+		baseHash = baseCommit.Hash
+		revision = baseCommit.Hash.String() + ".." + revision
+	}
+	if index := gr.tagIndex(); index != nil {
+		if ids, found := index.patchIDs(commit.Hash, baseHash); found {
+			return ids, nil
+		}
+	}
+
+	// Command: git log -p --no-merges <revision> | git patch-id --stable
+	logCmd := exec.CommandContext(ctx, "git", "log", "-p", "--no-merges", "--no-color", "--no-ext-diff", "--no-textconv", revision)
+	logCmd.Dir = gr.path
+	patchIDCmd := exec.CommandContext(ctx, "git", "patch-id", "--stable")
+	patchIDCmd.Dir = gr.path
+
+	patches, err := logCmd.StdoutPipe()
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+	patchIDCmd.Stdin = patches
+	if err := logCmd.Start(); err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	output, err := patchIDCmd.Output()
+	if waitErr := logCmd.Wait(); err == nil {
+		err = waitErr
+	}
+	if err != nil {
+		return nil, errors.Join(ErrTraverseCommits, err)
+	}
+
+	// Each line is "<patch-id> <commit>"
+	ids := make(map[string]plumbing.Hash)
+	for _, line := range strings.Split(strings.TrimSpace(string(output)), "\n") {
+		patchID, hash, ok := strings.Cut(line, " ")
+		if !ok {
+			continue
+		}
+		// Keep the newest commit when a change was applied more than once, e.g. cherry-picked to a branch
+		if _, seen := ids[patchID]; !seen {
+			ids[patchID] = plumbing.NewHash(hash)
+		}
+	}
+	return ids, nil
+}
+
+// GetPatchID returns the stable patch ID of a patch or mail, or "" if it contains no diff.
+// The ID is comparable with those of GetPatchIDs.
+func (gr *GitRepository) GetPatchID(ctx context.Context, patch string) (string, error) {
+	// Command: git patch-id --stable < patch
+	cmd := exec.CommandContext(ctx, "git", "patch-id", "--stable")
+	cmd.Dir = gr.path
+	cmd.Stdin = strings.NewReader(patch)
+
+	output, err := cmd.Output()
+	if err != nil {
+		return "", errors.Join(ErrTraverseCommits, err)
+	}
+	patchID, _, _ := strings.Cut(strings.TrimSpace(string(output)), " ")
+	return patchID, nil
+}
+
+// WriteArchive writes the source archive of a tag, as created by git archive, to w.
+// Handles both annotated tags (tag objects) and lightweight tags (direct commit refs).
+// The format is one git archive supports, e.g. tar, tar.gz, tgz, or zip; prefix is prepended to every path.
+func (gr *GitRepository) WriteArchive(ctx context.Context, ref *plumbing.Reference, format string, prefix string, w io.Writer) error {
+	commit, err := gr.resolveTagToCommit(ref)
+	if err != nil {
+		return err // Error already wrapped by helper
+	}
+
+	// Command: git archive --format=<format> --prefix=<prefix> <commit>
+	cmd := exec.CommandContext(ctx, "git", "archive", "--format="+format, "--prefix="+prefix, commit.Hash.String())
+	cmd.Dir = gr.path
+	cmd.Stdout = w
+
+	if err := cmd.Run(); err != nil {
+		return errors.Join(ErrArchiveTag, err)
+	}
+	return nil
+}
+
+// StreamCommitFiles calls visit for each commit with the files it changed, in the order of hashes,
+// as git resolves them. Only the hash, author, and subject line of the commit are populated.
+// Uses a single native git log process, so memory use does not grow with the number of commits.
+func (gr *GitRepository) StreamCommitFiles(ctx context.Context, hashes []plumbing.Hash, visit func(commit *object.Commit, files []string) error) error {
+	if len(hashes) == 0 {
+		return nil
+	}
+
+	// Command: git log --no-walk=unsorted --stdin --name-only --format=<header>
+	cmd := exec.CommandContext(ctx, "git", "-c", "core.quotepath=off", "log", "--no-walk=unsorted", "--stdin", "--name-only",
+		"--format="+commitRecordSeparator+"%H"+commitFieldSeparator+"%an"+commitFieldSeparator+"%ae"+commitFieldSeparator+"%aI"+commitFieldSeparator+"%s")
+	cmd.Dir = gr.path
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return errors.Join(ErrTraverseCommits, err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return errors.Join(ErrTraverseCommits, err)
+	}
+	if err := cmd.Start(); err != nil {
+		return errors.Join(ErrTraverseCommits, err)
+	}
+
+	// Feed the hashes concurrently so git can stream output while it is still reading input
+	go func() {
+		defer func() { _ = stdin.Close() }()
+		writer := bufio.NewWriter(stdin)
+		for _, hash := range hashes {
+			if _, err := writer.WriteString(hash.String() + "\n"); err != nil {
+				return
+			}
+		}
+		_ = writer.Flush()
+	}()
+
+	var current *object.Commit
+	var files []string
+	flush := func() error {
+		if current == nil {
+			return nil
+		}
+		err := visit(current, files)
+		current, files = nil, nil
+		return err
+	}
+
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	var visitErr error
+	for scanner.Scan() && visitErr == nil {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, commitRecordSeparator) {
+			if line != "" && current != nil {
+				files = append(files, line)
+			}
+			continue
+		}
+
+		if visitErr = flush(); visitErr != nil {
+			break
+		}
+		current, err = parseCommitHeader(strings.TrimPrefix(line, commitRecordSeparator))
+		if err != nil {
+			visitErr = errors.Join(ErrTraverseCommits, err)
+		}
+	}
+	if visitErr == nil {
+		visitErr = scanner.Err()
+	}
+	if visitErr == nil {
+		visitErr = flush()
+	}
+
+	if visitErr != nil {
+		// Stop git early; its exit status no longer matters
+		_ = cmd.Process.Kill()
+		_ = cmd.Wait()
+		return visitErr
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return errors.Join(ErrTraverseCommits, err)
+	}
+	return nil
+}
+
+// parseCommitHeader parses a "hash, author, email, date, subject" header of StreamCommitFiles
+func parseCommitHeader(header string) (*object.Commit, error) {
+	fields := strings.SplitN(header, commitFieldSeparator, 5)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("malformed git log header: %q", header)
+	}
+
+	date, err := time.Parse(time.RFC3339, fields[3])
+	if err != nil {
+		return nil, err
+	}
+
+	return &object.Commit{
+		Hash:    plumbing.NewHash(fields[0]),
+		Author:  object.Signature{Name: fields[1], Email: fields[2], When: date},
+		Message: fields[4],
+	}, nil
 }
@@ -1,12 +1,20 @@
 package internal
 
 import (
+	"context"
+	"errors"
 	"os"
-	"os/exec"
-	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
+	"github.com/byron1st/git-tag-similarity/testutil"
+	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+	formatcfg "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/object"
 )
 
 // TestCompareWithDirectoryFilter tests the Compare function with directory filtering
@@ -65,267 +73,773 @@ func TestCompareWithDirectoryFilter(t *testing.T) {
 	}
 }
 
+// newReleaseFixture builds a repository with two annotated release tags:
+// v1.0.0 (1 commit) and v1.1.0 (3 commits, 2 of them touching internal/)
+func newReleaseFixture(t *testing.T) *testutil.RepoBuilder {
+	t.Helper()
+	return testutil.NewRepo(t).
+		Commit("Add API", testutil.File("src/api/a.go", "package api\n"), testutil.File("internal/x.go", "package internal\n")).
+		AnnotatedTag("v1.0.0", "Release 1.0.0").
+		Commit("Refactor internal", testutil.File("internal/x.go", "package internal\n\nconst X = 1\n")).
+		Commit("Add b endpoint", testutil.File("src/api/b.go", "package api\n")).
+		AnnotatedTag("v1.1.0", "Release 1.1.0")
+}
+
+// openFixture opens the fixture's repository
+func openFixture(t *testing.T, fixture *testutil.RepoBuilder) *GitRepository {
+	t.Helper()
+	repo, err := NewGitRepository(fixture.Path())
+	if err != nil {
+		t.Fatalf("Failed to open repository: %v", err)
+	}
+	return repo
+}
+
 // TestResolveTagToCommit_AnnotatedTag tests the helper with real annotated tags
 func TestResolveTagToCommit_AnnotatedTag(t *testing.T) {
-	// This repo has annotated tags (v1.0.0, v1.1.0, etc.)
-	repo, err := NewGitRepository("..")
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	// Resolve tag to commit
+	commit, err := repo.resolveTagToCommit(fixture.Reference("v1.0.0"))
 	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
+		t.Fatalf("resolveTagToCommit() failed for annotated tag: %v", err)
 	}
 
-	// Test with an annotated tag
+	if commit.Hash != fixture.Hash("v1.0.0") {
+		t.Errorf("resolveTagToCommit() = %s, want %s", commit.Hash, fixture.Hash("v1.0.0"))
+	}
+}
+
+// TestResolveTagToCommit_LightweightTag tests the helper with lightweight tags
+func TestResolveTagToCommit_LightweightTag(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("test commit", testutil.File("test.txt", "test")).
+		Tag("lightweight-test")
+	repo := openFixture(t, fixture)
+
 	tags, err := repo.FetchAllTags()
 	if err != nil {
 		t.Fatalf("Failed to fetch tags: %v", err)
 	}
 
-	// Find v1.0.0 tag (we know it's annotated)
-	var v100Ref *plumbing.Reference
+	var lwRef *plumbing.Reference
 	for _, ref := range tags {
-		if ref.Name().Short() == "v1.0.0" {
-			v100Ref = ref
+		if ref.Name().Short() == "lightweight-test" {
+			lwRef = ref
 			break
 		}
 	}
-	if v100Ref == nil {
-		t.Skip("v1.0.0 tag not found, skipping test")
+	if lwRef == nil {
+		t.Fatalf("lightweight-test tag not found")
 	}
 
 	// Resolve tag to commit
-	commit, err := repo.resolveTagToCommit(v100Ref)
+	commit, err := repo.resolveTagToCommit(lwRef)
 	if err != nil {
-		t.Errorf("resolveTagToCommit() failed for annotated tag: %v", err)
-		return
+		t.Fatalf("resolveTagToCommit() failed for lightweight tag: %v", err)
 	}
-	if commit == nil {
-		t.Errorf("resolveTagToCommit() returned nil commit")
-		return
+
+	if commit.Hash != fixture.Hash("HEAD") {
+		t.Errorf("resolveTagToCommit() = %s, want %s", commit.Hash, fixture.Hash("HEAD"))
 	}
+}
+
+// TestGetCommitSetForTag_AnnotatedTag tests with real annotated tags
+func TestGetCommitSetForTag_AnnotatedTag(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
 
-	// Verify it's a valid commit
-	if commit.Hash.IsZero() {
-		t.Errorf("resolveTagToCommit() returned commit with zero hash")
+	tests := []struct {
+		tag  string
+		want int
+	}{
+		{tag: "v1.0.0", want: 1},
+		{tag: "v1.1.0", want: 3},
+	}
+
+	for _, tt := range tests {
+		commits, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference(tt.tag))
+		if err != nil {
+			t.Fatalf("GetCommitSetForTag(%s) failed: %v", tt.tag, err)
+		}
+		if len(commits) != tt.want {
+			t.Errorf("GetCommitSetForTag(%s) returned %d commits, want %d", tt.tag, len(commits), tt.want)
+		}
 	}
 }
 
-// TestResolveTagToCommit_LightweightTag tests the helper with lightweight tags
-func TestResolveTagToCommit_LightweightTag(t *testing.T) {
-	// Create a test git repository with lightweight tag
-	tempDir := t.TempDir()
+// TestGetCommitSetForTag_Concurrent tests traversing the histories of several tags at the same time
+func TestGetCommitSetForTag_Concurrent(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	tags := []string{"v1.0.0", "v1.1.0", "v1.0.0", "v1.1.0"}
+	counts := make([]int, len(tags))
+	errs := make([]error, len(tags))
+	var wg sync.WaitGroup
+	for i, tag := range tags {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			commits, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference(tag))
+			counts[i], errs[i] = len(commits), err
+		}()
+	}
+	wg.Wait()
+
+	for i, tag := range tags {
+		if errs[i] != nil {
+			t.Fatalf("GetCommitSetForTag(%s) failed: %v", tag, errs[i])
+		}
+	}
+	if want := []int{1, 3, 1, 3}; !reflect.DeepEqual(counts, want) {
+		t.Errorf("commit counts = %v, want %v", counts, want)
+	}
+}
+
+// TestGitRepository_ConcurrentUse tests that methods reading objects through go-git can share one repository;
+// run with -race to detect unsynchronized access
+func TestGitRepository_ConcurrentUse(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+	tag := fixture.Reference("v1.1.0")
+
+	calls := []func() error{
+		func() error { _, err := repo.FetchAllTags(); return err },
+		func() error {
+			_, err := repo.GetFirstParentCommitSet(context.Background(), tag, "internal")
+			return err
+		},
+		func() error { _, err := repo.GetIntroducingCommits(context.Background(), tag); return err },
+		func() error { _, err := repo.GetTagCommit(tag); return err },
+		func() error { _, err := repo.GetTagObject(tag); return err },
+		func() error { _, err := repo.HasDirectory(tag, "src/api"); return err },
+		func() error { _, err := repo.GetBranchReference(""); return err },
+		func() error {
+			_, err := repo.IsFirstParentAncestor(context.Background(), fixture.Hash("v1.0.0"), fixture.Hash("v1.1.0"))
+			return err
+		},
+		func() error { _, err := repo.GetRemoteURLs(); return err },
+		func() error {
+			_, err := repo.commitSetTouchingDirectory(context.Background(), fixture.Hash("v1.1.0"), "src")
+			return err
+		},
+	}
+
+	errs := make([]error, 4*len(calls))
+	var wg sync.WaitGroup
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs[i] = calls[i%len(calls)]()
+		}()
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		t.Errorf("concurrent calls failed: %v", err)
+	}
+}
+
+// TestGetCommitSetForTagFilteredByDirectory_AnnotatedTag tests with directory filter
+func TestGetCommitSetForTagFilteredByDirectory_AnnotatedTag(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
 
-	// Initialize git repo
-	cmd := exec.Command("git", "init")
-	cmd.Dir = tempDir
-	if err := cmd.Run(); err != nil {
-		t.Fatalf("Failed to init git repo: %v", err)
+	commits, err := repo.GetCommitSetForTagFilteredByDirectory(context.Background(), fixture.Reference("v1.1.0"), "internal")
+	if err != nil {
+		t.Fatalf("GetCommitSetForTagFilteredByDirectory() failed: %v", err)
 	}
 
-	// Create a commit
-	testFile := filepath.Join(tempDir, "test.txt")
-	if err := os.WriteFile(testFile, []byte("test"), 0644); err != nil {
-		t.Fatalf("Failed to write test file: %v", err)
+	// "Add API" and "Refactor internal" touch internal/; "Add b endpoint" does not
+	if len(commits) != 2 {
+		t.Errorf("GetCommitSetForTagFilteredByDirectory() returned %d commits, want 2", len(commits))
+	}
+	if _, ok := commits[fixture.Hash("v1.1.0")]; ok {
+		t.Errorf("GetCommitSetForTagFilteredByDirectory() included a commit outside internal/")
 	}
+}
 
-	cmd = exec.Command("git", "add", "test.txt")
-	cmd.Dir = tempDir
-	if err := cmd.Run(); err != nil {
-		t.Fatalf("Failed to add file: %v", err)
+// TestCompare_DirectoryFilter tests that the directory filter is validated against the tags, not the working tree
+func TestCompare_DirectoryFilter(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Commit("Remove API", testutil.Remove("src/api/a.go"), testutil.Remove("src/api/b.go")).
+		Tag("v2.0.0")
+
+	tests := []struct {
+		name      string
+		directory string
+		wantError error
+	}{
+		{name: "Only in the older tag", directory: "src/api/"},
+		{name: "Whole repository", directory: "."},
+		{name: "Typo", directory: "src/apis", wantError: ErrInvalidDirectory},
+		{name: "Outside the repository", directory: "../other", wantError: ErrInvalidDirectory},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+				Directory:  tt.directory,
+			}
+			result, err := Compare(config)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("Compare() error = %v, want %v", err, tt.wantError)
+			}
+			if tt.wantError == nil && len(result.SharedCommits) != 1 {
+				t.Errorf("Compare() shared commits = %d, want 1", len(result.SharedCommits))
+			}
+		})
 	}
+}
 
-	cmd = exec.Command("git", "-c", "user.name=Test", "-c", "user.email=test@test.com",
-		"commit", "-m", "test commit")
-	cmd.Dir = tempDir
-	if err := cmd.Run(); err != nil {
-		t.Fatalf("Failed to commit: %v", err)
+// TestCompare_DockerProfile tests that the docker profile only counts commits touching image-defining paths
+func TestCompare_DockerProfile(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("main.go", "package main\n"), testutil.File("Dockerfile", "FROM golang\n")).
+		Tag("v1.0.0").
+		Commit("Update code", testutil.File("main.go", "package main // v2\n")).
+		Commit("Bump base image", testutil.File("Dockerfile", "FROM golang:1.23\n")).
+		Commit("Add compose file", testutil.File("deploy/docker-compose.yml", "services: {}\n")).
+		Tag("v1.1.0")
+
+	config := CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		Profile:    ProfileDocker,
+	}
+	result, err := Compare(config)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
 	}
 
-	// Create lightweight tag
-	cmd = exec.Command("git", "tag", "lightweight-test")
-	cmd.Dir = tempDir
-	if err := cmd.Run(); err != nil {
-		t.Fatalf("Failed to create lightweight tag: %v", err)
+	if len(result.SharedCommits) != 1 {
+		t.Errorf("Compare() shared commits = %d, want 1", len(result.SharedCommits))
 	}
+	if len(result.OnlyInTag2) != 2 {
+		t.Errorf("Compare() commits only in v1.1.0 = %d, want 2", len(result.OnlyInTag2))
+	}
+}
+
+// TestGetFirstParentCommitSet tests that merged branches collapse into their merge commit
+func TestGetFirstParentCommitSet(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n")).
+		Branch("feature").
+		Checkout("feature").
+		Commit("Add handler", testutil.File("src/api/a.go", "package api\n")).
+		Commit("Fix typo", testutil.File("src/api/a.go", "package api // fixed\n")).
+		Commit("Add test", testutil.File("src/api/a_test.go", "package api\n")).
+		Checkout("main").
+		Commit("Update docs", testutil.File("README.md", "readme v2\n")).
+		Merge("feature", "Merge feature").
+		Tag("v1.0.0")
+	repo := openFixture(t, fixture)
+
+	tests := []struct {
+		name      string
+		directory string
+		want      []string
+	}{
+		{name: "Whole history", want: []string{"Merge feature", "Update docs", "Initial"}},
+		{name: "Directory", directory: "src/api", want: []string{"Merge feature"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			commits, err := repo.GetFirstParentCommitSet(context.Background(), fixture.Reference("v1.0.0"), tt.directory)
+			if err != nil {
+				t.Fatalf("GetFirstParentCommitSet() failed: %v", err)
+			}
+
+			var subjects []string
+			for hash := range commits {
+				commit, err := repo.GetCommitObject(hash)
+				if err != nil {
+					t.Fatalf("GetCommitObject() failed: %v", err)
+				}
+				subjects = append(subjects, strings.TrimSpace(commit.Message))
+			}
+			if len(subjects) != len(tt.want) {
+				t.Fatalf("GetFirstParentCommitSet() = %v, want %v", subjects, tt.want)
+			}
+			for _, want := range tt.want {
+				if !slices.Contains(subjects, want) {
+					t.Errorf("GetFirstParentCommitSet() = %v, missing %q", subjects, want)
+				}
+			}
+		})
+	}
+}
 
-	// Open repository and test
-	repo, err := NewGitRepository(tempDir)
+// TestGetBranchesContaining tests branch containment, branch tips, and first-parent ancestry against a real repository
+func TestGetBranchesContaining(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n")).
+		Branch("release/1.0").
+		Checkout("release/1.0").
+		Commit("Fix bug", testutil.File("fix.go", "package fix\n")).
+		Tag("v1.0.1").
+		Checkout("main").
+		Merge("release/1.0", "Merge release/1.0")
+	repo := openFixture(t, fixture)
+	tagged := fixture.Hash("v1.0.1")
+
+	refs, err := repo.GetBranchesContaining(context.Background(), tagged)
 	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
+		t.Fatalf("GetBranchesContaining() failed: %v", err)
+	}
+	var names []string
+	for _, ref := range refs {
+		names = append(names, ref.Name().Short())
+	}
+	if !slices.Equal(names, []string{"main", "release/1.0"}) {
+		t.Errorf("GetBranchesContaining() = %v, want [main release/1.0]", names)
 	}
 
-	tags, err := repo.FetchAllTags()
+	// Only release/1.0 still points at the tag; main moved on to the merge
+	tips, err := repo.GetBranchesPointingAt(tagged)
 	if err != nil {
-		t.Fatalf("Failed to fetch tags: %v", err)
+		t.Fatalf("GetBranchesPointingAt() failed: %v", err)
+	}
+	if len(tips) != 1 || tips[0].Name().Short() != "release/1.0" {
+		t.Errorf("GetBranchesPointingAt() = %v, want [release/1.0]", tips)
 	}
 
-	var lwRef *plumbing.Reference
-	for _, ref := range tags {
-		if ref.Name().Short() == "lightweight-test" {
-			lwRef = ref
-			break
+	for branch, want := range map[string]bool{"main": false, "release/1.0": true} {
+		got, err := repo.IsFirstParentAncestor(context.Background(), tagged, fixture.Hash(branch))
+		if err != nil {
+			t.Fatalf("IsFirstParentAncestor() failed: %v", err)
+		}
+		if got != want {
+			t.Errorf("IsFirstParentAncestor(v1.0.1, %s) = %v, want %v", branch, got, want)
 		}
 	}
-	if lwRef == nil {
-		t.Fatalf("lightweight-test tag not found")
+
+	detected, err := DetectTagBranch(context.Background(), repo, fixture.Reference("v1.0.1"))
+	if err != nil || detected.Branch != "release/1.0" {
+		t.Errorf("DetectTagBranch() = %q, %v, want release/1.0", detected.Branch, err)
 	}
+}
 
-	// Resolve tag to commit
-	commit, err := repo.resolveTagToCommit(lwRef)
+// TestGetDiffBetweenTags_AnnotatedTags tests diff with two annotated tags
+func TestGetDiffBetweenTags_AnnotatedTags(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	diff, err := repo.GetDiffBetweenTags(context.Background(), fixture.Reference("v1.0.0"), fixture.Reference("v1.1.0"), nil)
 	if err != nil {
-		t.Errorf("resolveTagToCommit() failed for lightweight tag: %v", err)
-		return
-	}
-	if commit == nil {
-		t.Errorf("resolveTagToCommit() returned nil commit")
-		return
+		t.Fatalf("GetDiffBetweenTags() failed: %v", err)
 	}
 
-	// Verify it's a valid commit
-	if commit.Hash.IsZero() {
-		t.Errorf("resolveTagToCommit() returned commit with zero hash")
+	if !strings.Contains(diff, "src/api/b.go") || !strings.Contains(diff, "internal/x.go") {
+		t.Errorf("GetDiffBetweenTags() = %q, want changes to src/api/b.go and internal/x.go", diff)
 	}
 }
 
-// TestGetCommitSetForTag_AnnotatedTag tests with real annotated tags
-func TestGetCommitSetForTag_AnnotatedTag(t *testing.T) {
-	repo, err := NewGitRepository("..")
+// TestGetDiffBetweenTags_WithDirectory tests diff with directory filter
+func TestGetDiffBetweenTags_WithDirectory(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	diff, err := repo.GetDiffBetweenTags(context.Background(), fixture.Reference("v1.0.0"), fixture.Reference("v1.1.0"), []string{"internal"}, "--name-only")
 	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
+		t.Fatalf("GetDiffBetweenTags() with directory filter failed: %v", err)
 	}
 
-	tags, err := repo.FetchAllTags()
+	if strings.TrimSpace(diff) != "internal/x.go" {
+		t.Errorf("GetDiffBetweenTags() = %q, want only internal/x.go", diff)
+	}
+}
+
+// TestGetLimitedDiffBetweenTags tests that a diff over the limit is cut after its last complete line
+func TestGetLimitedDiffBetweenTags(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+	tag1, tag2 := fixture.Reference("v1.0.0"), fixture.Reference("v1.1.0")
+
+	full, err := repo.GetDiffBetweenTags(context.Background(), tag1, tag2, nil, "--patch")
 	if err != nil {
-		t.Fatalf("Failed to fetch tags: %v", err)
+		t.Fatalf("GetDiffBetweenTags() failed: %v", err)
 	}
 
-	// Find v1.0.0 tag (annotated)
-	var v100Ref *plumbing.Reference
-	for _, ref := range tags {
-		if ref.Name().Short() == "v1.0.0" {
-			v100Ref = ref
-			break
-		}
+	whole, truncated, err := repo.GetLimitedDiffBetweenTags(context.Background(), tag1, tag2, nil, int64(len(full)), "--patch")
+	if err != nil || truncated || whole != full {
+		t.Errorf("GetLimitedDiffBetweenTags() at the diff size = %q, %v, %v, want the whole diff", whole, truncated, err)
 	}
-	if v100Ref == nil {
-		t.Skip("v1.0.0 tag not found, skipping test")
+
+	limit := int64(len(full) / 2)
+	partial, truncated, err := repo.GetLimitedDiffBetweenTags(context.Background(), tag1, tag2, nil, limit, "--patch")
+	if err != nil {
+		t.Fatalf("GetLimitedDiffBetweenTags() failed: %v", err)
+	}
+	if !truncated {
+		t.Errorf("GetLimitedDiffBetweenTags() truncated = false, want true")
 	}
+	if partial == "" || int64(len(partial)) > limit || !strings.HasPrefix(full, partial) || !strings.HasSuffix(partial, "\n") {
+		t.Errorf("GetLimitedDiffBetweenTags() = %q, want complete lines of at most %d bytes from %q", partial, limit, full)
+	}
+}
 
-	// Get commit set
-	commits, err := repo.GetCommitSetForTag(v100Ref)
+// TestGetCommitObjects tests batch commit retrieval
+func TestGetCommitObjects(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+	head := fixture.Hash("HEAD")
+
+	commits, err := repo.GetCommitObjects([]plumbing.Hash{head, head})
 	if err != nil {
-		t.Errorf("GetCommitSetForTag() failed: %v", err)
+		t.Fatalf("GetCommitObjects() failed: %v", err)
+	}
+	if len(commits) != 2 || commits[0].Hash != head || commits[1].Hash != head {
+		t.Errorf("GetCommitObjects() returned unexpected commits: %v", commits)
 	}
-	if len(commits) == 0 {
-		t.Errorf("GetCommitSetForTag() returned empty commit set")
+
+	missing := plumbing.NewHash("0123456789abcdef0123456789abcdef01234567")
+	if _, err := repo.GetCommitObjects([]plumbing.Hash{head, missing}); err == nil {
+		t.Errorf("GetCommitObjects() expected error for missing commit")
 	}
 }
 
-// TestGetCommitSetForTagFilteredByDirectory_AnnotatedTag tests with directory filter
-func TestGetCommitSetForTagFilteredByDirectory_AnnotatedTag(t *testing.T) {
-	repo, err := NewGitRepository("..")
+// TestGetCommitSetForTag_MissingObject tests traversal over a history with a deleted commit object
+func TestGetCommitSetForTag_MissingObject(t *testing.T) {
+	tests := []struct {
+		name      string
+		directory string
+		strict    bool
+		wantErr   bool
+	}{
+		{name: "skip gap", wantErr: false},
+		{name: "skip gap with directory filter", directory: "src", wantErr: false},
+		{name: "strict", strict: true, wantErr: true},
+		{name: "strict with directory filter", directory: "src", strict: true, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fixture := testutil.NewRepo(t).
+				Commit("first").
+				Commit("add src", testutil.File("src/main.go", "package main\n"))
+			missing := fixture.Hash("HEAD")
+			fixture.Commit("edit src", testutil.File("src/main.go", "package main\n\nfunc main() {}\n")).Tag("v2.0.0")
+			head := fixture.Hash("HEAD")
+
+			// Delete the loose object of the middle commit
+			if err := os.Remove(fixture.ObjectPath(missing)); err != nil {
+				t.Fatalf("failed to remove commit object: %v", err)
+			}
+
+			repo := openFixture(t, fixture)
+			repo.strict = tt.strict
+
+			ref := fixture.Reference("v2.0.0")
+			var commits map[plumbing.Hash]struct{}
+			var err error
+			if tt.directory != "" {
+				commits, err = repo.GetCommitSetForTagFilteredByDirectory(context.Background(), ref, tt.directory)
+			} else {
+				commits, err = repo.GetCommitSetForTag(context.Background(), ref)
+			}
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error in strict mode, got %d commits", len(commits))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("traversal failed: %v", err)
+			}
+
+			if _, ok := commits[head]; !ok || len(commits) != 1 {
+				t.Errorf("commits = %v, want only %s", commits, head)
+			}
+			unreadable := repo.UnreadableCommits()
+			if len(unreadable) != 1 || unreadable[0] != missing {
+				t.Errorf("UnreadableCommits() = %v, want [%s]", unreadable, missing)
+			}
+			if approximated := repo.ApproximatedDirectories(); (tt.directory != "") != slices.Equal(approximated, []string{tt.directory}) {
+				t.Errorf("ApproximatedDirectories() = %v, want the directory filter %q", approximated, tt.directory)
+			}
+		})
+	}
+}
+
+// TestGetCommitSetForTagFilteredByDirectory_GitFailure tests that failures other than unreadable objects
+// are returned rather than answered by the approximate walk
+func TestGetCommitSetForTagFilteredByDirectory_GitFailure(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+	t.Setenv("PATH", t.TempDir()) // No git binary
+
+	if _, err := repo.GetCommitSetForTagFilteredByDirectory(context.Background(), fixture.Reference("v1.0.0"), "src"); !errors.Is(err, ErrTraverseCommits) {
+		t.Errorf("GetCommitSetForTagFilteredByDirectory() error = %v, want %v", err, ErrTraverseCommits)
+	}
+	if approximated := repo.ApproximatedDirectories(); len(approximated) != 0 {
+		t.Errorf("ApproximatedDirectories() = %v, want none", approximated)
+	}
+}
+
+// TestStreamCommitFiles tests streaming commit metadata and changed files through git log
+func TestStreamCommitFiles(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("first").
+		Commit("Add sources", testutil.File("src/main.go", "package main\n"), testutil.File("README.md", "# test\n"))
+	repo := openFixture(t, fixture)
+
+	var subjects []string
+	var files [][]string
+	err := repo.StreamCommitFiles(context.Background(), []plumbing.Hash{fixture.Hash("HEAD"), fixture.Hash("HEAD~1")}, func(commit *object.Commit, changed []string) error {
+		subjects = append(subjects, commit.Message)
+		files = append(files, changed)
+		return nil
+	})
 	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
+		t.Fatalf("StreamCommitFiles() failed: %v", err)
 	}
 
-	tags, err := repo.FetchAllTags()
+	if len(subjects) != 2 || subjects[0] != "Add sources" || subjects[1] != "first" {
+		t.Errorf("subjects = %v, want [Add sources first] in input order", subjects)
+	}
+	if len(files) != 2 || len(files[0]) != 2 || len(files[1]) != 0 {
+		t.Errorf("files = %v, want [[README.md src/main.go] []]", files)
+	}
+}
+
+// TestGetFileCommits_NonASCIIPath tests that non-ASCII paths are listed as they are, not C-quoted
+func TestGetFileCommits_NonASCIIPath(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("README.md", "readme\n")).
+		Tag("v1.0.0").
+		Commit("Add café", testutil.File("café.go", "package cafe\n")).
+		Tag("v1.1.0")
+	repo := openFixture(t, fixture)
+
+	fileCommits, err := repo.GetFileCommits(context.Background(), fixture.Reference("v1.0.0"), fixture.Reference("v1.1.0"), nil)
 	if err != nil {
-		t.Fatalf("Failed to fetch tags: %v", err)
+		t.Fatalf("GetFileCommits() failed: %v", err)
 	}
+	if commits := fileCommits["café.go"]; len(fileCommits) != 1 || len(commits) != 1 || commits[0] != fixture.Hash("v1.1.0") {
+		t.Errorf("GetFileCommits() = %v, want café.go changed by v1.1.0", fileCommits)
+	}
+}
 
-	var v100Ref *plumbing.Reference
-	for _, ref := range tags {
-		if ref.Name().Short() == "v1.0.0" {
-			v100Ref = ref
-			break
+// TestGetFileCommits_Rename tests that a renamed file keeps its history under the old path
+func TestGetFileCommits_Rename(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("old.go", "package main\n\nfunc main() {}\n")).
+		Tag("v1.0.0").
+		Commit("Rename", testutil.Remove("old.go"), testutil.File("new.go", "package main\n\nfunc main() {}\n")).
+		Tag("v1.1.0")
+	repo := openFixture(t, fixture)
+
+	fileCommits, err := repo.GetFileCommits(context.Background(), fixture.Reference("v1.0.0"), fixture.Reference("v1.1.0"), nil)
+	if err != nil {
+		t.Fatalf("GetFileCommits() failed: %v", err)
+	}
+	for _, path := range []string{"old.go", "new.go"} {
+		if commits := fileCommits[path]; !slices.Contains(commits, fixture.Hash("v1.1.0")) {
+			t.Errorf("GetFileCommits()[%q] = %v, want the rename commit", path, commits)
 		}
 	}
-	if v100Ref == nil {
-		t.Skip("v1.0.0 tag not found, skipping test")
+	if commits := fileCommits["old.go"]; !slices.Contains(commits, fixture.Hash("v1.0.0")) {
+		t.Errorf("GetFileCommits()[old.go] = %v, want the commit that added it", commits)
+	}
+}
+
+// TestNewGitRepository_ObjectFormatMismatch tests that a repository whose object format differs from the build is rejected
+func TestNewGitRepository_ObjectFormatMismatch(t *testing.T) {
+	other := formatcfg.SHA256
+	if BuildObjectFormat() == formatcfg.SHA256 {
+		other = formatcfg.SHA1
 	}
 
-	// Get filtered commit set (internal directory exists in this repo)
-	commits, err := repo.GetCommitSetForTagFilteredByDirectory(v100Ref, "internal")
+	// go-git cannot init a repository in the other format, so write the extension the way native git does
+	path := t.TempDir()
+	repo, err := git.PlainInit(path, false)
 	if err != nil {
-		t.Errorf("GetCommitSetForTagFilteredByDirectory() failed: %v", err)
+		t.Fatalf("PlainInit() error = %v", err)
+	}
+	cfg, err := repo.Config()
+	if err != nil {
+		t.Fatalf("Config() error = %v", err)
+	}
+	cfg.Raw.Section("core").SetOption("repositoryformatversion", "1")
+	cfg.Raw.Section("extensions").SetOption("objectformat", string(other))
+	if err := repo.SetConfig(cfg); err != nil {
+		t.Fatalf("SetConfig() error = %v", err)
 	}
 
-	// Should have at least some commits touching internal/
-	if len(commits) == 0 {
-		t.Logf("Warning: No commits found for internal/ directory in v1.0.0")
+	_, err = NewGitRepository(path)
+	if !errors.Is(err, ErrUnsupportedObjectFormat) {
+		t.Fatalf("NewGitRepository() error = %v, want ErrUnsupportedObjectFormat", err)
+	}
+	if !strings.Contains(err.Error(), string(other)) {
+		t.Errorf("error should name the repository format %s: %v", other, err)
 	}
 }
 
-// TestGetDiffBetweenTags_AnnotatedTags tests diff with two annotated tags
-func TestGetDiffBetweenTags_AnnotatedTags(t *testing.T) {
-	repo, err := NewGitRepository("..")
+// TestNewGitRepository_LinkedWorktree tests that a linked worktree reads the same tags and history as the main checkout
+func TestNewGitRepository_LinkedWorktree(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	mainRepo := openFixture(t, fixture)
+
+	repo, err := NewGitRepository(fixture.LinkedWorktree("release"))
 	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
+		t.Fatalf("NewGitRepository() error = %v", err)
 	}
 
 	tags, err := repo.FetchAllTags()
 	if err != nil {
-		t.Fatalf("Failed to fetch tags: %v", err)
+		t.Fatalf("FetchAllTags() error = %v", err)
+	}
+	if len(tags) != 2 {
+		t.Fatalf("FetchAllTags() returned %d tags, want 2", len(tags))
 	}
 
-	var v100Ref, v110Ref *plumbing.Reference
-	for _, ref := range tags {
-		switch ref.Name().Short() {
-		case "v1.0.0":
-			v100Ref = ref
-		case "v1.1.0":
-			v110Ref = ref
-		}
+	// go-git traversal
+	got, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() error = %v", err)
+	}
+	want, err := mainRepo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag() on the main checkout error = %v", err)
+	}
+	if len(got) != len(want) {
+		t.Errorf("GetCommitSetForTag() returned %d commits, want %d", len(got), len(want))
 	}
 
-	if v100Ref == nil || v110Ref == nil {
-		t.Skip("Required tags not found, skipping test")
+	// Native git
+	filtered, err := repo.GetCommitSetForTagFilteredByDirectory(context.Background(), fixture.Reference("v1.1.0"), "src/api")
+	if err != nil {
+		t.Fatalf("GetCommitSetForTagFilteredByDirectory() error = %v", err)
+	}
+	if len(filtered) != 2 {
+		t.Errorf("GetCommitSetForTagFilteredByDirectory() returned %d commits, want 2", len(filtered))
 	}
+}
 
-	// Get diff between tags
-	diff, err := repo.GetDiffBetweenTags(v100Ref, v110Ref, "")
+// TestGetTagObject tests that annotated tags return their tag object and lightweight tags return nil
+func TestGetTagObject(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Author("Alice", "alice@example.com").
+		Commit("first").
+		AnnotatedTag("v1.0.0", "Release 1.0.0").
+		Commit("second").
+		Tag("v1.1.0")
+	repo := openFixture(t, fixture)
+
+	tagObj, err := repo.GetTagObject(fixture.Reference("v1.0.0"))
 	if err != nil {
-		t.Errorf("GetDiffBetweenTags() failed: %v", err)
+		t.Fatalf("GetTagObject() error = %v", err)
+	}
+	if tagObj == nil || tagObj.Tagger.Name != "Alice" {
+		t.Errorf("GetTagObject() for an annotated tag = %+v, want tagger Alice", tagObj)
 	}
 
-	// Diff should not be empty (there are changes between these versions)
-	if diff == "" {
-		t.Logf("Warning: Empty diff between v1.0.0 and v1.1.0")
+	tagObj, err = repo.GetTagObject(fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("GetTagObject() error = %v", err)
+	}
+	if tagObj != nil {
+		t.Errorf("GetTagObject() for a lightweight tag = %+v, want nil", tagObj)
 	}
 }
 
-// TestGetDiffBetweenTags_WithDirectory tests diff with directory filter
-func TestGetDiffBetweenTags_WithDirectory(t *testing.T) {
-	repo, err := NewGitRepository("..")
+// TestGetRootCommits tests that only the roots reachable from HEAD are returned
+func TestGetRootCommits(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("first").
+		Tag("root").
+		Commit("second").
+		Branch("main").
+		Orphan("pages").
+		Commit("docs site").
+		Checkout("main")
+	repo := openFixture(t, fixture)
+
+	roots, err := repo.GetRootCommits(context.Background())
 	if err != nil {
-		t.Fatalf("Failed to open repository: %v", err)
+		t.Fatalf("GetRootCommits() error = %v", err)
+	}
+	if len(roots) != 1 || roots[0] != fixture.Hash("root") {
+		t.Errorf("GetRootCommits() = %v, want [%s]", roots, fixture.Hash("root"))
 	}
 
-	tags, err := repo.FetchAllTags()
+	empty := openFixture(t, testutil.NewRepo(t))
+	if roots, err := empty.GetRootCommits(context.Background()); err != nil || len(roots) != 0 {
+		t.Errorf("GetRootCommits() of an unborn HEAD = %v, %v, want none", roots, err)
+	}
+}
+
+// TestFingerprintRepository_LinkedWorktree tests that two checkouts of one repository share a fingerprint
+func TestFingerprintRepository_LinkedWorktree(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Remote("origin", "git@github.com:owner/repo.git").
+		Commit("first").
+		Branch("main")
+	worktree, err := NewGitRepository(fixture.LinkedWorktree("release"))
 	if err != nil {
-		t.Fatalf("Failed to fetch tags: %v", err)
+		t.Fatalf("Failed to open linked worktree: %v", err)
 	}
 
-	var v100Ref, v110Ref *plumbing.Reference
-	for _, ref := range tags {
-		switch ref.Name().Short() {
-		case "v1.0.0":
-			v100Ref = ref
-		case "v1.1.0":
-			v110Ref = ref
-		}
+	main, err := FingerprintRepository(context.Background(), openFixture(t, fixture))
+	if err != nil {
+		t.Fatalf("FingerprintRepository() error = %v", err)
+	}
+	linked, err := FingerprintRepository(context.Background(), worktree)
+	if err != nil {
+		t.Fatalf("FingerprintRepository() error = %v", err)
 	}
+	if main.ID == "" || main != linked || main.Remote != "github.com/owner/repo" {
+		t.Errorf("FingerprintRepository() = %+v and %+v, want the same identity of github.com/owner/repo", main, linked)
+	}
+}
 
-	if v100Ref == nil || v110Ref == nil {
-		t.Skip("Required tags not found, skipping test")
+// TestResolveRevision tests resolving branches, abbreviated hashes, and revision expressions
+func TestResolveRevision(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	tests := []struct {
+		revision string
+		want     plumbing.Hash
+	}{
+		{revision: "main", want: fixture.Hash("v1.1.0")},
+		{revision: "HEAD~1", want: fixture.Hash("HEAD~1")},
+		{revision: fixture.Hash("v1.0.0").String()[:10], want: fixture.Hash("v1.0.0")},
+		{revision: "v1.0.0", want: fixture.Hash("v1.0.0")}, // Annotated tags are peeled to their commit
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.revision, func(t *testing.T) {
+			ref, err := repo.ResolveRevision(context.Background(), tt.revision)
+			if err != nil {
+				t.Fatalf("ResolveRevision() error = %v", err)
+			}
+			if ref.Hash() != tt.want || ref.Name().Short() != tt.revision {
+				t.Errorf("ResolveRevision() = %s %s, want %s %s", ref.Name(), ref.Hash(), tt.revision, tt.want)
+			}
+		})
+	}
+
+	for _, revision := range []string{"nope", "-h", ""} {
+		if _, err := repo.ResolveRevision(context.Background(), revision); !errors.Is(err, ErrResolveRevision) {
+			t.Errorf("ResolveRevision(%q) error = %v, want ErrResolveRevision", revision, err)
+		}
 	}
 
-	// Get diff for internal directory only
-	diff, err := repo.GetDiffBetweenTags(v100Ref, v110Ref, "internal")
+	// A tag compared with a branch and a revision expression
+	result, err := Compare(CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "main~1"}})
 	if err != nil {
-		t.Errorf("GetDiffBetweenTags() with directory filter failed: %v", err)
+		t.Fatalf("Compare() error = %v", err)
 	}
-
-	// Should have some diff (internal/ has changes between versions)
-	if diff == "" {
-		t.Logf("Warning: Empty diff for internal/ between v1.0.0 and v1.1.0")
+	if result.Similarity != 0.5 || result.Tag2Audit.Commit != fixture.Hash("HEAD~1").String() {
+		t.Errorf("Compare() similarity = %v, tag2 commit = %s, want 0.5 at HEAD~1", result.Similarity, result.Tag2Audit.Commit)
+	}
+	if !result.Tag2Audit.Revision {
+		t.Errorf("Tag2Audit.Revision = false, want true for a revision that is not a tag")
 	}
 }
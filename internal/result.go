@@ -0,0 +1,292 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrSaveResult        = errors.New("failed to save result")
+	ErrLoadResult        = errors.New("failed to load result")
+	ErrUnsupportedResult = errors.New("unsupported result format version")
+	ErrGetDiff           = errors.New("failed to get diff")
+	ErrGetCommitDetails  = errors.New("failed to get commit details")
+)
+
+// savedResultFormatLatest is the SavedResult format version written by this build
+const savedResultFormatLatest = 1
+
+// CommitInfo is the serializable summary of a single commit
+type CommitInfo struct {
+	Hash    string    `json:"hash"`
+	Author  string    `json:"author"`
+	Email   string    `json:"email"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+
+	// Topology tells whether a unique commit is on the tag's first-parent line or from a merged side branch
+	Topology CommitTopology `json:"topology,omitempty"`
+}
+
+// SavedResult is the self-contained JSON form of a CompareResult.
+// It carries everything needed to render a report without access to the repository.
+type SavedResult struct {
+	FormatVersion int       `json:"format_version"`
+	GeneratedAt   time.Time `json:"generated_at"`
+	RepoPath      string    `json:"repo_path"`
+	Tag1          string    `json:"tag1"`
+	Tag2          string    `json:"tag2"`
+	Tag1Branch    string    `json:"tag1_branch,omitempty"`
+	Tag2Branch    string    `json:"tag2_branch,omitempty"`
+
+	// Repository identifies the repository independently of RepoPath, which differs between clones
+	Repository *RepoFingerprint `json:"repository,omitempty"`
+
+	// Tag1Audit and Tag2Audit record who created each tag, when, and from which commit
+	Tag1Audit *TagAudit `json:"tag1_audit,omitempty"`
+	Tag2Audit *TagAudit `json:"tag2_audit,omitempty"`
+
+	Directory    string   `json:"directory,omitempty"`
+	Profile      string   `json:"profile,omitempty"`
+	FirstParent  bool     `json:"first_parent,omitempty"`
+	Baseline     []string `json:"exclude_reachable_from,omitempty"`
+	AuthorFilter string   `json:"author_filter,omitempty"`
+	DateRange    string   `json:"date_range,omitempty"`
+	Merges       string   `json:"merges,omitempty"`
+	Match        string   `json:"match,omitempty"`
+	Metric       string   `json:"metric,omitempty"`
+	Similarity   float64  `json:"similarity"`
+	// Interpretation labels the similarity with the score band of the project config it falls in
+	Interpretation string       `json:"interpretation,omitempty"`
+	SharedCommits  []string     `json:"shared_commits"`
+	OnlyInTag1     []CommitInfo `json:"only_in_tag1"`
+	OnlyInTag2     []CommitInfo `json:"only_in_tag2"`
+	DiffStat       string       `json:"diff_stat"`
+
+	// LargeFiles lists the binary and large files changed between the tags, which DiffStat excludes
+	LargeFiles    []LargeFileChange `json:"large_files,omitempty"`
+	FileMatrix    []FileSimilarity  `json:"file_matrix,omitempty"`
+	PathBreakdown []FileSimilarity  `json:"path_breakdown,omitempty"`
+
+	// CategoryBreakdown splits the changes into infrastructure and application code
+	CategoryBreakdown []FileSimilarity `json:"category_breakdown,omitempty"`
+
+	// Tag1Tests and Tag2Tests summarize test changes among the commits unique to each tag
+	Tag1Tests *TestChangeStats `json:"tag1_tests,omitempty"`
+	Tag2Tests *TestChangeStats `json:"tag2_tests,omitempty"`
+
+	// Risk scores the change between the tags from churn, hotspots, breaking changes, signatures, and dependencies
+	Risk *RiskScore `json:"risk,omitempty"`
+
+	// Policy is the outcome of evaluating the comparison against a policy of the project config
+	Policy *PolicyResult `json:"policy,omitempty"`
+
+	// Rewrite records indicators of rewritten history; only set for diverged tags with a low similarity
+	Rewrite *RewriteAnalysis `json:"rewrite,omitempty"`
+
+	// MessageSimilarity compares the commit subjects of the tags; only set with -metric message
+	MessageSimilarity *float64 `json:"message_similarity,omitempty"`
+	// TreeSimilarity compares the files in the trees of the tags; only set with -metric files
+	TreeSimilarity *float64 `json:"tree_similarity,omitempty"`
+	// LineSimilarity compares the lines of code in the trees of the tags; only set with -metric lines
+	LineSimilarity *float64 `json:"line_similarity,omitempty"`
+
+	// PatchEquivalents maps tag2 commits to the tag1 commits they were cherry-picked from; only set with -match patch-id
+	PatchEquivalents map[string]string `json:"patch_equivalents,omitempty"`
+
+	// Archives records whether the published source archives of the tags match git archive
+	Archives []ArchiveVerification `json:"archives,omitempty"`
+
+	// Timings records how long each phase of the comparison took; only set with -timings
+	Timings []PhaseTiming `json:"timings,omitempty"`
+
+	// Metadata holds the -meta annotations of the run, e.g. the CI build that produced it
+	Metadata Metadata `json:"metadata,omitempty"`
+
+	// UnreadableCommits lists commits skipped during traversal; the similarity is approximate when set
+	UnreadableCommits []string `json:"unreadable_commits,omitempty"`
+
+	// Warnings lists the caveats printed with the result, e.g. unreadable commits or a failed metrics push
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// NewSavedResult converts a CompareResult into its serializable form,
+// loading commit details and the diff stat from the result's repository
+func NewSavedResult(ctx context.Context, result CompareResult) (SavedResult, error) {
+	saved := SavedResult{
+		FormatVersion:  savedResultFormatLatest,
+		GeneratedAt:    time.Now().UTC(),
+		RepoPath:       result.Config.RepoPath,
+		Tag1:           result.Config.Tag1Name,
+		Tag2:           result.Config.Tag2Name,
+		Tag1Branch:     result.Tag1Branch.Branch,
+		Tag2Branch:     result.Tag2Branch.Branch,
+		Directory:      result.Config.directoryFilter().String(),
+		Profile:        string(result.Config.Profile),
+		FirstParent:    result.Config.FirstParent,
+		Baseline:       result.Config.Baseline,
+		AuthorFilter:   result.Config.Authors.String(),
+		DateRange:      result.Config.Dates.String(),
+		Merges:         mergeModeField(result.Config.Merges),
+		Match:          string(result.Config.Match),
+		Metric:         string(result.Config.Metric),
+		Similarity:     result.Similarity,
+		Interpretation: result.Interpretation,
+		FileMatrix:     result.FileMatrix,
+		PathBreakdown:  result.PathBreakdown,
+
+		CategoryBreakdown: result.CategoryBreakdown,
+		Tag1Tests:         result.Tag1Tests,
+		Tag2Tests:         result.Tag2Tests,
+		Risk:              result.Risk,
+		Policy:            result.Policy,
+		Metadata:          result.Config.Metadata,
+		Rewrite:           result.Rewrite,
+		MessageSimilarity: result.MessageSimilarity,
+		TreeSimilarity:    result.TreeSimilarity,
+		LineSimilarity:    result.LineSimilarity,
+		Archives:          result.Archives,
+	}
+
+	saved.SharedCommits = make([]string, 0, len(result.SharedCommits))
+	for hash := range result.SharedCommits {
+		saved.SharedCommits = append(saved.SharedCommits, hash.String())
+	}
+	sort.Strings(saved.SharedCommits)
+
+	if result.PatchEquivalents != nil {
+		saved.PatchEquivalents = make(map[string]string, len(result.PatchEquivalents))
+		for hash2, hash1 := range result.PatchEquivalents {
+			saved.PatchEquivalents[hash2.String()] = hash1.String()
+		}
+	}
+
+	if result.Tag1Audit.Commit != "" {
+		saved.Tag1Audit = &result.Tag1Audit
+	}
+	if result.Tag2Audit.Commit != "" {
+		saved.Tag2Audit = &result.Tag2Audit
+	}
+
+	for _, hash := range result.UnreadableCommits {
+		saved.UnreadableCommits = append(saved.UnreadableCommits, hash.String())
+	}
+	sort.Strings(saved.UnreadableCommits)
+	saved.Warnings = result.Warnings
+
+	fingerprint, err := FingerprintRepository(ctx, result.Repo)
+	if err != nil {
+		return saved, err
+	}
+	if fingerprint.ID != "" {
+		saved.Repository = &fingerprint
+	}
+
+	if saved.OnlyInTag1, err = loadCommitInfos(result.Repo, result.OnlyInTag1, result.Tag1Mainline); err != nil {
+		return saved, errors.Join(ErrGetCommitDetails, err)
+	}
+	if saved.OnlyInTag2, err = loadCommitInfos(result.Repo, result.OnlyInTag2, result.Tag2Mainline); err != nil {
+		return saved, errors.Join(ErrGetCommitDetails, err)
+	}
+
+	phases := phaseRecorder{timings: result.Timings, progress: result.Config.Progress}
+	done := phases.start("diff stat")
+	pathspecs := result.Config.pathspecs()
+	if saved.LargeFiles, err = FindLargeFileChanges(ctx, result.Repo, result.Tag1Ref, result.Tag2Ref, pathspecs, result.Config.LargeFileSize); err != nil {
+		return saved, errors.Join(ErrGetDiff, err)
+	}
+
+	if saved.DiffStat, err = result.Repo.GetDiffBetweenTags(ctx, result.Tag1Ref, result.Tag2Ref, excludeLargeFiles(pathspecs, saved.LargeFiles), DiffOptions{StatWidth: result.Config.Output.Width, DetectRenames: true}.gitArgs()...); err != nil {
+		return saved, errors.Join(ErrGetDiff, err)
+	}
+	done()
+	phases.diffed(len(saved.DiffStat))
+
+	if result.Config.Timings && result.Timings != nil {
+		saved.Timings = result.Timings.Phases
+	}
+
+	return saved, nil
+}
+
+// loadCommitInfos loads commit details for a set of hashes, newest first.
+// The topology of each commit is set when the mainline commits are given.
+func loadCommitInfos(repo Repository, commitSet map[plumbing.Hash]struct{}, mainline map[plumbing.Hash]struct{}) ([]CommitInfo, error) {
+	hashes := hashesOf(commitSet)
+	commits, err := repo.GetCommitObjects(hashes)
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]CommitInfo, 0, len(hashes))
+	for i, hash := range hashes {
+		infos = append(infos, CommitInfo{
+			Hash:     hash.String(),
+			Author:   commits[i].Author.Name,
+			Email:    commits[i].Author.Email,
+			Date:     commits[i].Author.When,
+			Subject:  strings.Split(commits[i].Message, "\n")[0],
+			Topology: commitTopology(mainline, hash),
+		})
+	}
+
+	sortCommitInfos(infos)
+	return infos, nil
+}
+
+// sortCommitInfos orders commits from newest to oldest, breaking ties by hash
+func sortCommitInfos(infos []CommitInfo) {
+	sort.Slice(infos, func(i int, j int) bool {
+		if !infos[i].Date.Equal(infos[j].Date) {
+			return infos[i].Date.After(infos[j].Date)
+		}
+		return infos[i].Hash < infos[j].Hash
+	})
+}
+
+// SaveResult writes the JSON form of a CompareResult to path
+func SaveResult(ctx context.Context, result CompareResult, path string) error {
+	saved, err := NewSavedResult(ctx, result)
+	if err != nil {
+		return errors.Join(ErrSaveResult, err)
+	}
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return errors.Join(ErrSaveResult, err)
+	}
+
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return errors.Join(ErrSaveResult, err)
+	}
+
+	return nil
+}
+
+// LoadSavedResult reads a result previously written by SaveResult
+func LoadSavedResult(path string) (SavedResult, error) {
+	var saved SavedResult
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return saved, errors.Join(ErrLoadResult, err)
+	}
+
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return saved, errors.Join(ErrLoadResult, err)
+	}
+
+	if saved.FormatVersion < 1 || saved.FormatVersion > savedResultFormatLatest {
+		return saved, errors.Join(ErrUnsupportedResult, fmt.Errorf("%s has format version %d", path, saved.FormatVersion))
+	}
+
+	return saved, nil
+}
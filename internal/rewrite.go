@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+var ErrRewriteCheck = errors.New("failed to check for rewritten history")
+
+// rewriteCheckThreshold is the similarity below which diverged tags are checked for rewritten history
+const rewriteCheckThreshold = 0.5
+
+// RewriteAnalysis records indicators that the history between two tags was rewritten, e.g. by a rebase
+// or filter-repo, so the same changes have different hashes and the similarity understates the overlap
+type RewriteAnalysis struct {
+	Rewritten      bool `json:"rewritten"`
+	IdenticalTrees bool `json:"identical_trees,omitempty"` // The tags point to the same tree despite different commits
+	// MatchedCommits is the number of commits unique to the second tag whose patch ID matches a commit unique to the first
+	MatchedCommits int `json:"matched_commits"`
+	// Score is the share of the smaller unique side duplicated by the other side, between 0 and 1
+	Score float64 `json:"score"`
+	// AdjustedSimilarity counts each pair of matched commits as one shared commit
+	AdjustedSimilarity float64 `json:"adjusted_similarity"`
+}
+
+// Indicators describes why the history is considered rewritten
+func (a RewriteAnalysis) Indicators() []string {
+	var indicators []string
+	if a.IdenticalTrees {
+		indicators = append(indicators, "identical trees")
+	}
+	if a.MatchedCommits > 0 {
+		indicators = append(indicators, fmt.Sprintf("%d unique commits match by patch ID", a.MatchedCommits))
+	}
+	return indicators
+}
+
+// rewriteCheckApplies reports whether both tags have unique commits and the similarity is low enough
+// that rewritten history would make it misleading
+func rewriteCheckApplies(result CompareResult) bool {
+	return len(result.OnlyInTag1) > 0 && len(result.OnlyInTag2) > 0 && result.Similarity < rewriteCheckThreshold
+}
+
+// AnalyzeRewrite looks for rewritten history between diverged tags, or returns nil when the check does not apply.
+// Commits are matched by stable patch ID, which survives rebases that do not change the diff.
+func AnalyzeRewrite(ctx context.Context, repo Repository, result CompareResult) (*RewriteAnalysis, error) {
+	if !rewriteCheckApplies(result) {
+		return nil, nil
+	}
+
+	commit1, err := repo.GetTagCommit(result.Tag1Ref)
+	if err != nil {
+		return nil, errors.Join(ErrRewriteCheck, err)
+	}
+	commit2, err := repo.GetTagCommit(result.Tag2Ref)
+	if err != nil {
+		return nil, errors.Join(ErrRewriteCheck, err)
+	}
+
+	// The patch IDs of tag1..tag2 and tag2..tag1 cover the unique commits, except merges, which have no diff
+	ids1, err := repo.GetPatchIDs(ctx, result.Tag1Ref, result.Tag2Ref)
+	if err != nil {
+		return nil, errors.Join(ErrRewriteCheck, err)
+	}
+	ids2, err := repo.GetPatchIDs(ctx, result.Tag2Ref, result.Tag1Ref)
+	if err != nil {
+		return nil, errors.Join(ErrRewriteCheck, err)
+	}
+
+	analysis := RewriteAnalysis{IdenticalTrees: commit1.TreeHash == commit2.TreeHash}
+	for patchID, hash := range ids2 {
+		// Commits outside a directory or profile filter are not part of the comparison
+		if _, ok := result.OnlyInTag2[hash]; !ok {
+			continue
+		}
+		if match, ok := ids1[patchID]; ok {
+			if _, ok := result.OnlyInTag1[match]; ok {
+				analysis.MatchedCommits++
+			}
+		}
+	}
+
+	analysis.Score = float64(analysis.MatchedCommits) / float64(min(len(result.OnlyInTag1), len(result.OnlyInTag2)))
+	if analysis.IdenticalTrees {
+		analysis.Score = 1
+	}
+	analysis.Rewritten = analysis.IdenticalTrees || analysis.Score >= rewriteMatchRatio
+
+	shared := len(result.SharedCommits) + analysis.MatchedCommits
+	union := len(result.SharedCommits) + len(result.OnlyInTag1) + len(result.OnlyInTag2) - analysis.MatchedCommits
+	analysis.AdjustedSimilarity = float64(shared) / float64(union)
+
+	return &analysis, nil
+}
+
+// printRewriteAnalysis prints the rewritten history classification below the similarity
+func printRewriteAnalysis(result CompareResult) {
+	if result.Rewrite == nil || !result.Rewrite.Rewritten {
+		return
+	}
+
+	rewrite := result.Rewrite
+	fmt.Printf("History rewritten (score %.2f): ", rewrite.Score)
+	for i, indicator := range rewrite.Indicators() {
+		if i > 0 {
+			fmt.Printf(", ")
+		}
+		fmt.Printf("%s", indicator)
+	}
+	fmt.Printf("\nAdjusted similarity: %.2f%% (matched commits counted as shared)\n", rewrite.AdjustedSimilarity*100.0)
+}
@@ -0,0 +1,71 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestAnalyzeRewrite tests classifying diverged tags as rewritten history
+func TestAnalyzeRewrite(t *testing.T) {
+	// v1.0.0 and its rebased copy share only the base; "diverged" adds an unrelated change instead
+	fixture := testutil.NewRepo(t).
+		Commit("Base", testutil.File("a.go", "package a\n")).
+		Branch("rebase").
+		Branch("other").
+		Commit("Add x", testutil.File("x.go", "package x\n")).
+		Commit("Add y", testutil.File("y.go", "package y\n")).
+		Tag("v1.0.0").
+		Checkout("rebase").
+		Commit("Add x", testutil.File("x.go", "package x\n")).
+		Commit("Add y", testutil.File("y.go", "package y\n")).
+		Tag("v1.0.0-rebased").
+		Checkout("other").
+		Commit("Add z", testutil.File("z.go", "package z\n")).
+		Tag("diverged")
+
+	tests := []struct {
+		name      string
+		tag2      string
+		rewritten bool
+		matched   int
+		adjusted  float64
+	}{
+		{name: "rebased", tag2: "v1.0.0-rebased", rewritten: true, matched: 2, adjusted: 1},
+		{name: "diverged", tag2: "diverged", rewritten: false, matched: 0, adjusted: 0.25},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: tt.tag2}})
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if result.Rewrite == nil {
+				t.Fatalf("Rewrite = nil, want an analysis for diverged tags with similarity %.2f", result.Similarity)
+			}
+
+			if result.Rewrite.Rewritten != tt.rewritten {
+				t.Errorf("Rewritten = %v, want %v", result.Rewrite.Rewritten, tt.rewritten)
+			}
+			if result.Rewrite.IdenticalTrees != tt.rewritten {
+				t.Errorf("IdenticalTrees = %v, want %v", result.Rewrite.IdenticalTrees, tt.rewritten)
+			}
+			if result.Rewrite.MatchedCommits != tt.matched {
+				t.Errorf("MatchedCommits = %d, want %d", result.Rewrite.MatchedCommits, tt.matched)
+			}
+			if result.Rewrite.AdjustedSimilarity != tt.adjusted {
+				t.Errorf("AdjustedSimilarity = %v, want %v", result.Rewrite.AdjustedSimilarity, tt.adjusted)
+			}
+		})
+	}
+
+	// Tags on one line of history are not checked
+	result, err := Compare(CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.0.0"}})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Rewrite != nil {
+		t.Errorf("Rewrite = %+v, want nil for identical tags", result.Rewrite)
+	}
+}
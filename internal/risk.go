@@ -0,0 +1,344 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	ErrInvalidRiskWeights = errors.New("invalid risk weights")
+	ErrScoreRisk          = errors.New("failed to score risk")
+)
+
+// RiskSignal is a measurable property of the change between two tags that contributes to its risk
+type RiskSignal string
+
+const (
+	RiskChurn        RiskSignal = "churn"        // Lines added and deleted between the tags
+	RiskHotspots     RiskSignal = "hotspots"     // Share of file changes landing on files changed by many unique commits
+	RiskBreaking     RiskSignal = "breaking"     // Unique commits flagged as breaking changes
+	RiskUnsigned     RiskSignal = "unsigned"     // Share of unique commits without a signature
+	RiskDependencies RiskSignal = "dependencies" // Dependency manifests and lock files changed between the tags
+)
+
+// riskSignals lists the signals in output order
+var riskSignals = []RiskSignal{RiskChurn, RiskHotspots, RiskBreaking, RiskUnsigned, RiskDependencies}
+
+// defaultRiskWeights favors the size and nature of the change over its provenance
+var defaultRiskWeights = RiskWeights{
+	RiskChurn:        3,
+	RiskHotspots:     2,
+	RiskBreaking:     3,
+	RiskUnsigned:     1,
+	RiskDependencies: 2,
+}
+
+const (
+	riskChurnLines      = 5000 // Lines added and deleted at which churn reaches its maximum
+	riskHotspotCommits  = 3    // Unique commits changing a file that make it a hotspot
+	riskDependencyFiles = 3    // Changed dependency files at which the dependency signal reaches its maximum
+
+	riskMediumScore = 30 // Scores from here on are medium risk
+	riskHighScore   = 60 // Scores from here on are high risk
+)
+
+// dependencyFiles are the manifests and lock files whose changes indicate dependency bumps
+var dependencyFiles = map[string]struct{}{
+	"go.mod": {}, "go.sum": {},
+	"package.json": {}, "package-lock.json": {}, "yarn.lock": {}, "pnpm-lock.yaml": {},
+	"requirements.txt": {}, "Pipfile.lock": {}, "poetry.lock": {}, "pyproject.toml": {},
+	"Cargo.toml": {}, "Cargo.lock": {},
+	"Gemfile": {}, "Gemfile.lock": {},
+	"pom.xml": {}, "build.gradle": {}, "build.gradle.kts": {},
+	"composer.json": {}, "composer.lock": {},
+}
+
+// isDependencyFile reports whether a repository-relative path is a dependency manifest or lock file
+func isDependencyFile(filePath string) bool {
+	_, ok := dependencyFiles[path.Base(filePath)]
+	return ok
+}
+
+// FindDependencyChanges returns the dependency manifests and lock files changed between two tags, ordered by path
+func FindDependencyChanges(ctx context.Context, repo Repository, tag1 *plumbing.Reference, tag2 *plumbing.Reference, pathspecs []string) ([]string, error) {
+	names, err := repo.GetDiffBetweenTags(ctx, tag1, tag2, pathspecs, "--name-only", "-z", "--no-renames")
+	if err != nil {
+		return nil, err
+	}
+
+	var changed []string
+	for _, name := range strings.Split(names, "\x00") {
+		if name != "" && isDependencyFile(name) {
+			changed = append(changed, name)
+		}
+	}
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// breakingSubjectPattern matches Conventional Commits subjects marked as breaking, e.g. "feat(api)!: drop v1"
+var breakingSubjectPattern = regexp.MustCompile(`^[a-zA-Z]+(\([^)]*\))?!:`)
+
+// RiskWeights is the relative weight of each signal in the score; signals weighted 0 are ignored
+type RiskWeights map[RiskSignal]float64
+
+// ParseRiskWeights converts a comma-separated list of signal=weight pairs into RiskWeights.
+// Signals that are not listed keep their default weight; an empty value selects the defaults.
+func ParseRiskWeights(value string) (RiskWeights, error) {
+	weights := make(RiskWeights, len(defaultRiskWeights))
+	for signal, weight := range defaultRiskWeights {
+		weights[signal] = weight
+	}
+
+	for _, pair := range strings.Split(value, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, number, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, errors.Join(ErrInvalidRiskWeights, fmt.Errorf("expected signal=weight: %q", pair))
+		}
+		signal := RiskSignal(strings.TrimSpace(name))
+		if _, known := defaultRiskWeights[signal]; !known {
+			return nil, errors.Join(ErrInvalidRiskWeights, fmt.Errorf("unknown signal %q (valid: %s)", signal, riskSignalNames()))
+		}
+		weight, err := strconv.ParseFloat(strings.TrimSpace(number), 64)
+		if err != nil || weight < 0 {
+			return nil, errors.Join(ErrInvalidRiskWeights, fmt.Errorf("weight of %s must be a non-negative number: %q", signal, number))
+		}
+		weights[signal] = weight
+	}
+
+	return weights, nil
+}
+
+// riskSignalNames lists the signal names for error messages
+func riskSignalNames() string {
+	names := make([]string, len(riskSignals))
+	for i, signal := range riskSignals {
+		names[i] = string(signal)
+	}
+	return strings.Join(names, ", ")
+}
+
+// RiskFactor is the contribution of one signal to a risk score
+type RiskFactor struct {
+	Signal RiskSignal `json:"signal"`
+	Weight float64    `json:"weight"`
+	Value  float64    `json:"value"` // Normalized to 0-1
+	Detail string     `json:"detail"`
+}
+
+// RiskScore rates the change between two tags from 0 (low) to 100 (high risk)
+type RiskScore struct {
+	Score   float64      `json:"score"`
+	Level   string       `json:"level"` // low, medium, or high
+	Factors []RiskFactor `json:"factors"`
+}
+
+// riskInputs are the raw measurements the signals are normalized from
+type riskInputs struct {
+	lines           int      // Lines added and deleted between the tags
+	files           int      // Files changed between the tags
+	dependencies    []string // Changed dependency files
+	commits         int      // Commits unique to either tag
+	breaking        int      // Unique commits flagged as breaking changes
+	unsigned        int      // Unique commits without a signature
+	fileChanges     int      // File changes summed over the unique commits
+	hotspotChanges  int      // File changes on hotspot files
+	hotspotFiles    int      // Files changed by at least riskHotspotCommits unique commits
+	hotspotExamples []string // The most changed hotspot files
+}
+
+// ScoreRisk measures the risk signals of a comparison and combines them into a weighted score.
+// Churn and dependency changes are taken from the diff between the tags; the other signals
+// from the commits unique to either tag.
+func ScoreRisk(ctx context.Context, repo Repository, result CompareResult, weights RiskWeights) (RiskScore, error) {
+	if weights == nil {
+		weights = defaultRiskWeights
+	}
+
+	var inputs riskInputs
+	pathspecs := result.Config.pathspecs()
+	numstat, err := repo.GetDiffBetweenTags(ctx, result.Tag1Ref, result.Tag2Ref, pathspecs, "--numstat", "-z", "--no-renames")
+	if err != nil {
+		return RiskScore{}, errors.Join(ErrScoreRisk, err)
+	}
+	for _, entry := range parseNumstat(numstat) {
+		inputs.files++
+		inputs.lines += entry.Added + entry.Deleted
+		if isDependencyFile(entry.Path) {
+			inputs.dependencies = append(inputs.dependencies, entry.Path)
+		}
+	}
+	sort.Strings(inputs.dependencies)
+
+	unique := make(map[plumbing.Hash]struct{}, len(result.OnlyInTag1)+len(result.OnlyInTag2))
+	for hash := range result.OnlyInTag1 {
+		unique[hash] = struct{}{}
+	}
+	for hash := range result.OnlyInTag2 {
+		unique[hash] = struct{}{}
+	}
+	hashes := hashesOf(unique)
+	inputs.commits = len(hashes)
+
+	// Full commit objects carry the message body and signature, which the file stream omits
+	commits, err := repo.GetCommitObjects(hashes)
+	if err != nil {
+		return RiskScore{}, errors.Join(ErrScoreRisk, err)
+	}
+	for _, commit := range commits {
+		if isBreakingChange(commit.Message) {
+			inputs.breaking++
+		}
+		if commit.PGPSignature == "" {
+			inputs.unsigned++
+		}
+	}
+
+	filter := result.Config.directoryFilter()
+	fileCommits := make(map[string]int)
+	err = repo.StreamCommitFiles(ctx, hashes, func(commit *object.Commit, files []string) error {
+		for _, file := range files {
+			if filter.Match(file) {
+				fileCommits[file]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return RiskScore{}, errors.Join(ErrScoreRisk, err)
+	}
+	inputs.addHotspots(fileCommits)
+
+	return buildRiskScore(inputs, weights), nil
+}
+
+// addHotspots records the file changes landing on files changed by many unique commits
+func (in *riskInputs) addHotspots(fileCommits map[string]int) {
+	var hotspots []string
+	for file, count := range fileCommits {
+		in.fileChanges += count
+		if count >= riskHotspotCommits {
+			in.hotspotChanges += count
+			hotspots = append(hotspots, file)
+		}
+	}
+
+	sort.Slice(hotspots, func(i int, j int) bool {
+		if fileCommits[hotspots[i]] != fileCommits[hotspots[j]] {
+			return fileCommits[hotspots[i]] > fileCommits[hotspots[j]]
+		}
+		return hotspots[i] < hotspots[j]
+	})
+	in.hotspotFiles = len(hotspots)
+	in.hotspotExamples = hotspots[:min(len(hotspots), 3)]
+}
+
+// isBreakingChange reports whether a commit message marks a breaking change,
+// either with a Conventional Commits "!" or a BREAKING CHANGE footer
+func isBreakingChange(message string) bool {
+	subject, _, _ := strings.Cut(message, "\n")
+	if breakingSubjectPattern.MatchString(subject) {
+		return true
+	}
+	for _, line := range strings.Split(message, "\n") {
+		if strings.HasPrefix(line, "BREAKING CHANGE:") || strings.HasPrefix(line, "BREAKING-CHANGE:") {
+			return true
+		}
+	}
+	return false
+}
+
+// buildRiskScore normalizes the measurements into signal values and combines them with the weights
+func buildRiskScore(in riskInputs, weights RiskWeights) RiskScore {
+	var score RiskScore
+	var weighted, total float64
+
+	for _, signal := range riskSignals {
+		factor := RiskFactor{Signal: signal, Weight: weights[signal]}
+		switch signal {
+		case RiskChurn:
+			factor.Value = min(1, float64(in.lines)/riskChurnLines)
+			factor.Detail = fmt.Sprintf("%d lines changed in %d files", in.lines, in.files)
+		case RiskHotspots:
+			if in.fileChanges > 0 {
+				factor.Value = float64(in.hotspotChanges) / float64(in.fileChanges)
+			}
+			factor.Detail = fmt.Sprintf("%d files changed by %d+ unique commits", in.hotspotFiles, riskHotspotCommits)
+			if len(in.hotspotExamples) > 0 {
+				factor.Detail += " (" + strings.Join(in.hotspotExamples, ", ") + ")"
+			}
+		case RiskBreaking:
+			if in.breaking > 0 {
+				factor.Value = 1
+			}
+			factor.Detail = fmt.Sprintf("%d of %d unique commits marked as breaking", in.breaking, in.commits)
+		case RiskUnsigned:
+			if in.commits > 0 {
+				factor.Value = float64(in.unsigned) / float64(in.commits)
+			}
+			factor.Detail = fmt.Sprintf("%d of %d unique commits unsigned", in.unsigned, in.commits)
+		case RiskDependencies:
+			factor.Value = min(1, float64(len(in.dependencies))/riskDependencyFiles)
+			factor.Detail = fmt.Sprintf("%d dependency files changed", len(in.dependencies))
+			if len(in.dependencies) > 0 {
+				factor.Detail += " (" + strings.Join(in.dependencies, ", ") + ")"
+			}
+		}
+
+		weighted += factor.Weight * factor.Value
+		total += factor.Weight
+		score.Factors = append(score.Factors, factor)
+	}
+
+	if total > 0 {
+		score.Score = 100 * weighted / total
+	}
+	score.Level = riskLevel(score.Score)
+	return score
+}
+
+// riskLevel names the band a score falls into
+func riskLevel(score float64) string {
+	switch {
+	case score >= riskHighScore:
+		return "high"
+	case score >= riskMediumScore:
+		return "medium"
+	default:
+		return "low"
+	}
+}
+
+// printRiskScore prints the risk score and the contribution of each signal
+func printRiskScore(result CompareResult) {
+	if result.Risk == nil {
+		return
+	}
+
+	fmt.Printf("\nRisk: %.0f/100 (%s)\n", result.Risk.Score, result.Risk.Level)
+	rows := [][]string{{"  SIGNAL", "WEIGHT", "VALUE", "DETAIL"}}
+	for _, factor := range result.Risk.Factors {
+		rows = append(rows, []string{
+			"  " + string(factor.Signal),
+			strconv.FormatFloat(factor.Weight, 'g', -1, 64),
+			fmt.Sprintf("%.2f", factor.Value),
+			factor.Detail,
+		})
+	}
+	writeTable(os.Stdout, result.Config.Output, 3, rows)
+}
@@ -0,0 +1,177 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestParseRiskWeights tests overriding the default weights of the risk signals
+func TestParseRiskWeights(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    RiskWeights
+		wantErr bool
+	}{
+		{name: "Empty selects defaults", value: "", want: defaultRiskWeights},
+		{
+			name:  "Overrides keep other defaults",
+			value: "unsigned=0, churn=5",
+			want:  RiskWeights{RiskChurn: 5, RiskHotspots: 2, RiskBreaking: 3, RiskUnsigned: 0, RiskDependencies: 2},
+		},
+		{name: "Unknown signal", value: "coverage=1", wantErr: true},
+		{name: "Missing weight", value: "churn", wantErr: true},
+		{name: "Negative weight", value: "churn=-1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseRiskWeights(tt.value)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidRiskWeights) {
+					t.Errorf("ParseRiskWeights(%q) error = %v, want ErrInvalidRiskWeights", tt.value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseRiskWeights(%q) error = %v", tt.value, err)
+			}
+			for _, signal := range riskSignals {
+				if got[signal] != tt.want[signal] {
+					t.Errorf("weight of %s = %v, want %v", signal, got[signal], tt.want[signal])
+				}
+			}
+		})
+	}
+}
+
+// TestIsBreakingChange tests the Conventional Commits markers of breaking changes
+func TestIsBreakingChange(t *testing.T) {
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		{message: "feat!: drop the v1 API", want: true},
+		{message: "fix(auth)!: reject empty tokens", want: true},
+		{message: "feat: add endpoint\n\nBREAKING CHANGE: removes /v1", want: true},
+		{message: "refactor: rename\n\nBREAKING-CHANGE: config keys renamed", want: true},
+		{message: "feat: add endpoint", want: false},
+		{message: "Fix breaking change in parser", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isBreakingChange(tt.message); got != tt.want {
+			t.Errorf("isBreakingChange(%q) = %v, want %v", tt.message, got, tt.want)
+		}
+	}
+}
+
+// TestBuildRiskScore tests how the measurements are normalized and combined with the weights
+func TestBuildRiskScore(t *testing.T) {
+	inputs := riskInputs{
+		lines:          2500, // 0.5
+		files:          10,
+		dependencies:   []string{"go.mod", "go.sum", "web/package.json"}, // 1
+		commits:        4,
+		breaking:       1, // 1
+		unsigned:       2, // 0.5
+		fileChanges:    8,
+		hotspotChanges: 4, // 0.5
+	}
+
+	tests := []struct {
+		name      string
+		weights   RiskWeights
+		wantScore float64
+		wantLevel string
+	}{
+		{name: "Default weights", weights: defaultRiskWeights, wantScore: 100 * (3*0.5 + 2*0.5 + 3*1 + 1*0.5 + 2*1) / 11, wantLevel: "high"},
+		{name: "Only churn", weights: RiskWeights{RiskChurn: 1}, wantScore: 50, wantLevel: "medium"},
+		{name: "Only hotspots and unsigned", weights: RiskWeights{RiskHotspots: 1, RiskUnsigned: 3}, wantScore: 50, wantLevel: "medium"},
+		{name: "All weights zero", weights: RiskWeights{}, wantScore: 0, wantLevel: "low"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score := buildRiskScore(inputs, tt.weights)
+			if math.Abs(score.Score-tt.wantScore) > 1e-9 || score.Level != tt.wantLevel {
+				t.Errorf("buildRiskScore() = %.2f (%s), want %.2f (%s)", score.Score, score.Level, tt.wantScore, tt.wantLevel)
+			}
+			if len(score.Factors) != len(riskSignals) {
+				t.Errorf("buildRiskScore() returned %d factors, want %d", len(score.Factors), len(riskSignals))
+			}
+		})
+	}
+}
+
+// TestScoreRisk tests that the signals are measured from the diff and the unique commits
+func TestScoreRisk(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+	hash3 := plumbing.NewHash("0000000000000000000000000000000000000003")
+	commits := map[plumbing.Hash]*object.Commit{
+		hash1: {Hash: hash1, Message: "feat!: new config format\n", PGPSignature: "-----BEGIN PGP SIGNATURE-----"},
+		hash2: {Hash: hash2, Message: "fix: handle nil\n"},
+		hash3: {Hash: hash3, Message: "chore: bump deps\n"},
+	}
+	files := map[plumbing.Hash][]string{
+		hash1: {"src/config.go", "docs/config.md"},
+		hash2: {"src/config.go"},
+		hash3: {"src/config.go", "go.mod", "go.sum"},
+	}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetDiffBetweenTags(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), "--numstat", "-z", "--no-renames").
+		Return("10\t5\tsrc/config.go\x002\t1\tgo.mod\x0040\t0\tgo.sum\x00-\t-\tlogo.png\x00", nil)
+	mockRepo.EXPECT().GetCommitObjects(gomock.Any()).DoAndReturn(func(hashes []plumbing.Hash) ([]*object.Commit, error) {
+		result := make([]*object.Commit, len(hashes))
+		for i, hash := range hashes {
+			result[i] = commits[hash]
+		}
+		return result, nil
+	})
+	mockRepo.EXPECT().StreamCommitFiles(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, hashes []plumbing.Hash, visit func(commit *object.Commit, files []string) error) error {
+			for _, hash := range hashes {
+				if err := visit(commits[hash], files[hash]); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+
+	result := CompareResult{
+		OnlyInTag1: map[plumbing.Hash]struct{}{hash1: {}},
+		OnlyInTag2: map[plumbing.Hash]struct{}{hash2: {}, hash3: {}},
+	}
+	score, err := ScoreRisk(context.Background(), mockRepo, result, nil)
+	if err != nil {
+		t.Fatalf("ScoreRisk() error = %v", err)
+	}
+
+	want := map[RiskSignal]string{
+		RiskChurn:        "58 lines changed in 4 files",
+		RiskHotspots:     "1 files changed by 3+ unique commits (src/config.go)",
+		RiskBreaking:     "1 of 3 unique commits marked as breaking",
+		RiskUnsigned:     "2 of 3 unique commits unsigned",
+		RiskDependencies: "2 dependency files changed (go.mod, go.sum)",
+	}
+	for _, factor := range score.Factors {
+		if factor.Detail != want[factor.Signal] {
+			t.Errorf("%s detail = %q, want %q", factor.Signal, factor.Detail, want[factor.Signal])
+		}
+	}
+	if score.Factors[1].Value != 0.5 {
+		t.Errorf("hotspot value = %v, want 0.5 (3 of 6 file changes)", score.Factors[1].Value)
+	}
+}
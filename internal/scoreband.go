@@ -0,0 +1,56 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+)
+
+var ErrInvalidScoreBand = errors.New("invalid score band")
+
+// ScoreBand labels the similarities from Min up to the next higher band, so readers get the same
+// framing of a score in every report, e.g. "routine release delta" for 60% to 95%
+type ScoreBand struct {
+	Min   float64 `yaml:"min"`   // Lowest similarity of the band, between 0 and 1
+	Label string  `yaml:"label"` // Interpretation printed for similarities in the band
+}
+
+// defaultScoreBands interpret the similarity when the project config defines no bands
+var defaultScoreBands = []ScoreBand{
+	{Min: 0.95, Label: "effectively identical"},
+	{Min: 0.6, Label: "routine release delta"},
+	{Min: 0, Label: "major divergence"},
+}
+
+// validateScoreBands checks that every band has a label and a distinct threshold between 0 and 1
+func validateScoreBands(bands []ScoreBand) error {
+	seen := make(map[float64]bool)
+	for _, band := range bands {
+		if band.Label == "" {
+			return errors.Join(ErrInvalidScoreBand, fmt.Errorf("band at %v has no label", band.Min))
+		}
+		if band.Min < 0 || band.Min > 1 {
+			return errors.Join(ErrInvalidScoreBand, fmt.Errorf("%s: min %v is not between 0 and 1", band.Label, band.Min))
+		}
+		if seen[band.Min] {
+			return errors.Join(ErrInvalidScoreBand, fmt.Errorf("%s: another band also starts at %v", band.Label, band.Min))
+		}
+		seen[band.Min] = true
+	}
+	return nil
+}
+
+// InterpretSimilarity returns the label of the band a similarity falls in: the band with the highest
+// Min not above it. Bands need not be sorted; a similarity below every band has no interpretation.
+func InterpretSimilarity(bands []ScoreBand, similarity float64) string {
+	sorted := append([]ScoreBand(nil), bands...)
+	sort.Slice(sorted, func(i int, j int) bool {
+		return sorted[i].Min > sorted[j].Min
+	})
+	for _, band := range sorted {
+		if similarity >= band.Min {
+			return band.Label
+		}
+	}
+	return ""
+}
@@ -0,0 +1,94 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestInterpretSimilarity tests picking the band of a similarity
+func TestInterpretSimilarity(t *testing.T) {
+	custom := []ScoreBand{
+		{Min: 0.5, Label: "related"},
+		{Min: 0.9, Label: "same release line"},
+		{Min: 0.2, Label: "distant"},
+	}
+
+	tests := []struct {
+		name       string
+		bands      []ScoreBand
+		similarity float64
+		want       string
+	}{
+		{name: "Default identical", bands: defaultScoreBands, similarity: 1, want: "effectively identical"},
+		{name: "Default lower bound", bands: defaultScoreBands, similarity: 0.95, want: "effectively identical"},
+		{name: "Default routine", bands: defaultScoreBands, similarity: 0.72, want: "routine release delta"},
+		{name: "Default divergence", bands: defaultScoreBands, similarity: 0, want: "major divergence"},
+		{name: "Unsorted bands", bands: custom, similarity: 0.6, want: "related"},
+		{name: "Below every band", bands: custom, similarity: 0.1, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InterpretSimilarity(tt.bands, tt.similarity); got != tt.want {
+				t.Errorf("InterpretSimilarity(%v) = %q, want %q", tt.similarity, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCompare_Interpretation tests that the bands of the project config label the similarity in results and reports
+func TestCompare_Interpretation(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("first", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Commit("second", testutil.File("b.go", "package b\n")).
+		Tag("v1.1.0")
+
+	configPath := filepath.Join(t.TempDir(), "config.yaml")
+	bands := "bands:\n  - min: 0.4\n    label: same family\n  - min: 0\n    label: unrelated\n"
+	if err := os.WriteFile(configPath, []byte(bands), 0o644); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		configPath string
+		want       string
+	}{
+		{name: "Default bands", want: "major divergence"},
+		{name: "Configured bands", configPath: configPath, want: "same family"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+				ConfigPath: tt.configPath,
+			})
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if result.Interpretation != tt.want {
+				t.Errorf("Interpretation = %q, want %q", result.Interpretation, tt.want)
+			}
+
+			saved, err := NewSavedResult(context.Background(), result)
+			if err != nil {
+				t.Fatalf("NewSavedResult() error = %v", err)
+			}
+			var buf bytes.Buffer
+			if err := WriteMarkdownReport(&buf, saved, ReportTemplateOptions{}); err != nil {
+				t.Fatalf("WriteMarkdownReport() error = %v", err)
+			}
+			if want := "| Interpretation | " + tt.want + " |"; !strings.Contains(buf.String(), want) {
+				t.Errorf("WriteMarkdownReport() output missing %q\n%s", want, buf.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,347 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	formatcfg "github.com/go-git/go-git/v5/plumbing/format/config"
+)
+
+var ErrSelftestFailed = errors.New("selftest failed")
+
+// SelftestConfig holds the configuration of the selftest command
+type SelftestConfig struct {
+	Command Command
+	Keep    bool // Keep the fixture repository instead of removing it, to inspect a failure
+	Output  OutputOptions
+	Timeout time.Duration // Cancel the self-test after this long; 0 never times out
+}
+
+// selftestUsage is the help of the selftest command
+var selftestUsage = commandUsage{
+	Name:        "selftest",
+	Summary:     "Validate the installation against a built-in fixture repository",
+	Description: "Build a small repository with known similarities in a temporary directory using the git binary,\nrun compare, the filters, patch ID matching, the tree and line metrics, and report rendering on\nit, and check every score. A failure points at the git binary, the file permissions, or this build,\nnot at your repositories.",
+	Examples: []string{
+		"selftest",
+		"selftest -keep",
+	},
+}
+
+// NewSelftestConfig parses the selftest command flags
+func NewSelftestConfig(args []string) (SelftestConfig, error) {
+	config := SelftestConfig{Command: SelftestCommand}
+	selftestCmd := newCommandFlagSet(selftestUsage)
+	selftestCmd.BoolVar(&config.Keep, "keep", false, "Keep the fixture repository and print its path")
+	parseOutputOptions := config.Output.registerFlags(selftestCmd, "Maximum line width of the results table")
+	registerTimeoutFlag(selftestCmd, &config.Timeout, "the self-test")
+
+	if err := selftestCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	if err := validateTimeout(config.Timeout); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// selftestFixture describes the history built by buildSelftestRepository:
+//
+//	main:        Initial ── Add b endpoint ── Fix crash ── Add guide
+//	             (v1.0.0, lightweight)                      (v1.1.0, annotated)
+//	release/1.0: Initial ── Fix crash, cherry-picked
+//	                        (v1.0.1, annotated)
+//
+// Everything but the guide lives in src/api.
+var selftestFixture = []struct {
+	args    []string
+	message string // Commit message; the args are then files to write as path=content pairs
+}{
+	{message: "Initial commit", args: []string{"README.md=# Fixture\n", "src/api/a.go=package api\n\nfunc A() int { return 1 }\n"}},
+	{args: []string{"tag", "v1.0.0"}},
+	{args: []string{"branch", "release/1.0"}},
+	{message: "Add b endpoint", args: []string{"src/api/b.go=package api\n\nfunc B() int { return 2 }\n"}},
+	{message: "Fix crash in A", args: []string{"src/api/a.go=package api\n\nfunc A() int { return 0 }\n"}},
+	{message: "Add guide", args: []string{"docs/guide.md=# Guide\n\nCall A, then B.\n"}},
+	{args: []string{"tag", "-a", "v1.1.0", "-m", "Release 1.1.0"}},
+	{args: []string{"checkout", "--quiet", "release/1.0"}},
+	{args: []string{"cherry-pick", "main~1"}},
+	{args: []string{"tag", "-a", "v1.0.1", "-m", "Release 1.0.1"}},
+}
+
+// selftestCase is a comparison of the fixture with its expected score
+type selftestCase struct {
+	name   string
+	config CompareConfig
+	score  func(CompareResult) float64
+	want   float64
+}
+
+// selftestCases returns the comparisons run on the fixture at path. The expected scores follow from
+// selftestFixture: v1.0.0 has 1 commit, v1.1.0 has 4, and v1.0.1 has 2, one of them a cherry-pick.
+func selftestCases(path string) []selftestCase {
+	tags := func(tag1 string, tag2 string) TagOptions {
+		return TagOptions{RepoPath: path, Tag1Name: tag1, Tag2Name: tag2}
+	}
+	similarity := func(result CompareResult) float64 { return result.Similarity }
+
+	return []selftestCase{
+		{
+			// The full pipeline, with tag metadata and the diff stat
+			name:   "compare lightweight and annotated tags",
+			config: CompareConfig{TagOptions: tags("v1.0.0", "v1.1.0")},
+			score:  similarity,
+			want:   1.0 / 4,
+		},
+		{
+			name:   "directory filter",
+			config: CompareConfig{TagOptions: tags("v1.0.0", "v1.1.0"), Directory: "src/api", SetsOnly: true},
+			score:  similarity,
+			want:   1.0 / 3,
+		},
+		{
+			name:   "cherry-pick by hash",
+			config: CompareConfig{TagOptions: tags("v1.0.1", "v1.1.0"), SetsOnly: true},
+			score:  similarity,
+			want:   1.0 / 5,
+		},
+		{
+			name:   "cherry-pick by patch ID",
+			config: CompareConfig{TagOptions: tags("v1.0.1", "v1.1.0"), Match: MatchPatchID, SetsOnly: true},
+			score:  similarity,
+			want:   2.0 / 4,
+		},
+		{
+			// README.md is the only file with the same content in both trees
+			name:   "file metric",
+			config: CompareConfig{TagOptions: tags("v1.0.0", "v1.1.0"), Metric: MetricFiles, SetsOnly: true},
+			score:  func(result CompareResult) float64 { return derefSimilarity(result.TreeSimilarity) },
+			want:   1.0 / 5,
+		},
+		{
+			// 3 unchanged lines of 4; the fix replaces one line, the new files add 6
+			name:   "line metric",
+			config: CompareConfig{TagOptions: tags("v1.0.0", "v1.1.0"), Metric: MetricLines, SetsOnly: true},
+			score:  func(result CompareResult) float64 { return derefSimilarity(result.LineSimilarity) },
+			want:   3.0 / 11,
+		},
+	}
+}
+
+// derefSimilarity returns an optional similarity, or -1 when it was not computed
+func derefSimilarity(similarity *float64) float64 {
+	if similarity == nil {
+		return -1
+	}
+	return *similarity
+}
+
+// RunSelftest builds the fixture repository, compares its tags, and checks every score.
+// It returns the results and the path of the fixture, which is empty unless config.Keep is set.
+// ErrSelftestFailed is returned when at least one check failed.
+func RunSelftest(ctx context.Context, config SelftestConfig) (_ []CheckResult, _ string, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	results := []CheckResult{checkGitBinary(ctx)}
+	if results[0].Status != CheckOK {
+		results[0].Status = CheckFail
+		return results, "", ErrSelftestFailed
+	}
+
+	path, err := os.MkdirTemp("", "git-tag-similarity-selftest-")
+	if err != nil {
+		results = append(results, CheckResult{
+			Name:   "fixture repository",
+			Status: CheckFail,
+			Detail: err.Error(),
+			Hint:   "make sure the temporary directory ($TMPDIR) exists and is writable",
+		})
+		return results, "", ErrSelftestFailed
+	}
+	if !config.Keep {
+		defer func() { _ = os.RemoveAll(path) }()
+	}
+
+	if err := buildSelftestRepository(ctx, path); err != nil {
+		results = append(results, CheckResult{
+			Name:   "fixture repository",
+			Status: CheckFail,
+			Detail: err.Error(),
+			Hint:   "the git binary could not build a repository; check 'git --version' and the temporary directory permissions",
+		})
+		return results, keptPath(config, path), ErrSelftestFailed
+	}
+	results = append(results, CheckResult{Name: "fixture repository", Status: CheckOK, Detail: "3 tags, 5 commits, 1 cherry-pick"})
+
+	failed := false
+	var full CompareResult
+	for i, tc := range selftestCases(path) {
+		result, compared := runSelftestCase(ctx, tc)
+		failed = failed || result.Status == CheckFail
+		results = append(results, result)
+		if i == 0 {
+			full = compared
+		}
+	}
+	if !failed {
+		report := checkSelftestReport(ctx, full, filepath.Join(path, ".git", "selftest.json"))
+		failed = report.Status == CheckFail
+		results = append(results, report)
+	}
+	results = append(results, checkCacheDirectory())
+
+	if failed {
+		return results, keptPath(config, path), ErrSelftestFailed
+	}
+	return results, keptPath(config, path), nil
+}
+
+// keptPath returns the fixture path if it is kept
+func keptPath(config SelftestConfig, path string) string {
+	if config.Keep {
+		return path
+	}
+	return ""
+}
+
+// buildSelftestRepository creates the selftestFixture history in dir with the git binary. The user's
+// signing, hook, and identity settings are overridden so they cannot change or block the history.
+func buildSelftestRepository(ctx context.Context, dir string) error {
+	// Command: git init --quiet [--object-format=sha256]
+	args := []string{"init", "--quiet"}
+	if format := BuildObjectFormat(); format != formatcfg.SHA1 {
+		args = append(args, "--object-format="+string(format))
+	}
+	if err := runGitContext(ctx, dir, args...); err != nil {
+		return fmt.Errorf("git init: %w", err)
+	}
+
+	settings := []string{
+		"-c", "user.name=Selftest", "-c", "user.email=selftest@example.com",
+		"-c", "commit.gpgSign=false", "-c", "tag.gpgSign=false",
+		"-c", "core.hooksPath=" + filepath.Join(dir, ".git", "no-hooks"),
+		"-c", "core.autocrlf=false",
+	}
+	// Command: git symbolic-ref HEAD refs/heads/main, whatever init.defaultBranch says
+	if err := runGitContext(ctx, dir, "symbolic-ref", "HEAD", "refs/heads/main"); err != nil {
+		return fmt.Errorf("git symbolic-ref: %w", err)
+	}
+
+	for _, step := range selftestFixture {
+		if step.message == "" {
+			if err := runGitContext(ctx, dir, append(settings, step.args...)...); err != nil {
+				return fmt.Errorf("git %s: %w", strings.Join(step.args, " "), err)
+			}
+			continue
+		}
+
+		for _, file := range step.args {
+			name, content, _ := strings.Cut(file, "=")
+			target := filepath.Join(dir, filepath.FromSlash(name))
+			if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(target, []byte(content), 0o644); err != nil {
+				return err
+			}
+		}
+		// Command: git add --all && git commit --quiet -m <message>
+		if err := runGitContext(ctx, dir, append(settings, "add", "--all")...); err != nil {
+			return fmt.Errorf("git add: %w", err)
+		}
+		if err := runGitContext(ctx, dir, append(settings, "commit", "--quiet", "-m", step.message)...); err != nil {
+			return fmt.Errorf("git commit: %w", err)
+		}
+	}
+	return nil
+}
+
+// runSelftestCase compares the fixture and checks the score against the expected value
+func runSelftestCase(ctx context.Context, tc selftestCase) (CheckResult, CompareResult) {
+	result := CheckResult{Name: tc.name}
+
+	// The fixture is thrown away, so its commit sets are not cached
+	tc.config.NoCache = true
+	compared, err := CompareContext(ctx, tc.config)
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = err.Error()
+		result.Hint = "run 'check' on one of your repositories to see whether the git binary and object store are usable"
+		return result, compared
+	}
+
+	got := tc.score(compared)
+	if math.Abs(got-tc.want) > 1e-9 {
+		result.Status = CheckFail
+		result.Detail = fmt.Sprintf("similarity %.2f%%, want %.2f%%", got*100, tc.want*100)
+		result.Hint = "results from this build are not trustworthy; report the issue with the output of 'git --version' and 'version'"
+		return result, compared
+	}
+
+	result.Status = CheckOK
+	result.Detail = fmt.Sprintf("similarity %.2f%%", got*100)
+	return result, compared
+}
+
+// checkSelftestReport saves a comparison as compare -json does, loads it back, and renders it as a markdown report
+func checkSelftestReport(ctx context.Context, compared CompareResult, path string) CheckResult {
+	result := CheckResult{Name: "saved result and report"}
+
+	err := SaveResult(ctx, compared, path)
+	var saved SavedResult
+	if err == nil {
+		saved, err = LoadSavedResult(path)
+	}
+	if err == nil {
+		err = WriteMarkdownReport(io.Discard, saved, ReportTemplateOptions{Style: ReportStyleEngineering, Language: defaultReportLanguage})
+	}
+	if err != nil {
+		result.Status = CheckFail
+		result.Detail = err.Error()
+		result.Hint = "the saved result or the built-in report templates are broken in this build"
+		return result
+	}
+
+	result.Status = CheckOK
+	result.Detail = "saved, loaded, and rendered as markdown"
+	return result
+}
+
+// checkCacheDirectory warns when commit sets cannot be cached, which only makes runs slower
+func checkCacheDirectory() CheckResult {
+	result := CheckResult{Name: "commit set cache"}
+
+	root, err := commitSetCacheRoot()
+	if err == nil {
+		err = os.MkdirAll(root, 0o755)
+	}
+	var file *os.File
+	if err == nil {
+		file, err = os.CreateTemp(root, "selftest-*")
+	}
+	if err != nil {
+		result.Status = CheckWarn
+		result.Detail = fmt.Sprintf("cache directory is not writable: %v", err)
+		result.Hint = "set " + CacheDirEnv + " to a writable directory; without it every run walks the full history"
+		return result
+	}
+	_ = file.Close()
+	_ = os.Remove(file.Name())
+
+	result.Status = CheckOK
+	result.Detail = root
+	return result
+}
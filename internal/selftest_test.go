@@ -0,0 +1,46 @@
+package internal
+
+import (
+	"context"
+	"os"
+	"testing"
+)
+
+// TestRunSelftest tests that every selftest check passes on this build, and that -keep keeps the fixture
+func TestRunSelftest(t *testing.T) {
+	results, fixture, err := RunSelftest(context.Background(), SelftestConfig{Keep: true})
+	if fixture != "" {
+		t.Cleanup(func() { _ = os.RemoveAll(fixture) })
+	}
+	if err != nil {
+		t.Fatalf("RunSelftest() error = %v, results = %+v", err, results)
+	}
+	for _, result := range results {
+		if result.Status == CheckFail {
+			t.Errorf("%s failed: %s", result.Name, result.Detail)
+		}
+	}
+	if len(results) != 10 {
+		t.Errorf("RunSelftest() = %d results, want 10", len(results))
+	}
+
+	if _, err := os.Stat(fixture); err != nil {
+		t.Errorf("fixture was not kept: %v", err)
+	}
+}
+
+// TestSelftestCase_Mismatch tests that a score other than the expected one fails the check
+func TestSelftestCase_Mismatch(t *testing.T) {
+	fixture := newReleaseFixture(t)
+	tc := selftestCase{
+		name:   "wrong expectation",
+		config: CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}, SetsOnly: true},
+		score:  func(result CompareResult) float64 { return result.Similarity },
+		want:   0.5,
+	}
+
+	result, _ := runSelftestCase(context.Background(), tc)
+	if result.Status != CheckFail || result.Detail != "similarity 33.33%, want 50.00%" {
+		t.Errorf("runSelftestCase() = %+v, want a failure reporting 33.33%%", result)
+	}
+}
@@ -1,16 +1,21 @@
 package internal
 
-import "github.com/go-git/go-git/v5/plumbing"
+import (
+	"iter"
 
-// CalculateJaccardSimilarity computes the Jaccard similarity coefficient between two commit sets
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// CalculateJaccardSimilarity computes the Jaccard similarity coefficient between two sets,
+// such as commit sets or the subject shingles of -metric message
 // Returns a value between 0.0 and 1.0, where 1.0 means identical sets
-func CalculateJaccardSimilarity(setA map[plumbing.Hash]struct{}, setB map[plumbing.Hash]struct{}) float64 {
+func CalculateJaccardSimilarity[K comparable](setA map[K]struct{}, setB map[K]struct{}) float64 {
 	if len(setA) == 0 && len(setB) == 0 {
 		return 1.0 // Both empty sets are considered identical
 	}
 
 	// Calculate union
-	union := make(map[plumbing.Hash]struct{})
+	union := make(map[K]struct{})
 	for hash := range setA {
 		union[hash] = struct{}{}
 	}
@@ -23,7 +28,7 @@ func CalculateJaccardSimilarity(setA map[plumbing.Hash]struct{}, setB map[plumbi
 	}
 
 	// Calculate intersection
-	intersection := make(map[plumbing.Hash]struct{})
+	intersection := make(map[K]struct{})
 	for hash := range setA {
 		if _, ok := setB[hash]; ok {
 			intersection[hash] = struct{}{}
@@ -32,3 +37,21 @@ func CalculateJaccardSimilarity(setA map[plumbing.Hash]struct{}, setB map[plumbi
 
 	return float64(len(intersection)) / float64(len(union))
 }
+
+// CalculateSortedJaccardSimilarity is CalculateJaccardSimilarity for two ascending sequences of distinct
+// hashes, such as those of SortedCommitHashes. It merges the sequences instead of building a union,
+// so it needs no memory beyond the inputs.
+func CalculateSortedJaccardSimilarity(seqA iter.Seq[plumbing.Hash], seqB iter.Seq[plumbing.Hash]) float64 {
+	union, intersection := 0, 0
+	for _, count := range MergeSortedHashes(seqA, seqB) {
+		union++
+		if count == 2 {
+			intersection++
+		}
+	}
+
+	if union == 0 {
+		return 1.0 // Both empty sets are considered identical
+	}
+	return float64(intersection) / float64(union)
+}
@@ -2,6 +2,7 @@ package internal
 
 import (
 	"math"
+	"slices"
 	"testing"
 
 	"github.com/go-git/go-git/v5/plumbing"
@@ -134,6 +135,39 @@ func TestCalculateJaccardSimilaritySymmetry(t *testing.T) {
 	}
 }
 
+// TestCalculateSortedJaccardSimilarity tests that merging sorted hashes agrees with the set-based calculation
+func TestCalculateSortedJaccardSimilarity(t *testing.T) {
+	tests := []struct {
+		name string
+		a    []string
+		b    []string
+	}{
+		{name: "Both empty", a: nil, b: nil},
+		{name: "One empty", a: []string{"commit1"}, b: nil},
+		{name: "Identical", a: []string{"commit1", "commit2"}, b: []string{"commit1", "commit2"}},
+		{name: "Partial overlap", a: []string{"commit1", "commit2", "commit3"}, b: []string{"commit2", "commit3", "commit4", "commit5"}},
+		{name: "Disjoint", a: []string{"commit1"}, b: []string{"commit2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setA, setB := map[plumbing.Hash]struct{}{}, map[plumbing.Hash]struct{}{}
+			for _, s := range tt.a {
+				setA[hashFromString(s)] = struct{}{}
+			}
+			for _, s := range tt.b {
+				setB[hashFromString(s)] = struct{}{}
+			}
+
+			want := CalculateJaccardSimilarity(setA, setB)
+			got := CalculateSortedJaccardSimilarity(slices.Values(sortedHashes(setA)), slices.Values(sortedHashes(setB)))
+			if math.Abs(got-want) > 0.0001 {
+				t.Errorf("CalculateSortedJaccardSimilarity() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
 // Helper function to create a commit hash from a string
 func hashFromString(s string) plumbing.Hash {
 	var h plumbing.Hash
@@ -0,0 +1,339 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrMissingSnapshot  = errors.New("snapshot directory is required")
+	ErrReadSnapshot     = errors.New("failed to read snapshot")
+	ErrSnapshotMismatch = errors.New("snapshot does not match the tag")
+)
+
+// SnapshotConfig holds the configuration of the snapshot command
+type SnapshotConfig struct {
+	Command      Command
+	RepoPath     string
+	TagName      string   // Tag the snapshot should correspond to (or latest, latest-N)
+	SnapshotPath string   // Directory corresponding to the repository root, e.g. an extracted release tarball
+	Exclude      []string // Globs of paths ignored on both sides; a trailing / excludes a directory
+	NoIgnore     bool     // Compare all files, disregarding .gitignore files, exclude files, and IgnoreFile
+	Output       OutputOptions
+	Timeout      time.Duration // Cancel the snapshot comparison after this long; 0 never times out
+}
+
+// snapshotUsage is the help of the snapshot command
+var snapshotUsage = commandUsage{
+	Name:        "snapshot",
+	Summary:     "Compare a tag with a directory, e.g. an extracted tarball",
+	Description: "Compare the tree of a tag with a plain directory, e.g. an extracted release tarball,\nto verify that a published artifact corresponds to the tag. Files are compared by content.\nFiles matching the .gitignore files of the tag, the repository's exclude files, or a\n" + IgnoreFile + " file in the tag are left out on both sides unless -no-ignore is set.",
+	Examples: []string{
+		"snapshot -repo /path/to/repo -tag v1.0.0 -path ./project-1.0.0",
+		"snapshot -repo /path/to/repo -tag latest -path ./dist -exclude 'configure,*.pyc'",
+		"snapshot -repo /path/to/repo -tag v1.0.0 -path ./project-1.0.0 -no-ignore",
+	},
+}
+
+// NewSnapshotConfig parses the snapshot command flags
+func NewSnapshotConfig(args []string) (SnapshotConfig, error) {
+	config := SnapshotConfig{Command: SnapshotCommand}
+	var exclude string
+
+	snapshotCmd := newCommandFlagSet(snapshotUsage)
+	snapshotCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	snapshotCmd.StringVar(&config.TagName, "tag", "", "Tag the snapshot should correspond to (or latest, latest-N)")
+	snapshotCmd.StringVar(&config.SnapshotPath, "path", "", "Directory to compare with the tree of the tag, e.g. an extracted release tarball")
+	snapshotCmd.StringVar(&exclude, "exclude", "", "Comma-separated globs of paths to ignore, e.g. 'configure,*.pyc,dist/'; a trailing / excludes a directory")
+	snapshotCmd.BoolVar(&config.NoIgnore, "no-ignore", false, "Compare all files, disregarding .gitignore files, exclude files, and "+IgnoreFile)
+	parseOutputOptions := config.Output.registerFlags(snapshotCmd, "Maximum line width of the listed files")
+	registerTimeoutFlag(snapshotCmd, &config.Timeout, "the snapshot comparison")
+
+	if err := snapshotCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	for _, pattern := range strings.Split(exclude, ",") {
+		if pattern = strings.TrimSpace(pattern); pattern != "" {
+			config.Exclude = append(config.Exclude, pattern)
+		}
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *SnapshotConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+	if c.TagName == "" {
+		return ErrMissingTag1
+	}
+	if c.SnapshotPath == "" {
+		return ErrMissingSnapshot
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+	if info, err := os.Stat(c.SnapshotPath); err != nil || !info.IsDir() {
+		return errors.Join(ErrReadSnapshot, fmt.Errorf("not a directory: %s", c.SnapshotPath))
+	}
+	for _, pattern := range c.Exclude {
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return errors.Join(ErrInvalidConfiguration, fmt.Errorf("invalid exclude pattern %q: %w", pattern, err))
+		}
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// SnapshotComparison is the file-level comparison of a tag's tree with a directory
+type SnapshotComparison struct {
+	Tag            string
+	Commit         plumbing.Hash
+	Path           string
+	Identical      int
+	Modified       []string // Files with different content, sorted by path
+	OnlyInTag      []string // Files of the tag missing from the snapshot
+	OnlyInSnapshot []string // Files of the snapshot not in the tag
+	Ignored        int      // Files on either side left out by ignore rules
+}
+
+// Total returns the number of distinct files on either side
+func (c SnapshotComparison) Total() int {
+	return c.Identical + len(c.Modified) + len(c.OnlyInTag) + len(c.OnlyInSnapshot)
+}
+
+// Similarity returns the share of identical files among all files on either side
+func (c SnapshotComparison) Similarity() float64 {
+	if c.Total() == 0 {
+		return 1
+	}
+	return float64(c.Identical) / float64(c.Total())
+}
+
+// Matches reports whether the snapshot holds exactly the files of the tag
+func (c SnapshotComparison) Matches() bool {
+	return c.Identical == c.Total()
+}
+
+// CompareSnapshot compares the tree of the configured tag with the snapshot directory.
+// ErrSnapshotMismatch is returned along with the comparison when they differ.
+func CompareSnapshot(ctx context.Context, config SnapshotConfig) (_ SnapshotComparison, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return SnapshotComparison{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return SnapshotComparison{}, errors.Join(ErrOpenRepository, err)
+	}
+
+	return compareSnapshot(ctx, repo, config)
+}
+
+// compareSnapshot runs the comparison against a repository
+func compareSnapshot(ctx context.Context, repo Repository, config SnapshotConfig) (SnapshotComparison, error) {
+	comparison := SnapshotComparison{Path: config.SnapshotPath}
+
+	tag, err := ResolveTagOffset(repo, config.TagName, SortBySemver)
+	if err != nil {
+		return comparison, errors.Join(ErrValidationFailed, err)
+	}
+	comparison.Tag = tag
+
+	options := TagOptions{RepoPath: config.RepoPath}
+	ref, err := options.GetTagReference(ctx, repo, tag)
+	if err != nil {
+		return comparison, errors.Join(ErrGetTagReference, err)
+	}
+	commit, err := repo.GetTagCommit(ref)
+	if err != nil {
+		return comparison, err
+	}
+	comparison.Commit = commit.Hash
+
+	tagFiles, err := repo.GetFileHashes(ctx, ref)
+	if err != nil {
+		return comparison, err
+	}
+	snapshotFiles, err := hashDirectory(config.SnapshotPath)
+	if err != nil {
+		return comparison, err
+	}
+
+	var rules ignoreRules
+	if !config.NoIgnore {
+		if rules, err = loadIgnoreRules(ctx, repo, tagFiles); err != nil {
+			return comparison, err
+		}
+	}
+
+	for filePath, hash := range tagFiles {
+		if excludedPath(config.Exclude, filePath) {
+			continue
+		}
+		if rules.Ignored(filePath) {
+			comparison.Ignored++
+			continue
+		}
+		snapshotHash, ok := snapshotFiles[filePath]
+		switch {
+		case !ok:
+			comparison.OnlyInTag = append(comparison.OnlyInTag, filePath)
+		case snapshotHash != hash:
+			comparison.Modified = append(comparison.Modified, filePath)
+		default:
+			comparison.Identical++
+		}
+	}
+	for filePath := range snapshotFiles {
+		if _, ok := tagFiles[filePath]; ok || excludedPath(config.Exclude, filePath) {
+			continue
+		}
+		if rules.Ignored(filePath) {
+			comparison.Ignored++
+			continue
+		}
+		comparison.OnlyInSnapshot = append(comparison.OnlyInSnapshot, filePath)
+	}
+	sort.Strings(comparison.Modified)
+	sort.Strings(comparison.OnlyInTag)
+	sort.Strings(comparison.OnlyInSnapshot)
+
+	if !comparison.Matches() {
+		return comparison, errors.Join(ErrSnapshotMismatch, fmt.Errorf("%d of %d files differ between %s and %s",
+			comparison.Total()-comparison.Identical, comparison.Total(), comparison.Tag, comparison.Path))
+	}
+	return comparison, nil
+}
+
+// hashDirectory returns the git blob hash of every file below root, keyed by slash-separated relative path.
+// Symbolic links are hashed by their target path, as git stores them; .git is skipped.
+func hashDirectory(root string) (map[string]plumbing.Hash, error) {
+	hashes := make(map[string]plumbing.Hash)
+	err := filepath.WalkDir(root, func(filePath string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if entry.Name() == ".git" && filePath != root {
+			if entry.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if entry.IsDir() {
+			return nil
+		}
+
+		var content []byte
+		if entry.Type()&fs.ModeSymlink != 0 {
+			target, err := os.Readlink(filePath)
+			if err != nil {
+				return err
+			}
+			content = []byte(filepath.ToSlash(target))
+		} else if entry.Type().IsRegular() {
+			if content, err = os.ReadFile(filePath); err != nil {
+				return err
+			}
+		} else {
+			// Sockets, devices, and pipes cannot be part of a tree
+			return nil
+		}
+
+		relative, err := filepath.Rel(root, filePath)
+		if err != nil {
+			return err
+		}
+		hashes[filepath.ToSlash(relative)] = plumbing.ComputeHash(plumbing.BlobObject, content)
+		return nil
+	})
+	if err != nil {
+		return nil, errors.Join(ErrReadSnapshot, err)
+	}
+	return hashes, nil
+}
+
+// excludedPath reports whether a path matches one of the exclude globs.
+// A glob matches the whole path or the file name; a glob ending in / matches a directory anywhere in the path.
+func excludedPath(patterns []string, filePath string) bool {
+	parts := strings.Split(filePath, "/")
+	for _, pattern := range patterns {
+		if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+			for i := 1; i < len(parts); i++ {
+				prefix := strings.Join(parts[:i], "/")
+				if matched, _ := path.Match(dir, prefix); matched {
+					return true
+				}
+				if matched, _ := path.Match(dir, parts[i-1]); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, filePath); matched {
+			return true
+		}
+		if matched, _ := path.Match(pattern, parts[len(parts)-1]); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// PrintSnapshotComparison prints the file-level differences between a tag and a snapshot
+func PrintSnapshotComparison(w io.Writer, comparison SnapshotComparison, output OutputOptions) {
+	if comparison.Tag == "" {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "Tag: %s (commit %s)\n", comparison.Tag, shortHash(comparison.Commit.String()))
+	_, _ = fmt.Fprintf(w, "Snapshot: %s\n", comparison.Path)
+	_, _ = fmt.Fprintf(w, "Similarity: %.2f%% (%d of %d files identical)\n", comparison.Similarity()*100.0, comparison.Identical, comparison.Total())
+	_, _ = fmt.Fprintf(w, "  Modified: %d\n", len(comparison.Modified))
+	_, _ = fmt.Fprintf(w, "  Only in [%s]: %d\n", comparison.Tag, len(comparison.OnlyInTag))
+	_, _ = fmt.Fprintf(w, "  Only in snapshot: %d\n", len(comparison.OnlyInSnapshot))
+	if comparison.Ignored > 0 {
+		_, _ = fmt.Fprintf(w, "  Ignored: %d (matched ignore rules; -no-ignore compares them)\n", comparison.Ignored)
+	}
+
+	printPaths(w, "Modified files", comparison.Modified, output)
+	printPaths(w, fmt.Sprintf("Files only in [%s]", comparison.Tag), comparison.OnlyInTag, output)
+	printPaths(w, "Files only in the snapshot", comparison.OnlyInSnapshot, output)
+}
+
+// printPaths prints a titled list of paths, skipping empty lists
+func printPaths(w io.Writer, title string, paths []string, output OutputOptions) {
+	if len(paths) == 0 {
+		return
+	}
+
+	_, _ = fmt.Fprintf(w, "\n%s (%d):\n", title, len(paths))
+	for _, filePath := range paths {
+		_, _ = fmt.Fprintf(w, "  %s\n", output.FitLine(filePath, 2))
+	}
+}
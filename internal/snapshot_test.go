@@ -0,0 +1,120 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// writeSnapshot writes files, keyed by slash-separated path, below a new temporary directory
+func writeSnapshot(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+	return dir
+}
+
+// TestCompareSnapshot tests comparing the tree of a tag with a directory
+func TestCompareSnapshot(t *testing.T) {
+	isolateGitConfig(t)
+	fixture := newReleaseFixture(t)
+	repo := openFixture(t, fixture)
+
+	release := map[string]string{
+		"src/api/a.go":  "package api\n",
+		"src/api/b.go":  "package api\n",
+		"internal/x.go": "package internal\n\nconst X = 1\n",
+	}
+
+	tests := []struct {
+		name      string
+		tag       string
+		files     map[string]string
+		exclude   []string
+		identical int
+		modified  []string
+		onlyTag   []string
+		onlySnap  []string
+	}{
+		{name: "identical", tag: "v1.1.0", files: release, identical: 3},
+		{name: "older tag", tag: "v1.0.0", files: release, identical: 1, modified: []string{"internal/x.go"}, onlySnap: []string{"src/api/b.go"}},
+		{
+			name:      "generated files",
+			tag:       "latest",
+			files:     map[string]string{"src/api/a.go": "package api\n", "internal/x.go": "package internal\n", "configure": "#!/bin/sh\n", "dist/app.tar": "x"},
+			identical: 1,
+			modified:  []string{"internal/x.go"},
+			onlyTag:   []string{"src/api/b.go"},
+			onlySnap:  []string{"configure", "dist/app.tar"},
+		},
+		{
+			name:      "excluded",
+			tag:       "v1.1.0",
+			files:     map[string]string{"src/api/a.go": "package api\n", "internal/x.go": "package internal\n\nconst X = 1\n", "configure": "#!/bin/sh\n", "dist/app.tar": "x"},
+			exclude:   []string{"configure", "dist/", "b.go"},
+			identical: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := SnapshotConfig{RepoPath: fixture.Path(), TagName: tt.tag, SnapshotPath: writeSnapshot(t, tt.files), Exclude: tt.exclude}
+			comparison, err := compareSnapshot(context.Background(), repo, config)
+
+			matches := tt.modified == nil && tt.onlyTag == nil && tt.onlySnap == nil
+			if matches && err != nil {
+				t.Fatalf("compareSnapshot() error = %v", err)
+			}
+			if !matches && !errors.Is(err, ErrSnapshotMismatch) {
+				t.Fatalf("compareSnapshot() error = %v, want ErrSnapshotMismatch", err)
+			}
+
+			if comparison.Identical != tt.identical {
+				t.Errorf("Identical = %d, want %d", comparison.Identical, tt.identical)
+			}
+			if !reflect.DeepEqual(comparison.Modified, tt.modified) {
+				t.Errorf("Modified = %v, want %v", comparison.Modified, tt.modified)
+			}
+			if !reflect.DeepEqual(comparison.OnlyInTag, tt.onlyTag) {
+				t.Errorf("OnlyInTag = %v, want %v", comparison.OnlyInTag, tt.onlyTag)
+			}
+			if !reflect.DeepEqual(comparison.OnlyInSnapshot, tt.onlySnap) {
+				t.Errorf("OnlyInSnapshot = %v, want %v", comparison.OnlyInSnapshot, tt.onlySnap)
+			}
+		})
+	}
+}
+
+// TestExcludedPath tests matching paths against exclude globs
+func TestExcludedPath(t *testing.T) {
+	tests := []struct {
+		pattern string
+		path    string
+		want    bool
+	}{
+		{pattern: "*.pyc", path: "pkg/mod.pyc", want: true},
+		{pattern: "pkg/*.pyc", path: "pkg/mod.pyc", want: true},
+		{pattern: "pkg/*.pyc", path: "other/mod.pyc", want: false},
+		{pattern: "dist/", path: "dist/app.tar", want: true},
+		{pattern: "node_modules/", path: "web/node_modules/x/index.js", want: true},
+		{pattern: "dist/", path: "dist", want: false},
+		{pattern: "configure", path: "configure.ac", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := excludedPath([]string{tt.pattern}, tt.path); got != tt.want {
+			t.Errorf("excludedPath(%q, %q) = %v, want %v", tt.pattern, tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,381 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrNoReleaseTags = errors.New("no semantic version tags found")
+
+// lowSimilarityFactor flags a pair of adjacent releases whose similarity is below this fraction of the
+// median similarity of pairs with the same version distance
+const lowSimilarityFactor = 0.5
+
+// minDistanceSamples is the number of pairs with the same version distance needed for their own median;
+// with fewer, pairs are measured against the median of all pairs
+const minDistanceSamples = 3
+
+// SuggestConfig holds the configuration of the suggest command
+type SuggestConfig struct {
+	Command  Command
+	RepoPath string
+	Pattern  string // Glob matched against tag names (e.g. "v*")
+	Recent   int    // Number of newest semantic version tags inspected
+	Output   OutputOptions
+	Timeout  time.Duration // Cancel the suggestions after this long; 0 never times out
+}
+
+// suggestUsage is the help of the suggest command
+var suggestUsage = commandUsage{
+	Name:        "suggest",
+	Summary:     "Recommend tag pairs worth comparing",
+	Description: "Inspect the release tags and recent activity of a repository and recommend comparisons:\nthe latest release against the previous one on each release line, adjacent releases\nwith unusually low similarity for their version distance, and unreleased commits on\nthe default branch. Each suggestion comes with the compare command that runs it.",
+	Examples: []string{
+		"suggest -repo /path/to/repo",
+		"suggest -repo /path/to/repo -pattern 'v2.*' -recent 50",
+	},
+}
+
+// NewSuggestConfig parses the suggest command flags
+func NewSuggestConfig(args []string) (SuggestConfig, error) {
+	config := SuggestConfig{Command: SuggestCommand}
+
+	suggestCmd := newCommandFlagSet(suggestUsage)
+	suggestCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	suggestCmd.StringVar(&config.Pattern, "pattern", "", "Only inspect tags matching this glob (e.g. 'v*')")
+	suggestCmd.IntVar(&config.Recent, "recent", 20, "Number of newest semantic version tags to inspect")
+	parseOutputOptions := config.Output.registerFlags(suggestCmd, "Maximum line width of the suggestions table")
+	registerTimeoutFlag(suggestCmd, &config.Timeout, "the suggestions")
+
+	if err := suggestCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *SuggestConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+
+	if c.Pattern != "" {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return errors.Join(ErrInvalidTagPattern, err)
+		}
+	}
+
+	if c.Recent < 2 {
+		return errors.Join(ErrTooFewTags, fmt.Errorf("-recent must be at least 2, got %d", c.Recent))
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// Suggestion is a recommended comparison of two refs
+type Suggestion struct {
+	Tag1       string
+	Tag2       string
+	Similarity float64
+	Reasons    []string // Why the pair is worth comparing, in the order the reasons were found
+}
+
+// SuggestReport holds the suggested comparisons, most relevant first
+type SuggestReport struct {
+	RepoPath    string
+	Tags        int // Number of semantic version tags inspected
+	Suggestions []Suggestion
+}
+
+// Suggest inspects the configured repository and recommends comparisons
+func Suggest(ctx context.Context, config SuggestConfig) (_ SuggestReport, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return SuggestReport{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return SuggestReport{}, errors.Join(ErrOpenRepository, err)
+	}
+
+	return suggestComparisons(ctx, repo, config)
+}
+
+// releaseTag is a tag with its parsed semantic version
+type releaseTag struct {
+	ref     *plumbing.Reference
+	version semver
+}
+
+// releaseLine names the major.minor line of a version
+func (t releaseTag) releaseLine() string {
+	return fmt.Sprintf("%d.%d", t.version.major, t.version.minor)
+}
+
+// suggestComparisons collects the suggestions: the latest releases of each release line first, then adjacent
+// releases with unusually low similarity, then the commits on the default branch since the newest release
+func suggestComparisons(ctx context.Context, repo Repository, config SuggestConfig) (SuggestReport, error) {
+	report := SuggestReport{RepoPath: config.RepoPath}
+
+	allTags, err := repo.FetchAllTags()
+	if err != nil {
+		return report, err
+	}
+	var releases []releaseTag
+	for _, ref := range allTags {
+		if config.Pattern != "" {
+			if matched, _ := path.Match(config.Pattern, ref.Name().Short()); !matched {
+				continue
+			}
+		}
+		if version, ok := parseSemver(ref.Name().Short()); ok {
+			releases = append(releases, releaseTag{ref: ref, version: version})
+		}
+	}
+	if len(releases) == 0 {
+		return report, errors.Join(ErrNoReleaseTags, fmt.Errorf("no tags like v1.2.3 match pattern: %q", config.Pattern))
+	}
+
+	// Inspect the newest releases, oldest first
+	sort.SliceStable(releases, func(i int, j int) bool { return releases[i].version.less(releases[j].version) })
+	if len(releases) > config.Recent {
+		releases = releases[len(releases)-config.Recent:]
+	}
+	report.Tags = len(releases)
+
+	sets := make(map[plumbing.ReferenceName]map[plumbing.Hash]struct{})
+	commitSet := func(ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error) {
+		if set, ok := sets[ref.Name()]; ok {
+			return set, nil
+		}
+		set, err := repo.GetCommitSetForTag(ctx, ref)
+		if err != nil {
+			return nil, errors.Join(ErrGetCommits, err)
+		}
+		sets[ref.Name()] = set
+		return set, nil
+	}
+
+	var suggestions []Suggestion
+	suggest := func(tag1 releaseTag, tag2 *plumbing.Reference, reason string) error {
+		for i := range suggestions {
+			if suggestions[i].Tag1 == tag1.ref.Name().Short() && suggestions[i].Tag2 == tag2.Name().Short() {
+				suggestions[i].Reasons = append(suggestions[i].Reasons, reason)
+				return nil
+			}
+		}
+		set1, err := commitSet(tag1.ref)
+		if err != nil {
+			return err
+		}
+		set2, err := commitSet(tag2)
+		if err != nil {
+			return err
+		}
+		suggestions = append(suggestions, Suggestion{
+			Tag1:       tag1.ref.Name().Short(),
+			Tag2:       tag2.Name().Short(),
+			Similarity: CalculateJaccardSimilarity(set1, set2),
+			Reasons:    []string{reason},
+		})
+		return nil
+	}
+
+	// Latest against previous release on each release line, newest line first
+	var lines [][]releaseTag
+	for _, release := range releases {
+		if n := len(lines); n > 0 && lines[n-1][0].releaseLine() == release.releaseLine() {
+			lines[n-1] = append(lines[n-1], release)
+		} else {
+			lines = append(lines, []releaseTag{release})
+		}
+	}
+	for i := len(lines) - 1; i >= 0; i-- {
+		if line := lines[i]; len(line) >= 2 {
+			reason := fmt.Sprintf("latest vs previous release on the %s line", line[0].releaseLine())
+			if err := suggest(line[len(line)-2], line[len(line)-1].ref, reason); err != nil {
+				return report, err
+			}
+		}
+	}
+	if n := len(lines); n >= 2 {
+		previous, newest := lines[n-2], lines[n-1]
+		reason := fmt.Sprintf("newest release line %s vs the %s line before it", newest[0].releaseLine(), previous[0].releaseLine())
+		if err := suggest(previous[len(previous)-1], newest[len(newest)-1].ref, reason); err != nil {
+			return report, err
+		}
+	}
+
+	// Adjacent releases that share unusually few commits for their version distance
+	lowPairs, err := lowSimilarityPairs(releases, commitSet)
+	if err != nil {
+		return report, err
+	}
+	for _, pair := range lowPairs {
+		if err := suggest(pair.tag1, pair.tag2.ref, pair.reason); err != nil {
+			return report, err
+		}
+	}
+
+	// Commits on the default branch since the newest release; repositories without one get no suggestion
+	newest := releases[len(releases)-1]
+	if branch, err := repo.GetBranchReference(""); err == nil {
+		branchCommits, err := commitSet(branch)
+		if err != nil {
+			return report, err
+		}
+		releaseCommits, err := commitSet(newest.ref)
+		if err != nil {
+			return report, err
+		}
+		unreleased := 0
+		for hash := range branchCommits {
+			if _, ok := releaseCommits[hash]; !ok {
+				unreleased++
+			}
+		}
+		if unreleased > 0 {
+			reason := fmt.Sprintf("unreleased commits on %s: %d", branch.Name().Short(), unreleased)
+			if err := suggest(newest, branch, reason); err != nil {
+				return report, err
+			}
+		}
+	}
+
+	report.Suggestions = suggestions
+	return report, nil
+}
+
+// releasePair is a pair of adjacent releases flagged for low similarity
+type releasePair struct {
+	tag1, tag2 releaseTag
+	reason     string
+}
+
+// lowSimilarityPairs compares each release with the next one and returns the pairs whose similarity is below
+// lowSimilarityFactor times the median of the pairs with the same version distance
+func lowSimilarityPairs(releases []releaseTag, commitSet func(*plumbing.Reference) (map[plumbing.Hash]struct{}, error)) ([]releasePair, error) {
+	if len(releases) < 2 {
+		return nil, nil
+	}
+
+	distances := make([]string, len(releases)-1)
+	similarities := make([]float64, len(releases)-1)
+	byDistance := make(map[string][]float64)
+	for i := 0; i+1 < len(releases); i++ {
+		set1, err := commitSet(releases[i].ref)
+		if err != nil {
+			return nil, err
+		}
+		set2, err := commitSet(releases[i+1].ref)
+		if err != nil {
+			return nil, err
+		}
+		distances[i] = versionDistance(releases[i].version, releases[i+1].version)
+		similarities[i] = CalculateJaccardSimilarity(set1, set2)
+		byDistance[distances[i]] = append(byDistance[distances[i]], similarities[i])
+	}
+	if len(similarities) < minDistanceSamples {
+		return nil, nil
+	}
+
+	var pairs []releasePair
+	for i, similarity := range similarities {
+		samples := byDistance[distances[i]]
+		if len(samples) < minDistanceSamples {
+			samples = similarities
+		}
+		typical := median(samples)
+		if similarity < typical*lowSimilarityFactor {
+			pairs = append(pairs, releasePair{
+				tag1: releases[i],
+				tag2: releases[i+1],
+				reason: fmt.Sprintf("low similarity for a %s release (%.2f%%, typically %.2f%%)",
+					distances[i], similarity*100.0, typical*100.0),
+			})
+		}
+	}
+	return pairs, nil
+}
+
+// versionDistance names the largest version component that changed between two releases
+func versionDistance(older semver, newer semver) string {
+	switch {
+	case older.major != newer.major:
+		return "major"
+	case older.minor != newer.minor:
+		return "minor"
+	default:
+		return "patch"
+	}
+}
+
+// median returns the median of values, which must not be empty
+func median(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	n := len(sorted)
+	if n%2 == 1 {
+		return sorted[n/2]
+	}
+	return (sorted[n/2-1] + sorted[n/2]) / 2
+}
+
+// PrintSuggestions prints the suggested comparisons and the compare command of each
+func PrintSuggestions(w io.Writer, report SuggestReport, output OutputOptions) {
+	if len(report.Suggestions) == 0 {
+		_, _ = fmt.Fprintf(w, "No comparisons to suggest (release tags inspected: %d)\n", report.Tags)
+		return
+	}
+
+	rows := [][]string{{"#", "TAG1", "TAG2", "SIMILARITY", "REASON"}}
+	for i, suggestion := range report.Suggestions {
+		rows = append(rows, []string{
+			fmt.Sprintf("%d", i+1),
+			suggestion.Tag1,
+			suggestion.Tag2,
+			fmt.Sprintf("%.2f%%", suggestion.Similarity*100.0),
+			strings.Join(suggestion.Reasons, "; "),
+		})
+	}
+	writeTable(w, output, 4, rows)
+
+	_, _ = fmt.Fprintln(w, "\nTo run a suggestion:")
+	for i, suggestion := range report.Suggestions {
+		_, _ = fmt.Fprintf(w, "  %d. git-tag-similarity compare -repo %s -tag1 %s -tag2 %s\n",
+			i+1, shellQuote(report.RepoPath), shellQuote(suggestion.Tag1), shellQuote(suggestion.Tag2))
+	}
+}
+
+// shellQuote quotes a command line argument for POSIX shells when it contains more than safe characters
+func shellQuote(arg string) string {
+	if arg != "" && strings.IndexFunc(arg, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_./:@+=,", r))
+	}) < 0 {
+		return arg
+	}
+	return "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+}
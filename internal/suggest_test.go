@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestSuggestComparisons tests the suggestions for release lines, low similarity, and unreleased commits
+func TestSuggestComparisons(t *testing.T) {
+	fixture := newReleaseFixture(t).
+		Commit("Fix a", testutil.File("src/api/a.go", "package api\n\nconst A = 1\n")).
+		Tag("v1.1.1").
+		Commit("Fix b", testutil.File("src/api/b.go", "package api\n\nconst B = 1\n")).
+		Tag("v1.1.2").
+		Orphan("rewritten").
+		Commit("Import rewritten history", testutil.File("src/api/a.go", "package api\n")).
+		Tag("v1.1.3").
+		Checkout("main")
+	repo := openFixture(t, fixture)
+
+	report, err := suggestComparisons(context.Background(), repo, SuggestConfig{Recent: 20})
+	if err != nil {
+		t.Fatalf("suggestComparisons() error = %v", err)
+	}
+	if report.Tags != 5 {
+		t.Errorf("Tags = %d, want 5", report.Tags)
+	}
+
+	want := []Suggestion{
+		{Tag1: "v1.1.2", Tag2: "v1.1.3", Similarity: 0, Reasons: []string{
+			"latest vs previous release on the 1.1 line",
+			"low similarity for a patch release (0.00%, typically 75.00%)",
+		}},
+		{Tag1: "v1.0.0", Tag2: "v1.1.3", Similarity: 0, Reasons: []string{"newest release line 1.1 vs the 1.0 line before it"}},
+		{Tag1: "v1.1.3", Tag2: "main", Similarity: 0, Reasons: []string{"unreleased commits on main: 5"}},
+	}
+	if !reflect.DeepEqual(report.Suggestions, want) {
+		t.Errorf("Suggestions = %+v, want %+v", report.Suggestions, want)
+	}
+
+	recent, err := suggestComparisons(context.Background(), repo, SuggestConfig{Pattern: "v1.1.*", Recent: 2})
+	if err != nil {
+		t.Fatalf("suggestComparisons() with -recent 2 error = %v", err)
+	}
+	if recent.Tags != 2 || len(recent.Suggestions) == 0 || recent.Suggestions[0].Tag1 != "v1.1.2" {
+		t.Errorf("suggestComparisons() with -recent 2 = %+v, want v1.1.2 and v1.1.3 only", recent)
+	}
+
+	if _, err := suggestComparisons(context.Background(), repo, SuggestConfig{Pattern: "release-*", Recent: 20}); !errors.Is(err, ErrNoReleaseTags) {
+		t.Errorf("suggestComparisons() without matching tags error = %v, want ErrNoReleaseTags", err)
+	}
+}
+
+// TestPrintSuggestions tests the table and the compare commands of the suggestions
+func TestPrintSuggestions(t *testing.T) {
+	report := SuggestReport{
+		RepoPath: "/tmp/my repo",
+		Tags:     2,
+		Suggestions: []Suggestion{
+			{Tag1: "v1.0.0", Tag2: "v1.0.1", Similarity: 0.75, Reasons: []string{"latest vs previous release on the 1.0 line"}},
+		},
+	}
+
+	var buf bytes.Buffer
+	PrintSuggestions(&buf, report, OutputOptions{})
+	output := buf.String()
+	for _, want := range []string{
+		"1  v1.0.0  v1.0.1  75.00%      latest vs previous release on the 1.0 line",
+		"1. git-tag-similarity compare -repo '/tmp/my repo' -tag1 v1.0.0 -tag2 v1.0.1",
+	} {
+		if !strings.Contains(output, want) {
+			t.Errorf("PrintSuggestions() output missing %q:\n%s", want, output)
+		}
+	}
+
+	buf.Reset()
+	PrintSuggestions(&buf, SuggestReport{Tags: 1}, OutputOptions{})
+	if got := buf.String(); got != "No comparisons to suggest (release tags inspected: 1)\n" {
+		t.Errorf("PrintSuggestions() without suggestions = %q", got)
+	}
+}
+
+// TestSuggestConfig_Validate tests the validation of the suggest flags
+func TestSuggestConfig_Validate(t *testing.T) {
+	config, err := NewSuggestConfig([]string{"-repo", ".", "-recent", "1"})
+	if err != nil {
+		t.Fatalf("NewSuggestConfig() error = %v", err)
+	}
+	if err := config.Validate(); !errors.Is(err, ErrTooFewTags) {
+		t.Errorf("Validate() with -recent 1 error = %v, want ErrTooFewTags", err)
+	}
+
+	config.Recent, config.Pattern = 20, "v[1"
+	if err := config.Validate(); !errors.Is(err, ErrInvalidTagPattern) {
+		t.Errorf("Validate() with a malformed pattern error = %v, want ErrInvalidTagPattern", err)
+	}
+}
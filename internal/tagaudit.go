@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrTagAudit = errors.New("failed to audit tag")
+
+// TagAudit records who created a tag, when, and from which commit, for release sign-off.
+// Lightweight tags, branches, and commits store no creator, so the committer of the commit stands in for it.
+type TagAudit struct {
+	Tag        string    `json:"tag"`
+	Annotated  bool      `json:"annotated"`
+	Revision   bool      `json:"revision,omitempty"` // A branch, commit, or revision expression rather than a tag
+	Creator    string    `json:"creator"`
+	Email      string    `json:"email"`
+	CreatedAt  time.Time `json:"created_at"`
+	Commit     string    `json:"commit"`
+	BranchTips []string  `json:"branch_tips,omitempty"` // Branches whose tip is the tagged commit
+}
+
+// String formats the audit as a single line for console output
+func (a TagAudit) String() string {
+	kind := "lightweight, committed"
+	if a.Annotated {
+		kind = "annotated"
+	}
+	if a.Revision {
+		kind = "not a tag, committed"
+	}
+
+	tips := "not a branch tip"
+	if len(a.BranchTips) > 0 {
+		tips = "tip of " + strings.Join(a.BranchTips, ", ")
+	}
+
+	return fmt.Sprintf("%s by %s <%s> at %s, commit %s, %s",
+		kind, a.Creator, a.Email, a.CreatedAt.Format("2006-01-02 15:04:05 -0700"), shortHash(a.Commit), tips)
+}
+
+// AuditTag collects the creation record of a tag
+func AuditTag(repo Repository, tag *plumbing.Reference) (TagAudit, error) {
+	audit := TagAudit{Tag: tag.Name().Short(), Revision: !tag.Name().IsTag()}
+
+	commit, err := repo.GetTagCommit(tag)
+	if err != nil {
+		return audit, errors.Join(ErrTagAudit, err)
+	}
+	audit.Commit = commit.Hash.String()
+
+	tagObj, err := repo.GetTagObject(tag)
+	if err != nil {
+		return audit, errors.Join(ErrTagAudit, err)
+	}
+	if tagObj != nil {
+		audit.Annotated = true
+		audit.Creator = tagObj.Tagger.Name
+		audit.Email = tagObj.Tagger.Email
+		audit.CreatedAt = tagObj.Tagger.When
+	} else {
+		audit.Creator = commit.Committer.Name
+		audit.Email = commit.Committer.Email
+		audit.CreatedAt = commit.Committer.When
+	}
+
+	refs, err := repo.GetBranchesPointingAt(commit.Hash)
+	if err != nil {
+		return audit, errors.Join(ErrTagAudit, err)
+	}
+	for _, ref := range refs {
+		audit.BranchTips = append(audit.BranchTips, ref.Name().Short())
+	}
+	sort.Strings(audit.BranchTips)
+
+	return audit, nil
+}
+
+// printTagAudits prints the creation record of both tags
+func printTagAudits(result CompareResult) {
+	if result.Tag1Audit.Commit == "" && result.Tag2Audit.Commit == "" {
+		return
+	}
+
+	width := max(len(result.Tag1Audit.Tag), len(result.Tag2Audit.Tag))
+	fmt.Printf("\nTag audit:\n")
+	for _, audit := range []TagAudit{result.Tag1Audit, result.Tag2Audit} {
+		fmt.Printf("  %-*s  %s\n", width, audit.Tag, audit)
+	}
+}
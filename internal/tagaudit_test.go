@@ -0,0 +1,104 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestAuditTag tests the creator, date, and branch tips recorded for annotated and lightweight tags
+func TestAuditTag(t *testing.T) {
+	commitHash := plumbing.NewHash("0000000000000000000000000000000000000001")
+	tagDate := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	commitDate := time.Date(2025, 2, 28, 9, 30, 0, 0, time.UTC)
+	commit := &object.Commit{
+		Hash:      commitHash,
+		Committer: object.Signature{Name: "Bob", Email: "bob@example.com", When: commitDate},
+	}
+	tagger := &object.Tag{Tagger: object.Signature{Name: "Alice", Email: "alice@example.com", When: tagDate}}
+
+	mainTip := plumbing.NewHashReference("refs/heads/main", commitHash)
+	originTip := plumbing.NewHashReference("refs/remotes/origin/main", commitHash)
+
+	tests := []struct {
+		name          string
+		tagObj        *object.Tag
+		tips          []*plumbing.Reference
+		wantAnnotated bool
+		wantCreator   string
+		wantDate      time.Time
+		wantTips      []string
+	}{
+		{
+			name:          "Annotated tag at a branch tip",
+			tagObj:        tagger,
+			tips:          []*plumbing.Reference{originTip, mainTip},
+			wantAnnotated: true,
+			wantCreator:   "Alice",
+			wantDate:      tagDate,
+			wantTips:      []string{"main", "origin/main"},
+		},
+		{
+			name:        "Lightweight tag falls back to the committer",
+			wantCreator: "Bob",
+			wantDate:    commitDate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			tag := plumbing.NewHashReference("refs/tags/v1.0.0", commitHash)
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetTagCommit(tag).Return(commit, nil)
+			mockRepo.EXPECT().GetTagObject(tag).Return(tt.tagObj, nil)
+			mockRepo.EXPECT().GetBranchesPointingAt(commitHash).Return(tt.tips, nil)
+
+			audit, err := AuditTag(mockRepo, tag)
+			if err != nil {
+				t.Fatalf("AuditTag() error = %v", err)
+			}
+
+			if audit.Tag != "v1.0.0" || audit.Commit != commitHash.String() {
+				t.Errorf("AuditTag() tag = %s, commit = %s", audit.Tag, audit.Commit)
+			}
+			if audit.Annotated != tt.wantAnnotated || audit.Creator != tt.wantCreator || !audit.CreatedAt.Equal(tt.wantDate) {
+				t.Errorf("AuditTag() = %+v, want annotated %v by %s at %s", audit, tt.wantAnnotated, tt.wantCreator, tt.wantDate)
+			}
+			if strings.Join(audit.BranchTips, ",") != strings.Join(tt.wantTips, ",") {
+				t.Errorf("AuditTag() branch tips = %v, want %v", audit.BranchTips, tt.wantTips)
+			}
+		})
+	}
+}
+
+// TestTagAuditString tests the console line of a tag audit
+func TestTagAuditString(t *testing.T) {
+	audit := TagAudit{
+		Tag:        "v1.0.0",
+		Annotated:  true,
+		Creator:    "Alice",
+		Email:      "alice@example.com",
+		CreatedAt:  time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		Commit:     "0123456789abcdef0123456789abcdef01234567",
+		BranchTips: []string{"main"},
+	}
+
+	want := "annotated by Alice <alice@example.com> at 2025-03-01 12:00:00 +0000, commit 0123456, tip of main"
+	if got := audit.String(); got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+
+	audit.Annotated = false
+	audit.BranchTips = nil
+	if got := audit.String(); !strings.HasPrefix(got, "lightweight, committed by") || !strings.HasSuffix(got, "not a branch tip") {
+		t.Errorf("String() = %q", got)
+	}
+}
@@ -0,0 +1,260 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrInvalidSortStrategy = errors.New("invalid sort strategy")
+	ErrInvalidTagOffset    = errors.New("invalid tag offset")
+	ErrSortTags            = errors.New("failed to sort tags")
+)
+
+// SortStrategy determines how tags are ordered when resolving "latest" references
+type SortStrategy string
+
+const (
+	SortBySemver SortStrategy = "semver"
+	SortByDate   SortStrategy = "date"
+)
+
+// latestTagKeyword is the tag name prefix that refers to the newest tag
+const latestTagKeyword = "latest"
+
+// ParseSortStrategy converts a flag value into a SortStrategy
+func ParseSortStrategy(value string) (SortStrategy, error) {
+	switch SortStrategy(value) {
+	case SortBySemver, SortByDate:
+		return SortStrategy(value), nil
+	default:
+		return "", errors.Join(ErrInvalidSortStrategy, fmt.Errorf("unknown sort strategy: %s (expected semver or date)", value))
+	}
+}
+
+// parseTagOffset reports whether name is a "latest" or "latest-N" reference and returns N
+func parseTagOffset(name string) (int, bool, error) {
+	if name == latestTagKeyword {
+		return 0, true, nil
+	}
+
+	suffix, ok := strings.CutPrefix(name, latestTagKeyword+"-")
+	if !ok {
+		return 0, false, nil
+	}
+
+	offset, err := strconv.Atoi(suffix)
+	if err != nil || offset < 0 {
+		return 0, true, errors.Join(ErrInvalidTagOffset, fmt.Errorf("invalid offset in '%s'", name))
+	}
+
+	return offset, true, nil
+}
+
+// splitTagNamespace splits a namespaced tag such as "service-a/v1.2.0" into its namespace and version.
+// Names without a slash have an empty namespace.
+func splitTagNamespace(name string) (string, string) {
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		return name[:i], name[i+1:]
+	}
+	return "", name
+}
+
+// ResolveTagOffset resolves a "latest" or "latest-N" reference to a concrete tag name.
+// A namespaced reference such as "service-a/latest" counts only the tags of that namespace.
+// Names that are not offset references are returned unchanged.
+func ResolveTagOffset(repo Repository, name string, strategy SortStrategy) (string, error) {
+	namespace, version := splitTagNamespace(name)
+	offset, ok, err := parseTagOffset(version)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return name, nil
+	}
+
+	tagRefs, err := repo.FetchAllTags()
+	if err != nil {
+		return "", err
+	}
+
+	if namespace != "" {
+		tagRefs = namespaceTags(tagRefs, namespace)
+		// A branch such as "feature/latest" is not an offset reference when no tags use the namespace
+		if len(tagRefs) == 0 {
+			return name, nil
+		}
+	}
+
+	sorted, err := sortTagsInNamespace(repo, tagRefs, namespace, strategy)
+	if err != nil {
+		return "", err
+	}
+
+	if offset >= len(sorted) {
+		return "", errors.Join(ErrInvalidTagOffset, fmt.Errorf("'%s' is out of range: only %d tags available", name, len(sorted)))
+	}
+
+	return sorted[offset].Name().Short(), nil
+}
+
+// SortTags orders tag references from newest to oldest using the given strategy.
+// With the semver strategy, tags that are not semantic versions are left out.
+func SortTags(repo Repository, tagRefs []*plumbing.Reference, strategy SortStrategy) ([]*plumbing.Reference, error) {
+	return sortTagsInNamespace(repo, tagRefs, "", strategy)
+}
+
+// sortTagsInNamespace is SortTags for the tags of one namespace, whose prefix is stripped before
+// parsing versions, so "service-a/v1.10.0" sorts after "service-a/v1.9.0"
+func sortTagsInNamespace(repo Repository, tagRefs []*plumbing.Reference, namespace string, strategy SortStrategy) ([]*plumbing.Reference, error) {
+	switch strategy {
+	case SortBySemver:
+		return sortTagsBySemver(tagRefs, namespace), nil
+	case SortByDate:
+		return sortTagsByDate(repo, tagRefs)
+	default:
+		return nil, errors.Join(ErrInvalidSortStrategy, fmt.Errorf("unknown sort strategy: %s", strategy))
+	}
+}
+
+// namespaceTags returns the tags directly in a namespace, e.g. "service-a/v1.2.0" for "service-a"
+func namespaceTags(tagRefs []*plumbing.Reference, namespace string) []*plumbing.Reference {
+	var tags []*plumbing.Reference
+	for _, ref := range tagRefs {
+		if tagNamespace, _ := splitTagNamespace(ref.Name().Short()); tagNamespace == namespace {
+			tags = append(tags, ref)
+		}
+	}
+	return tags
+}
+
+func sortTagsBySemver(tagRefs []*plumbing.Reference, namespace string) []*plumbing.Reference {
+	type versionedRef struct {
+		ref     *plumbing.Reference
+		version semver
+	}
+
+	var versioned []versionedRef
+	for _, ref := range tagRefs {
+		name := ref.Name().Short()
+		if namespace != "" {
+			name = strings.TrimPrefix(name, namespace+"/")
+		}
+		if v, ok := parseSemver(name); ok {
+			versioned = append(versioned, versionedRef{ref: ref, version: v})
+		}
+	}
+
+	sort.SliceStable(versioned, func(i int, j int) bool {
+		return versioned[j].version.less(versioned[i].version)
+	})
+
+	sorted := make([]*plumbing.Reference, 0, len(versioned))
+	for _, v := range versioned {
+		sorted = append(sorted, v.ref)
+	}
+	return sorted
+}
+
+func sortTagsByDate(repo Repository, tagRefs []*plumbing.Reference) ([]*plumbing.Reference, error) {
+	dates := make(map[plumbing.ReferenceName]int64, len(tagRefs))
+	for _, ref := range tagRefs {
+		commit, err := repo.GetTagCommit(ref)
+		if err != nil {
+			return nil, errors.Join(ErrSortTags, err)
+		}
+		dates[ref.Name()] = commit.Committer.When.Unix()
+	}
+
+	sorted := append([]*plumbing.Reference(nil), tagRefs...)
+	sort.SliceStable(sorted, func(i int, j int) bool {
+		di, dj := dates[sorted[i].Name()], dates[sorted[j].Name()]
+		if di != dj {
+			return di > dj
+		}
+		return sorted[i].Name().Short() > sorted[j].Name().Short()
+	})
+	return sorted, nil
+}
+
+// semver is a parsed semantic version (an optional leading "v" is accepted)
+type semver struct {
+	major, minor, patch int
+	prerelease          string
+}
+
+func parseSemver(name string) (semver, bool) {
+	version := strings.TrimPrefix(name, "v")
+
+	// Build metadata does not affect precedence
+	version, _, _ = strings.Cut(version, "+")
+	core, prerelease, _ := strings.Cut(version, "-")
+
+	parts := strings.Split(core, ".")
+	if len(parts) != 3 {
+		return semver{}, false
+	}
+
+	var numbers [3]int
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return semver{}, false
+		}
+		numbers[i] = n
+	}
+
+	return semver{major: numbers[0], minor: numbers[1], patch: numbers[2], prerelease: prerelease}, true
+}
+
+// less reports whether v has lower precedence than other
+func (v semver) less(other semver) bool {
+	if v.major != other.major {
+		return v.major < other.major
+	}
+	if v.minor != other.minor {
+		return v.minor < other.minor
+	}
+	if v.patch != other.patch {
+		return v.patch < other.patch
+	}
+
+	// A version without a pre-release has higher precedence
+	if v.prerelease == "" || other.prerelease == "" {
+		return v.prerelease != "" && other.prerelease == ""
+	}
+	return comparePrerelease(v.prerelease, other.prerelease) < 0
+}
+
+// comparePrerelease compares dot-separated pre-release identifiers per the semver spec
+func comparePrerelease(a string, b string) int {
+	aParts := strings.Split(a, ".")
+	bParts := strings.Split(b, ".")
+
+	for i := range min(len(aParts), len(bParts)) {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+
+		switch {
+		case aErr == nil && bErr == nil:
+			if aNum != bNum {
+				return aNum - bNum
+			}
+		case aErr == nil:
+			return -1 // Numeric identifiers have lower precedence
+		case bErr == nil:
+			return 1
+		default:
+			if c := strings.Compare(aParts[i], bParts[i]); c != 0 {
+				return c
+			}
+		}
+	}
+
+	return len(aParts) - len(bParts)
+}
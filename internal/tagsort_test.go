@@ -0,0 +1,103 @@
+package internal
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestResolveTagOffset tests resolving latest/latest-N references with semver ordering
+func TestResolveTagOffset(t *testing.T) {
+	tags := []*plumbing.Reference{
+		plumbing.NewReferenceFromStrings("refs/tags/v1.0.0", "0000000000000000000000000000000000000001"),
+		plumbing.NewReferenceFromStrings("refs/tags/v1.10.0", "0000000000000000000000000000000000000002"),
+		plumbing.NewReferenceFromStrings("refs/tags/v1.2.0", "0000000000000000000000000000000000000003"),
+		plumbing.NewReferenceFromStrings("refs/tags/v2.0.0-rc.1", "0000000000000000000000000000000000000004"),
+		plumbing.NewReferenceFromStrings("refs/tags/nightly", "0000000000000000000000000000000000000005"),
+		plumbing.NewReferenceFromStrings("refs/tags/service-a/v1.2.0", "0000000000000000000000000000000000000006"),
+		plumbing.NewReferenceFromStrings("refs/tags/service-a/v1.10.0", "0000000000000000000000000000000000000007"),
+		plumbing.NewReferenceFromStrings("refs/tags/service-b/v3.0.0", "0000000000000000000000000000000000000008"),
+	}
+
+	tests := []struct {
+		name      string
+		tagName   string
+		want      string
+		wantError error
+	}{
+		{name: "Latest", tagName: "latest", want: "v2.0.0-rc.1"},
+		{name: "Latest minus one", tagName: "latest-1", want: "v1.10.0"},
+		{name: "Latest minus three", tagName: "latest-3", want: "v1.0.0"},
+		{name: "Plain tag name is unchanged", tagName: "nightly", want: "nightly"},
+		{name: "Offset out of range", tagName: "latest-4", wantError: ErrInvalidTagOffset},
+		{name: "Malformed offset", tagName: "latest-x", wantError: ErrInvalidTagOffset},
+		{name: "Latest in a namespace", tagName: "service-a/latest", want: "service-a/v1.10.0"},
+		{name: "Latest minus one in a namespace", tagName: "service-a/latest-1", want: "service-a/v1.2.0"},
+		{name: "Offset out of range in a namespace", tagName: "service-b/latest-1", wantError: ErrInvalidTagOffset},
+		{name: "Branch without namespaced tags is unchanged", tagName: "feature/latest", want: "feature/latest"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().FetchAllTags().Return(tags, nil).AnyTimes()
+
+			got, err := ResolveTagOffset(mockRepo, tt.tagName, SortBySemver)
+			if tt.wantError != nil {
+				if !errors.Is(err, tt.wantError) {
+					t.Errorf("ResolveTagOffset() error = %v, want %v", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ResolveTagOffset() error = %v, want nil", err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveTagOffset() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestSortTagsByDate tests ordering tags by the commit date they point to
+func TestSortTagsByDate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	older := plumbing.NewReferenceFromStrings("refs/tags/b-older", "0000000000000000000000000000000000000001")
+	newer := plumbing.NewReferenceFromStrings("refs/tags/a-newer", "0000000000000000000000000000000000000002")
+
+	base := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().GetTagCommit(older).Return(&object.Commit{Committer: object.Signature{When: base}}, nil)
+	mockRepo.EXPECT().GetTagCommit(newer).Return(&object.Commit{Committer: object.Signature{When: base.Add(time.Hour)}}, nil)
+
+	sorted, err := SortTags(mockRepo, []*plumbing.Reference{older, newer}, SortByDate)
+	if err != nil {
+		t.Fatalf("SortTags() error = %v, want nil", err)
+	}
+	if sorted[0].Name().Short() != "a-newer" || sorted[1].Name().Short() != "b-older" {
+		t.Errorf("SortTags() = [%s, %s], want [a-newer, b-older]", sorted[0].Name().Short(), sorted[1].Name().Short())
+	}
+}
+
+// TestParseSortStrategy tests parsing of the -sort flag value
+func TestParseSortStrategy(t *testing.T) {
+	if _, err := ParseSortStrategy("semver"); err != nil {
+		t.Errorf("ParseSortStrategy(semver) error = %v, want nil", err)
+	}
+	if _, err := ParseSortStrategy("date"); err != nil {
+		t.Errorf("ParseSortStrategy(date) error = %v, want nil", err)
+	}
+	if _, err := ParseSortStrategy("alphabetical"); !errors.Is(err, ErrInvalidSortStrategy) {
+		t.Errorf("ParseSortStrategy(alphabetical) error = %v, want %v", err, ErrInvalidSortStrategy)
+	}
+}
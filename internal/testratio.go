@@ -0,0 +1,146 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+var (
+	ErrInvalidTestPattern = errors.New("invalid test file pattern")
+	ErrTestRatio          = errors.New("failed to compute test change ratio")
+)
+
+// defaultTestPatterns identify test files across common languages.
+// Patterns ending in "/" match a directory anywhere in the path; others match the file name.
+var defaultTestPatterns = []string{
+	"*_test.go",
+	"test_*.py",
+	"*_test.py",
+	"*.test.*",
+	"*.spec.*",
+	"*Test.java",
+	"*Tests.java",
+	"*Test.kt",
+	"*_spec.rb",
+	"test/",
+	"tests/",
+	"__tests__/",
+	"spec/",
+	"testdata/",
+}
+
+// TestPatterns classifies changed files as test or non-test files
+type TestPatterns []string
+
+// ParseTestPatterns converts a comma-separated flag value into TestPatterns.
+// An empty value selects the default patterns.
+func ParseTestPatterns(value string) (TestPatterns, error) {
+	if strings.TrimSpace(value) == "" {
+		return TestPatterns(defaultTestPatterns), nil
+	}
+
+	var patterns TestPatterns
+	for _, pattern := range strings.Split(value, ",") {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		if _, err := path.Match(strings.TrimSuffix(pattern, "/"), ""); err != nil {
+			return nil, errors.Join(ErrInvalidTestPattern, fmt.Errorf("bad pattern %q: %w", pattern, err))
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
+
+// IsTestFile reports whether a repository-relative file path matches any of the patterns
+func (p TestPatterns) IsTestFile(filePath string) bool {
+	parts := strings.Split(filePath, "/")
+	dirs, name := parts[:len(parts)-1], parts[len(parts)-1]
+
+	for _, pattern := range p {
+		if dirPattern, ok := strings.CutSuffix(pattern, "/"); ok {
+			for _, dir := range dirs {
+				if matched, _ := path.Match(dirPattern, dir); matched {
+					return true
+				}
+			}
+			continue
+		}
+		if matched, _ := path.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// TestChangeStats summarizes how much of the change in a set of commits is test code
+type TestChangeStats struct {
+	Commits          int `json:"commits"`
+	CommitsWithTests int `json:"commits_with_tests"` // Commits changing at least one test file
+	TestFiles        int `json:"test_files"`         // File changes matching the test patterns, summed over commits
+	CodeFiles        int `json:"code_files"`         // All other file changes, summed over commits
+}
+
+// Ratio returns test file changes per non-test file change, or 0 when no non-test files changed
+func (s TestChangeStats) Ratio() float64 {
+	if s.CodeFiles == 0 {
+		return 0
+	}
+	return float64(s.TestFiles) / float64(s.CodeFiles)
+}
+
+// String formats the stats for console output
+func (s TestChangeStats) String() string {
+	if s.CodeFiles == 0 {
+		return fmt.Sprintf("%d test / %d code file changes, %d of %d commits touch tests", s.TestFiles, s.CodeFiles, s.CommitsWithTests, s.Commits)
+	}
+	return fmt.Sprintf("%d test / %d code file changes (ratio %.2f), %d of %d commits touch tests", s.TestFiles, s.CodeFiles, s.Ratio(), s.CommitsWithTests, s.Commits)
+}
+
+// ComputeTestChangeStats classifies the files changed by each commit of the set.
+// Files outside the directory filter are ignored.
+func ComputeTestChangeStats(ctx context.Context, repo Repository, commitSet map[plumbing.Hash]struct{}, filter DirectoryFilter, patterns TestPatterns) (TestChangeStats, error) {
+	var stats TestChangeStats
+
+	err := repo.StreamCommitFiles(ctx, hashesOf(commitSet), func(commit *object.Commit, files []string) error {
+		stats.Commits++
+		touchesTests := false
+		for _, file := range files {
+			if !filter.Match(file) {
+				continue
+			}
+			if patterns.IsTestFile(file) {
+				stats.TestFiles++
+				touchesTests = true
+			} else {
+				stats.CodeFiles++
+			}
+		}
+		if touchesTests {
+			stats.CommitsWithTests++
+		}
+		return nil
+	})
+	if err != nil {
+		return stats, errors.Join(ErrTestRatio, err)
+	}
+	return stats, nil
+}
+
+// printTestChangeStats prints the test change ratio of the commits unique to each tag
+func printTestChangeStats(result CompareResult) {
+	if result.Tag1Tests == nil || result.Tag2Tests == nil {
+		return
+	}
+
+	fmt.Printf("\nTest changes among unique commits:\n")
+	fmt.Printf("  [%s]: %s\n", result.Config.Tag1Name, *result.Tag1Tests)
+	fmt.Printf("  [%s]: %s\n", result.Config.Tag2Name, *result.Tag2Tests)
+}
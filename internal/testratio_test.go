@@ -0,0 +1,117 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestIsTestFile tests classifying changed files with the default and custom patterns
+func TestIsTestFile(t *testing.T) {
+	custom, err := ParseTestPatterns("*.it.ts, e2e/")
+	if err != nil {
+		t.Fatalf("ParseTestPatterns() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		patterns TestPatterns
+		path     string
+		want     bool
+	}{
+		{name: "Go test", patterns: defaultTestPatterns, path: "internal/cli_test.go", want: true},
+		{name: "Go source", patterns: defaultTestPatterns, path: "internal/cli.go", want: false},
+		{name: "JavaScript spec", patterns: defaultTestPatterns, path: "web/app.spec.ts", want: true},
+		{name: "Test directory", patterns: defaultTestPatterns, path: "tests/fixtures/data.json", want: true},
+		{name: "Test-like file name", patterns: defaultTestPatterns, path: "internal/testutil.go", want: false},
+		{name: "Custom file pattern", patterns: custom, path: "web/login.it.ts", want: true},
+		{name: "Custom directory pattern", patterns: custom, path: "e2e/login.ts", want: true},
+		{name: "Default pattern not in custom", patterns: custom, path: "internal/cli_test.go", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.patterns.IsTestFile(tt.path); got != tt.want {
+				t.Errorf("IsTestFile(%q) = %v, want %v", tt.path, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestParseTestPatterns tests parsing of the -test-patterns flag
+func TestParseTestPatterns(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     string
+		wantLen   int
+		wantError error
+	}{
+		{name: "Empty selects defaults", value: "", wantLen: len(defaultTestPatterns)},
+		{name: "Custom", value: "*_test.go,testdata/", wantLen: 2},
+		{name: "Malformed", value: "[", wantError: ErrInvalidTestPattern},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTestPatterns(tt.value)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("ParseTestPatterns() error = %v, want %v", err, tt.wantError)
+			}
+			if len(got) != tt.wantLen {
+				t.Errorf("ParseTestPatterns() = %v, want %d patterns", got, tt.wantLen)
+			}
+		})
+	}
+}
+
+// TestComputeTestChangeStats tests counting test and non-test file changes of a commit set
+func TestComputeTestChangeStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash1 := plumbing.NewHash("0000000000000000000000000000000000000001")
+	hash2 := plumbing.NewHash("0000000000000000000000000000000000000002")
+	files := map[plumbing.Hash][]string{
+		hash1: {"api/handler.go", "api/handler_test.go", "README.md"},
+		hash2: {"api/router.go", "web/app.spec.ts"},
+	}
+
+	mockRepo := mocks.NewMockRepository(ctrl)
+	mockRepo.EXPECT().StreamCommitFiles(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, hashes []plumbing.Hash, visit func(commit *object.Commit, files []string) error) error {
+			for _, hash := range hashes {
+				if err := visit(&object.Commit{Hash: hash}, files[hash]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}).Times(2)
+
+	commitSet := map[plumbing.Hash]struct{}{hash1: {}, hash2: {}}
+
+	stats, err := ComputeTestChangeStats(context.Background(), mockRepo, commitSet, DirectoryFilter{}, defaultTestPatterns)
+	if err != nil {
+		t.Fatalf("ComputeTestChangeStats() error = %v", err)
+	}
+	want := TestChangeStats{Commits: 2, CommitsWithTests: 2, TestFiles: 2, CodeFiles: 3}
+	if stats != want {
+		t.Errorf("ComputeTestChangeStats() = %+v, want %+v", stats, want)
+	}
+
+	stats, err = ComputeTestChangeStats(context.Background(), mockRepo, commitSet, DirectoryFilter{Include: []string{"api"}}, defaultTestPatterns)
+	if err != nil {
+		t.Fatalf("ComputeTestChangeStats() error = %v", err)
+	}
+	want = TestChangeStats{Commits: 2, CommitsWithTests: 1, TestFiles: 1, CodeFiles: 2}
+	if stats != want {
+		t.Errorf("ComputeTestChangeStats() with directory = %+v, want %+v", stats, want)
+	}
+	if stats.Ratio() != 0.5 {
+		t.Errorf("Ratio() = %v, want 0.5", stats.Ratio())
+	}
+}
@@ -0,0 +1,63 @@
+package internal
+
+import (
+	"fmt"
+	"io"
+	"time"
+)
+
+// PhaseTiming is how long one phase of a run took
+type PhaseTiming struct {
+	Phase   string  `json:"phase"`
+	Seconds float64 `json:"seconds"`
+}
+
+// Timings records how long each phase of a run took, in the order the phases ran.
+// A nil *Timings records nothing, so phases can be timed unconditionally.
+type Timings struct {
+	Phases []PhaseTiming
+}
+
+// record adds a phase that started at start and ended now
+func (t *Timings) record(phase string, start time.Time) {
+	if t == nil {
+		return
+	}
+	t.Phases = append(t.Phases, PhaseTiming{Phase: phase, Seconds: time.Since(start).Seconds()})
+}
+
+// Total returns the summed duration of all phases in seconds
+func (t *Timings) Total() float64 {
+	if t == nil {
+		return 0
+	}
+
+	var total float64
+	for _, phase := range t.Phases {
+		total += phase.Seconds
+	}
+	return total
+}
+
+// PrintTimings prints each phase with its duration and share of the total
+func PrintTimings(w io.Writer, timings *Timings) {
+	if timings == nil || len(timings.Phases) == 0 {
+		return
+	}
+
+	width := len("total")
+	for _, phase := range timings.Phases {
+		width = max(width, len(phase.Phase))
+	}
+
+	total := timings.Total()
+	_, _ = fmt.Fprintf(w, "\nTimings:\n")
+	for _, phase := range timings.Phases {
+		share := 0.0
+		if total > 0 {
+			share = phase.Seconds / total * 100
+		}
+		_, _ = fmt.Fprintf(w, "  %-*s  %9.3fs  %5.1f%%\n", width, phase.Phase, phase.Seconds, share)
+	}
+	_, _ = fmt.Fprintf(w, "  %-*s  %9.3fs\n", width, "total", total)
+}
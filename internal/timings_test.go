@@ -0,0 +1,40 @@
+package internal
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestTimings tests recording phases and printing their durations and shares
+func TestTimings(t *testing.T) {
+	var missing *Timings
+	missing.record("ignored", time.Now())
+	if missing.Total() != 0 {
+		t.Errorf("Total() of nil timings = %v, want 0", missing.Total())
+	}
+
+	timings := &Timings{}
+	timings.record("open repository", time.Now())
+	if len(timings.Phases) != 1 || timings.Phases[0].Phase != "open repository" || timings.Phases[0].Seconds < 0 {
+		t.Fatalf("record() phases = %+v", timings.Phases)
+	}
+
+	timings.Phases = []PhaseTiming{{Phase: "traverse v1.0.0", Seconds: 1.5}, {Phase: "diff stat", Seconds: 0.5}}
+	if timings.Total() != 2 {
+		t.Errorf("Total() = %v, want 2", timings.Total())
+	}
+
+	var buf bytes.Buffer
+	PrintTimings(&buf, timings)
+	for _, want := range []string{
+		"  traverse v1.0.0      1.500s   75.0%",
+		"  diff stat            0.500s   25.0%",
+		"  total                2.000s",
+	} {
+		if !strings.Contains(buf.String(), want) {
+			t.Errorf("PrintTimings() output missing %q:\n%s", want, buf.String())
+		}
+	}
+}
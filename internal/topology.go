@@ -0,0 +1,61 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrClassifyTopology = errors.New("failed to classify commit topology")
+
+// CommitTopology tells where a commit unique to a tag sits in the tag's history
+type CommitTopology string
+
+const (
+	// TopologyMainline commits are on the first-parent line of the tag: made directly on the release
+	// line, or the merges that brought side branches into it
+	TopologyMainline CommitTopology = "mainline"
+	// TopologySideBranch commits are only reachable through a merge, e.g. the commits of a merged feature branch
+	TopologySideBranch CommitTopology = "side-branch"
+)
+
+// ClassifyTopology returns the commits of uniqueCommits that are on the first-parent line of ref.
+// The remaining unique commits were brought in by merges of side branches.
+func ClassifyTopology(ctx context.Context, repo Repository, ref *plumbing.Reference, uniqueCommits map[plumbing.Hash]struct{}) (map[plumbing.Hash]struct{}, error) {
+	mainline := make(map[plumbing.Hash]struct{})
+	if len(uniqueCommits) == 0 {
+		return mainline, nil
+	}
+
+	firstParents, err := repo.GetFirstParentCommitSet(ctx, ref, "")
+	if err != nil {
+		return nil, errors.Join(ErrClassifyTopology, err)
+	}
+	for hash := range uniqueCommits {
+		if _, ok := firstParents[hash]; ok {
+			mainline[hash] = struct{}{}
+		}
+	}
+	return mainline, nil
+}
+
+// commitTopology returns the topology of a commit, or "" when the topology was not classified
+func commitTopology(mainline map[plumbing.Hash]struct{}, hash plumbing.Hash) CommitTopology {
+	if mainline == nil {
+		return ""
+	}
+	if _, ok := mainline[hash]; ok {
+		return TopologyMainline
+	}
+	return TopologySideBranch
+}
+
+// formatTopologyCounts describes how many unique commits are on the mainline and in merged side branches
+func formatTopologyCounts(unique int, mainline map[plumbing.Hash]struct{}) string {
+	if mainline == nil || unique == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%d on the first-parent line, %d in merged side branches)", len(mainline), unique-len(mainline))
+}
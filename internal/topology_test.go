@@ -0,0 +1,59 @@
+package internal
+
+import (
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestClassifyTopology tests telling mainline commits from commits of merged side branches
+func TestClassifyTopology(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Branch("feature").
+		Checkout("feature").
+		Commit("Add feature", testutil.File("feature.go", "package a\n")).
+		Tag("feature-start").
+		Commit("Test feature", testutil.File("feature_test.go", "package a\n")).
+		Checkout("main").
+		Commit("Hotfix", testutil.File("a.go", "package a\n\n// fixed\n")).
+		Tag("hotfix").
+		Merge("feature", "Merge branch 'feature'").
+		Tag("v1.1.0")
+
+	result, err := Compare(CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+
+	tests := []struct {
+		revision string
+		want     CommitTopology
+	}{
+		{revision: "hotfix", want: TopologyMainline},
+		{revision: "main", want: TopologyMainline},
+		{revision: "feature", want: TopologySideBranch},
+		{revision: "feature-start", want: TopologySideBranch},
+	}
+	for _, tt := range tests {
+		if got := commitTopology(result.Tag2Mainline, fixture.Hash(tt.revision)); got != tt.want {
+			t.Errorf("topology of %s = %q, want %q", tt.revision, got, tt.want)
+		}
+	}
+	if got := formatTopologyCounts(len(result.OnlyInTag2), result.Tag2Mainline); got != " (2 on the first-parent line, 2 in merged side branches)" {
+		t.Errorf("formatTopologyCounts() = %q", got)
+	}
+	if len(result.Tag1Mainline) != 0 {
+		t.Errorf("Tag1Mainline = %v, want empty without unique commits", result.Tag1Mainline)
+	}
+
+	// First-parent comparisons count only the first-parent line and are not classified
+	result, err = Compare(CompareConfig{TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"}, FirstParent: true})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Tag2Mainline != nil {
+		t.Errorf("Tag2Mainline = %v, want nil with FirstParent", result.Tag2Mainline)
+	}
+}
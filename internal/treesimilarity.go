@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var ErrTreeSimilarity = errors.New("failed to compute tree similarity")
+
+// treeFile is a file of a tag's tree: its path and the hash of its content
+type treeFile struct {
+	path string
+	blob plumbing.Hash
+}
+
+// TreeSimilarity computes the Jaccard similarity of the files in the trees of two tags, ignoring history.
+// A file counts as shared when both trees hold the same content at the same path, so tags whose history
+// was rewritten but whose content is identical score 1. Only files passing the filter are compared.
+func TreeSimilarity(ctx context.Context, repo Repository, tag1 *plumbing.Reference, tag2 *plumbing.Reference, filter DirectoryFilter) (float64, error) {
+	files1, err := treeFiles(ctx, repo, tag1, filter)
+	if err != nil {
+		return 0, err
+	}
+	files2, err := treeFiles(ctx, repo, tag2, filter)
+	if err != nil {
+		return 0, err
+	}
+	return CalculateJaccardSimilarity(files1, files2), nil
+}
+
+// treeFiles collects the files of a tag's tree that pass the filter
+func treeFiles(ctx context.Context, repo Repository, ref *plumbing.Reference, filter DirectoryFilter) (map[treeFile]struct{}, error) {
+	hashes, err := repo.GetFileHashes(ctx, ref)
+	if err != nil {
+		return nil, errors.Join(ErrTreeSimilarity, err)
+	}
+
+	files := make(map[treeFile]struct{}, len(hashes))
+	for path, blob := range hashes {
+		if filter.Match(path) {
+			files[treeFile{path: path, blob: blob}] = struct{}{}
+		}
+	}
+	return files, nil
+}
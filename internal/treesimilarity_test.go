@@ -0,0 +1,74 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestCompare_FilesMetric tests that the file similarity compares tree content regardless of history
+func TestCompare_FilesMetric(t *testing.T) {
+	// The same content is committed on two unrelated roots; v1.1.0 then modifies one file and adds another
+	fixture := testutil.NewRepo(t).
+		Commit("Add API", testutil.File("src/api/a.go", "package api\n"), testutil.File("docs/README.md", "# API\n")).
+		Tag("v1.0.0").
+		Orphan("imported").
+		Commit("Import", testutil.File("src/api/a.go", "package api\n"), testutil.File("docs/README.md", "# API\n")).
+		Tag("v1.0.0-imported").
+		Checkout("main").
+		Commit("Change API", testutil.File("src/api/a.go", "package api\n\nconst V = 2\n"), testutil.File("src/api/b.go", "package api\n")).
+		Tag("v1.1.0")
+
+	tests := []struct {
+		name       string
+		tag2       string
+		directory  string
+		similarity float64
+		files      float64
+	}{
+		{name: "Rewritten history, same content", tag2: "v1.0.0-imported", similarity: 0, files: 1},
+		{name: "Modified and added files", tag2: "v1.1.0", similarity: 0.5, files: 1.0 / 4.0},
+		{name: "Directory filter", tag2: "v1.1.0", directory: "docs", similarity: 1, files: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := Compare(CompareConfig{
+				TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: tt.tag2},
+				Directory:  tt.directory,
+				Metric:     MetricFiles,
+			})
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+			if result.Similarity != tt.similarity {
+				t.Errorf("Similarity = %v, want %v", result.Similarity, tt.similarity)
+			}
+			if result.TreeSimilarity == nil || *result.TreeSimilarity != tt.files {
+				t.Fatalf("TreeSimilarity = %v, want %v", result.TreeSimilarity, tt.files)
+			}
+		})
+	}
+
+	result, err := Compare(CompareConfig{
+		TagOptions: TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.0.0-imported"},
+		Metric:     MetricFiles,
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	saved, err := NewSavedResult(context.Background(), result)
+	if err != nil {
+		t.Fatalf("NewSavedResult() error = %v", err)
+	}
+	var buf bytes.Buffer
+	if err := WriteMarkdownReport(&buf, saved, ReportTemplateOptions{}); err != nil {
+		t.Fatalf("WriteMarkdownReport() error = %v", err)
+	}
+	if want := "| File similarity (tree content) | 100.00% |"; !strings.Contains(buf.String(), want) {
+		t.Errorf("WriteMarkdownReport() output missing %q\n%s", want, buf.String())
+	}
+}
@@ -0,0 +1,535 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"golang.org/x/term"
+)
+
+var ErrNotTerminal = errors.New("tui requires an interactive terminal")
+
+// TUIConfig holds the configuration of the tui command
+type TUIConfig struct {
+	Command   Command
+	RepoPath  string
+	Directory string
+	Paths     DirectoryFilter // Several directories, globs, or exclusions, in addition to Directory
+	SortBy    SortStrategy
+}
+
+// tuiUsage is the help of the tui command
+var tuiUsage = commandUsage{
+	Name:        "tui",
+	Summary:     "Pick two tags and browse their shared and unique commits interactively",
+	Description: "List the tags of the repository, pick two with the arrow keys and Enter, and browse the shared\ncommits and the commits only in either tag. Enter on a commit shows its details, Tab switches\nlists, Esc goes back, and q quits.",
+	Examples: []string{
+		"tui -repo /path/to/repo",
+		"tui -repo /path/to/repo -d src/api -sort semver",
+	},
+}
+
+// NewTUIConfig parses the tui command flags
+func NewTUIConfig(args []string) (TUIConfig, error) {
+	config := TUIConfig{Command: TUICommand}
+	var sortBy string
+
+	tuiCmd := newCommandFlagSet(tuiUsage)
+	tuiCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	config.Paths.registerFlags(tuiCmd, "Directory path to filter commits (only commits touching this directory)")
+	tuiCmd.StringVar(&sortBy, "sort", string(SortByDate), "Order of the tag list, newest first (date, or semver followed by the other tags by name)")
+
+	if err := tuiCmd.Parse(args); err != nil {
+		return config, err
+	}
+	config.Directory, config.Paths = splitDirectoryFilter(config.Paths)
+
+	strategy, err := ParseSortStrategy(sortBy)
+	if err != nil {
+		return config, err
+	}
+	config.SortBy = strategy
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *TUIConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+
+	if _, err := cleanDirectory(c.Directory); err != nil {
+		return err
+	}
+	return c.Paths.Validate()
+}
+
+// RunTUI lists the tags of the repository and runs the interactive browser until the user quits
+func RunTUI(ctx context.Context, config TUIConfig) error {
+	if err := config.Validate(); err != nil {
+		return errors.Join(ErrInvalidConfiguration, err)
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) || !term.IsTerminal(int(os.Stdout.Fd())) {
+		return ErrNotTerminal
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return errors.Join(ErrOpenRepository, err)
+	}
+	repo.enableCommitSetCache()
+	tags, err := pickerTags(repo, config.SortBy)
+	if err != nil {
+		return err
+	}
+	if len(tags) < 2 {
+		return errors.Join(ErrTooFewTags, fmt.Errorf("the repository has %d tags", len(tags)))
+	}
+
+	model := newTUIModel(tags, func(tag1 string, tag2 string) (tuiResult, error) {
+		return compareForTUI(ctx, config, tag1, tag2)
+	}, func(hash plumbing.Hash) ([]string, error) {
+		return commitDetails(repo, hash)
+	})
+
+	state, err := term.MakeRaw(int(os.Stdin.Fd()))
+	if err != nil {
+		return errors.Join(ErrNotTerminal, err)
+	}
+	defer func() { _ = term.Restore(int(os.Stdin.Fd()), state) }()
+
+	// Draw on the alternate screen with the cursor hidden, restoring both on exit
+	_, _ = fmt.Fprint(os.Stdout, "\x1b[?1049h\x1b[?25l")
+	defer func() { _, _ = fmt.Fprint(os.Stdout, "\x1b[?25h\x1b[?1049l") }()
+
+	input := bufio.NewReader(os.Stdin)
+	for !model.quit {
+		if err := ctx.Err(); err != nil {
+			return errors.Join(ErrCanceled, err)
+		}
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		model.resize(width, height)
+		_, _ = fmt.Fprint(os.Stdout, "\x1b[H\x1b[2J"+strings.Join(model.render(), "\r\n"))
+
+		key, err := readKey(input)
+		if err != nil {
+			return err
+		}
+		model.handleKey(key)
+	}
+	return nil
+}
+
+// pickerTags returns the tag names of the repository, newest first. The semver order lists the tags that
+// are not semantic versions after the releases, by name, so that every tag can be picked.
+func pickerTags(repo Repository, strategy SortStrategy) ([]string, error) {
+	refs, err := repo.FetchAllTags()
+	if err != nil {
+		return nil, errors.Join(ErrFetchTags, err)
+	}
+	sorted, err := SortTags(repo, refs, strategy)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(refs))
+	for _, ref := range sorted {
+		names = append(names, ref.Name().Short())
+	}
+	var others []string
+	for _, ref := range refs {
+		if name := ref.Name().Short(); !slices.Contains(names, name) {
+			others = append(others, name)
+		}
+	}
+	slices.Sort(others)
+	return append(names, others...), nil
+}
+
+// tuiResult holds the lists of a comparison browsed in the tui
+type tuiResult struct {
+	Similarity float64
+	Lists      [3][]CommitInfo // Shared commits, commits only in the first tag, commits only in the second tag
+}
+
+// compareForTUI compares two tags with the sets-only comparison of get and loads the commits of each list
+func compareForTUI(ctx context.Context, config TUIConfig, tag1 string, tag2 string) (tuiResult, error) {
+	compared, err := CompareContext(ctx, CompareConfig{
+		TagOptions: TagOptions{RepoPath: config.RepoPath, Tag1Name: tag1, Tag2Name: tag2, SortBy: config.SortBy},
+		Directory:  config.Directory,
+		Paths:      config.Paths,
+		SetsOnly:   true,
+	})
+	if err != nil {
+		return tuiResult{}, err
+	}
+
+	result := tuiResult{Similarity: compared.Similarity}
+	for i, set := range []map[plumbing.Hash]struct{}{compared.SharedCommits, compared.OnlyInTag1, compared.OnlyInTag2} {
+		if result.Lists[i], err = loadCommitInfos(compared.Repo, set, nil); err != nil {
+			return tuiResult{}, errors.Join(ErrGetCommits, err)
+		}
+	}
+	return result, nil
+}
+
+// commitDetails describes a commit for the details screen: header fields followed by the full message
+func commitDetails(repo Repository, hash plumbing.Hash) ([]string, error) {
+	commits, err := repo.GetCommitObjects([]plumbing.Hash{hash})
+	if err != nil {
+		return nil, errors.Join(ErrGetCommits, err)
+	}
+	commit := commits[0]
+
+	lines := []string{
+		"commit " + commit.Hash.String(),
+		fmt.Sprintf("Author:    %s <%s>", commit.Author.Name, commit.Author.Email),
+		"Date:      " + commit.Author.When.Format("2006-01-02 15:04:05 -0700"),
+	}
+	if commit.Committer.Email != commit.Author.Email || !commit.Committer.When.Equal(commit.Author.When) {
+		lines = append(lines,
+			fmt.Sprintf("Committer: %s <%s>", commit.Committer.Name, commit.Committer.Email),
+			"Committed: "+commit.Committer.When.Format("2006-01-02 15:04:05 -0700"))
+	}
+	if len(commit.ParentHashes) > 1 {
+		parents := make([]string, 0, len(commit.ParentHashes))
+		for _, parent := range commit.ParentHashes {
+			parents = append(parents, parent.String()[:7])
+		}
+		lines = append(lines, "Merge:     "+strings.Join(parents, " "))
+	}
+
+	lines = append(lines, "")
+	for _, line := range strings.Split(strings.TrimRight(commit.Message, "\n"), "\n") {
+		lines = append(lines, "    "+line)
+	}
+	return lines, nil
+}
+
+// tuiScreen is the screen the tui shows
+type tuiScreen int
+
+const (
+	screenPickTag1 tuiScreen = iota
+	screenPickTag2
+	screenResult
+	screenCommit
+)
+
+// tuiList is a scrollable list with a cursor
+type tuiList struct {
+	cursor int
+	offset int // Index of the first visible row
+}
+
+// move moves the cursor by delta rows within a list of size rows, scrolling to keep it visible in height rows
+func (l *tuiList) move(delta int, size int, height int) {
+	l.cursor = max(min(l.cursor+delta, size-1), 0)
+	l.scroll(height)
+}
+
+// scroll keeps the cursor within the height rows shown from offset
+func (l *tuiList) scroll(height int) {
+	height = max(height, 1)
+	if l.cursor < l.offset {
+		l.offset = l.cursor
+	}
+	if l.cursor >= l.offset+height {
+		l.offset = l.cursor - height + 1
+	}
+}
+
+// tuiModel is the state of the tui. It reacts to keys and renders to lines, leaving the terminal to RunTUI.
+type tuiModel struct {
+	tags    []string
+	compare func(tag1 string, tag2 string) (tuiResult, error)
+	details func(hash plumbing.Hash) ([]string, error)
+
+	screen        tuiScreen
+	width, height int
+	tag1, tag2    string
+	picker        tuiList
+	result        tuiResult
+	current       int // Index of the list shown on the result screen
+	lists         [3]tuiList
+	detail        []string
+	detailOffset  int
+	status        string // Error shown in place of the key help until the next key
+	quit          bool
+}
+
+// newTUIModel creates the model on the screen picking the first tag
+func newTUIModel(tags []string, compare func(string, string) (tuiResult, error), details func(plumbing.Hash) ([]string, error)) *tuiModel {
+	return &tuiModel{tags: tags, compare: compare, details: details, width: 80, height: 24}
+}
+
+// resize sets the terminal size; the smallest usable screen shows one row of each list
+func (m *tuiModel) resize(width int, height int) {
+	m.width, m.height = max(width, 20), max(height, 4)
+}
+
+// rows returns the number of list rows between the two header lines and the footer
+func (m *tuiModel) rows() int {
+	return m.height - 3
+}
+
+// handleKey applies a key returned by readKey
+func (m *tuiModel) handleKey(key string) {
+	m.status = ""
+	if key == "ctrl-c" || (key == "q" && m.screen != screenCommit) {
+		m.quit = true
+		return
+	}
+
+	switch m.screen {
+	case screenPickTag1, screenPickTag2:
+		m.handlePickerKey(key)
+	case screenResult:
+		m.handleResultKey(key)
+	case screenCommit:
+		m.handleCommitKey(key)
+	}
+}
+
+// moveKeys maps navigation keys to a cursor movement, given the page size
+func moveKeys(key string, page int, size int) (int, bool) {
+	switch key {
+	case "up", "k":
+		return -1, true
+	case "down", "j":
+		return 1, true
+	case "pgup":
+		return -page, true
+	case "pgdn", " ":
+		return page, true
+	case "home", "g":
+		return -size, true
+	case "end", "G":
+		return size, true
+	}
+	return 0, false
+}
+
+func (m *tuiModel) handlePickerKey(key string) {
+	if delta, ok := moveKeys(key, m.rows(), len(m.tags)); ok {
+		m.picker.move(delta, len(m.tags), m.rows())
+		return
+	}
+
+	switch key {
+	case "esc", "backspace", "left":
+		if m.screen == screenPickTag2 {
+			m.screen = screenPickTag1
+			m.picker = tuiList{cursor: slices.Index(m.tags, m.tag1)}
+			m.picker.scroll(m.rows())
+		}
+	case "enter":
+		selected := m.tags[m.picker.cursor]
+		if m.screen == screenPickTag1 {
+			m.tag1 = selected
+			m.screen = screenPickTag2
+			return
+		}
+		if selected == m.tag1 {
+			m.status = "pick a tag other than " + m.tag1
+			return
+		}
+
+		result, err := m.compare(m.tag1, selected)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		m.tag2, m.result, m.current, m.lists = selected, result, 0, [3]tuiList{}
+		m.screen = screenResult
+	}
+}
+
+func (m *tuiModel) handleResultKey(key string) {
+	list := &m.lists[m.current]
+	size := len(m.result.Lists[m.current])
+	if delta, ok := moveKeys(key, m.rows(), size); ok {
+		list.move(delta, size, m.rows())
+		return
+	}
+
+	switch key {
+	case "tab", "right", "l":
+		m.current = (m.current + 1) % len(m.lists)
+	case "shift-tab", "left", "h":
+		m.current = (m.current + len(m.lists) - 1) % len(m.lists)
+	case "esc", "backspace":
+		m.screen = screenPickTag2
+	case "enter":
+		if size == 0 {
+			return
+		}
+		hash := plumbing.NewHash(m.result.Lists[m.current][list.cursor].Hash)
+		detail, err := m.details(hash)
+		if err != nil {
+			m.status = err.Error()
+			return
+		}
+		m.detail, m.detailOffset = detail, 0
+		m.screen = screenCommit
+	}
+}
+
+func (m *tuiModel) handleCommitKey(key string) {
+	if delta, ok := moveKeys(key, m.rows(), len(m.detail)); ok {
+		// Scrolling moves the text, not a cursor; the last page stays full
+		m.detailOffset = max(min(m.detailOffset+delta, len(m.detail)-m.rows()), 0)
+		return
+	}
+	if key == "esc" || key == "backspace" || key == "enter" || key == "left" || key == "q" {
+		m.screen = screenResult
+	}
+}
+
+// render returns the lines of the screen, each fitting the width, and exactly height lines
+func (m *tuiModel) render() []string {
+	output := OutputOptions{Width: m.width, Truncate: TruncateEnd}
+	var header, subheader, help string
+	var body []string
+
+	switch m.screen {
+	case screenPickTag1, screenPickTag2:
+		header = fmt.Sprintf("Select the first tag (%d tags)", len(m.tags))
+		if m.screen == screenPickTag2 {
+			header = "Select the tag to compare with " + m.tag1
+		}
+		help = "↑/↓ move  Enter select  Esc back  q quit"
+		for i := m.picker.offset; i < min(m.picker.offset+m.rows(), len(m.tags)); i++ {
+			marker := "  "
+			if i == m.picker.cursor {
+				marker = "> "
+			}
+			suffix := ""
+			if m.screen == screenPickTag2 && m.tags[i] == m.tag1 {
+				suffix = " (first tag)"
+			}
+			body = append(body, marker+m.tags[i]+suffix)
+		}
+	case screenResult:
+		header = fmt.Sprintf("%s ↔ %s  Similarity: %.2f%%", m.tag1, m.tag2, m.result.Similarity*100)
+		tabs := make([]string, len(m.lists))
+		for i, name := range []string{"Shared", "Only in " + m.tag1, "Only in " + m.tag2} {
+			tabs[i] = fmt.Sprintf("%s (%d)", name, len(m.result.Lists[i]))
+			if i == m.current {
+				tabs[i] = "[" + tabs[i] + "]"
+			} else {
+				tabs[i] = " " + tabs[i] + " "
+			}
+		}
+		subheader = strings.TrimRight(strings.Join(tabs, " "), " ")
+		help = "↑/↓ move  Tab switch list  Enter details  Esc back  q quit"
+
+		commits, list := m.result.Lists[m.current], m.lists[m.current]
+		if len(commits) == 0 {
+			body = append(body, "  (no commits)")
+		}
+		for i := list.offset; i < min(list.offset+m.rows(), len(commits)); i++ {
+			marker := "  "
+			if i == list.cursor {
+				marker = "> "
+			}
+			commit := commits[i]
+			prefix := fmt.Sprintf("%s%s %s %s  ", marker, commit.Hash[:7], commit.Date.Format("2006-01-02"), commit.Author)
+			body = append(body, prefix+output.FitLine(commit.Subject, utf8.RuneCountInString(prefix)))
+		}
+	case screenCommit:
+		header = fmt.Sprintf("%s ↔ %s", m.tag1, m.tag2)
+		help = "↑/↓ scroll  Esc back"
+		body = m.detail[m.detailOffset:min(m.detailOffset+m.rows(), len(m.detail))]
+	}
+
+	if m.status != "" {
+		help = "! " + m.status
+	}
+	lines := append([]string{header, subheader}, body...)
+	for len(lines) < m.height-1 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, help)
+	for i, line := range lines {
+		lines[i] = output.Fit(line, m.width)
+	}
+	return lines
+}
+
+// readKey reads one key press from a terminal in raw mode and names it: "up", "down", "left", "right",
+// "pgup", "pgdn", "home", "end", "tab", "shift-tab", "enter", "esc", "backspace", "ctrl-c", or the
+// typed character. Unknown escape sequences are returned as "".
+func readKey(r *bufio.Reader) (string, error) {
+	c, _, err := r.ReadRune()
+	if err != nil {
+		return "", err
+	}
+
+	switch c {
+	case '\r', '\n':
+		return "enter", nil
+	case '\t':
+		return "tab", nil
+	case 0x7f, 0x08:
+		return "backspace", nil
+	case 0x03:
+		return "ctrl-c", nil
+	case 0x1b:
+		// A lone Esc is not followed by the rest of a sequence in the same read
+		if r.Buffered() == 0 {
+			return "esc", nil
+		}
+		return readEscapeSequence(r)
+	}
+	return string(c), nil
+}
+
+// escapeSequences names the keys sent as ESC [ or ESC O sequences by common terminals
+var escapeSequences = map[string]string{
+	"[A": "up", "[B": "down", "[C": "right", "[D": "left",
+	"OA": "up", "OB": "down", "OC": "right", "OD": "left",
+	"[H": "home", "[F": "end", "OH": "home", "OF": "end",
+	"[1~": "home", "[4~": "end", "[7~": "home", "[8~": "end",
+	"[5~": "pgup", "[6~": "pgdn", "[Z": "shift-tab",
+}
+
+// readEscapeSequence reads the rest of an escape sequence after ESC, up to its final byte
+func readEscapeSequence(r *bufio.Reader) (string, error) {
+	introducer, err := r.ReadByte()
+	if err != nil {
+		return "", err
+	}
+	if introducer != '[' && introducer != 'O' {
+		return "", nil
+	}
+
+	sequence := []byte{introducer}
+	for {
+		b, err := r.ReadByte()
+		if err != nil {
+			return "", err
+		}
+		sequence = append(sequence, b)
+		// Parameters and intermediates are 0x20-0x3f; the final byte ends the sequence
+		if b >= 0x40 && b <= 0x7e {
+			break
+		}
+	}
+	return escapeSequences[string(sequence)], nil
+}
@@ -0,0 +1,195 @@
+package internal
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestReadKey tests naming the keys sent by terminals in raw mode
+func TestReadKey(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "arrows", input: "\x1b[A\x1b[B\x1b[C\x1b[D", want: []string{"up", "down", "right", "left"}},
+		{name: "application mode arrows", input: "\x1bOA\x1bOB", want: []string{"up", "down"}},
+		{name: "paging", input: "\x1b[5~\x1b[6~\x1b[H\x1b[4~", want: []string{"pgup", "pgdn", "home", "end"}},
+		{name: "control keys", input: "\r\t\x7f\x03\x1b[Z", want: []string{"enter", "tab", "backspace", "ctrl-c", "shift-tab"}},
+		{name: "characters", input: "qjé", want: []string{"q", "j", "é"}},
+		{name: "unknown sequence", input: "\x1b[15~j", want: []string{"", "j"}},
+		{name: "lone escape", input: "\x1b", want: []string{"esc"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.input))
+			var got []string
+			for range tt.want {
+				key, err := readKey(reader)
+				if err != nil {
+					t.Fatalf("readKey() error = %v", err)
+				}
+				got = append(got, key)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("readKey() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestTUIModel creates a model over three tags whose comparison returns one commit in each list
+func newTestTUIModel(t *testing.T) (*tuiModel, *[]string) {
+	t.Helper()
+	var compared []string
+	when := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	commit := func(hash string, subject string) CommitInfo {
+		return CommitInfo{Hash: strings.Repeat(hash, len(plumbing.ZeroHash.String())), Author: "Alice", Date: when, Subject: subject}
+	}
+
+	model := newTUIModel([]string{"v2.0.0", "v1.1.0", "v1.0.0"}, func(tag1 string, tag2 string) (tuiResult, error) {
+		compared = append(compared, tag1+".."+tag2)
+		if tag2 == "v1.0.0" {
+			return tuiResult{}, errors.New("comparison failed")
+		}
+		return tuiResult{Similarity: 0.5, Lists: [3][]CommitInfo{
+			{commit("a", "Initial commit")},
+			{commit("b", "Add b endpoint")},
+			{commit("c", "Add c endpoint"), commit("d", "Fix crash")},
+		}}, nil
+	}, func(hash plumbing.Hash) ([]string, error) {
+		return []string{"commit " + hash.String(), "", "    Fix crash"}, nil
+	})
+	model.resize(80, 8)
+	return model, &compared
+}
+
+// TestTUIModel_PickAndBrowse tests picking two tags, switching lists, and opening commit details
+func TestTUIModel_PickAndBrowse(t *testing.T) {
+	model, compared := newTestTUIModel(t)
+
+	model.handleKey("enter")
+	if model.screen != screenPickTag2 || model.tag1 != "v2.0.0" {
+		t.Fatalf("after picking the first tag: screen %d, tag1 %q", model.screen, model.tag1)
+	}
+	model.handleKey("enter")
+	if model.screen != screenPickTag2 || model.status != "pick a tag other than v2.0.0" {
+		t.Errorf("picking the same tag twice: screen %d, status %q", model.screen, model.status)
+	}
+
+	model.handleKey("down")
+	model.handleKey("enter")
+	if model.screen != screenResult || !reflect.DeepEqual(*compared, []string{"v2.0.0..v1.1.0"}) {
+		t.Fatalf("after picking the second tag: screen %d, compared %v", model.screen, *compared)
+	}
+
+	lines := model.render()
+	if len(lines) != 8 {
+		t.Errorf("render() = %d lines, want the height of 8", len(lines))
+	}
+	if lines[0] != "v2.0.0 ↔ v1.1.0  Similarity: 50.00%" || !strings.HasPrefix(lines[1], "[Shared (1)]") {
+		t.Errorf("result header = %q, %q", lines[0], lines[1])
+	}
+
+	model.handleKey("shift-tab")
+	model.handleKey("end")
+	if model.current != 2 || model.lists[2].cursor != 1 {
+		t.Fatalf("after shift-tab and end: list %d, cursor %d", model.current, model.lists[2].cursor)
+	}
+	if lines := model.render(); !strings.HasPrefix(lines[3], "> dddddd") {
+		t.Errorf("cursor row = %q, want the second commit", lines[3])
+	}
+
+	model.handleKey("enter")
+	if model.screen != screenCommit || model.render()[2] != "commit "+strings.Repeat("d", len(plumbing.ZeroHash.String())) {
+		t.Errorf("details screen = %d, %q", model.screen, model.render()[2])
+	}
+	model.handleKey("q")
+	if model.screen != screenResult || model.quit {
+		t.Errorf("q on the details screen: screen %d, quit %v, want back on the result", model.screen, model.quit)
+	}
+
+	model.handleKey("esc")
+	model.handleKey("down")
+	model.handleKey("enter")
+	if model.screen != screenPickTag2 || model.status != "comparison failed" {
+		t.Errorf("failed comparison: screen %d, status %q", model.screen, model.status)
+	}
+	if footer := model.render()[7]; footer != "! comparison failed" {
+		t.Errorf("footer = %q, want the error", footer)
+	}
+
+	model.handleKey("q")
+	if !model.quit {
+		t.Error("q did not quit")
+	}
+}
+
+// TestTUIModel_Scroll tests that the picker scrolls to keep the cursor visible and lines fit the width
+func TestTUIModel_Scroll(t *testing.T) {
+	tags := make([]string, 20)
+	for i := range tags {
+		tags[i] = "release-with-a-long-name-" + strings.Repeat("x", i)
+	}
+	model := newTUIModel(tags, nil, nil)
+	model.resize(30, 6)
+
+	model.handleKey("pgdn")
+	model.handleKey("pgdn")
+	if model.picker.cursor != 6 || model.picker.offset != 4 {
+		t.Errorf("after two pages: cursor %d, offset %d, want 6, 4", model.picker.cursor, model.picker.offset)
+	}
+	model.handleKey("end")
+	model.handleKey("down")
+	if model.picker.cursor != 19 || model.picker.offset != 17 {
+		t.Errorf("at the end: cursor %d, offset %d, want 19, 17", model.picker.cursor, model.picker.offset)
+	}
+	for _, line := range model.render() {
+		if width := len([]rune(line)); width > 30 {
+			t.Errorf("line %q is %d characters wide, want at most 30", line, width)
+		}
+	}
+}
+
+// TestPickerTags tests that the semver order lists the other tags after the releases
+func TestPickerTags(t *testing.T) {
+	fixture := newReleaseFixture(t).Tag("nightly").Tag("beta")
+	repo := openFixture(t, fixture)
+
+	got, err := pickerTags(repo, SortBySemver)
+	if err != nil {
+		t.Fatalf("pickerTags() error = %v", err)
+	}
+	if want := []string{"v1.1.0", "v1.0.0", "beta", "nightly"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("pickerTags() = %v, want %v", got, want)
+	}
+}
+
+// TestCompareForTUI tests loading the shared and unique commits of a pair
+func TestCompareForTUI(t *testing.T) {
+	fixture := newReleaseFixture(t)
+
+	result, err := compareForTUI(context.Background(), TUIConfig{RepoPath: fixture.Path(), SortBy: SortBySemver}, "v1.0.0", "v1.1.0")
+	if err != nil {
+		t.Fatalf("compareForTUI() error = %v", err)
+	}
+	if len(result.Lists[0]) != 1 || len(result.Lists[1]) != 0 || len(result.Lists[2]) != 2 {
+		t.Errorf("compareForTUI() lists = %d, %d, %d commits, want 1, 0, 2", len(result.Lists[0]), len(result.Lists[1]), len(result.Lists[2]))
+	}
+
+	lines, err := commitDetails(openFixture(t, fixture), fixture.Hash("v1.1.0"))
+	if err != nil {
+		t.Fatalf("commitDetails() error = %v", err)
+	}
+	if lines[0] != "commit "+fixture.Hash("v1.1.0").String() {
+		t.Errorf("commitDetails() = %q", lines)
+	}
+}
@@ -0,0 +1,287 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+var (
+	ErrVerifyFailed      = errors.New("tag verification failed")
+	ErrInvalidTagPattern = errors.New("invalid tag pattern")
+	ErrTagNotFound       = errors.New("tag not found in repository")
+	ErrNoTagsSelected    = errors.New("no tags selected")
+)
+
+// VerifyConfig holds the configuration of the verify command
+type VerifyConfig struct {
+	Command  Command
+	RepoPath string
+	Tags     []string // Tags to verify; empty means all tags matching Pattern
+	Pattern  string   // Glob matched against tag names (e.g. "v*")
+	Branch   string   // Branch releases must be reachable from; empty selects the default branch
+	SortBy   SortStrategy
+	Output   OutputOptions
+	Timeout  time.Duration // Cancel the verification after this long; 0 never times out
+}
+
+// verifyUsage is the help of the verify command
+var verifyUsage = commandUsage{
+	Name:        "verify",
+	Summary:     "Verify that release tags are reachable and in line",
+	Description: "Verify that release tags are reachable from the default branch and that each\nrelease is an ancestor of the next one, flagging orphaned or out-of-line tags.",
+	Examples: []string{
+		"verify -repo /path/to/repo",
+		"verify -repo /path/to/repo -pattern 'v2.*' -branch release",
+		"verify -repo /path/to/repo -tags v1.0.0,v1.1.0,latest",
+	},
+}
+
+// NewVerifyConfig parses the verify command flags
+func NewVerifyConfig(args []string) (VerifyConfig, error) {
+	config := VerifyConfig{Command: VerifyCommand}
+	var tags, sortBy string
+
+	verifyCmd := newCommandFlagSet(verifyUsage)
+	verifyCmd.StringVar(&config.RepoPath, "repo", "", "Path or URL of the Git repository")
+	verifyCmd.StringVar(&tags, "tags", "", "Comma-separated tags to verify (or latest, latest-N)")
+	verifyCmd.StringVar(&config.Pattern, "pattern", "", "Verify all tags matching this glob (e.g. 'v*'); ignored when -tags is set")
+	verifyCmd.StringVar(&config.Branch, "branch", "", "Branch releases must be reachable from (default: origin/HEAD, main, or master)")
+	verifyCmd.StringVar(&sortBy, "sort", string(SortBySemver), "Release order used to find each tag's next release (semver, date)")
+	parseOutputOptions := config.Output.registerFlags(verifyCmd, "Maximum line width of the results table")
+	registerTimeoutFlag(verifyCmd, &config.Timeout, "the verification")
+
+	if err := verifyCmd.Parse(args); err != nil {
+		return config, err
+	}
+
+	for _, tag := range strings.Split(tags, ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			config.Tags = append(config.Tags, tag)
+		}
+	}
+
+	strategy, err := ParseSortStrategy(sortBy)
+	if err != nil {
+		return config, err
+	}
+	config.SortBy = strategy
+
+	if err := parseOutputOptions(); err != nil {
+		return config, err
+	}
+
+	return config, nil
+}
+
+// Validate checks if the configuration is valid
+func (c *VerifyConfig) Validate() error {
+	if c.RepoPath == "" {
+		return ErrMissingRepo
+	}
+
+	if err := validateRepoPath(c.RepoPath); err != nil {
+		return err
+	}
+
+	if c.Pattern != "" {
+		if _, err := path.Match(c.Pattern, ""); err != nil {
+			return errors.Join(ErrInvalidTagPattern, err)
+		}
+	}
+
+	if err := validateTimeout(c.Timeout); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// TagVerification is the verification outcome of a single tag
+type TagVerification struct {
+	Tag      string
+	Commit   plumbing.Hash
+	OnBranch bool   // Tag commit is reachable from the branch
+	NextTag  string // Next newer release, empty for the newest tag or tags outside the release order
+	InLine   bool   // Tag commit is an ancestor of NextTag
+}
+
+// Problems returns the reasons the tag failed verification
+func (v TagVerification) Problems() []string {
+	var problems []string
+	if !v.OnBranch {
+		problems = append(problems, "orphaned")
+	}
+	if v.NextTag != "" && !v.InLine {
+		problems = append(problems, "out-of-line")
+	}
+	return problems
+}
+
+// VerifyReport holds the verification results of all selected tags, oldest release first
+type VerifyReport struct {
+	Branch string
+	Tags   []TagVerification
+}
+
+// Verify checks the configured tags against the branch and the release order.
+// ErrVerifyFailed is returned along with the report when any tag has problems.
+func Verify(ctx context.Context, config VerifyConfig) (_ VerifyReport, err error) {
+	ctx, end := startRun(ctx, config.Timeout)
+	defer func() { err = end(err) }()
+	if err := config.Validate(); err != nil {
+		return VerifyReport{}, errors.Join(ErrInvalidConfiguration, err)
+	}
+
+	repo, err := OpenGitRepositoryContext(ctx, config.RepoPath, 0)
+	if err != nil {
+		return VerifyReport{}, errors.Join(ErrOpenRepository, err)
+	}
+
+	return verifyTags(ctx, repo, config)
+}
+
+// verifyTags runs the verification against a repository
+func verifyTags(ctx context.Context, repo Repository, config VerifyConfig) (VerifyReport, error) {
+	branch, err := repo.GetBranchReference(config.Branch)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	report := VerifyReport{Branch: branch.Name().Short()}
+
+	selected, err := selectTags(repo, config.Tags, config.Pattern, config.SortBy)
+	if err != nil {
+		return report, err
+	}
+
+	// Order releases oldest first; tags the strategy cannot order are only checked against the branch
+	sorted, err := SortTags(repo, selected, config.SortBy)
+	if err != nil {
+		return report, err
+	}
+	ordered := make(map[plumbing.ReferenceName]struct{}, len(sorted))
+	refs := make([]*plumbing.Reference, 0, len(selected))
+	for i := len(sorted) - 1; i >= 0; i-- {
+		ordered[sorted[i].Name()] = struct{}{}
+		refs = append(refs, sorted[i])
+	}
+	for _, ref := range selected {
+		if _, ok := ordered[ref.Name()]; !ok {
+			refs = append(refs, ref)
+		}
+	}
+
+	commits := make([]plumbing.Hash, len(refs))
+	for i, ref := range refs {
+		commit, err := repo.GetTagCommit(ref)
+		if err != nil {
+			return report, err
+		}
+		commits[i] = commit.Hash
+	}
+
+	failed := false
+	for i, ref := range refs {
+		verification := TagVerification{Tag: ref.Name().Short(), Commit: commits[i]}
+
+		if verification.OnBranch, err = repo.IsAncestor(ctx, commits[i], branch.Hash()); err != nil {
+			return report, err
+		}
+
+		if i+1 < len(sorted) {
+			verification.NextTag = refs[i+1].Name().Short()
+			if verification.InLine, err = repo.IsAncestor(ctx, commits[i], commits[i+1]); err != nil {
+				return report, err
+			}
+		}
+
+		if len(verification.Problems()) > 0 {
+			failed = true
+		}
+		report.Tags = append(report.Tags, verification)
+	}
+
+	if failed {
+		return report, ErrVerifyFailed
+	}
+	return report, nil
+}
+
+// selectTags returns the named tags, resolving latest and latest-N in the sort order, or all tags matching the pattern
+func selectTags(repo Repository, names []string, pattern string, sortBy SortStrategy) ([]*plumbing.Reference, error) {
+	allTags, err := repo.FetchAllTags()
+	if err != nil {
+		return nil, err
+	}
+
+	var selected []*plumbing.Reference
+	if len(names) > 0 {
+		byName := make(map[string]*plumbing.Reference, len(allTags))
+		for _, ref := range allTags {
+			byName[ref.Name().Short()] = ref
+		}
+		for _, name := range names {
+			resolved, err := ResolveTagOffset(repo, name, sortBy)
+			if err != nil {
+				return nil, err
+			}
+			ref, ok := byName[resolved]
+			if !ok {
+				return nil, errors.Join(ErrTagNotFound, fmt.Errorf("tag: %s", name))
+			}
+			selected = append(selected, ref)
+		}
+	} else {
+		for _, ref := range allTags {
+			if pattern == "" {
+				selected = append(selected, ref)
+				continue
+			}
+			if matched, _ := path.Match(pattern, ref.Name().Short()); matched {
+				selected = append(selected, ref)
+			}
+		}
+	}
+
+	if len(selected) == 0 {
+		return nil, errors.Join(ErrNoTagsSelected, fmt.Errorf("no tags match pattern: %q", pattern))
+	}
+	return selected, nil
+}
+
+// PrintVerifyReport prints one row per verified tag
+func PrintVerifyReport(w io.Writer, report VerifyReport, output OutputOptions) {
+	if len(report.Tags) == 0 {
+		return
+	}
+
+	rows := [][]string{{"TAG", "COMMIT", "ON " + report.Branch, "IN LINE WITH", "STATUS"}}
+	for _, v := range report.Tags {
+		onBranch := "yes"
+		if !v.OnBranch {
+			onBranch = "no"
+		}
+
+		inLine := "-"
+		if v.NextTag != "" {
+			inLine = "yes (" + v.NextTag + ")"
+			if !v.InLine {
+				inLine = "no (" + v.NextTag + ")"
+			}
+		}
+
+		status := "ok"
+		if problems := v.Problems(); len(problems) > 0 {
+			status = strings.Join(problems, ", ")
+		}
+
+		rows = append(rows, []string{v.Tag, v.Commit.String()[:7], onBranch, inLine, status})
+	}
+	writeTable(w, output, 0, rows)
+}
@@ -0,0 +1,110 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/mocks"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"go.uber.org/mock/gomock"
+)
+
+// TestNewVerifyConfig tests the verify config creation
+func TestNewVerifyConfig(t *testing.T) {
+	config, err := NewVerifyConfig([]string{"-repo", ".", "-tags", "v1.0.0, latest,", "-branch", "release"})
+	if err != nil {
+		t.Fatalf("NewVerifyConfig() error = %v, want nil", err)
+	}
+	if !reflect.DeepEqual(config.Tags, []string{"v1.0.0", "latest"}) {
+		t.Errorf("Tags = %v, want [v1.0.0 latest]", config.Tags)
+	}
+	if config.Branch != "release" || config.SortBy != SortBySemver {
+		t.Errorf("Branch = %q, SortBy = %q, want release, semver", config.Branch, config.SortBy)
+	}
+}
+
+// TestVerifyTags tests branch reachability and release-line checks
+func TestVerifyTags(t *testing.T) {
+	branch := plumbing.NewReferenceFromStrings("refs/heads/main", "00000000000000000000000000000000000000ff")
+	v100 := plumbing.NewReferenceFromStrings("refs/tags/v1.0.0", "0000000000000000000000000000000000000001")
+	v101 := plumbing.NewReferenceFromStrings("refs/tags/v1.0.1", "0000000000000000000000000000000000000002")
+	v110 := plumbing.NewReferenceFromStrings("refs/tags/v1.1.0", "0000000000000000000000000000000000000003")
+	nightly := plumbing.NewReferenceFromStrings("refs/tags/nightly", "0000000000000000000000000000000000000004")
+	tags := []*plumbing.Reference{v110, nightly, v100, v101}
+
+	// v1.0.1 was cut from an abandoned branch: not on main and not contained in v1.1.0
+	ancestors := map[[2]plumbing.Hash]bool{
+		{v100.Hash(), branch.Hash()}:    true,
+		{v101.Hash(), branch.Hash()}:    false,
+		{v110.Hash(), branch.Hash()}:    true,
+		{nightly.Hash(), branch.Hash()}: true,
+		{v100.Hash(), v101.Hash()}:      true,
+		{v101.Hash(), v110.Hash()}:      false,
+		{v100.Hash(), v110.Hash()}:      true,
+	}
+
+	tests := []struct {
+		name      string
+		config    VerifyConfig
+		want      []TagVerification
+		wantError error
+	}{
+		{
+			name:   "All tags",
+			config: VerifyConfig{SortBy: SortBySemver},
+			want: []TagVerification{
+				{Tag: "v1.0.0", Commit: v100.Hash(), OnBranch: true, NextTag: "v1.0.1", InLine: true},
+				{Tag: "v1.0.1", Commit: v101.Hash(), OnBranch: false, NextTag: "v1.1.0", InLine: false},
+				{Tag: "v1.1.0", Commit: v110.Hash(), OnBranch: true},
+				{Tag: "nightly", Commit: nightly.Hash(), OnBranch: true},
+			},
+			wantError: ErrVerifyFailed,
+		},
+		{
+			name:   "Explicit tags skip the orphaned release",
+			config: VerifyConfig{Tags: []string{"latest", "v1.0.0"}, SortBy: SortBySemver},
+			want: []TagVerification{
+				{Tag: "v1.0.0", Commit: v100.Hash(), OnBranch: true, NextTag: "v1.1.0", InLine: true},
+				{Tag: "v1.1.0", Commit: v110.Hash(), OnBranch: true},
+			},
+		},
+		{
+			name:      "Pattern matching nothing",
+			config:    VerifyConfig{Pattern: "v9.*", SortBy: SortBySemver},
+			wantError: ErrNoTagsSelected,
+		},
+		{
+			name:      "Unknown tag",
+			config:    VerifyConfig{Tags: []string{"v9.0.0"}, SortBy: SortBySemver},
+			wantError: ErrTagNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockRepo := mocks.NewMockRepository(ctrl)
+			mockRepo.EXPECT().GetBranchReference("").Return(branch, nil)
+			mockRepo.EXPECT().FetchAllTags().Return(tags, nil).AnyTimes()
+			mockRepo.EXPECT().GetTagCommit(gomock.Any()).DoAndReturn(func(ref *plumbing.Reference) (*object.Commit, error) {
+				return &object.Commit{Hash: ref.Hash()}, nil
+			}).AnyTimes()
+			mockRepo.EXPECT().IsAncestor(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, ancestor plumbing.Hash, descendant plumbing.Hash) (bool, error) {
+				return ancestors[[2]plumbing.Hash{ancestor, descendant}], nil
+			}).AnyTimes()
+
+			report, err := verifyTags(context.Background(), mockRepo, tt.config)
+			if !errors.Is(err, tt.wantError) {
+				t.Fatalf("verifyTags() error = %v, want %v", err, tt.wantError)
+			}
+			if !reflect.DeepEqual(report.Tags, tt.want) {
+				t.Errorf("verifyTags() = %+v, want %+v", report.Tags, tt.want)
+			}
+		})
+	}
+}
@@ -6,20 +6,28 @@ import (
 	"runtime/debug"
 )
 
-// PrintVersion prints the version information retrieved from the binary's build info
-func PrintVersion() {
-	version := "dev"
+// versionUsage is the help of the version command
+var versionUsage = commandUsage{
+	Name:        "version",
+	Summary:     "Show version information",
+	Description: "Show the version of the binary, the Go version it was built with, and the object format it supports.",
+	Examples:    []string{"version"},
+}
 
-	// Read build info from the binary
+// ToolVersion returns the module version of the binary from its build info, or "dev" for local builds
+func ToolVersion() string {
 	if info, ok := debug.ReadBuildInfo(); ok {
-		// Get version from module
 		if info.Main.Version != "" && info.Main.Version != "(devel)" {
-			version = info.Main.Version
+			return info.Main.Version
 		}
 	}
+	return "dev"
+}
 
-	// Print version information
-	fmt.Printf("git-tag-similarity version %s\n", version)
+// PrintVersion prints the version information retrieved from the binary's build info
+func PrintVersion() {
+	fmt.Printf("git-tag-similarity version %s\n", ToolVersion())
 	fmt.Printf("  Go version: %s\n", runtime.Version())
 	fmt.Printf("  OS/Arch: %s/%s\n", runtime.GOOS, runtime.GOARCH)
+	fmt.Printf("  Object format: %s\n", BuildObjectFormat())
 }
@@ -1,9 +1,14 @@
 package main
 
 import (
+	"context"
 	"errors"
+	"fmt"
 	"log"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"github.com/byron1st/git-tag-similarity/internal"
 )
@@ -21,6 +26,12 @@ func main() {
 
 	switch command {
 	case internal.HelpCommand:
+		if len(os.Args) > 2 {
+			if err := internal.PrintCommandHelp(os.Args[2:]); err != nil {
+				log.Fatalf("Failed to show help: %v", err)
+			}
+			os.Exit(0)
+		}
 		internal.PrintUsage()
 		os.Exit(0)
 	case internal.VersionCommand:
@@ -32,15 +43,321 @@ func main() {
 			log.Fatalf("Failed to create compare config: %v", err)
 			os.Exit(1)
 		}
-		result, err := internal.Compare(config)
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		if config.GitHub != "" {
+			result, err := internal.CompareGitHub(ctx, config)
+			if err != nil {
+				log.Fatalf("Failed to compare: %v", err)
+			}
+			internal.PrintGitHubComparison(os.Stdout, result)
+			if err := result.CheckMinSimilarity(); err != nil {
+				log.Fatalf("Failed similarity threshold: %v", err)
+			}
+			os.Exit(0)
+		}
+		start := time.Now()
+		result, err := internal.CompareContext(ctx, config)
+		if config.PushgatewayURL != "" {
+			metrics := internal.NewRunMetrics(config, result, time.Since(start), err)
+			if pushErr := internal.PushMetrics(ctx, config.HTTP.Client(), config.PushgatewayURL, config.PushJob, metrics); pushErr != nil {
+				result.AddWarning("failed to push metrics: %v", pushErr)
+			}
+		}
 		if err != nil {
+			// Warnings of a canceled run mostly report the cancellation again
+			if !errors.Is(err, internal.ErrCanceled) {
+				internal.PrintWarnings(os.Stderr, result.Warnings)
+			}
 			log.Fatalf("Failed to compare: %v", err)
 			os.Exit(1)
 		}
-		internal.PrintCompareResult(result)
+		if result.Config.Format == internal.CompareFormatNDJSONCommits {
+			if err := internal.WriteCommitsNDJSON(ctx, os.Stdout, result); err != nil {
+				log.Fatalf("Failed to write commits: %v", err)
+			}
+			// Standard output carries only commit records
+			internal.PrintWarnings(os.Stderr, result.Warnings)
+		} else {
+			internal.PrintCompareResult(ctx, result)
+		}
+		if config.FileMatrixPath != "" {
+			if err := internal.WriteFileMatrix(result); err != nil {
+				log.Fatalf("Failed to export file matrix: %v", err)
+			}
+		}
+		if config.JSONPath != "" {
+			if err := internal.SaveResult(ctx, result, config.JSONPath); err != nil {
+				log.Fatalf("Failed to save result: %v", err)
+			}
+		}
+		if config.ReportPath != "" {
+			if err := internal.WriteCompareReport(ctx, result); err != nil {
+				log.Fatalf("Failed to write report: %v", err)
+			}
+		}
+		if config.AttestationPath != "" {
+			if err := internal.WriteAttestation(ctx, result); err != nil {
+				log.Fatalf("Failed to write attestation: %v", err)
+			}
+		}
+		if config.Timings {
+			internal.PrintTimings(os.Stderr, result.Timings)
+		}
+		if err := result.CheckMinSimilarity(); err != nil {
+			log.Fatalf("Failed similarity threshold: %v", err)
+		}
+		if result.Policy != nil {
+			if err := result.Policy.Err(); err != nil {
+				log.Fatalf("Failed policy check: %v", err)
+			}
+		}
+		os.Exit(0)
+	case internal.DiffCommand:
+		config, err := internal.NewDiffConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create diff config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		output, err := internal.Diff(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to diff: %v", err)
+		}
+		fmt.Print(output)
+		os.Exit(0)
+	case internal.ReportCommand:
+		if len(os.Args) > 2 && os.Args[2] == internal.ReportDiffSubcommand {
+			config, err := internal.NewReportDiffConfig(os.Args[3:])
+			if err != nil {
+				log.Fatalf("Failed to create report diff config: %v", err)
+			}
+			if err := internal.GenerateReportDiff(config); err != nil {
+				log.Fatalf("Failed to generate report diff: %v", err)
+			}
+			os.Exit(0)
+		}
+		config, err := internal.NewReportConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create report config: %v", err)
+		}
+		if err := internal.GenerateReport(config); err != nil {
+			log.Fatalf("Failed to generate report: %v", err)
+		}
+		os.Exit(0)
+	case internal.CheckCommand:
+		config, err := internal.NewCheckConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create check config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		results, err := internal.RunCheck(ctx, config)
+		internal.PrintCheckResults(os.Stdout, results, config.Output)
+		if err != nil {
+			log.Fatalf("Failed to check repository: %v", err)
+		}
+		os.Exit(0)
+	case internal.VerifyCommand:
+		config, err := internal.NewVerifyConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create verify config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		report, err := internal.Verify(ctx, config)
+		internal.PrintVerifyReport(os.Stdout, report, config.Output)
+		if err != nil {
+			log.Fatalf("Failed to verify tags: %v", err)
+		}
+		os.Exit(0)
+	case internal.HistoryCommand:
+		config, err := internal.NewHistoryConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create history config: %v", err)
+		}
+		if config.Subcommand == internal.HistoryExportSubcommand {
+			if err := internal.ExportHistory(config); err != nil {
+				log.Fatalf("Failed to export history: %v", err)
+			}
+			os.Exit(0)
+		}
+		diff, err := internal.DiffHistory(config)
+		if err != nil {
+			log.Fatalf("Failed to diff results: %v", err)
+		}
+		internal.PrintResultDiff(os.Stdout, diff, config.Output)
+		os.Exit(0)
+	case internal.ExplainZeroCommand:
+		config, err := internal.NewExplainConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create explain-zero config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		explanation, err := internal.Explain(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to explain similarity: %v", err)
+		}
+		internal.PrintExplanation(os.Stdout, explanation, config.Output)
+		os.Exit(0)
+	case internal.PatchesCommand:
+		config, err := internal.NewPatchesConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create patches config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		result, err := internal.CheckPatchSeries(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to check patch series: %v", err)
+		}
+		internal.PrintPatchSeriesResult(os.Stdout, result, config.Output)
+		os.Exit(0)
+	case internal.SnapshotCommand:
+		config, err := internal.NewSnapshotConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create snapshot config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		comparison, err := internal.CompareSnapshot(ctx, config)
+		internal.PrintSnapshotComparison(os.Stdout, comparison, config.Output)
+		if err != nil {
+			log.Fatalf("Failed to compare snapshot: %v", err)
+		}
+		os.Exit(0)
+	case internal.MatrixCommand:
+		config, err := internal.NewMatrixConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create matrix config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		matrix, err := internal.ComputeMatrix(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to compute matrix: %v", err)
+		}
+		if err := internal.PrintMatrix(os.Stdout, matrix, config.Format, config.Output); err != nil {
+			log.Fatalf("Failed to print matrix: %v", err)
+		}
+		os.Exit(0)
+	case internal.GetCommand:
+		config, err := internal.NewGetConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create get config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		value, warnings, err := internal.Get(ctx, config)
+		// Standard output carries only the value; warnings of a canceled run mostly report the cancellation again
+		if !errors.Is(err, internal.ErrCanceled) {
+			internal.PrintWarnings(os.Stderr, warnings)
+		}
+		if err != nil {
+			log.Fatalf("Failed to get %s: %v", config.Query, err)
+		}
+		fmt.Println(value)
+		os.Exit(0)
+	case internal.SuggestCommand:
+		config, err := internal.NewSuggestConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create suggest config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		report, err := internal.Suggest(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to suggest comparisons: %v", err)
+		}
+		internal.PrintSuggestions(os.Stdout, report, config.Output)
+		os.Exit(0)
+	case internal.RCAuditCommand:
+		config, err := internal.NewRCAuditConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create rc-audit config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		report, err := internal.RCAuditTags(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to audit release candidates: %v", err)
+		}
+		internal.PrintRCAudit(os.Stdout, report, config.Output)
+		os.Exit(0)
+	case internal.IndexCommand:
+		config, err := internal.NewIndexConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create index config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		stats, err := internal.IndexRepository(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to index repository: %v", err)
+		}
+		internal.PrintIndexStats(os.Stdout, stats, config)
+		os.Exit(0)
+	case internal.CacheCommand:
+		config, err := internal.NewCacheConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create cache config: %v", err)
+		}
+		ctx, cancel := runContext(0)
+		defer cancel()
+		stats, err := internal.ClearCache(ctx, config)
+		if err != nil {
+			log.Fatalf("Failed to clear cache: %v", err)
+		}
+		internal.PrintCacheClearStats(os.Stdout, stats)
+		os.Exit(0)
+	case internal.SelftestCommand:
+		config, err := internal.NewSelftestConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create selftest config: %v", err)
+		}
+		ctx, cancel := runContext(config.Timeout)
+		defer cancel()
+		results, fixture, err := internal.RunSelftest(ctx, config)
+		internal.PrintCheckResults(os.Stdout, results, config.Output)
+		if fixture != "" {
+			fmt.Printf("Fixture: %s\n", fixture)
+		}
+		if err != nil {
+			log.Fatalf("Failed to validate installation: %v", err)
+		}
+		os.Exit(0)
+	case internal.TUICommand:
+		config, err := internal.NewTUIConfig(os.Args[2:])
+		if err != nil {
+			log.Fatalf("Failed to create tui config: %v", err)
+		}
+		ctx, cancel := runContext(0)
+		defer cancel()
+		if err := internal.RunTUI(ctx, config); err != nil {
+			log.Fatalf("Failed to run tui: %v", err)
+		}
 		os.Exit(0)
 	default:
 		log.Fatalf("Unexpected command: %s", command)
 	}
 
 }
+
+// runContext returns the context of a run: canceled by Ctrl-C or SIGTERM, and after timeout unless it is 0.
+// A second Ctrl-C terminates the process at once, for work that does not watch the context.
+func runContext(timeout time.Duration) (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-ctx.Done()
+		stop()
+	}()
+	if timeout <= 0 {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
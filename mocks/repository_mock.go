@@ -10,6 +10,8 @@
 package mocks
 
 import (
+	context "context"
+	io "io"
 	reflect "reflect"
 
 	plumbing "github.com/go-git/go-git/v5/plumbing"
@@ -41,6 +43,20 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// ApproximatedDirectories mocks base method.
+func (m *MockRepository) ApproximatedDirectories() []string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ApproximatedDirectories")
+	ret0, _ := ret[0].([]string)
+	return ret0
+}
+
+// ApproximatedDirectories indicates an expected call of ApproximatedDirectories.
+func (mr *MockRepositoryMockRecorder) ApproximatedDirectories() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ApproximatedDirectories", reflect.TypeOf((*MockRepository)(nil).ApproximatedDirectories))
+}
+
 // FetchAllTags mocks base method.
 func (m *MockRepository) FetchAllTags() ([]*plumbing.Reference, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +72,80 @@ func (mr *MockRepositoryMockRecorder) FetchAllTags() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchAllTags", reflect.TypeOf((*MockRepository)(nil).FetchAllTags))
 }
 
+// ForEachCommitInTag mocks base method.
+func (m *MockRepository) ForEachCommitInTag(ctx context.Context, ref *plumbing.Reference, fn func(plumbing.Hash) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ForEachCommitInTag", ctx, ref, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ForEachCommitInTag indicates an expected call of ForEachCommitInTag.
+func (mr *MockRepositoryMockRecorder) ForEachCommitInTag(ctx, ref, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ForEachCommitInTag", reflect.TypeOf((*MockRepository)(nil).ForEachCommitInTag), ctx, ref, fn)
+}
+
+// GetBlob mocks base method.
+func (m *MockRepository) GetBlob(hash plumbing.Hash) ([]byte, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBlob", hash)
+	ret0, _ := ret[0].([]byte)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBlob indicates an expected call of GetBlob.
+func (mr *MockRepositoryMockRecorder) GetBlob(hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBlob", reflect.TypeOf((*MockRepository)(nil).GetBlob), hash)
+}
+
+// GetBranchReference mocks base method.
+func (m *MockRepository) GetBranchReference(name string) (*plumbing.Reference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranchReference", name)
+	ret0, _ := ret[0].(*plumbing.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBranchReference indicates an expected call of GetBranchReference.
+func (mr *MockRepositoryMockRecorder) GetBranchReference(name any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchReference", reflect.TypeOf((*MockRepository)(nil).GetBranchReference), name)
+}
+
+// GetBranchesContaining mocks base method.
+func (m *MockRepository) GetBranchesContaining(ctx context.Context, hash plumbing.Hash) ([]*plumbing.Reference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranchesContaining", ctx, hash)
+	ret0, _ := ret[0].([]*plumbing.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBranchesContaining indicates an expected call of GetBranchesContaining.
+func (mr *MockRepositoryMockRecorder) GetBranchesContaining(ctx, hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchesContaining", reflect.TypeOf((*MockRepository)(nil).GetBranchesContaining), ctx, hash)
+}
+
+// GetBranchesPointingAt mocks base method.
+func (m *MockRepository) GetBranchesPointingAt(hash plumbing.Hash) ([]*plumbing.Reference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBranchesPointingAt", hash)
+	ret0, _ := ret[0].([]*plumbing.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBranchesPointingAt indicates an expected call of GetBranchesPointingAt.
+func (mr *MockRepositoryMockRecorder) GetBranchesPointingAt(hash any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBranchesPointingAt", reflect.TypeOf((*MockRepository)(nil).GetBranchesPointingAt), hash)
+}
+
 // GetCommitObject mocks base method.
 func (m *MockRepository) GetCommitObject(hash plumbing.Hash) (*object.Commit, error) {
 	m.ctrl.T.Helper()
@@ -71,47 +161,400 @@ func (mr *MockRepositoryMockRecorder) GetCommitObject(hash any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitObject", reflect.TypeOf((*MockRepository)(nil).GetCommitObject), hash)
 }
 
+// GetCommitObjects mocks base method.
+func (m *MockRepository) GetCommitObjects(hashes []plumbing.Hash) ([]*object.Commit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommitObjects", hashes)
+	ret0, _ := ret[0].([]*object.Commit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommitObjects indicates an expected call of GetCommitObjects.
+func (mr *MockRepositoryMockRecorder) GetCommitObjects(hashes any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitObjects", reflect.TypeOf((*MockRepository)(nil).GetCommitObjects), hashes)
+}
+
 // GetCommitSetForTag mocks base method.
-func (m *MockRepository) GetCommitSetForTag(ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error) {
+func (m *MockRepository) GetCommitSetForTag(ctx context.Context, ref *plumbing.Reference) (map[plumbing.Hash]struct{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommitSetForTag", ref)
+	ret := m.ctrl.Call(m, "GetCommitSetForTag", ctx, ref)
 	ret0, _ := ret[0].(map[plumbing.Hash]struct{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCommitSetForTag indicates an expected call of GetCommitSetForTag.
-func (mr *MockRepositoryMockRecorder) GetCommitSetForTag(ref any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCommitSetForTag(ctx, ref any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitSetForTag", reflect.TypeOf((*MockRepository)(nil).GetCommitSetForTag), ref)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitSetForTag", reflect.TypeOf((*MockRepository)(nil).GetCommitSetForTag), ctx, ref)
 }
 
 // GetCommitSetForTagFilteredByDirectory mocks base method.
-func (m *MockRepository) GetCommitSetForTagFilteredByDirectory(ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error) {
+func (m *MockRepository) GetCommitSetForTagFilteredByDirectory(ctx context.Context, ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommitSetForTagFilteredByDirectory", ref, directory)
+	ret := m.ctrl.Call(m, "GetCommitSetForTagFilteredByDirectory", ctx, ref, directory)
 	ret0, _ := ret[0].(map[plumbing.Hash]struct{})
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCommitSetForTagFilteredByDirectory indicates an expected call of GetCommitSetForTagFilteredByDirectory.
-func (mr *MockRepositoryMockRecorder) GetCommitSetForTagFilteredByDirectory(ref, directory any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetCommitSetForTagFilteredByDirectory(ctx, ref, directory any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitSetForTagFilteredByDirectory", reflect.TypeOf((*MockRepository)(nil).GetCommitSetForTagFilteredByDirectory), ctx, ref, directory)
+}
+
+// GetCommitSetForTagFilteredByPathspecs mocks base method.
+func (m *MockRepository) GetCommitSetForTagFilteredByPathspecs(ctx context.Context, ref *plumbing.Reference, pathspecs []string) (map[plumbing.Hash]struct{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommitSetForTagFilteredByPathspecs", ctx, ref, pathspecs)
+	ret0, _ := ret[0].(map[plumbing.Hash]struct{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommitSetForTagFilteredByPathspecs indicates an expected call of GetCommitSetForTagFilteredByPathspecs.
+func (mr *MockRepositoryMockRecorder) GetCommitSetForTagFilteredByPathspecs(ctx, ref, pathspecs any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitSetForTagFilteredByDirectory", reflect.TypeOf((*MockRepository)(nil).GetCommitSetForTagFilteredByDirectory), ref, directory)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommitSetForTagFilteredByPathspecs", reflect.TypeOf((*MockRepository)(nil).GetCommitSetForTagFilteredByPathspecs), ctx, ref, pathspecs)
 }
 
 // GetDiffBetweenTags mocks base method.
-func (m *MockRepository) GetDiffBetweenTags(tag1, tag2 *plumbing.Reference, directory string) (string, error) {
+func (m *MockRepository) GetDiffBetweenTags(ctx context.Context, tag1, tag2 *plumbing.Reference, pathspecs []string, diffArgs ...string) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetDiffBetweenTags", tag1, tag2, directory)
+	varargs := []any{ctx, tag1, tag2, pathspecs}
+	for _, a := range diffArgs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetDiffBetweenTags", varargs...)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetDiffBetweenTags indicates an expected call of GetDiffBetweenTags.
-func (mr *MockRepositoryMockRecorder) GetDiffBetweenTags(tag1, tag2, directory any) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) GetDiffBetweenTags(ctx, tag1, tag2, pathspecs any, diffArgs ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, tag1, tag2, pathspecs}, diffArgs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiffBetweenTags", reflect.TypeOf((*MockRepository)(nil).GetDiffBetweenTags), varargs...)
+}
+
+// GetExcludePatterns mocks base method.
+func (m *MockRepository) GetExcludePatterns(ctx context.Context) ([]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetExcludePatterns", ctx)
+	ret0, _ := ret[0].([]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetExcludePatterns indicates an expected call of GetExcludePatterns.
+func (mr *MockRepositoryMockRecorder) GetExcludePatterns(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetExcludePatterns", reflect.TypeOf((*MockRepository)(nil).GetExcludePatterns), ctx)
+}
+
+// GetFileCommits mocks base method.
+func (m *MockRepository) GetFileCommits(ctx context.Context, tag1, tag2 *plumbing.Reference, pathspecs []string) (map[string][]plumbing.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileCommits", ctx, tag1, tag2, pathspecs)
+	ret0, _ := ret[0].(map[string][]plumbing.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileCommits indicates an expected call of GetFileCommits.
+func (mr *MockRepositoryMockRecorder) GetFileCommits(ctx, tag1, tag2, pathspecs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileCommits", reflect.TypeOf((*MockRepository)(nil).GetFileCommits), ctx, tag1, tag2, pathspecs)
+}
+
+// GetFileHashes mocks base method.
+func (m *MockRepository) GetFileHashes(ctx context.Context, ref *plumbing.Reference) (map[string]plumbing.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileHashes", ctx, ref)
+	ret0, _ := ret[0].(map[string]plumbing.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileHashes indicates an expected call of GetFileHashes.
+func (mr *MockRepositoryMockRecorder) GetFileHashes(ctx, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileHashes", reflect.TypeOf((*MockRepository)(nil).GetFileHashes), ctx, ref)
+}
+
+// GetFileSizes mocks base method.
+func (m *MockRepository) GetFileSizes(ctx context.Context, ref *plumbing.Reference) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFileSizes", ctx, ref)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFileSizes indicates an expected call of GetFileSizes.
+func (mr *MockRepositoryMockRecorder) GetFileSizes(ctx, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFileSizes", reflect.TypeOf((*MockRepository)(nil).GetFileSizes), ctx, ref)
+}
+
+// GetFirstParentCommitSet mocks base method.
+func (m *MockRepository) GetFirstParentCommitSet(ctx context.Context, ref *plumbing.Reference, directory string) (map[plumbing.Hash]struct{}, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetFirstParentCommitSet", ctx, ref, directory)
+	ret0, _ := ret[0].(map[plumbing.Hash]struct{})
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetFirstParentCommitSet indicates an expected call of GetFirstParentCommitSet.
+func (mr *MockRepositoryMockRecorder) GetFirstParentCommitSet(ctx, ref, directory any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFirstParentCommitSet", reflect.TypeOf((*MockRepository)(nil).GetFirstParentCommitSet), ctx, ref, directory)
+}
+
+// GetIntroducingCommits mocks base method.
+func (m *MockRepository) GetIntroducingCommits(ctx context.Context, ref *plumbing.Reference) (map[plumbing.Hash]plumbing.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetIntroducingCommits", ctx, ref)
+	ret0, _ := ret[0].(map[plumbing.Hash]plumbing.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetIntroducingCommits indicates an expected call of GetIntroducingCommits.
+func (mr *MockRepositoryMockRecorder) GetIntroducingCommits(ctx, ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetIntroducingCommits", reflect.TypeOf((*MockRepository)(nil).GetIntroducingCommits), ctx, ref)
+}
+
+// GetLimitedDiffBetweenTags mocks base method.
+func (m *MockRepository) GetLimitedDiffBetweenTags(ctx context.Context, tag1, tag2 *plumbing.Reference, pathspecs []string, limit int64, diffArgs ...string) (string, bool, error) {
+	m.ctrl.T.Helper()
+	varargs := []any{ctx, tag1, tag2, pathspecs, limit}
+	for _, a := range diffArgs {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "GetLimitedDiffBetweenTags", varargs...)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetLimitedDiffBetweenTags indicates an expected call of GetLimitedDiffBetweenTags.
+func (mr *MockRepositoryMockRecorder) GetLimitedDiffBetweenTags(ctx, tag1, tag2, pathspecs, limit any, diffArgs ...any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]any{ctx, tag1, tag2, pathspecs, limit}, diffArgs...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLimitedDiffBetweenTags", reflect.TypeOf((*MockRepository)(nil).GetLimitedDiffBetweenTags), varargs...)
+}
+
+// GetLineCount mocks base method.
+func (m *MockRepository) GetLineCount(ctx context.Context, ref *plumbing.Reference, pathspecs []string) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetLineCount", ctx, ref, pathspecs)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetLineCount indicates an expected call of GetLineCount.
+func (mr *MockRepositoryMockRecorder) GetLineCount(ctx, ref, pathspecs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetLineCount", reflect.TypeOf((*MockRepository)(nil).GetLineCount), ctx, ref, pathspecs)
+}
+
+// GetPatchID mocks base method.
+func (m *MockRepository) GetPatchID(ctx context.Context, patch string) (string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatchID", ctx, patch)
+	ret0, _ := ret[0].(string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPatchID indicates an expected call of GetPatchID.
+func (mr *MockRepositoryMockRecorder) GetPatchID(ctx, patch any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatchID", reflect.TypeOf((*MockRepository)(nil).GetPatchID), ctx, patch)
+}
+
+// GetPatchIDs mocks base method.
+func (m *MockRepository) GetPatchIDs(ctx context.Context, ref, base *plumbing.Reference) (map[string]plumbing.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPatchIDs", ctx, ref, base)
+	ret0, _ := ret[0].(map[string]plumbing.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPatchIDs indicates an expected call of GetPatchIDs.
+func (mr *MockRepositoryMockRecorder) GetPatchIDs(ctx, ref, base any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPatchIDs", reflect.TypeOf((*MockRepository)(nil).GetPatchIDs), ctx, ref, base)
+}
+
+// GetRemoteURLs mocks base method.
+func (m *MockRepository) GetRemoteURLs() (map[string]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRemoteURLs")
+	ret0, _ := ret[0].(map[string]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRemoteURLs indicates an expected call of GetRemoteURLs.
+func (mr *MockRepositoryMockRecorder) GetRemoteURLs() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRemoteURLs", reflect.TypeOf((*MockRepository)(nil).GetRemoteURLs))
+}
+
+// GetRootCommits mocks base method.
+func (m *MockRepository) GetRootCommits(ctx context.Context) ([]plumbing.Hash, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRootCommits", ctx)
+	ret0, _ := ret[0].([]plumbing.Hash)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRootCommits indicates an expected call of GetRootCommits.
+func (mr *MockRepositoryMockRecorder) GetRootCommits(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRootCommits", reflect.TypeOf((*MockRepository)(nil).GetRootCommits), ctx)
+}
+
+// GetTagCommit mocks base method.
+func (m *MockRepository) GetTagCommit(ref *plumbing.Reference) (*object.Commit, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagCommit", ref)
+	ret0, _ := ret[0].(*object.Commit)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagCommit indicates an expected call of GetTagCommit.
+func (mr *MockRepositoryMockRecorder) GetTagCommit(ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagCommit", reflect.TypeOf((*MockRepository)(nil).GetTagCommit), ref)
+}
+
+// GetTagObject mocks base method.
+func (m *MockRepository) GetTagObject(ref *plumbing.Reference) (*object.Tag, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTagObject", ref)
+	ret0, _ := ret[0].(*object.Tag)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTagObject indicates an expected call of GetTagObject.
+func (mr *MockRepositoryMockRecorder) GetTagObject(ref any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTagObject", reflect.TypeOf((*MockRepository)(nil).GetTagObject), ref)
+}
+
+// HasDirectory mocks base method.
+func (m *MockRepository) HasDirectory(ref *plumbing.Reference, directory string) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasDirectory", ref, directory)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasDirectory indicates an expected call of HasDirectory.
+func (mr *MockRepositoryMockRecorder) HasDirectory(ref, directory any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasDirectory", reflect.TypeOf((*MockRepository)(nil).HasDirectory), ref, directory)
+}
+
+// IsAncestor mocks base method.
+func (m *MockRepository) IsAncestor(ctx context.Context, ancestor, descendant plumbing.Hash) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsAncestor", ctx, ancestor, descendant)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsAncestor indicates an expected call of IsAncestor.
+func (mr *MockRepositoryMockRecorder) IsAncestor(ctx, ancestor, descendant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsAncestor", reflect.TypeOf((*MockRepository)(nil).IsAncestor), ctx, ancestor, descendant)
+}
+
+// IsFirstParentAncestor mocks base method.
+func (m *MockRepository) IsFirstParentAncestor(ctx context.Context, ancestor, descendant plumbing.Hash) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFirstParentAncestor", ctx, ancestor, descendant)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IsFirstParentAncestor indicates an expected call of IsFirstParentAncestor.
+func (mr *MockRepositoryMockRecorder) IsFirstParentAncestor(ctx, ancestor, descendant any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFirstParentAncestor", reflect.TypeOf((*MockRepository)(nil).IsFirstParentAncestor), ctx, ancestor, descendant)
+}
+
+// ResolveRevision mocks base method.
+func (m *MockRepository) ResolveRevision(ctx context.Context, revision string) (*plumbing.Reference, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ResolveRevision", ctx, revision)
+	ret0, _ := ret[0].(*plumbing.Reference)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ResolveRevision indicates an expected call of ResolveRevision.
+func (mr *MockRepositoryMockRecorder) ResolveRevision(ctx, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResolveRevision", reflect.TypeOf((*MockRepository)(nil).ResolveRevision), ctx, revision)
+}
+
+// StreamCommitFiles mocks base method.
+func (m *MockRepository) StreamCommitFiles(ctx context.Context, hashes []plumbing.Hash, visit func(*object.Commit, []string) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamCommitFiles", ctx, hashes, visit)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamCommitFiles indicates an expected call of StreamCommitFiles.
+func (mr *MockRepositoryMockRecorder) StreamCommitFiles(ctx, hashes, visit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamCommitFiles", reflect.TypeOf((*MockRepository)(nil).StreamCommitFiles), ctx, hashes, visit)
+}
+
+// UnreadableCommits mocks base method.
+func (m *MockRepository) UnreadableCommits() []plumbing.Hash {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UnreadableCommits")
+	ret0, _ := ret[0].([]plumbing.Hash)
+	return ret0
+}
+
+// UnreadableCommits indicates an expected call of UnreadableCommits.
+func (mr *MockRepositoryMockRecorder) UnreadableCommits() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UnreadableCommits", reflect.TypeOf((*MockRepository)(nil).UnreadableCommits))
+}
+
+// WriteArchive mocks base method.
+func (m *MockRepository) WriteArchive(ctx context.Context, ref *plumbing.Reference, format, prefix string, w io.Writer) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteArchive", ctx, ref, format, prefix, w)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteArchive indicates an expected call of WriteArchive.
+func (mr *MockRepositoryMockRecorder) WriteArchive(ctx, ref, format, prefix, w any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDiffBetweenTags", reflect.TypeOf((*MockRepository)(nil).GetDiffBetweenTags), tag1, tag2, directory)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteArchive", reflect.TypeOf((*MockRepository)(nil).WriteArchive), ctx, ref, format, prefix, w)
 }
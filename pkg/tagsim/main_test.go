@@ -0,0 +1,21 @@
+package tagsim_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/pkg/tagsim"
+)
+
+// TestMain keeps the commit set cache of the tests out of the user cache directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "git-tag-similarity-cache-")
+	if err != nil {
+		panic(err)
+	}
+	_ = os.Setenv(tagsim.CacheDirEnv, dir)
+
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,311 @@
+// Package tagsim compares Git tags by their commit history, for Go programs that embed the
+// analysis of the git-tag-similarity command instead of running it.
+//
+// The similarity of two tags is the Jaccard index of the commits reachable from each:
+// shared commits divided by the commits reachable from either tag.
+//
+//	result, err := tagsim.Compare(tagsim.CompareConfig{
+//		TagOptions: tagsim.TagOptions{RepoPath: "/path/to/repo", Tag1Name: "v1.0.0", Tag2Name: "v2.0.0"},
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	fmt.Printf("%.2f%% similar, %d commits only in v2.0.0\n", result.Similarity*100, len(result.OnlyInTag2))
+//
+// The package wraps the implementation of the command, so results match the command line.
+package tagsim
+
+import (
+	"context"
+	"iter"
+	"time"
+
+	"github.com/byron1st/git-tag-similarity/internal"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+type (
+	// Repository abstracts the Git operations of a comparison, e.g. for mocks in tests
+	Repository = internal.Repository
+	// GitRepository is the Repository of a repository on disk, read with go-git and the git binary
+	GitRepository = internal.GitRepository
+
+	// TagOptions names the repository and the two tags, branches, or commits to compare
+	TagOptions = internal.TagOptions
+	// SavedResult is the JSON form of a CompareResult written by the compare -json flag
+	SavedResult = internal.SavedResult
+	// CommitInfo is the author, date, and subject of a commit in a SavedResult
+	CommitInfo = internal.CommitInfo
+
+	// CommitMatch selects when a commit of one tag counts as shared with the other tag
+	CommitMatch = internal.CommitMatch
+	// SimilarityMetric selects the similarity computed in addition to the commit similarity
+	SimilarityMetric = internal.SimilarityMetric
+	// PathProfile restricts a comparison to the paths of a predefined profile
+	PathProfile = internal.PathProfile
+	// SortStrategy orders tags to resolve latest and latest-N
+	SortStrategy = internal.SortStrategy
+
+	// DirectoryFilter restricts a comparison to directories or glob patterns, minus exclusions
+	DirectoryFilter = internal.DirectoryFilter
+	// BaselineExclusion leaves out the commits reachable from refs, e.g. an earlier release
+	BaselineExclusion = internal.BaselineExclusion
+	// AuthorFilter only counts the commits of matching authors or committers
+	AuthorFilter = internal.AuthorFilter
+	// DateRange only counts the commits committed within a range
+	DateRange = internal.DateRange
+	// MergeMode selects whether merge commits are counted
+	MergeMode = internal.MergeMode
+	// TestPatterns identify test files for the test-to-code change ratio
+	TestPatterns = internal.TestPatterns
+	// RiskSignal is a property of the change between two tags that contributes to its risk score
+	RiskSignal = internal.RiskSignal
+	// RiskWeights weigh the risk signals of the risk score
+	RiskWeights = internal.RiskWeights
+	// ProgressReporter receives progress events of a comparison
+	ProgressReporter = internal.ProgressReporter
+	// Metadata holds key/value annotations saved with a result, e.g. CI build IDs
+	Metadata = internal.Metadata
+
+	// FileSimilarity is the shared and unique commit counts of a file or directory, as in the file breakdown
+	FileSimilarity = internal.FileSimilarity
+	// TestChangeStats summarizes the test and code file changes of the commits unique to a tag
+	TestChangeStats = internal.TestChangeStats
+	// RiskScore is the risk of the change between two tags with the factors it is made of
+	RiskScore = internal.RiskScore
+	// RiskFactor is the contribution of one risk signal to a RiskScore
+	RiskFactor = internal.RiskFactor
+	// PolicyResult is the outcome of evaluating a comparison against a policy of the project config
+	PolicyResult = internal.PolicyResult
+	// PolicyCheck is the outcome of one rule of a policy
+	PolicyCheck = internal.PolicyCheck
+	// RewriteAnalysis records indicators of rewritten history between diverged tags
+	RewriteAnalysis = internal.RewriteAnalysis
+	// ArchiveVerification records whether a published source archive matches its tag
+	ArchiveVerification = internal.ArchiveVerification
+	// Timings records how long each phase of a comparison took
+	Timings = internal.Timings
+	// PhaseTiming is how long one phase of a comparison took
+	PhaseTiming = internal.PhaseTiming
+	// TagBranch is the branch a tag was most likely cut from, with the reason it was chosen
+	TagBranch = internal.TagBranch
+	// TagAudit records who created a tag, when, and from which commit
+	TagAudit = internal.TagAudit
+	// RepoFingerprint identifies the repository of a SavedResult by its remote and root commit
+	RepoFingerprint = internal.RepoFingerprint
+	// LargeFileChange is a large or binary file left out of the diff stat of a SavedResult
+	LargeFileChange = internal.LargeFileChange
+)
+
+// compareResult is the result of the compare command, whose fields CompareResult promotes
+type compareResult = internal.CompareResult
+
+// CompareResult holds the similarity and the shared and unique commits of a comparison. Its fields,
+// such as Similarity, OnlyInTag2, and Warnings, are those of the compare command's result; Config is
+// the configuration the comparison ran with.
+type CompareResult struct {
+	compareResult
+	Config CompareConfig // The configuration, with latest and latest-N resolved and project defaults applied
+}
+
+// newCompareResult wraps a result of the compare command
+func newCompareResult(result internal.CompareResult) CompareResult {
+	return CompareResult{compareResult: result, Config: newCompareConfig(result.Config)}
+}
+
+// CompareConfig configures a comparison. The fields are the analysis options of the compare command;
+// options that only concern the command line, such as output formats, result files, attestations, and
+// metric pushes, are left out.
+type CompareConfig struct {
+	TagOptions
+	Directory   string            // Single directory to filter commits by
+	Paths       DirectoryFilter   // Several directories, globs, or exclusions, in addition to Directory
+	FirstParent bool              // Count each merged side branch as a single change by following first parents only
+	Baseline    BaselineExclusion // Leave out the commits reachable from these refs; empty leaves out none
+	Authors     AuthorFilter      // Only count commits of these authors or committers; zero counts every commit
+	Dates       DateRange         // Only count commits committed within this range; zero counts every commit
+	Merges      MergeMode         // Whether merge commits are counted; empty counts them
+	Match       CommitMatch       // When a commit counts as shared; empty matches by hash
+	Metric      SimilarityMetric  // Similarity computed in addition to the commit similarity; empty computes none
+	Profile     PathProfile
+
+	Depth         int          // Aggregate a per-path breakdown at this directory depth; 0 computes none
+	TestRatio     bool         // Compute the test-to-code change ratio of the unique commits
+	TestFiles     TestPatterns // Patterns identifying test files for TestRatio; nil selects the defaults
+	Risk          bool         // Score the risk of the change between the tags
+	RiskWeights   RiskWeights  // Weights of the risk signals for Risk; nil selects the defaults
+	LargeFileSize int64        // Files at least this large are left out of the diff stat of SaveResult; 0 only leaves out binary files
+	PolicyName    string       // Evaluate the comparison against this policy of the project config
+	ConfigPath    string       // Project config file; empty selects the one in the repository root
+	Metadata      Metadata     // Annotations saved with the result
+
+	Progress ProgressReporter // Receives progress events of the comparison; nil reports none
+	Strict   bool             // Fail on missing or corrupt objects instead of skipping them
+	SetsOnly bool             // Only compute the commit sets and similarity, skipping tag metadata and the rewrite check
+	Timeout  time.Duration    // Stop with ErrCanceled after this long; 0 never times out
+
+	// Cache reads and stores commit sets in the user cache directory, as the command does by default,
+	// so later comparisons in any process skip the traversal. Set CacheDirEnv to move the cache.
+	Cache bool
+}
+
+// newCompareConfig converts the configuration of the compare command, leaving out the command line options
+func newCompareConfig(c internal.CompareConfig) CompareConfig {
+	return CompareConfig{
+		TagOptions:    c.TagOptions,
+		Directory:     c.Directory,
+		Paths:         c.Paths,
+		FirstParent:   c.FirstParent,
+		Baseline:      c.Baseline,
+		Authors:       c.Authors,
+		Dates:         c.Dates,
+		Merges:        c.Merges,
+		Match:         c.Match,
+		Metric:        c.Metric,
+		Profile:       c.Profile,
+		Depth:         c.Depth,
+		TestRatio:     c.TestRatio,
+		TestFiles:     c.TestFiles,
+		Risk:          c.Risk,
+		RiskWeights:   c.RiskWeights,
+		LargeFileSize: c.LargeFileSize,
+		PolicyName:    c.PolicyName,
+		ConfigPath:    c.ConfigPath,
+		Metadata:      c.Metadata,
+		Progress:      c.Progress,
+		Strict:        c.Strict,
+		SetsOnly:      c.SetsOnly,
+		Timeout:       c.Timeout,
+		Cache:         !c.NoCache,
+	}
+}
+
+// compareConfig converts the configuration into the one of the compare command
+func (c CompareConfig) compareConfig() internal.CompareConfig {
+	return internal.CompareConfig{
+		TagOptions:    c.TagOptions,
+		Directory:     c.Directory,
+		Paths:         c.Paths,
+		FirstParent:   c.FirstParent,
+		Baseline:      c.Baseline,
+		Authors:       c.Authors,
+		Dates:         c.Dates,
+		Merges:        c.Merges,
+		Match:         c.Match,
+		Metric:        c.Metric,
+		Profile:       c.Profile,
+		Depth:         c.Depth,
+		TestRatio:     c.TestRatio,
+		TestFiles:     c.TestFiles,
+		Risk:          c.Risk,
+		RiskWeights:   c.RiskWeights,
+		LargeFileSize: c.LargeFileSize,
+		PolicyName:    c.PolicyName,
+		ConfigPath:    c.ConfigPath,
+		Metadata:      c.Metadata,
+		Progress:      c.Progress,
+		Strict:        c.Strict,
+		SetsOnly:      c.SetsOnly,
+		Timeout:       c.Timeout,
+		NoCache:       !c.Cache,
+	}
+}
+
+const (
+	MatchHash    = internal.MatchHash
+	MatchPatchID = internal.MatchPatchID
+
+	MetricCommits = internal.MetricCommits
+	MetricMessage = internal.MetricMessage
+	MetricFiles   = internal.MetricFiles
+	MetricLines   = internal.MetricLines
+
+	ProfileNone   = internal.ProfileNone
+	ProfileDocker = internal.ProfileDocker
+
+	SortBySemver = internal.SortBySemver
+	SortByDate   = internal.SortByDate
+
+	MergesInclude = internal.MergesInclude
+	MergesExclude = internal.MergesExclude
+	MergesOnly    = internal.MergesOnly
+
+	RiskChurn        = internal.RiskChurn
+	RiskHotspots     = internal.RiskHotspots
+	RiskBreaking     = internal.RiskBreaking
+	RiskUnsigned     = internal.RiskUnsigned
+	RiskDependencies = internal.RiskDependencies
+)
+
+// CacheDirEnv names the environment variable that moves the commit set cache out of the user cache directory
+const CacheDirEnv = internal.CacheDirEnv
+
+var (
+	ErrInvalidConfiguration = internal.ErrInvalidConfiguration
+	ErrOpenRepository       = internal.ErrOpenRepository
+	ErrTag1NotFound         = internal.ErrTag1NotFound
+	ErrTag2NotFound         = internal.ErrTag2NotFound
+	ErrGetCommits           = internal.ErrGetCommits
+	ErrCanceled             = internal.ErrCanceled
+)
+
+// Compare compares the two tags of config and returns the similarity and the commits shared by
+// and unique to each tag. Nothing is printed; use the result or save it with SaveResult.
+func Compare(config CompareConfig) (CompareResult, error) {
+	result, err := internal.Compare(config.compareConfig())
+	return newCompareResult(result), err
+}
+
+// CompareContext is Compare, stopping with ErrCanceled when ctx is done
+func CompareContext(ctx context.Context, config CompareConfig) (CompareResult, error) {
+	result, err := internal.CompareContext(ctx, config.compareConfig())
+	return newCompareResult(result), err
+}
+
+// OpenRepository opens the repository at path, which may be a working tree, a bare repository,
+// a linked worktree, or the URL of a remote repository to clone into the user cache directory.
+// The repository is safe for concurrent use.
+func OpenRepository(path string) (*GitRepository, error) {
+	return internal.NewGitRepository(path)
+}
+
+// CalculateJaccardSimilarity computes the Jaccard similarity of two commit sets, such as those
+// returned by Repository.GetCommitSetForTag, between 0.0 and 1.0, where 1.0 means identical sets
+func CalculateJaccardSimilarity(setA map[plumbing.Hash]struct{}, setB map[plumbing.Hash]struct{}) float64 {
+	return internal.CalculateJaccardSimilarity(setA, setB)
+}
+
+// SortedCommitHashes streams the commits reachable from a tag into a sorted slice, which is smaller to
+// hold than the commit set of Repository.GetCommitSetForTag. The traversal still tracks the commits it
+// visits, so the peak memory of a call grows with the history either way. Repository.ForEachCommitInTag
+// visits the commits without collecting them.
+func SortedCommitHashes(ctx context.Context, repo Repository, ref *plumbing.Reference) ([]plumbing.Hash, error) {
+	return internal.SortedCommitHashes(ctx, repo, ref)
+}
+
+// CalculateSortedJaccardSimilarity computes the Jaccard similarity of two ascending sequences of
+// distinct commit hashes, e.g. slices.Values of SortedCommitHashes, without building their union
+func CalculateSortedJaccardSimilarity(seqA iter.Seq[plumbing.Hash], seqB iter.Seq[plumbing.Hash]) float64 {
+	return internal.CalculateSortedJaccardSimilarity(seqA, seqB)
+}
+
+// MergeSortedHashes merges ascending sequences of distinct hashes, yielding each hash once in
+// ascending order with the number of sequences that contain it
+func MergeSortedHashes(seqs ...iter.Seq[plumbing.Hash]) iter.Seq2[plumbing.Hash, int] {
+	return internal.MergeSortedHashes(seqs...)
+}
+
+// NewSavedResult converts a result into its JSON form, loading commit details and the diff stat
+func NewSavedResult(ctx context.Context, result CompareResult) (SavedResult, error) {
+	return internal.NewSavedResult(ctx, result.compareResult)
+}
+
+// SaveResult writes the JSON form of a result to path, as the compare -json flag does
+func SaveResult(ctx context.Context, result CompareResult, path string) error {
+	return internal.SaveResult(ctx, result.compareResult, path)
+}
+
+// LoadSavedResult reads a result written by SaveResult or the compare -json flag
+func LoadSavedResult(path string) (SavedResult, error) {
+	return internal.LoadSavedResult(path)
+}
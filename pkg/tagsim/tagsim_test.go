@@ -0,0 +1,151 @@
+package tagsim_test
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/byron1st/git-tag-similarity/pkg/tagsim"
+	"github.com/byron1st/git-tag-similarity/testutil"
+)
+
+// TestCompare tests comparing tags through the public API
+func TestCompare(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Commit("Add b", testutil.File("b.go", "package a\n")).
+		Tag("v1.1.0")
+
+	result, err := tagsim.Compare(tagsim.CompareConfig{
+		TagOptions: tagsim.TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+	})
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if result.Similarity != 0.5 || len(result.SharedCommits) != 1 || len(result.OnlyInTag2) != 1 {
+		t.Errorf("Compare() similarity = %v, shared = %d, only in tag2 = %d, want 0.5, 1, 1",
+			result.Similarity, len(result.SharedCommits), len(result.OnlyInTag2))
+	}
+
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := tagsim.SaveResult(context.Background(), result, path); err != nil {
+		t.Fatalf("SaveResult() error = %v", err)
+	}
+	saved, err := tagsim.LoadSavedResult(path)
+	if err != nil {
+		t.Fatalf("LoadSavedResult() error = %v", err)
+	}
+	if saved.Similarity != result.Similarity {
+		t.Errorf("saved similarity = %v, want %v", saved.Similarity, result.Similarity)
+	}
+
+	_, err = tagsim.Compare(tagsim.CompareConfig{
+		TagOptions: tagsim.TagOptions{RepoPath: fixture.Path(), Tag1Name: "v9.9.9", Tag2Name: "v1.1.0"},
+	})
+	if !errors.Is(err, tagsim.ErrTag1NotFound) {
+		t.Errorf("Compare() error = %v, want ErrTag1NotFound", err)
+	}
+}
+
+// TestCompare_Options tests that the filters can be configured with the package's own types, and that
+// the commit set cache is only used when requested
+func TestCompare_Options(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Author("Bob", "bob@example.com").
+		Commit("Add docs", testutil.File("docs/guide.md", "# Guide\n")).
+		Tag("v1.1.0")
+	cacheDir := t.TempDir()
+	t.Setenv(tagsim.CacheDirEnv, cacheDir)
+
+	config := tagsim.CompareConfig{
+		TagOptions:  tagsim.TagOptions{RepoPath: fixture.Path(), Tag1Name: "v1.0.0", Tag2Name: "v1.1.0"},
+		Paths:       tagsim.DirectoryFilter{Exclude: []string{"docs"}},
+		Authors:     tagsim.AuthorFilter{Authors: []string{"bob@example.com"}},
+		Merges:      tagsim.MergesExclude,
+		Risk:        true,
+		RiskWeights: tagsim.RiskWeights{tagsim.RiskUnsigned: 0},
+	}
+	result, err := tagsim.Compare(config)
+	if err != nil {
+		t.Fatalf("Compare() error = %v", err)
+	}
+	if len(result.OnlyInTag2) != 0 || result.Risk == nil {
+		t.Errorf("Compare() only in tag2 = %d, risk = %v, want the docs commit filtered out and a risk score", len(result.OnlyInTag2), result.Risk)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) != 0 {
+		t.Errorf("Compare() without Cache wrote %d entries to the cache", len(entries))
+	}
+	var returned tagsim.CompareConfig = result.Config
+	if returned.RepoPath != fixture.Path() || returned.Merges != tagsim.MergesExclude || !returned.Risk || returned.Cache {
+		t.Errorf("result Config = %+v, want the configuration of the comparison", returned)
+	}
+	var risk *tagsim.RiskScore = result.Risk
+	if factors := riskSignals(risk.Factors); !slices.Contains(factors, tagsim.RiskUnsigned) {
+		t.Errorf("risk signals = %v, want %s", factors, tagsim.RiskUnsigned)
+	}
+
+	// Only full histories are cached, so the second run has no directory filter
+	config = tagsim.CompareConfig{TagOptions: config.TagOptions, Cache: true}
+	if _, err := tagsim.Compare(config); err != nil {
+		t.Fatalf("Compare() with Cache error = %v", err)
+	}
+	if entries, _ := os.ReadDir(cacheDir); len(entries) == 0 {
+		t.Error("Compare() with Cache wrote nothing to the cache")
+	}
+}
+
+// riskSignals returns the signals of risk factors; it names the factor type as callers of the package do
+func riskSignals(factors []tagsim.RiskFactor) []tagsim.RiskSignal {
+	signals := make([]tagsim.RiskSignal, 0, len(factors))
+	for _, factor := range factors {
+		signals = append(signals, factor.Signal)
+	}
+	return signals
+}
+
+// TestCalculateJaccardSimilarity tests computing the similarity from the commit sets of a Repository
+func TestCalculateJaccardSimilarity(t *testing.T) {
+	fixture := testutil.NewRepo(t).
+		Commit("Initial", testutil.File("a.go", "package a\n")).
+		Tag("v1.0.0").
+		Commit("Add b", testutil.File("b.go", "package a\n")).
+		Commit("Add c", testutil.File("c.go", "package a\n")).
+		Tag("v1.1.0")
+
+	var repo tagsim.Repository
+	repo, err := tagsim.OpenRepository(fixture.Path())
+	if err != nil {
+		t.Fatalf("OpenRepository() error = %v", err)
+	}
+
+	commits1, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.0.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag(v1.0.0) error = %v", err)
+	}
+	commits2, err := repo.GetCommitSetForTag(context.Background(), fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("GetCommitSetForTag(v1.1.0) error = %v", err)
+	}
+
+	if got := tagsim.CalculateJaccardSimilarity(commits1, commits2); got != 1.0/3.0 {
+		t.Errorf("CalculateJaccardSimilarity() = %v, want 1/3", got)
+	}
+
+	sorted1, err := tagsim.SortedCommitHashes(context.Background(), repo, fixture.Reference("v1.0.0"))
+	if err != nil {
+		t.Fatalf("SortedCommitHashes(v1.0.0) error = %v", err)
+	}
+	sorted2, err := tagsim.SortedCommitHashes(context.Background(), repo, fixture.Reference("v1.1.0"))
+	if err != nil {
+		t.Fatalf("SortedCommitHashes(v1.1.0) error = %v", err)
+	}
+	if got := tagsim.CalculateSortedJaccardSimilarity(slices.Values(sorted1), slices.Values(sorted2)); got != 1.0/3.0 {
+		t.Errorf("CalculateSortedJaccardSimilarity() = %v, want 1/3", got)
+	}
+}
@@ -0,0 +1,341 @@
+// Package testutil builds temporary Git repositories for integration tests.
+//
+// A RepoBuilder records commits, branches, merges, and tags through go-git, so tests
+// do not depend on a git binary or the user's git configuration:
+//
+//	repo := testutil.NewRepo(t).
+//		Commit("Add API", testutil.File("src/api/a.go", "package api")).
+//		Tag("v1.0.0").
+//		Branch("feature").
+//		Checkout("feature").
+//		Commit("Add b endpoint", testutil.File("src/api/b.go", "package api")).
+//		Checkout("main").
+//		Merge("feature", "Merge feature").
+//		AnnotatedTag("v1.1.0", "Release 1.1.0")
+//
+//	repo.Path()         // directory to open with the library
+//	repo.Hash("v1.1.0") // commit a tag, branch, or HEAD points to
+package testutil
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	formatcfg "github.com/go-git/go-git/v5/plumbing/format/config"
+	"github.com/go-git/go-git/v5/plumbing/hash"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// DefaultBranch is the branch a new repository starts on
+const DefaultBranch = "main"
+
+// baseTime is the author date of the first commit; each later commit is one hour newer
+var baseTime = time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Change modifies the working tree before a commit
+type Change struct {
+	path    string
+	content string
+	remove  bool
+}
+
+// File writes content to path, creating parent directories as needed
+func File(path string, content string) Change {
+	return Change{path: path, content: content}
+}
+
+// Remove deletes path from the working tree
+func Remove(path string) Change {
+	return Change{path: path, remove: true}
+}
+
+// RepoBuilder constructs a repository step by step. Any failure aborts the test.
+type RepoBuilder struct {
+	t        testing.TB
+	path     string
+	repo     *git.Repository
+	author   object.Signature
+	nextTime time.Time
+}
+
+// NewRepo initializes an empty repository in a temporary directory removed when the test ends
+func NewRepo(t testing.TB) *RepoBuilder {
+	t.Helper()
+
+	path := t.TempDir()
+	// Match the object format go-git was built for, so native git reads the repository too
+	objectFormat := formatcfg.SHA1
+	if hash.Size == 32 {
+		objectFormat = formatcfg.SHA256
+	}
+	repo, err := git.PlainInitWithOptions(path, &git.PlainInitOptions{
+		InitOptions:  git.InitOptions{DefaultBranch: plumbing.NewBranchReferenceName(DefaultBranch)},
+		ObjectFormat: objectFormat,
+	})
+	if err != nil {
+		t.Fatalf("testutil: failed to init repository: %v", err)
+	}
+
+	return &RepoBuilder{
+		t:        t,
+		path:     path,
+		repo:     repo,
+		author:   object.Signature{Name: "Test", Email: "test@example.com"},
+		nextTime: baseTime,
+	}
+}
+
+// Path returns the repository's working tree directory
+func (b *RepoBuilder) Path() string {
+	return b.path
+}
+
+// Repository returns the underlying go-git repository
+func (b *RepoBuilder) Repository() *git.Repository {
+	return b.repo
+}
+
+// Author sets the author and committer of subsequent commits and tags
+func (b *RepoBuilder) Author(name string, email string) *RepoBuilder {
+	b.author.Name = name
+	b.author.Email = email
+	return b
+}
+
+// At sets the date of the next commit; later commits continue one hour apart from it
+func (b *RepoBuilder) At(when time.Time) *RepoBuilder {
+	b.nextTime = when
+	return b
+}
+
+// Commit applies changes to the working tree and commits them on the current branch.
+// A commit without changes is allowed.
+func (b *RepoBuilder) Commit(message string, changes ...Change) *RepoBuilder {
+	b.t.Helper()
+	b.commit(message, nil, changes)
+	return b
+}
+
+// Branch creates a branch at HEAD without checking it out
+func (b *RepoBuilder) Branch(name string) *RepoBuilder {
+	b.t.Helper()
+
+	head := b.head()
+	ref := plumbing.NewHashReference(plumbing.NewBranchReferenceName(name), head)
+	if err := b.repo.Storer.SetReference(ref); err != nil {
+		b.t.Fatalf("testutil: failed to create branch %s: %v", name, err)
+	}
+	return b
+}
+
+// Checkout switches the working tree to an existing branch
+func (b *RepoBuilder) Checkout(name string) *RepoBuilder {
+	b.t.Helper()
+
+	worktree := b.worktree()
+	if err := worktree.Checkout(&git.CheckoutOptions{Branch: plumbing.NewBranchReferenceName(name), Force: true}); err != nil {
+		b.t.Fatalf("testutil: failed to check out %s: %v", name, err)
+	}
+	return b
+}
+
+// Orphan switches HEAD to a new branch without history, like 'git checkout --orphan'.
+// The working tree and index are kept, so the next commit is a root commit with the current files.
+func (b *RepoBuilder) Orphan(name string) *RepoBuilder {
+	b.t.Helper()
+
+	head := plumbing.NewSymbolicReference(plumbing.HEAD, plumbing.NewBranchReferenceName(name))
+	if err := b.repo.Storer.SetReference(head); err != nil {
+		b.t.Fatalf("testutil: failed to create orphan branch %s: %v", name, err)
+	}
+	return b
+}
+
+// Merge records a merge commit of branch into the current branch.
+// Files of the merged branch overwrite those of the current branch; conflicts are not detected.
+// Extra changes are applied on top of the merged tree.
+func (b *RepoBuilder) Merge(branch string, message string, changes ...Change) *RepoBuilder {
+	b.t.Helper()
+
+	ref, err := b.repo.Reference(plumbing.NewBranchReferenceName(branch), true)
+	if err != nil {
+		b.t.Fatalf("testutil: failed to resolve branch %s: %v", branch, err)
+	}
+	commit, err := b.repo.CommitObject(ref.Hash())
+	if err != nil {
+		b.t.Fatalf("testutil: failed to read head of %s: %v", branch, err)
+	}
+	files, err := commit.Files()
+	if err != nil {
+		b.t.Fatalf("testutil: failed to list files of %s: %v", branch, err)
+	}
+
+	var merged []Change
+	err = files.ForEach(func(f *object.File) error {
+		content, err := f.Contents()
+		if err != nil {
+			return err
+		}
+		merged = append(merged, File(f.Name, content))
+		return nil
+	})
+	if err != nil {
+		b.t.Fatalf("testutil: failed to read files of %s: %v", branch, err)
+	}
+
+	b.commit(message, []plumbing.Hash{ref.Hash()}, append(merged, changes...))
+	return b
+}
+
+// Tag creates a lightweight tag at HEAD
+func (b *RepoBuilder) Tag(name string) *RepoBuilder {
+	b.t.Helper()
+
+	if _, err := b.repo.CreateTag(name, b.head(), nil); err != nil {
+		b.t.Fatalf("testutil: failed to create tag %s: %v", name, err)
+	}
+	return b
+}
+
+// AnnotatedTag creates an annotated tag object at HEAD
+func (b *RepoBuilder) AnnotatedTag(name string, message string) *RepoBuilder {
+	b.t.Helper()
+
+	tagger := b.author
+	tagger.When = b.nextTime
+	if _, err := b.repo.CreateTag(name, b.head(), &git.CreateTagOptions{Tagger: &tagger, Message: message}); err != nil {
+		b.t.Fatalf("testutil: failed to create tag %s: %v", name, err)
+	}
+	return b
+}
+
+// Remote adds a remote with the given URL, e.g. for tests of remote-tracking behavior
+func (b *RepoBuilder) Remote(name string, url string) *RepoBuilder {
+	b.t.Helper()
+
+	if _, err := b.repo.CreateRemote(&config.RemoteConfig{Name: name, URLs: []string{url}}); err != nil {
+		b.t.Fatalf("testutil: failed to create remote %s: %v", name, err)
+	}
+	return b
+}
+
+// Hash returns the commit a tag, branch, or "HEAD" points to, peeling annotated tags
+func (b *RepoBuilder) Hash(name string) plumbing.Hash {
+	b.t.Helper()
+
+	hash, err := b.repo.ResolveRevision(plumbing.Revision(name))
+	if err != nil {
+		b.t.Fatalf("testutil: failed to resolve %s: %v", name, err)
+	}
+	return *hash
+}
+
+// Reference returns the tag reference for name as FetchAllTags would report it
+func (b *RepoBuilder) Reference(tag string) *plumbing.Reference {
+	b.t.Helper()
+
+	ref, err := b.repo.Tag(tag)
+	if err != nil {
+		b.t.Fatalf("testutil: failed to find tag %s: %v", tag, err)
+	}
+	return ref
+}
+
+// LinkedWorktree adds a linked worktree with HEAD detached at the current HEAD, like 'git worktree add --detach',
+// and returns its directory. Only the administrative files are written; the working tree stays empty.
+func (b *RepoBuilder) LinkedWorktree(name string) string {
+	b.t.Helper()
+
+	path := b.t.TempDir()
+	adminDir := filepath.Join(b.path, ".git", "worktrees", name)
+	files := map[string]string{
+		filepath.Join(adminDir, "HEAD"):      b.head().String() + "\n",
+		filepath.Join(adminDir, "commondir"): "../..\n",
+		filepath.Join(adminDir, "gitdir"):    filepath.Join(path, ".git") + "\n",
+		filepath.Join(path, ".git"):          "gitdir: " + adminDir + "\n",
+	}
+
+	if err := os.MkdirAll(adminDir, 0o755); err != nil {
+		b.t.Fatalf("testutil: failed to create worktree %s: %v", name, err)
+	}
+	for file, content := range files {
+		if err := os.WriteFile(file, []byte(content), 0o644); err != nil {
+			b.t.Fatalf("testutil: failed to write %s: %v", file, err)
+		}
+	}
+	return path
+}
+
+// ObjectPath returns the path of the loose object file of hash, for tests that corrupt the object store
+func (b *RepoBuilder) ObjectPath(hash plumbing.Hash) string {
+	hex := hash.String()
+	return filepath.Join(b.path, ".git", "objects", hex[:2], hex[2:])
+}
+
+// head returns the commit HEAD points to
+func (b *RepoBuilder) head() plumbing.Hash {
+	b.t.Helper()
+
+	ref, err := b.repo.Head()
+	if err != nil {
+		b.t.Fatalf("testutil: failed to resolve HEAD (commit something first): %v", err)
+	}
+	return ref.Hash()
+}
+
+// worktree returns the repository's working tree
+func (b *RepoBuilder) worktree() *git.Worktree {
+	b.t.Helper()
+
+	worktree, err := b.repo.Worktree()
+	if err != nil {
+		b.t.Fatalf("testutil: failed to open worktree: %v", err)
+	}
+	return worktree
+}
+
+// commit applies changes and records a commit with HEAD and extraParents as parents
+func (b *RepoBuilder) commit(message string, extraParents []plumbing.Hash, changes []Change) {
+	b.t.Helper()
+
+	worktree := b.worktree()
+	for _, change := range changes {
+		path := filepath.Join(b.path, filepath.FromSlash(change.path))
+		if change.remove {
+			if _, err := worktree.Remove(change.path); err != nil {
+				b.t.Fatalf("testutil: failed to remove %s: %v", change.path, err)
+			}
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			b.t.Fatalf("testutil: failed to create directory for %s: %v", change.path, err)
+		}
+		if err := os.WriteFile(path, []byte(change.content), 0o644); err != nil {
+			b.t.Fatalf("testutil: failed to write %s: %v", change.path, err)
+		}
+		if _, err := worktree.Add(change.path); err != nil {
+			b.t.Fatalf("testutil: failed to add %s: %v", change.path, err)
+		}
+	}
+
+	signature := b.author
+	signature.When = b.nextTime
+	b.nextTime = b.nextTime.Add(time.Hour)
+
+	options := &git.CommitOptions{Author: &signature, Committer: &signature, AllowEmptyCommits: true}
+	if len(extraParents) > 0 {
+		if head, err := b.repo.Head(); err == nil {
+			options.Parents = append([]plumbing.Hash{head.Hash()}, extraParents...)
+		}
+	}
+
+	if _, err := worktree.Commit(message, options); err != nil {
+		b.t.Fatalf("testutil: failed to commit %q: %v", message, err)
+	}
+}
@@ -0,0 +1,98 @@
+package testutil
+
+import (
+	"testing"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+)
+
+// TestRepoBuilder tests building a repository with branches, a merge, and both kinds of tags
+func TestRepoBuilder(t *testing.T) {
+	repo := NewRepo(t).
+		Author("Alice", "alice@example.com").
+		Commit("Add API", File("src/api/a.go", "package api\n")).
+		Tag("v1.0.0").
+		Branch("feature").
+		Checkout("feature").
+		Commit("Add b endpoint", File("src/api/b.go", "package api\n")).
+		Checkout(DefaultBranch).
+		Commit("Remove API", Remove("src/api/a.go")).
+		Merge("feature", "Merge feature").
+		AnnotatedTag("v1.1.0", "Release 1.1.0")
+
+	merge, err := repo.Repository().CommitObject(repo.Hash("v1.1.0"))
+	if err != nil {
+		t.Fatalf("failed to read merge commit: %v", err)
+	}
+	if merge.NumParents() != 2 || merge.ParentHashes[1] != repo.Hash("feature") {
+		t.Errorf("merge parents = %v, want HEAD and feature", merge.ParentHashes)
+	}
+	if merge.Author.Name != "Alice" {
+		t.Errorf("author = %s, want Alice", merge.Author.Name)
+	}
+
+	// The merged branch's files overwrite the current tree
+	if _, err := merge.File("src/api/b.go"); err != nil {
+		t.Errorf("merged tree is missing src/api/b.go: %v", err)
+	}
+
+	if ref := repo.Reference("v1.0.0"); ref.Hash() != repo.Hash("v1.0.0") {
+		t.Errorf("lightweight tag should point at its commit, got %s", ref.Hash())
+	}
+	if ref := repo.Reference("v1.1.0"); ref.Hash() == repo.Hash("v1.1.0") {
+		t.Errorf("annotated tag reference should point at a tag object, got the commit %s", ref.Hash())
+	}
+
+	previous := plumbing.ZeroHash
+	for _, name := range []string{"v1.0.0", "feature", "HEAD"} {
+		hash := repo.Hash(name)
+		if hash.IsZero() || hash == previous {
+			t.Errorf("Hash(%s) = %s, want a distinct commit", name, hash)
+		}
+		previous = hash
+	}
+}
+
+// TestRepoBuilder_Orphan tests that an orphan branch starts a new root commit
+func TestRepoBuilder_Orphan(t *testing.T) {
+	repo := NewRepo(t).
+		Commit("Initial", File("README.md", "readme\n")).
+		Orphan("imported").
+		Commit("Import", File("src/a.go", "package src\n"))
+
+	commit, err := repo.Repository().CommitObject(repo.Hash("imported"))
+	if err != nil {
+		t.Fatalf("failed to read orphan commit: %v", err)
+	}
+	if commit.NumParents() != 0 {
+		t.Errorf("orphan commit parents = %v, want none", commit.ParentHashes)
+	}
+	if _, err := commit.File("README.md"); err != nil {
+		t.Errorf("orphan commit should keep the index, missing README.md: %v", err)
+	}
+}
+
+// TestRepoBuilder_LinkedWorktree tests that a linked worktree resolves to the main repository's refs
+func TestRepoBuilder_LinkedWorktree(t *testing.T) {
+	repo := NewRepo(t).
+		Commit("Initial", File("README.md", "readme\n")).
+		Tag("v1.0.0")
+
+	path := repo.LinkedWorktree("release")
+
+	worktree, err := git.PlainOpenWithOptions(path, &git.PlainOpenOptions{EnableDotGitCommonDir: true})
+	if err != nil {
+		t.Fatalf("failed to open linked worktree: %v", err)
+	}
+	head, err := worktree.Head()
+	if err != nil {
+		t.Fatalf("failed to resolve worktree HEAD: %v", err)
+	}
+	if head.Hash() != repo.Hash("HEAD") {
+		t.Errorf("worktree HEAD = %s, want %s", head.Hash(), repo.Hash("HEAD"))
+	}
+	if _, err := worktree.Tag("v1.0.0"); err != nil {
+		t.Errorf("worktree should see the tags of the main repository: %v", err)
+	}
+}